@@ -0,0 +1,282 @@
+// Package api embeds openapi.yaml, the OpenAPI 3 description of the HTTP contract, and
+// validates request bodies against its component schemas. Client teams generate SDKs from
+// the document; the server's routing (ServerInterface) and the component types are generated
+// from it by oapi-codegen, and requests are validated against the same file, so the two
+// cannot drift.
+package api
+
+//go:generate go tool oapi-codegen -config oapi-codegen.yaml openapi.yaml
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Document is the OpenAPI document, served by the HTTP server at /openapi.yaml.
+//
+//go:embed openapi.yaml
+var Document []byte
+
+// ValidationError lists every way a document violates its schema.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "schema validation failed: " + strings.Join(e.Problems, "; ")
+}
+
+// Schema is the subset of an OpenAPI schema object the validator understands.
+type Schema struct {
+	Ref                  string             `yaml:"$ref"`
+	Type                 string             `yaml:"type"`
+	Format               string             `yaml:"format"`
+	Description          string             `yaml:"description"`
+	Properties           map[string]*Schema `yaml:"properties"`
+	Required             []string           `yaml:"required"`
+	AdditionalProperties yaml.Node          `yaml:"additionalProperties"`
+	Items                *Schema            `yaml:"items"`
+	Enum                 []string           `yaml:"enum"`
+	Minimum              *float64           `yaml:"minimum"`
+	MaxItems             *int               `yaml:"maxItems"`
+}
+
+type document struct {
+	Components struct {
+		Schemas map[string]*Schema `yaml:"schemas"`
+	} `yaml:"components"`
+}
+
+var schemas = sync.OnceValues(func() (map[string]*Schema, error) {
+	var doc document
+	if err := yaml.Unmarshal(Document, &doc); err != nil {
+		return nil, fmt.Errorf("parse openapi.yaml: %w", err)
+	}
+	return doc.Components.Schemas, nil
+})
+
+// Component returns the named schema from components/schemas.
+func Component(name string) (*Schema, error) {
+	all, err := schemas()
+	if err != nil {
+		return nil, err
+	}
+	s, ok := all[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown schema %q", name)
+	}
+	return s, nil
+}
+
+// Validate checks the JSON document body against the named component schema. Schema
+// violations are reported as a *ValidationError; malformed JSON as a decoding error.
+func Validate(schema string, body []byte) error {
+	return validate(schema, body, false)
+}
+
+// ValidateStrict is Validate, but also rejects fields the schema does not define, suggesting
+// the defined one a misspelled field was probably meant to be. Objects declaring
+// additionalProperties keep accepting any field.
+func ValidateStrict(schema string, body []byte) error {
+	return validate(schema, body, true)
+}
+
+func validate(schema string, body []byte, strict bool) error {
+	s, err := Component(schema)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return fmt.Errorf("decode %s: %w", schema, err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return fmt.Errorf("decode %s: trailing data after document", schema)
+	}
+
+	var problems []string
+	if err := check(s, v, "$", strict, &problems); err != nil {
+		return err
+	}
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+func check(s *Schema, v any, path string, strict bool, problems *[]string) error {
+	if s.Ref != "" {
+		name, ok := strings.CutPrefix(s.Ref, "#/components/schemas/")
+		if !ok {
+			return fmt.Errorf("unsupported $ref %q", s.Ref)
+		}
+		ref, err := Component(name)
+		if err != nil {
+			return err
+		}
+		s = ref
+	}
+	if v == nil {
+		return nil // absent and null values are both treated as omitted
+	}
+	fail := func(format string, args ...any) {
+		*problems = append(*problems, path+": "+fmt.Sprintf(format, args...))
+	}
+
+	switch s.Type {
+	case "object":
+		obj, ok := v.(map[string]any)
+		if !ok {
+			fail("must be an object")
+			return nil
+		}
+		for _, name := range s.Required {
+			if _, ok := obj[name]; !ok {
+				fail("%s is required", name)
+			}
+		}
+		var extra *Schema
+		closed := strict
+		switch s.AdditionalProperties.Kind {
+		case yaml.MappingNode:
+			extra = new(Schema)
+			if err := s.AdditionalProperties.Decode(extra); err != nil {
+				return fmt.Errorf("additionalProperties at %s: %w", path, err)
+			}
+		case yaml.ScalarNode:
+			closed = s.AdditionalProperties.Value == "false"
+			if s.AdditionalProperties.Value == "true" {
+				closed = false
+			}
+		}
+		keys := make([]string, 0, len(obj))
+		for k := range obj {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			prop, ok := s.Properties[k]
+			switch {
+			case ok:
+			case extra != nil:
+				prop = extra
+			case closed:
+				if near := closest(k, s.Properties); near != "" {
+					fail("unknown field %s (did you mean %s?)", k, near)
+				} else {
+					fail("unknown field %s", k)
+				}
+				continue
+			default:
+				continue
+			}
+			if err := check(prop, obj[k], path+"."+k, strict, problems); err != nil {
+				return err
+			}
+		}
+	case "array":
+		arr, ok := v.([]any)
+		if !ok {
+			fail("must be an array")
+			return nil
+		}
+		if s.MaxItems != nil && len(arr) > *s.MaxItems {
+			fail("must have at most %d items", *s.MaxItems)
+		}
+		if s.Items != nil {
+			for i, item := range arr {
+				if err := check(s.Items, item, fmt.Sprintf("%s[%d]", path, i), strict, problems); err != nil {
+					return err
+				}
+			}
+		}
+	case "string":
+		str, ok := v.(string)
+		if !ok {
+			fail("must be a string")
+			return nil
+		}
+		if len(s.Enum) > 0 && !slices.Contains(s.Enum, str) {
+			fail("must be one of %s", strings.Join(s.Enum, ", "))
+		}
+		if s.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339, str); err != nil {
+				fail("must be an RFC 3339 date-time")
+			}
+		}
+	case "number", "integer":
+		kind := "a number"
+		if s.Type == "integer" {
+			kind = "an integer"
+		}
+		num, ok := v.(json.Number)
+		if !ok {
+			fail("must be %s", kind)
+			return nil
+		}
+		f, err := num.Float64()
+		if err != nil || (s.Type == "integer" && f != math.Trunc(f)) {
+			fail("must be %s", kind)
+			return nil
+		}
+		if s.Minimum != nil && f < *s.Minimum {
+			fail("must be at least %v", *s.Minimum)
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			fail("must be a boolean")
+		}
+	case "":
+	default:
+		return errors.New("unsupported schema type " + s.Type)
+	}
+	return nil
+}
+
+// closest returns the property name within two edits of name, preferring the nearest and
+// then the alphabetically first, or "" when none is that close.
+func closest(name string, properties map[string]*Schema) string {
+	best, bestDistance := "", 3
+	for candidate := range properties {
+		d := editDistance(name, candidate)
+		if d < bestDistance || (d == bestDistance && candidate < best) {
+			best, bestDistance = candidate, d
+		}
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateAcceptsEvents(t *testing.T) {
+	require.NoError(t, Validate("SubscriptionEvent", []byte(`{"number":"2507","amount":1000,"metadata":{"plan":"gold"}}`)))
+	require.NoError(t, Validate("SubscriptionEvent", []byte(`{"action":"batch","items":[{"number":"2507","amount":1}]}`)))
+	require.NoError(t, Validate("SubscriptionEvent", []byte(`{"action":"export","export":{"from":"2025-01-01T00:00:00Z"}}`)))
+}
+
+func TestValidateReportsEveryProblem(t *testing.T) {
+	err := Validate("SubscriptionEvent", []byte(`{
+		"action": "charge",
+		"amount": "1000",
+		"retry_attempt": 1.5,
+		"tax": {"name": "VAT"},
+		"items": [{"amount": -1}],
+		"export": {"from": "yesterday"}
+	}`))
+	var invalid *ValidationError
+	require.ErrorAs(t, err, &invalid)
+	require.Equal(t, []string{
+		"$.action: must be one of cashin, customer_get, customer_put, customer_delete, replay, approve, reject, resume, export, payment_link, qr, balance, audit_verify, batch, status, refund, cashout, quote, bulk_status, erase",
+		"$.amount: must be a number",
+		"$.export.from: must be an RFC 3339 date-time",
+		"$.items[0].amount: must be at least 0",
+		"$.retry_attempt: must be an integer",
+		"$.tax: rate is required",
+	}, invalid.Problems)
+}
+
+func TestValidateRejectsMalformedJSON(t *testing.T) {
+	err := Validate("SubscriptionEvent", []byte(`{"number":`))
+	require.Error(t, err)
+	require.NotErrorAs(t, err, new(*ValidationError))
+
+	require.ErrorContains(t, Validate("SubscriptionEvent", []byte(`{} {}`)), "trailing data")
+	require.ErrorContains(t, Validate("Missing", []byte(`{}`)), `unknown schema "Missing"`)
+}
+
+func TestValidateStrictRejectsUnknownFields(t *testing.T) {
+	body := []byte(`{"number":"2507","amout":1000,"items":[{"number":"2508","amount":1,"refrence":"x"}],"metadata":{"anything":true}}`)
+	require.NoError(t, Validate("SubscriptionEvent", body))
+
+	err := ValidateStrict("SubscriptionEvent", body)
+	var invalid *ValidationError
+	require.ErrorAs(t, err, &invalid)
+	require.Equal(t, []string{
+		"$: unknown field amout (did you mean amount?)",
+		"$.items[0]: unknown field refrence",
+	}, invalid.Problems)
+}
@@ -0,0 +1,800 @@
+//go:build go1.22
+
+// Package api provides primitives to interact with the openapi HTTP API.
+//
+// Code generated by github.com/oapi-codegen/oapi-codegen/v2 version v2.4.1 DO NOT EDIT.
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/oapi-codegen/runtime"
+)
+
+// Defines values for ErrorCode.
+const (
+	CALLBACKFAILED      ErrorCode = "CALLBACK_FAILED"
+	CONFIRMATIONTIMEOUT ErrorCode = "CONFIRMATION_TIMEOUT"
+	INSUFFICIENTFUNDS   ErrorCode = "INSUFFICIENT_FUNDS"
+	PAYPACKUNAVAILABLE  ErrorCode = "PAYPACK_UNAVAILABLE"
+	TRANSACTIONFAILED   ErrorCode = "TRANSACTION_FAILED"
+	UNAUTHORIZED        ErrorCode = "UNAUTHORIZED"
+	UNEXPECTEDRESPONSE  ErrorCode = "UNEXPECTED_RESPONSE"
+	VALIDATIONERROR     ErrorCode = "VALIDATION_ERROR"
+)
+
+// Defines values for ExportFilterFormat.
+const (
+	Csv     ExportFilterFormat = "csv"
+	Parquet ExportFilterFormat = "parquet"
+)
+
+// Defines values for FailureSource.
+const (
+	Callback   FailureSource = "callback"
+	Paypack    FailureSource = "paypack"
+	Timeout    FailureSource = "timeout"
+	Validation FailureSource = "validation"
+)
+
+// Defines values for RiskAssessmentDecision.
+const (
+	RiskAssessmentDecisionAllow  RiskAssessmentDecision = "allow"
+	RiskAssessmentDecisionFlag   RiskAssessmentDecision = "flag"
+	RiskAssessmentDecisionHold   RiskAssessmentDecision = "hold"
+	RiskAssessmentDecisionReject RiskAssessmentDecision = "reject"
+)
+
+// Defines values for SubscriptionStatus.
+const (
+	Active    SubscriptionStatus = "active"
+	Cancelled SubscriptionStatus = "cancelled"
+	PastDue   SubscriptionStatus = "past_due"
+	Trialing  SubscriptionStatus = "trialing"
+)
+
+// Defines values for SubscriptionEventAction.
+const (
+	SubscriptionEventActionApprove        SubscriptionEventAction = "approve"
+	SubscriptionEventActionAuditVerify    SubscriptionEventAction = "audit_verify"
+	SubscriptionEventActionBalance        SubscriptionEventAction = "balance"
+	SubscriptionEventActionBatch          SubscriptionEventAction = "batch"
+	SubscriptionEventActionBulkStatus     SubscriptionEventAction = "bulk_status"
+	SubscriptionEventActionCashin         SubscriptionEventAction = "cashin"
+	SubscriptionEventActionCashout        SubscriptionEventAction = "cashout"
+	SubscriptionEventActionCustomerDelete SubscriptionEventAction = "customer_delete"
+	SubscriptionEventActionCustomerGet    SubscriptionEventAction = "customer_get"
+	SubscriptionEventActionCustomerPut    SubscriptionEventAction = "customer_put"
+	SubscriptionEventActionErase          SubscriptionEventAction = "erase"
+	SubscriptionEventActionExport         SubscriptionEventAction = "export"
+	SubscriptionEventActionPaymentLink    SubscriptionEventAction = "payment_link"
+	SubscriptionEventActionQr             SubscriptionEventAction = "qr"
+	SubscriptionEventActionQuote          SubscriptionEventAction = "quote"
+	SubscriptionEventActionRefund         SubscriptionEventAction = "refund"
+	SubscriptionEventActionReject         SubscriptionEventAction = "reject"
+	SubscriptionEventActionReplay         SubscriptionEventAction = "replay"
+	SubscriptionEventActionResume         SubscriptionEventAction = "resume"
+	SubscriptionEventActionStatus         SubscriptionEventAction = "status"
+)
+
+// Defines values for SubscriptionEventQr.
+const (
+	Link SubscriptionEventQr = "link"
+	Ussd SubscriptionEventQr = "ussd"
+)
+
+// AuditVerification defines model for AuditVerification.
+type AuditVerification struct {
+	BrokenAt *int    `json:"broken_at,omitempty"`
+	HeadHash *string `json:"head_hash,omitempty"`
+	Reason   *string `json:"reason,omitempty"`
+	Records  *int    `json:"records,omitempty"`
+	Valid    *bool   `json:"valid,omitempty"`
+}
+
+// BalanceReport defines model for BalanceReport.
+type BalanceReport struct {
+	CheckedAt *time.Time `json:"checked_at,omitempty"`
+	Currency  *string    `json:"currency,omitempty"`
+	Drift     *float32   `json:"drift,omitempty"`
+	Drifted   *bool      `json:"drifted,omitempty"`
+	Provider  *float32   `json:"provider,omitempty"`
+	Threshold *float32   `json:"threshold,omitempty"`
+	Tracked   *float32   `json:"tracked,omitempty"`
+}
+
+// BatchReport defines model for BatchReport.
+type BatchReport struct {
+	Errors  *int `json:"errors,omitempty"`
+	Failed  *int `json:"failed,omitempty"`
+	Pending *int `json:"pending,omitempty"`
+	Results *[]struct {
+		Code    *ErrorCode `json:"code,omitempty"`
+		Index   *int       `json:"index,omitempty"`
+		Message *string    `json:"message,omitempty"`
+		Ref     *string    `json:"ref,omitempty"`
+		Status  *string    `json:"status,omitempty"`
+	} `json:"results,omitempty"`
+	Skipped   *int `json:"skipped,omitempty"`
+	Succeeded *int `json:"succeeded,omitempty"`
+	Total     *int `json:"total,omitempty"`
+}
+
+// Conversion defines model for Conversion.
+type Conversion struct {
+	ConvertedAmount *float32   `json:"converted_amount,omitempty"`
+	From            *string    `json:"from,omitempty"`
+	OriginalAmount  *float32   `json:"original_amount,omitempty"`
+	QuotedAt        *time.Time `json:"quoted_at,omitempty"`
+	Rate            *float32   `json:"rate,omitempty"`
+	To              *string    `json:"to,omitempty"`
+}
+
+// CustomerProfile defines model for CustomerProfile.
+type CustomerProfile struct {
+	ClientId    *string            `json:"client_id,omitempty"`
+	CreatedAt   *time.Time         `json:"created_at,omitempty"`
+	Email       *string            `json:"email,omitempty"`
+	Name        *string            `json:"name,omitempty"`
+	Number      string             `json:"number"`
+	Preferences *map[string]string `json:"preferences,omitempty"`
+	UpdatedAt   *time.Time         `json:"updated_at,omitempty"`
+}
+
+// Disbursement defines model for Disbursement.
+type Disbursement struct {
+	Amount *float32   `json:"amount,omitempty"`
+	Code   *ErrorCode `json:"code,omitempty"`
+	Error  *string    `json:"error,omitempty"`
+	Name   *string    `json:"name,omitempty"`
+	Number *string    `json:"number,omitempty"`
+	Ref    *string    `json:"ref,omitempty"`
+	Status *string    `json:"status,omitempty"`
+}
+
+// ErasureReport defines model for ErasureReport.
+type ErasureReport struct {
+	// Approvals Approval requests rewritten; pending ones are rejected first.
+	Approvals int `json:"approvals"`
+
+	// Checkpoints Pending confirmation checkpoints rewritten.
+	Checkpoints int `json:"checkpoints"`
+
+	// Customer Whether a customer profile was deleted.
+	Customer bool `json:"customer"`
+
+	// Events Stored webhooks and callbacks rewritten.
+	Events int `json:"events"`
+
+	// Exports Export objects deleted because they listed one of the refs.
+	Exports       int `json:"exports"`
+	LedgerEntries int `json:"ledger_entries"`
+
+	// Outcomes Recorded idempotency outcomes rewritten.
+	Outcomes int `json:"outcomes"`
+
+	// Receipts Refs whose receipt objects were deleted.
+	Receipts int `json:"receipts"`
+
+	// Refs Transactions whose records mentioned the number.
+	Refs *[]string `json:"refs,omitempty"`
+
+	// Retries Retry entries deleted, so the number is not charged again.
+	Retries int `json:"retries"`
+
+	// Token HMAC of the number, which now stands in for it in stored records.
+	Token string `json:"token"`
+}
+
+// Error defines model for Error.
+type Error struct {
+	Code *ErrorCode `json:"code,omitempty"`
+
+	// Details Schema violations, for 400 responses.
+	Details *[]string `json:"details,omitempty"`
+	Error   string    `json:"error"`
+
+	// Failure Why a response failed, and whether resending the event (with the same correlation_id) is safe.
+	Failure *Failure `json:"failure,omitempty"`
+
+	// SupportedVersions Event schema versions the processor decodes, when the event's version is unsupported.
+	SupportedVersions *[]int `json:"supported_versions,omitempty"`
+}
+
+// ErrorCode defines model for ErrorCode.
+type ErrorCode string
+
+// ExportFilter defines model for ExportFilter.
+type ExportFilter struct {
+	Client *string             `json:"client,omitempty"`
+	Format *ExportFilterFormat `json:"format,omitempty"`
+	From   *time.Time          `json:"from,omitempty"`
+	Status *string             `json:"status,omitempty"`
+	To     *time.Time          `json:"to,omitempty"`
+}
+
+// ExportFilterFormat defines model for ExportFilter.Format.
+type ExportFilterFormat string
+
+// ExportResult defines model for ExportResult.
+type ExportResult struct {
+	Format *string `json:"format,omitempty"`
+	Key    *string `json:"key,omitempty"`
+	Rows   *int    `json:"rows,omitempty"`
+	Url    *string `json:"url,omitempty"`
+}
+
+// Failure Why a response failed, and whether resending the event (with the same correlation_id) is safe.
+type Failure struct {
+	Code      ErrorCode     `json:"code"`
+	Retryable bool          `json:"retryable"`
+	Source    FailureSource `json:"source"`
+}
+
+// FailureSource defines model for Failure.Source.
+type FailureSource string
+
+// FeeBreakdown defines model for FeeBreakdown.
+type FeeBreakdown struct {
+	ActualFee   *float32 `json:"actual_fee,omitempty"`
+	ExpectedFee *float32 `json:"expected_fee,omitempty"`
+	Mismatch    *bool    `json:"mismatch,omitempty"`
+	NetAmount   *float32 `json:"net_amount,omitempty"`
+	Provider    *string  `json:"provider,omitempty"`
+}
+
+// PaymentLink defines model for PaymentLink.
+type PaymentLink struct {
+	Amount    *float32   `json:"amount,omitempty"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	Currency  *string    `json:"currency,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Id        *string    `json:"id,omitempty"`
+	Status    *string    `json:"status,omitempty"`
+	Url       *string    `json:"url,omitempty"`
+}
+
+// QRCode defines model for QRCode.
+type QRCode struct {
+	Content     *string `json:"content,omitempty"`
+	ImageBase64 *string `json:"image_base64,omitempty"`
+	Key         *string `json:"key,omitempty"`
+	Kind        *string `json:"kind,omitempty"`
+	Url         *string `json:"url,omitempty"`
+}
+
+// Quote Fee preview returned by the quote action; nothing is charged.
+type Quote struct {
+	// Amount Amount charged before fees, after currency conversion and tax.
+	Amount   *float32 `json:"amount,omitempty"`
+	Currency *string  `json:"currency,omitempty"`
+	Fee      *float32 `json:"fee,omitempty"`
+	Provider *string  `json:"provider,omitempty"`
+
+	// Total Amount including the fee.
+	Total *float32 `json:"total,omitempty"`
+}
+
+// Receipt defines model for Receipt.
+type Receipt struct {
+	ContentType *string    `json:"content_type,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	Key         *string    `json:"key,omitempty"`
+	Url         *string    `json:"url,omitempty"`
+}
+
+// RefStatus defines model for RefStatus.
+type RefStatus struct {
+	Code    *ErrorCode `json:"code,omitempty"`
+	Found   bool       `json:"found"`
+	Message *string    `json:"message,omitempty"`
+	Ref     string     `json:"ref"`
+
+	// Status The transaction's status, pending while Paypack does not know it yet, or error when the lookup failed.
+	Status      string       `json:"status"`
+	Transaction *Transaction `json:"transaction,omitempty"`
+}
+
+// Refund defines model for Refund.
+type Refund struct {
+	Amount      *float32 `json:"amount,omitempty"`
+	OriginalRef *string  `json:"original_ref,omitempty"`
+
+	// RefundableRemaining Amount of the original charge that can still be refunded.
+	RefundableRemaining *float32 `json:"refundable_remaining,omitempty"`
+
+	// Refunded Cumulative amount refunded on the original charge, this refund included.
+	Refunded *float32 `json:"refunded,omitempty"`
+}
+
+// RetryInfo defines model for RetryInfo.
+type RetryInfo struct {
+	Attempt       *int       `json:"attempt,omitempty"`
+	Exhausted     *bool      `json:"exhausted,omitempty"`
+	MaxAttempts   *int       `json:"max_attempts,omitempty"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
+}
+
+// RiskAssessment defines model for RiskAssessment.
+type RiskAssessment struct {
+	Decision *RiskAssessmentDecision `json:"decision,omitempty"`
+	Results  *[]struct {
+		Decision *string `json:"decision,omitempty"`
+		Reason   *string `json:"reason,omitempty"`
+		Rule     *string `json:"rule,omitempty"`
+	} `json:"results,omitempty"`
+}
+
+// RiskAssessmentDecision defines model for RiskAssessment.Decision.
+type RiskAssessmentDecision string
+
+// SplitPlan defines model for SplitPlan.
+type SplitPlan struct {
+	Recipients []struct {
+		Fixed   *float32 `json:"fixed,omitempty"`
+		Name    *string  `json:"name,omitempty"`
+		Number  string   `json:"number"`
+		Percent *float32 `json:"percent,omitempty"`
+	} `json:"recipients"`
+}
+
+// Subscription defines model for Subscription.
+type Subscription struct {
+	ActivatedAt   *time.Time              `json:"activated_at,omitempty"`
+	Amount        *float32                `json:"amount,omitempty"`
+	CancelledAt   *time.Time              `json:"cancelled_at,omitempty"`
+	Client        *string                 `json:"client,omitempty"`
+	CreatedAt     *time.Time              `json:"created_at,omitempty"`
+	FailureReason *string                 `json:"failure_reason,omitempty"`
+	Id            *string                 `json:"id,omitempty"`
+	LastRef       *string                 `json:"last_ref,omitempty"`
+	Metadata      *map[string]interface{} `json:"metadata,omitempty"`
+	Number        *string                 `json:"number,omitempty"`
+	PaymentLink   *map[string]interface{} `json:"payment_link,omitempty"`
+	Plan          *string                 `json:"plan,omitempty"`
+	Status        *SubscriptionStatus     `json:"status,omitempty"`
+	UpdatedAt     *time.Time              `json:"updated_at,omitempty"`
+}
+
+// SubscriptionStatus defines model for Subscription.Status.
+type SubscriptionStatus string
+
+// SubscriptionEvent defines model for SubscriptionEvent.
+type SubscriptionEvent struct {
+	// Action Defaults to cashin.
+	Action *SubscriptionEventAction `json:"action,omitempty"`
+	Amount *float32                 `json:"amount,omitempty"`
+
+	// AmountMinor Amount in minor units of currency (whole francs for RWF, cents for USD); preferred over amount, which must match it when both are sent.
+	AmountMinor *int64  `json:"amount_minor,omitempty"`
+	ApprovalId  *string `json:"approval_id,omitempty"`
+
+	// Approver With approve or reject, one of the configured APPROVERS.
+	Approver *string `json:"approver,omitempty"`
+
+	// ApproverSignature Base64 signature by the approver's key of the canonical event without signature or approver_signature; required with approve or reject.
+	ApproverSignature *string `json:"approver_signature,omitempty"`
+	Client            *string `json:"client,omitempty"`
+
+	// ConfirmTimeoutSeconds Overrides how long confirmation is awaited, capped at CONFIRM_TIMEOUT_MAX.
+	ConfirmTimeoutSeconds *int `json:"confirm_timeout_seconds,omitempty"`
+
+	// ConnectionId API Gateway WebSocket connection that receives live status updates.
+	ConnectionId *string `json:"connection_id,omitempty"`
+
+	// CorrelationId Tags log lines, Paypack requests, the response and callbacks.
+	CorrelationId *string `json:"correlation_id,omitempty"`
+
+	// Currency ISO 4217 code; defaults to RWF.
+	Currency *string              `json:"currency,omitempty"`
+	Customer *CustomerProfile     `json:"customer,omitempty"`
+	Debug    *bool                `json:"debug,omitempty"`
+	Export   *ExportFilter        `json:"export,omitempty"`
+	Items    *[]SubscriptionEvent `json:"items,omitempty"`
+
+	// MaxAttempts Lookups after which the payment is reported unconfirmed, capped at POLL_MAX_ATTEMPTS.
+	MaxAttempts *int                    `json:"max_attempts,omitempty"`
+	Metadata    *map[string]interface{} `json:"metadata,omitempty"`
+
+	// Number Payer phone number; required for cash-ins.
+	Number *string `json:"number,omitempty"`
+
+	// PollIntervalSeconds Wait between confirmation lookups, clamped to POLL_MIN_INTERVAL..POLL_MAX_INTERVAL.
+	PollIntervalSeconds *int `json:"poll_interval_seconds,omitempty"`
+
+	// Provider Mobile money provider (mtn, airtel) a quote is priced for.
+	Provider *string              `json:"provider,omitempty"`
+	Qr       *SubscriptionEventQr `json:"qr,omitempty"`
+	Ref      *string              `json:"ref,omitempty"`
+
+	// Refs Transactions a bulk_status event asks about.
+	Refs *[]string `json:"refs,omitempty"`
+
+	// RequestedBy Who submitted the charge. A held cash-in cannot be approved by its requester.
+	RequestedBy  *string `json:"requested_by,omitempty"`
+	RetryAttempt *int    `json:"retry_attempt,omitempty"`
+	RetryId      *string `json:"retry_id,omitempty"`
+
+	// ScheduledAt Defers a cash-in or payout until this time (requires SCHEDULE_TABLE); the event is answered with status scheduled.
+	ScheduledAt *time.Time `json:"scheduled_at,omitempty"`
+
+	// Signature Base64 signature of the canonical event (sorted keys, no whitespace, without this field), required when event signing is configured.
+	Signature *string `json:"signature,omitempty"`
+
+	// SignedAt Unix time the event was signed, checked against EVENT_SIGNATURE_MAX_AGE.
+	SignedAt       *int64     `json:"signed_at,omitempty"`
+	Split          *SplitPlan `json:"split,omitempty"`
+	SubscriptionId *string    `json:"subscription_id,omitempty"`
+	Tax            *TaxRule   `json:"tax,omitempty"`
+	Traceparent    *string    `json:"traceparent,omitempty"`
+	Tracestate     *string    `json:"tracestate,omitempty"`
+
+	// Version Event schema version; omitted means 1. Unsupported versions are rejected with the supported ones listed.
+	Version *int `json:"version,omitempty"`
+}
+
+// SubscriptionEventAction Defaults to cashin.
+type SubscriptionEventAction string
+
+// SubscriptionEventQr defines model for SubscriptionEvent.Qr.
+type SubscriptionEventQr string
+
+// SubscriptionResponse defines model for SubscriptionResponse.
+type SubscriptionResponse struct {
+	ApprovalId *string `json:"approval_id,omitempty"`
+
+	// Attempts Status lookups made before confirmation timed out.
+	Attempts *int               `json:"attempts,omitempty"`
+	Audit    *AuditVerification `json:"audit,omitempty"`
+	Balance  *BalanceReport     `json:"balance,omitempty"`
+	Batch    *BatchReport       `json:"batch,omitempty"`
+
+	// Client The event's client, also set on transaction.
+	Client *string    `json:"client,omitempty"`
+	Code   *ErrorCode `json:"code,omitempty"`
+
+	// ContinuationToken Ties a pending callback sent when confirmation was cut short to the final outcome delivered later.
+	ContinuationToken *string     `json:"continuation_token,omitempty"`
+	Conversion        *Conversion `json:"conversion,omitempty"`
+
+	// CorrelationId The event's correlation_id.
+	CorrelationId *string          `json:"correlation_id,omitempty"`
+	Customer      *CustomerProfile `json:"customer,omitempty"`
+	Disbursements *[]Disbursement  `json:"disbursements,omitempty"`
+	Erasure       *ErasureReport   `json:"erasure,omitempty"`
+	Export        *ExportResult    `json:"export,omitempty"`
+
+	// Failure Why a response failed, and whether resending the event (with the same correlation_id) is safe.
+	Failure *Failure `json:"failure,omitempty"`
+
+	// Fee Fee Paypack charged, set once the transaction succeeded.
+	Fee     *float32      `json:"fee,omitempty"`
+	Fees    *FeeBreakdown `json:"fees,omitempty"`
+	Found   bool          `json:"found"`
+	Message *string       `json:"message,omitempty"`
+
+	// NetAmount Transaction amount less fee, set once the transaction succeeded.
+	NetAmount *float32 `json:"net_amount,omitempty"`
+
+	// NumberId Keyed hash of the customer number, sent to destinations that mask or hash numbers.
+	NumberId    *string      `json:"number_id,omitempty"`
+	PaymentLink *PaymentLink `json:"payment_link,omitempty"`
+	QrCode      *QRCode      `json:"qr_code,omitempty"`
+
+	// Quote Fee preview returned by the quote action; nothing is charged.
+	Quote   *Quote   `json:"quote,omitempty"`
+	Receipt *Receipt `json:"receipt,omitempty"`
+	Ref     string   `json:"ref"`
+	Refund  *Refund  `json:"refund,omitempty"`
+
+	// Replayed The result already recorded for the ref, answered without calling Paypack.
+	Replayed *bool             `json:"replayed,omitempty"`
+	Request  SubscriptionEvent `json:"request"`
+	Retry    *RetryInfo        `json:"retry,omitempty"`
+	Risk     *RiskAssessment   `json:"risk,omitempty"`
+
+	// ScheduleId Identifies an event deferred by scheduled_at.
+	ScheduleId *string `json:"schedule_id,omitempty"`
+
+	// Status success, failed, pending, held, rejected, ...
+	Status string `json:"status"`
+
+	// Statuses One entry per ref of a bulk_status event, in order.
+	Statuses     *[]RefStatus  `json:"statuses,omitempty"`
+	Subscription *Subscription `json:"subscription,omitempty"`
+	Tax          *TaxBreakdown `json:"tax,omitempty"`
+	Transaction  *Transaction  `json:"transaction,omitempty"`
+}
+
+// TaxBreakdown defines model for TaxBreakdown.
+type TaxBreakdown struct {
+	Gross     *float32 `json:"gross,omitempty"`
+	Inclusive *bool    `json:"inclusive,omitempty"`
+	Name      *string  `json:"name,omitempty"`
+	Net       *float32 `json:"net,omitempty"`
+	Rate      *float32 `json:"rate,omitempty"`
+	Tax       *float32 `json:"tax,omitempty"`
+}
+
+// TaxRule defines model for TaxRule.
+type TaxRule struct {
+	Inclusive *bool   `json:"inclusive,omitempty"`
+	Name      *string `json:"name,omitempty"`
+	Rate      float32 `json:"rate"`
+}
+
+// Transaction defines model for Transaction.
+type Transaction struct {
+	Amount    float32                 `json:"amount"`
+	Client    *string                 `json:"client,omitempty"`
+	CreatedAt *time.Time              `json:"created_at,omitempty"`
+	Currency  *string                 `json:"currency,omitempty"`
+	Fee       *float32                `json:"fee,omitempty"`
+	Kind      *string                 `json:"kind,omitempty"`
+	Merchant  *string                 `json:"merchant,omitempty"`
+	Metadata  *map[string]interface{} `json:"metadata,omitempty"`
+
+	// Number The payer's mobile-money number.
+	Number    *string    `json:"number,omitempty"`
+	Provider  *string    `json:"provider,omitempty"`
+	Ref       string     `json:"ref"`
+	Status    *string    `json:"status,omitempty"`
+	Timestamp *time.Time `json:"timestamp,omitempty"`
+}
+
+// WebhookEvent defines model for WebhookEvent.
+type WebhookEvent struct {
+	CreatedAt *time.Time  `json:"created_at,omitempty"`
+	Data      Transaction `json:"data"`
+	EventId   *string     `json:"event_id,omitempty"`
+	EventKind *string     `json:"event_kind,omitempty"`
+}
+
+// HandleWebhookParams defines parameters for HandleWebhook.
+type HandleWebhookParams struct {
+	// XPaypackSignature HMAC-SHA256 of the body, required when PAYPACK_WEBHOOK_SECRET is set.
+	XPaypackSignature *string `json:"X-Paypack-Signature,omitempty"`
+}
+
+// HandleEventJSONRequestBody defines body for HandleEvent for application/json ContentType.
+type HandleEventJSONRequestBody = SubscriptionEvent
+
+// HandleWebhookJSONRequestBody defines body for HandleWebhook for application/json ContentType.
+type HandleWebhookJSONRequestBody = WebhookEvent
+
+// ServerInterface represents all server handlers.
+type ServerInterface interface {
+	// Process a subscription event.
+	// (POST /events)
+	HandleEvent(w http.ResponseWriter, r *http.Request)
+
+	// (GET /healthz)
+	Health(w http.ResponseWriter, r *http.Request)
+	// This document.
+	// (GET /openapi.yaml)
+	Spec(w http.ResponseWriter, r *http.Request)
+	// Receive a Paypack webhook.
+	// (POST /webhook)
+	HandleWebhook(w http.ResponseWriter, r *http.Request, params HandleWebhookParams)
+}
+
+// ServerInterfaceWrapper converts contexts to parameters.
+type ServerInterfaceWrapper struct {
+	Handler            ServerInterface
+	HandlerMiddlewares []MiddlewareFunc
+	ErrorHandlerFunc   func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+type MiddlewareFunc func(http.Handler) http.Handler
+
+// HandleEvent operation middleware
+func (siw *ServerInterfaceWrapper) HandleEvent(w http.ResponseWriter, r *http.Request) {
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.HandleEvent(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// Health operation middleware
+func (siw *ServerInterfaceWrapper) Health(w http.ResponseWriter, r *http.Request) {
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.Health(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// Spec operation middleware
+func (siw *ServerInterfaceWrapper) Spec(w http.ResponseWriter, r *http.Request) {
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.Spec(w, r)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// HandleWebhook operation middleware
+func (siw *ServerInterfaceWrapper) HandleWebhook(w http.ResponseWriter, r *http.Request) {
+
+	var err error
+
+	// Parameter object where we will unmarshal all parameters from the context
+	var params HandleWebhookParams
+
+	headers := r.Header
+
+	// ------------- Optional header parameter "X-Paypack-Signature" -------------
+	if valueList, found := headers[http.CanonicalHeaderKey("X-Paypack-Signature")]; found {
+		var XPaypackSignature string
+		n := len(valueList)
+		if n != 1 {
+			siw.ErrorHandlerFunc(w, r, &TooManyValuesForParamError{ParamName: "X-Paypack-Signature", Count: n})
+			return
+		}
+
+		err = runtime.BindStyledParameterWithOptions("simple", "X-Paypack-Signature", valueList[0], &XPaypackSignature, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationHeader, Explode: false, Required: false})
+		if err != nil {
+			siw.ErrorHandlerFunc(w, r, &InvalidParamFormatError{ParamName: "X-Paypack-Signature", Err: err})
+			return
+		}
+
+		params.XPaypackSignature = &XPaypackSignature
+
+	}
+
+	handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siw.Handler.HandleWebhook(w, r, params)
+	}))
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		handler = middleware(handler)
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+type UnescapedCookieParamError struct {
+	ParamName string
+	Err       error
+}
+
+func (e *UnescapedCookieParamError) Error() string {
+	return fmt.Sprintf("error unescaping cookie parameter '%s'", e.ParamName)
+}
+
+func (e *UnescapedCookieParamError) Unwrap() error {
+	return e.Err
+}
+
+type UnmarshalingParamError struct {
+	ParamName string
+	Err       error
+}
+
+func (e *UnmarshalingParamError) Error() string {
+	return fmt.Sprintf("Error unmarshaling parameter %s as JSON: %s", e.ParamName, e.Err.Error())
+}
+
+func (e *UnmarshalingParamError) Unwrap() error {
+	return e.Err
+}
+
+type RequiredParamError struct {
+	ParamName string
+}
+
+func (e *RequiredParamError) Error() string {
+	return fmt.Sprintf("Query argument %s is required, but not found", e.ParamName)
+}
+
+type RequiredHeaderError struct {
+	ParamName string
+	Err       error
+}
+
+func (e *RequiredHeaderError) Error() string {
+	return fmt.Sprintf("Header parameter %s is required, but not found", e.ParamName)
+}
+
+func (e *RequiredHeaderError) Unwrap() error {
+	return e.Err
+}
+
+type InvalidParamFormatError struct {
+	ParamName string
+	Err       error
+}
+
+func (e *InvalidParamFormatError) Error() string {
+	return fmt.Sprintf("Invalid format for parameter %s: %s", e.ParamName, e.Err.Error())
+}
+
+func (e *InvalidParamFormatError) Unwrap() error {
+	return e.Err
+}
+
+type TooManyValuesForParamError struct {
+	ParamName string
+	Count     int
+}
+
+func (e *TooManyValuesForParamError) Error() string {
+	return fmt.Sprintf("Expected one value for %s, got %d", e.ParamName, e.Count)
+}
+
+// Handler creates http.Handler with routing matching OpenAPI spec.
+func Handler(si ServerInterface) http.Handler {
+	return HandlerWithOptions(si, StdHTTPServerOptions{})
+}
+
+// ServeMux is an abstraction of http.ServeMux.
+type ServeMux interface {
+	HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request))
+	ServeHTTP(w http.ResponseWriter, r *http.Request)
+}
+
+type StdHTTPServerOptions struct {
+	BaseURL          string
+	BaseRouter       ServeMux
+	Middlewares      []MiddlewareFunc
+	ErrorHandlerFunc func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+// HandlerFromMux creates http.Handler with routing matching OpenAPI spec based on the provided mux.
+func HandlerFromMux(si ServerInterface, m ServeMux) http.Handler {
+	return HandlerWithOptions(si, StdHTTPServerOptions{
+		BaseRouter: m,
+	})
+}
+
+func HandlerFromMuxWithBaseURL(si ServerInterface, m ServeMux, baseURL string) http.Handler {
+	return HandlerWithOptions(si, StdHTTPServerOptions{
+		BaseURL:    baseURL,
+		BaseRouter: m,
+	})
+}
+
+// HandlerWithOptions creates http.Handler with additional options
+func HandlerWithOptions(si ServerInterface, options StdHTTPServerOptions) http.Handler {
+	m := options.BaseRouter
+
+	if m == nil {
+		m = http.NewServeMux()
+	}
+	if options.ErrorHandlerFunc == nil {
+		options.ErrorHandlerFunc = func(w http.ResponseWriter, r *http.Request, err error) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+	}
+
+	wrapper := ServerInterfaceWrapper{
+		Handler:            si,
+		HandlerMiddlewares: options.Middlewares,
+		ErrorHandlerFunc:   options.ErrorHandlerFunc,
+	}
+
+	m.HandleFunc("POST "+options.BaseURL+"/events", wrapper.HandleEvent)
+	m.HandleFunc("GET "+options.BaseURL+"/healthz", wrapper.Health)
+	m.HandleFunc("GET "+options.BaseURL+"/openapi.yaml", wrapper.Spec)
+	m.HandleFunc("POST "+options.BaseURL+"/webhook", wrapper.HandleWebhook)
+
+	return m
+}
@@ -1,33 +1,1155 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
+	"net/http"
+	"net/smtp"
 	"os"
+	"strconv"
 	"strings"
+	"sync"
+	"text/template"
+	"time"
 
 	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-lambda-go/lambda/messages"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/aws/aws-sdk-go-v2/service/firehose"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
 
+	"github.com/berniyo/paypack-lambda/internal/alert"
+	"github.com/berniyo/paypack-lambda/internal/analytics"
+	"github.com/berniyo/paypack-lambda/internal/approval"
+	"github.com/berniyo/paypack-lambda/internal/appsync"
+	"github.com/berniyo/paypack-lambda/internal/audit"
+	"github.com/berniyo/paypack-lambda/internal/billing"
+	"github.com/berniyo/paypack-lambda/internal/checkpoint"
+	"github.com/berniyo/paypack-lambda/internal/customer"
+	"github.com/berniyo/paypack-lambda/internal/egress"
+	"github.com/berniyo/paypack-lambda/internal/eventstore"
+	"github.com/berniyo/paypack-lambda/internal/fee"
+	"github.com/berniyo/paypack-lambda/internal/fx"
 	"github.com/berniyo/paypack-lambda/internal/handler"
-	"github.com/berniyo/paypack-lambda/internal/paypack"
+	"github.com/berniyo/paypack-lambda/internal/lambdainvoke"
+	"github.com/berniyo/paypack-lambda/internal/ledger"
+	"github.com/berniyo/paypack-lambda/internal/metrics"
+	"github.com/berniyo/paypack-lambda/internal/objectstore"
+	"github.com/berniyo/paypack-lambda/internal/oidc"
+	"github.com/berniyo/paypack-lambda/internal/oneshot"
+	"github.com/berniyo/paypack-lambda/internal/outcome"
+	"github.com/berniyo/paypack-lambda/internal/receipt"
+	"github.com/berniyo/paypack-lambda/internal/redact"
+	"github.com/berniyo/paypack-lambda/internal/refund"
+	"github.com/berniyo/paypack-lambda/internal/retry"
+	"github.com/berniyo/paypack-lambda/internal/risk"
+	"github.com/berniyo/paypack-lambda/internal/rpc"
+	"github.com/berniyo/paypack-lambda/internal/server"
+	"github.com/berniyo/paypack-lambda/internal/split"
+	"github.com/berniyo/paypack-lambda/internal/sqsqueue"
+	"github.com/berniyo/paypack-lambda/internal/ssmparam"
+	"github.com/berniyo/paypack-lambda/internal/subscription"
+	"github.com/berniyo/paypack-lambda/internal/tax"
+	"github.com/berniyo/paypack-lambda/internal/txcache"
+	"github.com/berniyo/paypack-lambda/internal/wallet"
+	"github.com/berniyo/paypack-lambda/internal/webhookauth"
+	"github.com/berniyo/paypack-lambda/internal/wspush"
+	"github.com/berniyo/paypack-lambda/pkg/envelope"
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
 )
 
 func main() {
-	client, err := paypack.NewClientFromEnv(nil)
+	redactor := newRedactor()
+	log.SetOutput(redactor.Writer(log.Writer()))
+
+	mode := strings.TrimSpace(os.Getenv("HANDLER_MODE"))
+	meter, prom, err := newMetrics(mode)
+	if err != nil {
+		log.Fatalf("failed to configure metrics: %v", err)
+	}
+	meter = redactor.Metrics(meter)
+
+	egressTLS, err := egress.TLSConfig(os.Getenv("EGRESS_TLS_MIN_VERSION"), os.Getenv("EGRESS_CA_BUNDLE"))
+	if err != nil {
+		log.Fatalf("failed to configure outbound TLS: %v", err)
+	}
+	clientOpts := append(transportOptions(), paypack.WithMetrics(meter), paypack.WithTLSConfig(egressTLS))
+	client, err := paypack.NewClientFromEnv(nil, clientOpts...)
 	if err != nil {
 		log.Fatalf("failed to configure paypack client: %v", err)
 	}
+	if eager, _ := strconv.ParseBool(os.Getenv("PAYPACK_EAGER_AUTH")); eager {
+		warmToken(client)
+	}
 
 	callbackURL := strings.TrimSpace(os.Getenv("SUBSCRIPTION_CALLBACK_URL"))
 	if callbackURL == "" {
 		log.Fatal("SUBSCRIPTION_CALLBACK_URL must be set")
 	}
 	callbackSecret := os.Getenv("SUBSCRIPTION_CALLBACK_SECRET")
-	callbackSender, err := handler.NewHTTPSCallbackSender(callbackURL, callbackSecret, nil)
+	var callbackOpts []handler.CallbackOption
+	if version := strings.TrimSpace(os.Getenv("CALLBACK_API_VERSION")); version != "" {
+		if strings.EqualFold(version, "latest") {
+			version = envelope.Latest
+		}
+		callbackOpts = append(callbackOpts, handler.WithCallbackEnvelope(version))
+	}
+	if protobuf, _ := strconv.ParseBool(os.Getenv("CALLBACK_PROTOBUF")); protobuf {
+		callbackOpts = append(callbackOpts, handler.WithProtobuf())
+	}
+	if mode := strings.TrimSpace(os.Getenv("CALLBACK_CLOUDEVENTS")); mode != "" {
+		callbackOpts = append(callbackOpts, handler.WithCloudEvents(handler.CloudEventsMode(mode), strings.TrimSpace(os.Getenv("CLOUDEVENTS_SOURCE"))))
+	}
+	if name := strings.TrimSpace(os.Getenv("CALLBACK_ENCODING")); name != "" {
+		enc, err := handler.ParsePayloadEncoder(name)
+		if err != nil {
+			log.Fatalf("failed to configure callback encoding: %v", err)
+		}
+		callbackOpts = append(callbackOpts, handler.WithPayloadEncoder(enc))
+	}
+	if n, err := strconv.Atoi(strings.TrimSpace(os.Getenv("CALLBACK_ATTEMPTS"))); err == nil {
+		maxDelay := 10 * time.Second
+		if raw := strings.TrimSpace(os.Getenv("CALLBACK_MAX_RETRY_DELAY")); raw != "" {
+			if maxDelay, err = time.ParseDuration(raw); err != nil {
+				log.Fatalf("invalid CALLBACK_MAX_RETRY_DELAY %q: %v", raw, err)
+			}
+		}
+		callbackOpts = append(callbackOpts, handler.WithCallbackRetries(n, maxDelay))
+	}
+	if n, err := strconv.Atoi(strings.TrimSpace(os.Getenv("CALLBACK_BREAKER_THRESHOLD"))); err == nil {
+		var cooldown time.Duration
+		if raw := strings.TrimSpace(os.Getenv("CALLBACK_BREAKER_COOLDOWN")); raw != "" {
+			if cooldown, err = time.ParseDuration(raw); err != nil {
+				log.Fatalf("invalid CALLBACK_BREAKER_COOLDOWN %q: %v", raw, err)
+			}
+		}
+		callbackOpts = append(callbackOpts, handler.WithCallbackBreaker(n, cooldown))
+	}
+	if secret := strings.TrimSpace(os.Getenv("CALLBACK_SIGNING_SECRET")); secret != "" {
+		current, err := handler.NewCallbackKey(os.Getenv("CALLBACK_SIGNING_KEY_ID"), secret)
+		if err != nil {
+			log.Fatalf("failed to configure callback signing: %v", err)
+		}
+		var previous []handler.CallbackKey
+		if old := strings.TrimSpace(os.Getenv("CALLBACK_SIGNING_PREVIOUS_SECRET")); old != "" {
+			key, err := handler.NewCallbackKey(os.Getenv("CALLBACK_SIGNING_PREVIOUS_KEY_ID"), old)
+			if err != nil {
+				log.Fatalf("failed to configure previous callback signing key: %v", err)
+			}
+			previous = append(previous, key)
+		}
+		callbackOpts = append(callbackOpts, handler.WithCallbackSigning(current, previous...))
+	}
+	if tokens := callbackTokenSource(); tokens != nil {
+		callbackOpts = append(callbackOpts, handler.WithCallbackBearerToken(tokens))
+	}
+	callbackTLS, err := egress.TLSConfig(os.Getenv("EGRESS_TLS_MIN_VERSION"), os.Getenv("EGRESS_CA_BUNDLE"), callbackCABundle())
+	if err != nil {
+		log.Fatalf("failed to configure callback TLS: %v", err)
+	}
+	var callbackHTTP *http.Client
+	if callbackTLS != nil {
+		callbackHTTP = egress.Client(callbackTLS, 15*time.Second)
+	}
+	callbackSender, err := handler.NewHTTPSCallbackSender(callbackURL, callbackSecret, callbackHTTP, callbackOpts...)
 	if err != nil {
 		log.Fatalf("failed to configure callback sender: %v", err)
 	}
 
-	processor := handler.NewProcessor(client, handler.WithCallbackSender(callbackSender))
+	var fieldFilters map[handler.Channel]handler.FieldFilter
+	if raw := strings.TrimSpace(os.Getenv("CALLBACK_FIELD_FILTERS")); raw != "" {
+		fieldFilters, err = handler.ParseFieldFilters([]byte(raw))
+		if err != nil {
+			log.Fatalf("failed to configure callback field filters: %v", err)
+		}
+	}
+	if err := keyFieldFilters(fieldFilters, strings.TrimSpace(os.Getenv("CALLBACK_NUMBER_HMAC_KEY"))); err != nil {
+		log.Fatalf("failed to configure callback field filters: %v", err)
+	}
+
+	var sender handler.CallbackSender = handler.MeteredSender(handler.ChannelCallback, filterFields(handler.ChannelCallback, callbackSender, fieldFilters), meter)
+	if prefs := strings.TrimSpace(os.Getenv("NOTIFICATION_PREFERENCES")); prefs != "" {
+		fanout, err := newFanoutSender(prefs, sender, meter, fieldFilters)
+		if err != nil {
+			log.Fatalf("failed to configure notifications: %v", err)
+		}
+		sender = fanout
+	}
+
+	opts := []handler.Option{
+		handler.WithCallbackSender(sender),
+		handler.WithMetrics(meter),
+		handler.WithRedaction(redactor),
+	}
+
+	if n, err := strconv.Atoi(strings.TrimSpace(os.Getenv("BATCH_CONCURRENCY"))); err == nil {
+		opts = append(opts, handler.WithBatchConcurrency(n))
+	}
+	if n, err := strconv.Atoi(strings.TrimSpace(os.Getenv("CALLBACK_ASYNC_CONCURRENCY"))); err == nil && n > 0 {
+		opts = append(opts, handler.WithAsyncCallbacks(n))
+	}
+	if digest, _ := strconv.ParseBool(os.Getenv("CALLBACK_BATCH_DIGEST")); digest {
+		opts = append(opts, handler.WithBatchDigest())
+	}
+	if raw := strings.TrimSpace(os.Getenv("CONFIRM_TIMEOUT_MAX")); raw != "" {
+		limit, err := time.ParseDuration(raw)
+		if err != nil {
+			log.Fatalf("invalid CONFIRM_TIMEOUT_MAX %q: %v", raw, err)
+		}
+		opts = append(opts, handler.WithMaxConfirmTimeout(limit))
+	}
+	if strict, _ := strconv.ParseBool(os.Getenv("STRICT_EVENT_DECODING")); strict {
+		opts = append(opts, handler.WithStrictDecoding())
+	}
+	if raw := strings.TrimSpace(os.Getenv("LENIENT_EVENT_TYPES")); raw != "" {
+		types, err := parseLenientTypes(raw)
+		if err != nil {
+			log.Fatalf("failed to configure lenient event types: %v", err)
+		}
+		opts = append(opts, handler.WithLenientTypes(types))
+	}
+	if bounds, ok := pollBoundsFromEnv(); ok {
+		opts = append(opts, handler.WithPollBounds(bounds))
+	}
+	if n, err := strconv.Atoi(strings.TrimSpace(os.Getenv("UPSTREAM_ERROR_LIMIT"))); err == nil {
+		opts = append(opts, handler.WithUpstreamErrorLimit(n))
+	}
+	var metadataLimits handler.MetadataLimits
+	metadataLimits.MaxDepth, _ = strconv.Atoi(strings.TrimSpace(os.Getenv("METADATA_MAX_DEPTH")))
+	metadataLimits.MaxBytes, _ = strconv.Atoi(strings.TrimSpace(os.Getenv("METADATA_MAX_BYTES")))
+	opts = append(opts, handler.WithMetadataLimits(metadataLimits))
+	if raw := strings.TrimSpace(os.Getenv("CALLBACK_RESERVE")); raw != "" {
+		reserve, err := time.ParseDuration(raw)
+		if err != nil {
+			log.Fatalf("invalid CALLBACK_RESERVE %q: %v", raw, err)
+		}
+		opts = append(opts, handler.WithCallbackReserve(reserve))
+	}
+	if raw := strings.TrimSpace(os.Getenv("ADAPTIVE_POLLING_WINDOW")); raw != "" {
+		span, err := time.ParseDuration(raw)
+		if err != nil {
+			log.Fatalf("invalid ADAPTIVE_POLLING_WINDOW %q: %v", raw, err)
+		}
+		opts = append(opts, handler.WithAdaptivePolling(span))
+	}
+	if raw := strings.TrimSpace(os.Getenv("SLO_WINDOW")); raw != "" {
+		span, err := time.ParseDuration(raw)
+		if err != nil {
+			log.Fatalf("invalid SLO_WINDOW %q: %v", raw, err)
+		}
+		opts = append(opts, handler.WithSLOWindow(span))
+	}
+	if strings.EqualFold(strings.TrimSpace(os.Getenv("LOG_LEVEL")), "debug") {
+		opts = append(opts, handler.WithDebugSampling(1))
+	} else if rate, err := strconv.ParseFloat(strings.TrimSpace(os.Getenv("DEBUG_SAMPLE_RATE")), 64); err == nil {
+		opts = append(opts, handler.WithDebugSampling(rate))
+	}
+
+	if bucket := strings.TrimSpace(os.Getenv("RECEIPT_BUCKET")); bucket != "" {
+		issuer, err := newReceiptIssuer(bucket)
+		if err != nil {
+			log.Fatalf("failed to configure receipts: %v", err)
+		}
+		opts = append(opts, handler.WithReceipts(issuer))
+	}
+
+	var eventLog *eventstore.DynamoStore
+	if table := strings.TrimSpace(os.Getenv("EVENT_STORE_TABLE")); table != "" {
+		store, err := eventstore.NewDynamoStore(dynamodb.NewFromConfig(awsConfig()), table)
+		if err != nil {
+			log.Fatalf("failed to configure event store: %v", err)
+		}
+		opts = append(opts, handler.WithEventStore(store))
+		eventLog = store
+	}
+
+	if table := strings.TrimSpace(os.Getenv("APPROVAL_TABLE")); table != "" {
+		threshold, err := strconv.ParseFloat(strings.TrimSpace(os.Getenv("APPROVAL_THRESHOLD")), 64)
+		if err != nil || threshold <= 0 {
+			log.Fatal("APPROVAL_THRESHOLD must be a positive amount when APPROVAL_TABLE is set")
+		}
+		store, err := approval.NewDynamoStore(dynamodb.NewFromConfig(awsConfig()), table)
+		if err != nil {
+			log.Fatalf("failed to configure approval store: %v", err)
+		}
+		ttl, _ := time.ParseDuration(strings.TrimSpace(os.Getenv("APPROVAL_TTL")))
+		approvers, err := parseApprovers(os.Getenv("APPROVERS"))
+		if err != nil {
+			log.Fatalf("failed to configure approvers: %v", err)
+		}
+		opts = append(opts, handler.WithApprovalGate(store, threshold, ttl), handler.WithApprovers(approvers))
+	}
+	minPayout, _ := strconv.ParseFloat(strings.TrimSpace(os.Getenv("PAYOUT_MIN_AMOUNT")), 64)
+	maxPayout, _ := strconv.ParseFloat(strings.TrimSpace(os.Getenv("PAYOUT_MAX_AMOUNT")), 64)
+	if minPayout > 0 || maxPayout > 0 {
+		if maxPayout > 0 && minPayout > maxPayout {
+			log.Fatal("PAYOUT_MIN_AMOUNT must not exceed PAYOUT_MAX_AMOUNT")
+		}
+		opts = append(opts, handler.WithPayoutLimits(minPayout, maxPayout))
+	}
+
+	if rules := strings.TrimSpace(os.Getenv("RISK_RULES")); rules != "" {
+		engine, err := risk.NewEngineFromJSON([]byte(rules), nil)
+		if err != nil {
+			log.Fatalf("failed to configure risk rules: %v", err)
+		}
+		opts = append(opts, handler.WithRiskChecks(engine))
+	}
+
+	if codes := strings.TrimSpace(os.Getenv("SUPPORTED_CURRENCIES")); codes != "" {
+		opts = append(opts, handler.WithCurrencies(strings.Split(codes, ",")...))
+	}
+	if ratesURL := strings.TrimSpace(os.Getenv("FX_RATES_URL")); ratesURL != "" {
+		ttl, _ := time.ParseDuration(strings.TrimSpace(os.Getenv("FX_RATES_TTL")))
+		rates, err := fx.NewHTTPRateProvider(ratesURL, ttl, nil)
+		if err != nil {
+			log.Fatalf("failed to configure FX rates: %v", err)
+		}
+		opts = append(opts, handler.WithFX(rates, os.Getenv("FX_SETTLEMENT_CURRENCY")))
+	}
+
+	if schedule := strings.TrimSpace(os.Getenv("FEE_SCHEDULE")); schedule != "" {
+		fees, err := fee.ParseSchedule([]byte(schedule))
+		if err != nil {
+			log.Fatalf("failed to configure fee schedule: %v", err)
+		}
+		opts = append(opts, handler.WithFeeSchedule(fees))
+	} else {
+		opts = append(opts, handler.WithFeeSchedule(fee.DefaultSchedule()))
+	}
+
+	if table := strings.TrimSpace(os.Getenv("TAX_TABLE")); table != "" {
+		taxes, err := tax.ParseTable([]byte(table))
+		if err != nil {
+			log.Fatalf("failed to configure tax table: %v", err)
+		}
+		opts = append(opts, handler.WithTaxTable(taxes))
+	}
+
+	if table := strings.TrimSpace(os.Getenv("LEDGER_TABLE")); table != "" {
+		journal, err := ledger.NewDynamoStore(dynamodb.NewFromConfig(awsConfig()), table)
+		if err != nil {
+			log.Fatalf("failed to configure ledger: %v", err)
+		}
+		opts = append(opts, handler.WithLedger(journal))
+	}
+	if table := strings.TrimSpace(os.Getenv("CUSTOMER_TABLE")); table != "" {
+		store, err := customer.NewDynamoStore(dynamodb.NewFromConfig(awsConfig()), table)
+		if err != nil {
+			log.Fatalf("failed to configure customer table: %v", err)
+		}
+		opts = append(opts, handler.WithCustomerStore(store))
+	}
+	if table := strings.TrimSpace(os.Getenv("REFUND_TABLE")); table != "" {
+		store, err := refund.NewDynamoStore(dynamodb.NewFromConfig(awsConfig()), table)
+		if err != nil {
+			log.Fatalf("failed to configure refund tracking: %v", err)
+		}
+		opts = append(opts, handler.WithRefundTracking(store))
+	}
+	if table := strings.TrimSpace(os.Getenv("OUTCOME_TABLE")); table != "" {
+		store, err := outcome.NewDynamoStore(dynamodb.NewFromConfig(awsConfig()), table)
+		if err != nil {
+			log.Fatalf("failed to configure outcome recording: %v", err)
+		}
+		opts = append(opts, handler.WithOutcomeStore(store))
+	}
+
+	if table := strings.TrimSpace(os.Getenv("SPLIT_TABLE")); table != "" {
+		splits, err := split.ParseTable([]byte(table))
+		if err != nil {
+			log.Fatalf("failed to configure split table: %v", err)
+		}
+		opts = append(opts, handler.WithDisbursements(splits))
+	}
+
+	if table := strings.TrimSpace(os.Getenv("WALLET_TABLE")); table != "" {
+		store, err := wallet.NewDynamoStore(dynamodb.NewFromConfig(awsConfig()), table)
+		if err != nil {
+			log.Fatalf("failed to configure wallet: %v", err)
+		}
+		threshold, _ := strconv.ParseFloat(strings.TrimSpace(os.Getenv("BALANCE_DRIFT_THRESHOLD")), 64)
+		opts = append(opts, handler.WithWallet(store, threshold))
+	}
+	if topic := strings.TrimSpace(os.Getenv("ALERT_TOPIC_ARN")); topic != "" {
+		sender, err := alert.NewSNSSender(sns.NewFromConfig(awsConfig()), topic)
+		if err != nil {
+			log.Fatalf("failed to configure alerts: %v", err)
+		}
+		opts = append(opts, handler.WithAlerts(sender))
+	}
+
+	if table := strings.TrimSpace(os.Getenv("AUDIT_TABLE")); table != "" {
+		store, err := audit.NewDynamoStore(dynamodb.NewFromConfig(awsConfig()), table)
+		if err != nil {
+			log.Fatalf("failed to configure audit log: %v", err)
+		}
+		chain, err := audit.NewLog(store)
+		if err != nil {
+			log.Fatalf("failed to configure audit log: %v", err)
+		}
+		opts = append(opts, handler.WithAuditLog(chain))
+	}
+	erasureKey := strings.TrimSpace(os.Getenv("ERASURE_HMAC_KEY"))
+	if erasureKey == "" {
+		erasureKey = strings.TrimSpace(os.Getenv("CALLBACK_NUMBER_HMAC_KEY"))
+	}
+	if erasureKey != "" {
+		opts = append(opts, handler.WithErasureKey([]byte(erasureKey)))
+	}
+
+	if table := strings.TrimSpace(os.Getenv("RETRY_TABLE")); table != "" {
+		queue, err := retry.NewDynamoStore(dynamodb.NewFromConfig(awsConfig()), table)
+		if err != nil {
+			log.Fatalf("failed to configure retry queue: %v", err)
+		}
+		maxAttempts, _ := strconv.Atoi(strings.TrimSpace(os.Getenv("RETRY_MAX_ATTEMPTS")))
+		if maxAttempts <= 0 {
+			maxAttempts = 3
+		}
+		interval, _ := time.ParseDuration(strings.TrimSpace(os.Getenv("RETRY_INTERVAL")))
+		opts = append(opts, handler.WithRetryQueue(queue, maxAttempts, interval))
+	}
+	if table := strings.TrimSpace(os.Getenv("SCHEDULE_TABLE")); table != "" {
+		store, err := retry.NewDynamoStore(dynamodb.NewFromConfig(awsConfig()), table)
+		if err != nil {
+			log.Fatalf("failed to configure schedule table: %v", err)
+		}
+		opts = append(opts, handler.WithScheduledEvents(store))
+	}
+	if verifiers := eventVerifiers(); len(verifiers) > 0 {
+		maxAge, _ := time.ParseDuration(strings.TrimSpace(os.Getenv("EVENT_SIGNATURE_MAX_AGE")))
+		opts = append(opts, handler.WithEventSignatures(maxAge, verifiers...))
+	}
+	if secret := strings.TrimSpace(os.Getenv("STEP_STATE_SECRET")); secret != "" {
+		opts = append(opts, handler.WithStepStateSecret(secret))
+	} else if secret := strings.TrimSpace(os.Getenv("EVENT_SIGNING_SECRET")); secret != "" {
+		opts = append(opts, handler.WithStepStateSecret(secret))
+	}
+	if raw := strings.TrimSpace(os.Getenv("DUNNING_SCHEDULE")); raw != "" {
+		schedule, err := handler.ParseDunningSchedule(raw)
+		if err != nil {
+			log.Fatalf("invalid DUNNING_SCHEDULE %q: %v", raw, err)
+		}
+		opts = append(opts, handler.WithDunning(schedule))
+	}
+	if table := strings.TrimSpace(os.Getenv("SUBSCRIPTION_TABLE")); table != "" {
+		store, err := subscription.NewDynamoStore(dynamodb.NewFromConfig(awsConfig()), table)
+		if err != nil {
+			log.Fatalf("failed to configure subscriptions: %v", err)
+		}
+		opts = append(opts, handler.WithSubscriptions(subscription.NewService(store)))
+	}
+
+	if table := strings.TrimSpace(os.Getenv("CHECKPOINT_TABLE")); table != "" {
+		store, err := checkpoint.NewDynamoStore(dynamodb.NewFromConfig(awsConfig()), table)
+		if err != nil {
+			log.Fatalf("failed to configure checkpoints: %v", err)
+		}
+		opts = append(opts, handler.WithCheckpoints(store))
+		if continuations, _ := strconv.ParseBool(os.Getenv("CONTINUATION_CALLBACKS")); continuations {
+			opts = append(opts, handler.WithContinuations())
+		}
+		if reinvoke, _ := strconv.ParseBool(os.Getenv("SELF_REINVOKE")); reinvoke {
+			cfg := awsConfig()
+			invoker, err := lambdainvoke.New("", cfg.Region, os.Getenv("AWS_LAMBDA_FUNCTION_NAME"), cfg.Credentials, nil)
+			if err != nil {
+				log.Fatalf("failed to configure self re-invocation: %v", err)
+			}
+			opts = append(opts, handler.WithSelfReinvocation(invoker, strings.TrimSpace(os.Getenv("EVENT_SIGNING_SECRET"))))
+		}
+	}
+	if queueURL := strings.TrimSpace(os.Getenv("CHECK_QUEUE_URL")); queueURL != "" {
+		cfg := awsConfig()
+		queue, err := sqsqueue.New(queueURL, cfg.Region, cfg.Credentials, nil)
+		if err != nil {
+			log.Fatalf("failed to configure check queue: %v", err)
+		}
+		opts = append(opts, handler.WithDelayQueue(queue))
+	} else if target := strings.TrimSpace(os.Getenv("CHECK_SCHEDULE_TARGET_ARN")); target != "" {
+		cfg := awsConfig()
+		scheduler, err := oneshot.New("", cfg.Region, target, strings.TrimSpace(os.Getenv("CHECK_SCHEDULE_ROLE_ARN")), cfg.Credentials, nil)
+		if err != nil {
+			log.Fatalf("failed to configure check schedules: %v", err)
+		}
+		opts = append(opts, handler.WithDelayQueue(scheduler))
+	}
+
+	var sharedTxnCache txcache.Store
+	if addr := strings.TrimSpace(os.Getenv("TXN_CACHE_REDIS_ADDR")); addr != "" {
+		ttl, _ := time.ParseDuration(strings.TrimSpace(os.Getenv("TXN_CACHE_TTL")))
+		sharedTxnCache = txcache.NewRedisStore(addr, os.Getenv("TXN_CACHE_REDIS_PASSWORD"), "paypack:txn:")
+		opts = append(opts, handler.WithTransactionCache(sharedTxnCache, ttl))
+	} else if !strings.EqualFold(strings.TrimSpace(os.Getenv("TXN_CACHE")), "off") {
+		ttl, _ := time.ParseDuration(strings.TrimSpace(os.Getenv("TXN_CACHE_TTL")))
+		opts = append(opts, handler.WithTransactionCache(txcache.NewMemoryStore(0), ttl))
+	}
+	if poller := pollerFromEnv(client, sharedTxnCache); poller != nil {
+		opts = append(opts, handler.WithPoller(poller))
+	}
+
+	if stream := strings.TrimSpace(os.Getenv("ANALYTICS_FIREHOSE_STREAM")); stream != "" {
+		sink, err := analytics.NewFirehoseSink(firehose.NewFromConfig(awsConfig()), stream)
+		if err != nil {
+			log.Fatalf("failed to configure analytics: %v", err)
+		}
+		opts = append(opts, handler.WithAnalytics(sink))
+	} else if bus := strings.TrimSpace(os.Getenv("ANALYTICS_EVENT_BUS")); bus != "" {
+		sink, err := analytics.NewEventBridgeSink(eventbridge.NewFromConfig(awsConfig()), bus, os.Getenv("ANALYTICS_EVENT_SOURCE"))
+		if err != nil {
+			log.Fatalf("failed to configure analytics: %v", err)
+		}
+		opts = append(opts, handler.WithAnalytics(sink))
+	}
+
+	if bucket := strings.TrimSpace(os.Getenv("EXPORT_BUCKET")); bucket != "" {
+		store, err := objectstore.NewS3Store(s3.NewFromConfig(awsConfig()), bucket)
+		if err != nil {
+			log.Fatalf("failed to configure exports: %v", err)
+		}
+		ttl, _ := time.ParseDuration(strings.TrimSpace(os.Getenv("EXPORT_URL_TTL")))
+		opts = append(opts, handler.WithExports(store, ttl))
+	}
+
+	if bucket := strings.TrimSpace(os.Getenv("QR_BUCKET")); bucket != "" {
+		store, err := objectstore.NewS3Store(s3.NewFromConfig(awsConfig()), bucket)
+		if err != nil {
+			log.Fatalf("failed to configure QR codes: %v", err)
+		}
+		ttl, _ := time.ParseDuration(strings.TrimSpace(os.Getenv("QR_URL_TTL")))
+		opts = append(opts, handler.WithQRStore(store, ttl))
+	}
+	if template := strings.TrimSpace(os.Getenv("USSD_TEMPLATE")); template != "" {
+		opts = append(opts, handler.WithUSSDTemplate(template))
+	}
+
+	if endpoint := strings.TrimSpace(os.Getenv("WEBSOCKET_ENDPOINT")); endpoint != "" {
+		cfg := awsConfig()
+		poster, err := wspush.New(endpoint, cfg.Region, cfg.Credentials, nil)
+		if err != nil {
+			log.Fatalf("failed to configure websocket status updates: %v", err)
+		}
+		opts = append(opts, handler.WithStatusUpdates(poster))
+	}
+
+	var broker *server.Broker
+	if mode == "server" {
+		broker = server.NewBroker()
+		opts = append(opts, handler.WithStatusListener(broker))
+	}
+	processor := handler.NewProcessor(client, opts...)
+
+	switch mode {
+	case "", "processor":
+		lambda.Start(func(ctx context.Context, payload json.RawMessage) (handler.SubscriptionResponse, error) {
+			event, err := processor.DecodeEvent(payload)
+			if err != nil {
+				return handler.SubscriptionResponse{}, lambdaError(err)
+			}
+			resp, err := processor.Handle(ctx, event)
+			return resp, lambdaError(err)
+		})
+	case "initiate":
+		lambda.Start(func(ctx context.Context, payload json.RawMessage) (handler.StepState, error) {
+			event, err := processor.DecodeEvent(payload)
+			if err != nil {
+				return handler.StepState{}, lambdaError(err)
+			}
+			state, err := processor.Initiate(ctx, event)
+			return state, lambdaError(err)
+		})
+	case "check_status":
+		lambda.Start(func(ctx context.Context, state handler.StepState) (handler.StepState, error) {
+			state, err := processor.CheckStatus(ctx, state)
+			return state, lambdaError(err)
+		})
+	case "check_queue":
+		lambda.Start(processor.HandleCheckQueue)
+	case "check_schedule":
+		lambda.Start(processor.HandleScheduledCheck)
+	case "billing_stream":
+		rows, err := billing.NewDynamoStore(dynamodb.NewFromConfig(awsConfig()), strings.TrimSpace(os.Getenv("BILLING_TABLE")))
+		if err != nil {
+			log.Fatalf("failed to configure billing table: %v", err)
+		}
+		stream, err := handler.NewBillingStreamHandler(processor, rows)
+		if err != nil {
+			log.Fatalf("failed to configure billing stream: %v", err)
+		}
+		lambda.Start(stream.Handle)
+	case "s3_ingest":
+		bucket := strings.TrimSpace(os.Getenv("INGEST_BUCKET"))
+		store, err := objectstore.NewS3Store(s3.NewFromConfig(awsConfig()), bucket)
+		if err != nil {
+			log.Fatalf("failed to configure ingest bucket: %v", err)
+		}
+		ingester, err := handler.NewS3IngestHandler(processor, store, bucket)
+		if err != nil {
+			log.Fatalf("failed to configure S3 ingest: %v", err)
+		}
+		lambda.Start(ingester.Handle)
+	case "webhook":
+		webhook := handler.NewWebhookHandler(processor, os.Getenv("PAYPACK_WEBHOOK_SECRET"))
+		lambda.Start(webhookauth.APIGateway(webhook.Handle, webhookChecks()...))
+	case "function_url":
+		auth := handler.FunctionURLAuth{Secret: os.Getenv("FUNCTION_URL_SECRET")}
+		auth.IAM, _ = strconv.ParseBool(os.Getenv("FUNCTION_URL_IAM"))
+		for _, account := range strings.Split(os.Getenv("FUNCTION_URL_ALLOWED_ACCOUNTS"), ",") {
+			if account = strings.TrimSpace(account); account != "" {
+				auth.AllowedAccounts = append(auth.AllowedAccounts, account)
+			}
+		}
+		functionURL, err := handler.NewFunctionURLHandler(processor, auth)
+		if err != nil {
+			log.Fatalf("invalid function url auth: %v", err)
+		}
+		lambda.Start(functionURL.Handle)
+	case "retry":
+		lambda.Start(processor.RunRetries)
+	case "scheduled":
+		lambda.Start(processor.RunScheduled)
+	case "reconcile":
+		lambda.Start(processor.ResumePending)
+	case "balance_check":
+		lambda.Start(processor.CheckBalance)
+	case "server":
+		addr := strings.TrimSpace(os.Getenv("SERVER_ADDR"))
+		if addr == "" {
+			addr = ":8080"
+		}
+		webhook := handler.NewWebhookHandler(processor, os.Getenv("PAYPACK_WEBHOOK_SECRET"))
+		log.Printf("serving on %s", addr)
+		var metricsHandler http.Handler
+		if prom != nil {
+			metricsHandler = prom.Handler()
+		}
+		serverOpts := []server.Option{server.WithStatusStream(broker), server.WithWebhookAuth(webhookChecks()...)}
+		if debug, _ := strconv.ParseBool(os.Getenv("SERVER_DIAGNOSTICS")); debug {
+			serverOpts = append(serverOpts, server.WithDiagnostics())
+		}
+		if eventLog != nil {
+			serverOpts = append(serverOpts, server.WithGraphQL(server.GraphQLSources{Transactions: eventLog}))
+		}
+		log.Fatal(http.ListenAndServe(addr, server.New(processor, webhook, metricsHandler, serverOpts...)))
+	case "grpc":
+		addr := strings.TrimSpace(os.Getenv("GRPC_ADDR"))
+		if addr == "" {
+			addr = ":9090"
+		}
+		log.Printf("serving gRPC on %s", addr)
+		log.Fatal(rpc.ListenAndServe(addr, processor))
+	default:
+		log.Fatalf("unknown HANDLER_MODE %q", mode)
+	}
+}
+
+// newRedactor masks phone numbers in logs unless REDACT_PII=false, also stripping the
+// comma-separated REDACT_METADATA_KEYS.
+func newRedactor() *redact.Redactor {
+	if on, err := strconv.ParseBool(strings.TrimSpace(os.Getenv("REDACT_PII"))); err == nil && !on {
+		return nil
+	}
+	return redact.New(strings.Split(os.Getenv("REDACT_METADATA_KEYS"), ",")...)
+}
+
+// transportOptions reads the PAYPACK_* transport, failover and DNS overrides; unset values
+// keep the defaults.
+func transportOptions() []paypack.ClientOption {
+	var opts []paypack.ClientOption
+	if n, err := strconv.Atoi(strings.TrimSpace(os.Getenv("PAYPACK_MAX_IDLE_CONNS"))); err == nil {
+		opts = append(opts, paypack.WithMaxIdleConns(n))
+	}
+	if d, err := time.ParseDuration(strings.TrimSpace(os.Getenv("PAYPACK_IDLE_CONN_TIMEOUT"))); err == nil {
+		opts = append(opts, paypack.WithIdleConnTimeout(d))
+	}
+	if d, err := time.ParseDuration(strings.TrimSpace(os.Getenv("PAYPACK_TLS_HANDSHAKE_TIMEOUT"))); err == nil {
+		opts = append(opts, paypack.WithTLSHandshakeTimeout(d))
+	}
+	if on, err := strconv.ParseBool(strings.TrimSpace(os.Getenv("PAYPACK_FORCE_HTTP2"))); err == nil {
+		opts = append(opts, paypack.WithForceHTTP2(on))
+	}
+	if raw := strings.TrimSpace(os.Getenv("PAYPACK_FALLBACK_BASE_URLS")); raw != "" {
+		opts = append(opts, paypack.WithFallbackBaseURLs(strings.Split(raw, ",")...))
+	}
+	if d, err := time.ParseDuration(strings.TrimSpace(os.Getenv("PAYPACK_ENDPOINT_COOLDOWN"))); err == nil {
+		opts = append(opts, paypack.WithEndpointCooldown(d))
+	}
+	if raw := strings.TrimSpace(os.Getenv("PAYPACK_DNS_SERVERS")); raw != "" {
+		opts = append(opts, paypack.WithDNSServers(strings.Split(raw, ",")...))
+	}
+	if d, err := time.ParseDuration(strings.TrimSpace(os.Getenv("PAYPACK_DNS_CACHE_TTL"))); err == nil {
+		opts = append(opts, paypack.WithDNSCacheTTL(d))
+	}
+	if raw := strings.TrimSpace(os.Getenv("PAYPACK_PINNED_IPS")); raw != "" {
+		opts = append(opts, paypack.WithPinnedIPs(strings.Split(raw, ",")...))
+	}
+	return opts
+}
+
+// eventVerifiers reads the keys directly-invoked events must be signed with:
+// EVENT_SIGNING_SECRET (HMAC) and EVENT_SIGNING_PUBLIC_KEY (a PEM public key or a path to one).
+func eventVerifiers() []handler.EventVerifier {
+	var verifiers []handler.EventVerifier
+	if secret := strings.TrimSpace(os.Getenv("EVENT_SIGNING_SECRET")); secret != "" {
+		verifiers = append(verifiers, handler.HMACEventVerifier(secret))
+	}
+	if key := strings.TrimSpace(os.Getenv("EVENT_SIGNING_PUBLIC_KEY")); key != "" {
+		data := []byte(key)
+		if !strings.HasPrefix(key, "-----BEGIN") {
+			var err error
+			if data, err = os.ReadFile(key); err != nil {
+				log.Fatalf("failed to read EVENT_SIGNING_PUBLIC_KEY: %v", err)
+			}
+		}
+		verifier, err := handler.PublicKeyEventVerifier(data)
+		if err != nil {
+			log.Fatalf("failed to configure event signatures: %v", err)
+		}
+		verifiers = append(verifiers, verifier)
+	}
+	return verifiers
+}
+
+// parseApprovers reads APPROVERS, a JSON object from approver name to the key that verifies
+// their approvals: a PEM public key, or otherwise an HMAC secret.
+func parseApprovers(raw string) (map[string]handler.EventVerifier, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, errors.New("APPROVERS is required when APPROVAL_TABLE is set")
+	}
+	var keys map[string]string
+	if err := json.Unmarshal([]byte(raw), &keys); err != nil {
+		return nil, fmt.Errorf("APPROVERS must be a JSON object of approver keys: %w", err)
+	}
+	approvers := make(map[string]handler.EventVerifier, len(keys))
+	for name, key := range keys {
+		name, key = strings.TrimSpace(name), strings.TrimSpace(key)
+		if name == "" || key == "" {
+			return nil, errors.New("APPROVERS entries need a name and a key")
+		}
+		if !strings.HasPrefix(key, "-----BEGIN") {
+			approvers[name] = handler.HMACEventVerifier(key)
+			continue
+		}
+		verifier, err := handler.PublicKeyEventVerifier([]byte(key))
+		if err != nil {
+			return nil, fmt.Errorf("approver %s: %w", name, err)
+		}
+		approvers[name] = verifier
+	}
+	return approvers, nil
+}
+
+// callbackCABundle is the PEM bundle only the callback sender trusts, from
+// CALLBACK_CA_BUNDLE or, when CALLBACK_CA_BUNDLE_PARAMETER names one, an SSM parameter read
+// through the Parameters and Secrets extension.
+func callbackCABundle() string {
+	name := strings.TrimSpace(os.Getenv("CALLBACK_CA_BUNDLE_PARAMETER"))
+	if name == "" {
+		return os.Getenv("CALLBACK_CA_BUNDLE")
+	}
+	params, err := ssmparam.NewFromEnv(nil)
+	if err != nil {
+		log.Fatalf("failed to configure parameter lookups: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	bundle, err := params.Get(ctx, name)
+	if err != nil {
+		log.Fatalf("failed to load callback CA bundle: %v", err)
+	}
+	return bundle
+}
+
+// callbackTokenSource returns the OIDC token source for callbacks, or nil when
+// CALLBACK_OIDC_ISSUER and CALLBACK_OIDC_TOKEN_URL are unset.
+func callbackTokenSource() *oidc.TokenSource {
+	cfg := oidc.Config{
+		Issuer:       strings.TrimSpace(os.Getenv("CALLBACK_OIDC_ISSUER")),
+		TokenURL:     strings.TrimSpace(os.Getenv("CALLBACK_OIDC_TOKEN_URL")),
+		ClientID:     strings.TrimSpace(os.Getenv("CALLBACK_OIDC_CLIENT_ID")),
+		ClientSecret: strings.TrimSpace(os.Getenv("CALLBACK_OIDC_CLIENT_SECRET")),
+		Audience:     strings.TrimSpace(os.Getenv("CALLBACK_OIDC_AUDIENCE")),
+		Scopes:       strings.Fields(os.Getenv("CALLBACK_OIDC_SCOPES")),
+	}
+	if cfg.Issuer == "" && cfg.TokenURL == "" {
+		return nil
+	}
+	tlsCfg, err := egress.TLSConfig(os.Getenv("EGRESS_TLS_MIN_VERSION"), os.Getenv("EGRESS_CA_BUNDLE"))
+	if err != nil {
+		log.Fatalf("failed to configure OIDC TLS: %v", err)
+	}
+	var httpClient *http.Client
+	if tlsCfg != nil {
+		httpClient = egress.Client(tlsCfg, 10*time.Second)
+	}
+	tokens, err := oidc.New(cfg, httpClient)
+	if err != nil {
+		log.Fatalf("failed to configure callback OIDC tokens: %v", err)
+	}
+	return tokens
+}
+
+// parseLenientTypes reads a comma-separated LENIENT_EVENT_TYPES list such as
+// "integer_strings,numeric_strings".
+func parseLenientTypes(raw string) (handler.LenientTypes, error) {
+	var types handler.LenientTypes
+	for _, name := range strings.Split(raw, ",") {
+		switch strings.TrimSpace(name) {
+		case "integer_strings":
+			types.IntegerStrings = true
+		case "numeric_strings":
+			types.NumericStrings = true
+		case "boolean_strings":
+			types.BooleanStrings = true
+		case "":
+		default:
+			return handler.LenientTypes{}, fmt.Errorf("unknown variant %q; use integer_strings, numeric_strings or boolean_strings", name)
+		}
+	}
+	return types, nil
+}
+
+// pollBoundsFromEnv reads the limits on per-event poll tuning; ok is false when none is set.
+func pollBoundsFromEnv() (bounds handler.PollBounds, ok bool) {
+	for name, dst := range map[string]*time.Duration{
+		"POLL_MIN_INTERVAL": &bounds.MinInterval,
+		"POLL_MAX_INTERVAL": &bounds.MaxInterval,
+	} {
+		raw := strings.TrimSpace(os.Getenv(name))
+		if raw == "" {
+			continue
+		}
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			log.Fatalf("invalid %s %q: %v", name, raw, err)
+		}
+		*dst, ok = d, true
+	}
+	if strings.TrimSpace(os.Getenv("MAX_POLL_ATTEMPTS")) != "" {
+		log.Fatal("MAX_POLL_ATTEMPTS was replaced by POLL_MAX_ATTEMPTS")
+	}
+	if raw := strings.TrimSpace(os.Getenv("POLL_MAX_ATTEMPTS")); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			log.Fatalf("invalid POLL_MAX_ATTEMPTS %q", raw)
+		}
+		bounds.MaxAttempts, ok = n, true
+	}
+	return bounds, ok
+}
+
+// pollerFromEnv selects the confirmation strategy named by POLL_STRATEGY, or nil for the
+// default adaptive polling.
+func pollerFromEnv(client *paypack.Client, sharedCache txcache.Store) handler.Poller {
+	interval := 5 * time.Second
+	if raw := strings.TrimSpace(os.Getenv("POLL_INTERVAL")); raw != "" {
+		d, err := time.ParseDuration(raw)
+		if err != nil || d <= 0 {
+			log.Fatalf("invalid POLL_INTERVAL %q", raw)
+		}
+		interval = d
+	}
+	strategy := strings.ToLower(strings.TrimSpace(os.Getenv("POLL_STRATEGY")))
+	switch strategy {
+	case "", "adaptive":
+		return nil
+	case "fixed":
+		return handler.FixedIntervalPoller(interval)
+	case "backoff":
+		maxDelay := 30 * time.Second
+		if raw := strings.TrimSpace(os.Getenv("POLL_MAX_DELAY")); raw != "" {
+			d, err := time.ParseDuration(raw)
+			if err != nil {
+				log.Fatalf("invalid POLL_MAX_DELAY %q: %v", raw, err)
+			}
+			maxDelay = d
+		}
+		return handler.BackoffPoller(interval, maxDelay)
+	case "events":
+		return handler.EventsPoller(client, interval)
+	case "webhook":
+		if sharedCache == nil {
+			log.Fatal("POLL_STRATEGY=webhook needs TXN_CACHE_REDIS_ADDR, shared with the webhook function")
+		}
+		fallback := time.Minute
+		if raw := strings.TrimSpace(os.Getenv("WEBHOOK_WAIT_FALLBACK")); raw != "" {
+			d, err := time.ParseDuration(raw)
+			if err != nil {
+				log.Fatalf("invalid WEBHOOK_WAIT_FALLBACK %q: %v", raw, err)
+			}
+			fallback = d
+		}
+		return handler.WebhookWaitPoller(sharedCache, interval, fallback)
+	default:
+		log.Fatalf("unsupported POLL_STRATEGY %q", strategy)
+		return nil
+	}
+}
+
+// warmToken authorizes during init. A failure is only logged: the first payment will retry.
+func warmToken(client *paypack.Client) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.WarmToken(ctx); err != nil {
+		log.Printf("eager paypack authorization failed; deferring to first request: %v", err)
+	}
+}
+
+// lambdaError reports coded handler errors with their ErrorCode as the Lambda errorType, so
+// synchronous invokers can branch on it.
+func lambdaError(err error) error {
+	if code := handler.CodeOf(err); code != "" {
+		return messages.InvokeResponse_Error{Message: err.Error(), Type: string(code)}
+	}
+	return err
+}
+
+// newMetrics selects the METRICS_BACKEND. Server mode defaults to Prometheus, which is also
+// returned so /metrics can serve it; Lambda modes default to discarding measurements.
+func newMetrics(mode string) (metrics.Metrics, *metrics.Prometheus, error) {
+	backend := strings.TrimSpace(os.Getenv("METRICS_BACKEND"))
+	if backend == "" && mode == "server" {
+		backend = "prometheus"
+	}
+	namespace := strings.TrimSpace(os.Getenv("METRICS_NAMESPACE"))
+	if namespace == "" {
+		namespace = "paypack_lambda"
+	}
+
+	switch backend {
+	case "", "none":
+		return metrics.Nop{}, nil, nil
+	case "prometheus":
+		prom := metrics.NewPrometheus(namespace)
+		return prom, prom, nil
+	case "emf", "cloudwatch":
+		return metrics.NewEMF(namespace, nil), nil, nil
+	case "statsd":
+		statsd, err := metrics.NewStatsD(strings.TrimSpace(os.Getenv("STATSD_ADDR")), namespace)
+		return statsd, nil, err
+	case "otlp":
+		service := strings.TrimSpace(os.Getenv("OTEL_SERVICE_NAME"))
+		if service == "" {
+			service = "paypack-lambda"
+		}
+		otlp, err := metrics.NewOTLP(os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"), service, nil)
+		return otlp, nil, err
+	default:
+		return nil, nil, fmt.Errorf("unknown METRICS_BACKEND %q", backend)
+	}
+}
+
+var (
+	awsOnce sync.Once
+	awsCfg  aws.Config
+)
+
+// awsConfig loads the default AWS configuration once, on first use.
+func awsConfig() aws.Config {
+	awsOnce.Do(func() {
+		cfg, err := config.LoadDefaultConfig(context.Background())
+		if err != nil {
+			log.Fatalf("failed to load AWS configuration: %v", err)
+		}
+		awsCfg = cfg
+	})
+	return awsCfg
+}
+
+func newFanoutSender(prefsJSON string, callback handler.CallbackSender, meter metrics.Metrics, filters map[handler.Channel]handler.FieldFilter) (*handler.FanoutSender, error) {
+	prefs, err := handler.ParsePreferences([]byte(prefsJSON))
+	if err != nil {
+		return nil, err
+	}
+
+	senders := map[handler.Channel]handler.CallbackSender{handler.ChannelCallback: callback}
+	if smsURL := strings.TrimSpace(os.Getenv("SMS_API_URL")); smsURL != "" {
+		sms, err := handler.NewSMSSender(smsURL, os.Getenv("SMS_API_TOKEN"), os.Getenv("SMS_SENDER_ID"), nil)
+		if err != nil {
+			return nil, err
+		}
+		senders[handler.ChannelSMS] = handler.MeteredSender(handler.ChannelSMS, sms, meter)
+	}
+	if addr := strings.TrimSpace(os.Getenv("SMTP_ADDR")); addr != "" {
+		var auth smtp.Auth
+		if user := os.Getenv("SMTP_USERNAME"); user != "" {
+			host, _, _ := strings.Cut(addr, ":")
+			auth = smtp.PlainAuth("", user, os.Getenv("SMTP_PASSWORD"), host)
+		}
+		email, err := handler.NewEmailSender(addr, os.Getenv("SMTP_FROM"), auth)
+		if err != nil {
+			return nil, err
+		}
+		senders[handler.ChannelEmail] = handler.MeteredSender(handler.ChannelEmail, email, meter)
+	}
+	if webhookURL, token := os.Getenv("SLACK_WEBHOOK_URL"), os.Getenv("SLACK_BOT_TOKEN"); strings.TrimSpace(webhookURL+token) != "" {
+		filter, err := operationsFilter("SLACK")
+		if err != nil {
+			return nil, err
+		}
+		slack, err := handler.NewSlackSender(handler.SlackDestination{
+			WebhookURL: webhookURL,
+			Token:      token,
+			Channel:    os.Getenv("SLACK_CHANNEL"),
+		}, filter, nil)
+		if err != nil {
+			return nil, err
+		}
+		senders[handler.ChannelSlack] = handler.MeteredSender(handler.ChannelSlack, slack, meter)
+	}
+	if token := strings.TrimSpace(os.Getenv("TELEGRAM_BOT_TOKEN")); token != "" {
+		filter, err := operationsFilter("TELEGRAM")
+		if err != nil {
+			return nil, err
+		}
+		tmpl, err := operationsTemplate("TELEGRAM_TEMPLATE")
+		if err != nil {
+			return nil, err
+		}
+		telegram, err := handler.NewTelegramSender(token, os.Getenv("TELEGRAM_CHAT_ID"), filter, tmpl, nil)
+		if err != nil {
+			return nil, err
+		}
+		senders[handler.ChannelTelegram] = handler.MeteredSender(handler.ChannelTelegram, telegram, meter)
+	}
+	if webhookURL := strings.TrimSpace(os.Getenv("DISCORD_WEBHOOK_URL")); webhookURL != "" {
+		filter, err := operationsFilter("DISCORD")
+		if err != nil {
+			return nil, err
+		}
+		tmpl, err := operationsTemplate("DISCORD_TEMPLATE")
+		if err != nil {
+			return nil, err
+		}
+		discord, err := handler.NewDiscordSender(webhookURL, filter, tmpl, nil)
+		if err != nil {
+			return nil, err
+		}
+		senders[handler.ChannelDiscord] = handler.MeteredSender(handler.ChannelDiscord, discord, meter)
+	}
+	if bus := strings.TrimSpace(os.Getenv("CALLBACK_EVENT_BUS")); bus != "" {
+		version := strings.TrimSpace(os.Getenv("CALLBACK_API_VERSION"))
+		if strings.EqualFold(version, "latest") {
+			version = ""
+		}
+		bridge, err := handler.NewEventBridgeSender(eventbridge.NewFromConfig(awsConfig()), bus, strings.TrimSpace(os.Getenv("CLOUDEVENTS_SOURCE")), version)
+		if err != nil {
+			return nil, err
+		}
+		senders[handler.ChannelEventBridge] = handler.MeteredSender(handler.ChannelEventBridge, bridge, meter)
+	}
+	if endpoint := strings.TrimSpace(os.Getenv("APPSYNC_ENDPOINT")); endpoint != "" {
+		cfg := awsConfig()
+		client, err := appsync.New(endpoint, cfg.Region, strings.TrimSpace(os.Getenv("APPSYNC_API_KEY")), cfg.Credentials, nil)
+		if err != nil {
+			return nil, err
+		}
+		push, err := handler.NewAppSyncSender(client, os.Getenv("APPSYNC_MUTATION"))
+		if err != nil {
+			return nil, err
+		}
+		senders[handler.ChannelAppSync] = handler.MeteredSender(handler.ChannelAppSync, push, meter)
+	}
+
+	for ch, sender := range senders {
+		if ch != handler.ChannelCallback {
+			senders[ch] = filterFields(ch, sender, filters)
+		}
+	}
+	return handler.NewFanoutSender(prefs, senders)
+}
+
+// keyFieldFilters sets the CALLBACK_NUMBER_HMAC_KEY on every filter; hash_numbers needs it.
+func keyFieldFilters(filters map[handler.Channel]handler.FieldFilter, key string) error {
+	for ch, filter := range filters {
+		if filter.HashNumbers && key == "" {
+			return fmt.Errorf("%s: hash_numbers requires CALLBACK_NUMBER_HMAC_KEY", ch)
+		}
+		if key != "" {
+			filter.NumberKey = []byte(key)
+			filters[ch] = filter
+		}
+	}
+	return nil
+}
+
+// filterFields applies the CALLBACK_FIELD_FILTERS entry for ch, if any, to sender.
+func filterFields(ch handler.Channel, sender handler.CallbackSender, filters map[handler.Channel]handler.FieldFilter) handler.CallbackSender {
+	if filter, ok := filters[ch]; ok {
+		return handler.FilteredSender(sender, filter)
+	}
+	return sender
+}
+
+// webhookChecks builds the inbound webhook checks from WEBHOOK_ALLOWED_IPS and
+// WEBHOOK_SHARED_SECRET; PAYPACK_WEBHOOK_SECRET is verified by the webhook handler itself.
+func webhookChecks() []webhookauth.Check {
+	var checks []webhookauth.Check
+	if ips := strings.TrimSpace(os.Getenv("WEBHOOK_ALLOWED_IPS")); ips != "" {
+		allow, err := webhookauth.AllowIPs(strings.Split(ips, ",")...)
+		if err != nil {
+			log.Fatalf("failed to configure webhook auth: %v", err)
+		}
+		checks = append(checks, allow)
+	}
+	if secret := os.Getenv("WEBHOOK_SHARED_SECRET"); secret != "" {
+		checks = append(checks, webhookauth.SharedSecret(strings.TrimSpace(os.Getenv("WEBHOOK_SHARED_SECRET_HEADER")), secret))
+	}
+	return checks
+}
+
+// operationsFilter reads <prefix>_NOTIFY_FAILURES (default true), <prefix>_MIN_AMOUNT and
+// the comma-separated <prefix>_CLIENTS allow-list.
+func operationsFilter(prefix string) (handler.OperationsFilter, error) {
+	filter := handler.OperationsFilter{Failures: true}
+	if raw := strings.TrimSpace(os.Getenv(prefix + "_NOTIFY_FAILURES")); raw != "" {
+		failures, err := strconv.ParseBool(raw)
+		if err != nil {
+			return filter, fmt.Errorf("invalid %s_NOTIFY_FAILURES %q: %w", prefix, raw, err)
+		}
+		filter.Failures = failures
+	}
+	if raw := strings.TrimSpace(os.Getenv(prefix + "_MIN_AMOUNT")); raw != "" {
+		amount, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return filter, fmt.Errorf("invalid %s_MIN_AMOUNT %q: %w", prefix, raw, err)
+		}
+		filter.MinAmount = amount
+	}
+	for _, c := range strings.Split(os.Getenv(prefix+"_CLIENTS"), ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			filter.Clients = append(filter.Clients, c)
+		}
+	}
+	return filter, nil
+}
+
+// operationsTemplate parses the message template in env, or returns nil for the default.
+func operationsTemplate(env string) (*template.Template, error) {
+	text := os.Getenv(env)
+	if strings.TrimSpace(text) == "" {
+		return nil, nil
+	}
+	return handler.ParseOperationsTemplate(text)
+}
+
+func newReceiptIssuer(bucket string) (*receipt.Issuer, error) {
+	store, err := objectstore.NewS3Store(s3.NewFromConfig(awsConfig()), bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []receipt.Option{receipt.WithFormat(receipt.Format(strings.TrimSpace(os.Getenv("RECEIPT_FORMAT"))))}
+	if ttl, err := time.ParseDuration(strings.TrimSpace(os.Getenv("RECEIPT_URL_TTL"))); err == nil {
+		opts = append(opts, receipt.WithURLTTL(ttl))
+	}
 
-	lambda.Start(processor.Handle)
+	return receipt.NewIssuer(store, opts...)
 }
@@ -0,0 +1,124 @@
+// Command loadtest drives the processor against an in-process paypacktest server and reports
+// throughput, latency percentiles and outcome counts.
+//
+//	go run ./cmd/loadtest -n 2000 -c 100 -latency 40ms -jitter 80ms -error-rate 0.02
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/berniyo/paypack-lambda/internal/chaos"
+	"github.com/berniyo/paypack-lambda/internal/handler"
+	"github.com/berniyo/paypack-lambda/internal/workpool"
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+	"github.com/berniyo/paypack-lambda/pkg/paypack/paypacktest"
+)
+
+type result struct {
+	latency time.Duration
+	status  string
+}
+
+func main() {
+	var (
+		requests     = flag.Int("n", 1000, "number of cash-in events")
+		concurrency  = flag.Int("c", 50, "events processed concurrently")
+		latency      = flag.Duration("latency", 20*time.Millisecond, "base Paypack response latency")
+		jitter       = flag.Duration("jitter", 30*time.Millisecond, "extra random latency added per response")
+		errorRate    = flag.Float64("error-rate", 0, "fraction of Paypack calls answered with 503")
+		confirmAfter = flag.Duration("confirm-after", 200*time.Millisecond, "delay before a cash-in is findable")
+		failRate     = flag.Float64("fail-rate", 0.05, "fraction of cash-ins resolved as failed")
+		poll         = flag.Duration("poll", 100*time.Millisecond, "processor poll interval")
+		timeout      = flag.Duration("timeout", 30*time.Second, "processor confirmation timeout")
+		dropRate     = flag.Float64("drop-rate", 0, "fraction of Paypack calls failed with a transport error")
+		malformed    = flag.Float64("malformed-rate", 0, "fraction of Paypack responses truncated mid-JSON")
+		seed         = flag.Uint64("seed", 0, "random seed for the fake server and fault injector (0 = random)")
+	)
+	flag.Parse()
+
+	srv := paypacktest.NewServer(paypacktest.Config{
+		Latency:      *latency,
+		Jitter:       *jitter,
+		ErrorRate:    *errorRate,
+		ConfirmAfter: *confirmAfter,
+		FailRate:     *failRate,
+		Seed:         *seed,
+	})
+	defer srv.Close()
+
+	faults := chaos.New(chaos.Faults{DropRate: *dropRate, MalformedRate: *malformed, Seed: *seed})
+	client, err := paypack.NewClient(srv.URL, paypacktest.AppID, paypacktest.AppSecret, &http.Client{Transport: faults.Transport(nil)})
+	if err != nil {
+		log.Fatalf("build client: %v", err)
+	}
+
+	p := handler.NewProcessor(client,
+		handler.WithPollInterval(*poll),
+		handler.WithTimeout(*timeout),
+		handler.WithLogger(log.New(io.Discard, "", 0)),
+	)
+
+	events := make([]handler.SubscriptionEvent, *requests)
+	for i := range events {
+		events[i] = handler.SubscriptionEvent{Number: fmt.Sprintf("0788%06d", i), Amount: 1000}
+	}
+
+	start := time.Now()
+	results := workpool.Map(context.Background(), *concurrency, events, func(ctx context.Context, event handler.SubscriptionEvent) result {
+		began := time.Now()
+		resp, err := p.Handle(ctx, event)
+		status := resp.Status
+		if err != nil {
+			status = "error:" + string(handler.CodeOf(err))
+		}
+		return result{latency: time.Since(began), status: status}
+	})
+	elapsed := time.Since(start)
+
+	report(os.Stdout, results, elapsed, srv, faults.Stats())
+}
+
+func report(w io.Writer, results []result, elapsed time.Duration, srv *paypacktest.Server, injected chaos.Stats) {
+	latencies := make([]time.Duration, len(results))
+	statuses := make(map[string]int)
+	for i, r := range results {
+		latencies[i] = r.latency
+		statuses[r.status]++
+	}
+	slices.Sort(latencies)
+
+	fmt.Fprintf(w, "events:      %d in %s\n", len(results), elapsed.Round(time.Millisecond))
+	fmt.Fprintf(w, "throughput:  %.1f events/s\n", float64(len(results))/elapsed.Seconds())
+	fmt.Fprintf(w, "latency:     p50=%s p90=%s p99=%s max=%s\n",
+		percentile(latencies, 50), percentile(latencies, 90), percentile(latencies, 99), percentile(latencies, 100))
+	fmt.Fprintf(w, "paypack:     %d requests, %d injected errors, %d dropped, %d malformed\n",
+		srv.Requests(), srv.InjectedErrors(), injected.Dropped, injected.Malformed)
+
+	keys := make([]string, 0, len(statuses))
+	for k := range statuses {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(w, "  %-24s %d\n", k, statuses[k])
+	}
+}
+
+// percentile reads the q-th percentile from sorted latencies.
+func percentile(sorted []time.Duration, q int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := (len(sorted)*q + 99) / 100
+	i = min(max(i-1, 0), len(sorted)-1)
+	return sorted[i].Round(100 * time.Microsecond)
+}
@@ -0,0 +1,27 @@
+package alert
+
+import (
+	"context"
+	"time"
+)
+
+// Alert is an operational condition that needs a human to look at it.
+type Alert struct {
+	Kind       string         `json:"kind"`
+	Message    string         `json:"message"`
+	Details    map[string]any `json:"details,omitempty"`
+	OccurredAt time.Time      `json:"occurred_at"`
+}
+
+// Sender delivers alerts to an on-call channel.
+type Sender interface {
+	Send(ctx context.Context, a Alert) error
+}
+
+// SenderFunc adapts a function to the Sender interface.
+type SenderFunc func(ctx context.Context, a Alert) error
+
+// Send calls f(ctx, a).
+func (f SenderFunc) Send(ctx context.Context, a Alert) error {
+	return f(ctx, a)
+}
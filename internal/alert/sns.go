@@ -0,0 +1,56 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sns/types"
+
+	"github.com/berniyo/paypack-lambda/internal/tracing"
+)
+
+// SNSSender publishes alerts as JSON to an SNS topic, with the kind as a message attribute
+// for subscription filter policies.
+type SNSSender struct {
+	client *sns.Client
+	topic  string
+}
+
+// NewSNSSender builds a sender publishing to topicARN.
+func NewSNSSender(client *sns.Client, topicARN string) (*SNSSender, error) {
+	if client == nil {
+		return nil, errors.New("sns client is required")
+	}
+	if topicARN == "" {
+		return nil, errors.New("topic arn is required")
+	}
+	return &SNSSender{client: client, topic: topicARN}, nil
+}
+
+// Send publishes a.
+func (s *SNSSender) Send(ctx context.Context, a Alert) error {
+	body, err := json.Marshal(a)
+	if err != nil {
+		return fmt.Errorf("encode alert: %w", err)
+	}
+	attrs := map[string]types.MessageAttributeValue{
+		"kind": {DataType: aws.String("String"), StringValue: aws.String(a.Kind)},
+	}
+	tracing.Inject(ctx, func(key, value string) {
+		attrs[key] = types.MessageAttributeValue{DataType: aws.String("String"), StringValue: aws.String(value)}
+	})
+	_, err = s.client.Publish(ctx, &sns.PublishInput{
+		TopicArn:          aws.String(s.topic),
+		Subject:           aws.String("paypack-lambda: " + a.Kind),
+		Message:           aws.String(string(body)),
+		MessageAttributes: attrs,
+	})
+	if err != nil {
+		return fmt.Errorf("publish alert: %w", err)
+	}
+	return nil
+}
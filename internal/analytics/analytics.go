@@ -0,0 +1,47 @@
+package analytics
+
+import (
+	"context"
+	"time"
+)
+
+// Type names an analytics event.
+type Type string
+
+const (
+	PaymentInitiated Type = "payment_initiated"
+	PaymentConfirmed Type = "payment_confirmed"
+	PaymentFailed    Type = "payment_failed"
+)
+
+// Event is a flat, BI-friendly record of one step in a payment. It deliberately omits the
+// customer's phone number.
+type Event struct {
+	ID             string    `json:"id"`
+	Type           Type      `json:"type"`
+	Ref            string    `json:"ref"`
+	Status         string    `json:"status,omitempty"`
+	Reason         string    `json:"reason,omitempty"`
+	Amount         float64   `json:"amount"`
+	Currency       string    `json:"currency,omitempty"`
+	Fee            float64   `json:"fee,omitempty"`
+	Client         string    `json:"client,omitempty"`
+	SubscriptionID string    `json:"subscription_id,omitempty"`
+	RetryAttempt   int       `json:"retry_attempt,omitempty"`
+	OccurredAt     time.Time `json:"occurred_at"`
+	TraceParent    string    `json:"traceparent,omitempty"`
+	TraceState     string    `json:"tracestate,omitempty"`
+}
+
+// Sink receives analytics events.
+type Sink interface {
+	Emit(ctx context.Context, event Event) error
+}
+
+// SinkFunc adapts a function to the Sink interface.
+type SinkFunc func(ctx context.Context, event Event) error
+
+// Emit calls f(ctx, event).
+func (f SinkFunc) Emit(ctx context.Context, event Event) error {
+	return f(ctx, event)
+}
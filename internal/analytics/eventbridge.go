@@ -0,0 +1,62 @@
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+)
+
+// DefaultSource is the EventBridge source used when none is configured.
+const DefaultSource = "paypack-lambda"
+
+// EventBridgeSink publishes each event to an EventBridge bus with the event type as its
+// detail-type, so rules can route payment_failed separately from the rest.
+type EventBridgeSink struct {
+	client *eventbridge.Client
+	bus    string
+	source string
+}
+
+// NewEventBridgeSink builds a sink publishing to bus. An empty source uses DefaultSource.
+func NewEventBridgeSink(client *eventbridge.Client, bus, source string) (*EventBridgeSink, error) {
+	if client == nil {
+		return nil, errors.New("eventbridge client is required")
+	}
+	if bus == "" {
+		return nil, errors.New("event bus is required")
+	}
+	if source == "" {
+		source = DefaultSource
+	}
+	return &EventBridgeSink{client: client, bus: bus, source: source}, nil
+}
+
+// Emit publishes event to the bus.
+func (e *EventBridgeSink) Emit(ctx context.Context, event Event) error {
+	detail, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("encode analytics event: %w", err)
+	}
+	out, err := e.client.PutEvents(ctx, &eventbridge.PutEventsInput{
+		Entries: []types.PutEventsRequestEntry{{
+			EventBusName: aws.String(e.bus),
+			Source:       aws.String(e.source),
+			DetailType:   aws.String(string(event.Type)),
+			Detail:       aws.String(string(detail)),
+			Time:         aws.Time(event.OccurredAt),
+		}},
+	})
+	if err != nil {
+		return fmt.Errorf("put eventbridge event: %w", err)
+	}
+	if out.FailedEntryCount > 0 && len(out.Entries) > 0 {
+		entry := out.Entries[0]
+		return fmt.Errorf("eventbridge rejected event: %s: %s", aws.ToString(entry.ErrorCode), aws.ToString(entry.ErrorMessage))
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/firehose"
+	"github.com/aws/aws-sdk-go-v2/service/firehose/types"
+)
+
+// FirehoseSink writes each event as a newline-terminated JSON record to a Kinesis Data
+// Firehose delivery stream, so S3 destinations end up with NDJSON objects.
+type FirehoseSink struct {
+	client *firehose.Client
+	stream string
+}
+
+// NewFirehoseSink builds a sink writing to stream.
+func NewFirehoseSink(client *firehose.Client, stream string) (*FirehoseSink, error) {
+	if client == nil {
+		return nil, errors.New("firehose client is required")
+	}
+	if stream == "" {
+		return nil, errors.New("delivery stream is required")
+	}
+	return &FirehoseSink{client: client, stream: stream}, nil
+}
+
+// Emit puts event on the delivery stream.
+func (f *FirehoseSink) Emit(ctx context.Context, event Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("encode analytics event: %w", err)
+	}
+	_, err = f.client.PutRecord(ctx, &firehose.PutRecordInput{
+		DeliveryStreamName: aws.String(f.stream),
+		Record:             &types.Record{Data: append(data, '\n')},
+	})
+	if err != nil {
+		return fmt.Errorf("put firehose record: %w", err)
+	}
+	return nil
+}
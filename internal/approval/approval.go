@@ -0,0 +1,154 @@
+package approval
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrNotFound marks a lookup for an approval request that does not exist.
+	ErrNotFound = errors.New("approval request not found")
+	// ErrNotPending is returned when resolving a request that was already approved, rejected or expired.
+	ErrNotPending = errors.New("approval request is not pending")
+)
+
+// Status tracks an approval request's decision.
+type Status string
+
+const (
+	StatusPending  Status = "pending"
+	StatusApproved Status = "approved"
+	StatusRejected Status = "rejected"
+)
+
+// Request is a held payment instruction awaiting a second approval.
+type Request struct {
+	ID          string          `json:"id"`
+	Amount      float64         `json:"amount"`
+	Payload     json.RawMessage `json:"payload"`
+	Status      Status          `json:"status"`
+	Approver    string          `json:"approver,omitempty"`
+	RequestedAt time.Time       `json:"requested_at"`
+	ExpiresAt   time.Time       `json:"expires_at,omitempty"`
+	ResolvedAt  time.Time       `json:"resolved_at,omitempty"`
+}
+
+// Expired reports whether the request can no longer be approved at now.
+func (r *Request) Expired(now time.Time) bool {
+	return !r.ExpiresAt.IsZero() && now.After(r.ExpiresAt)
+}
+
+// Store persists approval requests.
+type Store interface {
+	Create(ctx context.Context, req Request) error
+	Get(ctx context.Context, id string) (*Request, error)
+	// Resolve atomically moves a pending request to status, returning ErrNotPending if another
+	// caller resolved it first. This is what prevents a double approval from charging twice.
+	Resolve(ctx context.Context, id string, status Status, approver string, at time.Time) (*Request, error)
+}
+
+// Scanner is implemented by stores that can walk every request, as erasing a customer's data
+// needs. Requests are not returned in any particular order.
+type Scanner interface {
+	Scan(ctx context.Context, fn func(Request) error) error
+}
+
+// Rewriter is implemented by stores that can replace a request's payload in place, returning
+// ErrNotFound when there is no request with its ID. Nothing else about the request changes.
+type Rewriter interface {
+	Rewrite(ctx context.Context, req Request) error
+}
+
+// MemoryStore holds approval requests in memory, for tests only: approvers resolve a request
+// in a later invocation, usually in another container.
+type MemoryStore struct {
+	mu   sync.Mutex
+	reqs map[string]Request
+}
+
+// NewMemoryStore builds an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{reqs: make(map[string]Request)}
+}
+
+// Create stores a new request.
+func (m *MemoryStore) Create(ctx context.Context, req Request) error {
+	if req.ID == "" {
+		return errors.New("approval id is required")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.reqs[req.ID]; ok {
+		return errors.New("approval request already exists")
+	}
+	m.reqs[req.ID] = req
+	return nil
+}
+
+// Get returns a copy of the stored request or ErrNotFound.
+func (m *MemoryStore) Get(ctx context.Context, id string) (*Request, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	req, ok := m.reqs[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &req, nil
+}
+
+// Resolve moves a pending request to status.
+func (m *MemoryStore) Resolve(ctx context.Context, id string, status Status, approver string, at time.Time) (*Request, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	req, ok := m.reqs[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if req.Status != StatusPending {
+		return nil, ErrNotPending
+	}
+
+	req.Status = status
+	req.Approver = approver
+	req.ResolvedAt = at
+	m.reqs[id] = req
+	return &req, nil
+}
+
+// Scan calls fn for every request.
+func (m *MemoryStore) Scan(ctx context.Context, fn func(Request) error) error {
+	m.mu.Lock()
+	reqs := make([]Request, 0, len(m.reqs))
+	for _, req := range m.reqs {
+		reqs = append(reqs, req)
+	}
+	m.mu.Unlock()
+
+	for _, req := range reqs {
+		if err := fn(req); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Rewrite replaces the payload of the stored request with req's ID.
+func (m *MemoryStore) Rewrite(ctx context.Context, req Request) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.reqs[req.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	stored.Payload = append(json.RawMessage(nil), req.Payload...)
+	m.reqs[req.ID] = stored
+	return nil
+}
@@ -0,0 +1,179 @@
+package approval
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DynamoStore is a Store backed by a DynamoDB table with partition key "id" (S).
+type DynamoStore struct {
+	client *dynamodb.Client
+	table  string
+}
+
+type dynamoRequest struct {
+	ID          string    `dynamodbav:"id"`
+	Amount      float64   `dynamodbav:"amount"`
+	Payload     string    `dynamodbav:"payload"`
+	Status      Status    `dynamodbav:"status"`
+	Approver    string    `dynamodbav:"approver,omitempty"`
+	RequestedAt time.Time `dynamodbav:"requested_at"`
+	ExpiresAt   time.Time `dynamodbav:"expires_at"`
+	ResolvedAt  time.Time `dynamodbav:"resolved_at"`
+}
+
+// NewDynamoStore builds a Store writing to table.
+func NewDynamoStore(client *dynamodb.Client, table string) (*DynamoStore, error) {
+	if client == nil {
+		return nil, errors.New("dynamodb client is required")
+	}
+	if table == "" {
+		return nil, errors.New("table is required")
+	}
+	return &DynamoStore{client: client, table: table}, nil
+}
+
+// Create stores a new request, failing if the id already exists.
+func (d *DynamoStore) Create(ctx context.Context, req Request) error {
+	item, err := attributevalue.MarshalMap(dynamoRequest{
+		ID:          req.ID,
+		Amount:      req.Amount,
+		Payload:     string(req.Payload),
+		Status:      req.Status,
+		Approver:    req.Approver,
+		RequestedAt: req.RequestedAt,
+		ExpiresAt:   req.ExpiresAt,
+		ResolvedAt:  req.ResolvedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("encode approval request: %w", err)
+	}
+
+	_, err = d.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(d.table),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(id)"),
+	})
+	if err != nil {
+		return fmt.Errorf("put approval request: %w", err)
+	}
+	return nil
+}
+
+// Get returns the stored request or ErrNotFound.
+func (d *DynamoStore) Get(ctx context.Context, id string) (*Request, error) {
+	out, err := d.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(d.table),
+		Key:            map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: id}},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get approval request: %w", err)
+	}
+	if out.Item == nil {
+		return nil, ErrNotFound
+	}
+	return decodeRequest(out.Item)
+}
+
+// Resolve moves a pending request to status using a conditional update.
+func (d *DynamoStore) Resolve(ctx context.Context, id string, status Status, approver string, at time.Time) (*Request, error) {
+	resolvedAt, err := attributevalue.Marshal(at)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := d.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:           aws.String(d.table),
+		Key:                 map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: id}},
+		UpdateExpression:    aws.String("SET #status = :status, approver = :approver, resolved_at = :at"),
+		ConditionExpression: aws.String("#status = :pending"),
+		ExpressionAttributeNames: map[string]string{
+			"#status": "status",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":status":   &types.AttributeValueMemberS{Value: string(status)},
+			":approver": &types.AttributeValueMemberS{Value: approver},
+			":at":       resolvedAt,
+			":pending":  &types.AttributeValueMemberS{Value: string(StatusPending)},
+		},
+		ReturnValues: types.ReturnValueAllNew,
+	})
+	if err != nil {
+		var condErr *types.ConditionalCheckFailedException
+		if errors.As(err, &condErr) {
+			if _, getErr := d.Get(ctx, id); errors.Is(getErr, ErrNotFound) {
+				return nil, ErrNotFound
+			}
+			return nil, ErrNotPending
+		}
+		return nil, fmt.Errorf("resolve approval request: %w", err)
+	}
+	return decodeRequest(out.Attributes)
+}
+
+// Scan calls fn for every request.
+func (d *DynamoStore) Scan(ctx context.Context, fn func(Request) error) error {
+	paginator := dynamodb.NewScanPaginator(d.client, &dynamodb.ScanInput{TableName: aws.String(d.table)})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("scan approval requests: %w", err)
+		}
+		for _, item := range page.Items {
+			req, err := decodeRequest(item)
+			if err != nil {
+				return err
+			}
+			if err := fn(*req); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Rewrite replaces the payload of an existing request.
+func (d *DynamoStore) Rewrite(ctx context.Context, req Request) error {
+	_, err := d.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:           aws.String(d.table),
+		Key:                 map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: req.ID}},
+		UpdateExpression:    aws.String("SET payload = :payload"),
+		ConditionExpression: aws.String("attribute_exists(id)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":payload": &types.AttributeValueMemberS{Value: string(req.Payload)},
+		},
+	})
+	var missing *types.ConditionalCheckFailedException
+	if errors.As(err, &missing) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("rewrite approval request: %w", err)
+	}
+	return nil
+}
+
+func decodeRequest(item map[string]types.AttributeValue) (*Request, error) {
+	var rec dynamoRequest
+	if err := attributevalue.UnmarshalMap(item, &rec); err != nil {
+		return nil, fmt.Errorf("decode approval request: %w", err)
+	}
+	return &Request{
+		ID:          rec.ID,
+		Amount:      rec.Amount,
+		Payload:     []byte(rec.Payload),
+		Status:      rec.Status,
+		Approver:    rec.Approver,
+		RequestedAt: rec.RequestedAt,
+		ExpiresAt:   rec.ExpiresAt,
+		ResolvedAt:  rec.ResolvedAt,
+	}, nil
+}
@@ -0,0 +1,59 @@
+package appsync
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMutateSignsRequests(t *testing.T) {
+	var gotAuth string
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		body, _ := io.ReadAll(r.Body)
+		require.NoError(t, json.Unmarshal(body, &got))
+		w.Write([]byte(`{"data":{"publishPaymentOutcome":{"ref":"abc"}}}`))
+	}))
+	defer srv.Close()
+
+	creds := credentials.NewStaticCredentialsProvider("AKID", "SECRET", "")
+	client, err := New(srv.URL+"/graphql", "eu-west-1", "", creds, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, client.Mutate(context.Background(), "mutation M($input: In!) { m(input: $input) { ref } }", map[string]any{"input": map[string]any{"ref": "abc"}}))
+	require.True(t, strings.HasPrefix(gotAuth, "AWS4-HMAC-SHA256 Credential=AKID/"), gotAuth)
+	require.Contains(t, gotAuth, "/eu-west-1/appsync/aws4_request")
+	require.Equal(t, map[string]any{"ref": "abc"}, got["variables"].(map[string]any)["input"])
+}
+
+func TestMutateWithAPIKeyReportsGraphQLErrors(t *testing.T) {
+	var gotKey string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotKey = r.Header.Get("x-api-key")
+		w.Write([]byte(`{"data":null,"errors":[{"errorType":"Unauthorized","message":"Not Authorized to access publishPaymentOutcome"}]}`))
+	}))
+	defer srv.Close()
+
+	client, err := New(srv.URL, "", "da2-key", nil, nil)
+	require.NoError(t, err)
+	err = client.Mutate(context.Background(), "mutation { m }", nil)
+	require.EqualError(t, err, "appsync mutation failed: Unauthorized: Not Authorized to access publishPaymentOutcome")
+	require.Equal(t, "da2-key", gotKey)
+}
+
+func TestNewValidatesConfiguration(t *testing.T) {
+	_, err := New("", "eu-west-1", "key", nil, nil)
+	require.Error(t, err)
+	_, err = New("https://example.com/graphql", "eu-west-1", "", nil, nil)
+	require.Error(t, err)
+	_, err = New("https://example.com/graphql", "", "", credentials.NewStaticCredentialsProvider("a", "b", ""), nil)
+	require.Error(t, err)
+}
@@ -0,0 +1,201 @@
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrConflict is returned by Store.Put when a record with the same sequence already exists.
+var ErrConflict = errors.New("audit sequence already written")
+
+// maxAppendAttempts bounds how often Append retries after losing a race for the next sequence.
+const maxAppendAttempts = 5
+
+// Record is one entry in the hash chain. Hash covers every other field, including PrevHash,
+// so altering or removing any record breaks every hash after it.
+type Record struct {
+	Seq       int64           `json:"seq"`
+	Action    string          `json:"action"`
+	Ref       string          `json:"ref,omitempty"`
+	Payload   json.RawMessage `json:"payload,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+	PrevHash  string          `json:"prev_hash"`
+	Hash      string          `json:"hash"`
+}
+
+// ComputeHash returns the hex SHA-256 over the record's content and PrevHash.
+func (r Record) ComputeHash() string {
+	h := sha256.New()
+	for _, part := range []string{
+		r.PrevHash,
+		strconv.FormatInt(r.Seq, 10),
+		r.Action,
+		r.Ref,
+		r.CreatedAt.UTC().Format(time.RFC3339Nano),
+		string(r.Payload),
+	} {
+		h.Write([]byte(strconv.Itoa(len(part))))
+		h.Write([]byte{':'})
+		h.Write([]byte(part))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Store persists chain records. Put must fail with ErrConflict rather than overwrite.
+type Store interface {
+	// Head returns the record with the highest sequence, or nil for an empty chain.
+	Head(ctx context.Context) (*Record, error)
+	Put(ctx context.Context, rec Record) error
+	// List returns up to limit records with Seq greater than after, in sequence order.
+	List(ctx context.Context, after int64, limit int) ([]Record, error)
+}
+
+// Log appends hash-chained records to a Store.
+type Log struct {
+	store Store
+	now   func() time.Time
+}
+
+// NewLog builds a Log over store.
+func NewLog(store Store) (*Log, error) {
+	if store == nil {
+		return nil, errors.New("audit store is required")
+	}
+	return &Log{store: store, now: time.Now}, nil
+}
+
+// Append links a new record to the current head and stores it.
+func (l *Log) Append(ctx context.Context, action, ref string, payload json.RawMessage) (Record, error) {
+	for attempt := 0; attempt < maxAppendAttempts; attempt++ {
+		head, err := l.store.Head(ctx)
+		if err != nil {
+			return Record{}, fmt.Errorf("load audit head: %w", err)
+		}
+
+		rec := Record{Seq: 1, Action: action, Ref: ref, Payload: payload, CreatedAt: l.now().UTC()}
+		if head != nil {
+			rec.Seq = head.Seq + 1
+			rec.PrevHash = head.Hash
+		}
+		rec.Hash = rec.ComputeHash()
+
+		err = l.store.Put(ctx, rec)
+		if errors.Is(err, ErrConflict) {
+			continue
+		}
+		if err != nil {
+			return Record{}, fmt.Errorf("store audit record: %w", err)
+		}
+		return rec, nil
+	}
+	return Record{}, fmt.Errorf("append audit record: %w after %d attempts", ErrConflict, maxAppendAttempts)
+}
+
+// Verification is the outcome of walking the chain.
+type Verification struct {
+	Valid    bool   `json:"valid"`
+	Records  int64  `json:"records"`
+	HeadHash string `json:"head_hash,omitempty"`
+	// BrokenAt is the first sequence whose hash or link does not check out.
+	BrokenAt int64  `json:"broken_at,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// Verify walks the whole chain, recomputing every hash and checking that sequences are
+// contiguous and each record links to its predecessor.
+func (l *Log) Verify(ctx context.Context) (Verification, error) {
+	const page = 500
+
+	var (
+		v        = Verification{Valid: true}
+		prevHash string
+		prevSeq  int64
+	)
+	for {
+		recs, err := l.store.List(ctx, prevSeq, page)
+		if err != nil {
+			return Verification{}, fmt.Errorf("list audit records: %w", err)
+		}
+		for _, rec := range recs {
+			switch {
+			case rec.Seq != prevSeq+1:
+				return broken(v, prevSeq+1, fmt.Sprintf("sequence gap: expected %d, found %d", prevSeq+1, rec.Seq)), nil
+			case rec.PrevHash != prevHash:
+				return broken(v, rec.Seq, "previous hash does not match"), nil
+			case rec.ComputeHash() != rec.Hash:
+				return broken(v, rec.Seq, "record hash does not match its content"), nil
+			}
+			prevHash, prevSeq = rec.Hash, rec.Seq
+			v.Records++
+			v.HeadHash = rec.Hash
+		}
+		if len(recs) < page {
+			return v, nil
+		}
+	}
+}
+
+func broken(v Verification, seq int64, reason string) Verification {
+	v.Valid = false
+	v.BrokenAt = seq
+	v.Reason = reason
+	return v
+}
+
+// MemoryStore is a chain held in memory, for tests only; an audit trail that disappears
+// with its container proves nothing.
+type MemoryStore struct {
+	mu      sync.Mutex
+	records []Record
+}
+
+// NewMemoryStore builds an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+// Head returns the last record.
+func (m *MemoryStore) Head(ctx context.Context) (*Record, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.records) == 0 {
+		return nil, nil
+	}
+	rec := m.records[len(m.records)-1]
+	return &rec, nil
+}
+
+// Put appends rec when it is the next sequence.
+func (m *MemoryStore) Put(ctx context.Context, rec Record) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if rec.Seq != int64(len(m.records))+1 {
+		return ErrConflict
+	}
+	m.records = append(m.records, rec)
+	return nil
+}
+
+// List returns records after the given sequence.
+func (m *MemoryStore) List(ctx context.Context, after int64, limit int) ([]Record, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if after < 0 || after >= int64(len(m.records)) {
+		return nil, nil
+	}
+	out := m.records[after:]
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return append([]Record(nil), out...), nil
+}
@@ -0,0 +1,55 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogAppendAndVerify(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	log, err := NewLog(store)
+	require.NoError(t, err)
+
+	first, err := log.Append(ctx, "outcome", "ref-1", json.RawMessage(`{"status":"success"}`))
+	require.NoError(t, err)
+	require.Equal(t, int64(1), first.Seq)
+	require.Empty(t, first.PrevHash)
+
+	second, err := log.Append(ctx, "outcome", "ref-2", json.RawMessage(`{"status":"failed"}`))
+	require.NoError(t, err)
+	require.Equal(t, first.Hash, second.PrevHash)
+
+	v, err := log.Verify(ctx)
+	require.NoError(t, err)
+	require.Equal(t, Verification{Valid: true, Records: 2, HeadHash: second.Hash}, v)
+}
+
+func TestVerifyDetectsTampering(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	log, err := NewLog(store)
+	require.NoError(t, err)
+	for _, ref := range []string{"a", "b", "c"} {
+		_, err := log.Append(ctx, "outcome", ref, json.RawMessage(`{"amount":1000}`))
+		require.NoError(t, err)
+	}
+
+	store.records[1].Payload = json.RawMessage(`{"amount":10}`)
+	v, err := log.Verify(ctx)
+	require.NoError(t, err)
+	require.False(t, v.Valid)
+	require.Equal(t, int64(2), v.BrokenAt)
+	require.Equal(t, "record hash does not match its content", v.Reason)
+
+	store.records[1].Payload = json.RawMessage(`{"amount":1000}`)
+	store.records = append(store.records[:1], store.records[2:]...)
+	v, err = log.Verify(ctx)
+	require.NoError(t, err)
+	require.False(t, v.Valid)
+	require.Equal(t, int64(2), v.BrokenAt)
+	require.Equal(t, int64(1), v.Records)
+}
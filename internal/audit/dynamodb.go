@@ -0,0 +1,155 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// chainKey is the single partition holding the chain, so sequence order is the sort order.
+const chainKey = "audit"
+
+// DynamoStore is a Store backed by a DynamoDB table with partition key "chain" (S) and sort
+// key "seq" (N). Writes are conditional on the sequence being new, which serialises
+// concurrent appenders.
+type DynamoStore struct {
+	client *dynamodb.Client
+	table  string
+}
+
+type dynamoRecord struct {
+	Chain     string    `dynamodbav:"chain"`
+	Seq       int64     `dynamodbav:"seq"`
+	Action    string    `dynamodbav:"action"`
+	Ref       string    `dynamodbav:"ref,omitempty"`
+	Payload   string    `dynamodbav:"payload,omitempty"`
+	CreatedAt time.Time `dynamodbav:"created_at"`
+	PrevHash  string    `dynamodbav:"prev_hash"`
+	Hash      string    `dynamodbav:"hash"`
+}
+
+// NewDynamoStore builds a Store writing to table.
+func NewDynamoStore(client *dynamodb.Client, table string) (*DynamoStore, error) {
+	if client == nil {
+		return nil, errors.New("dynamodb client is required")
+	}
+	if table == "" {
+		return nil, errors.New("table is required")
+	}
+	return &DynamoStore{client: client, table: table}, nil
+}
+
+// Head returns the record with the highest sequence.
+func (d *DynamoStore) Head(ctx context.Context) (*Record, error) {
+	out, err := d.client.Query(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(d.table),
+		KeyConditionExpression: aws.String("chain = :chain"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":chain": &types.AttributeValueMemberS{Value: chainKey},
+		},
+		ScanIndexForward: aws.Bool(false),
+		Limit:            aws.Int32(1),
+		ConsistentRead:   aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("query audit head: %w", err)
+	}
+	if len(out.Items) == 0 {
+		return nil, nil
+	}
+	rec, err := decodeRecord(out.Items[0])
+	if err != nil {
+		return nil, err
+	}
+	return &rec, nil
+}
+
+// Put writes rec unless its sequence already exists.
+func (d *DynamoStore) Put(ctx context.Context, rec Record) error {
+	item, err := attributevalue.MarshalMap(dynamoRecord{
+		Chain:     chainKey,
+		Seq:       rec.Seq,
+		Action:    rec.Action,
+		Ref:       rec.Ref,
+		Payload:   string(rec.Payload),
+		CreatedAt: rec.CreatedAt,
+		PrevHash:  rec.PrevHash,
+		Hash:      rec.Hash,
+	})
+	if err != nil {
+		return fmt.Errorf("encode audit record: %w", err)
+	}
+
+	_, err = d.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(d.table),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(seq)"),
+	})
+	var failed *types.ConditionalCheckFailedException
+	if errors.As(err, &failed) {
+		return ErrConflict
+	}
+	if err != nil {
+		return fmt.Errorf("put audit record: %w", err)
+	}
+	return nil
+}
+
+// List returns up to limit records after the given sequence.
+func (d *DynamoStore) List(ctx context.Context, after int64, limit int) ([]Record, error) {
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(d.table),
+		KeyConditionExpression: aws.String("chain = :chain AND seq > :after"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":chain": &types.AttributeValueMemberS{Value: chainKey},
+			":after": &types.AttributeValueMemberN{Value: strconv.FormatInt(after, 10)},
+		},
+		ConsistentRead: aws.Bool(true),
+	}
+
+	var recs []Record
+	paginator := dynamodb.NewQueryPaginator(d.client, input)
+	for paginator.HasMorePages() && (limit <= 0 || len(recs) < limit) {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("query audit records: %w", err)
+		}
+		for _, item := range page.Items {
+			rec, err := decodeRecord(item)
+			if err != nil {
+				return nil, err
+			}
+			recs = append(recs, rec)
+		}
+	}
+	if limit > 0 && len(recs) > limit {
+		recs = recs[:limit]
+	}
+	return recs, nil
+}
+
+func decodeRecord(item map[string]types.AttributeValue) (Record, error) {
+	var rec dynamoRecord
+	if err := attributevalue.UnmarshalMap(item, &rec); err != nil {
+		return Record{}, fmt.Errorf("decode audit record: %w", err)
+	}
+	out := Record{
+		Seq:       rec.Seq,
+		Action:    rec.Action,
+		Ref:       rec.Ref,
+		CreatedAt: rec.CreatedAt,
+		PrevHash:  rec.PrevHash,
+		Hash:      rec.Hash,
+	}
+	if rec.Payload != "" {
+		out.Payload = []byte(rec.Payload)
+	}
+	return out, nil
+}
@@ -0,0 +1,112 @@
+// Package billing tracks rows of a billing table, where writing a row with status "due"
+// requests a charge and the outcome is written back onto the same row.
+package billing
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Row statuses. Rows are written as due by their owner; the processor claims them as
+// processing and completes them as paid, failed or pending.
+const (
+	StatusDue        = "due"
+	StatusProcessing = "processing"
+	StatusPaid       = "paid"
+	StatusFailed     = "failed"
+	StatusPending    = "pending"
+)
+
+var (
+	// ErrNotFound marks a lookup for a row that does not exist.
+	ErrNotFound = errors.New("billing row not found")
+	// ErrNotDue is returned by Claim when the row is no longer due, typically because an
+	// earlier delivery of the same stream record already claimed it.
+	ErrNotDue = errors.New("billing row is not due")
+)
+
+// Result is what a charge wrote back onto its row.
+type Result struct {
+	Status      string    `json:"status"`
+	Ref         string    `json:"ref,omitempty"`
+	Code        string    `json:"code,omitempty"`
+	Message     string    `json:"message,omitempty"`
+	CompletedAt time.Time `json:"completed_at"`
+}
+
+// Store moves rows through their statuses with conditional writes, so a row is charged at
+// most once however often its stream record is delivered.
+type Store interface {
+	// Claim moves the row from due to processing, or returns ErrNotDue.
+	Claim(ctx context.Context, id string) error
+	// Release moves a claimed row back to due so a later delivery can charge it.
+	Release(ctx context.Context, id string) error
+	// Complete records result on a claimed row.
+	Complete(ctx context.Context, id string, result Result) error
+}
+
+// MemoryStore is an in-process Store, suitable for tests.
+type MemoryStore struct {
+	mu       sync.Mutex
+	statuses map[string]string
+	results  map[string]Result
+}
+
+// NewMemoryStore builds a MemoryStore holding a due row for each id.
+func NewMemoryStore(due ...string) *MemoryStore {
+	m := &MemoryStore{statuses: make(map[string]string), results: make(map[string]Result)}
+	for _, id := range due {
+		m.statuses[id] = StatusDue
+	}
+	return m
+}
+
+// Claim implements Store.
+func (m *MemoryStore) Claim(ctx context.Context, id string) error {
+	return m.move(id, StatusDue, StatusProcessing)
+}
+
+// Release implements Store.
+func (m *MemoryStore) Release(ctx context.Context, id string) error {
+	return m.move(id, StatusProcessing, StatusDue)
+}
+
+// Complete implements Store.
+func (m *MemoryStore) Complete(ctx context.Context, id string, result Result) error {
+	if err := m.move(id, StatusProcessing, result.Status); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.results[id] = result
+	return nil
+}
+
+// Status returns the row's status and, once completed, its result.
+func (m *MemoryStore) Status(id string) (string, Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	status, ok := m.statuses[id]
+	if !ok {
+		return "", Result{}, ErrNotFound
+	}
+	return status, m.results[id], nil
+}
+
+func (m *MemoryStore) move(id, from, to string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	status, ok := m.statuses[id]
+	switch {
+	case !ok:
+		return ErrNotFound
+	case status != from && from == StatusDue:
+		return ErrNotDue
+	case status != from:
+		return errors.New("billing row is not being processed")
+	}
+	m.statuses[id] = to
+	return nil
+}
@@ -0,0 +1,30 @@
+package billing
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStoreClaimsOnce(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore("row-1")
+
+	require.NoError(t, store.Claim(ctx, "row-1"))
+	require.ErrorIs(t, store.Claim(ctx, "row-1"), ErrNotDue)
+	require.NoError(t, store.Release(ctx, "row-1"))
+	require.NoError(t, store.Claim(ctx, "row-1"))
+
+	done := Result{Status: StatusPaid, Ref: "abc", CompletedAt: time.Now()}
+	require.NoError(t, store.Complete(ctx, "row-1", done))
+	status, result, err := store.Status("row-1")
+	require.NoError(t, err)
+	require.Equal(t, StatusPaid, status)
+	require.Equal(t, done, result)
+
+	require.ErrorIs(t, store.Claim(ctx, "row-1"), ErrNotDue)
+	require.ErrorIs(t, store.Claim(ctx, "missing"), ErrNotFound)
+	require.Error(t, store.Complete(ctx, "row-1", done))
+}
@@ -0,0 +1,93 @@
+package billing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DynamoStore is a Store over the billing table itself (partition key "id" (S)). Only
+// "status", "last_ref", "result_code", "result_message" and the claimed_at/completed_at
+// timestamps are written; the rest of the row belongs to whoever wrote it.
+type DynamoStore struct {
+	client *dynamodb.Client
+	table  string
+	now    func() time.Time
+}
+
+// NewDynamoStore builds a Store updating rows of table.
+func NewDynamoStore(client *dynamodb.Client, table string) (*DynamoStore, error) {
+	if client == nil {
+		return nil, errors.New("dynamodb client is required")
+	}
+	if table == "" {
+		return nil, errors.New("table is required")
+	}
+	return &DynamoStore{client: client, table: table, now: time.Now}, nil
+}
+
+// Claim implements Store.
+func (d *DynamoStore) Claim(ctx context.Context, id string) error {
+	return d.update(ctx, id, StatusDue, "SET #status = :to, claimed_at = :at", map[string]types.AttributeValue{
+		":to": &types.AttributeValueMemberS{Value: StatusProcessing},
+	})
+}
+
+// Release implements Store.
+func (d *DynamoStore) Release(ctx context.Context, id string) error {
+	return d.update(ctx, id, StatusProcessing, "SET #status = :to, released_at = :at REMOVE claimed_at", map[string]types.AttributeValue{
+		":to": &types.AttributeValueMemberS{Value: StatusDue},
+	})
+}
+
+// Complete implements Store.
+func (d *DynamoStore) Complete(ctx context.Context, id string, result Result) error {
+	completedAt, err := attributevalue.Marshal(result.CompletedAt)
+	if err != nil {
+		return err
+	}
+	return d.update(ctx, id, StatusProcessing,
+		"SET #status = :to, last_ref = :ref, result_code = :code, result_message = :message, completed_at = :completed, updated_at = :at",
+		map[string]types.AttributeValue{
+			":to":        &types.AttributeValueMemberS{Value: result.Status},
+			":ref":       &types.AttributeValueMemberS{Value: result.Ref},
+			":code":      &types.AttributeValueMemberS{Value: result.Code},
+			":message":   &types.AttributeValueMemberS{Value: result.Message},
+			":completed": completedAt,
+		})
+}
+
+// update applies expr to id when its status is from.
+func (d *DynamoStore) update(ctx context.Context, id, from, expr string, values map[string]types.AttributeValue) error {
+	at, err := attributevalue.Marshal(d.now().UTC())
+	if err != nil {
+		return err
+	}
+	values[":at"] = at
+	values[":from"] = &types.AttributeValueMemberS{Value: from}
+	_, err = d.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(d.table),
+		Key:                       map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: id}},
+		UpdateExpression:          aws.String(expr),
+		ConditionExpression:       aws.String("#status = :from"),
+		ExpressionAttributeNames:  map[string]string{"#status": "status"},
+		ExpressionAttributeValues: values,
+	})
+	if err != nil {
+		var condErr *types.ConditionalCheckFailedException
+		if errors.As(err, &condErr) {
+			if from == StatusDue {
+				return ErrNotDue
+			}
+			return fmt.Errorf("billing row %s is not %s", id, from)
+		}
+		return fmt.Errorf("update billing row: %w", err)
+	}
+	return nil
+}
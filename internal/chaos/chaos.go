@@ -0,0 +1,211 @@
+// Package chaos injects faults into Paypack traffic and callback delivery so retry, checkpoint
+// and reconciliation paths can be exercised without bespoke fakes. It is meant for tests, the
+// load-test tool and staging; nothing in the production wiring enables it.
+package chaos
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrInjected is returned (wrapped) for every fault that surfaces as an error.
+var ErrInjected = errors.New("chaos: injected fault")
+
+// Faults sets the rate, between 0 and 1, at which each fault is injected. Rates are drawn
+// independently per call, so a delayed call can also fail.
+type Faults struct {
+	// DelayRate calls wait Delay plus up to Jitter before proceeding.
+	DelayRate float64
+	Delay     time.Duration
+	Jitter    time.Duration
+	// ErrorRate calls fail: HTTP calls get a synthesized 503, callbacks an error.
+	ErrorRate float64
+	// DropRate calls vanish: HTTP calls fail with a transport error, callbacks report success
+	// without being delivered.
+	DropRate float64
+	// MalformedRate HTTP responses have their body truncated mid-JSON.
+	MalformedRate float64
+	// Paths limits HTTP faults to requests whose URL path starts with one of these prefixes,
+	// such as "/api/transactions/find/". Empty means every request.
+	Paths []string
+	// Seed makes the draws reproducible; zero picks a random seed.
+	Seed uint64
+}
+
+// Stats counts the faults an Injector has injected.
+type Stats struct {
+	Calls     int64
+	Delayed   int64
+	Errors    int64
+	Dropped   int64
+	Malformed int64
+}
+
+// Injector draws faults for the transports and senders it wraps. It is safe for concurrent use.
+type Injector struct {
+	faults Faults
+
+	mu   sync.Mutex
+	rand *rand.Rand
+
+	calls, delayed, errs, dropped, malformed atomic.Int64
+}
+
+// New builds an Injector for faults.
+func New(faults Faults) *Injector {
+	seed := faults.Seed
+	if seed == 0 {
+		seed = rand.Uint64()
+	}
+	return &Injector{faults: faults, rand: rand.New(rand.NewPCG(seed, seed))}
+}
+
+// Stats returns the counts so far.
+func (in *Injector) Stats() Stats {
+	return Stats{
+		Calls:     in.calls.Load(),
+		Delayed:   in.delayed.Load(),
+		Errors:    in.errs.Load(),
+		Dropped:   in.dropped.Load(),
+		Malformed: in.malformed.Load(),
+	}
+}
+
+func (in *Injector) hit(rate float64) bool {
+	if rate <= 0 {
+		return false
+	}
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	return in.rand.Float64() < rate
+}
+
+// delay sleeps when a delay is drawn, returning early with ctx's error if it is cancelled.
+func (in *Injector) delay(ctx context.Context) error {
+	if !in.hit(in.faults.DelayRate) {
+		return nil
+	}
+	in.delayed.Add(1)
+	d := in.faults.Delay
+	if in.faults.Jitter > 0 {
+		in.mu.Lock()
+		d += time.Duration(in.rand.Int64N(int64(in.faults.Jitter)))
+		in.mu.Unlock()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// Transport wraps next (http.DefaultTransport when nil) so requests are delayed, failed,
+// dropped or answered with malformed bodies. Use it as a paypack.Client's HTTP transport.
+func (in *Injector) Transport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return roundTripper{in: in, next: next}
+}
+
+func (in *Injector) targets(path string) bool {
+	if len(in.faults.Paths) == 0 {
+		return true
+	}
+	for _, prefix := range in.faults.Paths {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+type roundTripper struct {
+	in   *Injector
+	next http.RoundTripper
+}
+
+func (rt roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	in := rt.in
+	if !in.targets(req.URL.Path) {
+		return rt.next.RoundTrip(req)
+	}
+	in.calls.Add(1)
+	if err := in.delay(req.Context()); err != nil {
+		return nil, err
+	}
+	if in.hit(in.faults.DropRate) {
+		in.dropped.Add(1)
+		return nil, errors.Join(ErrInjected, errors.New("connection reset by peer"))
+	}
+	if in.hit(in.faults.ErrorRate) {
+		in.errs.Add(1)
+		return &http.Response{
+			Status:     "503 Service Unavailable",
+			StatusCode: http.StatusServiceUnavailable,
+			Proto:      "HTTP/1.1",
+			ProtoMajor: 1,
+			ProtoMinor: 1,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(`{"message":"chaos: service unavailable"}`)),
+			Request:    req,
+		}, nil
+	}
+
+	resp, err := rt.next.RoundTrip(req)
+	if err != nil || !in.hit(in.faults.MalformedRate) {
+		return resp, err
+	}
+	in.malformed.Add(1)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	body = append(body[:len(body)/2:len(body)/2], `{"garbage`...)
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+	resp.Header.Del("Content-Length")
+	return resp, nil
+}
+
+// Sender is the delivery interface Wrap decorates; handler.CallbackSender satisfies it for
+// T = handler.SubscriptionResponse.
+type Sender[T any] interface {
+	Send(ctx context.Context, payload T) error
+}
+
+// Wrap returns a Sender that delays, fails or silently drops deliveries to next.
+func Wrap[T any](in *Injector, next Sender[T]) Sender[T] {
+	return sender[T]{in: in, next: next}
+}
+
+type sender[T any] struct {
+	in   *Injector
+	next Sender[T]
+}
+
+func (s sender[T]) Send(ctx context.Context, payload T) error {
+	in := s.in
+	in.calls.Add(1)
+	if err := in.delay(ctx); err != nil {
+		return err
+	}
+	if in.hit(in.faults.DropRate) {
+		in.dropped.Add(1)
+		return nil
+	}
+	if in.hit(in.faults.ErrorRate) {
+		in.errs.Add(1)
+		return ErrInjected
+	}
+	return s.next.Send(ctx, payload)
+}
@@ -0,0 +1,80 @@
+package chaos
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+	"github.com/berniyo/paypack-lambda/pkg/paypack/paypacktest"
+)
+
+func chaosClient(t *testing.T, in *Injector) *paypack.Client {
+	t.Helper()
+	srv := paypacktest.NewServer(paypacktest.Config{})
+	t.Cleanup(srv.Close)
+	c, err := paypack.NewClient(srv.URL, paypacktest.AppID, paypacktest.AppSecret, &http.Client{Transport: in.Transport(nil)})
+	require.NoError(t, err)
+	require.NoError(t, c.WarmToken(context.Background()))
+	return c
+}
+
+func TestTransportInjectsServiceUnavailable(t *testing.T) {
+	in := New(Faults{})
+	c := chaosClient(t, in)
+	in.faults.ErrorRate = 1
+
+	_, err := c.CashIn(context.Background(), "0788000000", 100)
+	var apiErr *paypack.APIError
+	require.True(t, errors.As(err, &apiErr))
+	require.Equal(t, http.StatusServiceUnavailable, apiErr.StatusCode)
+	require.EqualValues(t, 1, in.Stats().Errors)
+}
+
+func TestTransportDropsAndCorruptsResponses(t *testing.T) {
+	in := New(Faults{})
+	c := chaosClient(t, in)
+
+	in.faults.DropRate = 1
+	_, err := c.CashIn(context.Background(), "0788000000", 100)
+	require.ErrorIs(t, err, ErrInjected)
+
+	in.faults.DropRate = 0
+	in.faults.MalformedRate = 1
+	_, err = c.CashIn(context.Background(), "0788000000", 100)
+	require.ErrorContains(t, err, "decode")
+	require.EqualValues(t, 1, in.Stats().Malformed)
+}
+
+func TestTransportDelayHonoursContext(t *testing.T) {
+	in := New(Faults{DelayRate: 1, Delay: time.Second})
+	rt := in.Transport(nil)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://127.0.0.1:1", nil)
+
+	_, err := rt.RoundTrip(req)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+type countingSender struct{ delivered int }
+
+func (s *countingSender) Send(context.Context, string) error {
+	s.delivered++
+	return nil
+}
+
+func TestWrapFailsAndDropsDeliveries(t *testing.T) {
+	next := &countingSender{}
+
+	require.ErrorIs(t, Wrap[string](New(Faults{ErrorRate: 1}), next).Send(context.Background(), "x"), ErrInjected)
+	require.NoError(t, Wrap[string](New(Faults{DropRate: 1}), next).Send(context.Background(), "x"))
+	require.Zero(t, next.delivered)
+
+	require.NoError(t, Wrap[string](New(Faults{}), next).Send(context.Background(), "x"))
+	require.Equal(t, 1, next.delivered)
+}
@@ -0,0 +1,102 @@
+package checkpoint
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrNotFound marks a lookup for a checkpoint that does not exist.
+var ErrNotFound = errors.New("checkpoint not found")
+
+// Checkpoint records a cash-in Paypack accepted but that has not been confirmed yet, so
+// confirmation can resume after the invocation that started it dies.
+type Checkpoint struct {
+	Ref string `json:"ref"`
+	// State is the caller's opaque snapshot needed to build the final outcome.
+	State     json.RawMessage `json:"state"`
+	Deadline  time.Time       `json:"deadline"`
+	Attempts  int             `json:"attempts"`
+	StartedAt time.Time       `json:"started_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// Store persists checkpoints keyed by transaction ref.
+type Store interface {
+	Put(ctx context.Context, cp Checkpoint) error
+	Get(ctx context.Context, ref string) (*Checkpoint, error)
+	Delete(ctx context.Context, ref string) error
+	// Pending returns up to limit checkpoints last updated at or before before, oldest first.
+	Pending(ctx context.Context, before time.Time, limit int) ([]Checkpoint, error)
+}
+
+// MemoryStore keeps checkpoints in memory, for tests; a checkpoint only helps if the next
+// invocation can read it.
+type MemoryStore struct {
+	mu          sync.Mutex
+	checkpoints map[string]Checkpoint
+}
+
+// NewMemoryStore builds an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{checkpoints: make(map[string]Checkpoint)}
+}
+
+// Put stores cp, replacing any checkpoint for the same ref.
+func (m *MemoryStore) Put(ctx context.Context, cp Checkpoint) error {
+	if cp.Ref == "" {
+		return errors.New("checkpoint ref is required")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.checkpoints[cp.Ref] = cp
+	return nil
+}
+
+// Get returns the checkpoint or ErrNotFound.
+func (m *MemoryStore) Get(ctx context.Context, ref string) (*Checkpoint, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cp, ok := m.checkpoints[ref]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &cp, nil
+}
+
+// Delete removes the checkpoint. Deleting a missing checkpoint is not an error.
+func (m *MemoryStore) Delete(ctx context.Context, ref string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.checkpoints, ref)
+	return nil
+}
+
+// Pending returns checkpoints that have not been touched since before.
+func (m *MemoryStore) Pending(ctx context.Context, before time.Time, limit int) ([]Checkpoint, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var pending []Checkpoint
+	for _, cp := range m.checkpoints {
+		if !cp.UpdatedAt.After(before) {
+			pending = append(pending, cp)
+		}
+	}
+	sortOldestFirst(pending)
+	if limit > 0 && len(pending) > limit {
+		pending = pending[:limit]
+	}
+	return pending, nil
+}
+
+func sortOldestFirst(cps []Checkpoint) {
+	sort.Slice(cps, func(i, j int) bool { return cps[i].UpdatedAt.Before(cps[j].UpdatedAt) })
+}
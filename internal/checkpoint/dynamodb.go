@@ -0,0 +1,141 @@
+package checkpoint
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DynamoStore is a Store backed by a DynamoDB table with partition key "ref" (S). Pending
+// checkpoints are found with a filtered scan on "updated_unix"; the table only holds
+// in-flight confirmations, so it stays small.
+type DynamoStore struct {
+	client *dynamodb.Client
+	table  string
+}
+
+type dynamoCheckpoint struct {
+	Ref         string    `dynamodbav:"ref"`
+	State       string    `dynamodbav:"state"`
+	Deadline    time.Time `dynamodbav:"deadline"`
+	Attempts    int       `dynamodbav:"attempts"`
+	StartedAt   time.Time `dynamodbav:"started_at"`
+	UpdatedAt   time.Time `dynamodbav:"updated_at"`
+	UpdatedUnix int64     `dynamodbav:"updated_unix"`
+}
+
+// NewDynamoStore builds a Store writing to table.
+func NewDynamoStore(client *dynamodb.Client, table string) (*DynamoStore, error) {
+	if client == nil {
+		return nil, errors.New("dynamodb client is required")
+	}
+	if table == "" {
+		return nil, errors.New("table is required")
+	}
+	return &DynamoStore{client: client, table: table}, nil
+}
+
+// Put stores cp, replacing any checkpoint for the same ref.
+func (d *DynamoStore) Put(ctx context.Context, cp Checkpoint) error {
+	item, err := attributevalue.MarshalMap(dynamoCheckpoint{
+		Ref:         cp.Ref,
+		State:       string(cp.State),
+		Deadline:    cp.Deadline,
+		Attempts:    cp.Attempts,
+		StartedAt:   cp.StartedAt,
+		UpdatedAt:   cp.UpdatedAt,
+		UpdatedUnix: cp.UpdatedAt.Unix(),
+	})
+	if err != nil {
+		return fmt.Errorf("encode checkpoint: %w", err)
+	}
+	if _, err := d.client.PutItem(ctx, &dynamodb.PutItemInput{TableName: aws.String(d.table), Item: item}); err != nil {
+		return fmt.Errorf("put checkpoint: %w", err)
+	}
+	return nil
+}
+
+// Get returns the checkpoint or ErrNotFound.
+func (d *DynamoStore) Get(ctx context.Context, ref string) (*Checkpoint, error) {
+	out, err := d.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(d.table),
+		Key:            map[string]types.AttributeValue{"ref": &types.AttributeValueMemberS{Value: ref}},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get checkpoint: %w", err)
+	}
+	if out.Item == nil {
+		return nil, ErrNotFound
+	}
+	cp, err := decodeCheckpoint(out.Item)
+	if err != nil {
+		return nil, err
+	}
+	return &cp, nil
+}
+
+// Delete removes the checkpoint.
+func (d *DynamoStore) Delete(ctx context.Context, ref string) error {
+	_, err := d.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(d.table),
+		Key:       map[string]types.AttributeValue{"ref": &types.AttributeValueMemberS{Value: ref}},
+	})
+	if err != nil {
+		return fmt.Errorf("delete checkpoint: %w", err)
+	}
+	return nil
+}
+
+// Pending returns checkpoints that have not been touched since before.
+func (d *DynamoStore) Pending(ctx context.Context, before time.Time, limit int) ([]Checkpoint, error) {
+	var pending []Checkpoint
+	paginator := dynamodb.NewScanPaginator(d.client, &dynamodb.ScanInput{
+		TableName:        aws.String(d.table),
+		FilterExpression: aws.String("updated_unix <= :before"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":before": &types.AttributeValueMemberN{Value: strconv.FormatInt(before.Unix(), 10)},
+		},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("scan checkpoints: %w", err)
+		}
+		for _, item := range page.Items {
+			cp, err := decodeCheckpoint(item)
+			if err != nil {
+				return nil, err
+			}
+			pending = append(pending, cp)
+		}
+	}
+
+	sortOldestFirst(pending)
+	if limit > 0 && len(pending) > limit {
+		pending = pending[:limit]
+	}
+	return pending, nil
+}
+
+func decodeCheckpoint(item map[string]types.AttributeValue) (Checkpoint, error) {
+	var rec dynamoCheckpoint
+	if err := attributevalue.UnmarshalMap(item, &rec); err != nil {
+		return Checkpoint{}, fmt.Errorf("decode checkpoint: %w", err)
+	}
+	return Checkpoint{
+		Ref:       rec.Ref,
+		State:     []byte(rec.State),
+		Deadline:  rec.Deadline,
+		Attempts:  rec.Attempts,
+		StartedAt: rec.StartedAt,
+		UpdatedAt: rec.UpdatedAt,
+	}, nil
+}
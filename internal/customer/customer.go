@@ -0,0 +1,103 @@
+package customer
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNotFound marks a lookup for a customer that is not registered.
+var ErrNotFound = errors.New("customer not found")
+
+// Profile is the registry entry for a paying MSISDN.
+type Profile struct {
+	Number      string            `json:"number"`
+	ClientID    string            `json:"client_id,omitempty"`
+	Email       string            `json:"email,omitempty"`
+	Name        string            `json:"name,omitempty"`
+	Preferences map[string]string `json:"preferences,omitempty"`
+	CreatedAt   time.Time         `json:"created_at,omitempty"`
+	UpdatedAt   time.Time         `json:"updated_at,omitempty"`
+}
+
+// Store persists customer profiles keyed by normalized number.
+type Store interface {
+	Get(ctx context.Context, number string) (*Profile, error)
+	Put(ctx context.Context, profile *Profile) error
+	Delete(ctx context.Context, number string) error
+}
+
+// NormalizeNumber strips formatting so "+250 780-000000" and "250780000000" share a key.
+func NormalizeNumber(number string) string {
+	var b strings.Builder
+	for _, r := range number {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// MemoryStore holds profiles in a map, for tests; profiles written to it are gone once the
+// container is recycled.
+type MemoryStore struct {
+	mu       sync.RWMutex
+	profiles map[string]Profile
+}
+
+// NewMemoryStore builds an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{profiles: make(map[string]Profile)}
+}
+
+// Get returns a copy of the stored profile or ErrNotFound.
+func (m *MemoryStore) Get(ctx context.Context, number string) (*Profile, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	profile, ok := m.profiles[NormalizeNumber(number)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &profile, nil
+}
+
+// Put stores the profile, preserving the original creation time on updates.
+func (m *MemoryStore) Put(ctx context.Context, profile *Profile) error {
+	if profile == nil {
+		return errors.New("profile is required")
+	}
+	key := NormalizeNumber(profile.Number)
+	if key == "" {
+		return errors.New("customer number is required")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	if existing, ok := m.profiles[key]; ok {
+		profile.CreatedAt = existing.CreatedAt
+	} else if profile.CreatedAt.IsZero() {
+		profile.CreatedAt = now
+	}
+	profile.UpdatedAt = now
+
+	m.profiles[key] = *profile
+	return nil
+}
+
+// Delete removes the profile, returning ErrNotFound if it was not registered.
+func (m *MemoryStore) Delete(ctx context.Context, number string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := NormalizeNumber(number)
+	if _, ok := m.profiles[key]; !ok {
+		return ErrNotFound
+	}
+	delete(m.profiles, key)
+	return nil
+}
@@ -0,0 +1,150 @@
+package customer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// optionalFields are the profile attributes Put sets when present and removes otherwise, so
+// an update clears what the new profile leaves out.
+var optionalFields = []string{"client_id", "email", "name", "preferences"}
+
+// DynamoStore is a Store backed by a DynamoDB table with partition key "number" (S), holding
+// the normalized number. Items use the profile's JSON field names.
+type DynamoStore struct {
+	client *dynamodb.Client
+	table  string
+}
+
+// NewDynamoStore builds a Store writing to table.
+func NewDynamoStore(client *dynamodb.Client, table string) (*DynamoStore, error) {
+	if client == nil {
+		return nil, errors.New("dynamodb client is required")
+	}
+	if table == "" {
+		return nil, errors.New("table is required")
+	}
+	return &DynamoStore{client: client, table: table}, nil
+}
+
+// Get returns the stored profile or ErrNotFound.
+func (d *DynamoStore) Get(ctx context.Context, number string) (*Profile, error) {
+	key := NormalizeNumber(number)
+	if key == "" {
+		return nil, ErrNotFound
+	}
+	out, err := d.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(d.table),
+		Key:            numberKey(key),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get customer: %w", err)
+	}
+	if out.Item == nil {
+		return nil, ErrNotFound
+	}
+	return decodeProfile(out.Item)
+}
+
+// Put stores the profile, preserving the original creation time on updates.
+func (d *DynamoStore) Put(ctx context.Context, profile *Profile) error {
+	if profile == nil {
+		return errors.New("profile is required")
+	}
+	key := NormalizeNumber(profile.Number)
+	if key == "" {
+		return errors.New("customer number is required")
+	}
+	item, err := attributevalue.MarshalMapWithOptions(profile, func(o *attributevalue.EncoderOptions) { o.TagKey = "json" })
+	if err != nil {
+		return fmt.Errorf("encode customer: %w", err)
+	}
+
+	now := time.Now()
+	created := profile.CreatedAt
+	if created.IsZero() {
+		created = now
+	}
+	sets := []string{"updated_at = :now", "created_at = if_not_exists(created_at, :created)"}
+	values := map[string]types.AttributeValue{
+		":now":     &types.AttributeValueMemberS{Value: now.UTC().Format(time.RFC3339Nano)},
+		":created": &types.AttributeValueMemberS{Value: created.UTC().Format(time.RFC3339Nano)},
+	}
+	// "name" is a DynamoDB reserved word, so the fields go through placeholders.
+	names := make(map[string]string, len(optionalFields))
+	var removes []string
+	for _, field := range optionalFields {
+		names["#"+field] = field
+		if v, ok := item[field]; ok {
+			sets = append(sets, "#"+field+" = :"+field)
+			values[":"+field] = v
+		} else {
+			removes = append(removes, "#"+field)
+		}
+	}
+	expr := "SET " + strings.Join(sets, ", ")
+	if len(removes) > 0 {
+		expr += " REMOVE " + strings.Join(removes, ", ")
+	}
+
+	out, err := d.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(d.table),
+		Key:                       numberKey(key),
+		UpdateExpression:          aws.String(expr),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+		ReturnValues:              types.ReturnValueAllNew,
+	})
+	if err != nil {
+		return fmt.Errorf("put customer: %w", err)
+	}
+	stored, err := decodeProfile(out.Attributes)
+	if err != nil {
+		return err
+	}
+	profile.CreatedAt, profile.UpdatedAt = stored.CreatedAt, stored.UpdatedAt
+	return nil
+}
+
+// Delete removes the profile, returning ErrNotFound if it was not registered.
+func (d *DynamoStore) Delete(ctx context.Context, number string) error {
+	key := NormalizeNumber(number)
+	if key == "" {
+		return ErrNotFound
+	}
+	_, err := d.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName:                aws.String(d.table),
+		Key:                      numberKey(key),
+		ConditionExpression:      aws.String("attribute_exists(#number)"),
+		ExpressionAttributeNames: map[string]string{"#number": "number"},
+	})
+	var failed *types.ConditionalCheckFailedException
+	if errors.As(err, &failed) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("delete customer: %w", err)
+	}
+	return nil
+}
+
+func numberKey(number string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{"number": &types.AttributeValueMemberS{Value: number}}
+}
+
+func decodeProfile(item map[string]types.AttributeValue) (*Profile, error) {
+	var profile Profile
+	if err := attributevalue.UnmarshalMapWithOptions(item, &profile, func(o *attributevalue.DecoderOptions) { o.TagKey = "json" }); err != nil {
+		return nil, fmt.Errorf("decode customer: %w", err)
+	}
+	return &profile, nil
+}
@@ -0,0 +1,102 @@
+// Package egress builds the TLS settings and HTTP transports for outbound calls, so
+// deployments that leave the VPC through an inspection proxy can trust its CA and set a TLS
+// floor for Paypack and the callback endpoint alike.
+package egress
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// TLSConfig returns a client TLS config refusing versions below minVersion ("1.2" or "1.3")
+// and trusting the system roots plus the PEM certificates in each CA bundle (a file path, or
+// the PEM itself). Empty values are ignored; with nothing set it returns nil, leaving Go's
+// defaults.
+func TLSConfig(minVersion string, caBundles ...string) (*tls.Config, error) {
+	var bundles []string
+	for _, b := range caBundles {
+		if b = strings.TrimSpace(b); b != "" {
+			bundles = append(bundles, b)
+		}
+	}
+	minVersion = strings.TrimSpace(minVersion)
+	if len(bundles) == 0 && minVersion == "" {
+		return nil, nil
+	}
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if minVersion != "" {
+		v, err := ParseVersion(minVersion)
+		if err != nil {
+			return nil, err
+		}
+		cfg.MinVersion = v
+	}
+	if len(bundles) > 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		for _, b := range bundles {
+			if err := appendBundle(pool, b); err != nil {
+				return nil, err
+			}
+		}
+		cfg.RootCAs = pool
+	}
+	return cfg, nil
+}
+
+// ParseVersion reads a TLS version such as "1.2" or "TLS1.3".
+func ParseVersion(s string) (uint16, error) {
+	switch strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(s)), "TLS") {
+	case "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("unsupported minimum TLS version %q (want 1.2 or 1.3)", s)
+	}
+}
+
+func appendBundle(pool *x509.CertPool, bundle string) error {
+	pem := []byte(bundle)
+	if !strings.HasPrefix(bundle, "-----BEGIN") {
+		data, err := os.ReadFile(bundle)
+		if err != nil {
+			return fmt.Errorf("read CA bundle: %w", err)
+		}
+		pem = data
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return errors.New("CA bundle contains no PEM certificates")
+	}
+	return nil
+}
+
+// Transport is an HTTP transport honouring HTTP_PROXY, HTTPS_PROXY and NO_PROXY and dialling
+// TLS with tlsConfig (nil for Go's defaults).
+func Transport(tlsConfig *tls.Config) *http.Transport {
+	dialer := &net.Dialer{Timeout: 10 * time.Second, KeepAlive: 30 * time.Second}
+	return &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		TLSClientConfig:       tlsConfig.Clone(),
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          16,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   5 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+}
+
+// Client is an http.Client over Transport(tlsConfig).
+func Client(tlsConfig *tls.Config, timeout time.Duration) *http.Client {
+	return &http.Client{Timeout: timeout, Transport: Transport(tlsConfig)}
+}
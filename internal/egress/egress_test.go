@@ -0,0 +1,64 @@
+package egress
+
+import (
+	"crypto/tls"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func serverPEM(srv *httptest.Server) string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}))
+}
+
+func TestTLSConfigTrustsCABundle(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	_, err := Client(nil, time.Second).Get(srv.URL)
+	require.Error(t, err, "the test CA is not a system root")
+
+	cfg, err := TLSConfig("", serverPEM(srv))
+	require.NoError(t, err)
+	resp, err := Client(cfg, time.Second).Get(srv.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	path := filepath.Join(t.TempDir(), "proxy-ca.pem")
+	require.NoError(t, os.WriteFile(path, []byte(serverPEM(srv)), 0o600))
+	cfg, err = TLSConfig("1.2", path)
+	require.NoError(t, err)
+	resp, err = Client(cfg, time.Second).Get(srv.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	_, err = TLSConfig("", "-----BEGIN nothing")
+	require.Error(t, err)
+	cfg, err = TLSConfig("", "")
+	require.NoError(t, err)
+	require.Nil(t, cfg)
+}
+
+func TestTLSConfigEnforcesMinimumVersion(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.TLS = &tls.Config{MaxVersion: tls.VersionTLS12}
+	srv.StartTLS()
+	defer srv.Close()
+
+	cfg, err := TLSConfig("1.3", serverPEM(srv))
+	require.NoError(t, err)
+	_, err = Client(cfg, time.Second).Get(srv.URL)
+	require.ErrorContains(t, err, "protocol version")
+
+	_, err = ParseVersion("1.1")
+	require.Error(t, err)
+	v, err := ParseVersion("tls1.3")
+	require.NoError(t, err)
+	require.Equal(t, uint16(tls.VersionTLS13), v)
+}
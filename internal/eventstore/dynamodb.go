@@ -0,0 +1,198 @@
+package eventstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DynamoStore is a Store backed by a DynamoDB table with partition key "ref" (S)
+// and sort key "sk" (S). Sort keys are "<created_at RFC3339Nano>#<id>" so queries return
+// records in insertion order.
+type DynamoStore struct {
+	client *dynamodb.Client
+	table  string
+}
+
+type dynamoRecord struct {
+	Ref       string    `dynamodbav:"ref"`
+	SK        string    `dynamodbav:"sk"`
+	ID        string    `dynamodbav:"id"`
+	Kind      Kind      `dynamodbav:"kind"`
+	Payload   string    `dynamodbav:"payload"`
+	Delivered bool      `dynamodbav:"delivered"`
+	Error     string    `dynamodbav:"error,omitempty"`
+	CreatedAt time.Time `dynamodbav:"created_at"`
+}
+
+// NewDynamoStore builds a Store writing to table.
+func NewDynamoStore(client *dynamodb.Client, table string) (*DynamoStore, error) {
+	if client == nil {
+		return nil, errors.New("dynamodb client is required")
+	}
+	if table == "" {
+		return nil, errors.New("table is required")
+	}
+	return &DynamoStore{client: client, table: table}, nil
+}
+
+// Append stores rec, assigning an ID and timestamp if missing.
+func (d *DynamoStore) Append(ctx context.Context, rec Record) error {
+	if rec.Ref == "" {
+		return errors.New("record ref is required")
+	}
+	if rec.ID == "" {
+		rec.ID = NewID()
+	}
+	if rec.CreatedAt.IsZero() {
+		rec.CreatedAt = time.Now()
+	}
+
+	item, err := attributevalue.MarshalMap(dynamoRecord{
+		Ref:       rec.Ref,
+		SK:        rec.CreatedAt.UTC().Format(time.RFC3339Nano) + "#" + rec.ID,
+		ID:        rec.ID,
+		Kind:      rec.Kind,
+		Payload:   string(rec.Payload),
+		Delivered: rec.Delivered,
+		Error:     rec.Error,
+		CreatedAt: rec.CreatedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("encode event record: %w", err)
+	}
+
+	_, err = d.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(d.table),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(sk)"),
+	})
+	if err != nil {
+		return fmt.Errorf("put event record: %w", err)
+	}
+	return nil
+}
+
+// Latest returns the most recent record of kind for ref.
+func (d *DynamoStore) Latest(ctx context.Context, ref string, kind Kind) (*Record, error) {
+	recs, err := d.List(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(recs) - 1; i >= 0; i-- {
+		if recs[i].Kind == kind {
+			return &recs[i], nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// List returns every record for ref in insertion order.
+func (d *DynamoStore) List(ctx context.Context, ref string) ([]Record, error) {
+	var recs []Record
+	paginator := dynamodb.NewQueryPaginator(d.client, &dynamodb.QueryInput{
+		TableName:              aws.String(d.table),
+		KeyConditionExpression: aws.String("#ref = :ref"),
+		ExpressionAttributeNames: map[string]string{
+			"#ref": "ref",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":ref": &types.AttributeValueMemberS{Value: ref},
+		},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("query event records: %w", err)
+		}
+		for _, item := range page.Items {
+			rec, err := decodeRecord(item)
+			if err != nil {
+				return nil, err
+			}
+			recs = append(recs, rec)
+		}
+	}
+	return recs, nil
+}
+
+// Scan walks the whole table with a filter on kind; the time range is applied after
+// decoding. It is meant for occasional exports, not request paths.
+func (d *DynamoStore) Scan(ctx context.Context, kind Kind, from, to time.Time, fn func(Record) error) error {
+	paginator := dynamodb.NewScanPaginator(d.client, &dynamodb.ScanInput{
+		TableName:        aws.String(d.table),
+		FilterExpression: aws.String("#kind = :kind"),
+		ExpressionAttributeNames: map[string]string{
+			"#kind": "kind",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":kind": &types.AttributeValueMemberS{Value: string(kind)},
+		},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("scan event records: %w", err)
+		}
+		for _, item := range page.Items {
+			rec, err := decodeRecord(item)
+			if err != nil {
+				return err
+			}
+			if !InRange(rec.CreatedAt, from, to) {
+				continue
+			}
+			if err := fn(rec); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Rewrite replaces the payload of an existing record; its sort key is rebuilt from
+// CreatedAt and ID.
+func (d *DynamoStore) Rewrite(ctx context.Context, rec Record) error {
+	_, err := d.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(d.table),
+		Key: map[string]types.AttributeValue{
+			"ref": &types.AttributeValueMemberS{Value: rec.Ref},
+			"sk":  &types.AttributeValueMemberS{Value: rec.CreatedAt.UTC().Format(time.RFC3339Nano) + "#" + rec.ID},
+		},
+		UpdateExpression:    aws.String("SET payload = :payload"),
+		ConditionExpression: aws.String("attribute_exists(sk)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":payload": &types.AttributeValueMemberS{Value: string(rec.Payload)},
+		},
+	})
+	var missing *types.ConditionalCheckFailedException
+	if errors.As(err, &missing) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("rewrite event record: %w", err)
+	}
+	return nil
+}
+
+func decodeRecord(item map[string]types.AttributeValue) (Record, error) {
+	var rec dynamoRecord
+	if err := attributevalue.UnmarshalMap(item, &rec); err != nil {
+		return Record{}, fmt.Errorf("decode event record: %w", err)
+	}
+	return Record{
+		ID:        rec.ID,
+		Kind:      rec.Kind,
+		Ref:       rec.Ref,
+		Payload:   []byte(rec.Payload),
+		Delivered: rec.Delivered,
+		Error:     rec.Error,
+		CreatedAt: rec.CreatedAt,
+	}, nil
+}
@@ -0,0 +1,161 @@
+package eventstore
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrNotFound marks a lookup with no matching record.
+var ErrNotFound = errors.New("event record not found")
+
+// Kind distinguishes inbound webhooks from outbound callbacks.
+type Kind string
+
+const (
+	KindWebhook  Kind = "webhook"
+	KindCallback Kind = "callback"
+)
+
+// Record is a single persisted payload.
+type Record struct {
+	ID        string          `json:"id"`
+	Kind      Kind            `json:"kind"`
+	Ref       string          `json:"ref"`
+	Payload   json.RawMessage `json:"payload"`
+	Delivered bool            `json:"delivered,omitempty"`
+	Error     string          `json:"error,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// Store is an append-only log of webhook and callback payloads.
+type Store interface {
+	Append(ctx context.Context, rec Record) error
+	// Latest returns the most recent record of kind for ref.
+	Latest(ctx context.Context, ref string, kind Kind) (*Record, error)
+	// List returns every record for ref in insertion order.
+	List(ctx context.Context, ref string) ([]Record, error)
+}
+
+// Scanner is implemented by stores that can walk records across refs, as exports need.
+type Scanner interface {
+	// Scan calls fn for every record of kind created in [from, to); a zero bound is open.
+	// Records are not returned in any particular order.
+	Scan(ctx context.Context, kind Kind, from, to time.Time, fn func(Record) error) error
+}
+
+// Rewriter is implemented by stores that can replace a record's payload in place, as erasing
+// a customer's data needs. The record is identified by its Ref, ID and CreatedAt.
+type Rewriter interface {
+	Rewrite(ctx context.Context, rec Record) error
+}
+
+// InRange reports whether t falls in [from, to), treating a zero bound as open.
+func InRange(t, from, to time.Time) bool {
+	if !from.IsZero() && t.Before(from) {
+		return false
+	}
+	return to.IsZero() || t.Before(to)
+}
+
+// NewID returns a random record identifier.
+func NewID() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return time.Now().UTC().Format("20060102150405.000000000")
+	}
+	return hex.EncodeToString(buf)
+}
+
+// MemoryStore holds records in memory, for tests. It implements Scanner and Rewriter, so
+// exports and erasure can be exercised without DynamoDB.
+type MemoryStore struct {
+	mu    sync.RWMutex
+	byRef map[string][]Record
+}
+
+// NewMemoryStore builds an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{byRef: make(map[string][]Record)}
+}
+
+// Append stores rec, assigning an ID and timestamp if missing.
+func (m *MemoryStore) Append(ctx context.Context, rec Record) error {
+	if rec.Ref == "" {
+		return errors.New("record ref is required")
+	}
+	if rec.ID == "" {
+		rec.ID = NewID()
+	}
+	if rec.CreatedAt.IsZero() {
+		rec.CreatedAt = time.Now()
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.byRef[rec.Ref] = append(m.byRef[rec.Ref], rec)
+	return nil
+}
+
+// Latest returns the most recent record of kind for ref.
+func (m *MemoryStore) Latest(ctx context.Context, ref string, kind Kind) (*Record, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	recs := m.byRef[ref]
+	for i := len(recs) - 1; i >= 0; i-- {
+		if recs[i].Kind == kind {
+			rec := recs[i]
+			return &rec, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// List returns every record for ref in insertion order.
+func (m *MemoryStore) List(ctx context.Context, ref string) ([]Record, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return append([]Record(nil), m.byRef[ref]...), nil
+}
+
+// Scan calls fn for every matching record.
+func (m *MemoryStore) Scan(ctx context.Context, kind Kind, from, to time.Time, fn func(Record) error) error {
+	m.mu.RLock()
+	var matched []Record
+	for _, recs := range m.byRef {
+		for _, rec := range recs {
+			if rec.Kind == kind && InRange(rec.CreatedAt, from, to) {
+				matched = append(matched, rec)
+			}
+		}
+	}
+	m.mu.RUnlock()
+
+	for _, rec := range matched {
+		if err := fn(rec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Rewrite replaces the payload of the stored record with rec's ID.
+func (m *MemoryStore) Rewrite(ctx context.Context, rec Record) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, stored := range m.byRef[rec.Ref] {
+		if stored.ID == rec.ID {
+			m.byRef[rec.Ref][i].Payload = rec.Payload
+			return nil
+		}
+	}
+	return ErrNotFound
+}
@@ -0,0 +1,165 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+
+	"github.com/berniyo/paypack-lambda/internal/money"
+)
+
+// Format selects the encoding of an export object.
+type Format string
+
+const (
+	FormatCSV     Format = "csv"
+	FormatParquet Format = "parquet"
+)
+
+// ParseFormat normalizes s, defaulting to CSV.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(strings.TrimSpace(s))); f {
+	case "", FormatCSV:
+		return FormatCSV, nil
+	case FormatParquet:
+		return FormatParquet, nil
+	default:
+		return "", fmt.Errorf("unsupported export format %q", s)
+	}
+}
+
+// ContentType returns the MIME type stored with the object.
+func (f Format) ContentType() string {
+	if f == FormatParquet {
+		return "application/vnd.apache.parquet"
+	}
+	return "text/csv"
+}
+
+// Row is one exported transaction.
+type Row struct {
+	Ref            string    `parquet:"ref"`
+	CreatedAt      time.Time `parquet:"created_at,timestamp(millisecond)"`
+	Status         string    `parquet:"status"`
+	Amount         float64   `parquet:"amount"`
+	Currency       string    `parquet:"currency,optional"`
+	Fee            float64   `parquet:"fee"`
+	NetAmount      float64   `parquet:"net_amount"`
+	Provider       string    `parquet:"provider,optional"`
+	Client         string    `parquet:"client,optional"`
+	SubscriptionID string    `parquet:"subscription_id,optional"`
+	Message        string    `parquet:"message,optional"`
+}
+
+var csvHeader = []string{
+	"ref", "created_at", "status", "amount", "currency", "fee", "net_amount",
+	"provider", "client", "subscription_id", "message",
+}
+
+// rowGroupRows bounds the rows a Parquet writer buffers before flushing a row group.
+const rowGroupRows = 10_000
+
+// Writer encodes rows one at a time, so an export holds at most a row group in memory
+// rather than every row.
+type Writer struct {
+	csv     *csv.Writer
+	parquet *parquet.GenericWriter[Row]
+	rows    int
+}
+
+// NewWriter returns a Writer encoding rows to w in format. Close must be called to flush it.
+func NewWriter(w io.Writer, format Format) (*Writer, error) {
+	switch format {
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(csvHeader); err != nil {
+			return nil, fmt.Errorf("encode csv: %w", err)
+		}
+		return &Writer{csv: cw}, nil
+	case FormatParquet:
+		return &Writer{parquet: parquet.NewGenericWriter[Row](w, parquet.MaxRowsPerRowGroup(rowGroupRows))}, nil
+	default:
+		return nil, fmt.Errorf("unsupported export format %q", format)
+	}
+}
+
+// Write encodes r.
+func (w *Writer) Write(r Row) error {
+	if w.parquet != nil {
+		if _, err := w.parquet.Write([]Row{r}); err != nil {
+			return fmt.Errorf("encode parquet: %w", err)
+		}
+	} else if err := w.csv.Write([]string{
+		r.Ref,
+		r.CreatedAt.UTC().Format(time.RFC3339),
+		r.Status,
+		money.Format(r.Amount, r.Currency),
+		r.Currency,
+		money.Format(r.Fee, r.Currency),
+		money.Format(r.NetAmount, r.Currency),
+		r.Provider,
+		r.Client,
+		r.SubscriptionID,
+		r.Message,
+	}); err != nil {
+		return fmt.Errorf("encode csv: %w", err)
+	}
+	w.rows++
+	return nil
+}
+
+// Rows returns how many rows have been written.
+func (w *Writer) Rows() int {
+	return w.rows
+}
+
+// Close flushes buffered rows and, for Parquet, writes the footer. It does not close the
+// underlying writer.
+func (w *Writer) Close() error {
+	if w.parquet != nil {
+		if err := w.parquet.Close(); err != nil {
+			return fmt.Errorf("encode parquet: %w", err)
+		}
+		return nil
+	}
+	w.csv.Flush()
+	if err := w.csv.Error(); err != nil {
+		return fmt.Errorf("encode csv: %w", err)
+	}
+	return nil
+}
+
+// Refs returns the refs of the rows in an object written by a Writer in format.
+func Refs(format Format, body []byte) ([]string, error) {
+	switch format {
+	case FormatCSV:
+		records, err := csv.NewReader(bytes.NewReader(body)).ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("decode csv: %w", err)
+		}
+		var refs []string
+		for i, record := range records {
+			if i > 0 && len(record) > 0 {
+				refs = append(refs, record[0])
+			}
+		}
+		return refs, nil
+	case FormatParquet:
+		rows, err := parquet.Read[Row](bytes.NewReader(body), int64(len(body)))
+		if err != nil {
+			return nil, fmt.Errorf("decode parquet: %w", err)
+		}
+		refs := make([]string, len(rows))
+		for i, r := range rows {
+			refs[i] = r.Ref
+		}
+		return refs, nil
+	default:
+		return nil, fmt.Errorf("unsupported export format %q", format)
+	}
+}
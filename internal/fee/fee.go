@@ -0,0 +1,101 @@
+package fee
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/berniyo/paypack-lambda/internal/money"
+)
+
+// Rule prices one amount band. The first rule whose UpTo covers the amount applies;
+// UpTo == 0 means "no upper bound".
+type Rule struct {
+	UpTo    float64 `json:"up_to,omitempty"`
+	Percent float64 `json:"percent,omitempty"`
+	Fixed   float64 `json:"fixed,omitempty"`
+	Min     float64 `json:"min,omitempty"`
+	Max     float64 `json:"max,omitempty"`
+}
+
+// Schedule holds fee rules per provider, falling back to Default for unknown providers.
+type Schedule struct {
+	Providers map[string][]Rule `json:"providers,omitempty"`
+	Default   []Rule            `json:"default"`
+	// Tolerance is the absolute difference between expected and actual fees tolerated before
+	// a breakdown is marked as a mismatch.
+	Tolerance float64 `json:"tolerance,omitempty"`
+}
+
+// DefaultSchedule mirrors Paypack's published flat cash-in rate at the time of writing.
+// Deployments with negotiated pricing should load their own schedule with ParseSchedule.
+func DefaultSchedule() *Schedule {
+	return &Schedule{
+		Default:   []Rule{{Percent: 2.3}},
+		Tolerance: 1,
+	}
+}
+
+// ParseSchedule decodes a JSON schedule, e.g.
+//
+//	{"default": [{"percent": 2.3}],
+//	 "providers": {"mtn": [{"up_to": 1000, "fixed": 20}, {"percent": 2}]},
+//	 "tolerance": 1}
+func ParseSchedule(data []byte) (*Schedule, error) {
+	var s Schedule
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("decode fee schedule: %w", err)
+	}
+	if len(s.Default) == 0 && len(s.Providers) == 0 {
+		return nil, fmt.Errorf("fee schedule has no rules")
+	}
+	return &s, nil
+}
+
+// Expected returns the fee the schedule predicts for amount in currency through provider.
+func (s *Schedule) Expected(amount float64, currency, provider string) float64 {
+	rules, ok := s.Providers[strings.ToLower(provider)]
+	if !ok {
+		rules = s.Default
+	}
+	for _, r := range rules {
+		if r.UpTo == 0 || amount <= r.UpTo {
+			return r.apply(amount, currency)
+		}
+	}
+	return 0
+}
+
+func (r Rule) apply(amount float64, currency string) float64 {
+	fee := amount*r.Percent/100 + r.Fixed
+	if r.Min > 0 && fee < r.Min {
+		fee = r.Min
+	}
+	if r.Max > 0 && fee > r.Max {
+		fee = r.Max
+	}
+	return money.Round(fee, currency)
+}
+
+// Breakdown compares the expected fee with the one Paypack reported.
+type Breakdown struct {
+	Provider    string  `json:"provider,omitempty"`
+	ExpectedFee float64 `json:"expected_fee"`
+	ActualFee   float64 `json:"actual_fee"`
+	NetAmount   float64 `json:"net_amount"`
+	Mismatch    bool    `json:"mismatch,omitempty"`
+}
+
+// Compare builds the breakdown for a transaction of amount in currency that was charged
+// actualFee.
+func (s *Schedule) Compare(amount, actualFee float64, currency, provider string) Breakdown {
+	expected := s.Expected(amount, currency, provider)
+	return Breakdown{
+		Provider:    provider,
+		ExpectedFee: expected,
+		ActualFee:   actualFee,
+		NetAmount:   money.Round(amount-actualFee, currency),
+		Mismatch:    math.Abs(expected-actualFee) > s.Tolerance,
+	}
+}
@@ -0,0 +1,34 @@
+package fee
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduleExpected(t *testing.T) {
+	s, err := ParseSchedule([]byte(`{
+		"default": [{"percent": 2.3}],
+		"providers": {"mtn": [{"up_to": 1000, "fixed": 20}, {"percent": 2, "max": 500}]},
+		"tolerance": 1
+	}`))
+	require.NoError(t, err)
+
+	require.Equal(t, 23.0, s.Expected(1000, "RWF", "airtel"))
+	require.Equal(t, 20.0, s.Expected(800, "RWF", "MTN"))
+	require.Equal(t, 40.0, s.Expected(2000, "RWF", "mtn"))
+	require.Equal(t, 500.0, s.Expected(100000, "RWF", "mtn"))
+	require.Equal(t, 24.0, s.Expected(1050, "RWF", "airtel"), "francs have no minor unit")
+	require.Equal(t, 24.15, s.Expected(1050, "USD", "airtel"))
+}
+
+func TestScheduleCompare(t *testing.T) {
+	s := DefaultSchedule()
+
+	b := s.Compare(1000, 23, "RWF", "mtn")
+	require.Equal(t, Breakdown{Provider: "mtn", ExpectedFee: 23, ActualFee: 23, NetAmount: 977}, b)
+
+	b = s.Compare(1000, 50, "RWF", "mtn")
+	require.True(t, b.Mismatch)
+	require.Equal(t, 950.0, b.NetAmount)
+}
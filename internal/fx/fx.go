@@ -0,0 +1,164 @@
+package fx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/berniyo/paypack-lambda/internal/money"
+)
+
+// ErrRateUnavailable is returned when a provider has no rate for a currency pair.
+var ErrRateUnavailable = errors.New("exchange rate unavailable")
+
+// RateProvider returns how many units of to one unit of from buys.
+type RateProvider interface {
+	Rate(ctx context.Context, from, to string) (float64, error)
+}
+
+// Conversion records an amount converted into the settlement currency.
+type Conversion struct {
+	From            string    `json:"from"`
+	To              string    `json:"to"`
+	Rate            float64   `json:"rate"`
+	OriginalAmount  float64   `json:"original_amount"`
+	ConvertedAmount float64   `json:"converted_amount"`
+	QuotedAt        time.Time `json:"quoted_at"`
+}
+
+// Convert fetches the from→to rate and converts amount, rounding to the minor unit of to.
+func Convert(ctx context.Context, provider RateProvider, amount float64, from, to string) (*Conversion, error) {
+	rate, err := provider.Rate(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if rate <= 0 {
+		return nil, fmt.Errorf("%w: %s/%s", ErrRateUnavailable, from, to)
+	}
+
+	return &Conversion{
+		From:            from,
+		To:              to,
+		Rate:            rate,
+		OriginalAmount:  amount,
+		ConvertedAmount: money.Round(amount*rate, to),
+		QuotedAt:        time.Now(),
+	}, nil
+}
+
+// StaticRates is a RateProvider over fixed rates keyed "FROM/TO". Inverse pairs are derived.
+type StaticRates map[string]float64
+
+// Rate implements RateProvider.
+func (s StaticRates) Rate(ctx context.Context, from, to string) (float64, error) {
+	if from == to {
+		return 1, nil
+	}
+	if rate, ok := s[from+"/"+to]; ok {
+		return rate, nil
+	}
+	if rate, ok := s[to+"/"+from]; ok && rate > 0 {
+		return 1 / rate, nil
+	}
+	return 0, fmt.Errorf("%w: %s/%s", ErrRateUnavailable, from, to)
+}
+
+// HTTPRateProvider fetches rates from a JSON endpoint answering GET <url>?base=FROM with
+// {"rates": {"TO": 1234.5, ...}}, the shape used by most public FX APIs. Responses are cached per base.
+type HTTPRateProvider struct {
+	url        string
+	httpClient *http.Client
+	ttl        time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cachedRates
+}
+
+type cachedRates struct {
+	rates     map[string]float64
+	fetchedAt time.Time
+}
+
+// NewHTTPRateProvider builds an HTTPRateProvider caching responses for ttl.
+func NewHTTPRateProvider(endpoint string, ttl time.Duration, client *http.Client) (*HTTPRateProvider, error) {
+	endpoint = strings.TrimSpace(endpoint)
+	if endpoint == "" {
+		return nil, errors.New("rate endpoint is required")
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if ttl <= 0 {
+		ttl = time.Hour
+	}
+	return &HTTPRateProvider{url: endpoint, httpClient: client, ttl: ttl, cache: make(map[string]cachedRates)}, nil
+}
+
+// Rate implements RateProvider.
+func (h *HTTPRateProvider) Rate(ctx context.Context, from, to string) (float64, error) {
+	if from == to {
+		return 1, nil
+	}
+
+	h.mu.Lock()
+	cached, ok := h.cache[from]
+	h.mu.Unlock()
+	if !ok || time.Since(cached.fetchedAt) > h.ttl {
+		rates, err := h.fetch(ctx, from)
+		if err != nil {
+			return 0, err
+		}
+		cached = cachedRates{rates: rates, fetchedAt: time.Now()}
+		h.mu.Lock()
+		h.cache[from] = cached
+		h.mu.Unlock()
+	}
+
+	rate, ok := cached.rates[to]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s/%s", ErrRateUnavailable, from, to)
+	}
+	return rate, nil
+}
+
+func (h *HTTPRateProvider) fetch(ctx context.Context, base string) (map[string]float64, error) {
+	u, err := url.Parse(h.url)
+	if err != nil {
+		return nil, fmt.Errorf("parse rate endpoint: %w", err)
+	}
+	q := u.Query()
+	q.Set("base", base)
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := h.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch rates: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, fmt.Errorf("rate endpoint returned %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	var body struct {
+		Rates map[string]float64 `json:"rates"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("decode rates: %w", err)
+	}
+	return body.Rates, nil
+}
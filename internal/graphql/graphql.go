@@ -0,0 +1,315 @@
+// Package graphql executes GraphQL queries against a schema of Go resolvers. It implements
+// the query subset support tooling needs: fields, aliases, arguments, variables, and named
+// and inline fragments. Mutations, subscriptions, directives and introspection are not
+// supported; publish the schema SDL alongside the endpoint instead.
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// maxDepth bounds how deeply selections may nest.
+const maxDepth = 10
+
+// ResolveFunc computes a field of parent. args holds the field arguments after variables
+// and defaults are applied.
+type ResolveFunc func(ctx context.Context, parent any, args map[string]any) (any, error)
+
+// Field describes one field of an Object. Type is nil for scalar fields, whose resolved
+// values are encoded as JSON; object fields may resolve to nil, a value or a slice.
+type Field struct {
+	Type    *Object
+	Args    map[string]Arg
+	Resolve ResolveFunc
+}
+
+// Arg declares a field argument.
+type Arg struct {
+	Required bool
+	Default  any
+}
+
+// Object is a named object type.
+type Object struct {
+	Name   string
+	Fields map[string]*Field
+}
+
+// Schema is the set of types reachable from the query root.
+type Schema struct {
+	Query *Object
+}
+
+// Request is a GraphQL request as posted over HTTP.
+type Request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName,omitempty"`
+	Variables     map[string]any `json:"variables,omitempty"`
+}
+
+// Error reports a failure, located by Path when it happened while resolving a field.
+type Error struct {
+	Message string `json:"message"`
+	Path    []any  `json:"path,omitempty"`
+}
+
+// Response is the result of Execute. Data is nil when the request could not be executed.
+type Response struct {
+	Data   *Map    `json:"data"`
+	Errors []Error `json:"errors,omitempty"`
+}
+
+// Map is a JSON object that keeps its keys in selection order, as GraphQL responses must.
+type Map struct {
+	keys   []string
+	values map[string]any
+}
+
+// Get returns the value of key.
+func (m *Map) Get(key string) any {
+	return m.values[key]
+}
+
+func (m *Map) set(key string, v any) {
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = v
+}
+
+// MarshalJSON implements json.Marshaler.
+func (m *Map) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, k := range m.keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		key, _ := json.Marshal(k)
+		b.Write(key)
+		b.WriteByte(':')
+		v, err := json.Marshal(m.values[k])
+		if err != nil {
+			return nil, err
+		}
+		b.Write(v)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// Execute runs req against the schema. Resolver errors null the failing field and are
+// reported with its path; the rest of the response is still returned.
+func (s *Schema) Execute(ctx context.Context, req Request) Response {
+	doc, err := parse(req.Query)
+	if err != nil {
+		return Response{Errors: []Error{{Message: err.Error()}}}
+	}
+	op, err := doc.operation(req.OperationName)
+	if err != nil {
+		return Response{Errors: []Error{{Message: err.Error()}}}
+	}
+	vars, err := op.coerceVariables(req.Variables)
+	if err != nil {
+		return Response{Errors: []Error{{Message: err.Error()}}}
+	}
+
+	ex := &executor{doc: doc, vars: vars}
+	data := ex.selectFields(ctx, s.Query, nil, op.selection, nil)
+	return Response{Data: data, Errors: ex.errors}
+}
+
+func (d *document) operation(name string) (*operation, error) {
+	if name == "" {
+		if len(d.operations) > 1 {
+			return nil, errors.New("operationName is required when the document has several operations")
+		}
+		return d.operations[0], nil
+	}
+	for _, op := range d.operations {
+		if op.name == name {
+			return op, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown operation %q", name)
+}
+
+func (op *operation) coerceVariables(given map[string]any) (map[string]any, error) {
+	vars := make(map[string]any, len(op.variables))
+	for _, def := range op.variables {
+		v, ok := given[def.name]
+		switch {
+		case ok:
+			vars[def.name] = v
+		case def.hasDef:
+			vars[def.name] = def.def
+		case def.required:
+			return nil, fmt.Errorf("variable $%s is required", def.name)
+		}
+	}
+	return vars, nil
+}
+
+type executor struct {
+	doc    *document
+	vars   map[string]any
+	errors []Error
+}
+
+func (ex *executor) fail(path []any, format string, args ...any) {
+	ex.errors = append(ex.errors, Error{Message: fmt.Sprintf(format, args...), Path: append([]any(nil), path...)})
+}
+
+func (ex *executor) selectFields(ctx context.Context, obj *Object, parent any, set []*selection, path []any) *Map {
+	out := &Map{values: make(map[string]any)}
+	ex.collect(ctx, obj, parent, set, path, out, make(map[string]bool))
+	return out
+}
+
+func (ex *executor) collect(ctx context.Context, obj *Object, parent any, set []*selection, path []any, out *Map, visited map[string]bool) {
+	if depth(path) > maxDepth {
+		ex.fail(path, "query exceeds the maximum depth of %d", maxDepth)
+		return
+	}
+	for _, sel := range set {
+		switch {
+		case sel.spread != "":
+			frag, ok := ex.doc.fragments[sel.spread]
+			if !ok {
+				ex.fail(path, "unknown fragment %q", sel.spread)
+				continue
+			}
+			if visited[sel.spread] || frag.typeName != obj.Name {
+				continue
+			}
+			visited[sel.spread] = true
+			ex.collect(ctx, obj, parent, frag.selection, path, out, visited)
+		case sel.inline:
+			if sel.typeName == "" || sel.typeName == obj.Name {
+				ex.collect(ctx, obj, parent, sel.selection, path, out, visited)
+			}
+		default:
+			if _, done := out.values[sel.alias]; done {
+				continue
+			}
+			out.set(sel.alias, ex.resolve(ctx, obj, parent, sel, append(path, sel.alias)))
+		}
+	}
+}
+
+func (ex *executor) resolve(ctx context.Context, obj *Object, parent any, sel *selection, path []any) any {
+	if sel.name == "__typename" {
+		return obj.Name
+	}
+	field, ok := obj.Fields[sel.name]
+	if !ok {
+		ex.fail(path, "type %s has no field %q", obj.Name, sel.name)
+		return nil
+	}
+	args, err := ex.arguments(field, sel)
+	if err != nil {
+		ex.fail(path, "%v", err)
+		return nil
+	}
+	v, err := field.Resolve(ctx, parent, args)
+	if err != nil {
+		ex.fail(path, "%v", err)
+		return nil
+	}
+	return ex.complete(ctx, field, sel, v, path)
+}
+
+func (ex *executor) arguments(field *Field, sel *selection) (map[string]any, error) {
+	args := make(map[string]any, len(field.Args))
+	for name := range sel.args {
+		if _, ok := field.Args[name]; !ok {
+			return nil, fmt.Errorf("unknown argument %q", name)
+		}
+	}
+	for name, arg := range field.Args {
+		v, ok := sel.args[name]
+		if ok {
+			v, ok = ex.substitute(v)
+		}
+		switch {
+		case ok && v != nil:
+			args[name] = v
+		case arg.Default != nil:
+			args[name] = arg.Default
+		case arg.Required:
+			return nil, fmt.Errorf("argument %q is required", name)
+		}
+	}
+	return args, nil
+}
+
+// substitute replaces variables in v, reporting false when v names an unset variable.
+func (ex *executor) substitute(v any) (any, bool) {
+	switch v := v.(type) {
+	case variable:
+		value, ok := ex.vars[string(v)]
+		return value, ok
+	case []any:
+		out := make([]any, 0, len(v))
+		for _, item := range v {
+			if item, ok := ex.substitute(item); ok {
+				out = append(out, item)
+			}
+		}
+		return out, true
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, item := range v {
+			if item, ok := ex.substitute(item); ok {
+				out[k] = item
+			}
+		}
+		return out, true
+	}
+	return v, true
+}
+
+func (ex *executor) complete(ctx context.Context, field *Field, sel *selection, v any, path []any) any {
+	if field.Type == nil {
+		if sel.selection != nil {
+			ex.fail(path, "field %q is a scalar and takes no selection", sel.name)
+			return nil
+		}
+		return v
+	}
+	if sel.selection == nil {
+		ex.fail(path, "field %q of type %s needs a selection", sel.name, field.Type.Name)
+		return nil
+	}
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() || ((rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Slice) && rv.IsNil()) {
+		if rv.Kind() == reflect.Slice {
+			return []any{}
+		}
+		return nil
+	}
+	if rv.Kind() != reflect.Slice {
+		return ex.selectFields(ctx, field.Type, v, sel.selection, path)
+	}
+	list := make([]any, rv.Len())
+	for i := range list {
+		list[i] = ex.selectFields(ctx, field.Type, rv.Index(i).Interface(), sel.selection, append(path, i))
+	}
+	return list
+}
+
+// depth counts the fields in path, leaving out list indexes.
+func depth(path []any) int {
+	n := 0
+	for _, p := range path {
+		if _, ok := p.(string); ok {
+			n++
+		}
+	}
+	return n
+}
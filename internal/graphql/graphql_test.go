@@ -0,0 +1,134 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type book struct {
+	Title  string
+	Author string
+	Pages  int
+}
+
+func testSchema() *Schema {
+	authorType := &Object{Name: "Author", Fields: map[string]*Field{
+		"name": {Resolve: func(ctx context.Context, parent any, args map[string]any) (any, error) {
+			return parent.(string), nil
+		}},
+	}}
+	bookType := &Object{Name: "Book", Fields: map[string]*Field{
+		"title": {Resolve: func(ctx context.Context, parent any, args map[string]any) (any, error) {
+			return parent.(book).Title, nil
+		}},
+		"pages": {Resolve: func(ctx context.Context, parent any, args map[string]any) (any, error) {
+			return parent.(book).Pages, nil
+		}},
+		"author": {Type: authorType, Resolve: func(ctx context.Context, parent any, args map[string]any) (any, error) {
+			if parent.(book).Author == "" {
+				return nil, nil
+			}
+			return parent.(book).Author, nil
+		}},
+		"isbn": {Resolve: func(ctx context.Context, parent any, args map[string]any) (any, error) {
+			return nil, errors.New("isbn lookup failed")
+		}},
+	}}
+	books := []book{{"Dune", "Herbert", 412}, {"Emma", "Austen", 474}, {"Beowulf", "", 200}}
+	return &Schema{Query: &Object{Name: "Query", Fields: map[string]*Field{
+		"books": {
+			Type: bookType,
+			Args: map[string]Arg{"minPages": {}, "limit": {Default: 10}},
+			Resolve: func(ctx context.Context, parent any, args map[string]any) (any, error) {
+				minPages, _ := args["minPages"].(int)
+				if f, ok := args["minPages"].(float64); ok {
+					minPages = int(f)
+				}
+				var out []book
+				for _, b := range books {
+					if b.Pages >= minPages && len(out) < args["limit"].(int) {
+						out = append(out, b)
+					}
+				}
+				return out, nil
+			},
+		},
+		"book": {
+			Type: bookType,
+			Args: map[string]Arg{"title": {Required: true}},
+			Resolve: func(ctx context.Context, parent any, args map[string]any) (any, error) {
+				for _, b := range books {
+					if b.Title == args["title"] {
+						return b, nil
+					}
+				}
+				return nil, nil
+			},
+		},
+	}}}
+}
+
+func execute(t *testing.T, req Request) (string, []Error) {
+	t.Helper()
+	resp := testSchema().Execute(context.Background(), req)
+	if resp.Data == nil {
+		return "", resp.Errors
+	}
+	data, err := json.Marshal(resp.Data)
+	require.NoError(t, err)
+	return string(data), resp.Errors
+}
+
+func TestExecuteSelectsFieldsInOrder(t *testing.T) {
+	data, errs := execute(t, Request{Query: `
+		# long books only
+		query Long($min: Int = 0) {
+			books(minPages: $min) { title, by: author { name } ...Size }
+			first: book(title: "Dune") { __typename title }
+		}
+		fragment Size on Book { pages }`,
+		Variables: map[string]any{"min": 400.0}})
+	require.Empty(t, errs)
+	require.JSONEq(t, `{
+		"books": [
+			{"title": "Dune", "by": {"name": "Herbert"}, "pages": 412},
+			{"title": "Emma", "by": {"name": "Austen"}, "pages": 474}
+		],
+		"first": {"__typename": "Book", "title": "Dune"}
+	}`, data)
+	require.Regexp(t, `^\{"books":\[\{"title":"Dune","by"`, data)
+}
+
+func TestExecuteReportsFieldErrorsWithPaths(t *testing.T) {
+	data, errs := execute(t, Request{Query: `{ books(limit: 1) { title isbn ... on Book { author { name } } } missing }`})
+	require.JSONEq(t, `{"books": [{"title": "Dune", "isbn": null, "author": {"name": "Herbert"}}], "missing": null}`, data)
+	require.Equal(t, []Error{
+		{Message: "isbn lookup failed", Path: []any{"books", 0, "isbn"}},
+		{Message: `type Query has no field "missing"`, Path: []any{"missing"}},
+	}, errs)
+
+	data, _ = execute(t, Request{Query: `{ book(title: "Beowulf") { author { name } } }`})
+	require.JSONEq(t, `{"book": {"author": null}}`, data)
+}
+
+func TestExecuteRejectsInvalidRequests(t *testing.T) {
+	for query, want := range map[string]string{
+		`{ books { title }`:                                       "unterminated selection set",
+		`mutation { books { title } }`:                            "mutation operations are not supported",
+		`{ books @skip(if: true) { title } }`:                     "directives are not supported",
+		`query A { books { title } } query B { books { title } }`: "operationName is required",
+		`query Q($t: String!) { book(title: $t) { title } }`:      "variable $t is required",
+	} {
+		_, errs := execute(t, Request{Query: query})
+		require.Len(t, errs, 1, query)
+		require.Contains(t, errs[0].Message, want, query)
+	}
+
+	_, errs := execute(t, Request{Query: `{ book { title } books { title { x } } }`})
+	require.Equal(t, `argument "title" is required`, errs[0].Message)
+	require.Equal(t, `field "title" is a scalar and takes no selection`, errs[1].Message)
+}
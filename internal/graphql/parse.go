@@ -0,0 +1,384 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// document is a parsed query: its operations and named fragments.
+type document struct {
+	operations []*operation
+	fragments  map[string]*fragment
+}
+
+type operation struct {
+	name      string
+	variables []variableDef
+	selection []*selection
+}
+
+type variableDef struct {
+	name     string
+	required bool
+	def      any
+	hasDef   bool
+}
+
+type fragment struct {
+	typeName  string
+	selection []*selection
+}
+
+// selection is a field, a fragment spread (spread set) or an inline fragment (inline set).
+type selection struct {
+	alias     string
+	name      string
+	args      map[string]any
+	selection []*selection
+
+	spread   string
+	inline   bool
+	typeName string
+}
+
+// variable is an argument value naming a request variable.
+type variable string
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokPunct
+	tokName
+	tokInt
+	tokFloat
+	tokString
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	pos   int
+}
+
+type parser struct {
+	src string
+	pos int
+	tok token
+}
+
+// parse reads a query document. Mutations, subscriptions and directives are not supported.
+func parse(src string) (doc *document, err error) {
+	p := &parser{src: src}
+	defer func() {
+		if r := recover(); r != nil {
+			perr, ok := r.(parseError)
+			if !ok {
+				panic(r)
+			}
+			doc, err = nil, perr
+		}
+	}()
+	p.next()
+
+	doc = &document{fragments: make(map[string]*fragment)}
+	for p.tok.kind != tokEOF {
+		switch {
+		case p.peek(tokPunct, "{"):
+			doc.operations = append(doc.operations, &operation{selection: p.selectionSet()})
+		case p.peek(tokName, "query"):
+			p.next()
+			op := &operation{}
+			if p.tok.kind == tokName {
+				op.name = p.tok.value
+				p.next()
+			}
+			if p.peek(tokPunct, "(") {
+				op.variables = p.variableDefs()
+			}
+			op.selection = p.selectionSet()
+			doc.operations = append(doc.operations, op)
+		case p.peek(tokName, "fragment"):
+			p.next()
+			name := p.name()
+			p.expectName("on")
+			doc.fragments[name] = &fragment{typeName: p.name(), selection: p.selectionSet()}
+		case p.peek(tokName, "mutation"), p.peek(tokName, "subscription"):
+			p.fail("%s operations are not supported", p.tok.value)
+		default:
+			p.fail("unexpected %q", p.tok.value)
+		}
+	}
+	if len(doc.operations) == 0 {
+		p.fail("document has no operation")
+	}
+	return doc, nil
+}
+
+type parseError struct {
+	msg string
+}
+
+func (e parseError) Error() string { return e.msg }
+
+func (p *parser) fail(format string, args ...any) {
+	line := 1 + strings.Count(p.src[:min(p.tok.pos, len(p.src))], "\n")
+	panic(parseError{fmt.Sprintf("syntax error at line %d: %s", line, fmt.Sprintf(format, args...))})
+}
+
+func (p *parser) peek(kind tokenKind, value string) bool {
+	return p.tok.kind == kind && p.tok.value == value
+}
+
+func (p *parser) expect(value string) {
+	if !p.peek(tokPunct, value) {
+		p.fail("expected %q, found %q", value, p.tok.value)
+	}
+	p.next()
+}
+
+func (p *parser) expectName(value string) {
+	if !p.peek(tokName, value) {
+		p.fail("expected %q, found %q", value, p.tok.value)
+	}
+	p.next()
+}
+
+func (p *parser) name() string {
+	if p.tok.kind != tokName {
+		p.fail("expected a name, found %q", p.tok.value)
+	}
+	name := p.tok.value
+	p.next()
+	return name
+}
+
+func (p *parser) variableDefs() []variableDef {
+	p.expect("(")
+	var defs []variableDef
+	for !p.peek(tokPunct, ")") {
+		p.expect("$")
+		def := variableDef{name: p.name()}
+		p.expect(":")
+		def.required = p.typeRef()
+		if p.peek(tokPunct, "=") {
+			p.next()
+			def.def, def.hasDef = p.value(true), true
+		}
+		defs = append(defs, def)
+	}
+	p.next()
+	return defs
+}
+
+// typeRef skips a type such as [String!]! and reports whether it is non-null.
+func (p *parser) typeRef() bool {
+	if p.peek(tokPunct, "[") {
+		p.next()
+		p.typeRef()
+		p.expect("]")
+	} else {
+		p.name()
+	}
+	if p.peek(tokPunct, "!") {
+		p.next()
+		return true
+	}
+	return false
+}
+
+func (p *parser) selectionSet() []*selection {
+	p.expect("{")
+	var set []*selection
+	for !p.peek(tokPunct, "}") {
+		if p.tok.kind == tokEOF {
+			p.fail("unterminated selection set")
+		}
+		set = append(set, p.selection())
+	}
+	p.next()
+	if len(set) == 0 {
+		p.fail("empty selection set")
+	}
+	return set
+}
+
+func (p *parser) selection() *selection {
+	if p.peek(tokPunct, "...") {
+		p.next()
+		if p.peek(tokName, "on") {
+			p.next()
+			return &selection{inline: true, typeName: p.name(), selection: p.selectionSet()}
+		}
+		if p.peek(tokPunct, "{") {
+			return &selection{inline: true, selection: p.selectionSet()}
+		}
+		return &selection{spread: p.name()}
+	}
+
+	sel := &selection{name: p.name()}
+	sel.alias = sel.name
+	if p.peek(tokPunct, ":") {
+		p.next()
+		sel.name = p.name()
+	}
+	if p.peek(tokPunct, "(") {
+		p.next()
+		sel.args = make(map[string]any)
+		for !p.peek(tokPunct, ")") {
+			name := p.name()
+			p.expect(":")
+			sel.args[name] = p.value(false)
+		}
+		p.next()
+	}
+	if p.peek(tokPunct, "@") {
+		p.fail("directives are not supported")
+	}
+	if p.peek(tokPunct, "{") {
+		sel.selection = p.selectionSet()
+	}
+	return sel
+}
+
+// value reads an input value; constant values (variable defaults) may not name variables.
+func (p *parser) value(constant bool) any {
+	tok := p.tok
+	switch {
+	case tok.kind == tokPunct && tok.value == "$" && !constant:
+		p.next()
+		return variable(p.name())
+	case tok.kind == tokPunct && tok.value == "[":
+		p.next()
+		list := []any{}
+		for !p.peek(tokPunct, "]") {
+			if p.tok.kind == tokEOF {
+				p.fail("unterminated list")
+			}
+			list = append(list, p.value(constant))
+		}
+		p.next()
+		return list
+	case tok.kind == tokPunct && tok.value == "{":
+		p.next()
+		obj := map[string]any{}
+		for !p.peek(tokPunct, "}") {
+			name := p.name()
+			p.expect(":")
+			obj[name] = p.value(constant)
+		}
+		p.next()
+		return obj
+	case tok.kind == tokInt:
+		p.next()
+		n, err := strconv.ParseInt(tok.value, 10, 64)
+		if err != nil {
+			p.fail("invalid integer %s", tok.value)
+		}
+		return int(n)
+	case tok.kind == tokFloat:
+		p.next()
+		f, err := strconv.ParseFloat(tok.value, 64)
+		if err != nil {
+			p.fail("invalid float %s", tok.value)
+		}
+		return f
+	case tok.kind == tokString:
+		p.next()
+		return tok.value
+	case tok.kind == tokName:
+		p.next()
+		switch tok.value {
+		case "true":
+			return true
+		case "false":
+			return false
+		case "null":
+			return nil
+		}
+		return tok.value // enum values are passed to resolvers as strings
+	}
+	p.fail("unexpected %q in value", tok.value)
+	return nil
+}
+
+func (p *parser) next() {
+	src := p.src
+	for p.pos < len(src) {
+		c := src[p.pos]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			p.pos++
+			continue
+		case c == '#':
+			for p.pos < len(src) && src[p.pos] != '\n' {
+				p.pos++
+			}
+			continue
+		}
+		break
+	}
+	start := p.pos
+	if p.pos >= len(src) {
+		p.tok = token{kind: tokEOF, value: "<end>", pos: start}
+		return
+	}
+
+	c := src[p.pos]
+	switch {
+	case strings.HasPrefix(src[p.pos:], "..."):
+		p.pos += 3
+		p.tok = token{kind: tokPunct, value: "...", pos: start}
+	case strings.IndexByte("!$():=@[]{}|", c) >= 0:
+		p.pos++
+		p.tok = token{kind: tokPunct, value: string(c), pos: start}
+	case c == '_' || isLetter(c):
+		for p.pos < len(src) && (src[p.pos] == '_' || isLetter(src[p.pos]) || isDigit(src[p.pos])) {
+			p.pos++
+		}
+		p.tok = token{kind: tokName, value: src[start:p.pos], pos: start}
+	case c == '-' || isDigit(c):
+		p.pos++
+		kind := tokInt
+		for p.pos < len(src) {
+			d := src[p.pos]
+			if d == '.' || d == 'e' || d == 'E' || ((d == '+' || d == '-') && (src[p.pos-1] == 'e' || src[p.pos-1] == 'E')) {
+				kind = tokFloat
+			} else if !isDigit(d) {
+				break
+			}
+			p.pos++
+		}
+		p.tok = token{kind: kind, value: src[start:p.pos], pos: start}
+	case c == '"':
+		p.tok = token{kind: tokString, value: p.stringValue(), pos: start}
+	default:
+		p.tok = token{kind: tokPunct, value: string(c), pos: start}
+		p.fail("unexpected character %q", c)
+	}
+}
+
+func (p *parser) stringValue() string {
+	end := p.pos + 1
+	for end < len(p.src) && p.src[end] != '"' && p.src[end] != '\n' {
+		if p.src[end] == '\\' {
+			end++
+		}
+		end++
+	}
+	if end >= len(p.src) || p.src[end] != '"' {
+		p.tok = token{kind: tokString, pos: p.pos}
+		p.fail("unterminated string")
+	}
+	s, err := strconv.Unquote(p.src[p.pos : end+1])
+	if err != nil {
+		p.tok = token{kind: tokString, pos: p.pos}
+		p.fail("invalid string: %v", err)
+	}
+	p.pos = end + 1
+	return s
+}
+
+func isLetter(c byte) bool { return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') }
+func isDigit(c byte) bool  { return c >= '0' && c <= '9' }
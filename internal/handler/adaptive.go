@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/berniyo/paypack-lambda/internal/metrics"
+)
+
+const (
+	// adaptiveMinSamples is how many verdicts a provider needs before its history shapes polling.
+	adaptiveMinSamples = 20
+	// adaptiveMaxBackoff caps the tail interval as a multiple of the poll interval.
+	adaptiveMaxBackoff = 4
+)
+
+// WithAdaptivePolling shapes polling from the confirmation latencies seen per provider over
+// the last span (e.g. 1h). The first lookup waits until the provider's p50, lookups then run
+// every poll interval up to its p99, and past the p99 the interval grows with the overshoot to
+// at most four poll intervals. Providers with fewer than 20 recent verdicts poll as usual.
+func WithAdaptivePolling(span time.Duration) Option {
+	return func(p *Processor) {
+		p.confirmations = nil
+		if span > 0 {
+			p.confirmations = metrics.NewWindow(span)
+		}
+	}
+}
+
+// observeConfirmation feeds a verdict's latency into the provider's history.
+func (p *Processor) observeConfirmation(provider string, latency time.Duration) {
+	if p.confirmations == nil || provider == "" {
+		return
+	}
+	p.confirmations.Add(provider, true, latency)
+}
+
+// pollDelay returns how long to wait before the next lookup of a cash-in routed to provider,
+// elapsed after it was accepted. attempt counts the lookups already made.
+func (p *Processor) pollDelay(provider string, attempt int, elapsed time.Duration) time.Duration {
+	fixed := p.pollInterval
+	if attempt == 0 {
+		fixed = 0
+	}
+	if p.confirmations == nil || provider == "" {
+		return fixed
+	}
+	stats := p.confirmations.Stats(provider)
+	if stats.Succeeded < adaptiveMinSamples {
+		return fixed
+	}
+
+	switch {
+	case elapsed < stats.P50:
+		if attempt == 0 {
+			return stats.P50 - elapsed
+		}
+		return fixed
+	case elapsed < stats.P99:
+		return fixed
+	default:
+		return min(p.pollInterval+(elapsed-stats.P99)/2, adaptiveMaxBackoff*p.pollInterval)
+	}
+}
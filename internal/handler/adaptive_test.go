@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+func TestPollDelayFollowsProviderHistory(t *testing.T) {
+	p := NewProcessor(&fakeClient{}, WithPollInterval(time.Second), WithAdaptivePolling(time.Hour))
+	require.Zero(t, p.pollDelay("mtn", 0, 0), "no history polls immediately")
+
+	for i := 1; i <= 100; i++ {
+		p.observeConfirmation("mtn", time.Duration(i)*100*time.Millisecond)
+	}
+	require.Equal(t, 5*time.Second, p.pollDelay("mtn", 0, 0), "first lookup lands on p50")
+	require.Equal(t, 3*time.Second, p.pollDelay("mtn", 0, 2*time.Second))
+	require.Equal(t, time.Second, p.pollDelay("mtn", 1, 6*time.Second))
+	require.Equal(t, 2*time.Second, p.pollDelay("mtn", 3, 11900*time.Millisecond))
+	require.Equal(t, 4*time.Second, p.pollDelay("mtn", 9, time.Minute), "tail backoff is capped")
+	require.Zero(t, p.pollDelay("airtel", 0, 0), "other providers are unaffected")
+}
+
+func TestAdaptivePollingSkipsEarlyLookups(t *testing.T) {
+	var finds atomic.Int32
+	var accepted time.Time
+	client := &fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			accepted = time.Now()
+			return &paypack.Transaction{Ref: "ref", Provider: "mtn"}, nil
+		},
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			finds.Add(1)
+			if time.Since(accepted) < 40*time.Millisecond {
+				return nil, paypack.ErrTransactionNotFound
+			}
+			return &paypack.Transaction{Ref: ref, Status: "success", Provider: "mtn"}, nil
+		},
+	}
+	p := NewProcessor(client, WithPollInterval(5*time.Millisecond), WithAdaptivePolling(time.Hour))
+	for range adaptiveMinSamples {
+		p.observeConfirmation("mtn", 45*time.Millisecond)
+	}
+
+	resp, err := p.Handle(context.Background(), SubscriptionEvent{Number: "2507", Amount: 100})
+	require.NoError(t, err)
+	require.Equal(t, "success", resp.Status)
+	require.EqualValues(t, 1, finds.Load())
+}
@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"context"
+	"time"
+
+	"github.com/berniyo/paypack-lambda/internal/analytics"
+	"github.com/berniyo/paypack-lambda/internal/eventstore"
+	"github.com/berniyo/paypack-lambda/internal/tracing"
+)
+
+// WithAnalytics emits payment_initiated, payment_confirmed and payment_failed events to sink.
+// Delivery is independent of callbacks: failures are logged and never affect the outcome.
+func WithAnalytics(sink analytics.Sink) Option {
+	return func(p *Processor) {
+		p.analytics = sink
+	}
+}
+
+// trackInitiated records a cash-in Paypack accepted for amount in currency.
+func (p *Processor) trackInitiated(ctx context.Context, event SubscriptionEvent, ref string, amount float64, currency string) {
+	p.emitAnalytics(ctx, analytics.Event{
+		Type:           analytics.PaymentInitiated,
+		Ref:            ref,
+		Status:         "pending",
+		Amount:         amount,
+		Currency:       currency,
+		Client:         event.Client,
+		SubscriptionID: event.SubscriptionID,
+		RetryAttempt:   event.RetryAttempt,
+	})
+}
+
+// trackOutcome records the final outcome carried by resp.
+func (p *Processor) trackOutcome(ctx context.Context, resp SubscriptionResponse) {
+	if p.analytics == nil {
+		return
+	}
+
+	req := resp.Request
+	event := analytics.Event{
+		Type:           analytics.PaymentFailed,
+		Ref:            resp.Reference,
+		Status:         resp.Status,
+		Amount:         req.Amount,
+		Currency:       req.Currency,
+		Client:         req.Client,
+		SubscriptionID: req.SubscriptionID,
+		RetryAttempt:   req.RetryAttempt,
+	}
+	if txn := resp.Transaction; txn != nil {
+		event.Amount = txn.Amount
+		event.Fee = txn.Fee
+		if txn.Currency != "" {
+			event.Currency = txn.Currency
+		}
+	}
+	if resp.Found && resp.Status == "success" {
+		event.Type = analytics.PaymentConfirmed
+	} else {
+		event.Reason = resp.Message
+		if event.Reason == "" {
+			event.Reason = "transaction " + resp.Status
+		}
+	}
+	p.emitAnalytics(ctx, event)
+}
+
+func (p *Processor) emitAnalytics(ctx context.Context, event analytics.Event) {
+	if p.analytics == nil {
+		return
+	}
+	event.ID = eventstore.NewID()
+	event.OccurredAt = time.Now().UTC()
+	tracing.Inject(ctx, func(key, value string) {
+		if key == tracing.HeaderTraceParent {
+			event.TraceParent = value
+		} else {
+			event.TraceState = value
+		}
+	})
+	if err := p.analytics.Emit(ctx, event); err != nil {
+		p.logf(ctx, "analytics %s for ref=%s failed: %v", event.Type, event.Ref, err)
+	}
+}
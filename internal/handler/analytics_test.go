@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/berniyo/paypack-lambda/internal/analytics"
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+func TestProcessorEmitsAnalyticsEvents(t *testing.T) {
+	client := &fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: "abc"}, nil
+		},
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: ref, Status: "success", Amount: 1000, Fee: 23}, nil
+		},
+	}
+
+	var events []analytics.Event
+	sink := analytics.SinkFunc(func(ctx context.Context, event analytics.Event) error {
+		events = append(events, event)
+		return nil
+	})
+	processor := NewProcessor(client, WithPollInterval(5*time.Millisecond), WithAnalytics(sink))
+
+	_, err := processor.Handle(context.Background(), SubscriptionEvent{Number: "2507", Amount: 1000, Client: "acme"})
+	require.NoError(t, err)
+
+	require.Len(t, events, 2)
+	require.Equal(t, analytics.PaymentInitiated, events[0].Type)
+	require.Equal(t, "abc", events[0].Ref)
+	require.Equal(t, "RWF", events[0].Currency)
+	require.Equal(t, analytics.PaymentConfirmed, events[1].Type)
+	require.Equal(t, "acme", events[1].Client)
+	require.Equal(t, 23.0, events[1].Fee)
+	require.NotEmpty(t, events[1].ID)
+	require.NotEqual(t, events[0].ID, events[1].ID)
+}
+
+func TestProcessorEmitsFailureReason(t *testing.T) {
+	client := &fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: "abc"}, nil
+		},
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			return nil, paypack.ErrTransactionNotFound
+		},
+	}
+
+	var events []analytics.Event
+	sink := analytics.SinkFunc(func(ctx context.Context, event analytics.Event) error {
+		events = append(events, event)
+		return errors.New("stream unavailable")
+	})
+	cb := &fakeCallback{}
+	processor := NewProcessor(
+		client,
+		WithPollInterval(5*time.Millisecond),
+		WithTimeout(20*time.Millisecond),
+		WithCallbackSender(cb),
+		WithAnalytics(sink),
+	)
+
+	resp, err := processor.Handle(context.Background(), SubscriptionEvent{Number: "2507", Amount: 1000})
+	require.NoError(t, err)
+	require.Equal(t, "failed", resp.Status)
+	require.Len(t, cb.calls, 1)
+
+	require.Len(t, events, 2)
+	require.Equal(t, analytics.PaymentFailed, events[1].Type)
+	require.Equal(t, "transaction not confirmed within 20ms", events[1].Reason)
+}
@@ -0,0 +1,183 @@
+package handler
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/berniyo/paypack-lambda/internal/approval"
+	"github.com/berniyo/paypack-lambda/internal/eventstore"
+)
+
+const defaultApprovalTTL = 24 * time.Hour
+
+// WithApprovalGate holds cash-ins above threshold as pending until a separate approve event
+// names them. Requests not approved within ttl (default 24h) expire.
+func WithApprovalGate(store approval.Store, threshold float64, ttl time.Duration) Option {
+	return func(p *Processor) {
+		if store == nil || threshold <= 0 {
+			return
+		}
+		p.approvals = store
+		p.approvalThreshold = threshold
+		p.approvalTTL = defaultApprovalTTL
+		if ttl > 0 {
+			p.approvalTTL = ttl
+		}
+	}
+}
+
+// WithApprovers names who may resolve held cash-ins and how their approvals are verified. An
+// approve or reject event must name one of them as approver and carry approver_signature,
+// made with that approver's key over the canonical event (see SignApproval), so the channel
+// that submits charges cannot also approve them. Without approvers nothing can be approved.
+func WithApprovers(approvers map[string]EventVerifier) Option {
+	return func(p *Processor) {
+		p.approvers = approvers
+	}
+}
+
+// ApprovalPayload is what an approver signature covers: the canonical event without
+// signature or approver_signature.
+func ApprovalPayload(event SubscriptionEvent) ([]byte, error) {
+	event.ApproverSignature = ""
+	return CanonicalEventPayload(event)
+}
+
+// SignApproval returns the base64 HMAC-SHA256 approver signature under secret of an approve
+// or reject event.
+func SignApproval(event SubscriptionEvent, secret string) (string, error) {
+	payload, err := ApprovalPayload(event)
+	if err != nil {
+		return "", fmt.Errorf("encode approval for signing: %w", err)
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// verifyApprover checks that approver is allowed and signed event.
+func (p *Processor) verifyApprover(event SubscriptionEvent, approver string) error {
+	if len(p.approvers) == 0 {
+		return errors.New("approvers are not configured")
+	}
+	verifier, ok := p.approvers[approver]
+	if !ok {
+		return withCode(CodeUnauthorized, fmt.Errorf("approver %q is not allowed", approver))
+	}
+	signature, err := base64.StdEncoding.DecodeString(event.ApproverSignature)
+	if err != nil || len(signature) == 0 {
+		return withCode(CodeUnauthorized, errors.New("approver_signature is required"))
+	}
+	payload, err := ApprovalPayload(event)
+	if err != nil {
+		return fmt.Errorf("encode approval: %w", err)
+	}
+	if !verifier.Verify(payload, signature) {
+		return withCode(CodeUnauthorized, errors.New("approver signature is invalid"))
+	}
+	return nil
+}
+
+// requiresApproval compares the settlement-currency amount against the threshold.
+func (p *Processor) requiresApproval(amount float64) bool {
+	return p.approvals != nil && amount > p.approvalThreshold
+}
+
+func (p *Processor) holdForApproval(ctx context.Context, event SubscriptionEvent, amount float64) (SubscriptionResponse, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return SubscriptionResponse{}, fmt.Errorf("encode held event: %w", err)
+	}
+
+	now := time.Now()
+	req := approval.Request{
+		ID:          eventstore.NewID(),
+		Amount:      amount,
+		Payload:     payload,
+		Status:      approval.StatusPending,
+		RequestedAt: now,
+		ExpiresAt:   now.Add(p.approvalTTL),
+	}
+	if err := p.approvals.Create(ctx, req); err != nil {
+		return SubscriptionResponse{}, storeError{fmt.Errorf("store approval request: %w", err)}
+	}
+
+	p.logf(ctx, "cashin for number=%s amount=%.2f held for approval id=%s", event.Number, amount, req.ID)
+	return SubscriptionResponse{
+		Status:     "pending_approval",
+		Message:    fmt.Sprintf("amount exceeds approval threshold of %.2f; awaiting approval", p.approvalThreshold),
+		Request:    event,
+		ApprovalID: req.ID,
+	}, nil
+}
+
+// handleApproval resolves a held cash-in. Approving executes the original event exactly once.
+// The approver must be verified by WithApprovers and must not be the held event's requested_by.
+func (p *Processor) handleApproval(ctx context.Context, event SubscriptionEvent) (SubscriptionResponse, error) {
+	if p.approvals == nil {
+		return SubscriptionResponse{}, errors.New("approval gate is not configured")
+	}
+	id := strings.TrimSpace(event.ApprovalID)
+	if id == "" {
+		return SubscriptionResponse{}, withCode(CodeValidation, errors.New("approval_id is required"))
+	}
+	approver := strings.TrimSpace(event.Approver)
+	if approver == "" {
+		return SubscriptionResponse{}, withCode(CodeValidation, errors.New("approver is required"))
+	}
+	if err := p.verifyApprover(event, approver); err != nil {
+		return SubscriptionResponse{}, err
+	}
+
+	req, err := p.approvals.Get(ctx, id)
+	if err != nil {
+		return SubscriptionResponse{}, fmt.Errorf("lookup approval request: %w", err)
+	}
+	var held SubscriptionEvent
+	if err := json.Unmarshal(req.Payload, &held); err != nil {
+		return SubscriptionResponse{}, fmt.Errorf("decode held event: %w", err)
+	}
+	if requester := strings.TrimSpace(held.RequestedBy); requester != "" && strings.EqualFold(requester, approver) {
+		return SubscriptionResponse{}, withCode(CodeUnauthorized, fmt.Errorf("approver %q requested this cashin", approver))
+	}
+	now := time.Now()
+	if req.Status == approval.StatusPending && req.Expired(now) {
+		return SubscriptionResponse{}, withCode(CodeValidation, fmt.Errorf("approval request %s expired at %s", id, req.ExpiresAt.Format(time.RFC3339)))
+	}
+
+	status := approval.StatusApproved
+	if event.Action == ActionReject {
+		status = approval.StatusRejected
+	}
+	req, err = p.approvals.Resolve(ctx, id, status, approver, now)
+	if err != nil {
+		return SubscriptionResponse{}, fmt.Errorf("resolve approval request %s: %w", id, err)
+	}
+
+	p.logf(ctx, "approval id=%s %s by %s", id, status, approver)
+	p.recordAudit(ctx, auditApproval, "", req)
+	if status == approval.StatusRejected {
+		return SubscriptionResponse{
+			Status:     "rejected",
+			Message:    "cashin rejected by " + approver,
+			Request:    held,
+			ApprovalID: id,
+		}, nil
+	}
+
+	held.ApprovalID = id
+	held.Approver = approver
+	resp, err := p.runCashIn(ctx, held)
+	if err != nil {
+		return SubscriptionResponse{}, err
+	}
+	resp.ApprovalID = id
+	return resp, nil
+}
@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/berniyo/paypack-lambda/internal/approval"
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+var testApprovers = WithApprovers(map[string]EventVerifier{"ops": HMACEventVerifier("ops-key"), "alice": HMACEventVerifier("alice-key")})
+
+// approvalBy returns an approve or reject event for id signed by approver's test key.
+func approvalBy(t *testing.T, action, id, approver string) SubscriptionEvent {
+	t.Helper()
+	event := SubscriptionEvent{Action: action, ApprovalID: id, Approver: approver}
+	var err error
+	event.ApproverSignature, err = SignApproval(event, approver+"-key")
+	require.NoError(t, err)
+	return event
+}
+
+func TestProcessorApprovalGate(t *testing.T) {
+	cashIns := 0
+	client := &fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			cashIns++
+			return &paypack.Transaction{Ref: "abc"}, nil
+		},
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: ref, Status: "success"}, nil
+		},
+	}
+
+	cb := &fakeCallback{}
+	processor := NewProcessor(
+		client,
+		WithPollInterval(5*time.Millisecond),
+		WithTimeout(200*time.Millisecond),
+		WithCallbackSender(cb),
+		WithApprovalGate(approval.NewMemoryStore(), 50000, 0),
+		testApprovers,
+	)
+	ctx := context.Background()
+
+	resp, err := processor.Handle(ctx, SubscriptionEvent{Number: "2507", Amount: 1000})
+	require.NoError(t, err)
+	require.Equal(t, "success", resp.Status)
+	require.Equal(t, 1, cashIns)
+
+	held, err := processor.Handle(ctx, SubscriptionEvent{Number: "2507", Amount: 100000})
+	require.NoError(t, err)
+	require.Equal(t, "pending_approval", held.Status)
+	require.NotEmpty(t, held.ApprovalID)
+	require.Equal(t, 1, cashIns)
+
+	_, err = processor.Handle(ctx, SubscriptionEvent{Action: ActionApprove, ApprovalID: held.ApprovalID})
+	require.EqualError(t, err, "approver is required")
+
+	resp, err = processor.Handle(ctx, approvalBy(t, ActionApprove, held.ApprovalID, "ops"))
+	require.NoError(t, err)
+	require.Equal(t, "success", resp.Status)
+	require.Equal(t, held.ApprovalID, resp.ApprovalID)
+	require.Equal(t, float64(100000), resp.Request.Amount)
+	require.Equal(t, 2, cashIns)
+
+	_, err = processor.Handle(ctx, approvalBy(t, ActionApprove, held.ApprovalID, "ops"))
+	require.ErrorIs(t, err, approval.ErrNotPending)
+	require.Equal(t, 2, cashIns)
+}
+
+func TestProcessorApprovalReject(t *testing.T) {
+	client := &fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			t.Fatal("rejected cashin must not execute")
+			return nil, nil
+		},
+	}
+	processor := NewProcessor(client, WithApprovalGate(approval.NewMemoryStore(), 50000, time.Hour), testApprovers)
+	ctx := context.Background()
+
+	held, err := processor.Handle(ctx, SubscriptionEvent{Number: "2507", Amount: 100000})
+	require.NoError(t, err)
+
+	resp, err := processor.Handle(ctx, approvalBy(t, ActionReject, held.ApprovalID, "ops"))
+	require.NoError(t, err)
+	require.Equal(t, "rejected", resp.Status)
+}
+
+func TestProcessorApprovalNeedsAuthenticatedApprover(t *testing.T) {
+	client := &fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			t.Fatal("unapproved cashin must not execute")
+			return nil, nil
+		},
+	}
+	processor := NewProcessor(client, WithApprovalGate(approval.NewMemoryStore(), 50000, time.Hour), testApprovers)
+	ctx := context.Background()
+
+	held, err := processor.Handle(ctx, SubscriptionEvent{Number: "2507", Amount: 100000, RequestedBy: "alice"})
+	require.NoError(t, err)
+
+	_, err = processor.Handle(ctx, SubscriptionEvent{Action: ActionApprove, ApprovalID: held.ApprovalID, Approver: "ops"})
+	require.Equal(t, CodeUnauthorized, CodeOf(err), "an approver name alone is not enough")
+
+	forged := approvalBy(t, ActionApprove, held.ApprovalID, "ops")
+	forged.Approver = "mallory"
+	_, err = processor.Handle(ctx, forged)
+	require.EqualError(t, err, `approver "mallory" is not allowed`)
+
+	rejection := approvalBy(t, ActionReject, held.ApprovalID, "ops")
+	rejection.Action = ActionApprove
+	_, err = processor.Handle(ctx, rejection)
+	require.EqualError(t, err, "approver signature is invalid", "a signed rejection cannot be replayed as an approval")
+
+	_, err = processor.Handle(ctx, approvalBy(t, ActionApprove, held.ApprovalID, "alice"))
+	require.EqualError(t, err, `approver "alice" requested this cashin`)
+	require.Equal(t, CodeUnauthorized, CodeOf(err))
+
+	_, err = NewProcessor(client, WithApprovalGate(approval.NewMemoryStore(), 50000, time.Hour)).
+		Handle(ctx, approvalBy(t, ActionApprove, held.ApprovalID, "ops"))
+	require.EqualError(t, err, "approvers are not configured")
+}
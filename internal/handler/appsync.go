@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultAppSyncMutation publishes an outcome through a mutation that clients subscribe to
+// with @aws_subscribe(mutations: ["publishPaymentOutcome"]). Custom mutations must declare
+// the same $input variable.
+const DefaultAppSyncMutation = `mutation PublishPaymentOutcome($input: PaymentOutcomeInput!) {
+  publishPaymentOutcome(input: $input) { ref status client subscriptionId amount currency code message occurredAt }
+}`
+
+// AppSyncMutator runs a GraphQL mutation against an AppSync API; appsync.Client implements it.
+type AppSyncMutator interface {
+	Mutate(ctx context.Context, query string, variables map[string]any) error
+}
+
+// AppSyncSender publishes outcomes as AppSync mutations, so mobile clients subscribed to the
+// mutation get the confirmation in realtime over AppSync's own WebSockets.
+type AppSyncSender struct {
+	mutator  AppSyncMutator
+	mutation string
+	now      func() time.Time
+}
+
+// NewAppSyncSender builds a sender running mutation (DefaultAppSyncMutation when empty).
+func NewAppSyncSender(mutator AppSyncMutator, mutation string) (*AppSyncSender, error) {
+	if mutator == nil {
+		return nil, errors.New("appsync client is required")
+	}
+	mutation = orDefault(strings.TrimSpace(mutation), DefaultAppSyncMutation)
+	if !strings.Contains(mutation, "$input") {
+		return nil, errors.New("appsync mutation must declare an $input variable")
+	}
+	return &AppSyncSender{mutator: mutator, mutation: mutation, now: time.Now}, nil
+}
+
+// Send implements CallbackSender. The $input variable carries the outcome's ref, status,
+// client, subscriptionId, amount, currency, code, message and occurredAt; customer numbers
+// are left out since subscribers are end-user devices.
+func (a *AppSyncSender) Send(ctx context.Context, payload SubscriptionResponse) error {
+	input := map[string]any{
+		"ref":        payload.Reference,
+		"status":     payload.Status,
+		"occurredAt": a.now().UTC().Format(time.RFC3339),
+	}
+	setNonEmpty := func(key, value string) {
+		if value != "" {
+			input[key] = value
+		}
+	}
+	setNonEmpty("client", payload.Request.Client)
+	setNonEmpty("subscriptionId", payload.Request.SubscriptionID)
+	setNonEmpty("code", string(payload.Code))
+	setNonEmpty("message", payload.Message)
+	if amount, currency := outcomeAmount(payload); amount > 0 {
+		input["amount"] = amount
+		input["currency"] = currency
+	}
+
+	if err := a.mutator.Mutate(ctx, a.mutation, map[string]any{"input": input}); err != nil {
+		return fmt.Errorf("publish appsync outcome: %w", err)
+	}
+	return nil
+}
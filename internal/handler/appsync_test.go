@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+type fakeMutator struct {
+	query     string
+	variables map[string]any
+	err       error
+}
+
+func (f *fakeMutator) Mutate(ctx context.Context, query string, variables map[string]any) error {
+	f.query, f.variables = query, variables
+	return f.err
+}
+
+func TestAppSyncSenderPublishesOutcome(t *testing.T) {
+	mutator := &fakeMutator{}
+	sender, err := NewAppSyncSender(mutator, "")
+	require.NoError(t, err)
+	sender.now = func() time.Time { return time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC) }
+
+	require.NoError(t, sender.Send(context.Background(), SubscriptionResponse{
+		Reference:   "abc",
+		Status:      "success",
+		Found:       true,
+		Request:     SubscriptionEvent{Number: "0788000001", Client: "acme", SubscriptionID: "sub-1"},
+		Transaction: &paypack.Transaction{Ref: "abc", Amount: 1000, Currency: "RWF"},
+	}))
+	require.Equal(t, DefaultAppSyncMutation, mutator.query)
+	require.Equal(t, map[string]any{"input": map[string]any{
+		"ref":            "abc",
+		"status":         "success",
+		"client":         "acme",
+		"subscriptionId": "sub-1",
+		"amount":         1000.0,
+		"currency":       "RWF",
+		"occurredAt":     "2026-10-14T09:00:00Z",
+	}}, mutator.variables)
+
+	mutator.err = errors.New("appsync mutation failed: Unauthorized")
+	require.ErrorContains(t, sender.Send(context.Background(), SubscriptionResponse{Reference: "abc"}), "publish appsync outcome")
+}
+
+func TestNewAppSyncSenderValidatesMutation(t *testing.T) {
+	_, err := NewAppSyncSender(nil, "")
+	require.Error(t, err)
+	_, err = NewAppSyncSender(&fakeMutator{}, "mutation { ping }")
+	require.EqualError(t, err, "appsync mutation must declare an $input variable")
+}
@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"context"
+	"sync"
+)
+
+// asyncCallbacks tracks callbacks delivered in the background.
+type asyncCallbacks struct {
+	slots chan struct{}
+	wg    sync.WaitGroup
+}
+
+// WithAsyncCallbacks delivers callbacks on up to concurrency goroutines instead of inline, so
+// a slow endpoint does not hold up the rest of an outcome or the next item of a batch. Every
+// entry point waits for outstanding deliveries before it returns, until the invocation
+// deadline, so none is lost when Lambda freezes the execution environment. Failed deliveries
+// are logged, counted and recorded as usual, but cannot set CALLBACK_FAILED on a response
+// that may already be complete.
+func WithAsyncCallbacks(concurrency int) Option {
+	return func(p *Processor) {
+		if concurrency > 0 {
+			p.asyncCallbacks = &asyncCallbacks{slots: make(chan struct{}, concurrency)}
+		}
+	}
+}
+
+// dispatchCallback sends resp on a goroutine, waiting for a free slot first. It reports false
+// when ctx ended before one freed up, leaving the caller to deliver inline.
+func (p *Processor) dispatchCallback(ctx context.Context, resp SubscriptionResponse) bool {
+	a := p.asyncCallbacks
+	select {
+	case a.slots <- struct{}{}:
+	case <-ctx.Done():
+		return false
+	}
+	a.wg.Add(1)
+	go func() {
+		defer func() {
+			<-a.slots
+			a.wg.Done()
+		}()
+		_ = p.deliverCallback(context.WithoutCancel(ctx), resp)
+	}()
+	return true
+}
+
+// waitCallbacks blocks until background deliveries finish or ctx ends.
+func (p *Processor) waitCallbacks(ctx context.Context) {
+	a := p.asyncCallbacks
+	if a == nil {
+		return
+	}
+	done := make(chan struct{})
+	go func() {
+		a.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		p.logf(ctx, "returning with %d callbacks still in flight", len(a.slots))
+	}
+}
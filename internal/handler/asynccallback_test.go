@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+type slowCallback struct {
+	delay     time.Duration
+	mu        sync.Mutex
+	calls     []SubscriptionResponse
+	inFlight  atomic.Int32
+	maxFlight atomic.Int32
+}
+
+func (s *slowCallback) Send(ctx context.Context, payload SubscriptionResponse) error {
+	n := s.inFlight.Add(1)
+	defer s.inFlight.Add(-1)
+	for {
+		m := s.maxFlight.Load()
+		if n <= m || s.maxFlight.CompareAndSwap(m, n) {
+			break
+		}
+	}
+	time.Sleep(s.delay)
+	s.mu.Lock()
+	s.calls = append(s.calls, payload)
+	s.mu.Unlock()
+	return nil
+}
+
+func TestAsyncCallbacksAreFlushedBeforeHandleReturns(t *testing.T) {
+	client := &fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: number}, nil
+		},
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: ref, Status: "success"}, nil
+		},
+	}
+	callback := &slowCallback{delay: 30 * time.Millisecond}
+	processor := NewProcessor(client, WithCallbackSender(callback), WithAsyncCallbacks(4), WithBatchConcurrency(4))
+
+	items := make([]SubscriptionEvent, 4)
+	for i := range items {
+		items[i] = SubscriptionEvent{Number: fmt.Sprintf("07880000%02d", i), Amount: 100}
+	}
+	resp, err := processor.Handle(context.Background(), SubscriptionEvent{Action: ActionBatch, Items: items})
+	require.NoError(t, err)
+	require.Equal(t, 4, resp.Batch.Succeeded)
+
+	callback.mu.Lock()
+	defer callback.mu.Unlock()
+	require.Len(t, callback.calls, 4)
+	require.Zero(t, callback.inFlight.Load())
+	require.LessOrEqual(t, callback.maxFlight.Load(), int32(4))
+}
+
+func TestAsyncCallbackWaitStopsAtDeadline(t *testing.T) {
+	callback := &slowCallback{delay: time.Second}
+	processor := NewProcessor(&fakeClient{}, WithCallbackSender(callback), WithAsyncCallbacks(1))
+	require.NoError(t, processor.emitCallback(context.Background(), SubscriptionResponse{Reference: "abc"}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	processor.waitCallbacks(ctx)
+	require.Less(t, time.Since(start), 500*time.Millisecond)
+}
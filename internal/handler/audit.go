@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/berniyo/paypack-lambda/internal/audit"
+	"github.com/berniyo/paypack-lambda/internal/redact"
+)
+
+// Audit actions written to the hash chain.
+const (
+	auditOutcome  = "payment_outcome"
+	auditApproval = "approval_resolved"
+)
+
+// WithAuditLog appends every payment outcome and approval decision to a tamper-evident
+// hash chain and enables the audit_verify action.
+func WithAuditLog(log *audit.Log) Option {
+	return func(p *Processor) {
+		p.audit = log
+	}
+}
+
+func (p *Processor) recordAudit(ctx context.Context, action, ref string, v any) {
+	if p.audit == nil {
+		return
+	}
+	payload, err := json.Marshal(v)
+	if err != nil {
+		p.logf(ctx, "encode audit %s for ref=%s: %v", action, ref, err)
+		return
+	}
+	if _, err := p.audit.Append(ctx, action, ref, p.auditPayload(payload)); err != nil {
+		p.logf(ctx, "audit %s for ref=%s failed: %v", action, ref, err)
+	}
+}
+
+// auditPayload redacts payload for the chain. With an erasure key, numbers are written as
+// their erasure tokens and metadata is left out, so erasing a customer never has to alter a
+// record that is already chained.
+func (p *Processor) auditPayload(payload []byte) []byte {
+	if len(p.erasureKey) > 0 {
+		payload = redact.Hashing(p.erasureKey).JSON(payload)
+		var v any
+		if err := json.Unmarshal(payload, &v); err == nil && dropMetadata(v) {
+			if out, err := json.Marshal(v); err == nil {
+				payload = out
+			}
+		}
+	}
+	return p.redactor.JSON(payload)
+}
+
+// handleAuditVerify walks the audit chain and reports whether it is intact.
+func (p *Processor) handleAuditVerify(ctx context.Context, event SubscriptionEvent) (SubscriptionResponse, error) {
+	if p.audit == nil {
+		return SubscriptionResponse{}, errors.New("audit log is not configured")
+	}
+	v, err := p.audit.Verify(ctx)
+	if err != nil {
+		return SubscriptionResponse{}, fmt.Errorf("verify audit log: %w", err)
+	}
+
+	resp := SubscriptionResponse{Status: "success", Request: event, Audit: &v}
+	if !v.Valid {
+		p.logf(ctx, "audit chain broken at seq=%d: %s", v.BrokenAt, v.Reason)
+		resp.Status = "failed"
+		resp.Message = v.Reason
+	}
+	return resp, nil
+}
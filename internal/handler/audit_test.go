@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/berniyo/paypack-lambda/internal/audit"
+	"github.com/berniyo/paypack-lambda/internal/redact"
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+func TestProcessorAppendsOutcomesToAuditChain(t *testing.T) {
+	client := &fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: "abc"}, nil
+		},
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: ref, Status: "success", Amount: 1000}, nil
+		},
+	}
+
+	store := audit.NewMemoryStore()
+	log, err := audit.NewLog(store)
+	require.NoError(t, err)
+	processor := NewProcessor(client, WithPollInterval(5*time.Millisecond), WithAuditLog(log))
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		_, err := processor.Handle(ctx, SubscriptionEvent{Number: "2507", Amount: 1000})
+		require.NoError(t, err)
+	}
+
+	recs, err := store.List(ctx, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, recs, 2)
+	require.Equal(t, "payment_outcome", recs[0].Action)
+	require.Equal(t, "abc", recs[0].Ref)
+
+	resp, err := processor.Handle(ctx, SubscriptionEvent{Action: ActionAuditVerify})
+	require.NoError(t, err)
+	require.Equal(t, "success", resp.Status)
+	require.True(t, resp.Audit.Valid)
+	require.Equal(t, int64(2), resp.Audit.Records)
+	require.Equal(t, recs[1].Hash, resp.Audit.HeadHash)
+}
+
+func TestAuditVerifyRequiresLog(t *testing.T) {
+	_, err := NewProcessor(&fakeClient{}).Handle(context.Background(), SubscriptionEvent{Action: ActionAuditVerify})
+	require.EqualError(t, err, "audit log is not configured")
+}
+
+func TestRedactionAppliesToLogsAndAuditRecords(t *testing.T) {
+	client := &fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: "abc"}, nil
+		},
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: ref, Status: "success", Amount: 1000, Client: "250788123123"}, nil
+		},
+	}
+
+	store := audit.NewMemoryStore()
+	chain, err := audit.NewLog(store)
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	processor := NewProcessor(client,
+		WithPollInterval(5*time.Millisecond),
+		WithLogger(log.New(&buf, "", 0)),
+		WithAuditLog(chain),
+		WithRedaction(redact.New("national_id")),
+	)
+
+	_, err = processor.Handle(context.Background(), SubscriptionEvent{
+		Number:   "250788123123",
+		Amount:   1000,
+		Metadata: map[string]any{"national_id": "1199", "plan": "gold"},
+		Debug:    true,
+	})
+	require.NoError(t, err)
+	require.NotContains(t, buf.String(), "250788123123")
+	require.NotContains(t, buf.String(), "1199")
+	require.Contains(t, buf.String(), "2507****123")
+
+	recs, err := store.List(context.Background(), 0, 0)
+	require.NoError(t, err)
+	require.Len(t, recs, 1)
+	require.NotContains(t, string(recs[0].Payload), "250788123123")
+	require.NotContains(t, string(recs[0].Payload), "national_id")
+	require.Contains(t, string(recs[0].Payload), `"plan":"gold"`)
+}
@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultCallbackRetryDelay = time.Second
+	defaultBreakerThreshold   = 5
+	defaultBreakerCooldown    = 30 * time.Second
+)
+
+// ErrCallbackCircuitOpen is returned, without contacting the endpoint, while the callback
+// circuit breaker is open.
+var ErrCallbackCircuitOpen = errors.New("callback circuit open")
+
+// backpressureError is a 429 or 503 answer. retryAfter is the endpoint's Retry-After, or
+// zero when it sent none.
+type backpressureError struct {
+	retryAfter time.Duration
+	err        error
+}
+
+func (e *backpressureError) Error() string { return e.err.Error() }
+func (e *backpressureError) Unwrap() error { return e.err }
+
+// WithCallbackRetries re-sends a callback answered with 429 or 503 up to attempts times in
+// total, waiting for the endpoint's Retry-After (one second, doubling, when absent). A wait
+// longer than maxDelay, or past the invocation deadline, ends the retries early.
+func WithCallbackRetries(attempts int, maxDelay time.Duration) CallbackOption {
+	return func(h *HTTPSCallbackSender) {
+		h.maxAttempts = attempts
+		h.maxRetryDelay = maxDelay
+	}
+}
+
+// WithCallbackBreaker stops posting for cooldown (default 30s) after threshold (default 5)
+// consecutive failed deliveries. A Retry-After on a 429 or 503 opens it straight away until
+// the date the endpoint asked for, so later callbacks fail fast instead of piling onto a
+// struggling receiver.
+func WithCallbackBreaker(threshold int, cooldown time.Duration) CallbackOption {
+	return func(h *HTTPSCallbackSender) {
+		h.breaker = &callbackBreaker{threshold: defaultBreakerThreshold, cooldown: defaultBreakerCooldown}
+		if threshold > 0 {
+			h.breaker.threshold = threshold
+		}
+		if cooldown > 0 {
+			h.breaker.cooldown = cooldown
+		}
+	}
+}
+
+// callbackBreaker counts consecutive failures. Once open it rejects deliveries until
+// openUntil; the first failure after that reopens it, and a success closes it.
+type callbackBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+}
+
+func (b *callbackBreaker) allow(now time.Time) error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if now.Before(b.openUntil) {
+		return fmt.Errorf("%w until %s", ErrCallbackCircuitOpen, b.openUntil.UTC().Format(time.RFC3339))
+	}
+	return nil
+}
+
+func (b *callbackBreaker) record(now time.Time, err error) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err == nil {
+		b.failures = 0
+		b.openUntil = time.Time{}
+		return
+	}
+	b.failures++
+	var bp *backpressureError
+	if errors.As(err, &bp) && bp.retryAfter > 0 {
+		b.openUntil = later(b.openUntil, now.Add(bp.retryAfter))
+	}
+	if b.failures >= b.threshold {
+		b.openUntil = later(b.openUntil, now.Add(b.cooldown))
+	}
+}
+
+func later(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+// deliver posts body, retrying backpressure answers as configured by WithCallbackRetries.
+func (h *HTTPSCallbackSender) deliver(ctx context.Context, body []byte, headers http.Header) (int, error) {
+	delay := defaultCallbackRetryDelay
+	for attempt := 1; ; attempt++ {
+		if err := h.breaker.allow(h.now()); err != nil {
+			return 0, err
+		}
+		status, err := h.post(ctx, body, headers)
+		h.breaker.record(h.now(), err)
+
+		var bp *backpressureError
+		if !errors.As(err, &bp) || attempt >= h.maxAttempts {
+			return status, err
+		}
+		wait := delay
+		if bp.retryAfter > 0 {
+			wait = bp.retryAfter
+		}
+		if h.maxRetryDelay > 0 && wait > h.maxRetryDelay {
+			return status, err
+		}
+		if deadline, ok := ctx.Deadline(); ok && h.now().Add(wait).After(deadline) {
+			return status, err
+		}
+		if serr := h.sleep(ctx, wait); serr != nil {
+			return status, err
+		}
+		delay *= 2
+	}
+}
+
+// parseRetryAfter reads a Retry-After header in either delay-seconds or HTTP-date form.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(secs)*time.Second, 0)
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(at.Sub(now), 0)
+	}
+	return 0
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
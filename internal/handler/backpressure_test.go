@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCallbackRetriesHonourRetryAfter(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch hits.Add(1) {
+		case 1:
+			w.Header().Set("Retry-After", "7")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 2:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	sender, err := NewHTTPSCallbackSender(srv.URL, "", srv.Client(), WithCallbackRetries(3, time.Minute))
+	require.NoError(t, err)
+	var waits []time.Duration
+	sender.sleep = func(ctx context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return nil
+	}
+
+	require.NoError(t, sender.Send(context.Background(), SubscriptionResponse{Reference: "abc", Status: "success"}))
+	require.EqualValues(t, 3, hits.Load())
+	require.Equal(t, []time.Duration{7 * time.Second, 2 * time.Second}, waits)
+}
+
+func TestCallbackRetriesGiveUpBeyondMaxDelay(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	sender, err := NewHTTPSCallbackSender(srv.URL, "", srv.Client(), WithCallbackRetries(3, time.Minute))
+	require.NoError(t, err)
+	err = sender.Send(context.Background(), SubscriptionResponse{Reference: "abc"})
+	require.ErrorContains(t, err, "callback endpoint returned 429")
+	require.EqualValues(t, 1, hits.Load())
+}
+
+func TestCallbackBreakerOpensOnRetryAfter(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) == 1 {
+			w.Header().Set("Retry-After", "60")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	now := time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC)
+	sender, err := NewHTTPSCallbackSender(srv.URL, "", srv.Client(), WithCallbackBreaker(0, 0))
+	require.NoError(t, err)
+	sender.now = func() time.Time { return now }
+
+	require.Error(t, sender.Send(context.Background(), SubscriptionResponse{Reference: "a"}))
+	err = sender.Send(context.Background(), SubscriptionResponse{Reference: "b"})
+	require.ErrorIs(t, err, ErrCallbackCircuitOpen)
+	require.EqualValues(t, 1, hits.Load())
+
+	now = now.Add(time.Minute)
+	require.NoError(t, sender.Send(context.Background(), SubscriptionResponse{Reference: "b"}))
+	require.EqualValues(t, 2, hits.Load())
+}
+
+func TestCallbackBreakerOpensAfterConsecutiveFailures(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	sender, err := NewHTTPSCallbackSender(srv.URL, "", srv.Client(), WithCallbackBreaker(2, time.Hour))
+	require.NoError(t, err)
+	for range 3 {
+		_ = sender.Send(context.Background(), SubscriptionResponse{Reference: "a"})
+	}
+	require.EqualValues(t, 2, hits.Load())
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC)
+	require.Equal(t, 30*time.Second, parseRetryAfter("30", now))
+	require.Equal(t, 90*time.Second, parseRetryAfter(now.Add(90*time.Second).Format(http.TimeFormat), now))
+	require.Zero(t, parseRetryAfter(now.Add(-time.Hour).Format(http.TimeFormat), now))
+	require.Zero(t, parseRetryAfter("soon", now))
+}
@@ -0,0 +1,174 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/berniyo/paypack-lambda/internal/alert"
+	"github.com/berniyo/paypack-lambda/internal/money"
+	"github.com/berniyo/paypack-lambda/internal/wallet"
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+// AlertBalanceDrift is the alert kind raised when the tracked and Paypack balances diverge.
+const AlertBalanceDrift = "balance_drift"
+
+// BalanceClient is implemented by payment clients that report the merchant wallet balance.
+type BalanceClient interface {
+	Balance(ctx context.Context) (*paypack.Balance, error)
+}
+
+// BalanceReport compares the tracked balance with the one Paypack reports. Drift is Paypack
+// minus tracked.
+type BalanceReport struct {
+	Currency  string    `json:"currency"`
+	Tracked   float64   `json:"tracked"`
+	Provider  *float64  `json:"provider,omitempty"`
+	Drift     float64   `json:"drift,omitempty"`
+	Threshold float64   `json:"threshold,omitempty"`
+	Drifted   bool      `json:"drifted,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// WithWallet tracks a running merchant balance: confirmed cash-ins add their amount net of
+// fees and disbursement cash-outs subtract theirs. Drift beyond driftThreshold is alerted on
+// by CheckBalance. The first check seeds an empty balance from Paypack.
+func WithWallet(store wallet.Store, driftThreshold float64) Option {
+	return func(p *Processor) {
+		p.wallet = store
+		if driftThreshold > 0 {
+			p.driftThreshold = driftThreshold
+		}
+	}
+}
+
+// WithAlerts sends operational alerts, such as balance drift, to sender.
+func WithAlerts(sender alert.Sender) Option {
+	return func(p *Processor) {
+		p.alerts = sender
+	}
+}
+
+func (p *Processor) creditWallet(ctx context.Context, resp *SubscriptionResponse) {
+	if p.wallet == nil || !resp.Found || resp.Status != "success" || resp.Transaction == nil {
+		return
+	}
+	txn := resp.Transaction
+	p.moveBalance(ctx, "cashin#"+resp.Reference, walletCurrency(txn.Currency), txn.Amount-txn.Fee)
+}
+
+func (p *Processor) debitWallet(ctx context.Context, out *paypack.Transaction, amount float64) {
+	if p.wallet == nil {
+		return
+	}
+	p.moveBalance(ctx, "cashout#"+out.Ref, walletCurrency(out.Currency), -(amount + out.Fee))
+}
+
+func (p *Processor) moveBalance(ctx context.Context, key, currency string, delta float64) {
+	_, err := p.wallet.Apply(ctx, key, currency, delta)
+	switch {
+	case errors.Is(err, wallet.ErrDuplicate):
+		p.logf(ctx, "balance movement %s already applied", key)
+	case err != nil:
+		p.logf(ctx, "balance movement %s failed: %v", key, err)
+	}
+}
+
+// handleBalance reports the tracked balance for event.Currency (default RWF), compared with
+// Paypack's when the client can report it.
+func (p *Processor) handleBalance(ctx context.Context, event SubscriptionEvent) (SubscriptionResponse, error) {
+	report, err := p.balanceReport(ctx, walletCurrency(normalizeCurrency(event.Currency)))
+	if err != nil {
+		return SubscriptionResponse{}, err
+	}
+	return SubscriptionResponse{Status: "success", Request: event, Balance: report}, nil
+}
+
+// CheckBalance cross-checks the tracked balance against Paypack and raises an alert on drift.
+// It is the entry point for a scheduled (EventBridge) invocation.
+func (p *Processor) CheckBalance(ctx context.Context) (_ BalanceReport, err error) {
+	defer p.flush(ctx)
+	ctx, inv := p.beginInvocation(ctx, "balance_check")
+	defer func() { inv.end(errorOutcome(err), "") }()
+	if _, ok := p.client.(BalanceClient); !ok {
+		return BalanceReport{}, errors.New("payment client does not report balances")
+	}
+	report, err := p.balanceReport(ctx, paypack.DefaultCurrency)
+	if err != nil {
+		return BalanceReport{}, err
+	}
+	if !report.Drifted {
+		return *report, nil
+	}
+
+	p.logf(ctx, "balance drift %.2f %s exceeds %.2f", report.Drift, report.Currency, report.Threshold)
+	if p.alerts != nil {
+		err := p.alerts.Send(ctx, alert.Alert{
+			Kind:    AlertBalanceDrift,
+			Message: fmt.Sprintf("tracked %s balance differs from Paypack by %.2f", report.Currency, report.Drift),
+			Details: map[string]any{
+				"tracked":   report.Tracked,
+				"provider":  *report.Provider,
+				"threshold": report.Threshold,
+			},
+			OccurredAt: report.CheckedAt,
+		})
+		if err != nil {
+			p.logf(ctx, "balance drift alert failed: %v", err)
+		}
+	}
+	return *report, nil
+}
+
+func (p *Processor) balanceReport(ctx context.Context, currency string) (*BalanceReport, error) {
+	if p.wallet == nil {
+		return nil, errors.New("wallet is not configured")
+	}
+	tracked, err := p.wallet.Get(ctx, currency)
+	if err != nil {
+		return nil, fmt.Errorf("load balance: %w", err)
+	}
+	report := &BalanceReport{
+		Currency:  currency,
+		Tracked:   tracked.Amount,
+		Threshold: p.driftThreshold,
+		CheckedAt: time.Now().UTC(),
+	}
+
+	client, ok := p.client.(BalanceClient)
+	if !ok {
+		return report, nil
+	}
+	remote, err := client.Balance(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("fetch paypack balance: %w", err)
+	}
+	if walletCurrency(remote.Currency) != currency {
+		return report, nil
+	}
+
+	if tracked.UpdatedAt.IsZero() {
+		seeded, err := p.wallet.Apply(ctx, "opening#"+currency, currency, remote.Amount)
+		if err != nil && !errors.Is(err, wallet.ErrDuplicate) {
+			return nil, fmt.Errorf("seed balance: %w", err)
+		}
+		p.logf(ctx, "tracked %s balance seeded at %.2f", currency, seeded.Amount)
+		report.Tracked = seeded.Amount
+	}
+
+	provider := remote.Amount
+	report.Provider = &provider
+	report.Drift = money.Round(provider-report.Tracked, currency)
+	report.Drifted = p.driftThreshold > 0 && math.Abs(report.Drift) > p.driftThreshold
+	return report, nil
+}
+
+func walletCurrency(code string) string {
+	if code == "" {
+		return paypack.DefaultCurrency
+	}
+	return code
+}
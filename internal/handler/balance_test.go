@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/require"
+
+	"github.com/berniyo/paypack-lambda/internal/alert"
+	"github.com/berniyo/paypack-lambda/internal/ledger"
+	"github.com/berniyo/paypack-lambda/internal/split"
+	"github.com/berniyo/paypack-lambda/internal/wallet"
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+type balanceClient struct {
+	*payoutClient
+	balance float64
+}
+
+func (c *balanceClient) Balance(ctx context.Context) (*paypack.Balance, error) {
+	return &paypack.Balance{Amount: c.balance, Currency: "RWF"}, nil
+}
+
+func TestWalletTracksMovementsAndAlertsOnDrift(t *testing.T) {
+	ctx := context.Background()
+	client := &balanceClient{payoutClient: newPayoutClient(), balance: 5000}
+	var alerts []alert.Alert
+	processor := NewProcessor(
+		client,
+		WithPollInterval(5*time.Millisecond),
+		WithLedger(ledger.NewMemoryStore()),
+		WithWallet(wallet.NewMemoryStore(), 50),
+		WithAlerts(alert.SenderFunc(func(ctx context.Context, a alert.Alert) error {
+			alerts = append(alerts, a)
+			return nil
+		})),
+	)
+
+	report, err := processor.CheckBalance(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 5000.0, report.Tracked)
+	require.False(t, report.Drifted)
+
+	_, err = processor.Handle(ctx, SubscriptionEvent{
+		Number: "2507",
+		Amount: 1000,
+		Split:  &split.Plan{Recipients: []split.Recipient{{Number: "0788000001", Fixed: 100}}},
+	})
+	require.NoError(t, err)
+
+	body := `{"event_id":"evt-3","data":{"ref":"abc","amount":1000,"fee":20,"status":"successful"}}`
+	_, err = NewWebhookHandler(processor, "").Handle(ctx, events.APIGatewayV2HTTPRequest{Body: body})
+	require.NoError(t, err)
+
+	resp, err := processor.Handle(ctx, SubscriptionEvent{Action: ActionBalance})
+	require.NoError(t, err)
+	require.Equal(t, 5880.0, resp.Balance.Tracked)
+	require.Equal(t, -880.0, resp.Balance.Drift)
+	require.Empty(t, alerts)
+
+	client.balance = 5880
+	report, err = processor.CheckBalance(ctx)
+	require.NoError(t, err)
+	require.False(t, report.Drifted)
+
+	client.balance = 5500
+	report, err = processor.CheckBalance(ctx)
+	require.NoError(t, err)
+	require.True(t, report.Drifted)
+	require.Equal(t, -380.0, report.Drift)
+	require.Len(t, alerts, 1)
+	require.Equal(t, AlertBalanceDrift, alerts[0].Kind)
+}
+
+func TestBalanceRequiresWallet(t *testing.T) {
+	processor := NewProcessor(&fakeClient{})
+
+	_, err := processor.Handle(context.Background(), SubscriptionEvent{Action: ActionBalance})
+	require.EqualError(t, err, "wallet is not configured")
+}
@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/berniyo/paypack-lambda/internal/metrics"
+	"github.com/berniyo/paypack-lambda/internal/workpool"
+)
+
+const (
+	defaultBatchConcurrency = 25
+	maxBatchItems           = 1000
+)
+
+// BatchResult is the outcome of one batch item, in the order items were submitted.
+type BatchResult struct {
+	Index   int       `json:"index"`
+	Ref     string    `json:"ref,omitempty"`
+	Status  string    `json:"status"`
+	Message string    `json:"message,omitempty"`
+	Code    ErrorCode `json:"code,omitempty"`
+}
+
+// BatchReport aggregates a batch run. Skipped items were not started because too little of
+// the invocation remained to confirm them; they can be resubmitted as-is.
+type BatchReport struct {
+	Total     int           `json:"total"`
+	Succeeded int           `json:"succeeded"`
+	Failed    int           `json:"failed"`
+	Pending   int           `json:"pending"`
+	Skipped   int           `json:"skipped"`
+	Errors    int           `json:"errors"`
+	Results   []BatchResult `json:"results"`
+}
+
+// WithBatchDigest sends the aggregated BatchReport through the callback sender once a batch
+// finishes, in addition to each item's own callback. NDJSONEncoder streams it as one line
+// per item.
+func WithBatchDigest() Option {
+	return func(p *Processor) {
+		p.batchDigest = true
+	}
+}
+
+// WithBatchConcurrency caps how many batch items are charged and polled at once (default 25).
+// Items share the client, and with it the cached Paypack token.
+func WithBatchConcurrency(n int) Option {
+	return func(p *Processor) {
+		if n > 0 {
+			p.batchConcurrency = n
+		}
+	}
+}
+
+// handleBatch runs each item in event.Items as its own cash-in through a bounded worker pool.
+// Every item gets the full treatment of a single event, including its callback.
+func (p *Processor) handleBatch(ctx context.Context, event SubscriptionEvent) (SubscriptionResponse, error) {
+	if len(event.Items) == 0 {
+		return SubscriptionResponse{}, withCode(CodeValidation, errors.New("items are required"))
+	}
+	if len(event.Items) > maxBatchItems {
+		return SubscriptionResponse{}, withCode(CodeValidation, fmt.Errorf("batch exceeds %d items", maxBatchItems))
+	}
+
+	type item struct {
+		index int
+		event SubscriptionEvent
+	}
+	items := make([]item, len(event.Items))
+	for i, e := range event.Items {
+		items[i] = item{index: i, event: e}
+	}
+
+	start := time.Now()
+	p.logf(ctx, "processing batch of %d items with concurrency %d", len(items), p.batchConcurrency)
+	results := workpool.Map(ctx, p.batchConcurrency, items, func(ctx context.Context, it item) BatchResult {
+		return p.runBatchItem(ctx, it.index, it.event)
+	})
+
+	report := p.summarizeBatch(ctx, results, start)
+
+	resp := SubscriptionResponse{Status: "success", Found: true, Request: event, Batch: &report}
+	if p.batchDigest {
+		p.emitCallback(ctx, resp)
+	}
+	return resp, nil
+}
+
+// summarizeBatch totals results, recording the batch metrics.
+func (p *Processor) summarizeBatch(ctx context.Context, results []BatchResult, start time.Time) BatchReport {
+	report := BatchReport{Total: len(results), Results: results}
+	for _, r := range results {
+		switch {
+		case r.Status == "skipped":
+			report.Skipped++
+		case r.Status == "error":
+			report.Errors++
+		case r.Status == "success":
+			report.Succeeded++
+		case r.Status == "pending" || r.Status == "pending_approval":
+			report.Pending++
+		default:
+			report.Failed++
+		}
+		p.metrics.Count(metrics.BatchItems, 1, metrics.T("status", r.Status))
+	}
+	metrics.Since(p.metrics, metrics.BatchDuration, start)
+	p.logf(ctx, "batch done: %d succeeded, %d failed, %d pending, %d skipped, %d errors",
+		report.Succeeded, report.Failed, report.Pending, report.Skipped, report.Errors)
+	return report
+}
+
+func (p *Processor) runBatchItem(ctx context.Context, index int, event SubscriptionEvent) BatchResult {
+	result := BatchResult{Index: index}
+	if event.Action != "" && event.Action != ActionCashIn {
+		result.Status = "error"
+		result.Code = CodeValidation
+		result.Message = fmt.Sprintf("batch items must be cash-ins, got action %q", event.Action)
+		return result
+	}
+	if ctx.Err() != nil || time.Until(p.pollDeadline(ctx, time.Now().Add(p.timeout))) < p.pollInterval {
+		result.Status = "skipped"
+		result.Message = "not started before the invocation deadline"
+		return result
+	}
+
+	ctx = correlationContext(batchItemContext(ctx, index), event)
+	err := normalizeAmounts(&event)
+	if err == nil {
+		err = p.sanitizeMetadata(&event)
+	}
+	var resp SubscriptionResponse
+	if err == nil {
+		resp, err = p.handleCashIn(ctx, event)
+	}
+	if err != nil {
+		result.Status = "error"
+		result.Code = CodeOf(err)
+		result.Message = err.Error()
+		return result
+	}
+	result.Ref = resp.Reference
+	result.Status = resp.Status
+	result.Message = resp.Message
+	result.Code = resp.Code
+	return result
+}
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+type concurrentClient struct {
+	inFlight, peak atomic.Int32
+}
+
+func (c *concurrentClient) CashIn(ctx context.Context, number string, amount float64, opts ...paypack.CashInOption) (*paypack.Transaction, error) {
+	if number == "fail" {
+		return nil, errors.New("connection reset")
+	}
+	cur := c.inFlight.Add(1)
+	for old := c.peak.Load(); cur > old && !c.peak.CompareAndSwap(old, cur); old = c.peak.Load() {
+	}
+	return &paypack.Transaction{Ref: "ref-" + number}, nil
+}
+
+func (c *concurrentClient) FindTransaction(ctx context.Context, ref string) (*paypack.Transaction, error) {
+	time.Sleep(2 * time.Millisecond)
+	c.inFlight.Add(-1)
+	return &paypack.Transaction{Ref: ref, Status: "success"}, nil
+}
+
+func TestBatchRunsItemsThroughBoundedPool(t *testing.T) {
+	client := &concurrentClient{}
+	p := NewProcessor(client, WithPollInterval(time.Millisecond), WithBatchConcurrency(3))
+
+	items := make([]SubscriptionEvent, 12)
+	for i := range items {
+		items[i] = SubscriptionEvent{Number: string(rune('a' + i)), Amount: 100}
+	}
+	items[4] = SubscriptionEvent{Number: "fail", Amount: 100}
+	items[7] = SubscriptionEvent{Amount: 100}
+	items[9] = SubscriptionEvent{Action: ActionExport}
+
+	resp, err := p.Handle(context.Background(), SubscriptionEvent{Action: ActionBatch, Items: items})
+	require.NoError(t, err)
+	report := resp.Batch
+	require.NotNil(t, report)
+	require.Equal(t, 12, report.Total)
+	require.Equal(t, 9, report.Succeeded)
+	require.Equal(t, 3, report.Errors)
+	require.LessOrEqual(t, client.peak.Load(), int32(3))
+
+	require.Equal(t, "ref-a", report.Results[0].Ref)
+	require.Equal(t, CodePaypackUnavailable, report.Results[4].Code)
+	require.Equal(t, CodeValidation, report.Results[7].Code)
+	require.Equal(t, CodeValidation, report.Results[9].Code)
+}
+
+func TestBatchSkipsItemsWithoutTimeLeft(t *testing.T) {
+	p := NewProcessor(&concurrentClient{}, WithPollInterval(time.Millisecond))
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultFinishReserve)
+	defer cancel()
+	resp, err := p.Handle(ctx, SubscriptionEvent{Action: ActionBatch, Items: []SubscriptionEvent{{Number: "a", Amount: 100}}})
+	require.NoError(t, err)
+	require.Equal(t, 1, resp.Batch.Skipped)
+	require.Equal(t, "skipped", resp.Batch.Results[0].Status)
+
+	_, err = p.Handle(context.Background(), SubscriptionEvent{Action: ActionBatch})
+	require.Equal(t, CodeValidation, CodeOf(err))
+}
+
+func TestBatchReportsInvalidItemCurrencies(t *testing.T) {
+	p := NewProcessor(&concurrentClient{}, WithPollInterval(time.Millisecond))
+	resp, err := p.Handle(context.Background(), SubscriptionEvent{Action: ActionBatch, Items: []SubscriptionEvent{
+		{Number: "a", Amount: 100},
+		{Number: "b", Amount: 100, Currency: "francs"},
+	}})
+	require.NoError(t, err)
+	require.Equal(t, 1, resp.Batch.Succeeded)
+	require.Equal(t, CodeValidation, resp.Batch.Results[1].Code)
+	require.Equal(t, `invalid currency "FRANCS"`, resp.Batch.Results[1].Message)
+}
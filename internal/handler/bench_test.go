@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"context"
+	"io"
+	"log"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/berniyo/paypack-lambda/pkg/paypack/paypacktest"
+)
+
+func benchmarkCashIn(b *testing.B, cfg paypacktest.Config) {
+	srv := paypacktest.NewServer(cfg)
+	defer srv.Close()
+	p := NewProcessor(srv.NewClient(),
+		WithPollInterval(5*time.Millisecond),
+		WithTimeout(10*time.Second),
+		WithLogger(log.New(io.Discard, "", 0)),
+	)
+	event := SubscriptionEvent{Number: "0788000000", Amount: 1000}
+
+	var mu sync.Mutex
+	var latencies []time.Duration
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			start := time.Now()
+			if _, err := p.Handle(context.Background(), event); err != nil {
+				b.Error(err)
+			}
+			elapsed := time.Since(start)
+			mu.Lock()
+			latencies = append(latencies, elapsed)
+			mu.Unlock()
+		}
+	})
+	b.StopTimer()
+
+	slices.Sort(latencies)
+	if n := len(latencies); n > 0 {
+		b.ReportMetric(float64(latencies[n*99/100].Microseconds())/1000, "p99-ms")
+		b.ReportMetric(float64(n)/b.Elapsed().Seconds(), "ops/s")
+	}
+}
+
+func BenchmarkProcessorCashIn(b *testing.B) {
+	benchmarkCashIn(b, paypacktest.Config{})
+}
+
+func BenchmarkProcessorCashInWithLatency(b *testing.B) {
+	benchmarkCashIn(b, paypacktest.Config{
+		Latency:      2 * time.Millisecond,
+		Jitter:       3 * time.Millisecond,
+		ConfirmAfter: 10 * time.Millisecond,
+		FailRate:     0.1,
+	})
+}
@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/berniyo/paypack-lambda/internal/txcache"
+	"github.com/berniyo/paypack-lambda/internal/workpool"
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+// maxBulkRefs bounds the refs one bulk_status event may ask about.
+const maxBulkRefs = 1000
+
+// BulkStatusFinder is implemented by payment clients that resolve many refs in one pass;
+// paypack.Client does.
+type BulkStatusFinder interface {
+	FindTransactions(ctx context.Context, refs []string, opts paypack.BulkFindOptions) []paypack.StatusResult
+}
+
+// RefStatus is one ref's entry in a bulk_status response.
+type RefStatus struct {
+	Ref         string               `json:"ref"`
+	Status      string               `json:"status"`
+	Found       bool                 `json:"found"`
+	Transaction *paypack.Transaction `json:"transaction,omitempty"`
+	Message     string               `json:"message,omitempty"`
+	Code        ErrorCode            `json:"code,omitempty"`
+}
+
+// handleBulkStatus reports what Paypack knows about each of event.Refs, as the status action
+// does for one, without charging or callbacks. A ref whose lookup failed carries its code.
+func (p *Processor) handleBulkStatus(ctx context.Context, event SubscriptionEvent) (SubscriptionResponse, error) {
+	refs := make([]string, 0, len(event.Refs))
+	for _, ref := range event.Refs {
+		if ref = strings.TrimSpace(ref); ref != "" {
+			refs = append(refs, ref)
+		}
+	}
+	if len(refs) == 0 {
+		return SubscriptionResponse{}, withCode(CodeValidation, errors.New("refs are required for bulk_status"))
+	}
+	if len(refs) > maxBulkRefs {
+		return SubscriptionResponse{}, withCode(CodeValidation, fmt.Errorf("bulk_status exceeds %d refs", maxBulkRefs))
+	}
+
+	results := p.lookupStatuses(ctx, refs, time.Time{})
+	statuses := make([]RefStatus, len(results))
+	for i, res := range results {
+		status := RefStatus{Ref: res.Ref}
+		switch {
+		case res.Err == nil:
+			status.Status = res.Transaction.Status
+			status.Found = true
+			status.Transaction = res.Transaction
+		case errors.Is(res.Err, paypack.ErrTransactionNotFound):
+			status.Status = "pending"
+			status.Message = "transaction not found yet"
+		default:
+			status.Status = "error"
+			status.Message = res.Err.Error()
+			status.Code = paypackCode(res.Err)
+		}
+		statuses[i] = status
+	}
+	return SubscriptionResponse{Status: "success", Request: event, Statuses: statuses}, nil
+}
+
+// lookupStatuses resolves refs from the transaction cache first, then in one pass through the
+// client when it is a BulkStatusFinder (listing transactions since since, when set), else
+// looking each up up to the batch concurrency at a time. Final statuses are cached.
+func (p *Processor) lookupStatuses(ctx context.Context, refs []string, since time.Time) []paypack.StatusResult {
+	results := make([]paypack.StatusResult, len(refs))
+	var missing []string
+	var slots []int
+	for i, ref := range refs {
+		results[i].Ref = ref
+		if p.txCache != nil {
+			if txn, err := p.txCache.Get(ctx, ref); err == nil {
+				results[i].Transaction = txn
+				continue
+			}
+		}
+		missing = append(missing, ref)
+		slots = append(slots, i)
+	}
+	if len(missing) == 0 {
+		return results
+	}
+
+	var found []paypack.StatusResult
+	if finder, ok := p.client.(BulkStatusFinder); ok {
+		found = finder.FindTransactions(ctx, missing, paypack.BulkFindOptions{Since: since, Concurrency: p.batchConcurrency})
+	} else {
+		found = workpool.Map(ctx, p.batchConcurrency, missing, func(ctx context.Context, ref string) paypack.StatusResult {
+			txn, err := p.client.FindTransaction(ctx, ref)
+			return paypack.StatusResult{Ref: ref, Transaction: txn, Err: err}
+		})
+	}
+	for i, res := range found {
+		if res.Err == nil && res.Transaction != nil && txcache.Terminal(res.Transaction.Status) {
+			p.cacheTransaction(ctx, *res.Transaction)
+		}
+		results[slots[i]] = res
+	}
+	return results
+}
@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/berniyo/paypack-lambda/internal/checkpoint"
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+	"github.com/berniyo/paypack-lambda/pkg/paypack/paypacktest"
+)
+
+func TestBulkStatusResolvesEveryRef(t *testing.T) {
+	client := &fakeClient{
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			switch ref {
+			case "done":
+				return &paypack.Transaction{Ref: ref, Status: "success"}, nil
+			case "broken":
+				return nil, &paypack.APIError{StatusCode: 503}
+			default:
+				return nil, paypack.ErrTransactionNotFound
+			}
+		},
+	}
+	processor := NewProcessor(client)
+
+	resp, err := processor.Handle(context.Background(), SubscriptionEvent{Action: ActionBulkStatus, Refs: []string{"done", " waiting ", "broken", ""}})
+	require.NoError(t, err)
+	require.Len(t, resp.Statuses, 3)
+	require.Equal(t, RefStatus{Ref: "done", Status: "success", Found: true, Transaction: &paypack.Transaction{Ref: "done", Status: "success"}}, resp.Statuses[0])
+	require.Equal(t, RefStatus{Ref: "waiting", Status: "pending", Message: "transaction not found yet"}, resp.Statuses[1])
+	require.Equal(t, "error", resp.Statuses[2].Status)
+	require.Equal(t, CodePaypackUnavailable, resp.Statuses[2].Code)
+
+	_, err = processor.Handle(context.Background(), SubscriptionEvent{Action: ActionBulkStatus})
+	require.Equal(t, CodeValidation, CodeOf(err))
+}
+
+func TestBulkStatusUsesThePaypackClientInOnePass(t *testing.T) {
+	srv := paypacktest.NewServer(paypacktest.Config{})
+	defer srv.Close()
+	client := srv.NewClient()
+	txn, err := client.CashIn(context.Background(), "0788000000", 100)
+	require.NoError(t, err)
+
+	resp, err := NewProcessor(client).Handle(context.Background(), SubscriptionEvent{Action: ActionBulkStatus, Refs: []string{txn.Ref, "unknown"}})
+	require.NoError(t, err)
+	require.Equal(t, "success", resp.Statuses[0].Status)
+	require.Equal(t, "pending", resp.Statuses[1].Status)
+}
+
+func TestResumePendingSettlesConfirmedCheckpointsWithoutPolling(t *testing.T) {
+	var lookups atomic.Int32
+	client := &fakeClient{
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			lookups.Add(1)
+			return &paypack.Transaction{Ref: ref, Status: "success"}, nil
+		},
+	}
+	store := checkpoint.NewMemoryStore()
+	stale := time.Now().Add(-time.Hour)
+	for _, ref := range []string{"a", "b"} {
+		require.NoError(t, store.Put(context.Background(), checkpoint.Checkpoint{
+			Ref: ref, State: []byte(`{"event":{"number":"2507","amount":1000}}`), Deadline: time.Now().Add(time.Hour), StartedAt: stale, UpdatedAt: stale,
+		}))
+	}
+	cb := &fakeCallback{}
+	processor := NewProcessor(client, WithPollInterval(time.Minute), WithCheckpoints(store), WithCallbackSender(cb))
+
+	report, err := processor.ResumePending(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 2, report.Confirmed)
+	require.Equal(t, int32(2), lookups.Load(), "one lookup per checkpoint, no polling")
+	require.Len(t, cb.calls, 2)
+	_, err = store.Get(context.Background(), "a")
+	require.True(t, errors.Is(err, checkpoint.ErrNotFound))
+}
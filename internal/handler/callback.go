@@ -3,26 +3,93 @@ package handler
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"slices"
 	"strings"
+	"sync/atomic"
 	"time"
+
+	"github.com/berniyo/paypack-lambda/internal/tracing"
+	"github.com/berniyo/paypack-lambda/pkg/envelope"
 )
 
 const defaultCallbackTimeout = 15 * time.Second
 
 // HTTPSCallbackSender posts subscription outcomes to an HTTPS endpoint.
 type HTTPSCallbackSender struct {
-	url        string
-	secret     string
-	httpClient *http.Client
+	url         string
+	secret      string
+	httpClient  *http.Client
+	apiVersion  string
+	cloudEvents *cloudEventsFormat
+	protobuf    bool
+	jsonOnly    atomic.Bool
+	encoder     PayloadEncoder
+	tokens      CallbackTokenSource
+	signingKeys []CallbackKey
+	now         func() time.Time
+
+	maxAttempts   int
+	maxRetryDelay time.Duration
+	breaker       *callbackBreaker
+	sleep         func(context.Context, time.Duration) error
+}
+
+// CallbackOption configures an HTTPSCallbackSender.
+type CallbackOption func(*HTTPSCallbackSender)
+
+// WithCallbackEnvelope wraps each payload in an envelope.Event of apiVersion (envelope.Latest
+// when empty) instead of posting the bare SubscriptionResponse.
+func WithCallbackEnvelope(apiVersion string) CallbackOption {
+	return func(h *HTTPSCallbackSender) {
+		h.apiVersion = apiVersion
+		if h.apiVersion == "" {
+			h.apiVersion = envelope.Latest
+		}
+	}
+}
+
+// WithProtobuf posts each payload as a protobuf paypack.v1.Event (proto/paypack/v1/events.proto)
+// with Content-Type application/x-protobuf. Endpoints that answer 415 or 406 are sent the
+// JSON envelope instead, for that callback and every later one.
+func WithProtobuf() CallbackOption {
+	return func(h *HTTPSCallbackSender) {
+		h.protobuf = true
+	}
+}
+
+// WithPayloadEncoder serializes the payload (or its envelope) with enc instead of JSON.
+// CloudEvents and protobuf callbacks define their own encoding and cannot be combined with it.
+func WithPayloadEncoder(enc PayloadEncoder) CallbackOption {
+	return func(h *HTTPSCallbackSender) {
+		h.encoder = enc
+	}
+}
+
+// CallbackTokenSource supplies the bearer token sent with each callback; oidc.TokenSource
+// does, caching the issuer's token until shortly before it expires.
+type CallbackTokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// WithCallbackBearerToken sends "Authorization: Bearer <token>" from tokens with every
+// callback, for receivers that validate JWTs from our IdP. A token that cannot be fetched
+// fails the delivery. If the receiver answers 401 and tokens has an Invalidate method, the
+// cached token is dropped and the callback re-sent once with a fresh one.
+func WithCallbackBearerToken(tokens CallbackTokenSource) CallbackOption {
+	return func(h *HTTPSCallbackSender) {
+		h.tokens = tokens
+	}
 }
 
 // NewHTTPSCallbackSender builds an HTTPS callback client.
-func NewHTTPSCallbackSender(url, secret string, client *http.Client) (*HTTPSCallbackSender, error) {
+func NewHTTPSCallbackSender(url, secret string, client *http.Client, opts ...CallbackOption) (*HTTPSCallbackSender, error) {
 	url = strings.TrimSpace(url)
 	if url == "" {
 		return nil, errors.New("callback URL is required")
@@ -32,40 +99,183 @@ func NewHTTPSCallbackSender(url, secret string, client *http.Client) (*HTTPSCall
 		client = &http.Client{Timeout: defaultCallbackTimeout}
 	}
 
-	return &HTTPSCallbackSender{
+	h := &HTTPSCallbackSender{
 		url:        url,
 		secret:     secret,
 		httpClient: client,
-	}, nil
+		now:        time.Now,
+		sleep:      sleepContext,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	if h.apiVersion != "" && !slices.Contains(envelope.Versions, h.apiVersion) {
+		return nil, fmt.Errorf("unsupported callback api version %q", h.apiVersion)
+	}
+	if h.encoder != nil && (h.cloudEvents != nil || h.protobuf) {
+		return nil, errors.New("payload encoders only apply to json callbacks")
+	}
+	if h.encoder == nil {
+		h.encoder = JSONEncoder{}
+	}
+	if h.protobuf {
+		if h.cloudEvents != nil {
+			return nil, errors.New("protobuf and cloudevents callbacks are mutually exclusive")
+		}
+		h.apiVersion = orDefault(h.apiVersion, envelope.Latest)
+	}
+	if h.cloudEvents != nil {
+		if err := h.cloudEvents.mode.validate(); err != nil {
+			return nil, err
+		}
+		h.cloudEvents.apiVersion = orDefault(h.apiVersion, envelope.Latest)
+	}
+	return h, nil
 }
 
 // Send transmits the subscription response as JSON to the configured endpoint.
 func (h *HTTPSCallbackSender) Send(ctx context.Context, payload SubscriptionResponse) error {
+	if payload.CorrelationID != "" {
+		ctx = context.WithValue(ctx, correlationKey{}, payload.CorrelationID)
+	}
+	if h.protobuf && !h.jsonOnly.Load() {
+		event, err := newCallbackEvent(payload, h.apiVersion, h.now())
+		if err != nil {
+			return err
+		}
+		body, err := event.MarshalProto()
+		if err != nil {
+			return fmt.Errorf("encode callback payload: %w", err)
+		}
+		headers := http.Header{"Content-Type": {envelope.ProtobufContentType}}
+		status, err := h.deliver(ctx, body, headers)
+		if status != http.StatusUnsupportedMediaType && status != http.StatusNotAcceptable {
+			return err
+		}
+		// The endpoint does not take protobuf: send JSON from now on.
+		h.jsonOnly.Store(true)
+	}
+
 	body := &bytes.Buffer{}
-	if err := json.NewEncoder(body).Encode(payload); err != nil {
+	headers := http.Header{"Content-Type": {h.encoder.ContentType()}}
+	var doc any = payload
+	switch {
+	case h.cloudEvents != nil:
+		event, err := h.cloudEvents.event(payload, h.now)
+		if err != nil {
+			return err
+		}
+		doc = event
+		headers.Set("Content-Type", envelope.CloudEventsContentType)
+		if h.cloudEvents.mode == CloudEventsBinary {
+			doc = event.Data
+			headers.Set("Content-Type", event.DataContentType)
+			for name, value := range event.Attributes() {
+				headers.Set("ce-"+name, value)
+			}
+		}
+	case h.apiVersion != "":
+		event, err := newCallbackEvent(payload, h.apiVersion, h.now())
+		if err != nil {
+			return err
+		}
+		doc = event
+	}
+	if err := h.encoder.Encode(body, doc); err != nil {
 		return fmt.Errorf("encode callback payload: %w", err)
 	}
+	_, err := h.deliver(ctx, body.Bytes(), headers)
+	return err
+}
+
+// post sends body and returns the response status, or 0 when the request failed.
+func (h *HTTPSCallbackSender) post(ctx context.Context, body []byte, headers http.Header) (int, error) {
+	status, err := h.postOnce(ctx, body, headers)
+	if inv, ok := h.tokens.(interface{ Invalidate() }); ok && status == http.StatusUnauthorized {
+		inv.Invalidate()
+		status, err = h.postOnce(ctx, body, headers)
+	}
+	return status, err
+}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, body)
+func (h *HTTPSCallbackSender) postOnce(ctx context.Context, body []byte, headers http.Header) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, bytes.NewReader(body))
 	if err != nil {
-		return fmt.Errorf("build callback request: %w", err)
+		return 0, fmt.Errorf("build callback request: %w", err)
 	}
 
-	req.Header.Set("Content-Type", "application/json")
+	for name, values := range headers {
+		req.Header[name] = values
+	}
+	tracing.Inject(ctx, req.Header.Set)
+	if id := correlationID(ctx); id != "" {
+		req.Header.Set(HeaderCorrelationID, id)
+	}
 	if h.secret != "" {
 		req.Header.Set("X-Callback-Secret", h.secret)
 	}
+	h.sign(req.Header, body)
+	if h.tokens != nil {
+		token, err := h.tokens.Token(ctx)
+		if err != nil {
+			return 0, fmt.Errorf("fetch callback token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
 
 	resp, err := h.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("send callback request: %w", err)
+		return 0, fmt.Errorf("send callback request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 300 {
 		data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
-		return fmt.Errorf("callback endpoint returned %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+		err := fmt.Errorf("callback endpoint returned %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+			retryAfter := parseRetryAfter(resp.Header.Get("Retry-After"), h.now())
+			return resp.StatusCode, &backpressureError{retryAfter: retryAfter, err: err}
+		}
+		return resp.StatusCode, err
 	}
 
-	return nil
+	return resp.StatusCode, nil
+}
+
+// newCallbackEvent wraps resp in an envelope. The ID hashes the data, so a retried or
+// replayed outcome keeps its ID and receivers can drop duplicates.
+func newCallbackEvent(resp SubscriptionResponse, apiVersion string, now time.Time) (envelope.Event, error) {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return envelope.Event{}, fmt.Errorf("encode callback payload: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return envelope.Event{
+		ID:         "evt_" + hex.EncodeToString(sum[:12]),
+		Type:       callbackEventType(resp),
+		CreatedAt:  now.UTC(),
+		APIVersion: apiVersion,
+		Data:       data,
+	}, nil
+}
+
+func callbackEventType(resp SubscriptionResponse) string {
+	if resp.Batch != nil {
+		return envelope.TypeBatchCompleted
+	}
+	prefix := "payment."
+	if resp.Refund != nil {
+		prefix = "refund."
+	}
+	if isPayout(resp) {
+		prefix = "payout."
+	}
+	switch resp.Status {
+	case "success":
+		return prefix + "succeeded"
+	case "pending":
+		return prefix + "pending"
+	default:
+		return prefix + "failed"
+	}
 }
@@ -0,0 +1,232 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/berniyo/paypack-lambda/internal/tracing"
+	"github.com/berniyo/paypack-lambda/pkg/envelope"
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+func TestCallbackCarriesEventTraceContext(t *testing.T) {
+	headers := make(chan http.Header, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers <- r.Header.Clone()
+	}))
+	defer srv.Close()
+
+	sender, err := NewHTTPSCallbackSender(srv.URL, "", srv.Client())
+	require.NoError(t, err)
+	client := &fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: "abc"}, nil
+		},
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: ref, Status: "success"}, nil
+		},
+	}
+	processor := NewProcessor(client, WithPollInterval(time.Millisecond), WithCallbackSender(sender))
+
+	_, err = processor.Handle(context.Background(), SubscriptionEvent{
+		Number:      "2507",
+		Amount:      100,
+		TraceParent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		TraceState:  "shop=1",
+	})
+	require.NoError(t, err)
+
+	got := <-headers
+	tc, ok := tracing.Parse(got.Get("traceparent"), got.Get("tracestate"))
+	require.True(t, ok)
+	require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", tc.TraceID)
+	require.NotEqual(t, "00f067aa0ba902b7", tc.ParentID)
+	require.Equal(t, "shop=1", tc.State)
+}
+
+func TestCallbackStartsTraceWhenNoneIsGiven(t *testing.T) {
+	headers := make(chan http.Header, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers <- r.Header.Clone()
+	}))
+	defer srv.Close()
+
+	sender, err := NewHTTPSCallbackSender(srv.URL, "", srv.Client())
+	require.NoError(t, err)
+	require.NoError(t, sender.Send(context.Background(), SubscriptionResponse{Reference: "abc"}))
+
+	_, ok := tracing.Parse((<-headers).Get("traceparent"), "")
+	require.True(t, ok)
+}
+
+func TestCallbackEnvelope(t *testing.T) {
+	bodies := make(chan []byte, 2)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies <- body
+	}))
+	defer srv.Close()
+
+	sender, err := NewHTTPSCallbackSender(srv.URL, "", srv.Client(), WithCallbackEnvelope(""))
+	require.NoError(t, err)
+	sender.now = func() time.Time { return time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC) }
+
+	resp := SubscriptionResponse{Reference: "abc", Status: "success", Found: true, Request: SubscriptionEvent{Number: "2507", Amount: 1000}}
+	require.NoError(t, sender.Send(context.Background(), resp))
+	require.NoError(t, sender.Send(context.Background(), resp))
+
+	var first, second envelope.Event
+	require.NoError(t, json.Unmarshal(<-bodies, &first))
+	require.NoError(t, json.Unmarshal(<-bodies, &second))
+	require.Equal(t, envelope.TypePaymentSucceeded, first.Type)
+	require.Equal(t, envelope.Latest, first.APIVersion)
+	require.Equal(t, time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC), first.CreatedAt)
+	require.Regexp(t, `^evt_[0-9a-f]{24}$`, first.ID)
+	require.Equal(t, first.ID, second.ID, "redeliveries keep their id")
+
+	var payment envelope.Payment
+	require.NoError(t, first.DecodeData(&payment))
+	require.Equal(t, "abc", payment.Ref)
+	require.Equal(t, 1000.0, payment.Request.Amount)
+
+	_, err = NewHTTPSCallbackSender(srv.URL, "", nil, WithCallbackEnvelope("2019-01-01"))
+	require.EqualError(t, err, `unsupported callback api version "2019-01-01"`)
+}
+
+func TestCallbackEventTypes(t *testing.T) {
+	require.Equal(t, envelope.TypePaymentFailed, callbackEventType(SubscriptionResponse{Status: "failed"}))
+	require.Equal(t, envelope.TypePaymentFailed, callbackEventType(SubscriptionResponse{Status: "timeout"}))
+	require.Equal(t, envelope.TypePaymentPending, callbackEventType(SubscriptionResponse{Status: "pending"}))
+	require.Equal(t, envelope.TypeRefundSucceeded, callbackEventType(SubscriptionResponse{Status: "success", Refund: &Refund{}}))
+}
+
+// TestEnvelopePaymentMatchesResponse keeps the published data types a subset of what is sent.
+func TestEnvelopePaymentMatchesResponse(t *testing.T) {
+	jsonFields := func(typ reflect.Type) map[string]reflect.Type {
+		fields := make(map[string]reflect.Type)
+		for i := range typ.NumField() {
+			name, _, _ := strings.Cut(typ.Field(i).Tag.Get("json"), ",")
+			fields[name] = typ.Field(i).Type
+		}
+		return fields
+	}
+	for published, sent := range map[reflect.Type]reflect.Type{
+		reflect.TypeOf(envelope.Payment{}):        reflect.TypeOf(SubscriptionResponse{}),
+		reflect.TypeOf(envelope.PaymentRequest{}): reflect.TypeOf(SubscriptionEvent{}),
+		reflect.TypeOf(envelope.Refund{}):         reflect.TypeOf(Refund{}),
+	} {
+		sentFields := jsonFields(sent)
+		for name, typ := range jsonFields(published) {
+			sentType, ok := sentFields[name]
+			require.True(t, ok, "%s.%s is not sent", published.Name(), name)
+			require.Equal(t, sentType.Kind(), typ.Kind(), "%s.%s", published.Name(), name)
+		}
+	}
+}
+
+func TestCallbackProtobufFallsBackToJSON(t *testing.T) {
+	var contentTypes []string
+	var last []byte
+	acceptProtobuf := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentTypes = append(contentTypes, r.Header.Get("Content-Type"))
+		last, _ = io.ReadAll(r.Body)
+		if !acceptProtobuf && r.Header.Get("Content-Type") == envelope.ProtobufContentType {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+		}
+	}))
+	defer srv.Close()
+
+	sender, err := NewHTTPSCallbackSender(srv.URL, "", srv.Client(), WithProtobuf())
+	require.NoError(t, err)
+	resp := SubscriptionResponse{Reference: "abc", Status: "success", Found: true, Request: SubscriptionEvent{Number: "2507", Amount: 1000}}
+
+	require.NoError(t, sender.Send(context.Background(), resp))
+	var event envelope.Event
+	require.NoError(t, event.UnmarshalProto(last))
+	require.Equal(t, envelope.TypePaymentSucceeded, event.Type)
+	var payment envelope.Payment
+	require.NoError(t, event.DecodeData(&payment))
+	require.Equal(t, "abc", payment.Ref)
+
+	acceptProtobuf = false
+	require.NoError(t, sender.Send(context.Background(), resp))
+	require.NoError(t, sender.Send(context.Background(), resp))
+	require.Equal(t, []string{envelope.ProtobufContentType, envelope.ProtobufContentType, "application/json", "application/json"}, contentTypes)
+	require.NoError(t, json.Unmarshal(last, &event))
+	require.Equal(t, envelope.Latest, event.APIVersion)
+
+	_, err = NewHTTPSCallbackSender(srv.URL, "", nil, WithProtobuf(), WithCloudEvents(CloudEventsStructured, ""))
+	require.Error(t, err)
+}
+
+type fakeTokens struct {
+	tokens      []string
+	calls       int
+	invalidated int
+}
+
+func (f *fakeTokens) Token(context.Context) (string, error) {
+	f.calls++
+	return f.tokens[min(f.invalidated, len(f.tokens)-1)], nil
+}
+
+func (f *fakeTokens) Invalidate() { f.invalidated++ }
+
+func TestCallbackBearerTokenRefreshesAfterUnauthorized(t *testing.T) {
+	var auth []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = append(auth, r.Header.Get("Authorization"))
+		if r.Header.Get("Authorization") != "Bearer fresh" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer srv.Close()
+
+	tokens := &fakeTokens{tokens: []string{"stale", "fresh"}}
+	sender, err := NewHTTPSCallbackSender(srv.URL, "", srv.Client(), WithCallbackBearerToken(tokens))
+	require.NoError(t, err)
+	require.NoError(t, sender.Send(context.Background(), SubscriptionResponse{Reference: "abc"}))
+	require.Equal(t, []string{"Bearer stale", "Bearer fresh"}, auth)
+	require.Equal(t, 1, tokens.invalidated)
+}
+
+func TestCallbackSigningDuringRotation(t *testing.T) {
+	type received struct {
+		header http.Header
+		body   []byte
+	}
+	got := make(chan received, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got <- received{r.Header.Clone(), body}
+	}))
+	defer srv.Close()
+
+	current, err := NewCallbackKey("k2", "new-secret")
+	require.NoError(t, err)
+	previous, err := NewCallbackKey("", "old-secret")
+	require.NoError(t, err)
+	sender, err := NewHTTPSCallbackSender(srv.URL, "", srv.Client(), WithCallbackSigning(current, previous))
+	require.NoError(t, err)
+	require.NoError(t, sender.Send(context.Background(), SubscriptionResponse{Reference: "abc"}))
+
+	r := <-got
+	require.Equal(t, "k2", r.header.Get(CallbackKeyIDHeader))
+	require.Len(t, strings.Split(r.header.Get(CallbackSignatureHeader), ","), 2)
+	now := time.Now()
+	require.NoError(t, VerifyCallbackSignature(r.header, r.body, map[string]string{"k2": "new-secret"}, now))
+	require.NoError(t, VerifyCallbackSignature(r.header, r.body, map[string]string{previous.ID: "old-secret"}, now))
+	require.Error(t, VerifyCallbackSignature(r.header, r.body, map[string]string{"k2": "other"}, now))
+	require.Error(t, VerifyCallbackSignature(r.header, []byte("tampered"), map[string]string{"k2": "new-secret"}, now))
+	require.Error(t, VerifyCallbackSignature(r.header, r.body, map[string]string{"k2": "new-secret"}, now.Add(10*time.Minute)))
+}
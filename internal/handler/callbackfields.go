@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/berniyo/paypack-lambda/internal/redact"
+)
+
+// FieldFilter trims callback payloads for a destination that must not receive everything,
+// such as receivers barred from storing customer phone numbers.
+type FieldFilter struct {
+	// Include, when set, keeps only these top-level fields; ref and status are always kept.
+	Include []string `json:"include,omitempty"`
+	// Exclude clears these fields. Dotted paths reach into objects, e.g. "request.metadata".
+	// Fields every payload carries, such as request, are sent empty.
+	Exclude []string `json:"exclude,omitempty"`
+	// MaskNumbers masks phone numbers wherever they appear, e.g. 2507****123.
+	MaskNumbers bool `json:"mask_numbers,omitempty"`
+	// HashNumbers replaces phone numbers with an HMAC of the number under NumberKey instead,
+	// which stays the same for a customer across payloads.
+	HashNumbers bool `json:"hash_numbers,omitempty"`
+	// NumberKey is the HMAC key for HashNumbers and number_id. With it, masked and hashed
+	// payloads carry number_id, a stable identifier for the customer in place of the number.
+	NumberKey []byte `json:"-"`
+}
+
+// ParseFieldFilters decodes per-destination filters keyed by channel, e.g.
+// {"callback": {"exclude": ["request.metadata"], "mask_numbers": true}}. NumberKey is not
+// part of the JSON; callers set it from their secret.
+func ParseFieldFilters(data []byte) (map[Channel]FieldFilter, error) {
+	var filters map[Channel]FieldFilter
+	if err := json.Unmarshal(data, &filters); err != nil {
+		return nil, fmt.Errorf("decode callback field filters: %w", err)
+	}
+	return filters, nil
+}
+
+// FilteredSender applies filter to every payload before sender delivers it.
+func FilteredSender(sender CallbackSender, filter FieldFilter) CallbackSender {
+	return filteredSender{sender: sender, filter: filter}
+}
+
+type filteredSender struct {
+	sender CallbackSender
+	filter FieldFilter
+}
+
+func (s filteredSender) Send(ctx context.Context, payload SubscriptionResponse) error {
+	filtered, err := s.filter.Apply(payload)
+	if err != nil {
+		return err
+	}
+	return s.sender.Send(ctx, filtered)
+}
+
+// Apply returns a copy of resp with the filter applied.
+func (f FieldFilter) Apply(resp SubscriptionResponse) (SubscriptionResponse, error) {
+	if len(f.Include) == 0 && len(f.Exclude) == 0 && !f.MaskNumbers && !f.HashNumbers {
+		return resp, nil
+	}
+	if f.HashNumbers && len(f.NumberKey) == 0 {
+		return SubscriptionResponse{}, errors.New("hash_numbers needs a number key")
+	}
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return SubscriptionResponse{}, fmt.Errorf("encode callback payload for filtering: %w", err)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return SubscriptionResponse{}, fmt.Errorf("decode callback payload for filtering: %w", err)
+	}
+
+	if len(f.Include) > 0 {
+		keep := map[string]bool{"ref": true, "status": true}
+		for _, name := range f.Include {
+			keep[strings.TrimSpace(name)] = true
+		}
+		for name := range doc {
+			if !keep[name] {
+				delete(doc, name)
+			}
+		}
+	}
+	for _, path := range f.Exclude {
+		removePath(doc, strings.Split(strings.TrimSpace(path), "."))
+	}
+
+	if data, err = json.Marshal(doc); err != nil {
+		return SubscriptionResponse{}, fmt.Errorf("encode filtered callback payload: %w", err)
+	}
+	switch {
+	case f.HashNumbers:
+		data = redact.Hashing(f.NumberKey).JSON(data)
+	case f.MaskNumbers:
+		data = redact.New().JSON(data)
+	}
+	var filtered SubscriptionResponse
+	if err := json.Unmarshal(data, &filtered); err != nil {
+		return SubscriptionResponse{}, fmt.Errorf("decode filtered callback payload: %w", err)
+	}
+	if (f.MaskNumbers || f.HashNumbers) && len(f.NumberKey) > 0 {
+		filtered.NumberID = numberID(f.NumberKey, resp)
+	}
+	return filtered, nil
+}
+
+// numberID is the hashed customer number of resp, from the request or else the transaction.
+func numberID(key []byte, resp SubscriptionResponse) string {
+	number := resp.Request.Number
+	if number == "" && resp.Transaction != nil {
+		number = resp.Transaction.PayerNumber()
+	}
+	if number == "" {
+		return ""
+	}
+	return redact.HashNumber(key, number)
+}
+
+// removePath deletes the field at path, descending through objects and arrays of objects.
+func removePath(v any, path []string) {
+	switch v := v.(type) {
+	case map[string]any:
+		if len(path) == 1 {
+			delete(v, path[0])
+			return
+		}
+		if inner, ok := v[path[0]]; ok {
+			removePath(inner, path[1:])
+		}
+	case []any:
+		for _, item := range v {
+			removePath(item, path)
+		}
+	}
+}
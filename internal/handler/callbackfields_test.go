@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/berniyo/paypack-lambda/internal/redact"
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+func filterFixture() SubscriptionResponse {
+	fee := 23.0
+	return SubscriptionResponse{
+		Reference:   "abc",
+		Status:      "success",
+		Found:       true,
+		Transaction: &paypack.Transaction{Ref: "abc", Status: "success", Client: "250788123123", Amount: 1000},
+		Fee:         &fee,
+		Request:     SubscriptionEvent{Number: "250788123123", Amount: 1000, Metadata: map[string]any{"plan": "gold"}},
+	}
+}
+
+func TestFieldFilterExcludesAndMasks(t *testing.T) {
+	resp, err := FieldFilter{Exclude: []string{"request.metadata", "fee"}, MaskNumbers: true}.Apply(filterFixture())
+	require.NoError(t, err)
+	require.Nil(t, resp.Request.Metadata)
+	require.Nil(t, resp.Fee)
+	require.Equal(t, "2507****123", resp.Request.Number)
+	require.Equal(t, "2507****123", resp.Transaction.Client)
+	require.Equal(t, 1000.0, resp.Transaction.Amount)
+
+	original := filterFixture()
+	require.Equal(t, "250788123123", original.Request.Number, "the payload itself is untouched")
+}
+
+func TestFieldFilterHashesNumbersWithStableID(t *testing.T) {
+	key := []byte("secret")
+	id := redact.HashNumber(key, "250788123123")
+
+	resp, err := FieldFilter{HashNumbers: true, NumberKey: key}.Apply(filterFixture())
+	require.NoError(t, err)
+	require.Equal(t, id, resp.Request.Number)
+	require.Equal(t, id, resp.Transaction.Client)
+	require.Equal(t, id, resp.NumberID)
+
+	resp, err = FieldFilter{MaskNumbers: true, Exclude: []string{"request"}, NumberKey: key}.Apply(filterFixture())
+	require.NoError(t, err)
+	require.Equal(t, "2507****123", resp.Transaction.Client)
+	require.Equal(t, id, resp.NumberID, "the identifier survives the number being excluded")
+
+	resp, err = FieldFilter{MaskNumbers: true}.Apply(filterFixture())
+	require.NoError(t, err)
+	require.Empty(t, resp.NumberID)
+
+	_, err = FieldFilter{HashNumbers: true}.Apply(filterFixture())
+	require.Error(t, err)
+}
+
+func TestFieldFilterIncludesOnlyListedFields(t *testing.T) {
+	resp, err := FieldFilter{Include: []string{"transaction"}}.Apply(filterFixture())
+	require.NoError(t, err)
+	require.Equal(t, "abc", resp.Reference)
+	require.Equal(t, "success", resp.Status)
+	require.NotNil(t, resp.Transaction)
+	require.Nil(t, resp.Fee)
+	require.Empty(t, resp.Request.Number)
+}
+
+func TestFilteredSenderAppliesPerDestination(t *testing.T) {
+	filters, err := ParseFieldFilters([]byte(`{"callback": {"exclude": ["request"]}}`))
+	require.NoError(t, err)
+
+	filtered, plain := &fakeCallback{}, &fakeCallback{}
+	require.NoError(t, FilteredSender(filtered, filters[ChannelCallback]).Send(context.Background(), filterFixture()))
+	require.NoError(t, plain.Send(context.Background(), filterFixture()))
+	require.Empty(t, filtered.calls[0].Request.Number)
+	require.Equal(t, "250788123123", plain.calls[0].Request.Number)
+
+	_, err = ParseFieldFilters([]byte(`{"callback": {"exclude": "request"}}`))
+	require.Error(t, err)
+}
@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers of signed callbacks.
+const (
+	CallbackSignatureHeader = "X-Callback-Signature"
+	CallbackTimestampHeader = "X-Callback-Timestamp"
+	CallbackKeyIDHeader     = "X-Callback-Key-ID"
+)
+
+// CallbackKey is a callback signing secret and the ID receivers know it by.
+type CallbackKey struct {
+	ID     string
+	Secret string
+}
+
+// NewCallbackKey returns a key for secret. An empty id defaults to the first 8 hex digits of
+// the secret's SHA-256, which identifies it without revealing it.
+func NewCallbackKey(id, secret string) (CallbackKey, error) {
+	id, secret = strings.TrimSpace(id), strings.TrimSpace(secret)
+	if secret == "" {
+		return CallbackKey{}, errors.New("callback signing secret is required")
+	}
+	if id == "" {
+		sum := sha256.Sum256([]byte(secret))
+		id = hex.EncodeToString(sum[:4])
+	}
+	if strings.ContainsAny(id, "=, ") {
+		return CallbackKey{}, errors.New("callback key id must not contain '=', ',' or spaces")
+	}
+	return CallbackKey{ID: id, Secret: secret}, nil
+}
+
+// WithCallbackSigning signs every callback with current: X-Callback-Key-ID names it and
+// X-Callback-Signature carries "<key id>=<signature>", the base64 HMAC-SHA256 of
+// "<X-Callback-Timestamp>.<body>" (as SignFunctionURLRequest computes it). While a previous
+// key is given its signature follows, comma-separated, so receivers still holding only the
+// old secret keep accepting callbacks until they switch to the new one.
+func WithCallbackSigning(current CallbackKey, previous ...CallbackKey) CallbackOption {
+	return func(h *HTTPSCallbackSender) {
+		if current.Secret == "" {
+			return
+		}
+		h.signingKeys = []CallbackKey{current}
+		for _, k := range previous {
+			if k.Secret != "" && k.ID != current.ID {
+				h.signingKeys = append(h.signingKeys, k)
+			}
+		}
+	}
+}
+
+// sign adds the signature headers for body to header.
+func (h *HTTPSCallbackSender) sign(header http.Header, body []byte) {
+	if len(h.signingKeys) == 0 {
+		return
+	}
+	now := h.now()
+	signatures := make([]string, len(h.signingKeys))
+	for i, k := range h.signingKeys {
+		signatures[i] = k.ID + "=" + SignFunctionURLRequest(body, now, k.Secret)
+	}
+	header.Set(CallbackTimestampHeader, strconv.FormatInt(now.Unix(), 10))
+	header.Set(CallbackKeyIDHeader, h.signingKeys[0].ID)
+	header.Set(CallbackSignatureHeader, strings.Join(signatures, ", "))
+}
+
+// VerifyCallbackSignature checks a signed callback against keys (key ID to secret), as a Go
+// receiver would: the timestamp must be within 5 minutes of now and one signature must be by
+// a known key.
+func VerifyCallbackSignature(header http.Header, body []byte, keys map[string]string, now time.Time) error {
+	ts, err := strconv.ParseInt(header.Get(CallbackTimestampHeader), 10, 64)
+	if err != nil {
+		return errors.New("callback timestamp is missing")
+	}
+	if d := now.Sub(time.Unix(ts, 0)); d > functionURLSkew || d < -functionURLSkew {
+		return errors.New("callback timestamp is outside the allowed window")
+	}
+	for _, entry := range strings.Split(header.Get(CallbackSignatureHeader), ",") {
+		id, signature, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		secret, known := keys[id]
+		if ok && known && hmac.Equal([]byte(signature), []byte(SignFunctionURLRequest(body, time.Unix(ts, 0), secret))) {
+			return nil
+		}
+	}
+	return errors.New("callback signature is invalid")
+}
@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/berniyo/paypack-lambda/internal/chaos"
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+	"github.com/berniyo/paypack-lambda/pkg/paypack/paypacktest"
+)
+
+func chaosProcessor(t *testing.T, faults chaos.Faults, opts ...Option) (*Processor, *chaos.Injector) {
+	t.Helper()
+	srv := paypacktest.NewServer(paypacktest.Config{ConfirmAfter: 5 * time.Millisecond})
+	t.Cleanup(srv.Close)
+	in := chaos.New(faults)
+	client, err := paypack.NewClient(srv.URL, paypacktest.AppID, paypacktest.AppSecret, &http.Client{Transport: in.Transport(nil)})
+	require.NoError(t, err)
+	opts = append([]Option{
+		WithPollInterval(time.Millisecond),
+		WithTimeout(time.Second),
+		WithLogger(log.New(io.Discard, "", 0)),
+	}, opts...)
+	return NewProcessor(client, opts...), in
+}
+
+func TestProcessorReportsInjectedCallbackFailures(t *testing.T) {
+	delivered := &fakeCallback{}
+	callbacks := chaos.New(chaos.Faults{ErrorRate: 1})
+	p, _ := chaosProcessor(t, chaos.Faults{},
+		WithCallbackSender(chaos.Wrap[SubscriptionResponse](callbacks, delivered)))
+
+	resp, err := p.Handle(context.Background(), SubscriptionEvent{Number: "0788000000", Amount: 100})
+	require.NoError(t, err)
+	require.Equal(t, "success", resp.Status)
+	require.Equal(t, CodeCallbackFailed, resp.Code)
+	require.Empty(t, delivered.calls)
+}
+
+func TestProcessorSurfacesMalformedLookupsAsUnavailable(t *testing.T) {
+	p, in := chaosProcessor(t, chaos.Faults{MalformedRate: 1, Paths: []string{"/api/transactions/find/"}})
+
+	_, err := p.Handle(context.Background(), SubscriptionEvent{Number: "0788000000", Amount: 100})
+	require.Error(t, err)
+	require.Equal(t, CodePaypackUnavailable, CodeOf(err))
+	require.Positive(t, in.Stats().Malformed)
+}
@@ -0,0 +1,263 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/berniyo/paypack-lambda/internal/tracing"
+)
+
+const (
+	telegramAPIURL = "https://api.telegram.org"
+
+	// Message lengths accepted by Telegram's sendMessage and Discord webhooks.
+	telegramMaxText = 4096
+	discordMaxText  = 2000
+)
+
+// OperationsFilter selects the outcomes worth posting to an operations chat. Failures covers
+// every concluded outcome that is not a success; confirmations are posted only at or above
+// MinAmount (zero disables them). When Clients is non-empty, other clients are skipped.
+type OperationsFilter struct {
+	Failures  bool
+	MinAmount float64
+	Clients   []string
+}
+
+func (f OperationsFilter) validate() error {
+	if !f.Failures && f.MinAmount <= 0 {
+		return errors.New("notification filter selects no outcomes")
+	}
+	return nil
+}
+
+func (f OperationsFilter) selects(resp SubscriptionResponse) bool {
+	if len(f.Clients) > 0 && !slices.Contains(f.Clients, resp.Request.Client) {
+		return false
+	}
+	switch {
+	case resp.Found && resp.Status == "success":
+		amount, _ := outcomeAmount(resp)
+		return f.MinAmount > 0 && amount >= f.MinAmount
+	case resp.Status == "pending" && resp.Code == "":
+		// Still in flight, e.g. awaiting a payment link; the final outcome follows.
+		return false
+	default:
+		return f.Failures
+	}
+}
+
+// OperationsMessage is the data operations message templates render.
+type OperationsMessage struct {
+	Ref            string
+	Status         string
+	Confirmed      bool
+	Amount         float64
+	Currency       string
+	Number         string
+	Client         string
+	SubscriptionID string
+	Code           ErrorCode
+	Message        string
+}
+
+// DefaultOperationsTemplate is used by Telegram and Discord senders built without a template.
+const DefaultOperationsTemplate = `{{if .Confirmed}}✅ Payment of {{printf "%.0f" .Amount}} {{.Currency}} confirmed{{else}}❌ Payment of {{printf "%.0f" .Amount}} {{.Currency}} {{or .Status "failed"}}{{end}}
+{{- with .Ref}}
+Ref: {{.}}{{end}}
+{{- with .Number}}
+Number: {{.}}{{end}}
+{{- with .Client}}
+Client: {{.}}{{end}}
+{{- with .SubscriptionID}}
+Subscription: {{.}}{{end}}
+{{- with .Code}}
+Code: {{.}}{{end}}
+{{- with .Message}}
+Reason: {{.}}{{end}}`
+
+// ParseOperationsTemplate parses a text/template rendered with an OperationsMessage.
+func ParseOperationsTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("operations").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("parse notification template: %w", err)
+	}
+	return tmpl, nil
+}
+
+var defaultOperationsTemplate = template.Must(ParseOperationsTemplate(DefaultOperationsTemplate))
+
+func renderOperationsMessage(tmpl *template.Template, resp SubscriptionResponse, limit int) (string, error) {
+	amount, currency := outcomeAmount(resp)
+	var b strings.Builder
+	err := tmpl.Execute(&b, OperationsMessage{
+		Ref:            resp.Reference,
+		Status:         resp.Status,
+		Confirmed:      resp.Found && resp.Status == "success",
+		Amount:         amount,
+		Currency:       currency,
+		Number:         resp.Request.Number,
+		Client:         resp.Request.Client,
+		SubscriptionID: resp.Request.SubscriptionID,
+		Code:           resp.Code,
+		Message:        resp.Message,
+	})
+	if err != nil {
+		return "", fmt.Errorf("render notification: %w", err)
+	}
+	text := strings.TrimSpace(b.String())
+	if runes := []rune(text); len(runes) > limit {
+		text = string(runes[:limit-1]) + "…"
+	}
+	return text, nil
+}
+
+// TelegramSender posts operations messages to a Telegram chat through a bot.
+type TelegramSender struct {
+	token      string
+	chatID     string
+	filter     OperationsFilter
+	tmpl       *template.Template
+	apiURL     string
+	httpClient *http.Client
+}
+
+// NewTelegramSender builds a Telegram notifier posting to chatID as the bot identified by
+// token. tmpl may be nil for DefaultOperationsTemplate.
+func NewTelegramSender(token, chatID string, filter OperationsFilter, tmpl *template.Template, client *http.Client) (*TelegramSender, error) {
+	token, chatID = strings.TrimSpace(token), strings.TrimSpace(chatID)
+	if token == "" {
+		return nil, errors.New("telegram bot token is required")
+	}
+	if chatID == "" {
+		return nil, errors.New("telegram chat id is required")
+	}
+	if err := filter.validate(); err != nil {
+		return nil, err
+	}
+	if tmpl == nil {
+		tmpl = defaultOperationsTemplate
+	}
+	if client == nil {
+		client = &http.Client{Timeout: defaultCallbackTimeout}
+	}
+	return &TelegramSender{token: token, chatID: chatID, filter: filter, tmpl: tmpl, apiURL: telegramAPIURL, httpClient: client}, nil
+}
+
+// Send implements CallbackSender. Outcomes the filter does not select are skipped.
+func (s *TelegramSender) Send(ctx context.Context, payload SubscriptionResponse) error {
+	if !s.filter.selects(payload) {
+		return nil
+	}
+	text, err := renderOperationsMessage(s.tmpl, payload, telegramMaxText)
+	if err != nil {
+		return err
+	}
+
+	data, err := postChatMessage(ctx, s.httpClient, s.apiURL+"/bot"+s.token+"/sendMessage", map[string]any{
+		"chat_id":                  s.chatID,
+		"text":                     text,
+		"disable_web_page_preview": true,
+	})
+	if err != nil {
+		return fmt.Errorf("telegram: %w", err)
+	}
+	var result struct {
+		OK          bool   `json:"ok"`
+		Description string `json:"description"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return fmt.Errorf("decode telegram response: %w", err)
+	}
+	if !result.OK {
+		return fmt.Errorf("telegram rejected message: %s", result.Description)
+	}
+	return nil
+}
+
+// DiscordSender posts operations messages to a Discord channel webhook.
+type DiscordSender struct {
+	webhookURL string
+	filter     OperationsFilter
+	tmpl       *template.Template
+	httpClient *http.Client
+}
+
+// NewDiscordSender builds a Discord notifier. tmpl may be nil for DefaultOperationsTemplate.
+func NewDiscordSender(webhookURL string, filter OperationsFilter, tmpl *template.Template, client *http.Client) (*DiscordSender, error) {
+	webhookURL = strings.TrimSpace(webhookURL)
+	if webhookURL == "" {
+		return nil, errors.New("discord webhook URL is required")
+	}
+	if err := filter.validate(); err != nil {
+		return nil, err
+	}
+	if tmpl == nil {
+		tmpl = defaultOperationsTemplate
+	}
+	if client == nil {
+		client = &http.Client{Timeout: defaultCallbackTimeout}
+	}
+	return &DiscordSender{webhookURL: webhookURL, filter: filter, tmpl: tmpl, httpClient: client}, nil
+}
+
+// Send implements CallbackSender. Outcomes the filter does not select are skipped.
+func (s *DiscordSender) Send(ctx context.Context, payload SubscriptionResponse) error {
+	if !s.filter.selects(payload) {
+		return nil
+	}
+	text, err := renderOperationsMessage(s.tmpl, payload, discordMaxText)
+	if err != nil {
+		return err
+	}
+
+	// Mentions are disabled so a reason or client name can never ping the channel.
+	_, err = postChatMessage(ctx, s.httpClient, s.webhookURL, map[string]any{
+		"content":          text,
+		"allowed_mentions": map[string]any{"parse": []string{}},
+	})
+	if err != nil {
+		return fmt.Errorf("discord: %w", err)
+	}
+	return nil
+}
+
+// postChatMessage posts msg as JSON and returns the response body of a 2xx reply.
+func postChatMessage(ctx context.Context, client *http.Client, endpoint string, msg map[string]any) ([]byte, error) {
+	body := &bytes.Buffer{}
+	if err := json.NewEncoder(body).Encode(msg); err != nil {
+		return nil, fmt.Errorf("encode message: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, body)
+	if err != nil {
+		return nil, fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	tracing.Inject(ctx, req.Header.Set)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		// The URL carries the bot token or webhook secret; keep it out of the error.
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, fmt.Errorf("send message: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("returned %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	return data, nil
+}
@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var chatFailure = SubscriptionResponse{
+	Reference: "bad",
+	Status:    "failed",
+	Code:      CodeInsufficientFunds,
+	Message:   "insufficient balance",
+	Request:   SubscriptionEvent{Number: "2508", Amount: 1000, Client: "acme"},
+}
+
+func TestTelegramSenderPostsRenderedMessage(t *testing.T) {
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/bot123:abc/sendMessage", r.URL.Path)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer srv.Close()
+
+	sender, err := NewTelegramSender("123:abc", "-1001", OperationsFilter{Failures: true}, nil, srv.Client())
+	require.NoError(t, err)
+	sender.apiURL = srv.URL
+
+	require.NoError(t, sender.Send(context.Background(), chatFailure))
+	require.Equal(t, "-1001", got["chat_id"])
+	require.Equal(t, "❌ Payment of 1000 RWF failed\nRef: bad\nNumber: 2508\nClient: acme\nCode: INSUFFICIENT_FUNDS\nReason: insufficient balance", got["text"])
+
+	got = nil
+	require.NoError(t, sender.Send(context.Background(), SubscriptionResponse{Reference: "ok", Status: "success", Found: true}))
+	require.Nil(t, got)
+}
+
+func TestTelegramSenderReportsRejection(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok":false,"description":"Bad Request: chat not found"}`))
+	}))
+	defer srv.Close()
+
+	sender, err := NewTelegramSender("123:abc", "-1", OperationsFilter{Failures: true}, nil, srv.Client())
+	require.NoError(t, err)
+	sender.apiURL = srv.URL
+	require.EqualError(t, sender.Send(context.Background(), chatFailure), "telegram rejected message: Bad Request: chat not found")
+}
+
+func TestDiscordSenderUsesTemplate(t *testing.T) {
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	tmpl, err := ParseOperationsTemplate(`{{.Client}}: {{.Ref}} {{if .Confirmed}}paid{{else}}{{.Code}}{{end}}`)
+	require.NoError(t, err)
+	sender, err := NewDiscordSender(srv.URL, OperationsFilter{Failures: true, MinAmount: 5000}, tmpl, srv.Client())
+	require.NoError(t, err)
+
+	require.NoError(t, sender.Send(context.Background(), chatFailure))
+	require.Equal(t, "acme: bad INSUFFICIENT_FUNDS", got["content"])
+	require.Equal(t, map[string]any{"parse": []any{}}, got["allowed_mentions"])
+
+	require.NoError(t, sender.Send(context.Background(), SubscriptionResponse{
+		Reference: "big",
+		Status:    "success",
+		Found:     true,
+		Request:   SubscriptionEvent{Amount: 9000, Client: "acme"},
+	}))
+	require.Equal(t, "acme: big paid", got["content"])
+}
+
+func TestParseOperationsTemplateRejectsUnknownFields(t *testing.T) {
+	tmpl, err := ParseOperationsTemplate(`{{.Nope}}`)
+	require.NoError(t, err)
+	_, err = renderOperationsMessage(tmpl, chatFailure, discordMaxText)
+	require.Error(t, err)
+
+	_, err = ParseOperationsTemplate(`{{.Ref`)
+	require.Error(t, err)
+}
@@ -0,0 +1,259 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/berniyo/paypack-lambda/internal/checkpoint"
+	"github.com/berniyo/paypack-lambda/internal/customer"
+	"github.com/berniyo/paypack-lambda/internal/eventstore"
+	"github.com/berniyo/paypack-lambda/internal/fx"
+	"github.com/berniyo/paypack-lambda/internal/tax"
+	"github.com/berniyo/paypack-lambda/internal/txcache"
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+const (
+	defaultResumeBatch = 25
+	minCheckpointIdle  = time.Minute
+)
+
+// pendingCashIn is the state a checkpoint carries so a resumed confirmation builds the same
+// response the original invocation would have.
+type pendingCashIn struct {
+	Event      SubscriptionEvent `json:"event"`
+	Customer   *customer.Profile `json:"customer,omitempty"`
+	Conversion *fx.Conversion    `json:"conversion,omitempty"`
+	Tax        *tax.Breakdown    `json:"tax,omitempty"`
+	Provider   string            `json:"provider,omitempty"`
+	// Continuation is the token of the pending callback sent when confirmation was cut short.
+	Continuation string `json:"continuation,omitempty"`
+}
+
+// response starts the outcome for ref with the request context carried by pending.
+func (pending pendingCashIn) response(ref string) SubscriptionResponse {
+	return SubscriptionResponse{
+		Reference:  ref,
+		Request:    pending.Event,
+		Customer:   pending.Customer,
+		Conversion: pending.Conversion,
+		Tax:        pending.Tax,
+
+		ContinuationToken: pending.Continuation,
+	}
+}
+
+// ResumeReport summarises one reconciler run over abandoned checkpoints.
+type ResumeReport struct {
+	Pending   int `json:"pending"`
+	Confirmed int `json:"confirmed"`
+	TimedOut  int `json:"timed_out"`
+	Errors    int `json:"errors"`
+}
+
+// WithCheckpoints persists (ref, deadline, attempts) while a cash-in is being confirmed, so the
+// resume action or ResumePending can pick confirmation up after a timeout or crash.
+func WithCheckpoints(store checkpoint.Store) Option {
+	return func(p *Processor) {
+		p.checkpoints = store
+	}
+}
+
+// checkpointFor snapshots the state needed to resume confirming ref.
+func (p *Processor) checkpointFor(ctx context.Context, ref string, pending pendingCashIn, deadline time.Time) *checkpoint.Checkpoint {
+	now := time.Now()
+	cp := &checkpoint.Checkpoint{Ref: ref, Deadline: deadline, StartedAt: now, UpdatedAt: now}
+	if p.checkpoints == nil {
+		return cp
+	}
+	state, err := json.Marshal(pending)
+	if err != nil {
+		p.logf(ctx, "encode checkpoint for ref=%s: %v", ref, err)
+		return cp
+	}
+	cp.State = state
+	return cp
+}
+
+func (p *Processor) saveCheckpoint(ctx context.Context, cp *checkpoint.Checkpoint) {
+	if p.checkpoints == nil || cp.State == nil {
+		return
+	}
+	cp.UpdatedAt = time.Now()
+	if err := p.checkpoints.Put(ctx, *cp); err != nil {
+		p.logf(ctx, "store checkpoint for ref=%s: %v", cp.Ref, err)
+	}
+}
+
+// checkpointedCashIn returns the pending state checkpointed for ref, if any.
+func (p *Processor) checkpointedCashIn(ctx context.Context, ref string) (pendingCashIn, bool) {
+	if p.checkpoints == nil {
+		return pendingCashIn{}, false
+	}
+	cp, err := p.checkpoints.Get(ctx, ref)
+	if err != nil {
+		if !errors.Is(err, checkpoint.ErrNotFound) {
+			p.logf(ctx, "lookup checkpoint for ref=%s: %v", ref, err)
+		}
+		return pendingCashIn{}, false
+	}
+	var pending pendingCashIn
+	if err := json.Unmarshal(cp.State, &pending); err != nil {
+		p.logf(ctx, "decode checkpoint for ref=%s: %v", ref, err)
+		return pendingCashIn{}, false
+	}
+	return pending, true
+}
+
+func (p *Processor) clearCheckpoint(ctx context.Context, ref string) {
+	if p.checkpoints == nil {
+		return
+	}
+	if err := p.checkpoints.Delete(context.WithoutCancel(ctx), ref); err != nil {
+		p.logf(ctx, "remove checkpoint for ref=%s: %v", ref, err)
+	}
+}
+
+// handleResume continues confirming event.Ref from its checkpoint.
+func (p *Processor) handleResume(ctx context.Context, event SubscriptionEvent) (SubscriptionResponse, error) {
+	if p.checkpoints == nil {
+		return SubscriptionResponse{}, errors.New("checkpoint store is not configured")
+	}
+	ref := strings.TrimSpace(event.Ref)
+	if ref == "" {
+		return SubscriptionResponse{}, withCode(CodeValidation, errors.New("ref is required"))
+	}
+
+	cp, err := p.checkpoints.Get(ctx, ref)
+	if err != nil {
+		return SubscriptionResponse{}, fmt.Errorf("lookup checkpoint for ref=%s: %w", ref, err)
+	}
+	// A self re-invocation carries the confirmation budget left when it was queued.
+	if budget := event.ConfirmTimeoutSeconds; budget > 0 {
+		if deadline := time.Now().Add(time.Duration(budget) * time.Second); deadline.Before(cp.Deadline) {
+			cp.Deadline = deadline
+		}
+	}
+	return p.resume(ctx, cp)
+}
+
+// ResumePending resumes every checkpoint no invocation has touched recently. It is the
+// reconciler entry point for a scheduled (EventBridge) invocation.
+func (p *Processor) ResumePending(ctx context.Context) (report ResumeReport, err error) {
+	defer p.flush(ctx)
+	ctx, inv := p.beginInvocation(ctx, "reconcile")
+	defer func() { inv.end(errorOutcome(err), "") }()
+	if p.checkpoints == nil {
+		return report, errors.New("checkpoint store is not configured")
+	}
+
+	idle := 3 * p.pollInterval
+	if idle < minCheckpointIdle {
+		idle = minCheckpointIdle
+	}
+	pending, err := p.checkpoints.Pending(ctx, time.Now().Add(-idle), defaultResumeBatch)
+	if err != nil {
+		return report, fmt.Errorf("load pending checkpoints: %w", err)
+	}
+	report.Pending = len(pending)
+
+	// One bulk pass settles the checkpoints Paypack already confirmed without polling them.
+	refs := make([]string, len(pending))
+	since := time.Now()
+	for i, cp := range pending {
+		refs[i] = cp.Ref
+		if !cp.StartedAt.IsZero() && cp.StartedAt.Before(since) {
+			since = cp.StartedAt
+		}
+	}
+	known := p.lookupStatuses(ctx, refs, since.Add(-time.Minute))
+
+	for i := range pending {
+		if ctx.Err() != nil {
+			break
+		}
+		resp, err := p.reconcile(ctx, &pending[i], known[i])
+		switch {
+		case err != nil:
+			p.logf(ctx, "resume ref=%s failed: %v", pending[i].Ref, err)
+			report.Errors++
+		case resp.Found:
+			report.Confirmed++
+		default:
+			report.TimedOut++
+		}
+	}
+	return report, nil
+}
+
+// resume polls from where cp left off. A checkpoint past its deadline gets one final lookup
+// before the payment is reported as unconfirmed.
+func (p *Processor) resume(ctx context.Context, cp *checkpoint.Checkpoint) (SubscriptionResponse, error) {
+	var pending pendingCashIn
+	if err := json.Unmarshal(cp.State, &pending); err != nil {
+		return SubscriptionResponse{}, fmt.Errorf("decode checkpoint for ref=%s: %w", cp.Ref, err)
+	}
+
+	if floor := time.Now().Add(p.pollInterval); cp.Deadline.Before(floor) {
+		cp.Deadline = floor
+	}
+	p.logf(ctx, "resuming confirmation for ref=%s after %d attempts", cp.Ref, cp.Attempts)
+	return p.confirm(ctx, cp, pending)
+}
+
+// reconcile settles cp when its bulk lookup found the transaction final, and resumes polling
+// it otherwise.
+func (p *Processor) reconcile(ctx context.Context, cp *checkpoint.Checkpoint, known paypack.StatusResult) (SubscriptionResponse, error) {
+	txn := known.Transaction
+	if known.Err != nil || txn == nil || !txcache.Terminal(txn.Status) {
+		return p.resume(ctx, cp)
+	}
+	var pending pendingCashIn
+	if err := json.Unmarshal(cp.State, &pending); err != nil {
+		return SubscriptionResponse{}, fmt.Errorf("decode checkpoint for ref=%s: %w", cp.Ref, err)
+	}
+	ctx = correlationContext(ctx, pending.Event)
+	ctx = p.connectionContext(ctx, pending.Event)
+	p.logf(ctx, "ref=%s confirmed while unattended; settling", cp.Ref)
+	resp := pending.response(cp.Ref)
+	resp.Status = txn.Status
+	resp.Found = true
+	resp.Transaction = txn
+	p.observeConfirmation(pending.Provider, time.Since(cp.StartedAt))
+	p.settle(ctx, cp, &resp)
+	return resp, nil
+}
+
+// WithContinuations sends a pending callback, with a continuation token, when an invocation
+// nears its deadline before the transaction is confirmed. The checkpoint (see WithCheckpoints)
+// carries the token, and the final outcome delivered later by a webhook, resume or
+// ResumePending repeats it.
+func WithContinuations() Option {
+	return func(p *Processor) {
+		p.continuations = true
+	}
+}
+
+// continueLater records a continuation token in cp and delivers resp as a pending callback
+// carrying it. The invocation's context may already be ending, so neither is bound by it.
+func (p *Processor) continueLater(ctx context.Context, cp *checkpoint.Checkpoint, pending pendingCashIn, resp *SubscriptionResponse) {
+	ctx = context.WithoutCancel(ctx)
+	if pending.Continuation == "" {
+		pending.Continuation = eventstore.NewID()
+		state, err := json.Marshal(pending)
+		if err != nil {
+			p.logf(ctx, "encode checkpoint for ref=%s: %v", cp.Ref, err)
+			return
+		}
+		cp.State = state
+	}
+	p.saveCheckpoint(ctx, cp)
+	resp.ContinuationToken = pending.Continuation
+	if err := p.emitCallback(ctx, *resp); err != nil {
+		p.logf(ctx, "pending callback for ref=%s: %v", cp.Ref, err)
+	}
+}
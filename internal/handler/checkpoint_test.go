@@ -0,0 +1,162 @@
+package handler
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/require"
+
+	"github.com/berniyo/paypack-lambda/internal/checkpoint"
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+func TestProcessorClearsCheckpointOnConfirmation(t *testing.T) {
+	client := &fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: "abc"}, nil
+		},
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: ref, Status: "success"}, nil
+		},
+	}
+
+	store := checkpoint.NewMemoryStore()
+	processor := NewProcessor(client, WithPollInterval(5*time.Millisecond), WithCheckpoints(store))
+
+	resp, err := processor.Handle(context.Background(), SubscriptionEvent{Number: "2507", Amount: 1000})
+	require.NoError(t, err)
+	require.Equal(t, "success", resp.Status)
+
+	_, err = store.Get(context.Background(), "abc")
+	require.ErrorIs(t, err, checkpoint.ErrNotFound)
+}
+
+func TestProcessorResumesInterruptedConfirmation(t *testing.T) {
+	var confirmed atomic.Bool
+	client := &fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: "abc"}, nil
+		},
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			if !confirmed.Load() {
+				return nil, paypack.ErrTransactionNotFound
+			}
+			return &paypack.Transaction{Ref: ref, Status: "success"}, nil
+		},
+	}
+
+	store := checkpoint.NewMemoryStore()
+	cb := &fakeCallback{}
+	processor := NewProcessor(
+		client,
+		WithPollInterval(5*time.Millisecond),
+		WithTimeout(time.Minute),
+		WithCallbackSender(cb),
+		WithCheckpoints(store),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	resp, err := processor.Handle(ctx, SubscriptionEvent{Number: "2507", Amount: 1000})
+	require.NoError(t, err)
+	require.Equal(t, "pending", resp.Status)
+	require.Empty(t, cb.calls)
+
+	cp, err := store.Get(context.Background(), "abc")
+	require.NoError(t, err)
+	require.Positive(t, cp.Attempts)
+	require.WithinDuration(t, time.Now().Add(time.Minute), cp.Deadline, 5*time.Second)
+
+	confirmed.Store(true)
+	resp, err = processor.Handle(context.Background(), SubscriptionEvent{Action: ActionResume, Ref: "abc"})
+	require.NoError(t, err)
+	require.Equal(t, "success", resp.Status)
+	require.Equal(t, "2507", resp.Request.Number)
+	require.Len(t, cb.calls, 1)
+
+	_, err = store.Get(context.Background(), "abc")
+	require.ErrorIs(t, err, checkpoint.ErrNotFound)
+}
+
+func TestResumePendingReportsExpiredCheckpoints(t *testing.T) {
+	client := &fakeClient{
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			if ref == "done" {
+				return &paypack.Transaction{Ref: ref, Status: "success"}, nil
+			}
+			return nil, paypack.ErrTransactionNotFound
+		},
+	}
+
+	store := checkpoint.NewMemoryStore()
+	stale := time.Now().Add(-time.Hour)
+	state := []byte(`{"event":{"number":"2507","amount":1000}}`)
+	for _, ref := range []string{"done", "lost"} {
+		require.NoError(t, store.Put(context.Background(), checkpoint.Checkpoint{
+			Ref: ref, State: state, Deadline: stale, StartedAt: stale, UpdatedAt: stale,
+		}))
+	}
+	require.NoError(t, store.Put(context.Background(), checkpoint.Checkpoint{
+		Ref: "active", State: state, Deadline: time.Now().Add(time.Minute), UpdatedAt: time.Now(),
+	}))
+
+	processor := NewProcessor(client, WithPollInterval(5*time.Millisecond), WithCheckpoints(store))
+
+	report, err := processor.ResumePending(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, ResumeReport{Pending: 2, Confirmed: 1, TimedOut: 1}, report)
+
+	left, err := store.Pending(context.Background(), time.Now(), 0)
+	require.NoError(t, err)
+	require.Len(t, left, 1)
+	require.Equal(t, "active", left[0].Ref)
+}
+
+func TestContinuationCallbackPrecedesWebhookOutcome(t *testing.T) {
+	client := &fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: "abc"}, nil
+		},
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			return nil, paypack.ErrTransactionNotFound
+		},
+	}
+	store := checkpoint.NewMemoryStore()
+	cb := &fakeCallback{}
+	processor := NewProcessor(
+		client,
+		WithPollInterval(5*time.Millisecond),
+		WithTimeout(time.Minute),
+		WithCallbackSender(cb),
+		WithCheckpoints(store),
+		WithContinuations(),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	resp, err := processor.Handle(ctx, SubscriptionEvent{Number: "2507", Amount: 1000})
+	require.NoError(t, err)
+	require.Equal(t, "pending", resp.Status)
+	require.NotEmpty(t, resp.ContinuationToken)
+	require.Len(t, cb.calls, 1)
+	require.Equal(t, "pending", cb.calls[0].Status)
+	require.Equal(t, resp.ContinuationToken, cb.calls[0].ContinuationToken)
+
+	hook := NewWebhookHandler(processor, "")
+	res, err := hook.Handle(context.Background(), events.APIGatewayV2HTTPRequest{
+		Body: `{"event_id":"e1","event_kind":"transaction:processed","data":{"ref":"abc","status":"successful","amount":1000}}`,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 200, res.StatusCode)
+	require.Len(t, cb.calls, 2)
+	final := cb.calls[1]
+	require.Equal(t, "success", final.Status)
+	require.Equal(t, resp.ContinuationToken, final.ContinuationToken)
+	require.Equal(t, "2507", final.Request.Number, "the final outcome is built from the checkpoint")
+
+	_, err = store.Get(context.Background(), "abc")
+	require.ErrorIs(t, err, checkpoint.ErrNotFound)
+}
@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"github.com/berniyo/paypack-lambda/internal/checkpoint"
+)
+
+// DelayQueue holds a message back for delay before delivering it to the check consumer;
+// sqsqueue.Queue and oneshot.Scheduler implement it.
+type DelayQueue interface {
+	SendDelayed(ctx context.Context, body []byte, delay time.Duration) error
+}
+
+// WithDelayQueue stops cash-ins from sleeping in Lambda between status checks: once charged,
+// the pending state is enqueued on queue for its first check and the event returns pending.
+// HandleCheckQueue consumes the queue, looking each transaction up once and re-enqueueing it
+// until it is confirmed or times out, so a slow confirmation bills a few short invocations
+// instead of minutes of waiting. With a oneshot.Scheduler, HandleScheduledCheck is the
+// consumer instead.
+func WithDelayQueue(queue DelayQueue) Option {
+	return func(p *Processor) {
+		p.delayQueue = queue
+	}
+}
+
+// enqueueCheck hands the confirmation of a charge just initiated to the delay queue. When the
+// queue is unavailable the confirmation is polled in this invocation as usual.
+func (p *Processor) enqueueCheck(ctx context.Context, cp *checkpoint.Checkpoint, pending pendingCashIn) (SubscriptionResponse, error) {
+	state, err := p.stepState(cp, pending)
+	if err == nil {
+		err = p.sendCheck(ctx, state)
+	}
+	if err != nil {
+		p.logf(ctx, "enqueue check of ref=%s: %v; confirming in this invocation", cp.Ref, err)
+		return p.confirm(ctx, cp, pending)
+	}
+	// The queued state carries the confirmation from here, as a state machine's would.
+	p.clearCheckpoint(ctx, cp.Ref)
+	resp := pending.response(cp.Ref)
+	resp.Status = "pending"
+	resp.Message = "confirmation continues from the check queue"
+	return resp, nil
+}
+
+func (p *Processor) sendCheck(ctx context.Context, state StepState) error {
+	body, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("encode check of ref=%s: %w", state.Ref, err)
+	}
+	return p.delayQueue.SendDelayed(ctx, body, time.Duration(state.WaitSeconds)*time.Second)
+}
+
+// HandleCheckQueue implements the SQS Lambda entry point of the check queue. Each message is
+// a StepState checked once with CheckStatus; states still pending are enqueued again for
+// their next check. Messages whose check or re-enqueue failed are reported as batch item
+// failures, so the event source mapping must enable ReportBatchItemFailures.
+func (p *Processor) HandleCheckQueue(ctx context.Context, event events.SQSEvent) (events.SQSEventResponse, error) {
+	var resp events.SQSEventResponse
+	for _, record := range event.Records {
+		if !p.checkQueued(ctx, record) {
+			resp.BatchItemFailures = append(resp.BatchItemFailures, events.SQSBatchItemFailure{ItemIdentifier: record.MessageId})
+		}
+	}
+	return resp, nil
+}
+
+// checkQueued checks one queued state, reporting whether the message is done with.
+func (p *Processor) checkQueued(ctx context.Context, record events.SQSMessage) bool {
+	var state StepState
+	if err := json.Unmarshal([]byte(record.Body), &state); err != nil {
+		p.logf(ctx, "check queue message %s is not a step state; dropped: %v", record.MessageId, err)
+		return true
+	}
+	state, err := p.CheckStatus(ctx, state)
+	if err != nil {
+		// A lookup can be repeated safely; only a state that can never be checked is dropped.
+		if CodeOf(err) != CodeValidation {
+			return false
+		}
+		p.logf(ctx, "check of ref=%s failed; dropped: %v", state.Ref, err)
+		return true
+	}
+	if state.Done {
+		return true
+	}
+	if p.delayQueue == nil {
+		return false
+	}
+	if err := p.sendCheck(ctx, state); err != nil {
+		p.logf(ctx, "re-enqueue check of ref=%s: %v", state.Ref, err)
+		return false
+	}
+	return true
+}
+
+// DelayCleaner is implemented by delay queues whose deliveries leave something behind once
+// handled, such as oneshot.Scheduler's schedules.
+type DelayCleaner interface {
+	Delete(ctx context.Context, body []byte) error
+}
+
+// HandleScheduledCheck is the entry point of one-time scheduler invocations, for delay queues
+// that invoke the function directly with the queued body (oneshot.Scheduler): payload is the
+// StepState, checked once. A state still pending is scheduled again for its next check; one
+// that reached a terminal status has its schedule removed.
+func (p *Processor) HandleScheduledCheck(ctx context.Context, payload json.RawMessage) (StepState, error) {
+	var state StepState
+	if err := json.Unmarshal(payload, &state); err != nil {
+		return StepState{}, withCode(CodeValidation, fmt.Errorf("decode scheduled check: %w", err))
+	}
+	state, err := p.CheckStatus(ctx, state)
+	if err != nil {
+		return state, err
+	}
+	if !state.Done {
+		if p.delayQueue == nil {
+			return state, errors.New("no delay queue to schedule the next check on")
+		}
+		return state, p.sendCheck(ctx, state)
+	}
+	if cleaner, ok := p.delayQueue.(DelayCleaner); ok {
+		if err := cleaner.Delete(ctx, payload); err != nil {
+			p.logf(ctx, "clean up check of ref=%s: %v", state.Ref, err)
+		}
+	}
+	return state, nil
+}
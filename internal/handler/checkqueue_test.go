@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/require"
+
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+type fakeDelayQueue struct {
+	bodies [][]byte
+	delays []time.Duration
+	err    error
+}
+
+func (f *fakeDelayQueue) SendDelayed(ctx context.Context, body []byte, delay time.Duration) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.bodies = append(f.bodies, body)
+	f.delays = append(f.delays, delay)
+	return nil
+}
+
+func (f *fakeDelayQueue) drain() events.SQSEvent {
+	var event events.SQSEvent
+	for i, body := range f.bodies {
+		event.Records = append(event.Records, events.SQSMessage{MessageId: string(rune('a' + i)), Body: string(body)})
+	}
+	f.bodies, f.delays = nil, nil
+	return event
+}
+
+func TestDelayQueueConfirmsAcrossQueuedChecks(t *testing.T) {
+	var lookups atomic.Int32
+	client := &fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: "abc"}, nil
+		},
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			if lookups.Add(1) < 2 {
+				return nil, paypack.ErrTransactionNotFound
+			}
+			return &paypack.Transaction{Ref: ref, Status: "success"}, nil
+		},
+	}
+	queue := &fakeDelayQueue{}
+	cb := &fakeCallback{}
+	processor := NewProcessor(client, WithPollInterval(15*time.Second), WithTimeout(time.Minute), WithCallbackSender(cb), WithDelayQueue(queue))
+
+	resp, err := processor.Handle(authenticatedContext(context.Background()), SubscriptionEvent{Number: "2507", Amount: 1000})
+	require.NoError(t, err)
+	require.Equal(t, "pending", resp.Status)
+	require.Contains(t, resp.Message, "check queue")
+	require.Zero(t, lookups.Load(), "the charge is not polled in the invocation")
+	require.Len(t, queue.bodies, 1)
+	require.Equal(t, time.Second, queue.delays[0], "the first check is due at once")
+
+	out, err := processor.HandleCheckQueue(context.Background(), queue.drain())
+	require.NoError(t, err)
+	require.Empty(t, out.BatchItemFailures)
+	require.Len(t, queue.bodies, 1, "a pending check is enqueued again")
+	require.Equal(t, 15*time.Second, queue.delays[0])
+	require.Empty(t, cb.calls)
+
+	out, err = processor.HandleCheckQueue(context.Background(), queue.drain())
+	require.NoError(t, err)
+	require.Empty(t, out.BatchItemFailures)
+	require.Empty(t, queue.bodies)
+	require.Len(t, cb.calls, 1)
+	require.Equal(t, "success", cb.calls[0].Status)
+	require.Equal(t, "2507", cb.calls[0].Request.Number)
+}
+
+func TestDelayQueueFailuresFallBackAndRedeliver(t *testing.T) {
+	client := &fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: "abc"}, nil
+		},
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: ref, Status: "success"}, nil
+		},
+	}
+	queue := &fakeDelayQueue{err: errors.New("queue unavailable")}
+	processor := NewProcessor(client, WithPollInterval(time.Millisecond), WithTimeout(time.Minute), WithDelayQueue(queue))
+
+	resp, err := processor.Handle(authenticatedContext(context.Background()), SubscriptionEvent{Number: "2507", Amount: 1000})
+	require.NoError(t, err)
+	require.Equal(t, "success", resp.Status, "confirmation falls back to polling")
+
+	client.findTransactionFn = func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+		return nil, paypack.ErrTransactionNotFound
+	}
+	out, err := processor.HandleCheckQueue(context.Background(), events.SQSEvent{Records: []events.SQSMessage{
+		{MessageId: "pending", Body: `{"ref":"abc","status":"pending","deadline":"2999-01-01T00:00:00Z"}`},
+		{MessageId: "garbage", Body: `not json`},
+	}})
+	require.NoError(t, err)
+	require.Equal(t, []events.SQSBatchItemFailure{{ItemIdentifier: "pending"}}, out.BatchItemFailures)
+}
+
+type fakeScheduler struct {
+	fakeDelayQueue
+	deleted [][]byte
+}
+
+func (f *fakeScheduler) Delete(ctx context.Context, body []byte) error {
+	f.deleted = append(f.deleted, body)
+	return nil
+}
+
+func TestScheduledChecksRescheduleUntilTerminalThenCleanUp(t *testing.T) {
+	var lookups atomic.Int32
+	client := &fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: "abc"}, nil
+		},
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			if lookups.Add(1) < 2 {
+				return nil, paypack.ErrTransactionNotFound
+			}
+			return &paypack.Transaction{Ref: ref, Status: "success"}, nil
+		},
+	}
+	scheduler := &fakeScheduler{}
+	cb := &fakeCallback{}
+	processor := NewProcessor(client, WithPollInterval(15*time.Second), WithTimeout(time.Minute), WithCallbackSender(cb), WithDelayQueue(scheduler))
+
+	resp, err := processor.Handle(authenticatedContext(context.Background()), SubscriptionEvent{Number: "2507", Amount: 1000})
+	require.NoError(t, err)
+	require.Equal(t, "pending", resp.Status)
+	require.Len(t, scheduler.bodies, 1)
+
+	first := scheduler.bodies[0]
+	scheduler.bodies = nil
+	state, err := processor.HandleScheduledCheck(context.Background(), first)
+	require.NoError(t, err)
+	require.False(t, state.Done)
+	require.Len(t, scheduler.bodies, 1, "the next check is scheduled")
+	require.Empty(t, scheduler.deleted)
+
+	second := scheduler.bodies[0]
+	scheduler.bodies = nil
+	state, err = processor.HandleScheduledCheck(context.Background(), second)
+	require.NoError(t, err)
+	require.True(t, state.Done)
+	require.Equal(t, "success", state.Status)
+	require.Empty(t, scheduler.bodies)
+	require.Equal(t, [][]byte{second}, scheduler.deleted)
+	require.Len(t, cb.calls, 1)
+}
@@ -0,0 +1,233 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+
+	"github.com/berniyo/paypack-lambda/pkg/envelope"
+)
+
+// CloudEventsMode selects how a CloudEvent is laid out on the wire.
+type CloudEventsMode string
+
+const (
+	// CloudEventsStructured sends the whole event as an application/cloudevents+json body.
+	CloudEventsStructured CloudEventsMode = "structured"
+	// CloudEventsBinary sends the payload as the body and the attributes as headers.
+	CloudEventsBinary CloudEventsMode = "binary"
+)
+
+// DefaultCloudEventsSource is the CloudEvents source used when none is configured.
+const DefaultCloudEventsSource = "paypack-lambda"
+
+func (m CloudEventsMode) validate() error {
+	switch m {
+	case CloudEventsStructured, CloudEventsBinary:
+		return nil
+	}
+	return fmt.Errorf("unknown cloudevents mode %q", m)
+}
+
+// cloudEventsFormat renders outcomes as CloudEvents with a fixed source and API version.
+type cloudEventsFormat struct {
+	mode       CloudEventsMode
+	source     string
+	apiVersion string
+}
+
+func (f cloudEventsFormat) event(resp SubscriptionResponse, now func() time.Time) (envelope.CloudEvent, error) {
+	event, err := newCallbackEvent(resp, f.apiVersion, now())
+	if err != nil {
+		return envelope.CloudEvent{}, err
+	}
+	return event.CloudEvent(f.source, resp.Reference), nil
+}
+
+// WithCloudEvents posts each payload as a CloudEvents 1.0 event from source
+// (DefaultCloudEventsSource when empty) in the given mode. The envelope API version
+// (WithCallbackEnvelope, default envelope.Latest) travels as the apiversion attribute.
+func WithCloudEvents(mode CloudEventsMode, source string) CallbackOption {
+	return func(h *HTTPSCallbackSender) {
+		h.cloudEvents = &cloudEventsFormat{mode: mode, source: orDefault(source, DefaultCloudEventsSource)}
+	}
+}
+
+// EventBridgePublisher is the part of the EventBridge client the CloudEvents sender uses.
+type EventBridgePublisher interface {
+	PutEvents(ctx context.Context, in *eventbridge.PutEventsInput, opts ...func(*eventbridge.Options)) (*eventbridge.PutEventsOutput, error)
+}
+
+// EventBridgeSender publishes outcomes to an EventBridge bus as structured CloudEvents, with
+// the event type as detail-type so rules can route failures separately.
+type EventBridgeSender struct {
+	client EventBridgePublisher
+	bus    string
+	format cloudEventsFormat
+	now    func() time.Time
+}
+
+// NewEventBridgeSender builds a sender publishing to bus from source (DefaultCloudEventsSource
+// when empty) with the given envelope API version (envelope.Latest when empty).
+func NewEventBridgeSender(client EventBridgePublisher, bus, source, apiVersion string) (*EventBridgeSender, error) {
+	if client == nil {
+		return nil, errors.New("eventbridge client is required")
+	}
+	if bus == "" {
+		return nil, errors.New("event bus is required")
+	}
+	apiVersion = orDefault(apiVersion, envelope.Latest)
+	if !slices.Contains(envelope.Versions, apiVersion) {
+		return nil, fmt.Errorf("unsupported callback api version %q", apiVersion)
+	}
+	format := cloudEventsFormat{mode: CloudEventsStructured, source: orDefault(source, DefaultCloudEventsSource), apiVersion: apiVersion}
+	return &EventBridgeSender{client: client, bus: bus, format: format, now: time.Now}, nil
+}
+
+// Send implements CallbackSender.
+func (e *EventBridgeSender) Send(ctx context.Context, payload SubscriptionResponse) error {
+	event, err := e.format.event(payload, e.now)
+	if err != nil {
+		return err
+	}
+	detail, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("encode cloudevent: %w", err)
+	}
+	out, err := e.client.PutEvents(ctx, &eventbridge.PutEventsInput{
+		Entries: []types.PutEventsRequestEntry{{
+			EventBusName: aws.String(e.bus),
+			Source:       aws.String(event.Source),
+			DetailType:   aws.String(event.Type),
+			Detail:       aws.String(string(detail)),
+			Time:         aws.Time(event.Time),
+		}},
+	})
+	if err != nil {
+		return fmt.Errorf("put eventbridge event: %w", err)
+	}
+	if out.FailedEntryCount > 0 && len(out.Entries) > 0 {
+		entry := out.Entries[0]
+		return fmt.Errorf("eventbridge rejected event: %s: %s", aws.ToString(entry.ErrorCode), aws.ToString(entry.ErrorMessage))
+	}
+	return nil
+}
+
+// KafkaHeader is a Kafka record header.
+type KafkaHeader struct {
+	Key   string
+	Value []byte
+}
+
+// KafkaMessage is a record for KafkaProducer.
+type KafkaMessage struct {
+	Topic   string
+	Key     []byte
+	Value   []byte
+	Headers []KafkaHeader
+}
+
+// KafkaProducer writes records to Kafka; adapt the client library of your choice to it.
+type KafkaProducer interface {
+	Produce(ctx context.Context, msg KafkaMessage) error
+}
+
+// KafkaSender publishes outcomes to a Kafka topic as CloudEvents, keyed by ref so every
+// event of a transaction lands on the same partition, in order.
+type KafkaSender struct {
+	producer KafkaProducer
+	topic    string
+	format   cloudEventsFormat
+	protobuf bool
+	now      func() time.Time
+}
+
+// KafkaOption configures a KafkaSender.
+type KafkaOption func(*KafkaSender)
+
+// WithKafkaProtobuf writes binary-mode record values as protobuf paypack.v1.PaymentOutcome
+// messages (datacontenttype application/x-protobuf) instead of JSON.
+func WithKafkaProtobuf() KafkaOption {
+	return func(k *KafkaSender) {
+		k.protobuf = true
+	}
+}
+
+// NewKafkaSender builds a sender writing to topic in mode from source
+// (DefaultCloudEventsSource when empty) with the given envelope API version (envelope.Latest
+// when empty).
+func NewKafkaSender(producer KafkaProducer, topic string, mode CloudEventsMode, source, apiVersion string, opts ...KafkaOption) (*KafkaSender, error) {
+	if producer == nil {
+		return nil, errors.New("kafka producer is required")
+	}
+	if topic == "" {
+		return nil, errors.New("kafka topic is required")
+	}
+	if err := mode.validate(); err != nil {
+		return nil, err
+	}
+	apiVersion = orDefault(apiVersion, envelope.Latest)
+	if !slices.Contains(envelope.Versions, apiVersion) {
+		return nil, fmt.Errorf("unsupported callback api version %q", apiVersion)
+	}
+	format := cloudEventsFormat{mode: mode, source: orDefault(source, DefaultCloudEventsSource), apiVersion: apiVersion}
+	k := &KafkaSender{producer: producer, topic: topic, format: format, now: time.Now}
+	for _, opt := range opts {
+		opt(k)
+	}
+	if k.protobuf && mode != CloudEventsBinary {
+		return nil, errors.New("protobuf kafka records require binary mode")
+	}
+	return k, nil
+}
+
+// Send implements CallbackSender.
+func (k *KafkaSender) Send(ctx context.Context, payload SubscriptionResponse) error {
+	event, err := k.format.event(payload, k.now)
+	if err != nil {
+		return err
+	}
+	msg := KafkaMessage{Topic: k.topic, Key: []byte(payload.Reference)}
+	if k.format.mode == CloudEventsBinary {
+		msg.Value = event.Data
+		if k.protobuf {
+			var payment envelope.Payment
+			if err := json.Unmarshal(event.Data, &payment); err != nil {
+				return fmt.Errorf("encode callback payload: %w", err)
+			}
+			msg.Value = payment.MarshalProto()
+			event.DataContentType = envelope.ProtobufContentType
+		}
+		msg.Headers = append(msg.Headers, KafkaHeader{Key: "content-type", Value: []byte(event.DataContentType)})
+		attrs := event.Attributes()
+		for _, name := range sortedKeys(attrs) {
+			msg.Headers = append(msg.Headers, KafkaHeader{Key: "ce_" + name, Value: []byte(attrs[name])})
+		}
+	} else {
+		if msg.Value, err = json.Marshal(event); err != nil {
+			return fmt.Errorf("encode cloudevent: %w", err)
+		}
+		msg.Headers = []KafkaHeader{{Key: "content-type", Value: []byte(envelope.CloudEventsContentType)}}
+	}
+	if err := k.producer.Produce(ctx, msg); err != nil {
+		return fmt.Errorf("produce kafka record: %w", err)
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,162 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/stretchr/testify/require"
+
+	"github.com/berniyo/paypack-lambda/pkg/envelope"
+)
+
+var cloudEventOutcome = SubscriptionResponse{Reference: "abc", Status: "failed", Found: true, Request: SubscriptionEvent{Number: "2507", Amount: 1000}}
+
+func fixedNow() time.Time { return time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC) }
+
+func TestCallbackCloudEventsStructured(t *testing.T) {
+	var got *http.Request
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer srv.Close()
+
+	sender, err := NewHTTPSCallbackSender(srv.URL, "", srv.Client(), WithCloudEvents(CloudEventsStructured, ""))
+	require.NoError(t, err)
+	sender.now = fixedNow
+	require.NoError(t, sender.Send(context.Background(), cloudEventOutcome))
+
+	require.Equal(t, envelope.CloudEventsContentType, got.Header.Get("Content-Type"))
+	var event envelope.CloudEvent
+	require.NoError(t, json.Unmarshal(body, &event))
+	require.Equal(t, "1.0", event.SpecVersion)
+	require.Equal(t, DefaultCloudEventsSource, event.Source)
+	require.Equal(t, envelope.TypePaymentFailed, event.Type)
+	require.Equal(t, "abc", event.Subject)
+	require.Equal(t, envelope.Latest, event.APIVersion)
+	require.Equal(t, fixedNow(), event.Time)
+	require.Regexp(t, `^evt_`, event.ID)
+	var data SubscriptionResponse
+	require.NoError(t, json.Unmarshal(event.Data, &data))
+	require.Equal(t, "abc", data.Reference)
+}
+
+func TestCallbackCloudEventsBinary(t *testing.T) {
+	var got *http.Request
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer srv.Close()
+
+	sender, err := NewHTTPSCallbackSender(srv.URL, "", srv.Client(), WithCloudEvents(CloudEventsBinary, "urn:shop:payments"))
+	require.NoError(t, err)
+	sender.now = fixedNow
+	require.NoError(t, sender.Send(context.Background(), cloudEventOutcome))
+
+	require.Equal(t, "application/json", got.Header.Get("Content-Type"))
+	require.Equal(t, "1.0", got.Header.Get("ce-specversion"))
+	require.Equal(t, "urn:shop:payments", got.Header.Get("ce-source"))
+	require.Equal(t, envelope.TypePaymentFailed, got.Header.Get("ce-type"))
+	require.Equal(t, "abc", got.Header.Get("ce-subject"))
+	require.Equal(t, "2026-10-14T09:00:00Z", got.Header.Get("ce-time"))
+	require.NotEmpty(t, got.Header.Get("ce-id"))
+	var data SubscriptionResponse
+	require.NoError(t, json.Unmarshal(body, &data))
+	require.Equal(t, "failed", data.Status)
+
+	_, err = NewHTTPSCallbackSender(srv.URL, "", nil, WithCloudEvents("batched", ""))
+	require.EqualError(t, err, `unknown cloudevents mode "batched"`)
+}
+
+type fakeEventBridge struct {
+	inputs []*eventbridge.PutEventsInput
+}
+
+func (f *fakeEventBridge) PutEvents(ctx context.Context, in *eventbridge.PutEventsInput, opts ...func(*eventbridge.Options)) (*eventbridge.PutEventsOutput, error) {
+	f.inputs = append(f.inputs, in)
+	return &eventbridge.PutEventsOutput{}, nil
+}
+
+func TestEventBridgeSenderPublishesCloudEvent(t *testing.T) {
+	bus := &fakeEventBridge{}
+	sender, err := NewEventBridgeSender(bus, "payments", "", "")
+	require.NoError(t, err)
+	sender.now = fixedNow
+	require.NoError(t, sender.Send(context.Background(), cloudEventOutcome))
+
+	require.Len(t, bus.inputs, 1)
+	entry := bus.inputs[0].Entries[0]
+	require.Equal(t, "payments", aws.ToString(entry.EventBusName))
+	require.Equal(t, DefaultCloudEventsSource, aws.ToString(entry.Source))
+	require.Equal(t, envelope.TypePaymentFailed, aws.ToString(entry.DetailType))
+	var event envelope.CloudEvent
+	require.NoError(t, json.Unmarshal([]byte(aws.ToString(entry.Detail)), &event))
+	require.Equal(t, "abc", event.Subject)
+}
+
+type recordingProducer struct {
+	messages []KafkaMessage
+}
+
+func (p *recordingProducer) Produce(ctx context.Context, msg KafkaMessage) error {
+	p.messages = append(p.messages, msg)
+	return nil
+}
+
+func TestKafkaSenderModes(t *testing.T) {
+	producer := &recordingProducer{}
+	binary, err := NewKafkaSender(producer, "payments", CloudEventsBinary, "", "")
+	require.NoError(t, err)
+	binary.now = fixedNow
+	structured, err := NewKafkaSender(producer, "payments", CloudEventsStructured, "", "")
+	require.NoError(t, err)
+	structured.now = fixedNow
+
+	require.NoError(t, binary.Send(context.Background(), cloudEventOutcome))
+	require.NoError(t, structured.Send(context.Background(), cloudEventOutcome))
+	require.Len(t, producer.messages, 2)
+
+	msg := producer.messages[0]
+	require.Equal(t, "payments", msg.Topic)
+	require.Equal(t, []byte("abc"), msg.Key)
+	headers := make(map[string]string)
+	for _, h := range msg.Headers {
+		headers[h.Key] = string(h.Value)
+	}
+	require.Equal(t, "application/json", headers["content-type"])
+	require.Equal(t, envelope.TypePaymentFailed, headers["ce_type"])
+	require.Equal(t, "abc", headers["ce_subject"])
+
+	msg = producer.messages[1]
+	require.Equal(t, []KafkaHeader{{Key: "content-type", Value: []byte(envelope.CloudEventsContentType)}}, msg.Headers)
+	var event envelope.CloudEvent
+	require.NoError(t, json.Unmarshal(msg.Value, &event))
+	require.Equal(t, headers["ce_id"], event.ID, "both modes carry the same event")
+}
+
+func TestKafkaSenderProtobufValues(t *testing.T) {
+	producer := &recordingProducer{}
+	sender, err := NewKafkaSender(producer, "payments", CloudEventsBinary, "", "", WithKafkaProtobuf())
+	require.NoError(t, err)
+	require.NoError(t, sender.Send(context.Background(), cloudEventOutcome))
+
+	msg := producer.messages[0]
+	require.Equal(t, KafkaHeader{Key: "content-type", Value: []byte(envelope.ProtobufContentType)}, msg.Headers[0])
+	var payment envelope.Payment
+	require.NoError(t, payment.UnmarshalProto(msg.Value))
+	require.Equal(t, "abc", payment.Ref)
+	require.Equal(t, "2507", payment.Request.Number)
+
+	_, err = NewKafkaSender(producer, "payments", CloudEventsStructured, "", "", WithKafkaProtobuf())
+	require.EqualError(t, err, "protobuf kafka records require binary mode")
+}
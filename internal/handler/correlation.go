@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+// HeaderCorrelationID carries an event's correlation_id on callback deliveries.
+const HeaderCorrelationID = paypack.HeaderCorrelationID
+
+type correlationKey struct{}
+
+// correlationContext carries event's correlation_id through ctx, into log lines and Paypack
+// requests. Events without one keep the ID already in ctx, so batch items inherit the batch's.
+func correlationContext(ctx context.Context, event SubscriptionEvent) context.Context {
+	if event.CorrelationID == "" {
+		return ctx
+	}
+	ctx = context.WithValue(ctx, correlationKey{}, event.CorrelationID)
+	return paypack.WithCorrelationID(ctx, event.CorrelationID)
+}
+
+func correlationID(ctx context.Context) string {
+	id, _ := ctx.Value(correlationKey{}).(string)
+	return id
+}
+
+type itemKey struct{}
+
+// batchItemContext gives batch item index a charge key of its own, <batch correlation
+// ID>-<index>. Items without a correlation_id would otherwise share the batch's ID, and
+// Paypack would take items 2..N for resends of the first; the batch's ID still tags their
+// log lines.
+func batchItemContext(ctx context.Context, index int) context.Context {
+	id := correlationID(ctx)
+	if id == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, itemKey{}, fmt.Sprintf("%s-%d", id, index))
+}
+
+// idempotencyContext keys the charge for event with its correlation ID, so Paypack recognises
+// a request resent for the same attempt. Each retry attempt gets its own key; retries of
+// events without a correlation ID are keyed by their retry entry instead.
+func idempotencyContext(ctx context.Context, event SubscriptionEvent) context.Context {
+	key := idempotencyKey(ctx, event)
+	if key == "" {
+		return ctx
+	}
+	return paypack.WithIdempotencyKey(ctx, key)
+}
+
+// idempotencyKey is the key of event's charge attempt, or "" for a first attempt without a
+// correlation ID. An event's own correlation_id wins over the batch item key, which wins over
+// the ID ctx carries.
+func idempotencyKey(ctx context.Context, event SubscriptionEvent) string {
+	item, _ := ctx.Value(itemKey{}).(string)
+	id := orDefault(event.CorrelationID, orDefault(item, correlationID(ctx)))
+	if event.RetryAttempt == 0 {
+		return id
+	}
+	id = orDefault(id, event.RetryID)
+	if id == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s-retry-%d", id, event.RetryAttempt)
+}
+
+// logf logs through p.logger, tagged with the correlation ID ctx carries.
+func (p *Processor) logf(ctx context.Context, format string, args ...any) {
+	p.logger.Printf(correlated(correlationID(ctx), format), args...)
+}
+
+func correlated(id, format string) string {
+	if id == "" {
+		return format
+	}
+	return "correlation_id=" + id + " " + format
+}
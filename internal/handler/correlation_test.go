@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+func TestProcessorCarriesCorrelationID(t *testing.T) {
+	var buf bytes.Buffer
+	callback := &fakeCallback{}
+	client := &fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: "abc"}, nil
+		},
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: ref, Status: "success"}, nil
+		},
+	}
+	processor := NewProcessor(client, WithPollInterval(time.Millisecond), WithCallbackSender(callback), WithLogger(log.New(&buf, "", 0)))
+
+	resp, err := processor.Handle(context.Background(), SubscriptionEvent{Number: "2507", Amount: 1000, CorrelationID: "order-42"})
+	require.NoError(t, err)
+	require.Equal(t, "order-42", resp.CorrelationID)
+	require.Len(t, callback.calls, 1)
+	require.Equal(t, "order-42", callback.calls[0].CorrelationID)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.NotEmpty(t, lines)
+	for _, line := range lines {
+		require.True(t, strings.HasPrefix(line, "correlation_id=order-42 "), line)
+	}
+}
+
+func TestIdempotencyKeyVariesPerRetryAttempt(t *testing.T) {
+	keys := make(chan string, 2)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/auth/agents/authorize" {
+			fmt.Fprint(w, `{"access":"tok","expires":3600}`)
+			return
+		}
+		keys <- r.Header.Get(paypack.HeaderIdempotencyKey)
+		fmt.Fprint(w, `{"ref":"abc","status":"pending","amount":1000}`)
+	}))
+	defer srv.Close()
+	client, err := paypack.NewClient(srv.URL, "id", "secret", nil)
+	require.NoError(t, err)
+
+	ctx := correlationContext(context.Background(), SubscriptionEvent{CorrelationID: "order-42"})
+	_, err = client.CashIn(idempotencyContext(ctx, SubscriptionEvent{}), "0788000001", 1000)
+	require.NoError(t, err)
+	_, err = client.CashIn(idempotencyContext(ctx, SubscriptionEvent{RetryAttempt: 2}), "0788000001", 1000)
+	require.NoError(t, err)
+	require.Equal(t, "order-42", <-keys)
+	require.Equal(t, "order-42-retry-2", <-keys)
+
+	// Retries of events sent without a correlation ID are keyed by their queue entry.
+	require.Empty(t, idempotencyKey(context.Background(), SubscriptionEvent{}))
+	require.Equal(t, "ref-1-retry-1", idempotencyKey(context.Background(), SubscriptionEvent{RetryID: "ref-1", RetryAttempt: 1}))
+}
+
+func TestCallbackSendsCorrelationHeader(t *testing.T) {
+	headers := make(chan http.Header, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers <- r.Header.Clone()
+	}))
+	defer srv.Close()
+
+	sender, err := NewHTTPSCallbackSender(srv.URL, "", srv.Client())
+	require.NoError(t, err)
+	require.NoError(t, sender.Send(context.Background(), SubscriptionResponse{Reference: "abc", CorrelationID: "order-42"}))
+	require.Equal(t, "order-42", (<-headers).Get(HeaderCorrelationID))
+}
+
+func TestBatchItemsGetTheirOwnIdempotencyKeys(t *testing.T) {
+	var mu sync.Mutex
+	keys := make(map[string]int)
+	var charged atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/auth/agents/authorize":
+			fmt.Fprint(w, `{"access":"tok","expires":3600}`)
+		case r.Method == http.MethodPost:
+			mu.Lock()
+			keys[r.Header.Get(paypack.HeaderIdempotencyKey)]++
+			mu.Unlock()
+			fmt.Fprintf(w, `{"ref":"r%d","status":"pending","amount":100}`, charged.Add(1))
+		default:
+			ref := strings.TrimPrefix(r.URL.Path, "/api/transactions/find/")
+			fmt.Fprintf(w, `{"ref":%q,"kind":"CASHIN","status":"success","amount":100}`, ref)
+		}
+	}))
+	defer srv.Close()
+	client, err := paypack.NewClient(srv.URL, "id", "secret", nil)
+	require.NoError(t, err)
+	processor := NewProcessor(client, WithPollInterval(time.Millisecond))
+
+	items := []SubscriptionEvent{
+		{Number: "0788000001", Amount: 100},
+		{Number: "0788000002", Amount: 100},
+		{Number: "0788000003", Amount: 100, CorrelationID: "order-7"},
+	}
+	_, err = processor.Handle(context.Background(), SubscriptionEvent{Action: ActionBatch, CorrelationID: "batch-1", Items: items})
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{"batch-1-0": 1, "batch-1-1": 1, "order-7": 1}, keys)
+}
@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/berniyo/paypack-lambda/internal/fx"
+	"github.com/berniyo/paypack-lambda/internal/money"
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+// WithCurrencies sets the currencies that can be charged through Paypack as-is (default RWF).
+func WithCurrencies(codes ...string) Option {
+	return func(p *Processor) {
+		if len(codes) == 0 {
+			return
+		}
+		p.currencies = make(map[string]bool, len(codes))
+		for _, code := range codes {
+			p.currencies[normalizeCurrency(code)] = true
+		}
+	}
+}
+
+// WithFX converts events in currencies not accepted by WithCurrencies into settlement
+// (default RWF) using provider before charging. The applied rate is reported as conversion.
+func WithFX(provider fx.RateProvider, settlement string) Option {
+	return func(p *Processor) {
+		p.rates = provider
+		if settlement != "" {
+			p.settlementCurrency = normalizeCurrency(settlement)
+		}
+	}
+}
+
+// normalizeCurrency upper-cases code, defaulting to RWF.
+func normalizeCurrency(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return paypack.DefaultCurrency
+	}
+	return code
+}
+
+// normalizeAmounts fills in whichever of amount and amount_minor the event left out, so both
+// carry the same value from here on. amount_minor is exact and preferred; an amount that
+// disagrees with it is rejected rather than guessed at. Payments also have their currency
+// upper-cased or defaulted, so responses and callbacks echo what was charged.
+func normalizeAmounts(event *SubscriptionEvent) error {
+	currency := normalizeCurrency(event.Currency)
+	if !paypack.ValidCurrencyCode(currency) {
+		return withCode(CodeValidation, fmt.Errorf("invalid currency %q", currency))
+	}
+	switch {
+	case event.AmountMinor < 0:
+		return withCode(CodeValidation, errors.New("amount_minor must not be negative"))
+	case event.AmountMinor != 0:
+		if event.Amount != 0 && money.ToMinor(event.Amount, currency) != event.AmountMinor {
+			return withCode(CodeValidation, fmt.Errorf("amount %v does not match amount_minor %d", event.Amount, event.AmountMinor))
+		}
+		event.Amount = money.FromMinor(event.AmountMinor, currency)
+	case event.Amount > 0:
+		// Amounts finer than the currency's minor unit are rounded to it, so the amount
+		// charged is the one amount_minor reports.
+		event.AmountMinor = money.ToMinor(event.Amount, currency)
+		event.Amount = money.FromMinor(event.AmountMinor, currency)
+	}
+	if event.Amount > 0 {
+		event.Currency = currency
+	}
+	return nil
+}
+
+// chargeAmount resolves the amount and currency actually sent to Paypack for event.
+func (p *Processor) chargeAmount(ctx context.Context, event SubscriptionEvent) (float64, string, *fx.Conversion, error) {
+	currency := normalizeCurrency(event.Currency)
+	if p.currencies[currency] {
+		return event.Amount, currency, nil, nil
+	}
+	if p.rates == nil {
+		return 0, "", nil, withCode(CodeValidation, fmt.Errorf("currency %s is not supported", currency))
+	}
+
+	conv, err := fx.Convert(ctx, p.rates, event.Amount, currency, p.settlementCurrency)
+	if err != nil {
+		return 0, "", nil, fmt.Errorf("currency conversion failed: %w", err)
+	}
+	return conv.ConvertedAmount, conv.To, conv, nil
+}
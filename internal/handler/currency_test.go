@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/berniyo/paypack-lambda/internal/fx"
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+func TestProcessorDefaultsCurrencyToRWF(t *testing.T) {
+	client := &fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: "abc"}, nil
+		},
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: ref, Status: "success"}, nil
+		},
+	}
+	processor := NewProcessor(client, WithPollInterval(5*time.Millisecond), WithTimeout(200*time.Millisecond))
+
+	resp, err := processor.Handle(context.Background(), SubscriptionEvent{Number: "2507", Amount: 1000})
+	require.NoError(t, err)
+	require.Equal(t, "RWF", resp.Request.Currency)
+	require.Equal(t, "RWF", client.lastCashIn.Currency)
+	require.Nil(t, resp.Conversion)
+}
+
+func TestProcessorConvertsForeignCurrency(t *testing.T) {
+	var charged float64
+	client := &fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			charged = amount
+			return &paypack.Transaction{Ref: "abc"}, nil
+		},
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: ref, Status: "success"}, nil
+		},
+	}
+	processor := NewProcessor(
+		client,
+		WithPollInterval(5*time.Millisecond),
+		WithTimeout(200*time.Millisecond),
+		WithFX(fx.StaticRates{"USD/RWF": 1300}, ""),
+	)
+
+	resp, err := processor.Handle(context.Background(), SubscriptionEvent{Number: "2507", Amount: 2.5, Currency: "usd"})
+	require.NoError(t, err)
+	require.Equal(t, float64(3250), charged)
+	require.Equal(t, "RWF", client.lastCashIn.Currency)
+	require.Equal(t, "USD", resp.Request.Currency)
+	require.Equal(t, float64(1300), resp.Conversion.Rate)
+	require.Equal(t, float64(3250), resp.Conversion.ConvertedAmount)
+}
+
+func TestProcessorRejectsUnsupportedCurrency(t *testing.T) {
+	processor := NewProcessor(&fakeClient{})
+
+	_, err := processor.Handle(context.Background(), SubscriptionEvent{Number: "2507", Amount: 10, Currency: "KES"})
+	require.EqualError(t, err, "currency KES is not supported")
+
+	_, err = processor.Handle(context.Background(), SubscriptionEvent{Number: "2507", Amount: 10, Currency: "dollars"})
+	require.EqualError(t, err, `invalid currency "DOLLARS"`)
+}
+
+func TestProcessorChargesAmountMinor(t *testing.T) {
+	var charged float64
+	client := &fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			charged = amount
+			return &paypack.Transaction{Ref: "abc"}, nil
+		},
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: ref, Status: "success", Amount: charged}, nil
+		},
+	}
+	processor := NewProcessor(client, WithPollInterval(time.Millisecond))
+
+	resp, err := processor.Handle(context.Background(), SubscriptionEvent{Number: "2507", AmountMinor: 1500})
+	require.NoError(t, err)
+	require.Equal(t, 1500.0, charged)
+	require.Equal(t, 1500.0, resp.Request.Amount)
+
+	resp, err = processor.Handle(context.Background(), SubscriptionEvent{Number: "2507", Amount: 2500})
+	require.NoError(t, err)
+	require.Equal(t, int64(2500), resp.Request.AmountMinor)
+
+	_, err = processor.Handle(context.Background(), SubscriptionEvent{Number: "2507", Amount: 1000, AmountMinor: 1500})
+	require.Equal(t, CodeValidation, CodeOf(err))
+}
+
+func TestNormalizeAmountsUsesCurrencyExponent(t *testing.T) {
+	event := SubscriptionEvent{Currency: "usd", AmountMinor: 1999}
+	require.NoError(t, normalizeAmounts(&event))
+	require.Equal(t, 19.99, event.Amount)
+	require.Equal(t, "USD", event.Currency)
+
+	event = SubscriptionEvent{Amount: 0.1 + 0.2, Currency: "EUR"}
+	require.NoError(t, normalizeAmounts(&event))
+	require.Equal(t, int64(30), event.AmountMinor)
+	require.Equal(t, 0.3, event.Amount)
+
+	event = SubscriptionEvent{Amount: 1000.4}
+	require.NoError(t, normalizeAmounts(&event))
+	require.Equal(t, int64(1000), event.AmountMinor)
+	require.Equal(t, 1000.0, event.Amount, "the amount charged is the one amount_minor reports")
+
+	event = SubscriptionEvent{Amount: 1000}
+	require.NoError(t, normalizeAmounts(&event))
+	require.Equal(t, "RWF", event.Currency)
+
+	require.ErrorContains(t, normalizeAmounts(&SubscriptionEvent{AmountMinor: -1}), "amount_minor must not be negative")
+}
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/berniyo/paypack-lambda/internal/customer"
+)
+
+// WithCustomerStore enables the customer_* actions and enriches cash-ins with the registered profile.
+func WithCustomerStore(store customer.Store) Option {
+	return func(p *Processor) {
+		p.customers = store
+	}
+}
+
+func (p *Processor) handleCustomer(ctx context.Context, event SubscriptionEvent) (SubscriptionResponse, error) {
+	if p.customers == nil {
+		return SubscriptionResponse{}, errors.New("customer store is not configured")
+	}
+
+	number := event.Number
+	if number == "" && event.Customer != nil {
+		number = event.Customer.Number
+	}
+	if strings.TrimSpace(number) == "" {
+		return SubscriptionResponse{}, withCode(CodeValidation, errors.New("number is required"))
+	}
+
+	resp := SubscriptionResponse{Status: "success", Request: event}
+
+	switch event.Action {
+	case ActionCustomerGet:
+		profile, err := p.customers.Get(ctx, number)
+		if errors.Is(err, customer.ErrNotFound) {
+			resp.Message = err.Error()
+			return resp, nil
+		}
+		if err != nil {
+			return SubscriptionResponse{}, fmt.Errorf("lookup customer: %w", err)
+		}
+		resp.Found = true
+		resp.Customer = profile
+
+	case ActionCustomerPut:
+		if event.Customer == nil {
+			return SubscriptionResponse{}, withCode(CodeValidation, errors.New("customer is required"))
+		}
+		profile := *event.Customer
+		profile.Number = customer.NormalizeNumber(number)
+		if err := p.customers.Put(ctx, &profile); err != nil {
+			return SubscriptionResponse{}, fmt.Errorf("store customer: %w", err)
+		}
+		resp.Found = true
+		resp.Customer = &profile
+
+	case ActionCustomerDelete:
+		err := p.customers.Delete(ctx, number)
+		if errors.Is(err, customer.ErrNotFound) {
+			resp.Message = err.Error()
+			return resp, nil
+		}
+		if err != nil {
+			return SubscriptionResponse{}, fmt.Errorf("delete customer: %w", err)
+		}
+		resp.Found = true
+	}
+
+	return resp, nil
+}
+
+// enrichFromCustomer fills event fields the caller omitted from the registered profile.
+func (p *Processor) enrichFromCustomer(ctx context.Context, event *SubscriptionEvent) *customer.Profile {
+	if p.customers == nil {
+		return nil
+	}
+
+	profile, err := p.customers.Get(ctx, event.Number)
+	if err != nil {
+		if !errors.Is(err, customer.ErrNotFound) {
+			p.logf(ctx, "customer lookup failed for number=%s: %v", event.Number, err)
+		}
+		return nil
+	}
+
+	if event.Client == "" {
+		event.Client = profile.ClientID
+	}
+	return profile
+}
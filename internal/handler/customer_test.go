@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/berniyo/paypack-lambda/internal/customer"
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+func TestProcessorCustomerCRUD(t *testing.T) {
+	processor := NewProcessor(&fakeClient{}, WithCustomerStore(customer.NewMemoryStore()))
+	ctx := context.Background()
+
+	resp, err := processor.Handle(ctx, SubscriptionEvent{
+		Action:   ActionCustomerPut,
+		Customer: &customer.Profile{Number: "+250 780 000000", ClientID: "client-1", Email: "a@example.com"},
+	})
+	require.NoError(t, err)
+	require.True(t, resp.Found)
+	require.Equal(t, "250780000000", resp.Customer.Number)
+
+	resp, err = processor.Handle(ctx, SubscriptionEvent{Action: ActionCustomerGet, Number: "250780000000"})
+	require.NoError(t, err)
+	require.True(t, resp.Found)
+	require.Equal(t, "a@example.com", resp.Customer.Email)
+
+	resp, err = processor.Handle(ctx, SubscriptionEvent{Action: ActionCustomerDelete, Number: "+250780000000"})
+	require.NoError(t, err)
+	require.True(t, resp.Found)
+
+	resp, err = processor.Handle(ctx, SubscriptionEvent{Action: ActionCustomerGet, Number: "250780000000"})
+	require.NoError(t, err)
+	require.False(t, resp.Found)
+	require.Nil(t, resp.Customer)
+}
+
+func TestProcessorCashInEnrichesFromCustomer(t *testing.T) {
+	store := customer.NewMemoryStore()
+	require.NoError(t, store.Put(context.Background(), &customer.Profile{Number: "2507", ClientID: "client-1", Email: "a@example.com"}))
+
+	client := &fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: "abc"}, nil
+		},
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: ref, Status: "success"}, nil
+		},
+	}
+	cb := &fakeCallback{}
+	processor := NewProcessor(
+		client,
+		WithPollInterval(5*time.Millisecond),
+		WithTimeout(200*time.Millisecond),
+		WithCallbackSender(cb),
+		WithCustomerStore(store),
+	)
+
+	resp, err := processor.Handle(context.Background(), SubscriptionEvent{Number: "2507", Amount: 1000})
+	require.NoError(t, err)
+	require.Equal(t, "client-1", resp.Request.Client)
+	require.Equal(t, "a@example.com", resp.Customer.Email)
+	require.Equal(t, "a@example.com", cb.calls[0].Customer.Email)
+}
+
+func TestProcessorRejectsUnknownAction(t *testing.T) {
+	processor := NewProcessor(&fakeClient{})
+
+	_, err := processor.Handle(context.Background(), SubscriptionEvent{Action: "bogus"})
+	require.EqualError(t, err, `unsupported action "bogus"`)
+}
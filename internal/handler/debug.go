@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand/v2"
+
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+type debugKey struct{}
+
+// WithDebugSampling logs the event, every Paypack request and response, and the final
+// response for a random fraction rate of invocations (0 disables, 1 logs all of them).
+// Events can also opt in individually with "debug": true.
+func WithDebugSampling(rate float64) Option {
+	return func(p *Processor) {
+		if rate >= 0 && rate <= 1 {
+			p.debugRate = rate
+		}
+	}
+}
+
+// debugContext marks ctx for verbose logging when the event asks for it or is sampled.
+func (p *Processor) debugContext(ctx context.Context, event SubscriptionEvent) context.Context {
+	if !event.Debug && (p.debugRate == 0 || rand.Float64() >= p.debugRate) {
+		return ctx
+	}
+	ctx = context.WithValue(ctx, debugKey{}, true)
+	return paypack.WithDebugLog(ctx, func(format string, args ...any) {
+		p.logf(ctx, "DEBUG "+format, args...)
+	})
+}
+
+// debugf logs only for invocations selected by debugContext.
+func (p *Processor) debugf(ctx context.Context, format string, args ...any) {
+	if on, _ := ctx.Value(debugKey{}).(bool); on {
+		p.logf(ctx, "DEBUG "+format, args...)
+	}
+}
+
+// debugJSON renders v for debug logs, falling back to an error marker.
+func debugJSON(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "<unencodable: " + err.Error() + ">"
+	}
+	return string(data)
+}
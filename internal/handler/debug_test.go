@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+func TestDebugLoggingIsPerInvocation(t *testing.T) {
+	client := &fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: "abc"}, nil
+		},
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: ref, Status: "success"}, nil
+		},
+	}
+	var buf bytes.Buffer
+	p := NewProcessor(client, WithPollInterval(time.Millisecond), WithLogger(log.New(&buf, "", 0)))
+
+	_, err := p.Handle(context.Background(), SubscriptionEvent{Number: "2507", Amount: 100})
+	require.NoError(t, err)
+	require.NotContains(t, buf.String(), "DEBUG")
+
+	_, err = p.Handle(context.Background(), SubscriptionEvent{Number: "2507", Amount: 100, Debug: true})
+	require.NoError(t, err)
+	require.Contains(t, buf.String(), `DEBUG event {"number":"2507","amount":100,"debug":true}`)
+	require.Contains(t, buf.String(), `DEBUG response {"ref":"abc","status":"success"`)
+}
+
+func TestDebugSamplingAppliesToEveryEventAtFullRate(t *testing.T) {
+	var buf bytes.Buffer
+	p := NewProcessor(&fakeClient{}, WithDebugSampling(1), WithLogger(log.New(&buf, "", 0)))
+
+	_, err := p.Handle(context.Background(), SubscriptionEvent{})
+	require.Error(t, err)
+	require.Contains(t, buf.String(), "DEBUG response")
+	require.Contains(t, buf.String(), "err=number is required")
+}
@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/berniyo/paypack-lambda/internal/ledger"
+	"github.com/berniyo/paypack-lambda/internal/split"
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+// CashOutClient is implemented by payment clients that can pay out to a number.
+type CashOutClient interface {
+	CashOut(ctx context.Context, number string, amount float64) (*paypack.Transaction, error)
+}
+
+// Disbursement reports one split leg paid out after a confirmed cash-in.
+type Disbursement struct {
+	split.Leg
+	Ref    string    `json:"ref,omitempty"`
+	Status string    `json:"status"`
+	Error  string    `json:"error,omitempty"`
+	Code   ErrorCode `json:"code,omitempty"`
+}
+
+// WithDisbursements pays each confirmed cash-in out to the recipients of the table's plan for
+// the event (metadata "plan"); events may carry their own "split" plan. Shares are taken from
+// the net amount after the Paypack fee and tax. Every leg is journaled in the ledger, which
+// must be configured and implement ledger.Reserver: a ref's disbursement is reserved with a
+// conditional write before anything is paid, so racing or repeated confirmations of a ref pay
+// it out at most once.
+func WithDisbursements(table *split.Table) Option {
+	return func(p *Processor) {
+		p.splits = table
+	}
+}
+
+func (p *Processor) splitPlan(event SubscriptionEvent) (split.Plan, bool) {
+	if event.Split != nil {
+		return *event.Split, true
+	}
+	if p.splits == nil {
+		return split.Plan{}, false
+	}
+	plan, _ := event.Metadata["plan"].(string)
+	return p.splits.PlanFor(plan)
+}
+
+func (p *Processor) disburse(ctx context.Context, resp *SubscriptionResponse) {
+	if !resp.Found || resp.Status != "success" || resp.Transaction == nil {
+		return
+	}
+	plan, ok := p.splitPlan(resp.Request)
+	if !ok {
+		return
+	}
+	if p.ledger == nil {
+		p.logf(ctx, "disbursement skipped for ref=%s: ledger is not configured", resp.Reference)
+		return
+	}
+	payer, ok := p.client.(CashOutClient)
+	if !ok {
+		p.logf(ctx, "disbursement skipped for ref=%s: payment client cannot cash out", resp.Reference)
+		return
+	}
+
+	reserver, ok := p.ledger.(ledger.Reserver)
+	if !ok {
+		p.logf(ctx, "disbursement skipped for ref=%s: ledger cannot reserve disbursements", resp.Reference)
+		return
+	}
+
+	txn := resp.Transaction
+	available := txn.Amount - txn.Fee
+	if resp.Tax != nil {
+		available -= resp.Tax.Tax
+	}
+	legs, err := plan.Allocate(available, walletCurrency(txn.Currency))
+	if err != nil {
+		p.logf(ctx, "disbursement skipped for ref=%s: %v", resp.Reference, err)
+		return
+	}
+	// Once reserved, legs are never paid again: a crash part-way leaves the rest for an
+	// operator rather than risking a second payment.
+	if err := reserver.Reserve(ctx, resp.Reference, ledger.KindDisbursement); err != nil {
+		if errors.Is(err, ledger.ErrReserved) {
+			p.logf(ctx, "disbursement for ref=%s already reserved", resp.Reference)
+		} else {
+			p.logf(ctx, "disbursement skipped for ref=%s: %v", resp.Reference, err)
+		}
+		return
+	}
+
+	for i, leg := range legs {
+		d := Disbursement{Leg: leg, Status: "failed"}
+		out, err := payer.CashOut(paypack.WithIdempotencyKey(ctx, disbursementKey(resp.Reference, i)), leg.Number, leg.Amount)
+		if err != nil {
+			p.logf(ctx, "disbursement of %.2f to %s for ref=%s failed: %v", leg.Amount, leg.Number, resp.Reference, err)
+			d.Error = err.Error()
+			d.Code = paypackCode(err)
+			resp.Disbursements = append(resp.Disbursements, d)
+			continue
+		}
+		p.debitWallet(ctx, out, leg.Amount)
+		d.Ref = out.Ref
+		d.Status = "pending"
+		if out.Status != "" {
+			d.Status = out.Status
+		}
+		resp.Disbursements = append(resp.Disbursements, d)
+		if err := p.ledger.Append(ctx, ledger.Entry{
+			Ref:         resp.Reference,
+			Kind:        ledger.KindDisbursement,
+			Amount:      -leg.Amount,
+			Currency:    txn.Currency,
+			Description: fmt.Sprintf("cashout to %s ref=%s", leg.Number, out.Ref),
+		}); err != nil {
+			p.logf(ctx, "ledger write failed for disbursement to %s of ref=%s: %v", leg.Number, resp.Reference, err)
+		}
+	}
+}
+
+// disbursementKey is the Paypack idempotency key of leg i of ref's disbursement, so a cash-out
+// request resent for the same leg is not paid twice.
+func disbursementKey(ref string, i int) string {
+	return fmt.Sprintf("%s-disburse-%d", ref, i)
+}
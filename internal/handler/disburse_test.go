@@ -0,0 +1,155 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/require"
+
+	"github.com/berniyo/paypack-lambda/internal/ledger"
+	"github.com/berniyo/paypack-lambda/internal/split"
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+type payoutClient struct {
+	fakeClient
+	payouts []split.Leg
+	failFor string
+}
+
+func (c *payoutClient) CashOut(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+	if number == c.failFor {
+		return nil, errors.New("insufficient merchant balance")
+	}
+	c.payouts = append(c.payouts, split.Leg{Number: number, Amount: amount})
+	return &paypack.Transaction{Ref: fmt.Sprintf("out-%d", len(c.payouts))}, nil
+}
+
+func newPayoutClient() *payoutClient {
+	return &payoutClient{fakeClient: fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: "abc"}, nil
+		},
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: ref, Status: "success", Amount: 1000, Fee: 20, Currency: "RWF"}, nil
+		},
+	}}
+}
+
+func TestProcessorDisbursesSplitLegs(t *testing.T) {
+	client := newPayoutClient()
+	journal := ledger.NewMemoryStore()
+	table := &split.Table{Plans: map[string]split.Plan{"market": {Recipients: []split.Recipient{
+		{Number: "0788000001", Percent: 10},
+		{Number: "0788000002", Fixed: 100},
+	}}}}
+	processor := NewProcessor(
+		client,
+		WithPollInterval(5*time.Millisecond),
+		WithLedger(journal),
+		WithDisbursements(table),
+	)
+
+	resp, err := processor.Handle(context.Background(), SubscriptionEvent{
+		Number:   "2507",
+		Amount:   1000,
+		Metadata: map[string]any{"plan": "market"},
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Disbursements, 2)
+	require.Equal(t, 98.0, resp.Disbursements[0].Amount)
+	require.Equal(t, "out-1", resp.Disbursements[0].Ref)
+	require.Equal(t, "pending", resp.Disbursements[0].Status)
+	require.Equal(t, []split.Leg{{Number: "0788000001", Amount: 98}, {Number: "0788000002", Amount: 100}}, client.payouts)
+
+	entries, err := journal.List(context.Background(), "abc")
+	require.NoError(t, err)
+	require.Len(t, entries, 4)
+	require.Equal(t, ledger.KindDisbursement, entries[2].Kind)
+	require.Equal(t, -98.0, entries[2].Amount)
+	require.InDelta(t, 782.0, ledger.Net(entries), 0.001)
+
+	body := `{"event_id":"evt-2","data":{"ref":"abc","amount":1000,"fee":20,"status":"successful","metadata":{"plan":"market"}}}`
+	_, err = NewWebhookHandler(processor, "").Handle(context.Background(), events.APIGatewayV2HTTPRequest{Body: body})
+	require.NoError(t, err)
+	require.Len(t, client.payouts, 2)
+}
+
+func TestProcessorReportsFailedDisbursementLeg(t *testing.T) {
+	client := newPayoutClient()
+	client.failFor = "0788000002"
+	journal := ledger.NewMemoryStore()
+	processor := NewProcessor(client, WithPollInterval(5*time.Millisecond), WithLedger(journal))
+
+	resp, err := processor.Handle(context.Background(), SubscriptionEvent{
+		Number: "2507",
+		Amount: 1000,
+		Split: &split.Plan{Recipients: []split.Recipient{
+			{Number: "0788000001", Percent: 50},
+			{Number: "0788000002", Percent: 50},
+		}},
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Disbursements, 2)
+	require.Equal(t, "failed", resp.Disbursements[1].Status)
+	require.Equal(t, "insufficient merchant balance", resp.Disbursements[1].Error)
+
+	entries, err := journal.List(context.Background(), "abc")
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+}
+
+func TestDisbursementIsReservedAndKeyedPerLeg(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		keys []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/auth/agents/authorize" {
+			fmt.Fprint(w, `{"access":"tok","expires":3600}`)
+			return
+		}
+		mu.Lock()
+		keys = append(keys, r.Header.Get(paypack.HeaderIdempotencyKey))
+		mu.Unlock()
+		fmt.Fprint(w, `{"ref":"out","status":"pending","amount":500}`)
+	}))
+	defer srv.Close()
+	client, err := paypack.NewClient(srv.URL, "id", "secret", nil)
+	require.NoError(t, err)
+	journal := ledger.NewMemoryStore()
+	processor := NewProcessor(client, WithLedger(journal))
+	plan := &split.Plan{Recipients: []split.Recipient{
+		{Number: "0788000001", Percent: 50},
+		{Number: "0788000002", Percent: 50},
+	}}
+
+	// A webhook racing the poller confirms the same ref at the same time.
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			processor.disburse(context.Background(), &SubscriptionResponse{
+				Found:       true,
+				Status:      "success",
+				Reference:   "abc",
+				Request:     SubscriptionEvent{Split: plan},
+				Transaction: &paypack.Transaction{Ref: "abc", Amount: 1000},
+			})
+		}()
+	}
+	wg.Wait()
+
+	require.ElementsMatch(t, []string{"abc-disburse-0", "abc-disburse-1"}, keys)
+	entries, err := journal.List(context.Background(), "abc")
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+}
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/berniyo/paypack-lambda/internal/subscription"
+)
+
+// SubscriptionCanceller is implemented by subscription lifecycles that can end a
+// subscription; subscription.Service does.
+type SubscriptionCanceller interface {
+	Cancel(ctx context.Context, id, reason string) (*subscription.Subscription, error)
+}
+
+// WithDunning replaces the fixed retry interval with a schedule of offsets from the first
+// failed charge, e.g. 24h, 72h and 168h for days 1, 3 and 7, one re-attempt per offset. Each
+// failed attempt's notification tells the customer when the next one is due, and once the
+// last one fails the subscription is cancelled. It needs WithRetryQueue (whose maxAttempts
+// the schedule replaces) and, for cancellation, WithSubscriptions.
+func WithDunning(schedule []time.Duration) Option {
+	return func(p *Processor) {
+		if len(schedule) > 0 {
+			p.dunning = schedule
+		}
+	}
+}
+
+// ParseDunningSchedule reads a comma-separated list of offsets, each a number of days ("3"
+// or "3d") or a Go duration ("36h"). Offsets must increase.
+func ParseDunningSchedule(raw string) ([]time.Duration, error) {
+	var schedule []time.Duration
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		offset, err := time.ParseDuration(part)
+		if err != nil {
+			days, derr := strconv.Atoi(strings.TrimSuffix(part, "d"))
+			if derr != nil {
+				return nil, fmt.Errorf("invalid dunning offset %q", part)
+			}
+			offset = time.Duration(days) * 24 * time.Hour
+		}
+		if offset <= 0 || (len(schedule) > 0 && offset <= schedule[len(schedule)-1]) {
+			return nil, fmt.Errorf("dunning offsets must be positive and increasing, got %q", part)
+		}
+		schedule = append(schedule, offset)
+	}
+	if len(schedule) == 0 {
+		return nil, errors.New("dunning schedule is empty")
+	}
+	return schedule, nil
+}
+
+// maxRetries is the number of re-attempts a queued failure gets.
+func (p *Processor) maxRetries() int {
+	if len(p.dunning) > 0 {
+		return len(p.dunning)
+	}
+	return p.retryMax
+}
+
+// nextRetryAt is when a queue entry created at createdAt should be re-attempted after
+// attempts re-attempts. A dunning offset already in the past makes it due at once.
+func (p *Processor) nextRetryAt(createdAt time.Time, attempts int, now time.Time) time.Time {
+	if attempts < len(p.dunning) {
+		return later(createdAt.Add(p.dunning[attempts]), now)
+	}
+	return now.Add(p.retryInterval)
+}
+
+// cancelAfterDunning ends a subscription whose final dunning attempt failed.
+func (p *Processor) cancelAfterDunning(ctx context.Context, id, reason string, sub *subscription.Subscription) *subscription.Subscription {
+	canceller, ok := p.lifecycle.(SubscriptionCanceller)
+	if !ok {
+		p.logf(ctx, "subscription %s not cancelled: lifecycle does not support cancellation", id)
+		return sub
+	}
+	cancelled, err := canceller.Cancel(ctx, id, fmt.Sprintf("dunning exhausted after %d attempts: %s", p.maxRetries(), reason))
+	if err != nil {
+		p.logf(ctx, "cancel subscription %s after dunning: %v", id, err)
+		return sub
+	}
+	p.logf(ctx, "subscription %s cancelled after dunning", id)
+	return cancelled
+}
@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/berniyo/paypack-lambda/internal/retry"
+	"github.com/berniyo/paypack-lambda/internal/subscription"
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+func TestDunningFollowsScheduleThenCancels(t *testing.T) {
+	attempts := 0
+	client := &fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			attempts++
+			return &paypack.Transaction{Ref: fmt.Sprintf("ref-%d", attempts)}, nil
+		},
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: ref, Status: "failed"}, nil
+		},
+	}
+	ctx := context.Background()
+	subs := subscription.NewService(subscription.NewMemoryStore())
+	_, err := subs.Create(ctx, subscription.Subscription{ID: "sub-1", Number: "2507", Amount: 1000, Status: subscription.StatusActive})
+	require.NoError(t, err)
+
+	day := 24 * time.Hour
+	store := retry.NewMemoryStore()
+	cb := &fakeCallback{}
+	processor := NewProcessor(
+		client,
+		WithPollInterval(5*time.Millisecond),
+		WithTimeout(200*time.Millisecond),
+		WithCallbackSender(cb),
+		WithSubscriptions(subs),
+		WithRetryQueue(store, 10, time.Hour),
+		WithDunning([]time.Duration{day, 3 * day, 7 * day}),
+	)
+
+	start := time.Now()
+	resp, err := processor.Handle(ctx, SubscriptionEvent{Number: "2507", Amount: 1000, SubscriptionID: "sub-1"})
+	require.NoError(t, err)
+	require.Equal(t, 3, resp.Retry.MaxAttempts)
+	require.WithinDuration(t, start.Add(day), *resp.Retry.NextAttemptAt, time.Minute)
+	require.Contains(t, notificationText(resp), "We will try again on "+start.Add(day).Format("2 Jan 2006")+".")
+
+	for attempt, offset := range []time.Duration{3 * day, 7 * day} {
+		entry, err := store.Get(ctx, "ref-1")
+		require.NoError(t, err)
+		entry.NextAttemptAt = time.Now().Add(-time.Second)
+		require.NoError(t, store.Put(ctx, *entry))
+
+		report, err := processor.RunRetries(ctx)
+		require.NoError(t, err)
+		require.Equal(t, RetryReport{Due: 1, Rescheduled: 1}, report)
+		entry, err = store.Get(ctx, "ref-1")
+		require.NoError(t, err)
+		require.Equal(t, attempt+1, entry.Attempts)
+		require.WithinDuration(t, entry.CreatedAt.Add(offset), entry.NextAttemptAt, time.Second)
+	}
+
+	entry, err := store.Get(ctx, "ref-1")
+	require.NoError(t, err)
+	entry.NextAttemptAt = time.Now().Add(-time.Second)
+	require.NoError(t, store.Put(ctx, *entry))
+	report, err := processor.RunRetries(ctx)
+	require.NoError(t, err)
+	require.Equal(t, RetryReport{Due: 1, Stopped: 1}, report)
+
+	_, err = store.Get(ctx, "ref-1")
+	require.ErrorIs(t, err, retry.ErrNotFound)
+	sub, err := subs.Get(ctx, "sub-1")
+	require.NoError(t, err)
+	require.Equal(t, subscription.StatusCancelled, sub.Status)
+
+	last := cb.calls[len(cb.calls)-1]
+	require.True(t, last.Retry.Exhausted)
+	require.Equal(t, subscription.StatusCancelled, last.Subscription.Status)
+	require.Contains(t, notificationText(last), "Your subscription has been cancelled.")
+}
+
+func TestParseDunningSchedule(t *testing.T) {
+	schedule, err := ParseDunningSchedule("1, 3d, 168h")
+	require.NoError(t, err)
+	require.Equal(t, []time.Duration{24 * time.Hour, 72 * time.Hour, 168 * time.Hour}, schedule)
+
+	_, err = ParseDunningSchedule("3,1")
+	require.Error(t, err)
+	_, err = ParseDunningSchedule("soon")
+	require.Error(t, err)
+	_, err = ParseDunningSchedule(" ")
+	require.Error(t, err)
+}
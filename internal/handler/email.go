@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/smtp"
+	"strings"
+)
+
+// EmailSender mails the outcome to the customer's registered address over SMTP.
+// Outcomes without a customer email (see WithCustomerStore) are skipped.
+type EmailSender struct {
+	addr     string
+	from     string
+	auth     smtp.Auth
+	sendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewEmailSender builds an SMTP sender. auth may be nil for unauthenticated relays.
+func NewEmailSender(addr, from string, auth smtp.Auth) (*EmailSender, error) {
+	if strings.TrimSpace(addr) == "" {
+		return nil, errors.New("smtp address is required")
+	}
+	if strings.TrimSpace(from) == "" {
+		return nil, errors.New("sender address is required")
+	}
+	return &EmailSender{addr: addr, from: from, auth: auth, sendMail: smtp.SendMail}, nil
+}
+
+// Send implements CallbackSender.
+func (e *EmailSender) Send(ctx context.Context, payload SubscriptionResponse) error {
+	if payload.Customer == nil || payload.Customer.Email == "" {
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	to := payload.Customer.Email
+	subject := "Payment update"
+	if payload.Reference != "" {
+		subject += " " + payload.Reference
+	}
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n%s\r\n",
+		e.from, to, subject, notificationText(payload))
+
+	if err := e.sendMail(e.addr, e.auth, e.from, []string{to}, []byte(msg)); err != nil {
+		return fmt.Errorf("send email: %w", err)
+	}
+	return nil
+}
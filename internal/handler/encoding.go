@@ -0,0 +1,187 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+)
+
+// PayloadEncoder serializes the JSON-shaped documents HTTPSCallbackSender posts: the bare
+// payload or its envelope.
+type PayloadEncoder interface {
+	ContentType() string
+	Encode(w io.Writer, doc any) error
+}
+
+// Payload encodings selectable by name with ParsePayloadEncoder.
+const (
+	EncodingJSON        = "json"
+	EncodingMessagePack = "msgpack"
+	EncodingNDJSON      = "ndjson"
+)
+
+// ParsePayloadEncoder returns the encoder registered under name; empty means JSON.
+func ParsePayloadEncoder(name string) (PayloadEncoder, error) {
+	switch name {
+	case "", EncodingJSON:
+		return JSONEncoder{}, nil
+	case EncodingMessagePack:
+		return MessagePackEncoder{}, nil
+	case EncodingNDJSON:
+		return NDJSONEncoder{}, nil
+	}
+	return nil, fmt.Errorf("unknown payload encoding %q", name)
+}
+
+// JSONEncoder writes one JSON document, the default.
+type JSONEncoder struct{}
+
+func (JSONEncoder) ContentType() string { return "application/json" }
+
+func (JSONEncoder) Encode(w io.Writer, doc any) error {
+	return json.NewEncoder(w).Encode(doc)
+}
+
+// NDJSONEncoder writes newline-delimited JSON. A batch digest (see WithBatchDigest) becomes
+// one line per item result, so stream ingesters see one record per payment; any other
+// document is a single line.
+type NDJSONEncoder struct{}
+
+func (NDJSONEncoder) ContentType() string { return "application/x-ndjson" }
+
+func (NDJSONEncoder) Encode(w io.Writer, doc any) error {
+	enc := json.NewEncoder(w)
+	if resp, ok := doc.(SubscriptionResponse); ok && resp.Batch != nil {
+		for _, result := range resp.Batch.Results {
+			if err := enc.Encode(result); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return enc.Encode(doc)
+}
+
+// MessagePackEncoder writes the JSON form of the document as MessagePack: same keys and
+// values, smaller on the wire. Map keys are sorted, so equal documents encode identically.
+type MessagePackEncoder struct{}
+
+func (MessagePackEncoder) ContentType() string { return "application/msgpack" }
+
+func (MessagePackEncoder) Encode(w io.Writer, doc any) error {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var generic any
+	if err := dec.Decode(&generic); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := appendMessagePack(&buf, generic); err != nil {
+		return err
+	}
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+func appendMessagePack(b *bytes.Buffer, v any) error {
+	switch v := v.(type) {
+	case nil:
+		b.WriteByte(0xc0)
+	case bool:
+		if v {
+			b.WriteByte(0xc3)
+		} else {
+			b.WriteByte(0xc2)
+		}
+	case json.Number:
+		if i, err := strconv.ParseInt(string(v), 10, 64); err == nil {
+			appendMessagePackInt(b, i)
+			return nil
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return fmt.Errorf("encode number %s: %w", v, err)
+		}
+		b.WriteByte(0xcb)
+		binary.Write(b, binary.BigEndian, math.Float64bits(f))
+	case string:
+		n := len(v)
+		switch {
+		case n < 32:
+			b.WriteByte(0xa0 | byte(n))
+		case n <= math.MaxUint8:
+			b.Write([]byte{0xd9, byte(n)})
+		case n <= math.MaxUint16:
+			b.WriteByte(0xda)
+			binary.Write(b, binary.BigEndian, uint16(n))
+		default:
+			b.WriteByte(0xdb)
+			binary.Write(b, binary.BigEndian, uint32(n))
+		}
+		b.WriteString(v)
+	case []any:
+		appendMessagePackHeader(b, len(v), 0x90, 0xdc, 0xdd)
+		for _, item := range v {
+			if err := appendMessagePack(b, item); err != nil {
+				return err
+			}
+		}
+	case map[string]any:
+		appendMessagePackHeader(b, len(v), 0x80, 0xde, 0xdf)
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		for _, k := range keys {
+			if err := appendMessagePack(b, k); err != nil {
+				return err
+			}
+			if err := appendMessagePack(b, v[k]); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("cannot encode %T as msgpack", v)
+	}
+	return nil
+}
+
+func appendMessagePackInt(b *bytes.Buffer, i int64) {
+	switch {
+	case i >= 0 && i < 128:
+		b.WriteByte(byte(i))
+	case i < 0 && i >= -32:
+		b.WriteByte(byte(int8(i)))
+	case i >= math.MinInt32 && i <= math.MaxInt32:
+		b.WriteByte(0xd2)
+		binary.Write(b, binary.BigEndian, int32(i))
+	default:
+		b.WriteByte(0xd3)
+		binary.Write(b, binary.BigEndian, i)
+	}
+}
+
+// appendMessagePackHeader writes an array or map length: fix for up to 15 entries, then 16-
+// and 32-bit forms.
+func appendMessagePackHeader(b *bytes.Buffer, n int, fix, len16, len32 byte) {
+	switch {
+	case n < 16:
+		b.WriteByte(fix | byte(n))
+	case n <= math.MaxUint16:
+		b.WriteByte(len16)
+		binary.Write(b, binary.BigEndian, uint16(n))
+	default:
+		b.WriteByte(len32)
+		binary.Write(b, binary.BigEndian, uint32(n))
+	}
+}
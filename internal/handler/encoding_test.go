@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/berniyo/paypack-lambda/pkg/envelope"
+)
+
+func TestMessagePackEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	doc := map[string]any{"ref": "abc", "found": true, "amount": 1000, "fee": 23.5, "items": []any{nil, -1}}
+	require.NoError(t, MessagePackEncoder{}.Encode(&buf, doc))
+	require.Equal(t, []byte{
+		0x85,
+		0xa6, 'a', 'm', 'o', 'u', 'n', 't', 0xd2, 0x00, 0x00, 0x03, 0xe8,
+		0xa3, 'f', 'e', 'e', 0xcb, 0x40, 0x37, 0x80, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0xa5, 'f', 'o', 'u', 'n', 'd', 0xc3,
+		0xa5, 'i', 't', 'e', 'm', 's', 0x92, 0xc0, 0xff,
+		0xa3, 'r', 'e', 'f', 0xa3, 'a', 'b', 'c',
+	}, buf.Bytes())
+}
+
+func TestNDJSONEncoderSplitsBatchDigests(t *testing.T) {
+	var buf bytes.Buffer
+	digest := SubscriptionResponse{Status: "success", Found: true, Batch: &BatchReport{Total: 2, Results: []BatchResult{
+		{Index: 0, Ref: "a", Status: "success"},
+		{Index: 1, Status: "error", Code: CodeValidation, Message: "number is required"},
+	}}}
+	require.NoError(t, NDJSONEncoder{}.Encode(&buf, digest))
+	require.Equal(t, `{"index":0,"ref":"a","status":"success"}
+{"index":1,"status":"error","message":"number is required","code":"VALIDATION_ERROR"}
+`, buf.String())
+
+	buf.Reset()
+	require.NoError(t, NDJSONEncoder{}.Encode(&buf, SubscriptionResponse{Reference: "a", Status: "success"}))
+	require.Equal(t, 1, strings.Count(buf.String(), "\n"))
+}
+
+func TestCallbackBatchDigestAsNDJSON(t *testing.T) {
+	type delivery struct {
+		contentType string
+		body        string
+	}
+	deliveries := make(chan delivery, 4)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		deliveries <- delivery{r.Header.Get("Content-Type"), string(body)}
+	}))
+	defer srv.Close()
+
+	enc, err := ParsePayloadEncoder(EncodingNDJSON)
+	require.NoError(t, err)
+	sender, err := NewHTTPSCallbackSender(srv.URL, "", srv.Client(), WithPayloadEncoder(enc))
+	require.NoError(t, err)
+	p := NewProcessor(&concurrentClient{}, WithPollInterval(time.Millisecond), WithBatchConcurrency(1),
+		WithBatchDigest(), WithCallbackSender(sender))
+
+	_, err = p.Handle(context.Background(), SubscriptionEvent{Action: ActionBatch, Items: []SubscriptionEvent{
+		{Number: "a", Amount: 100},
+		{Number: "b", Amount: 100},
+	}})
+	require.NoError(t, err)
+	close(deliveries)
+
+	var got []delivery
+	for d := range deliveries {
+		got = append(got, d)
+	}
+	require.Len(t, got, 3, "two item callbacks and the digest")
+	for _, d := range got {
+		require.Equal(t, "application/x-ndjson", d.contentType)
+	}
+	require.Equal(t, `{"index":0,"ref":"ref-a","status":"success"}
+{"index":1,"ref":"ref-b","status":"success"}
+`, got[2].body)
+
+	_, err = ParsePayloadEncoder("xml")
+	require.EqualError(t, err, `unknown payload encoding "xml"`)
+	_, err = NewHTTPSCallbackSender(srv.URL, "", nil, WithPayloadEncoder(enc), WithProtobuf())
+	require.Error(t, err)
+	require.Equal(t, envelope.TypeBatchCompleted, callbackEventType(SubscriptionResponse{Batch: &BatchReport{}}))
+}
@@ -0,0 +1,469 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/berniyo/paypack-lambda/internal/approval"
+	"github.com/berniyo/paypack-lambda/internal/customer"
+	"github.com/berniyo/paypack-lambda/internal/eventstore"
+	"github.com/berniyo/paypack-lambda/internal/export"
+	"github.com/berniyo/paypack-lambda/internal/ledger"
+	"github.com/berniyo/paypack-lambda/internal/objectstore"
+	"github.com/berniyo/paypack-lambda/internal/outcome"
+	"github.com/berniyo/paypack-lambda/internal/redact"
+	"github.com/berniyo/paypack-lambda/internal/retry"
+	"github.com/berniyo/paypack-lambda/internal/txcache"
+)
+
+// auditErasure records an erase action in the hash chain. The chain itself is never
+// rewritten: with an erasure key, audit payloads only ever hold tokens (see auditPayload).
+const auditErasure = "data_erased"
+
+// erasureApprover is recorded as the approver of held cash-ins an erase action rejects.
+const erasureApprover = "erasure"
+
+// ErasureReport says what an erase action scrubbed. Token is what now stands in for the
+// number; it equals the number_id callbacks carry when CALLBACK_NUMBER_HMAC_KEY is the same key.
+type ErasureReport struct {
+	Token         string   `json:"token"`
+	Customer      bool     `json:"customer"`
+	Events        int      `json:"events"`
+	LedgerEntries int      `json:"ledger_entries"`
+	Receipts      int      `json:"receipts"`
+	Exports       int      `json:"exports"`
+	Outcomes      int      `json:"outcomes"`
+	Checkpoints   int      `json:"checkpoints"`
+	Retries       int      `json:"retries"`
+	Approvals     int      `json:"approvals"`
+	Refs          []string `json:"refs,omitempty"`
+}
+
+// WithErasureKey enables the erase action, which replaces a customer's number with its HMAC
+// under key. Without a key the tokens could be reversed by hashing every possible number.
+func WithErasureKey(key []byte) Option {
+	return func(p *Processor) {
+		p.erasureKey = key
+	}
+}
+
+// handleErase scrubs event.Number for a data-protection deletion request: the customer
+// profile is deleted, and stored webhooks, callbacks, recorded outcomes, checkpoints and
+// approval requests that mention the number have it replaced by a token and their metadata
+// dropped, as do the ledger descriptions of their refs. Retry entries for the number are
+// deleted and held cash-ins still pending approval are rejected, since neither can charge a
+// token. The cached transactions and receipts of those refs and every export object listing
+// one of them are deleted. The audit chain is append-only and already holds tokens, so it
+// only gains a data_erased record. Amounts, fees and statuses are kept, so totals still
+// reconcile.
+func (p *Processor) handleErase(ctx context.Context, event SubscriptionEvent) (SubscriptionResponse, error) {
+	if len(p.erasureKey) == 0 {
+		return SubscriptionResponse{}, errors.New("erasure key is not configured")
+	}
+	number := customer.NormalizeNumber(event.Number)
+	if number == "" {
+		return SubscriptionResponse{}, withCode(CodeValidation, errors.New("number is required for erase"))
+	}
+	token := redact.HashNumber(p.erasureKey, number)
+	report := ErasureReport{Token: token}
+
+	if p.customers != nil {
+		err := p.customers.Delete(ctx, number)
+		switch {
+		case err == nil:
+			report.Customer = true
+		case !errors.Is(err, customer.ErrNotFound):
+			return SubscriptionResponse{}, fmt.Errorf("delete customer: %w", err)
+		}
+	}
+
+	refs := make(map[string]bool)
+	if p.events != nil {
+		n, err := p.eraseEvents(ctx, number, token, refs)
+		report.Events = n
+		if err != nil {
+			return SubscriptionResponse{}, fmt.Errorf("erase stored events: %w", err)
+		}
+	}
+	if p.outcomes != nil {
+		n, err := p.eraseOutcomes(ctx, number, token, refs)
+		report.Outcomes = n
+		if err != nil {
+			return SubscriptionResponse{}, fmt.Errorf("erase recorded outcomes: %w", err)
+		}
+	}
+	if p.checkpoints != nil {
+		n, err := p.eraseCheckpoints(ctx, number, token, refs)
+		report.Checkpoints = n
+		if err != nil {
+			return SubscriptionResponse{}, fmt.Errorf("erase checkpoints: %w", err)
+		}
+	}
+	if p.retries != nil {
+		n, err := p.eraseRetries(ctx, number, token, refs)
+		report.Retries = n
+		if err != nil {
+			return SubscriptionResponse{}, fmt.Errorf("erase retry entries: %w", err)
+		}
+	}
+	if p.approvals != nil {
+		n, err := p.eraseApprovals(ctx, number, token)
+		report.Approvals = n
+		if err != nil {
+			return SubscriptionResponse{}, fmt.Errorf("erase approval requests: %w", err)
+		}
+	}
+	if redescriber, ok := p.ledger.(ledger.Redescriber); ok {
+		for ref := range refs {
+			n, err := redescriber.Redescribe(ctx, ref, func(e ledger.Entry) (string, bool) {
+				return redact.ReplaceNumber(e.Description, number, token)
+			})
+			report.LedgerEntries += n
+			if err != nil {
+				return SubscriptionResponse{}, fmt.Errorf("erase ledger entries for ref=%s: %w", ref, err)
+			}
+		}
+	}
+	for ref := range refs {
+		report.Refs = append(report.Refs, ref)
+	}
+	sort.Strings(report.Refs)
+	// Cached transactions are replayed by resolvedResult, so they must not outlive the erase
+	// until their TTL does.
+	if p.txCache != nil && len(refs) > 0 {
+		deleter, ok := p.txCache.(txcache.Deleter)
+		if !ok {
+			return SubscriptionResponse{}, errors.New("transaction cache does not support deleting")
+		}
+		for _, ref := range report.Refs {
+			if err := deleter.Delete(ctx, ref); err != nil {
+				return SubscriptionResponse{}, fmt.Errorf("erase cached transaction for ref=%s: %w", ref, err)
+			}
+		}
+	}
+	if p.receipts != nil && len(refs) > 0 {
+		deleter, ok := p.receipts.(ReceiptDeleter)
+		if !ok {
+			return SubscriptionResponse{}, errors.New("receipt issuer does not support deleting")
+		}
+		for _, ref := range report.Refs {
+			if err := deleter.Delete(ctx, ref); err != nil {
+				return SubscriptionResponse{}, fmt.Errorf("erase receipts for ref=%s: %w", ref, err)
+			}
+			report.Receipts++
+		}
+	}
+	if p.exports != nil && len(refs) > 0 {
+		n, err := p.eraseExports(ctx, refs)
+		report.Exports = n
+		if err != nil {
+			return SubscriptionResponse{}, fmt.Errorf("erase exports: %w", err)
+		}
+	}
+	p.recordAudit(ctx, auditErasure, "", report)
+	p.logf(ctx, "erased number token=%s customer=%t events=%d ledger=%d receipts=%d exports=%d outcomes=%d checkpoints=%d retries=%d approvals=%d",
+		token, report.Customer, report.Events, report.LedgerEntries, report.Receipts, report.Exports,
+		report.Outcomes, report.Checkpoints, report.Retries, report.Approvals)
+
+	found := report.Customer || report.Events > 0 || report.LedgerEntries > 0 ||
+		report.Outcomes > 0 || report.Checkpoints > 0 || report.Retries > 0 || report.Approvals > 0
+
+	// The response must not echo the number it just erased.
+	return SubscriptionResponse{
+		Status:  "success",
+		Found:   found,
+		Request: SubscriptionEvent{Action: ActionErase, Number: token},
+		Erasure: &report,
+	}, nil
+}
+
+// eraseEvents rewrites every stored webhook and callback that mentions number, adding their
+// refs to refs. Records that already carry token add their refs too, so an erase that failed
+// part-way still finds the receipts and exports left to delete when it is repeated. The event
+// store must be able to scan and rewrite.
+func (p *Processor) eraseEvents(ctx context.Context, number, token string, refs map[string]bool) (int, error) {
+	scanner, ok := p.events.(eventstore.Scanner)
+	if !ok {
+		return 0, errors.New("event store does not support scanning")
+	}
+	rewriter, ok := p.events.(eventstore.Rewriter)
+	if !ok {
+		return 0, errors.New("event store does not support rewriting")
+	}
+
+	changed := 0
+	for _, kind := range []eventstore.Kind{eventstore.KindWebhook, eventstore.KindCallback} {
+		err := scanner.Scan(ctx, kind, time.Time{}, time.Time{}, func(rec eventstore.Record) error {
+			payload, ok := eraseNumber(rec.Payload, number, token)
+			if !ok {
+				if bytes.Contains(rec.Payload, []byte(token)) {
+					refs[rec.Ref] = true
+				}
+				return nil
+			}
+			rec.Payload = payload
+			if err := rewriter.Rewrite(ctx, rec); err != nil {
+				return fmt.Errorf("rewrite %s record for ref=%s: %w", rec.Kind, rec.Ref, err)
+			}
+			changed++
+			refs[rec.Ref] = true
+			return nil
+		})
+		if err != nil {
+			return changed, err
+		}
+	}
+	return changed, nil
+}
+
+// eraseOutcomes rewrites every recorded outcome that mentions number, adding their refs to
+// refs the same way eraseEvents does. The outcome store must be able to scan and rewrite.
+func (p *Processor) eraseOutcomes(ctx context.Context, number, token string, refs map[string]bool) (int, error) {
+	scanner, ok := p.outcomes.(outcome.Scanner)
+	if !ok {
+		return 0, errors.New("outcome store does not support scanning")
+	}
+	rewriter, ok := p.outcomes.(outcome.Rewriter)
+	if !ok {
+		return 0, errors.New("outcome store does not support rewriting")
+	}
+
+	changed := 0
+	err := scanner.Scan(ctx, func(key string, result json.RawMessage) error {
+		erased, ok := eraseNumber(result, number, token)
+		if !ok {
+			if bytes.Contains(result, []byte(token)) {
+				addResultRef(refs, result)
+			}
+			return nil
+		}
+		err := rewriter.Rewrite(ctx, key, erased)
+		if errors.Is(err, outcome.ErrNotFound) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("rewrite outcome for key=%s: %w", key, err)
+		}
+		changed++
+		addResultRef(refs, erased)
+		return nil
+	})
+	return changed, err
+}
+
+// eraseCheckpoints rewrites the state of every checkpoint that mentions number, adding their
+// refs to refs; a confirmation resumed afterwards reports the token.
+func (p *Processor) eraseCheckpoints(ctx context.Context, number, token string, refs map[string]bool) (int, error) {
+	cps, err := p.checkpoints.Pending(ctx, time.Now(), 0)
+	if err != nil {
+		return 0, err
+	}
+	changed := 0
+	for _, cp := range cps {
+		state, ok := eraseNumber(cp.State, number, token)
+		if !ok {
+			if bytes.Contains(cp.State, []byte(token)) {
+				refs[cp.Ref] = true
+			}
+			continue
+		}
+		cp.State = state
+		if err := p.checkpoints.Put(ctx, cp); err != nil {
+			return changed, fmt.Errorf("rewrite checkpoint for ref=%s: %w", cp.Ref, err)
+		}
+		changed++
+		refs[cp.Ref] = true
+	}
+	return changed, nil
+}
+
+// eraseRetries deletes every retry entry that mentions number, adding the refs of its failed
+// attempts to refs. The retry store must be able to scan.
+func (p *Processor) eraseRetries(ctx context.Context, number, token string, refs map[string]bool) (int, error) {
+	scanner, ok := p.retries.(retry.Scanner)
+	if !ok {
+		return 0, errors.New("retry store does not support scanning")
+	}
+
+	deleted := 0
+	err := scanner.Scan(ctx, func(entry retry.Entry) error {
+		if _, ok := eraseNumber(entry.Event, number, token); !ok {
+			return nil
+		}
+		if err := p.retries.Delete(ctx, entry.ID); err != nil {
+			return fmt.Errorf("delete retry entry %s: %w", entry.ID, err)
+		}
+		deleted++
+		refs[entry.ID] = true
+		if entry.LastRef != "" {
+			refs[entry.LastRef] = true
+		}
+		return nil
+	})
+	return deleted, err
+}
+
+// eraseApprovals rewrites the held event of every approval request that mentions number,
+// first rejecting those still pending. The approval store must be able to scan and rewrite.
+func (p *Processor) eraseApprovals(ctx context.Context, number, token string) (int, error) {
+	scanner, ok := p.approvals.(approval.Scanner)
+	if !ok {
+		return 0, errors.New("approval store does not support scanning")
+	}
+	rewriter, ok := p.approvals.(approval.Rewriter)
+	if !ok {
+		return 0, errors.New("approval store does not support rewriting")
+	}
+
+	changed := 0
+	err := scanner.Scan(ctx, func(req approval.Request) error {
+		payload, ok := eraseNumber(req.Payload, number, token)
+		if !ok {
+			return nil
+		}
+		if req.Status == approval.StatusPending {
+			_, err := p.approvals.Resolve(ctx, req.ID, approval.StatusRejected, erasureApprover, time.Now())
+			if err != nil && !errors.Is(err, approval.ErrNotPending) {
+				return fmt.Errorf("reject approval request %s: %w", req.ID, err)
+			}
+		}
+		req.Payload = payload
+		if err := rewriter.Rewrite(ctx, req); err != nil {
+			return fmt.Errorf("rewrite approval request %s: %w", req.ID, err)
+		}
+		changed++
+		return nil
+	})
+	return changed, err
+}
+
+// addResultRef adds the ref of the SubscriptionResponse encoded in result to refs.
+func addResultRef(refs map[string]bool, result json.RawMessage) {
+	var resp struct {
+		Reference string `json:"ref"`
+	}
+	if json.Unmarshal(result, &resp) == nil && resp.Reference != "" {
+		refs[resp.Reference] = true
+	}
+}
+
+// eraseExports deletes every export object that lists one of refs. Exports are snapshots of
+// the event store, so they are deleted rather than edited; a new export reads the scrubbed
+// records. The export store must be able to list, read and delete.
+func (p *Processor) eraseExports(ctx context.Context, refs map[string]bool) (int, error) {
+	lister, ok := p.exports.(objectstore.Lister)
+	if !ok {
+		return 0, errors.New("export store does not support listing")
+	}
+	reader, ok := p.exports.(objectstore.Reader)
+	if !ok {
+		return 0, errors.New("export store does not support reading")
+	}
+	deleter, ok := p.exports.(objectstore.Deleter)
+	if !ok {
+		return 0, errors.New("export store does not support deleting")
+	}
+
+	deleted := 0
+	err := lister.List(ctx, exportKeyPrefix, func(key string) error {
+		format, err := export.ParseFormat(strings.TrimPrefix(path.Ext(key), "."))
+		if err != nil {
+			return fmt.Errorf("export %s: %w", key, err)
+		}
+		body, err := reader.Read(ctx, key)
+		if errors.Is(err, objectstore.ErrNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		listed, err := export.Refs(format, body)
+		if err != nil {
+			return fmt.Errorf("export %s: %w", key, err)
+		}
+		if !slices.ContainsFunc(listed, func(ref string) bool { return refs[ref] }) {
+			return nil
+		}
+		if err := deleter.Delete(ctx, key); err != nil {
+			return err
+		}
+		deleted++
+		return nil
+	})
+	return deleted, err
+}
+
+// eraseNumber replaces number with token throughout the JSON document doc; a document that
+// mentions the number also loses its metadata. It reports whether doc changed; documents that
+// are not JSON are left alone.
+func eraseNumber(doc json.RawMessage, number, token string) (json.RawMessage, bool) {
+	if len(doc) == 0 {
+		return doc, false
+	}
+	var v any
+	if err := json.Unmarshal(doc, &v); err != nil {
+		return doc, false
+	}
+	found := false
+	v = eraseValue(v, number, token, &found)
+	if !found {
+		return doc, false
+	}
+	dropMetadata(v)
+	out, err := json.Marshal(v)
+	if err != nil {
+		return doc, false
+	}
+	return out, true
+}
+
+func eraseValue(v any, number, token string, found *bool) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, inner := range v {
+			v[k] = eraseValue(inner, number, token, found)
+		}
+		return v
+	case []any:
+		for i, inner := range v {
+			v[i] = eraseValue(inner, number, token, found)
+		}
+		return v
+	case string:
+		out, ok := redact.ReplaceNumber(v, number, token)
+		if ok {
+			*found = true
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// dropMetadata removes every metadata object in v and reports whether there was any.
+func dropMetadata(v any) bool {
+	dropped := false
+	switch v := v.(type) {
+	case map[string]any:
+		if _, ok := v["metadata"]; ok {
+			delete(v, "metadata")
+			dropped = true
+		}
+		for _, inner := range v {
+			dropped = dropMetadata(inner) || dropped
+		}
+	case []any:
+		for _, inner := range v {
+			dropped = dropMetadata(inner) || dropped
+		}
+	}
+	return dropped
+}
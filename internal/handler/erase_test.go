@@ -0,0 +1,216 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/berniyo/paypack-lambda/internal/approval"
+	"github.com/berniyo/paypack-lambda/internal/audit"
+	"github.com/berniyo/paypack-lambda/internal/checkpoint"
+	"github.com/berniyo/paypack-lambda/internal/customer"
+	"github.com/berniyo/paypack-lambda/internal/eventstore"
+	"github.com/berniyo/paypack-lambda/internal/ledger"
+	"github.com/berniyo/paypack-lambda/internal/objectstore"
+	"github.com/berniyo/paypack-lambda/internal/outcome"
+	"github.com/berniyo/paypack-lambda/internal/receipt"
+	"github.com/berniyo/paypack-lambda/internal/redact"
+	"github.com/berniyo/paypack-lambda/internal/retry"
+	"github.com/berniyo/paypack-lambda/internal/txcache"
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+func TestEraseScrubsNumberAndKeepsTotals(t *testing.T) {
+	refs := map[string]string{"250788123123": "abc", "250788000001": "def"}
+	client := &fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: refs[number]}, nil
+		},
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: ref, Status: "success", Amount: 1000, Fee: 23}, nil
+		},
+	}
+	events := eventstore.NewMemoryStore()
+	journal := ledger.NewMemoryStore()
+	customers := customer.NewMemoryStore()
+	chainStore := audit.NewMemoryStore()
+	chain, err := audit.NewLog(chainStore)
+	require.NoError(t, err)
+	key := []byte("secret")
+	processor := NewProcessor(client, WithPollInterval(5*time.Millisecond), WithCallbackSender(&fakeCallback{}),
+		WithEventStore(events), WithLedger(journal), WithCustomerStore(customers), WithAuditLog(chain), WithErasureKey(key))
+	ctx := context.Background()
+
+	require.NoError(t, customers.Put(ctx, &customer.Profile{Number: "250788123123", ClientID: "c-1"}))
+	for number := range refs {
+		_, err := processor.Handle(ctx, SubscriptionEvent{Number: number, Amount: 1000, Metadata: map[string]any{"national_id": "1199"}})
+		require.NoError(t, err)
+	}
+	before, err := journal.List(ctx, "abc")
+	require.NoError(t, err)
+
+	resp, err := processor.Handle(ctx, SubscriptionEvent{Action: ActionErase, Number: "+250 788 123123"})
+	require.NoError(t, err)
+	token := redact.HashNumber(key, "250788123123")
+	require.True(t, resp.Found)
+	require.Equal(t, token, resp.Request.Number)
+	require.Equal(t, ErasureReport{Token: token, Customer: true, Events: 1, LedgerEntries: 1, Refs: []string{"abc"}}, *resp.Erasure)
+
+	_, err = customers.Get(ctx, "250788123123")
+	require.ErrorIs(t, err, customer.ErrNotFound)
+
+	rec, err := events.Latest(ctx, "abc", eventstore.KindCallback)
+	require.NoError(t, err)
+	var erased SubscriptionResponse
+	require.NoError(t, json.Unmarshal(rec.Payload, &erased))
+	require.Equal(t, token, erased.Request.Number)
+	require.Nil(t, erased.Request.Metadata)
+	require.Equal(t, 1000.0, erased.Transaction.Amount)
+	require.NotContains(t, string(rec.Payload), "250788123123")
+
+	rec, err = events.Latest(ctx, "def", eventstore.KindCallback)
+	require.NoError(t, err)
+	require.Contains(t, string(rec.Payload), "250788000001", "other customers are untouched")
+
+	after, err := journal.List(ctx, "abc")
+	require.NoError(t, err)
+	require.Equal(t, ledger.Net(before), ledger.Net(after))
+	require.Equal(t, "cashin from "+token, after[0].Description)
+
+	v, err := chain.Verify(ctx)
+	require.NoError(t, err)
+	require.True(t, v.Valid)
+	recs, err := chainStore.List(ctx, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, recs, 3)
+	for _, rec := range recs[:2] {
+		require.NotContains(t, string(rec.Payload), "national_id", "audit payloads are written without metadata")
+		require.NotContains(t, string(rec.Payload), "250788", "audit payloads are written with tokens")
+	}
+	require.Contains(t, string(recs[0].Payload)+string(recs[1].Payload), token)
+	require.Equal(t, auditErasure, recs[2].Action)
+}
+
+func TestEraseRequiresKeyAndNumber(t *testing.T) {
+	_, err := NewProcessor(&fakeClient{}).Handle(context.Background(), SubscriptionEvent{Action: ActionErase, Number: "250788123123"})
+	require.EqualError(t, err, "erasure key is not configured")
+
+	_, err = NewProcessor(&fakeClient{}, WithErasureKey([]byte("k"))).Handle(context.Background(), SubscriptionEvent{Action: ActionErase})
+	require.Equal(t, CodeValidation, CodeOf(err))
+}
+
+func TestEraseDeletesReceiptsAndExports(t *testing.T) {
+	refs := map[string]string{"250788123123": "abc", "250788000001": "def"}
+	client := &fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: refs[number]}, nil
+		},
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: ref, Status: "success", Amount: 1000}, nil
+		},
+	}
+	objects := objectstore.NewMemoryStore()
+	issuer, err := receipt.NewIssuer(objects)
+	require.NoError(t, err)
+	processor := NewProcessor(client, WithPollInterval(5*time.Millisecond), WithCallbackSender(&fakeCallback{}),
+		WithEventStore(eventstore.NewMemoryStore()), WithReceipts(issuer), WithExports(objects, 0), WithErasureKey([]byte("secret")))
+	ctx := context.Background()
+
+	for number := range refs {
+		_, err := processor.Handle(ctx, SubscriptionEvent{Number: number, Amount: 1000})
+		require.NoError(t, err)
+	}
+	exported, err := processor.Handle(ctx, SubscriptionEvent{Action: ActionExport})
+	require.NoError(t, err)
+	require.Equal(t, 2, exported.Export.Rows)
+
+	resp, err := processor.Handle(ctx, SubscriptionEvent{Action: ActionErase, Number: "250788123123"})
+	require.NoError(t, err)
+	require.Equal(t, 1, resp.Erasure.Receipts)
+	require.Equal(t, 1, resp.Erasure.Exports)
+
+	_, err = objects.Get("receipts/abc.html")
+	require.ErrorIs(t, err, objectstore.ErrNotFound)
+	_, err = objects.Get("receipts/def.html")
+	require.NoError(t, err, "other customers' receipts are kept")
+	_, err = objects.Get(exported.Export.Key)
+	require.ErrorIs(t, err, objectstore.ErrNotFound)
+
+	// Repeating the erase still finds the refs by their token.
+	resp, err = processor.Handle(ctx, SubscriptionEvent{Action: ActionErase, Number: "250788123123"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"abc"}, resp.Erasure.Refs)
+	require.Equal(t, 1, resp.Erasure.Receipts)
+}
+
+func TestEraseScrubsOutcomesCheckpointsRetriesAndApprovals(t *testing.T) {
+	client := &fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: "abc"}, nil
+		},
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: ref, Status: "success", Amount: 1000, Number: "250788123123"}, nil
+		},
+	}
+	outcomes := outcome.NewMemoryStore()
+	cache := txcache.NewMemoryStore(0)
+	checkpoints := checkpoint.NewMemoryStore()
+	retries := retry.NewMemoryStore()
+	approvals := approval.NewMemoryStore()
+	key := []byte("secret")
+	processor := NewProcessor(client, WithPollInterval(5*time.Millisecond), WithCallbackSender(&fakeCallback{}),
+		WithOutcomeStore(outcomes), WithTransactionCache(cache, time.Hour), WithCheckpoints(checkpoints),
+		WithRetryQueue(retries, 3, time.Minute), WithApprovalGate(approvals, 5000, time.Hour), WithErasureKey(key))
+	ctx := context.Background()
+
+	_, err := processor.Handle(ctx, SubscriptionEvent{Number: "250788123123", Amount: 1000, CorrelationID: "order-1"})
+	require.NoError(t, err)
+	_, err = cache.Get(ctx, "abc")
+	require.NoError(t, err)
+
+	held := func(number string) json.RawMessage {
+		payload, err := json.Marshal(SubscriptionEvent{Number: number, Amount: 9000, Metadata: map[string]any{"national_id": "1199"}})
+		require.NoError(t, err)
+		return payload
+	}
+	state, err := json.Marshal(pendingCashIn{Event: SubscriptionEvent{Number: "250788123123", Amount: 1000}})
+	require.NoError(t, err)
+	require.NoError(t, checkpoints.Put(ctx, checkpoint.Checkpoint{Ref: "ghi", State: state, UpdatedAt: time.Now().Add(-time.Minute)}))
+	require.NoError(t, retries.Put(ctx, retry.Entry{ID: "jkl", Event: held("250788123123"), NextAttemptAt: time.Now().Add(time.Hour)}))
+	require.NoError(t, retries.Put(ctx, retry.Entry{ID: "mno", Event: held("250788000001"), NextAttemptAt: time.Now().Add(time.Hour)}))
+	require.NoError(t, approvals.Create(ctx, approval.Request{ID: "held-1", Payload: held("250788123123"), Status: approval.StatusPending}))
+
+	resp, err := processor.Handle(ctx, SubscriptionEvent{Action: ActionErase, Number: "250788123123"})
+	require.NoError(t, err)
+	token := redact.HashNumber(key, "250788123123")
+	require.True(t, resp.Found)
+	require.Equal(t, ErasureReport{Token: token, Outcomes: 1, Checkpoints: 1, Retries: 1, Approvals: 1, Refs: []string{"abc", "ghi", "jkl"}}, *resp.Erasure)
+
+	_, err = cache.Get(ctx, "abc")
+	require.ErrorIs(t, err, txcache.ErrNotFound, "a cached transaction would be replayed with the number")
+
+	recorded, won, err := outcomes.Record(ctx, "order-1", json.RawMessage(`{}`))
+	require.NoError(t, err)
+	require.False(t, won)
+	require.NotContains(t, string(recorded), "250788123123")
+	require.Contains(t, string(recorded), token)
+
+	cp, err := checkpoints.Get(ctx, "ghi")
+	require.NoError(t, err)
+	require.NotContains(t, string(cp.State), "250788123123")
+
+	_, err = retries.Get(ctx, "jkl")
+	require.ErrorIs(t, err, retry.ErrNotFound, "an erased number is not charged again")
+	_, err = retries.Get(ctx, "mno")
+	require.NoError(t, err, "other customers' retries are kept")
+
+	req, err := approvals.Get(ctx, "held-1")
+	require.NoError(t, err)
+	require.Equal(t, approval.StatusRejected, req.Status)
+	require.Equal(t, erasureApprover, req.Approver)
+	require.NotContains(t, string(req.Payload), "250788123123")
+	require.NotContains(t, string(req.Payload), "national_id")
+}
@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+// ErrorCode is a stable, machine-readable failure category. Callers should branch on codes
+// rather than on message text, which may change.
+type ErrorCode string
+
+const (
+	// CodeValidation marks events rejected before any money moved.
+	CodeValidation ErrorCode = "VALIDATION_ERROR"
+	// CodePaypackUnavailable marks transport failures and 5xx or 429 responses from Paypack.
+	CodePaypackUnavailable ErrorCode = "PAYPACK_UNAVAILABLE"
+	// CodeInsufficientFunds marks Paypack refusing a transaction for lack of funds.
+	CodeInsufficientFunds ErrorCode = "INSUFFICIENT_FUNDS"
+	// CodeConfirmationTimeout marks cash-ins Paypack never confirmed within the timeout.
+	CodeConfirmationTimeout ErrorCode = "CONFIRMATION_TIMEOUT"
+	// CodeCallbackFailed marks outcomes that could not be delivered to the callback.
+	CodeCallbackFailed ErrorCode = "CALLBACK_FAILED"
+	// CodeUnauthorized marks events rejected for a missing, invalid or expired signature.
+	CodeUnauthorized ErrorCode = "UNAUTHORIZED"
+	// CodeTransactionFailed marks transactions Paypack resolved as failed, e.g. declined by
+	// the payer. It appears on failures only; such responses carry no code.
+	CodeTransactionFailed ErrorCode = "TRANSACTION_FAILED"
+	// CodeUnexpectedResponse marks Paypack answers that could not be read after the request
+	// reached it, so the transaction may have gone through.
+	CodeUnexpectedResponse ErrorCode = "UNEXPECTED_RESPONSE"
+)
+
+// Failure sources.
+const (
+	FailureValidation = "validation"
+	FailurePaypack    = "paypack"
+	FailureTimeout    = "timeout"
+	FailureCallback   = "callback"
+)
+
+// Failure classifies why a response failed, so upstream orchestration can retry only where
+// it is safe: Retryable failures cannot charge twice when the event is resent with the same
+// correlation_id.
+type Failure struct {
+	Code      ErrorCode `json:"code"`
+	Retryable bool      `json:"retryable"`
+	Source    string    `json:"source"`
+}
+
+// FailureOf classifies code, or returns nil for an empty code. Confirmation timeouts and
+// callback failures are not retryable: the charge may still complete, or already has.
+func FailureOf(code ErrorCode) *Failure {
+	switch code {
+	case "":
+		return nil
+	case CodeValidation, CodeUnauthorized:
+		return &Failure{Code: code, Source: FailureValidation}
+	case CodePaypackUnavailable, CodeInsufficientFunds, CodeTransactionFailed:
+		return &Failure{Code: code, Retryable: true, Source: FailurePaypack}
+	case CodeConfirmationTimeout:
+		return &Failure{Code: code, Source: FailureTimeout}
+	case CodeCallbackFailed:
+		return &Failure{Code: code, Source: FailureCallback}
+	default:
+		return &Failure{Code: code, Source: FailurePaypack}
+	}
+}
+
+// classifyFailure sets resp.Failure from its code, or marks a transaction Paypack resolved
+// as failed.
+func classifyFailure(resp *SubscriptionResponse) {
+	switch {
+	case resp.Code != "":
+		resp.Failure = FailureOf(resp.Code)
+	case resp.Status == "failed":
+		resp.Failure = FailureOf(CodeTransactionFailed)
+	}
+}
+
+// Error pairs an ErrorCode with the underlying error. Its message is the underlying message.
+type Error struct {
+	Code ErrorCode
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// withCode tags err with code, keeping any code already present. Nil stays nil.
+func withCode(code ErrorCode, err error) error {
+	if err == nil || CodeOf(err) != "" {
+		return err
+	}
+	return &Error{Code: code, Err: err}
+}
+
+// storeError marks a failure of one of the processor's own stores before anything was
+// charged, so the event can be resent as it is.
+type storeError struct{ err error }
+
+func (e storeError) Error() string { return e.err.Error() }
+
+func (e storeError) Unwrap() error { return e.err }
+
+// isStoreError reports whether err is, or wraps, a storeError.
+func isStoreError(err error) bool {
+	var s storeError
+	return errors.As(err, &s)
+}
+
+// CodeOf returns the code carried by err, or "" when it has none.
+func CodeOf(err error) ErrorCode {
+	var coded *Error
+	if errors.As(err, &coded) {
+		return coded.Code
+	}
+	return ""
+}
+
+// paypackCode classifies an error returned by the Paypack client.
+func paypackCode(err error) ErrorCode {
+	var apiErr *paypack.APIError
+	if !errors.As(err, &apiErr) {
+		switch {
+		case errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled):
+			return CodeConfirmationTimeout
+		case errors.Is(err, paypack.ErrUnexpectedResponse):
+			return CodeUnexpectedResponse
+		}
+		return CodePaypackUnavailable
+	}
+	switch {
+	case apiErr.StatusCode == http.StatusPaymentRequired || strings.Contains(strings.ToLower(apiErr.Body), "insufficient"):
+		return CodeInsufficientFunds
+	case apiErr.StatusCode >= 500 || apiErr.StatusCode == http.StatusTooManyRequests:
+		return CodePaypackUnavailable
+	default:
+		return CodeValidation
+	}
+}
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+func TestValidationErrorsCarryCode(t *testing.T) {
+	p := NewProcessor(&fakeClient{})
+
+	_, err := p.Handle(context.Background(), SubscriptionEvent{Amount: 100})
+	require.EqualError(t, err, "number is required")
+	require.Equal(t, CodeValidation, CodeOf(err))
+
+	_, err = p.Handle(context.Background(), SubscriptionEvent{Number: "2507", Amount: 100, Currency: "KES"})
+	require.Equal(t, CodeValidation, CodeOf(err))
+}
+
+func TestCashInErrorsAreClassified(t *testing.T) {
+	cases := []struct {
+		err  error
+		code ErrorCode
+	}{
+		{&paypack.APIError{StatusCode: http.StatusServiceUnavailable}, CodePaypackUnavailable},
+		{&paypack.APIError{StatusCode: http.StatusBadRequest, Body: `{"message":"insufficient balance"}`}, CodeInsufficientFunds},
+		{&paypack.APIError{StatusCode: http.StatusBadRequest, Body: `{"message":"invalid number"}`}, CodeValidation},
+		{errors.New("dial tcp: connection refused"), CodePaypackUnavailable},
+		{fmt.Errorf("decode cashin response: %w", paypack.ErrUnexpectedResponse), CodeUnexpectedResponse},
+	}
+	for _, tc := range cases {
+		client := &fakeClient{
+			cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+				return nil, tc.err
+			},
+		}
+		_, err := NewProcessor(client).Handle(context.Background(), SubscriptionEvent{Number: "2507", Amount: 100})
+		require.Equal(t, tc.code, CodeOf(err), tc.err.Error())
+	}
+}
+
+func TestOutcomeCodes(t *testing.T) {
+	client := &fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: "abc"}, nil
+		},
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			return nil, paypack.ErrTransactionNotFound
+		},
+	}
+	callback := &fakeCallback{}
+	p := NewProcessor(client, WithPollInterval(time.Millisecond), WithTimeout(5*time.Millisecond), WithCallbackSender(callback))
+
+	resp, err := p.Handle(context.Background(), SubscriptionEvent{Number: "2507", Amount: 100})
+	require.NoError(t, err)
+	require.Equal(t, CodeConfirmationTimeout, resp.Code)
+	require.Equal(t, CodeConfirmationTimeout, callback.calls[0].Code)
+	require.Equal(t, &Failure{Code: CodeConfirmationTimeout, Source: FailureTimeout}, resp.Failure)
+	require.Equal(t, resp.Failure, callback.calls[0].Failure)
+
+	client.findTransactionFn = func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+		return &paypack.Transaction{Ref: ref, Status: "success"}, nil
+	}
+	callback.err = errors.New("endpoint down")
+	resp, err = p.Handle(context.Background(), SubscriptionEvent{Number: "2507", Amount: 100})
+	require.NoError(t, err)
+	require.Equal(t, "success", resp.Status)
+	require.Equal(t, CodeCallbackFailed, resp.Code)
+	require.Equal(t, &Failure{Code: CodeCallbackFailed, Source: FailureCallback}, resp.Failure)
+
+	client.findTransactionFn = func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+		return &paypack.Transaction{Ref: ref, Status: "failed"}, nil
+	}
+	callback.err = nil
+	resp, err = p.Handle(context.Background(), SubscriptionEvent{Number: "2507", Amount: 100})
+	require.NoError(t, err)
+	require.Empty(t, resp.Code)
+	require.Equal(t, &Failure{Code: CodeTransactionFailed, Retryable: true, Source: FailurePaypack}, resp.Failure)
+}
+
+func TestFailuresAreRetryableOnlyWhenSafe(t *testing.T) {
+	for code, retryable := range map[ErrorCode]bool{
+		CodeValidation:          false,
+		CodeUnauthorized:        false,
+		CodePaypackUnavailable:  true,
+		CodeInsufficientFunds:   true,
+		CodeConfirmationTimeout: false,
+		CodeCallbackFailed:      false,
+		CodeUnexpectedResponse:  false,
+	} {
+		require.Equal(t, retryable, FailureOf(code).Retryable, code)
+	}
+	require.Nil(t, FailureOf(""))
+}
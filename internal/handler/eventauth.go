@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// EventVerifier checks an event signature over its canonical payload.
+type EventVerifier interface {
+	Verify(payload, signature []byte) bool
+}
+
+type hmacVerifier []byte
+
+func (v hmacVerifier) Verify(payload, signature []byte) bool {
+	mac := hmac.New(sha256.New, v)
+	mac.Write(payload)
+	return hmac.Equal(signature, mac.Sum(nil))
+}
+
+// HMACEventVerifier accepts events signed with SignEvent under secret.
+func HMACEventVerifier(secret string) EventVerifier {
+	return hmacVerifier(secret)
+}
+
+type publicKeyVerifier struct{ key crypto.PublicKey }
+
+func (v publicKeyVerifier) Verify(payload, signature []byte) bool {
+	digest := sha256.Sum256(payload)
+	switch key := v.key.(type) {
+	case ed25519.PublicKey:
+		return ed25519.Verify(key, payload, signature)
+	case *ecdsa.PublicKey:
+		return ecdsa.VerifyASN1(key, digest[:], signature)
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) == nil
+	}
+	return false
+}
+
+// PublicKeyEventVerifier accepts events signed with the private half of a PEM-encoded
+// (PKIX) public key: Ed25519 over the payload, or ECDSA (ASN.1) or RSA PKCS #1 v1.5 over its
+// SHA-256. Only the signer holds the private key, so the function can verify events it
+// could not have fabricated.
+func PublicKeyEventVerifier(publicKeyPEM []byte) (EventVerifier, error) {
+	block, _ := pem.Decode(publicKeyPEM)
+	if block == nil {
+		return nil, errors.New("event signing key is not PEM")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse event signing key: %w", err)
+	}
+	switch key.(type) {
+	case ed25519.PublicKey, *ecdsa.PublicKey, *rsa.PublicKey:
+		return publicKeyVerifier{key: key}, nil
+	default:
+		return nil, fmt.Errorf("unsupported event signing key type %T", key)
+	}
+}
+
+// WithEventSignatures requires events passed to Handle to carry a signature accepted by one
+// of verifiers, so a principal allowed to invoke the function still cannot fabricate billing
+// events without the signing key. A positive maxAge also rejects events signed longer ago,
+// limiting replays. Events received by the Function URL handler are authenticated there
+// instead.
+func WithEventSignatures(maxAge time.Duration, verifiers ...EventVerifier) Option {
+	return func(p *Processor) {
+		if len(verifiers) > 0 {
+			p.eventVerifiers = verifiers
+			p.eventMaxAge = maxAge
+		}
+	}
+}
+
+// CanonicalEventPayload is what an event signature covers: the event's JSON, without the
+// signature itself, with object keys sorted, empty fields omitted and no whitespace.
+func CanonicalEventPayload(event SubscriptionEvent) ([]byte, error) {
+	event.Signature = ""
+	return canonicalJSON(event)
+}
+
+// canonicalJSON encodes v with object keys sorted and no whitespace.
+func canonicalJSON(v any) ([]byte, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var tree any
+	if err := json.Unmarshal(raw, &tree); err != nil {
+		return nil, err
+	}
+	// encoding/json writes map keys in sorted order.
+	return json.Marshal(tree)
+}
+
+// SignEvent returns the base64 HMAC-SHA256 signature under secret of event, which should
+// already carry its signed_at.
+func SignEvent(event SubscriptionEvent, secret string) (string, error) {
+	payload, err := CanonicalEventPayload(event)
+	if err != nil {
+		return "", fmt.Errorf("encode event for signing: %w", err)
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+type authenticatedKey struct{}
+
+// authenticatedContext marks events whose transport was authenticated already.
+func authenticatedContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, authenticatedKey{}, true)
+}
+
+// verifyEvent applies WithEventSignatures to an event given to Handle.
+func (p *Processor) verifyEvent(ctx context.Context, event SubscriptionEvent) error {
+	if len(p.eventVerifiers) == 0 || ctx.Value(authenticatedKey{}) != nil {
+		return nil
+	}
+	if event.Signature == "" {
+		return withCode(CodeUnauthorized, errors.New("event signature is required"))
+	}
+	signature, err := base64.StdEncoding.DecodeString(event.Signature)
+	if err != nil {
+		return withCode(CodeUnauthorized, errors.New("event signature is not base64"))
+	}
+	if p.eventMaxAge > 0 {
+		signedAt := time.Unix(event.SignedAt, 0)
+		if event.SignedAt == 0 || time.Since(signedAt) > p.eventMaxAge || time.Until(signedAt) > functionURLSkew {
+			return withCode(CodeUnauthorized, errors.New("event signature is expired or signed_at is missing"))
+		}
+	}
+	payload, err := CanonicalEventPayload(event)
+	if err != nil {
+		return withCode(CodeUnauthorized, fmt.Errorf("encode event for verification: %w", err))
+	}
+	for _, v := range p.eventVerifiers {
+		if v.Verify(payload, signature) {
+			return nil
+		}
+	}
+	return withCode(CodeUnauthorized, errors.New("event signature is invalid"))
+}
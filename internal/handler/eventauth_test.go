@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+func signedClient() *fakeClient {
+	return &fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: "abc"}, nil
+		},
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: ref, Status: "success"}, nil
+		},
+	}
+}
+
+func TestProcessorRequiresValidEventSignature(t *testing.T) {
+	processor := NewProcessor(signedClient(), WithPollInterval(time.Millisecond), WithEventSignatures(time.Hour, HMACEventVerifier("s3cret")))
+	ctx := context.Background()
+	event := SubscriptionEvent{Number: "2507", Amount: 1000, Metadata: map[string]any{"invoice": "INV-1"}, SignedAt: time.Now().Unix()}
+
+	_, err := processor.Handle(ctx, event)
+	require.Equal(t, CodeUnauthorized, CodeOf(err))
+
+	event.Signature, err = SignEvent(event, "s3cret")
+	require.NoError(t, err)
+	resp, err := processor.Handle(ctx, event)
+	require.NoError(t, err)
+	require.Equal(t, "success", resp.Status)
+
+	tampered := event
+	tampered.Amount = 100000
+	_, err = processor.Handle(ctx, tampered)
+	require.EqualError(t, err, "event signature is invalid")
+	require.Equal(t, CodeUnauthorized, CodeOf(err))
+
+	stale := SubscriptionEvent{Number: "2507", Amount: 1000, SignedAt: time.Now().Add(-2 * time.Hour).Unix()}
+	stale.Signature, err = SignEvent(stale, "s3cret")
+	require.NoError(t, err)
+	_, err = processor.Handle(ctx, stale)
+	require.Equal(t, CodeUnauthorized, CodeOf(err))
+
+	_, err = processor.Handle(authenticatedContext(ctx), SubscriptionEvent{Number: "2507", Amount: 1000})
+	require.NoError(t, err, "events authenticated by their transport skip the check")
+}
+
+func TestPublicKeyEventVerifier(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	require.NoError(t, err)
+	verifier, err := PublicKeyEventVerifier(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	require.NoError(t, err)
+	processor := NewProcessor(signedClient(), WithPollInterval(time.Millisecond), WithEventSignatures(0, verifier))
+
+	event := SubscriptionEvent{Number: "2507", Amount: 1000}
+	payload, err := CanonicalEventPayload(event)
+	require.NoError(t, err)
+	require.Equal(t, `{"amount":1000,"number":"2507"}`, string(payload))
+	event.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(priv, payload))
+
+	_, err = processor.Handle(context.Background(), event)
+	require.NoError(t, err)
+
+	event.Signature, err = SignEvent(event, "guessed")
+	require.NoError(t, err)
+	_, err = processor.Handle(context.Background(), event)
+	require.Equal(t, CodeUnauthorized, CodeOf(err))
+
+	_, err = PublicKeyEventVerifier([]byte("not a key"))
+	require.Error(t, err)
+}
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/berniyo/paypack-lambda/internal/eventstore"
+)
+
+// WithEventStore persists every received webhook and emitted callback, and enables the replay action.
+func WithEventStore(store eventstore.Store) Option {
+	return func(p *Processor) {
+		p.events = store
+	}
+}
+
+func (p *Processor) recordCallback(ctx context.Context, resp SubscriptionResponse, sendErr error) {
+	if p.events == nil || resp.Reference == "" {
+		return
+	}
+
+	payload, err := json.Marshal(resp)
+	if err != nil {
+		p.logf(ctx, "encode callback record for ref=%s: %v", resp.Reference, err)
+		return
+	}
+
+	rec := eventstore.Record{
+		Kind:      eventstore.KindCallback,
+		Ref:       resp.Reference,
+		Payload:   payload,
+		Delivered: sendErr == nil,
+	}
+	if sendErr != nil {
+		rec.Error = sendErr.Error()
+	}
+	if err := p.events.Append(ctx, rec); err != nil {
+		p.logf(ctx, "store callback record for ref=%s: %v", resp.Reference, err)
+	}
+}
+
+func (p *Processor) recordWebhook(ctx context.Context, id, ref string, body []byte) {
+	if p.events == nil {
+		return
+	}
+	if err := p.events.Append(ctx, eventstore.Record{
+		ID:      id,
+		Kind:    eventstore.KindWebhook,
+		Ref:     ref,
+		Payload: json.RawMessage(body),
+	}); err != nil {
+		p.logf(ctx, "store webhook record for ref=%s: %v", ref, err)
+	}
+}
+
+// handleReplay re-delivers the most recent stored callback for event.Ref.
+func (p *Processor) handleReplay(ctx context.Context, event SubscriptionEvent) (SubscriptionResponse, error) {
+	if p.events == nil {
+		return SubscriptionResponse{}, errors.New("event store is not configured")
+	}
+	if p.callback == nil {
+		return SubscriptionResponse{}, errors.New("callback sender is not configured")
+	}
+	ref := strings.TrimSpace(event.Ref)
+	if ref == "" {
+		return SubscriptionResponse{}, withCode(CodeValidation, errors.New("ref is required"))
+	}
+
+	rec, err := p.events.Latest(ctx, ref, eventstore.KindCallback)
+	if err != nil {
+		return SubscriptionResponse{}, fmt.Errorf("lookup callback for ref=%s: %w", ref, err)
+	}
+
+	var resp SubscriptionResponse
+	if err := json.Unmarshal(rec.Payload, &resp); err != nil {
+		return SubscriptionResponse{}, fmt.Errorf("decode stored callback: %w", err)
+	}
+
+	p.logf(ctx, "replaying callback for ref=%s recorded at %s", ref, rec.CreatedAt.Format("2006-01-02T15:04:05Z07:00"))
+	sendErr := p.callback.Send(ctx, resp)
+	p.recordCallback(ctx, resp, sendErr)
+	if sendErr != nil {
+		return SubscriptionResponse{}, fmt.Errorf("replay callback: %w", sendErr)
+	}
+
+	return resp, nil
+}
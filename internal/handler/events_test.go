@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/berniyo/paypack-lambda/internal/eventstore"
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+func TestProcessorReplayRedeliversStoredCallback(t *testing.T) {
+	client := &fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: "abc"}, nil
+		},
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: ref, Status: "success"}, nil
+		},
+	}
+
+	store := eventstore.NewMemoryStore()
+	cb := &fakeCallback{err: errors.New("consumer down")}
+	processor := NewProcessor(
+		client,
+		WithPollInterval(5*time.Millisecond),
+		WithTimeout(200*time.Millisecond),
+		WithCallbackSender(cb),
+		WithEventStore(store),
+	)
+
+	original, err := processor.Handle(context.Background(), SubscriptionEvent{Number: "2507", Amount: 1000})
+	require.NoError(t, err)
+
+	cb.err = nil
+	replayed, err := processor.Handle(context.Background(), SubscriptionEvent{Action: ActionReplay, Ref: "abc"})
+	require.NoError(t, err)
+	require.Equal(t, original.Reference, replayed.Reference)
+	require.Equal(t, original.Status, replayed.Status)
+	require.Len(t, cb.calls, 2)
+
+	recs, err := store.List(context.Background(), "abc")
+	require.NoError(t, err)
+	require.Len(t, recs, 2)
+	require.False(t, recs[0].Delivered)
+	require.Equal(t, "consumer down", recs[0].Error)
+	require.True(t, recs[1].Delivered)
+}
+
+func TestProcessorReplayRequiresStoredCallback(t *testing.T) {
+	processor := NewProcessor(&fakeClient{}, WithCallbackSender(&fakeCallback{}), WithEventStore(eventstore.NewMemoryStore()))
+
+	_, err := processor.Handle(context.Background(), SubscriptionEvent{Action: ActionReplay, Ref: "missing"})
+	require.ErrorIs(t, err, eventstore.ErrNotFound)
+}
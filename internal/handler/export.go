@@ -0,0 +1,219 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/berniyo/paypack-lambda/internal/eventstore"
+	"github.com/berniyo/paypack-lambda/internal/export"
+	"github.com/berniyo/paypack-lambda/internal/objectstore"
+)
+
+const (
+	defaultExportURLTTL = time.Hour
+	exportKeyPrefix     = "exports/"
+)
+
+// ExportFilter selects the transactions written by the export action. From is inclusive
+// and To exclusive; empty fields do not filter.
+type ExportFilter struct {
+	From   time.Time `json:"from,omitzero"`
+	To     time.Time `json:"to,omitzero"`
+	Status string    `json:"status,omitempty"`
+	Client string    `json:"client,omitempty"`
+	Format string    `json:"format,omitempty"`
+}
+
+// ExportResult locates a written export object.
+type ExportResult struct {
+	Key    string        `json:"key"`
+	URL    string        `json:"url"`
+	Format export.Format `json:"format"`
+	Rows   int           `json:"rows"`
+}
+
+// WithExports enables the export action, writing objects to store and returning a
+// pre-signed URL valid for urlTTL (default 1h). Exports read the callback records of the
+// event store, so WithEventStore must be configured with a store that can scan.
+func WithExports(store objectstore.Store, urlTTL time.Duration) Option {
+	return func(p *Processor) {
+		p.exports = store
+		p.exportURLTTL = defaultExportURLTTL
+		if urlTTL > 0 {
+			p.exportURLTTL = urlTTL
+		}
+	}
+}
+
+// handleExport writes the latest outcome of every transaction matching event.Export.
+func (p *Processor) handleExport(ctx context.Context, event SubscriptionEvent) (SubscriptionResponse, error) {
+	if p.exports == nil {
+		return SubscriptionResponse{}, errors.New("export store is not configured")
+	}
+	scanner, ok := p.events.(eventstore.Scanner)
+	if !ok {
+		return SubscriptionResponse{}, errors.New("event store does not support exports")
+	}
+
+	var filter ExportFilter
+	if event.Export != nil {
+		filter = *event.Export
+	}
+	format, err := export.ParseFormat(filter.Format)
+	if err != nil {
+		return SubscriptionResponse{}, withCode(CodeValidation, err)
+	}
+	if !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return SubscriptionResponse{}, withCode(CodeValidation, errors.New("export from must be before to"))
+	}
+
+	now := time.Now().UTC()
+	key := fmt.Sprintf("%s%s/%s.%s", exportKeyPrefix, now.Format("2006/01/02"), eventstore.NewID(), format)
+	rows, err := p.writeExport(ctx, key, format, scanner, filter)
+	if err != nil {
+		return SubscriptionResponse{}, err
+	}
+	url, err := p.exports.PresignGet(ctx, key, p.exportURLTTL)
+	if err != nil {
+		return SubscriptionResponse{}, fmt.Errorf("presign export: %w", err)
+	}
+
+	p.logf(ctx, "export %s written with %d rows", key, rows)
+	return SubscriptionResponse{
+		Status:  "success",
+		Request: event,
+		Export:  &ExportResult{Key: key, URL: url, Format: format, Rows: rows},
+	}, nil
+}
+
+// writeExport encodes the export rows into key. Stores that can stream receive the object
+// as it is encoded, through a pipe; others get it in one Put.
+func (p *Processor) writeExport(ctx context.Context, key string, format export.Format, scanner eventstore.Scanner, filter ExportFilter) (int, error) {
+	streamer, ok := p.exports.(objectstore.Streamer)
+	if !ok {
+		var buf bytes.Buffer
+		rows, err := p.exportRows(ctx, &buf, format, scanner, filter)
+		if err != nil {
+			return 0, err
+		}
+		if err := p.exports.Put(ctx, key, format.ContentType(), buf.Bytes()); err != nil {
+			return 0, fmt.Errorf("store export: %w", err)
+		}
+		return rows, nil
+	}
+
+	type result struct {
+		rows int
+		err  error
+	}
+	pr, pw := io.Pipe()
+	encoded := make(chan result, 1)
+	go func() {
+		rows, err := p.exportRows(ctx, pw, format, scanner, filter)
+		pw.CloseWithError(err)
+		encoded <- result{rows, err}
+	}()
+	err := streamer.PutStream(ctx, key, format.ContentType(), pr)
+	// Unblocks the encoder when the upload stopped reading early.
+	pr.CloseWithError(errExportAborted)
+	res := <-encoded
+	if res.err != nil && !errors.Is(res.err, errExportAborted) {
+		return 0, res.err
+	}
+	if err != nil {
+		return 0, fmt.Errorf("store export: %w", err)
+	}
+	return res.rows, nil
+}
+
+// errExportAborted is returned to the export encoder once the upload has stopped reading.
+var errExportAborted = errors.New("export upload aborted")
+
+// exportRows writes the most recent callback per ref to w, so replays and retried
+// deliveries count once, after applying the status and client filters to that outcome.
+// Records come back from the scan in no particular order, so the first pass only indexes
+// the latest record of each ref and the second encodes those as it meets them; memory
+// grows with the number of refs, not with the rows. Rows are written in scan order.
+func (p *Processor) exportRows(ctx context.Context, w io.Writer, format export.Format, scanner eventstore.Scanner, filter ExportFilter) (int, error) {
+	type latestRecord struct {
+		id        string
+		createdAt time.Time
+	}
+	latest := make(map[string]latestRecord)
+	err := scanner.Scan(ctx, eventstore.KindCallback, filter.From, filter.To, func(rec eventstore.Record) error {
+		if prev, ok := latest[rec.Ref]; ok && !rec.CreatedAt.After(prev.createdAt) {
+			return nil
+		}
+		var resp SubscriptionResponse
+		if err := json.Unmarshal(rec.Payload, &resp); err != nil {
+			p.logf(ctx, "skip undecodable callback record %s for ref=%s: %v", rec.ID, rec.Ref, err)
+			return nil
+		}
+		latest[rec.Ref] = latestRecord{id: rec.ID, createdAt: rec.CreatedAt}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("scan transactions: %w", err)
+	}
+
+	out, err := export.NewWriter(w, format)
+	if err != nil {
+		return 0, err
+	}
+	err = scanner.Scan(ctx, eventstore.KindCallback, filter.From, filter.To, func(rec eventstore.Record) error {
+		if latest[rec.Ref].id != rec.ID {
+			return nil
+		}
+		var resp SubscriptionResponse
+		if err := json.Unmarshal(rec.Payload, &resp); err != nil {
+			return nil
+		}
+		row := exportRow(rec, resp)
+		if filter.Status != "" && !strings.EqualFold(row.Status, filter.Status) {
+			return nil
+		}
+		if filter.Client != "" && row.Client != filter.Client {
+			return nil
+		}
+		return out.Write(row)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("scan transactions: %w", err)
+	}
+	if err := out.Close(); err != nil {
+		return 0, err
+	}
+	return out.Rows(), nil
+}
+
+func exportRow(rec eventstore.Record, resp SubscriptionResponse) export.Row {
+	row := export.Row{
+		Ref:            rec.Ref,
+		CreatedAt:      rec.CreatedAt.UTC(),
+		Status:         resp.Status,
+		Amount:         resp.Request.Amount,
+		Currency:       resp.Request.Currency,
+		Client:         resp.Request.Client,
+		SubscriptionID: resp.Request.SubscriptionID,
+		Message:        resp.Message,
+	}
+	if txn := resp.Transaction; txn != nil {
+		row.Amount = txn.Amount
+		row.Fee = txn.Fee
+		row.Provider = txn.Provider
+		if txn.Currency != "" {
+			row.Currency = txn.Currency
+		}
+	}
+	row.NetAmount = row.Amount - row.Fee
+	if resp.Fees != nil {
+		row.NetAmount = resp.Fees.NetAmount
+	}
+	return row
+}
@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/require"
+
+	"github.com/berniyo/paypack-lambda/internal/eventstore"
+	"github.com/berniyo/paypack-lambda/internal/export"
+	"github.com/berniyo/paypack-lambda/internal/objectstore"
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+func seedCallbacks(t *testing.T, events *eventstore.MemoryStore, base time.Time) {
+	t.Helper()
+	records := []struct {
+		at   time.Duration
+		resp SubscriptionResponse
+	}{
+		{0, SubscriptionResponse{Reference: "a", Status: "failed", Request: SubscriptionEvent{Client: "acme", Amount: 500}}},
+		{time.Minute, SubscriptionResponse{Reference: "a", Status: "success", Found: true, Request: SubscriptionEvent{Client: "acme"},
+			Transaction: &paypack.Transaction{Ref: "a", Amount: 500, Fee: 12, Currency: "RWF", Provider: "mtn"}}},
+		{2 * time.Minute, SubscriptionResponse{Reference: "b", Status: "failed", Request: SubscriptionEvent{Client: "globex", Amount: 900}}},
+		{48 * time.Hour, SubscriptionResponse{Reference: "c", Status: "success", Request: SubscriptionEvent{Client: "acme", Amount: 100}}},
+	}
+	for _, r := range records {
+		payload, err := json.Marshal(r.resp)
+		require.NoError(t, err)
+		require.NoError(t, events.Append(context.Background(), eventstore.Record{
+			Kind: eventstore.KindCallback, Ref: r.resp.Reference, Payload: payload, CreatedAt: base.Add(r.at),
+		}))
+	}
+}
+
+func TestExportWritesFilteredCSV(t *testing.T) {
+	base := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	events := eventstore.NewMemoryStore()
+	seedCallbacks(t, events, base)
+	objects := objectstore.NewMemoryStore()
+	processor := NewProcessor(&fakeClient{}, WithEventStore(events), WithExports(objects, 0))
+
+	resp, err := processor.Handle(context.Background(), SubscriptionEvent{
+		Action: ActionExport,
+		Export: &ExportFilter{From: base, To: base.Add(24 * time.Hour), Client: "acme"},
+	})
+	require.NoError(t, err)
+	require.NotNil(t, resp.Export)
+	require.Equal(t, export.FormatCSV, resp.Export.Format)
+	require.Equal(t, 1, resp.Export.Rows)
+	require.Equal(t, "memory://"+resp.Export.Key, resp.Export.URL)
+
+	obj, err := objects.Get(resp.Export.Key)
+	require.NoError(t, err)
+	require.Equal(t, "text/csv", obj.ContentType)
+	lines, err := csv.NewReader(bytes.NewReader(obj.Body)).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, lines, 2)
+	require.Equal(t, []string{"a", "2024-05-01T09:01:00Z", "success", "500", "RWF", "12", "488", "mtn", "acme", "", ""}, lines[1])
+}
+
+func TestExportWritesParquet(t *testing.T) {
+	base := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	events := eventstore.NewMemoryStore()
+	seedCallbacks(t, events, base)
+	objects := objectstore.NewMemoryStore()
+	processor := NewProcessor(&fakeClient{}, WithEventStore(events), WithExports(objects, 0))
+
+	resp, err := processor.Handle(context.Background(), SubscriptionEvent{
+		Action: ActionExport,
+		Export: &ExportFilter{Status: "failed", Format: "parquet"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, resp.Export.Rows)
+
+	obj, err := objects.Get(resp.Export.Key)
+	require.NoError(t, err)
+	rows, err := parquet.Read[export.Row](bytes.NewReader(obj.Body), int64(len(obj.Body)))
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	require.Equal(t, "b", rows[0].Ref)
+	require.Equal(t, 900.0, rows[0].Amount)
+}
+
+func TestExportRequiresConfiguration(t *testing.T) {
+	processor := NewProcessor(&fakeClient{}, WithExports(objectstore.NewMemoryStore(), 0))
+
+	_, err := processor.Handle(context.Background(), SubscriptionEvent{Action: ActionExport})
+	require.EqualError(t, err, "event store does not support exports")
+
+	processor = NewProcessor(&fakeClient{}, WithEventStore(eventstore.NewMemoryStore()), WithExports(objectstore.NewMemoryStore(), 0))
+	_, err = processor.Handle(context.Background(), SubscriptionEvent{Action: ActionExport, Export: &ExportFilter{Format: "xlsx"}})
+	require.EqualError(t, err, `unsupported export format "xlsx"`)
+}
+
+func TestExportFallsBackToPutWithoutStreaming(t *testing.T) {
+	base := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	events := eventstore.NewMemoryStore()
+	seedCallbacks(t, events, base)
+	objects := objectstore.NewMemoryStore()
+	// Embedding only the Store interface hides MemoryStore's PutStream.
+	processor := NewProcessor(&fakeClient{}, WithEventStore(events), WithExports(struct{ objectstore.Store }{objects}, 0))
+
+	resp, err := processor.Handle(context.Background(), SubscriptionEvent{Action: ActionExport})
+	require.NoError(t, err)
+	require.Equal(t, 3, resp.Export.Rows)
+
+	obj, err := objects.Get(resp.Export.Key)
+	require.NoError(t, err)
+	lines, err := csv.NewReader(bytes.NewReader(obj.Body)).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, lines, 4)
+}
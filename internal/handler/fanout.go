@@ -0,0 +1,184 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/berniyo/paypack-lambda/internal/metrics"
+	"github.com/berniyo/paypack-lambda/internal/subscription"
+)
+
+// Channel names a notification destination type.
+type Channel string
+
+const (
+	ChannelCallback Channel = "callback"
+	ChannelSMS      Channel = "sms"
+	ChannelEmail    Channel = "email"
+	ChannelSlack    Channel = "slack"
+	ChannelTelegram Channel = "telegram"
+	ChannelDiscord  Channel = "discord"
+	// ChannelEventBridge and ChannelKafka publish CloudEvents for event-driven consumers.
+	ChannelEventBridge Channel = "eventbridge"
+	ChannelKafka       Channel = "kafka"
+	// ChannelAppSync publishes outcomes as AppSync mutations to subscribed mobile clients.
+	ChannelAppSync Channel = "appsync"
+)
+
+// PreferenceStore resolves the channels a client has opted into.
+type PreferenceStore interface {
+	Channels(ctx context.Context, client string) ([]Channel, error)
+}
+
+// StaticPreferences is a PreferenceStore loaded from configuration.
+type StaticPreferences struct {
+	byClient map[string][]Channel
+	fallback []Channel
+}
+
+// ParsePreferences decodes {"<client>": ["callback", "sms", "email", "slack"], "*": ["callback"]};
+// telegram, discord, eventbridge, kafka and appsync are also accepted.
+// The "*" entry applies to clients without their own entry and defaults to callback only.
+func ParsePreferences(data []byte) (*StaticPreferences, error) {
+	var raw map[string][]Channel
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("decode notification preferences: %w", err)
+	}
+
+	prefs := &StaticPreferences{byClient: make(map[string][]Channel), fallback: []Channel{ChannelCallback}}
+	for client, channels := range raw {
+		for _, ch := range channels {
+			switch ch {
+			case ChannelCallback, ChannelSMS, ChannelEmail, ChannelSlack, ChannelTelegram, ChannelDiscord, ChannelEventBridge, ChannelKafka, ChannelAppSync:
+			default:
+				return nil, fmt.Errorf("client %s: unknown channel %q", client, ch)
+			}
+		}
+		if client == "*" {
+			prefs.fallback = channels
+			continue
+		}
+		prefs.byClient[client] = channels
+	}
+	return prefs, nil
+}
+
+// Channels implements PreferenceStore.
+func (s *StaticPreferences) Channels(ctx context.Context, client string) ([]Channel, error) {
+	if channels, ok := s.byClient[client]; ok {
+		return channels, nil
+	}
+	return s.fallback, nil
+}
+
+// FanoutSender delivers each outcome to the channels the event's client opted into.
+type FanoutSender struct {
+	prefs   PreferenceStore
+	senders map[Channel]CallbackSender
+}
+
+// NewFanoutSender builds a FanoutSender. Channels without a registered sender are skipped.
+func NewFanoutSender(prefs PreferenceStore, senders map[Channel]CallbackSender) (*FanoutSender, error) {
+	if prefs == nil {
+		return nil, errors.New("preference store is required")
+	}
+	registered := make(map[Channel]CallbackSender, len(senders))
+	for ch, s := range senders {
+		if s != nil {
+			registered[ch] = s
+		}
+	}
+	return &FanoutSender{prefs: prefs, senders: registered}, nil
+}
+
+// Send implements CallbackSender. Every selected channel is attempted; failures are joined.
+func (f *FanoutSender) Send(ctx context.Context, payload SubscriptionResponse) error {
+	channels, err := f.prefs.Channels(ctx, payload.Request.Client)
+	if err != nil {
+		return fmt.Errorf("resolve notification channels: %w", err)
+	}
+
+	var errs []error
+	for _, ch := range channels {
+		sender, ok := f.senders[ch]
+		if !ok {
+			continue
+		}
+		if err := sender.Send(ctx, payload); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", ch, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// MeteredSender wraps sender so each delivery is counted and timed under the channel's name.
+func MeteredSender(ch Channel, sender CallbackSender, m metrics.Metrics) CallbackSender {
+	if m == nil {
+		return sender
+	}
+	return meteredSender{channel: string(ch), sender: sender, metrics: m}
+}
+
+type meteredSender struct {
+	channel string
+	sender  CallbackSender
+	metrics metrics.Metrics
+}
+
+func (s meteredSender) Send(ctx context.Context, payload SubscriptionResponse) error {
+	start := time.Now()
+	err := s.sender.Send(ctx, payload)
+	result := "delivered"
+	if err != nil {
+		result = "failed"
+	}
+	metrics.Since(s.metrics, metrics.NotificationLatency, start, metrics.T("channel", s.channel))
+	s.metrics.Count(metrics.NotificationDeliveries, 1, metrics.T("channel", s.channel), metrics.T("result", result))
+	return err
+}
+
+// notificationText renders the short human-readable outcome used by SMS and email.
+func notificationText(resp SubscriptionResponse) string {
+	amount, currency := outcomeAmount(resp)
+
+	outcome := "was not completed"
+	if resp.Found && resp.Status == "success" {
+		outcome = "was successful"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Your payment of %.0f %s %s.", amount, currency, outcome)
+	if resp.Reference != "" {
+		fmt.Fprintf(&b, " Ref: %s.", resp.Reference)
+	}
+	if resp.Retry != nil && resp.Retry.NextAttemptAt != nil {
+		fmt.Fprintf(&b, " We will try again on %s.", resp.Retry.NextAttemptAt.Format("2 Jan 2006"))
+	}
+	if resp.Subscription != nil && resp.Subscription.Status == subscription.StatusCancelled {
+		b.WriteString(" Your subscription has been cancelled.")
+	}
+	if resp.Receipt != nil {
+		fmt.Fprintf(&b, " Receipt: %s", resp.Receipt.URL)
+	}
+	return b.String()
+}
+
+// outcomeAmount is the amount Paypack reported for resp, falling back to the requested one.
+func outcomeAmount(resp SubscriptionResponse) (float64, string) {
+	currency := resp.Request.Currency
+	if currency == "" {
+		currency = "RWF"
+	}
+	amount := resp.Request.Amount
+	if resp.Transaction != nil && resp.Transaction.Amount > 0 {
+		amount = resp.Transaction.Amount
+		if resp.Transaction.Currency != "" {
+			currency = resp.Transaction.Currency
+		}
+	}
+	return amount, currency
+}
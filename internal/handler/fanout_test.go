@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/berniyo/paypack-lambda/internal/customer"
+	"github.com/berniyo/paypack-lambda/internal/metrics"
+)
+
+type recordingMetrics struct {
+	counts   map[string]float64
+	observed map[string]float64
+	flushes  int
+}
+
+func metricKey(name string, tags []metrics.Tag) string {
+	key := name
+	for _, t := range tags {
+		key += " " + t.Key + "=" + t.Value
+	}
+	return key
+}
+
+func (r *recordingMetrics) Count(name string, delta float64, tags ...metrics.Tag) {
+	if r.counts == nil {
+		r.counts = make(map[string]float64)
+	}
+	r.counts[metricKey(name, tags)] += delta
+}
+
+// Observe keeps the latest sample per series.
+func (r *recordingMetrics) Observe(name string, value float64, tags ...metrics.Tag) {
+	if r.observed == nil {
+		r.observed = make(map[string]float64)
+	}
+	r.observed[metricKey(name, tags)] = value
+}
+
+func (r *recordingMetrics) Flush(context.Context) error {
+	r.flushes++
+	return nil
+}
+
+func TestFanoutSenderHonoursPreferences(t *testing.T) {
+	prefs, err := ParsePreferences([]byte(`{"shop":["callback","sms"],"*":["callback"]}`))
+	require.NoError(t, err)
+
+	callback, sms, email := &fakeCallback{}, &fakeCallback{}, &fakeCallback{}
+	fanout, err := NewFanoutSender(prefs, map[Channel]CallbackSender{
+		ChannelCallback: callback,
+		ChannelSMS:      sms,
+		ChannelEmail:    email,
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, fanout.Send(context.Background(), SubscriptionResponse{Request: SubscriptionEvent{Client: "shop"}}))
+	require.NoError(t, fanout.Send(context.Background(), SubscriptionResponse{Request: SubscriptionEvent{Client: "other"}}))
+
+	require.Len(t, callback.calls, 2)
+	require.Len(t, sms.calls, 1)
+	require.Empty(t, email.calls)
+}
+
+func TestFanoutSenderJoinsErrors(t *testing.T) {
+	prefs, err := ParsePreferences([]byte(`{"*":["callback","sms"]}`))
+	require.NoError(t, err)
+
+	callback := &fakeCallback{}
+	sms := &fakeCallback{err: errors.New("gateway down")}
+	fanout, err := NewFanoutSender(prefs, map[Channel]CallbackSender{ChannelCallback: callback, ChannelSMS: sms})
+	require.NoError(t, err)
+
+	err = fanout.Send(context.Background(), SubscriptionResponse{})
+	require.EqualError(t, err, "sms: gateway down")
+	require.Len(t, callback.calls, 1)
+}
+
+func TestParsePreferencesRejectsUnknownChannel(t *testing.T) {
+	_, err := ParsePreferences([]byte(`{"shop":["pager"]}`))
+	require.EqualError(t, err, `client shop: unknown channel "pager"`)
+}
+
+func TestSMSSenderPostsToGateway(t *testing.T) {
+	var got map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "Bearer tok", r.Header.Get("Authorization"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	sender, err := NewSMSSender(srv.URL, "tok", "Shop", srv.Client())
+	require.NoError(t, err)
+
+	err = sender.Send(context.Background(), SubscriptionResponse{
+		Reference: "abc",
+		Status:    "success",
+		Found:     true,
+		Request:   SubscriptionEvent{Number: "250780000000", Amount: 1000, Currency: "RWF"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "+250780000000", got["to"])
+	require.Equal(t, "Your payment of 1000 RWF was successful. Ref: abc.", got["text"])
+}
+
+func TestEmailSenderUsesCustomerEmail(t *testing.T) {
+	sender, err := NewEmailSender("smtp.example.com:587", "billing@example.com", nil)
+	require.NoError(t, err)
+
+	var to []string
+	sender.sendMail = func(addr string, a smtp.Auth, from string, rcpt []string, msg []byte) error {
+		to = rcpt
+		return nil
+	}
+
+	require.NoError(t, sender.Send(context.Background(), SubscriptionResponse{}))
+	require.Nil(t, to)
+
+	require.NoError(t, sender.Send(context.Background(), SubscriptionResponse{Customer: &customer.Profile{Email: "a@example.com"}}))
+	require.Equal(t, []string{"a@example.com"}, to)
+}
+
+func TestMeteredSenderCountsDeliveries(t *testing.T) {
+	rec := &recordingMetrics{}
+	ok := MeteredSender(ChannelSMS, &fakeCallback{}, rec)
+	failing := MeteredSender(ChannelEmail, &fakeCallback{err: errors.New("smtp down")}, rec)
+
+	require.NoError(t, ok.Send(context.Background(), SubscriptionResponse{}))
+	require.Error(t, failing.Send(context.Background(), SubscriptionResponse{}))
+
+	require.Equal(t, 1.0, rec.counts["notification_deliveries_total channel=sms result=delivered"])
+	require.Equal(t, 1.0, rec.counts["notification_deliveries_total channel=email result=failed"])
+}
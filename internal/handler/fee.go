@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/berniyo/paypack-lambda/internal/fee"
+	"github.com/berniyo/paypack-lambda/internal/money"
+)
+
+// FeeCalculator predicts fees and compares them with what Paypack charged.
+type FeeCalculator interface {
+	Compare(amount, actualFee float64, currency, provider string) fee.Breakdown
+}
+
+// WithFeeSchedule adds an expected/actual fee and net amount breakdown to every response
+// carrying a transaction, logging discrepancies beyond the schedule tolerance.
+func WithFeeSchedule(calc FeeCalculator) Option {
+	return func(p *Processor) {
+		p.fees = calc
+	}
+}
+
+func (p *Processor) computeFees(ctx context.Context, resp *SubscriptionResponse) {
+	setNetAmount(resp)
+	if p.fees == nil || resp.Transaction == nil {
+		return
+	}
+
+	txn := resp.Transaction
+	breakdown := p.fees.Compare(txn.Amount, txn.Fee, walletCurrency(txn.Currency), txn.Provider)
+	if breakdown.Mismatch {
+		p.logf(ctx, "fee mismatch for ref=%s provider=%s expected=%.2f actual=%.2f",
+			resp.Reference, txn.Provider, breakdown.ExpectedFee, breakdown.ActualFee)
+	}
+	resp.Fees = &breakdown
+}
+
+// setNetAmount copies a successful transaction's fee to the top level of resp along with the
+// amount net of it.
+func setNetAmount(resp *SubscriptionResponse) {
+	txn := resp.Transaction
+	if txn == nil || !resp.Found || resp.Status != "success" {
+		return
+	}
+	fee, net := txn.Fee, money.Round(txn.Amount-txn.Fee, walletCurrency(txn.Currency))
+	resp.Fee, resp.NetAmount = &fee, &net
+}
@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/berniyo/paypack-lambda/internal/fee"
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+func TestProcessorReportsFees(t *testing.T) {
+	client := &fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: "abc"}, nil
+		},
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: ref, Status: "success", Amount: 1000, Fee: 23, Provider: "mtn"}, nil
+		},
+	}
+
+	cb := &fakeCallback{}
+	processor := NewProcessor(
+		client,
+		WithPollInterval(5*time.Millisecond),
+		WithTimeout(200*time.Millisecond),
+		WithCallbackSender(cb),
+		WithFeeSchedule(fee.DefaultSchedule()),
+	)
+
+	resp, err := processor.Handle(context.Background(), SubscriptionEvent{Number: "2507", Amount: 1000})
+	require.NoError(t, err)
+	require.Equal(t, &fee.Breakdown{Provider: "mtn", ExpectedFee: 23, ActualFee: 23, NetAmount: 977}, resp.Fees)
+	require.Equal(t, resp.Fees, cb.calls[0].Fees)
+}
+
+func TestProcessorReportsFeeAndNetAmountAtTopLevel(t *testing.T) {
+	txn := &paypack.Transaction{Ref: "abc", Status: "success", Amount: 1000, Fee: 23.4, Currency: "USD"}
+	client := &fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: "abc"}, nil
+		},
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			return txn, nil
+		},
+	}
+	cb := &fakeCallback{}
+	processor := NewProcessor(client, WithCallbackSender(cb))
+
+	resp, err := processor.Handle(context.Background(), SubscriptionEvent{Number: "2507", Amount: 1000})
+	require.NoError(t, err)
+	require.InDelta(t, 23.4, *resp.Fee, 0.001)
+	require.InDelta(t, 976.6, *resp.NetAmount, 0.001)
+	require.Equal(t, resp.NetAmount, cb.calls[0].NetAmount)
+
+	txn = &paypack.Transaction{Ref: "abc", Status: "failed", Amount: 1000}
+	resp, err = processor.Handle(context.Background(), SubscriptionEvent{Number: "2507", Amount: 1000})
+	require.NoError(t, err)
+	require.Nil(t, resp.Fee)
+	require.Nil(t, resp.NetAmount)
+}
@@ -0,0 +1,174 @@
+package handler
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"github.com/berniyo/paypack-lambda/api"
+	"github.com/berniyo/paypack-lambda/internal/tracing"
+)
+
+// Headers of Function URL requests authenticated by signature.
+const (
+	FunctionURLSignatureHeader = "X-Signature"
+	FunctionURLTimestampHeader = "X-Signature-Timestamp"
+)
+
+// functionURLSkew bounds how far a signed request's timestamp may be from now, which limits
+// how long a captured request can be replayed.
+const functionURLSkew = 5 * time.Minute
+
+// FunctionURLAuth selects how Function URL requests are authenticated. A request passes when
+// any configured method accepts it.
+type FunctionURLAuth struct {
+	// IAM accepts requests Lambda authenticated with SigV4, which requires the URL's auth type
+	// to be AWS_IAM. AllowedAccounts, when set, restricts the caller's account.
+	IAM             bool
+	AllowedAccounts []string
+	// Secret accepts requests whose X-Signature is SignFunctionURLRequest of the body and the
+	// X-Signature-Timestamp header.
+	Secret string
+}
+
+// FunctionURLHandler serves SubscriptionEvents posted straight to a Lambda Function URL.
+type FunctionURLHandler struct {
+	processor *Processor
+	auth      FunctionURLAuth
+	now       func() time.Time
+}
+
+// NewFunctionURLHandler builds the Function URL entry point. Function URLs are public, so at
+// least one authentication method is required.
+func NewFunctionURLHandler(processor *Processor, auth FunctionURLAuth) (*FunctionURLHandler, error) {
+	if !auth.IAM && auth.Secret == "" {
+		return nil, errors.New("function url requires IAM auth or a signing secret")
+	}
+	return &FunctionURLHandler{processor: processor, auth: auth, now: time.Now}, nil
+}
+
+// SignFunctionURLRequest returns the X-Signature for body sent at timestamp: the base64
+// HMAC-SHA256 under secret of the Unix timestamp, a dot, and the body.
+func SignFunctionURLRequest(body []byte, timestamp time.Time, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10) + "."))
+	mac.Write(body)
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Handle implements the Lambda Function URL handler entry point. Outcomes, including failed
+// payments, return 200; rejected events return 400, 401, 403 or 422, and Paypack outages 502.
+func (f *FunctionURLHandler) Handle(ctx context.Context, req events.LambdaFunctionURLRequest) (events.LambdaFunctionURLResponse, error) {
+	if method := req.RequestContext.HTTP.Method; method != http.MethodPost {
+		res := functionURLError(http.StatusMethodNotAllowed, "only POST is supported", "")
+		res.Headers["Allow"] = http.MethodPost
+		return res, nil
+	}
+	body := []byte(req.Body)
+	if req.IsBase64Encoded {
+		decoded, err := base64.StdEncoding.DecodeString(req.Body)
+		if err != nil {
+			return functionURLError(http.StatusBadRequest, "invalid base64 body", ""), nil
+		}
+		body = decoded
+	}
+	status, signed := f.authenticate(req, body)
+	if status != http.StatusOK {
+		return functionURLError(status, http.StatusText(status), ""), nil
+	}
+
+	event, err := f.processor.DecodeEvent(body)
+	var invalid *api.ValidationError
+	switch {
+	case errors.As(err, &invalid):
+		res := functionURLJSON(http.StatusBadRequest, map[string]any{
+			"error":   "invalid event payload",
+			"code":    CodeValidation,
+			"details": invalid.Problems,
+		})
+		return res, nil
+	case errors.Is(err, ErrUnsupportedEventVersion):
+		return functionURLJSON(http.StatusBadRequest, map[string]any{
+			"error":              err.Error(),
+			"code":               CodeValidation,
+			"supported_versions": SupportedEventVersions(),
+		}), nil
+	case err != nil:
+		return functionURLError(http.StatusBadRequest, "invalid event payload", ""), nil
+	}
+
+	if tc, ok := tracing.Parse(header(req.Headers, tracing.HeaderTraceParent), header(req.Headers, tracing.HeaderTraceState)); ok {
+		ctx = tracing.NewContext(ctx, tc)
+	}
+	// An HMAC over the body proves who produced the event, so WithEventSignatures need not
+	// check it again. IAM only proves who called, so IAM callers' events are still verified.
+	if signed {
+		ctx = authenticatedContext(ctx)
+	}
+	resp, err := f.processor.Handle(ctx, event)
+	if err != nil {
+		status := http.StatusUnprocessableEntity
+		code := CodeOf(err)
+		if code == CodePaypackUnavailable {
+			status = http.StatusBadGateway
+		}
+		return functionURLError(status, err.Error(), code), nil
+	}
+	return functionURLJSON(http.StatusOK, resp), nil
+}
+
+// authenticate returns http.StatusOK when a configured method accepts req, and whether it
+// was the body's HMAC signature that did.
+func (f *FunctionURLHandler) authenticate(req events.LambdaFunctionURLRequest, body []byte) (int, bool) {
+	status := http.StatusUnauthorized
+	if authz := req.RequestContext.Authorizer; f.auth.IAM && authz != nil && authz.IAM != nil {
+		if len(f.auth.AllowedAccounts) == 0 || slices.Contains(f.auth.AllowedAccounts, authz.IAM.AccountID) {
+			return http.StatusOK, false
+		}
+		status = http.StatusForbidden
+	}
+	if f.auth.Secret == "" {
+		return status, false
+	}
+
+	signature := header(req.Headers, FunctionURLSignatureHeader)
+	unix, err := strconv.ParseInt(header(req.Headers, FunctionURLTimestampHeader), 10, 64)
+	if signature == "" || err != nil {
+		return status, false
+	}
+	sent := time.Unix(unix, 0)
+	if now := f.now(); sent.Before(now.Add(-functionURLSkew)) || sent.After(now.Add(functionURLSkew)) {
+		return status, false
+	}
+	if !hmac.Equal([]byte(signature), []byte(SignFunctionURLRequest(body, sent, f.auth.Secret))) {
+		return status, false
+	}
+	return http.StatusOK, true
+}
+
+func functionURLError(status int, message string, code ErrorCode) events.LambdaFunctionURLResponse {
+	body := map[string]any{"error": message}
+	if code != "" {
+		body["code"] = code
+		body["failure"] = FailureOf(code)
+	}
+	return functionURLJSON(status, body)
+}
+
+func functionURLJSON(status int, v any) events.LambdaFunctionURLResponse {
+	body, _ := json.Marshal(v)
+	return events.LambdaFunctionURLResponse{
+		StatusCode: status,
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       string(body),
+	}
+}
@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/require"
+
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+func newFunctionURLHandler(t *testing.T, auth FunctionURLAuth) *FunctionURLHandler {
+	t.Helper()
+	client := &fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: "abc"}, nil
+		},
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: ref, Status: "success"}, nil
+		},
+	}
+	h, err := NewFunctionURLHandler(NewProcessor(client, WithPollInterval(5*time.Millisecond)), auth)
+	require.NoError(t, err)
+	return h
+}
+
+func functionURLRequest(body string, headers map[string]string, iamAccount string) events.LambdaFunctionURLRequest {
+	req := events.LambdaFunctionURLRequest{Body: body, Headers: headers}
+	req.RequestContext.HTTP.Method = http.MethodPost
+	if iamAccount != "" {
+		req.RequestContext.Authorizer = &events.LambdaFunctionURLRequestContextAuthorizerDescription{
+			IAM: &events.LambdaFunctionURLRequestContextAuthorizerIAMDescription{AccountID: iamAccount},
+		}
+	}
+	return req
+}
+
+func TestFunctionURLAcceptsIAMCallers(t *testing.T) {
+	h := newFunctionURLHandler(t, FunctionURLAuth{IAM: true, AllowedAccounts: []string{"111122223333"}})
+	body := `{"number":"2507","amount":1000}`
+
+	res, err := h.Handle(context.Background(), functionURLRequest(body, nil, "111122223333"))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	var resp SubscriptionResponse
+	require.NoError(t, json.Unmarshal([]byte(res.Body), &resp))
+	require.Equal(t, "success", resp.Status)
+
+	res, _ = h.Handle(context.Background(), functionURLRequest(body, nil, "444455556666"))
+	require.Equal(t, http.StatusForbidden, res.StatusCode)
+	res, _ = h.Handle(context.Background(), functionURLRequest(body, nil, ""))
+	require.Equal(t, http.StatusUnauthorized, res.StatusCode)
+}
+
+func TestFunctionURLVerifiesSignatures(t *testing.T) {
+	h := newFunctionURLHandler(t, FunctionURLAuth{Secret: "s3cret"})
+	now := time.Unix(1_700_000_000, 0)
+	h.now = func() time.Time { return now }
+	body := `{"number":"2507","amount":1000}`
+	signed := func(at time.Time, secret string) map[string]string {
+		return map[string]string{
+			"x-signature":           SignFunctionURLRequest([]byte(body), at, secret),
+			"x-signature-timestamp": strconv.FormatInt(at.Unix(), 10),
+		}
+	}
+
+	res, _ := h.Handle(context.Background(), functionURLRequest(body, signed(now.Add(-time.Minute), "s3cret"), ""))
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	res, _ = h.Handle(context.Background(), functionURLRequest(body, signed(now, "wrong"), ""))
+	require.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	res, _ = h.Handle(context.Background(), functionURLRequest(body, signed(now.Add(-10*time.Minute), "s3cret"), ""))
+	require.Equal(t, http.StatusUnauthorized, res.StatusCode, "stale timestamps are replays")
+	res, _ = h.Handle(context.Background(), functionURLRequest(body, nil, "111122223333"))
+	require.Equal(t, http.StatusUnauthorized, res.StatusCode, "IAM context is ignored unless IAM auth is enabled")
+}
+
+func TestFunctionURLVerifiesEventSignaturesOfIAMCallers(t *testing.T) {
+	processor := NewProcessor(signedClient(), WithPollInterval(time.Millisecond), WithEventSignatures(time.Hour, HMACEventVerifier("event-key")))
+	h, err := NewFunctionURLHandler(processor, FunctionURLAuth{IAM: true, Secret: "s3cret"})
+	require.NoError(t, err)
+	now := time.Now()
+	h.now = func() time.Time { return now }
+	body := `{"number":"2507","amount":1000}`
+
+	res, _ := h.Handle(context.Background(), functionURLRequest(body, nil, "111122223333"))
+	require.Equal(t, http.StatusUnprocessableEntity, res.StatusCode, "IAM proves the caller, not the event")
+	require.Contains(t, res.Body, "event signature is required")
+
+	event := SubscriptionEvent{Number: "2507", Amount: 1000, SignedAt: now.Unix()}
+	event.Signature, err = SignEvent(event, "event-key")
+	require.NoError(t, err)
+	signedEvent, err := json.Marshal(event)
+	require.NoError(t, err)
+	res, _ = h.Handle(context.Background(), functionURLRequest(string(signedEvent), nil, "111122223333"))
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	res, _ = h.Handle(context.Background(), functionURLRequest(body, map[string]string{
+		"x-signature":           SignFunctionURLRequest([]byte(body), now, "s3cret"),
+		"x-signature-timestamp": strconv.FormatInt(now.Unix(), 10),
+	}, ""))
+	require.Equal(t, http.StatusOK, res.StatusCode, "a signed body already authenticates the event")
+}
+
+func TestFunctionURLMapsFailuresToStatusCodes(t *testing.T) {
+	h := newFunctionURLHandler(t, FunctionURLAuth{IAM: true})
+
+	req := functionURLRequest(`{}`, nil, "111122223333")
+	req.RequestContext.HTTP.Method = http.MethodGet
+	res, _ := h.Handle(context.Background(), req)
+	require.Equal(t, http.StatusMethodNotAllowed, res.StatusCode)
+
+	res, _ = h.Handle(context.Background(), functionURLRequest(`{"amount":"1000"}`, nil, "111122223333"))
+	require.Equal(t, http.StatusBadRequest, res.StatusCode)
+	require.Contains(t, res.Body, "$.amount: must be a number")
+
+	res, _ = h.Handle(context.Background(), functionURLRequest(`{"amount":1000}`, nil, "111122223333"))
+	require.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+	require.Contains(t, res.Body, `"code":"VALIDATION_ERROR"`)
+
+	_, err := NewFunctionURLHandler(NewProcessor(&fakeClient{}), FunctionURLAuth{})
+	require.Error(t, err)
+}
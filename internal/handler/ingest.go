@@ -0,0 +1,237 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"github.com/berniyo/paypack-lambda/internal/ingest"
+	"github.com/berniyo/paypack-lambda/internal/objectstore"
+	"github.com/berniyo/paypack-lambda/internal/workpool"
+)
+
+// Keys under ingestResultsPrefix are results files and are never ingested, so the bucket's
+// notification can watch the whole bucket without the handler triggering itself.
+const ingestResultsPrefix = "results/"
+
+// Metadata keys stamped on charges started from an instructions file.
+const (
+	metadataIngestFile = "ingest_file"
+	metadataIngestLine = "ingest_line"
+)
+
+// IngestStore reads instruction files and writes their results; objectstore.S3Store
+// implements it.
+type IngestStore interface {
+	objectstore.Reader
+	Put(ctx context.Context, key, contentType string, body []byte) error
+}
+
+// IngestReport summarises one instructions file. AlreadyProcessed files had a results file
+// and were not charged again; DryRun files were only validated.
+type IngestReport struct {
+	Key              string `json:"key"`
+	ResultsKey       string `json:"results_key"`
+	AlreadyProcessed bool   `json:"already_processed,omitempty"`
+	DryRun           bool   `json:"dry_run,omitempty"`
+	Total            int    `json:"total"`
+	Succeeded        int    `json:"succeeded"`
+	Failed           int    `json:"failed"`
+	Pending          int    `json:"pending"`
+	Skipped          int    `json:"skipped"`
+	Errors           int    `json:"errors"`
+}
+
+// S3IngestHandler charges the billing instructions in files uploaded to one bucket and
+// writes a results file per upload, for bulk billing runs.
+type S3IngestHandler struct {
+	processor *Processor
+	store     IngestStore
+	bucket    string
+}
+
+// NewS3IngestHandler builds a handler for ObjectCreated notifications from bucket, whose
+// objects are read from and results written to store.
+func NewS3IngestHandler(processor *Processor, store IngestStore, bucket string) (*S3IngestHandler, error) {
+	if processor == nil {
+		return nil, errors.New("processor is required")
+	}
+	if store == nil {
+		return nil, errors.New("ingest store is required")
+	}
+	if bucket == "" {
+		return nil, errors.New("bucket is required")
+	}
+	return &S3IngestHandler{processor: processor, store: store, bucket: bucket}, nil
+}
+
+// IsDryRunKey reports whether key is under a dry-run/ folder. Such files are validated
+// without charging anyone, and their results file lists only the rows that would be rejected.
+func IsDryRunKey(key string) bool {
+	return strings.Contains("/"+key, "/dry-run/")
+}
+
+// IngestResultsKey is where the results for the instructions file key are written:
+// results/<key without extension>.results.<csv|jsonl>.
+func IngestResultsKey(key string, format ingest.Format) string {
+	return ingestResultsPrefix + strings.TrimSuffix(key, path.Ext(key)) + ".results." + string(format)
+}
+
+// Handle implements the S3 notification Lambda entry point. Files that fail before any row
+// is charged are returned as errors so Lambda retries them; once rows are charged the results
+// file is what makes a redelivered notification a no-op.
+func (h *S3IngestHandler) Handle(ctx context.Context, event events.S3Event) (reports []IngestReport, err error) {
+	p := h.processor
+	defer p.flush(ctx)
+	ctx, inv := p.beginInvocation(ctx, "s3_ingest")
+	defer func() { inv.end(errorOutcome(err), "") }()
+
+	var errs []error
+	for _, record := range event.Records {
+		if record.S3.Bucket.Name != h.bucket {
+			p.logf(ctx, "ignoring object from bucket %s", record.S3.Bucket.Name)
+			continue
+		}
+		key, err := url.QueryUnescape(record.S3.Object.Key)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("decode object key %q: %w", record.S3.Object.Key, err))
+			continue
+		}
+		if strings.HasPrefix(key, ingestResultsPrefix) {
+			continue
+		}
+		report, err := h.ingest(ctx, key)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("ingest %s: %w", key, err))
+			continue
+		}
+		reports = append(reports, report)
+	}
+	return reports, errors.Join(errs...)
+}
+
+func (h *S3IngestHandler) ingest(ctx context.Context, key string) (IngestReport, error) {
+	p := h.processor
+	format, err := ingest.FormatFor(key)
+	if err != nil {
+		return IngestReport{}, withCode(CodeValidation, err)
+	}
+	report := IngestReport{Key: key, ResultsKey: IngestResultsKey(key, format)}
+	switch _, err := h.store.Read(ctx, report.ResultsKey); {
+	case err == nil:
+		p.logf(ctx, "instructions %s already have results at %s; skipped", key, report.ResultsKey)
+		report.AlreadyProcessed = true
+		return report, nil
+	case !errors.Is(err, objectstore.ErrNotFound):
+		return IngestReport{}, fmt.Errorf("check results: %w", err)
+	}
+
+	data, err := h.store.Read(ctx, key)
+	if err != nil {
+		return IngestReport{}, fmt.Errorf("read instructions: %w", err)
+	}
+	rows, err := ingest.Parse(format, data)
+	if err != nil {
+		// An unreadable file fails the same way on every retry: answer it instead.
+		p.logf(ctx, "instructions %s rejected: %v", key, err)
+		report.Total, report.Errors = 1, 1
+		return report, h.writeResults(ctx, report.ResultsKey, format, []ingest.Result{{
+			Status:  "error",
+			Code:    string(CodeValidation),
+			Message: err.Error(),
+		}})
+	}
+
+	for i, row := range rows {
+		if row.Err == nil {
+			rows[i].Err = validateEvent(instructionEvent(key, row))
+		}
+	}
+	if IsDryRunKey(key) {
+		report.DryRun = true
+		failed := ingest.Errors(rows)
+		for i := range failed {
+			failed[i].Code = string(CodeValidation)
+		}
+		report.Total, report.Errors = len(rows), len(failed)
+		p.logf(ctx, "dry run of %s: %d of %d instructions rejected", key, report.Errors, report.Total)
+		return report, h.writeResults(ctx, report.ResultsKey, format, failed)
+	}
+
+	p.logf(ctx, "ingesting %d instructions from %s", len(rows), key)
+	start := time.Now()
+	batch := workpool.Map(ctx, p.batchConcurrency, rows, func(ctx context.Context, row ingest.Instruction) BatchResult {
+		if row.Err != nil {
+			return BatchResult{Status: "error", Code: CodeValidation, Message: row.Err.Error()}
+		}
+		return p.runBatchItem(ctx, row.Line, instructionEvent(key, row))
+	})
+	summary := p.summarizeBatch(ctx, batch, start)
+
+	results := make([]ingest.Result, len(rows))
+	for i, r := range batch {
+		results[i] = ingest.Result{
+			Line:    rows[i].Line,
+			Number:  rows[i].Number,
+			Amount:  rows[i].Amount,
+			Ref:     r.Ref,
+			Status:  r.Status,
+			Code:    string(r.Code),
+			Message: r.Message,
+		}
+	}
+	report.Total = summary.Total
+	report.Succeeded = summary.Succeeded
+	report.Failed = summary.Failed
+	report.Pending = summary.Pending
+	report.Skipped = summary.Skipped
+	report.Errors = summary.Errors
+	if err := h.writeResults(context.WithoutCancel(ctx), report.ResultsKey, format, results); err != nil {
+		// Rows were charged: report rather than fail, since a retry would charge them again.
+		p.logf(ctx, "results for %s not written: %v", key, err)
+	}
+	return report, nil
+}
+
+func (h *S3IngestHandler) writeResults(ctx context.Context, key string, format ingest.Format, results []ingest.Result) error {
+	body, err := ingest.EncodeResults(format, results)
+	if err != nil {
+		return err
+	}
+	if err := h.store.Put(ctx, key, format.ContentType(), body); err != nil {
+		return fmt.Errorf("write results: %w", err)
+	}
+	return nil
+}
+
+// instructionEvent turns a row into a cash-in tagged with the file and line it came from.
+func instructionEvent(key string, row ingest.Instruction) SubscriptionEvent {
+	metadata := make(map[string]any, len(row.Metadata)+4)
+	for k, v := range row.Metadata {
+		if !reservedMetadataKeys[k] {
+			metadata[k] = v
+		}
+	}
+	if row.Plan != "" {
+		metadata["plan"] = row.Plan
+	}
+	if row.ExternalID != "" {
+		metadata["external_id"] = row.ExternalID
+	}
+	metadata[metadataIngestFile] = key
+	metadata[metadataIngestLine] = row.Line
+	return SubscriptionEvent{
+		Number:         row.Number,
+		Amount:         row.Amount,
+		Currency:       row.Currency,
+		Client:         row.Client,
+		SubscriptionID: row.SubscriptionID,
+		Metadata:       metadata,
+	}
+}
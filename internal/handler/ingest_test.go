@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/require"
+
+	"github.com/berniyo/paypack-lambda/internal/objectstore"
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+func s3Event(bucket string, keys ...string) events.S3Event {
+	var event events.S3Event
+	for _, key := range keys {
+		var record events.S3EventRecord
+		record.S3.Bucket.Name = bucket
+		record.S3.Object.Key = key
+		event.Records = append(event.Records, record)
+	}
+	return event
+}
+
+func TestS3IngestChargesRowsAndWritesResults(t *testing.T) {
+	var mu sync.Mutex
+	charges := 0
+	client := &fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			charges++
+			return &paypack.Transaction{Ref: "ref-" + number}, nil
+		},
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			status := "success"
+			if ref == "ref-0788000002" {
+				status = "failed"
+			}
+			return &paypack.Transaction{Ref: ref, Status: status}, nil
+		},
+	}
+	store := objectstore.NewMemoryStore()
+	require.NoError(t, store.Put(context.Background(), "incoming/2026-10 run.csv", "text/csv", []byte(
+		"number,amount,invoice\n0788000001,1000,INV-1\n0788000002,500,INV-2\n,700,INV-3\n")))
+	processor := NewProcessor(client, WithPollInterval(time.Millisecond))
+	ingester, err := NewS3IngestHandler(processor, store, "billing")
+	require.NoError(t, err)
+
+	event := s3Event("billing", "incoming/2026-10+run.csv", "results/incoming/old.results.csv")
+	reports, err := ingester.Handle(context.Background(), event)
+	require.NoError(t, err)
+	require.Equal(t, []IngestReport{{
+		Key:        "incoming/2026-10 run.csv",
+		ResultsKey: "results/incoming/2026-10 run.results.csv",
+		Total:      3,
+		Succeeded:  1,
+		Failed:     1,
+		Errors:     1,
+	}}, reports)
+
+	obj, err := store.Get("results/incoming/2026-10 run.results.csv")
+	require.NoError(t, err)
+	require.Equal(t, "text/csv", obj.ContentType)
+	require.Equal(t, `line,number,amount,ref,status,code,message
+2,0788000001,1000,ref-0788000001,success,,
+3,0788000002,500,ref-0788000002,failed,,
+4,,700,,error,VALIDATION_ERROR,number is required
+`, string(obj.Body))
+
+	reports, err = ingester.Handle(context.Background(), event)
+	require.NoError(t, err)
+	require.True(t, reports[0].AlreadyProcessed)
+	require.Equal(t, 2, charges, "a redelivered notification charges nothing")
+}
+
+func TestS3IngestAnswersUnreadableFiles(t *testing.T) {
+	store := objectstore.NewMemoryStore()
+	require.NoError(t, store.Put(context.Background(), "bad.jsonl", "application/x-ndjson", nil))
+	ingester, err := NewS3IngestHandler(NewProcessor(&fakeClient{}), store, "billing")
+	require.NoError(t, err)
+
+	reports, err := ingester.Handle(context.Background(), s3Event("billing", "bad.jsonl"))
+	require.NoError(t, err)
+	require.Equal(t, 1, reports[0].Errors)
+	obj, err := store.Get("results/bad.results.jsonl")
+	require.NoError(t, err)
+	require.JSONEq(t, `{"line":0,"status":"error","code":"VALIDATION_ERROR","message":"instructions file has no rows"}`, string(obj.Body))
+
+	_, err = ingester.Handle(context.Background(), s3Event("billing", "missing.csv"))
+	require.ErrorIs(t, err, objectstore.ErrNotFound)
+}
+
+func TestS3IngestDryRunReportsErrorsWithoutCharging(t *testing.T) {
+	client := &fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			t.Fatal("a dry run must not charge")
+			return nil, nil
+		},
+	}
+	store := objectstore.NewMemoryStore()
+	require.NoError(t, store.Put(context.Background(), "incoming/dry-run/2026-10.csv", "text/csv", []byte(
+		"number,amount,plan,external_id,currency\n0788000001,1000,gold,A-1,\n0788000002,1000,gold,A-1,\n0788000003,1000,gold,A-3,rwf\n")))
+	ingester, err := NewS3IngestHandler(NewProcessor(client), store, "billing")
+	require.NoError(t, err)
+
+	reports, err := ingester.Handle(context.Background(), s3Event("billing", "incoming/dry-run/2026-10.csv"))
+	require.NoError(t, err)
+	require.Equal(t, []IngestReport{{
+		Key:        "incoming/dry-run/2026-10.csv",
+		ResultsKey: "results/incoming/dry-run/2026-10.results.csv",
+		DryRun:     true,
+		Total:      3,
+		Errors:     2,
+	}}, reports)
+	obj, err := store.Get("results/incoming/dry-run/2026-10.results.csv")
+	require.NoError(t, err)
+	require.Equal(t, `line,number,amount,ref,status,code,message
+3,0788000002,1000,,error,VALIDATION_ERROR,"duplicate external_id ""A-1"" (first on line 2)"
+4,0788000003,1000,,error,VALIDATION_ERROR,"invalid currency ""rwf"""
+`, string(obj.Body))
+}
@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"context"
+	"runtime"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/berniyo/paypack-lambda/internal/metrics"
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+var (
+	// processStart approximates when the execution environment initialised.
+	processStart = time.Now()
+	// warm flips on the first invocation in this execution environment.
+	warm atomic.Bool
+)
+
+// invocation measures one entry-point call: duration, allocation, GC activity and Paypack
+// connection timings, reported together with its outcome when end is called.
+type invocation struct {
+	p           *Processor
+	entry       string
+	correlation string
+	start       time.Time
+	cold        bool
+	conns       *paypack.ConnStats
+	before      runtime.MemStats
+}
+
+// beginInvocation starts measuring an entry point. entry names it in metrics and logs
+// ("cashin", "webhook", "retries", ...). Cold starts also record the init duration.
+func (p *Processor) beginInvocation(ctx context.Context, entry string) (context.Context, *invocation) {
+	inv := &invocation{p: p, entry: entry, correlation: correlationID(ctx), start: time.Now(), cold: !warm.Swap(true)}
+	p.metrics.Count(metrics.Invocations, 1, metrics.T("cold_start", boolTag(inv.cold)))
+	if inv.cold {
+		initDuration := inv.start.Sub(processStart)
+		p.metrics.Observe(metrics.InitDuration, initDuration.Seconds())
+		p.logf(ctx, "cold start: first invocation %s after init", initDuration.Round(time.Millisecond))
+	}
+	runtime.ReadMemStats(&inv.before)
+	ctx, inv.conns = paypack.WithConnStats(ctx)
+	return ctx, inv
+}
+
+// end records the invocation's outcome (a status, or an error code) and logs its summary.
+// ref names the payment the invocation was about, when there is one.
+func (inv *invocation) end(outcome, ref string) {
+	p := inv.p
+	elapsed := time.Since(inv.start)
+	var after runtime.MemStats
+	runtime.ReadMemStats(&after)
+	allocated := after.TotalAlloc - inv.before.TotalAlloc
+
+	tags := []metrics.Tag{metrics.T("entry", inv.entry), metrics.T("outcome", outcome)}
+	p.metrics.Observe(metrics.InvocationDuration, elapsed.Seconds(), tags...)
+	p.metrics.Observe(metrics.InvocationAllocated, float64(allocated), metrics.T("entry", inv.entry))
+	p.metrics.Observe(metrics.HeapInUse, float64(after.HeapInuse))
+
+	t := inv.conns.Timings()
+	if ref == "" {
+		ref = "-"
+	}
+	p.logger.Printf(correlated(inv.correlation, "invocation entry=%s ref=%s outcome=%s cold_start=%t duration=%s allocated=%s heap=%s gc=%d paypack_requests=%d reused=%d dns=%s connect=%s tls=%s wait=%s"),
+		inv.entry, ref, outcome, inv.cold, elapsed.Round(time.Millisecond),
+		mebibytes(allocated), mebibytes(after.HeapInuse), after.NumGC-inv.before.NumGC,
+		t.Requests, t.Reused, t.DNS.Round(time.Millisecond), t.Connect.Round(time.Millisecond),
+		t.TLS.Round(time.Millisecond), t.Wait.Round(time.Millisecond))
+}
+
+// entryName names a Handle invocation by its action, folding unknown actions together so
+// metric series stay bounded.
+func entryName(action string) string {
+	switch action {
+	case "":
+		return ActionCashIn
+	case ActionCashIn, ActionCustomerGet, ActionCustomerPut, ActionCustomerDelete, ActionReplay,
+		ActionApprove, ActionReject, ActionResume, ActionExport, ActionPaymentLink, ActionQRCode,
+		ActionBalance, ActionAuditVerify, ActionBatch, ActionStatus, ActionRefund, ActionCashOut, ActionQuote,
+		ActionBulkStatus, ActionErase:
+		return action
+	default:
+		return "unknown"
+	}
+}
+
+// errorOutcome names a failed invocation by its error code, or "ok".
+func errorOutcome(err error) string {
+	if err == nil {
+		return "ok"
+	}
+	if code := CodeOf(err); code != "" {
+		return "error:" + string(code)
+	}
+	return "error"
+}
+
+func mebibytes(b uint64) string {
+	return strconv.FormatFloat(float64(b)/(1<<20), 'f', 1, 64) + "MiB"
+}
+
+func boolTag(b bool) string {
+	if b {
+		return "true"
+	}
+	return "false"
+}
@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/berniyo/paypack-lambda/internal/metrics"
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+func TestInvocationsAreTaggedWithColdStart(t *testing.T) {
+	warm.Store(false)
+	rec := &recordingMetrics{}
+	p := NewProcessor(&fakeClient{}, WithMetrics(rec))
+
+	for range 3 {
+		_, err := p.Handle(context.Background(), SubscriptionEvent{Action: ActionStatus})
+		require.Error(t, err)
+	}
+
+	require.Equal(t, 1.0, rec.counts[metrics.Invocations+" cold_start=true"])
+	require.Equal(t, 2.0, rec.counts[metrics.Invocations+" cold_start=false"])
+	require.Contains(t, rec.observed, metrics.InitDuration)
+}
+
+func TestInvocationRecordsDurationAndOutcome(t *testing.T) {
+	client := &fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: "abc"}, nil
+		},
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: ref, Status: "success"}, nil
+		},
+	}
+	var logs bytes.Buffer
+	rec := &recordingMetrics{}
+	p := NewProcessor(client, WithMetrics(rec), WithLogger(log.New(&logs, "", 0)))
+
+	_, err := p.Handle(context.Background(), SubscriptionEvent{Number: "2507", Amount: 100})
+	require.NoError(t, err)
+	_, err = p.Handle(context.Background(), SubscriptionEvent{Action: "bogus"})
+	require.Error(t, err)
+
+	require.Contains(t, rec.observed, metrics.InvocationDuration+" entry=cashin outcome=success")
+	require.Contains(t, rec.observed, metrics.InvocationDuration+" entry=unknown outcome=error:VALIDATION_ERROR")
+	require.Contains(t, rec.observed, metrics.InvocationAllocated+" entry=cashin")
+	require.Contains(t, logs.String(), "invocation entry=cashin ref=abc outcome=success")
+}
@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/berniyo/paypack-lambda/internal/ledger"
+)
+
+// WithLedger journals charge, fee and tax lines for every successful transaction.
+func WithLedger(store ledger.Store) Option {
+	return func(p *Processor) {
+		p.ledger = store
+	}
+}
+
+func (p *Processor) recordLedger(ctx context.Context, resp *SubscriptionResponse) {
+	if p.ledger == nil || !resp.Found || resp.Status != "success" || resp.Transaction == nil {
+		return
+	}
+
+	txn := resp.Transaction
+	entries := []ledger.Entry{{
+		Ref:         resp.Reference,
+		Kind:        ledger.KindCharge,
+		Amount:      txn.Amount,
+		Currency:    txn.Currency,
+		Description: fmt.Sprintf("cashin from %s", resp.Request.Number),
+	}}
+	if txn.Fee != 0 {
+		entries = append(entries, ledger.Entry{
+			Ref:         resp.Reference,
+			Kind:        ledger.KindFee,
+			Amount:      -txn.Fee,
+			Currency:    txn.Currency,
+			Description: "paypack fee",
+		})
+	}
+	if resp.Tax != nil && resp.Tax.Tax != 0 {
+		entries = append(entries, ledger.Entry{
+			Ref:         resp.Reference,
+			Kind:        ledger.KindTax,
+			Amount:      -resp.Tax.Tax,
+			Currency:    txn.Currency,
+			Description: fmt.Sprintf("%s %.2f%%", resp.Tax.Name, resp.Tax.Rate),
+		})
+	}
+
+	if err := p.ledger.Append(ctx, entries...); err != nil {
+		p.logf(ctx, "ledger write failed for ref=%s: %v", resp.Reference, err)
+	}
+}
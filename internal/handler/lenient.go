@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// LenientTypes lists the loosely typed variants DecodeEvent accepts, for producers (PHP and
+// JavaScript ones, mostly) that cannot be made to send the schema's types. Each is off unless
+// set; values that still do not fit are reported as validation errors as usual.
+type LenientTypes struct {
+	// IntegerStrings accepts JSON integers for string fields, so "number": 250788123123 reads
+	// as "250788123123". Floats and exponents are not converted.
+	IntegerStrings bool
+	// NumericStrings accepts decimal strings for number fields, so "amount": "1000.50" reads as
+	// 1000.5. Integer fields only take integer strings.
+	NumericStrings bool
+	// BooleanStrings accepts "true", "false", "1" and "0" for boolean fields.
+	BooleanStrings bool
+}
+
+func (l LenientTypes) any() bool {
+	return l.IntegerStrings || l.NumericStrings || l.BooleanStrings
+}
+
+// WithLenientTypes makes DecodeEvent coerce the given type variants to the event schema's
+// types before validating. Event signatures cover the decoded event, so signing producers
+// sign the coerced values.
+func WithLenientTypes(types LenientTypes) Option {
+	return func(p *Processor) {
+		p.lenientTypes = types
+	}
+}
+
+var (
+	integerLiteral  = regexp.MustCompile(`^-?\d+$`)
+	decimalLiteral  = regexp.MustCompile(`^-?\d+(\.\d+)?$`)
+	unmarshalerType = reflect.TypeFor[json.Unmarshaler]()
+	eventType       = reflect.TypeFor[SubscriptionEvent]()
+)
+
+// coerceEvent rewrites data's loosely typed values to SubscriptionEvent's field types.
+// Documents that are not JSON objects are returned as they are for validation to report.
+func (l LenientTypes) coerceEvent(data []byte) []byte {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return data
+	}
+	if _, ok := doc.(map[string]any); !ok {
+		return data
+	}
+	out, err := json.Marshal(l.coerce(doc, eventType))
+	if err != nil {
+		return data
+	}
+	return out
+}
+
+// coerce converts v, decoded with UseNumber, towards t.
+func (l LenientTypes) coerce(v any, t reflect.Type) any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	// Types with their own decoding (times, raw messages) are left to it; events decode as
+	// their struct.
+	if t != eventType && (t.Implements(unmarshalerType) || reflect.PointerTo(t).Implements(unmarshalerType)) {
+		return v
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if obj, ok := v.(map[string]any); ok {
+			l.coerceFields(obj, t)
+		}
+	case reflect.Slice, reflect.Array:
+		if list, ok := v.([]any); ok {
+			for i, item := range list {
+				list[i] = l.coerce(item, t.Elem())
+			}
+		}
+	case reflect.Map:
+		if obj, ok := v.(map[string]any); ok {
+			for k, inner := range obj {
+				obj[k] = l.coerce(inner, t.Elem())
+			}
+		}
+	case reflect.String:
+		if n, ok := v.(json.Number); ok && l.IntegerStrings && integerLiteral.MatchString(string(n)) {
+			return string(n)
+		}
+	case reflect.Float32, reflect.Float64:
+		if s, ok := v.(string); ok && l.NumericStrings && decimalLiteral.MatchString(strings.TrimSpace(s)) {
+			return json.Number(strings.TrimSpace(s))
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if s, ok := v.(string); ok && l.NumericStrings && integerLiteral.MatchString(strings.TrimSpace(s)) {
+			return json.Number(strings.TrimSpace(s))
+		}
+	case reflect.Bool:
+		if s, ok := v.(string); ok && l.BooleanStrings {
+			switch strings.ToLower(strings.TrimSpace(s)) {
+			case "true", "1":
+				return true
+			case "false", "0":
+				return false
+			}
+		}
+	}
+	return v
+}
+
+// coerceFields coerces the members of obj that are fields of struct type t, including the
+// fields of embedded structs.
+func (l LenientTypes) coerceFields(obj map[string]any, t reflect.Type) {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				l.coerceFields(obj, embedded)
+				continue
+			}
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if inner, ok := obj[name]; ok {
+			obj[name] = l.coerce(inner, field.Type)
+		}
+	}
+}
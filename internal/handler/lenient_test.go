@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const looselyTypedEvent = `{"number":250788123123,"amount":"1000.50","amount_minor":"100050","debug":"true",` +
+	`"max_attempts":" 4 ","metadata":{"plan":7,"trial":"false"},` +
+	`"items":[{"number":250788000001,"amount":"5","debug":"0"}],"split":{"recipients":[{"number":250788000002,"percent":"10"}]}}`
+
+func TestDecodeEventRejectsLooseTypesByDefault(t *testing.T) {
+	_, err := NewProcessor(&fakeClient{}).DecodeEvent([]byte(looselyTypedEvent))
+	require.Equal(t, CodeValidation, CodeOf(err))
+}
+
+func TestDecodeEventCoercesConfiguredVariants(t *testing.T) {
+	processor := NewProcessor(&fakeClient{}, WithLenientTypes(LenientTypes{IntegerStrings: true, NumericStrings: true, BooleanStrings: true}))
+	event, err := processor.DecodeEvent([]byte(looselyTypedEvent))
+	require.NoError(t, err)
+	require.Equal(t, "250788123123", event.Number)
+	require.Equal(t, 1000.5, event.Amount)
+	require.Equal(t, int64(100050), event.AmountMinor)
+	require.True(t, event.Debug)
+	require.Equal(t, 4, event.MaxAttempts)
+	require.Equal(t, "250788000001", event.Items[0].Number)
+	require.Equal(t, 5.0, event.Items[0].Amount)
+	require.False(t, event.Items[0].Debug)
+	require.Equal(t, "250788000002", event.Split.Recipients[0].Number)
+	require.Equal(t, 10.0, event.Split.Recipients[0].Percent)
+	// Metadata has no schema types, so it is passed on as sent.
+	require.Equal(t, map[string]any{"plan": 7.0, "trial": "false"}, event.Metadata)
+}
+
+func TestDecodeEventCoercesOnlyWhatIsEnabled(t *testing.T) {
+	processor := NewProcessor(&fakeClient{}, WithLenientTypes(LenientTypes{IntegerStrings: true}))
+	event, err := processor.DecodeEvent([]byte(`{"number":250788123123,"amount":1000}`))
+	require.NoError(t, err)
+	require.Equal(t, "250788123123", event.Number)
+
+	for _, payload := range []string{
+		`{"number":250788123123,"amount":"1000"}`,
+		`{"number":2.50788123123e11,"amount":1000}`,
+		`{"number":"2507","amount":1000,"debug":"yes"}`,
+	} {
+		_, err := processor.DecodeEvent([]byte(payload))
+		require.Equal(t, CodeValidation, CodeOf(err), payload)
+	}
+}
@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Default metadata limits.
+const (
+	defaultMetadataDepth = 3
+	defaultMetadataBytes = 4 << 10
+)
+
+// metadataKeyPattern is what metadata keys, at every level, may look like.
+var metadataKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_.\-]{1,64}$`)
+
+// reservedMetadataKeys are stamped by the processor itself, and dropped from caller metadata
+// so events cannot pose as a billing row, ingest line or payment link.
+var reservedMetadataKeys = map[string]bool{
+	metadataSubscriptionID: true,
+	metadataClient:         true,
+	metadataBillingRow:     true,
+	metadataIngestFile:     true,
+	metadataIngestLine:     true,
+}
+
+// MetadataLimits bounds the metadata an event may carry before it is forwarded to Paypack or
+// persisted. Zero values use the defaults.
+type MetadataLimits struct {
+	// MaxDepth is how deeply objects and arrays may nest, the top level counting as 1
+	// (default 3).
+	MaxDepth int
+	// MaxBytes caps the JSON encoding of the metadata (default 4 KiB).
+	MaxBytes int
+}
+
+// WithMetadataLimits replaces the default metadata limits.
+func WithMetadataLimits(limits MetadataLimits) Option {
+	return func(p *Processor) {
+		p.metadataLimits = limits
+	}
+}
+
+// withoutReserved returns metadata less the reserved keys.
+func withoutReserved(metadata map[string]any) map[string]any {
+	for key := range metadata {
+		if reservedMetadataKeys[key] {
+			clean := make(map[string]any, len(metadata))
+			for k, v := range metadata {
+				if !reservedMetadataKeys[k] {
+					clean[k] = v
+				}
+			}
+			return clean
+		}
+	}
+	return metadata
+}
+
+// sanitizeMetadata checks event's metadata against p's limits and key rules, and strips
+// control characters and invalid UTF-8 from its strings, so storage and callback consumers
+// only ever see bounded, printable metadata.
+func (p *Processor) sanitizeMetadata(event *SubscriptionEvent) error {
+	if len(event.Metadata) == 0 {
+		return nil
+	}
+	depth := p.metadataLimits.MaxDepth
+	if depth <= 0 {
+		depth = defaultMetadataDepth
+	}
+	clean, err := sanitizeMetadataValue("metadata", event.Metadata, 1, depth)
+	if err != nil {
+		return err
+	}
+	metadata := clean.(map[string]any)
+
+	limit := p.metadataLimits.MaxBytes
+	if limit <= 0 {
+		limit = defaultMetadataBytes
+	}
+	encoded, err := json.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("encode metadata: %w", err)
+	}
+	if len(encoded) > limit {
+		return fmt.Errorf("metadata is %d bytes, over the %d byte limit", len(encoded), limit)
+	}
+	event.Metadata = metadata
+	return nil
+}
+
+func sanitizeMetadataValue(path string, value any, depth, maxDepth int) (any, error) {
+	switch v := value.(type) {
+	case map[string]any:
+		if depth > maxDepth {
+			return nil, fmt.Errorf("%s nests deeper than %d levels", path, maxDepth)
+		}
+		clean := make(map[string]any, len(v))
+		for key, item := range v {
+			if !metadataKeyPattern.MatchString(key) {
+				return nil, fmt.Errorf("%s has invalid key %q: keys are 1-64 letters, digits, '_', '-' or '.'", path, key)
+			}
+			sanitized, err := sanitizeMetadataValue(path+"."+key, item, depth+1, maxDepth)
+			if err != nil {
+				return nil, err
+			}
+			clean[key] = sanitized
+		}
+		return clean, nil
+	case []any:
+		if depth > maxDepth {
+			return nil, fmt.Errorf("%s nests deeper than %d levels", path, maxDepth)
+		}
+		clean := make([]any, len(v))
+		for i, item := range v {
+			sanitized, err := sanitizeMetadataValue(fmt.Sprintf("%s[%d]", path, i), item, depth+1, maxDepth)
+			if err != nil {
+				return nil, err
+			}
+			clean[i] = sanitized
+		}
+		return clean, nil
+	case string:
+		return printable(v), nil
+	default:
+		return v, nil
+	}
+}
+
+// printable drops invalid UTF-8 and control characters from s.
+func printable(s string) string {
+	s = strings.ToValidUTF8(s, "")
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+func TestMetadataIsSanitizedBeforeCharging(t *testing.T) {
+	client := &fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: "abc"}, nil
+		},
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: ref, Status: "success"}, nil
+		},
+	}
+	processor := NewProcessor(client)
+
+	resp, err := processor.Handle(context.Background(), SubscriptionEvent{
+		Number: "2507",
+		Amount: 1000,
+		Metadata: map[string]any{
+			"plan":        "gold",
+			"note":        "line\nbreak\x00\xff",
+			"billing_row": "inv-1",
+			"tags":        []any{"a", map[string]any{"b": "c"}},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{
+		"plan": "gold",
+		"note": "linebreak",
+		"tags": []any{"a", map[string]any{"b": "c"}},
+	}, resp.Request.Metadata)
+}
+
+func TestMetadataOutsideLimitsIsRejected(t *testing.T) {
+	processor := NewProcessor(&fakeClient{}, WithMetadataLimits(MetadataLimits{MaxDepth: 2, MaxBytes: 64}))
+	for name, metadata := range map[string]map[string]any{
+		"too deep":    {"a": map[string]any{"b": map[string]any{"c": 1}}},
+		"too large":   {"blob": strings.Repeat("x", 100)},
+		"invalid key": {"$where": "1"},
+		"nested key":  {"a": map[string]any{"bad key": 1}},
+	} {
+		_, err := processor.Handle(context.Background(), SubscriptionEvent{Number: "2507", Amount: 1000, Metadata: metadata})
+		require.Equal(t, CodeValidation, CodeOf(err), name)
+	}
+
+	event := SubscriptionEvent{Metadata: map[string]any{"a": map[string]any{"b": 1}}}
+	require.NoError(t, processor.sanitizeMetadata(&event), "two levels are allowed")
+}
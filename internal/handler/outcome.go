@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/berniyo/paypack-lambda/internal/outcome"
+	"github.com/berniyo/paypack-lambda/internal/txcache"
+)
+
+// WithOutcomeStore records each confirmed outcome under its idempotency key (the event's
+// correlation_id, per retry attempt; <batch id>-<index> for batch items without one) before
+// the callback, ledger entry and other side effects.
+// A duplicate invocation finishing the same key returns the recorded result instead and
+// leaves the side effects to the winner.
+func WithOutcomeStore(store outcome.Store) Option {
+	return func(p *Processor) {
+		p.outcomes = store
+	}
+}
+
+// claimOutcome records resp as the outcome of its idempotency key and reports whether this
+// invocation won. When another invocation recorded the key first, resp becomes its result.
+// Outcomes not yet confirmed, and events without a key, are not recorded.
+func (p *Processor) claimOutcome(ctx context.Context, resp *SubscriptionResponse) bool {
+	key := idempotencyKey(ctx, resp.Request)
+	if p.outcomes == nil || key == "" || !resp.Found || !txcache.Terminal(resp.Status) {
+		return true
+	}
+	result, err := json.Marshal(resp)
+	if err != nil {
+		p.logf(ctx, "encode outcome for ref=%s: %v", resp.Reference, err)
+		return true
+	}
+	recorded, won, err := p.outcomes.Record(ctx, key, result)
+	if err != nil {
+		// Without the store the outcome cannot be deduplicated; reporting it beats losing it.
+		p.logf(ctx, "record outcome for ref=%s: %v", resp.Reference, err)
+		return true
+	}
+	if won {
+		return true
+	}
+	var winner SubscriptionResponse
+	if err := json.Unmarshal(recorded, &winner); err != nil {
+		p.logf(ctx, "decode recorded outcome for ref=%s: %v", resp.Reference, err)
+		return true
+	}
+	p.logf(ctx, "outcome for key=%s already recorded as %s; returning it", key, winner.Status)
+	winner.Replayed = true
+	*resp = winner
+	return false
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/berniyo/paypack-lambda/internal/outcome"
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+func TestDuplicateInvocationsConvergeOnTheRecordedOutcome(t *testing.T) {
+	refs := []string{"abc", "def"}
+	client := &fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			ref := refs[0]
+			refs = refs[1:]
+			return &paypack.Transaction{Ref: ref}, nil
+		},
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: ref, Status: "success"}, nil
+		},
+	}
+	callback := &fakeCallback{}
+	p := NewProcessor(client, WithPollInterval(time.Millisecond), WithCallbackSender(callback), WithOutcomeStore(outcome.NewMemoryStore()))
+	event := SubscriptionEvent{Number: "2507", Amount: 1000, CorrelationID: "order-42"}
+
+	first, err := p.Handle(context.Background(), event)
+	require.NoError(t, err)
+	second, err := p.Handle(context.Background(), event)
+	require.NoError(t, err)
+
+	require.Equal(t, "abc", first.Reference)
+	require.Equal(t, "abc", second.Reference, "the losing invocation returns the winner's result")
+	require.True(t, second.Replayed)
+	require.Len(t, callback.calls, 1, "only the winner delivers the callback")
+
+	// A retry attempt is a new charge with its own key.
+	refs = []string{"ghi"}
+	event.RetryAttempt = 1
+	retried, err := p.Handle(context.Background(), event)
+	require.NoError(t, err)
+	require.Equal(t, "ghi", retried.Reference)
+	require.False(t, retried.Replayed)
+}
+
+func TestBatchItemsRecordOutcomesOfTheirOwn(t *testing.T) {
+	client := &fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: "ref-" + number}, nil
+		},
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: ref, Status: "success"}, nil
+		},
+	}
+	callback := &fakeCallback{}
+	p := NewProcessor(client, WithPollInterval(time.Millisecond), WithCallbackSender(callback),
+		WithOutcomeStore(outcome.NewMemoryStore()), WithBatchConcurrency(1))
+
+	items := []SubscriptionEvent{{Number: "1", Amount: 100}, {Number: "2", Amount: 100}, {Number: "3", Amount: 100}}
+	resp, err := p.Handle(context.Background(), SubscriptionEvent{Action: ActionBatch, CorrelationID: "batch-1", Items: items})
+	require.NoError(t, err)
+
+	refs := make(map[string]bool)
+	for _, result := range resp.Batch.Results {
+		require.Equal(t, "success", result.Status)
+		refs[result.Ref] = true
+	}
+	require.Len(t, refs, len(items), "every item keeps its own charge")
+	require.Len(t, callback.calls, len(items), "and delivers its own callback")
+	for _, call := range callback.calls {
+		require.False(t, call.Replayed)
+	}
+}
@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/berniyo/paypack-lambda/internal/subscription"
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+// Metadata keys stamped on payment links so the confirming webhook can be tied back to the
+// subscription and client that requested the link.
+const (
+	metadataSubscriptionID = "subscription_id"
+	metadataClient         = "client"
+)
+
+// PaymentLinkCreator is implemented by payment clients that support hosted checkout links.
+type PaymentLinkCreator interface {
+	CreatePaymentLink(ctx context.Context, req paypack.PaymentLinkRequest) (*paypack.PaymentLink, error)
+}
+
+// PaymentLinkRecorder is implemented by subscription lifecycles that track outstanding links.
+type PaymentLinkRecorder interface {
+	AttachPaymentLink(ctx context.Context, id string, link subscription.PaymentLink) (*subscription.Subscription, error)
+}
+
+// handlePaymentLink creates a hosted payment link instead of pushing a USSD prompt. The
+// outcome arrives later through the webhook handler, which recognises the link's metadata.
+func (p *Processor) handlePaymentLink(ctx context.Context, event SubscriptionEvent) (SubscriptionResponse, error) {
+	creator, ok := p.client.(PaymentLinkCreator)
+	if !ok {
+		return SubscriptionResponse{}, errors.New("payment client does not support payment links")
+	}
+	event.Currency = normalizeCurrency(event.Currency)
+	if event.Amount <= 0 {
+		return SubscriptionResponse{}, withCode(CodeValidation, errors.New("amount must be positive"))
+	}
+	if event.Currency != "" && !paypack.ValidCurrencyCode(event.Currency) {
+		return SubscriptionResponse{}, withCode(CodeValidation, fmt.Errorf("invalid currency %q", event.Currency))
+	}
+
+	var recorder PaymentLinkRecorder
+	if event.SubscriptionID != "" {
+		if recorder, ok = p.lifecycle.(PaymentLinkRecorder); !ok {
+			return SubscriptionResponse{}, errors.New("subscription lifecycle does not store payment links")
+		}
+	}
+
+	amount, currency, conversion, err := p.chargeAmount(ctx, event)
+	if err != nil {
+		return SubscriptionResponse{}, err
+	}
+
+	metadata := make(map[string]any, len(event.Metadata)+2)
+	for k, v := range event.Metadata {
+		metadata[k] = v
+	}
+	req := paypack.PaymentLinkRequest{Amount: amount, Currency: currency, Metadata: metadata}
+	if event.SubscriptionID != "" {
+		metadata[metadataSubscriptionID] = event.SubscriptionID
+		req.Description = "Subscription " + event.SubscriptionID
+	}
+	if event.Client != "" {
+		metadata[metadataClient] = event.Client
+	}
+
+	link, err := creator.CreatePaymentLink(ctx, req)
+	if err != nil {
+		return SubscriptionResponse{}, withCode(paypackCode(err), fmt.Errorf("create payment link: %w", err))
+	}
+	p.logf(ctx, "payment link %s created amount=%.2f currency=%s", link.ID, link.Amount, link.Currency)
+
+	resp := SubscriptionResponse{
+		Status:      "pending",
+		Message:     "awaiting payment through link",
+		Request:     event,
+		Conversion:  conversion,
+		PaymentLink: link,
+	}
+	if recorder != nil {
+		sub, err := recorder.AttachPaymentLink(ctx, event.SubscriptionID, subscription.PaymentLink{
+			ID:        link.ID,
+			URL:       link.URL,
+			Amount:    link.Amount,
+			Currency:  link.Currency,
+			ExpiresAt: link.ExpiresAt,
+		})
+		if err != nil {
+			return SubscriptionResponse{}, fmt.Errorf("store payment link on subscription %s: %w", event.SubscriptionID, err)
+		}
+		resp.Subscription = sub
+	}
+	return resp, nil
+}
+
+// requestFromMetadata rebuilds the originating request fields stamped on a transaction's
+// metadata by handlePaymentLink.
+func requestFromMetadata(txn paypack.Transaction) SubscriptionEvent {
+	event := SubscriptionEvent{
+		Number:   txn.PayerNumber(),
+		Amount:   txn.Amount,
+		Currency: txn.Currency,
+		Metadata: txn.Metadata,
+	}
+	if id, ok := txn.Metadata[metadataSubscriptionID].(string); ok {
+		event.SubscriptionID = id
+	}
+	if client, ok := txn.Metadata[metadataClient].(string); ok {
+		event.Client = client
+	}
+	return event
+}
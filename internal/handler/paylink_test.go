@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/require"
+
+	"github.com/berniyo/paypack-lambda/internal/subscription"
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+type linkClient struct {
+	fakeClient
+	lastLink paypack.PaymentLinkRequest
+}
+
+func (l *linkClient) CreatePaymentLink(ctx context.Context, req paypack.PaymentLinkRequest) (*paypack.PaymentLink, error) {
+	l.lastLink = req
+	return &paypack.PaymentLink{ID: "lnk-1", URL: "https://pay.example/lnk-1", Amount: req.Amount, Currency: req.Currency}, nil
+}
+
+func TestPaymentLinkConfirmedByWebhook(t *testing.T) {
+	ctx := context.Background()
+	subs := subscription.NewService(subscription.NewMemoryStore())
+	_, err := subs.Create(ctx, subscription.Subscription{ID: "sub-1", Number: "2507", Amount: 1000})
+	require.NoError(t, err)
+
+	client := &linkClient{}
+	cb := &fakeCallback{}
+	processor := NewProcessor(client, WithSubscriptions(subs), WithCallbackSender(cb))
+
+	resp, err := processor.Handle(ctx, SubscriptionEvent{
+		Action:         ActionPaymentLink,
+		Amount:         1000,
+		Client:         "acme",
+		SubscriptionID: "sub-1",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "pending", resp.Status)
+	require.Equal(t, "https://pay.example/lnk-1", resp.PaymentLink.URL)
+	require.Equal(t, "lnk-1", resp.Subscription.PaymentLink.ID)
+	require.Equal(t, "RWF", client.lastLink.Currency)
+	require.Equal(t, "sub-1", client.lastLink.Metadata["subscription_id"])
+	require.Empty(t, cb.calls)
+
+	body := `{"event_id":"evt-9","event_kind":"transaction:processed","data":{"ref":"xyz","kind":"CASHIN",` +
+		`"amount":1000,"client":"2507","status":"successful","metadata":{"subscription_id":"sub-1","client":"acme"}}}`
+	hook, err := NewWebhookHandler(processor, "").Handle(ctx, events.APIGatewayV2HTTPRequest{Body: body})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, hook.StatusCode)
+
+	require.Len(t, cb.calls, 1)
+	require.Equal(t, "acme", cb.calls[0].Request.Client)
+	sub, err := subs.Get(ctx, "sub-1")
+	require.NoError(t, err)
+	require.Equal(t, subscription.StatusActive, sub.Status)
+	require.Equal(t, "xyz", sub.LastRef)
+	require.Nil(t, sub.PaymentLink)
+}
+
+func TestPaymentLinkRequiresSupportingClient(t *testing.T) {
+	processor := NewProcessor(&fakeClient{})
+
+	_, err := processor.Handle(context.Background(), SubscriptionEvent{Action: ActionPaymentLink, Amount: 1000})
+	require.EqualError(t, err, "payment client does not support payment links")
+}
@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/berniyo/paypack-lambda/internal/checkpoint"
+	"github.com/berniyo/paypack-lambda/internal/ledger"
+	"github.com/berniyo/paypack-lambda/internal/metrics"
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+// WithPayoutLimits bounds the amount of a single cashout event. A zero bound is not enforced.
+// They are separate from any cash-in checks, since paying agents moves money out of the
+// merchant wallet.
+func WithPayoutLimits(minAmount, maxAmount float64) Option {
+	return func(p *Processor) {
+		p.payoutMin = max(minAmount, 0)
+		p.payoutMax = max(maxAmount, 0)
+	}
+}
+
+// validatePayout checks a cashout event. Payouts are paid from the wallet as they are, so
+// there is no conversion: the currency must be the settlement currency.
+func (p *Processor) validatePayout(event SubscriptionEvent) error {
+	if err := validateEvent(event); err != nil {
+		return err
+	}
+	if event.Currency != "" && event.Currency != p.settlementCurrency {
+		return fmt.Errorf("payouts are paid in %s, not %s", p.settlementCurrency, event.Currency)
+	}
+	if p.payoutMin > 0 && event.Amount < p.payoutMin {
+		return fmt.Errorf("payout %.2f is below the minimum of %.2f", event.Amount, p.payoutMin)
+	}
+	if p.payoutMax > 0 && event.Amount > p.payoutMax {
+		return fmt.Errorf("payout %.2f exceeds the maximum of %.2f", event.Amount, p.payoutMax)
+	}
+	return nil
+}
+
+// handlePayout pays event.Amount to event.Number, e.g. an agent's commission, and polls until
+// Paypack confirms it. Its callback is a payout.* event. Payout confirmations are not
+// checkpointed; an unconfirmed payout is reported failed with CONFIRMATION_TIMEOUT and can be
+// looked up with the status action.
+func (p *Processor) handlePayout(ctx context.Context, event SubscriptionEvent) (SubscriptionResponse, error) {
+	event.Currency = normalizeCurrency(event.Currency)
+	if err := p.validatePayout(event); err != nil {
+		return SubscriptionResponse{}, withCode(CodeValidation, err)
+	}
+	payer, ok := p.client.(CashOutClient)
+	if !ok {
+		return SubscriptionResponse{}, errors.New("payment client does not support cash-out")
+	}
+	ctx = p.connectionContext(ctx, event)
+
+	p.logf(ctx, "initiating payout for number=%s amount=%.2f", event.Number, event.Amount)
+	out, err := payer.CashOut(idempotencyContext(ctx, event), event.Number, event.Amount)
+	if err != nil {
+		return SubscriptionResponse{}, withCode(paypackCode(err), fmt.Errorf("payout failed: %w", err))
+	}
+	p.logf(ctx, "payout accepted ref=%s; starting polling", out.Ref)
+	p.debitWallet(ctx, out, event.Amount)
+	p.pushStatus(ctx, StatusUpdate{Ref: out.Ref, Stage: StageInitiated, Status: "pending"})
+
+	start := time.Now()
+	cp := &checkpoint.Checkpoint{Ref: out.Ref, StartedAt: start, UpdatedAt: start, Deadline: start.Add(p.confirmTimeout(event))}
+	resp := SubscriptionResponse{Reference: out.Ref, Request: event}
+	txn, err := p.pollTransaction(ctx, cp, out.Provider, event)
+	p.observePoll(start, err)
+	switch {
+	case err == nil:
+		resp.Status = txn.Status
+		resp.Found = true
+		resp.Transaction = txn
+	case errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) || errors.Is(err, errPollAttemptsExhausted):
+		resp.Status = "failed"
+		resp.Message = "payout not confirmed before the deadline"
+		resp.Code = CodeConfirmationTimeout
+		resp.Attempts = cp.Attempts
+	default:
+		return SubscriptionResponse{}, withCode(paypackCode(err), err)
+	}
+
+	p.finishPayout(ctx, &resp)
+	return resp, nil
+}
+
+// finishPayout records a payout outcome and delivers its callback. The cash-in steps (fees,
+// subscriptions, receipts, splits) do not apply.
+func (p *Processor) finishPayout(ctx context.Context, resp *SubscriptionResponse) {
+	p.metrics.Count(metrics.PayoutResults, 1, metrics.T("status", resp.Status))
+	resp.CorrelationID = orDefault(resp.CorrelationID, correlationID(ctx))
+	if !p.claimOutcome(ctx, resp) {
+		return
+	}
+	if p.ledger != nil && resp.Found && resp.Status == "success" {
+		err := p.ledger.Append(ctx, ledger.Entry{
+			Ref:         resp.Reference,
+			Kind:        ledger.KindPayout,
+			Amount:      -resp.Transaction.Amount,
+			Currency:    orDefault(resp.Transaction.Currency, paypack.DefaultCurrency),
+			Description: fmt.Sprintf("payout to %s", resp.Request.Number),
+		})
+		if err != nil {
+			p.logf(ctx, "ledger write failed for payout ref=%s: %v", resp.Reference, err)
+		}
+	}
+	classifyFailure(resp)
+	if err := p.emitCallback(ctx, *resp); err != nil && resp.Code == "" {
+		resp.Code = CodeCallbackFailed
+		classifyFailure(resp)
+	}
+	p.recordAudit(ctx, auditOutcome, resp.Reference, resp)
+	p.pushOutcome(ctx, *resp)
+}
+
+// isPayout reports whether resp is the outcome of a cashout event.
+func isPayout(resp SubscriptionResponse) bool {
+	return resp.Request.Action == ActionCashOut
+}
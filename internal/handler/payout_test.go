@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/berniyo/paypack-lambda/internal/ledger"
+	"github.com/berniyo/paypack-lambda/internal/split"
+	"github.com/berniyo/paypack-lambda/pkg/envelope"
+)
+
+func TestProcessorPaysOutAndConfirms(t *testing.T) {
+	client := newPayoutClient()
+	journal := ledger.NewMemoryStore()
+	callback := &fakeCallback{}
+	processor := NewProcessor(
+		client,
+		WithPollInterval(5*time.Millisecond),
+		WithLedger(journal),
+		WithCallbackSender(callback),
+	)
+
+	resp, err := processor.Handle(context.Background(), SubscriptionEvent{Action: ActionCashOut, Number: "0788000001", Amount: 1000})
+	require.NoError(t, err)
+	require.Equal(t, "out-1", resp.Reference)
+	require.Equal(t, "success", resp.Status)
+	require.True(t, resp.Found)
+	require.Equal(t, []split.Leg{{Number: "0788000001", Amount: 1000}}, client.payouts)
+
+	require.Len(t, callback.calls, 1)
+	require.Equal(t, envelope.TypePayoutSucceeded, callbackEventType(callback.calls[0]))
+
+	entries, err := journal.List(context.Background(), "out-1")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, ledger.KindPayout, entries[0].Kind)
+	require.InDelta(t, -1000, entries[0].Amount, 0.001)
+}
+
+func TestProcessorEnforcesPayoutLimits(t *testing.T) {
+	client := newPayoutClient()
+	processor := NewProcessor(client, WithPayoutLimits(500, 50000))
+
+	for _, event := range []SubscriptionEvent{
+		{Action: ActionCashOut, Number: "0788000001", Amount: 100},
+		{Action: ActionCashOut, Number: "0788000001", Amount: 60000},
+		{Action: ActionCashOut, Number: "0788000001", Amount: 1000, Currency: "usd"},
+		{Action: ActionCashOut, Amount: 1000},
+	} {
+		_, err := processor.Handle(context.Background(), event)
+		require.Equal(t, CodeValidation, CodeOf(err), "%+v", event)
+	}
+	require.Empty(t, client.payouts)
+}
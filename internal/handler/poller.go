@@ -0,0 +1,271 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/berniyo/paypack-lambda/internal/txcache"
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+// Poller waits for a cash-in or payout to be confirmed. The processor bounds ctx by the
+// confirmation deadline; Poll returns the confirmed transaction or ctx's error.
+type Poller interface {
+	Poll(ctx context.Context, target PollTarget) (*paypack.Transaction, error)
+}
+
+// PollTarget is the transaction a Poller waits for.
+type PollTarget struct {
+	Ref       string
+	Provider  string
+	StartedAt time.Time
+	// Interval, when set, is the wait between checks the event asked for; pollers use it in
+	// place of their own.
+	Interval time.Duration
+	// Find looks the transaction up, from the transaction cache first; an error matching
+	// paypack.ErrTransactionNotFound means it is not confirmed yet.
+	Find func(ctx context.Context) (*paypack.Transaction, error)
+	// Waiting records a check that found nothing yet, so checkpoints and status pushes keep
+	// up. Pollers call it once per unsuccessful check.
+	Waiting func(ctx context.Context)
+}
+
+// WithPoller replaces how confirmations are awaited. The default polls Paypack every
+// WithPollInterval, adapting to each provider's observed latency under
+// WithAdaptivePolling.
+func WithPoller(poller Poller) Option {
+	return func(p *Processor) {
+		if poller != nil {
+			p.poller = poller
+		}
+	}
+}
+
+// errPollAttemptsExhausted stops polling once max_attempts (or PollBounds.MaxAttempts) lookups
+// found nothing.
+var errPollAttemptsExhausted = errors.New("poll attempts exhausted")
+
+// errUpstreamUnavailable stops polling once lookups failed WithUpstreamErrorLimit times in a
+// row because Paypack was unavailable.
+var errUpstreamUnavailable = errors.New("paypack unavailable during confirmation")
+
+// defaultUpstreamErrorLimit is how many consecutive lookups may fail before polling gives up.
+const defaultUpstreamErrorLimit = 3
+
+// WithUpstreamErrorLimit sets how many status lookups in a row may fail with Paypack
+// unavailable (5xx, 429, network errors) before polling stops early instead of spending the
+// rest of the confirmation window on a down API (default 3). Fewer failures are retried at
+// the next poll. With WithCheckpoints the confirmation is left to the reconciler.
+func WithUpstreamErrorLimit(n int) Option {
+	return func(p *Processor) {
+		if n > 0 {
+			p.upstreamErrorLimit = n
+		}
+	}
+}
+
+// PollBounds limits the poll_interval_seconds and max_attempts events may ask for.
+type PollBounds struct {
+	// MinInterval and MaxInterval clamp poll_interval_seconds (defaults 1s and 1m).
+	MinInterval time.Duration
+	MaxInterval time.Duration
+	// MaxAttempts stops polling after this many status lookups found nothing, even with time
+	// left before the confirmation deadline, keeping Paypack API usage within contract limits.
+	// Events may ask for fewer with max_attempts, not more. Zero leaves polling bounded by
+	// time alone.
+	MaxAttempts int
+}
+
+// WithPollBounds sets the bounds of per-event poll tuning.
+func WithPollBounds(bounds PollBounds) Option {
+	return func(p *Processor) {
+		p.pollBounds = bounds
+	}
+}
+
+// pollTuning returns the interval and attempt limit event asked for, within p's bounds; zero
+// values use the defaults.
+func (p *Processor) pollTuning(event SubscriptionEvent) (time.Duration, int) {
+	var interval time.Duration
+	if event.PollIntervalSeconds > 0 {
+		lo := durationOr(p.pollBounds.MinInterval, time.Second)
+		hi := max(durationOr(p.pollBounds.MaxInterval, time.Minute), lo)
+		interval = min(max(time.Duration(event.PollIntervalSeconds)*time.Second, lo), hi)
+	}
+	attempts := event.MaxAttempts
+	if limit := p.pollBounds.MaxAttempts; limit > 0 && (attempts == 0 || attempts > limit) {
+		attempts = limit
+	}
+	return interval, attempts
+}
+
+// attemptsExhausted reports whether lookups have used up event's max_attempts.
+func (p *Processor) attemptsExhausted(event SubscriptionEvent, lookups int) bool {
+	_, limit := p.pollTuning(event)
+	return limit > 0 && lookups >= limit
+}
+
+func durationOr(d, fallback time.Duration) time.Duration {
+	if d > 0 {
+		return d
+	}
+	return fallback
+}
+
+// pollLoop checks target until check confirms it, waiting delay(attempt) before each check;
+// attempt counts the checks already made.
+func pollLoop(ctx context.Context, target PollTarget, delay func(attempt int) time.Duration, check func(context.Context) (*paypack.Transaction, error)) (*paypack.Transaction, error) {
+	for attempt := 0; ; attempt++ {
+		if d := delay(attempt); d > 0 {
+			timer := time.NewTimer(d)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return nil, ctx.Err()
+			case <-timer.C:
+			}
+		}
+		txn, err := check(ctx)
+		if err == nil {
+			return txn, nil
+		}
+		if !errors.Is(err, paypack.ErrTransactionNotFound) {
+			return nil, err
+		}
+		if target.Waiting != nil {
+			target.Waiting(ctx)
+		}
+	}
+}
+
+// adaptivePoller is the default: p.pollInterval, stretched or shortened by pollDelay.
+type adaptivePoller struct{ p *Processor }
+
+func (a adaptivePoller) Poll(ctx context.Context, target PollTarget) (*paypack.Transaction, error) {
+	p := a.p
+	if target.Interval > 0 {
+		return FixedIntervalPoller(target.Interval).Poll(ctx, target)
+	}
+	return pollLoop(ctx, target, func(attempt int) time.Duration {
+		delay := p.pollDelay(target.Provider, attempt, time.Since(target.StartedAt))
+		if attempt == 0 {
+			// An adaptive first wait must still leave room for a lookup before the deadline.
+			if deadline, ok := ctx.Deadline(); ok {
+				delay = max(min(delay, time.Until(deadline)-p.pollInterval), 0)
+			}
+		}
+		return delay
+	}, target.Find)
+}
+
+// FixedIntervalPoller looks the transaction up at once and then every interval.
+func FixedIntervalPoller(interval time.Duration) Poller {
+	return fixedPoller{interval: interval}
+}
+
+type fixedPoller struct{ interval time.Duration }
+
+func (f fixedPoller) Poll(ctx context.Context, target PollTarget) (*paypack.Transaction, error) {
+	interval := durationOr(target.Interval, f.interval)
+	return pollLoop(ctx, target, func(attempt int) time.Duration {
+		if attempt == 0 {
+			return 0
+		}
+		return interval
+	}, target.Find)
+}
+
+// BackoffPoller looks the transaction up at once, then after initial, doubling the wait up
+// to maxDelay, which suits confirmations that are either quick or very slow.
+func BackoffPoller(initial, maxDelay time.Duration) Poller {
+	return backoffPoller{initial: initial, maxDelay: max(maxDelay, initial)}
+}
+
+type backoffPoller struct{ initial, maxDelay time.Duration }
+
+func (b backoffPoller) Poll(ctx context.Context, target PollTarget) (*paypack.Transaction, error) {
+	initial := durationOr(target.Interval, b.initial)
+	return pollLoop(ctx, target, func(attempt int) time.Duration {
+		if attempt == 0 {
+			return 0
+		}
+		return min(initial<<min(attempt-1, 30), max(b.maxDelay, initial))
+	}, target.Find)
+}
+
+// TransactionLister streams transactions; paypack.Client implements it.
+type TransactionLister interface {
+	Transactions(ctx context.Context, opts paypack.ListOptions, fn func(paypack.Transaction) error) error
+}
+
+// EventsPoller watches Paypack's transaction list every interval instead of looking each ref
+// up, and confirms the transaction once it is listed with a final status. The list covers
+// the window since the transaction started, so one page usually answers.
+func EventsPoller(lister TransactionLister, interval time.Duration) Poller {
+	return eventsPoller{lister: lister, interval: interval}
+}
+
+type eventsPoller struct {
+	lister   TransactionLister
+	interval time.Duration
+}
+
+var errListed = errors.New("transaction listed")
+
+func (e eventsPoller) Poll(ctx context.Context, target PollTarget) (*paypack.Transaction, error) {
+	opts := paypack.ListOptions{From: target.StartedAt.Add(-time.Minute)}
+	interval := durationOr(target.Interval, e.interval)
+	return pollLoop(ctx, target, func(attempt int) time.Duration {
+		return interval
+	}, func(ctx context.Context) (*paypack.Transaction, error) {
+		var found *paypack.Transaction
+		err := e.lister.Transactions(ctx, opts, func(txn paypack.Transaction) error {
+			if txn.Ref == target.Ref && txcache.Terminal(txn.Status) {
+				found = &txn
+				return errListed
+			}
+			return nil
+		})
+		if found != nil {
+			return found, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("list transactions: %w", err)
+		}
+		return nil, paypack.ErrTransactionNotFound
+	})
+}
+
+// WebhookWaitPoller waits for the webhook handler to record the outcome in cache, which must
+// be shared with it (Redis), checking every interval without calling Paypack. After
+// fallbackAfter without a webhook it looks the transaction up as well, in case the webhook
+// was lost.
+func WebhookWaitPoller(cache txcache.Store, interval, fallbackAfter time.Duration) Poller {
+	return webhookPoller{cache: cache, interval: interval, fallbackAfter: fallbackAfter}
+}
+
+type webhookPoller struct {
+	cache                   txcache.Store
+	interval, fallbackAfter time.Duration
+}
+
+func (w webhookPoller) Poll(ctx context.Context, target PollTarget) (*paypack.Transaction, error) {
+	interval := durationOr(target.Interval, w.interval)
+	return pollLoop(ctx, target, func(attempt int) time.Duration {
+		return interval
+	}, func(ctx context.Context) (*paypack.Transaction, error) {
+		txn, err := w.cache.Get(ctx, target.Ref)
+		if err == nil {
+			return txn, nil
+		}
+		if !errors.Is(err, txcache.ErrNotFound) {
+			return nil, fmt.Errorf("webhook outcome lookup: %w", err)
+		}
+		if time.Since(target.StartedAt) < w.fallbackAfter {
+			return nil, paypack.ErrTransactionNotFound
+		}
+		return target.Find(ctx)
+	})
+}
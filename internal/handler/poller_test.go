@@ -0,0 +1,239 @@
+package handler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/berniyo/paypack-lambda/internal/checkpoint"
+	"github.com/berniyo/paypack-lambda/internal/txcache"
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+// findAfter returns a target confirmed on the nth lookup.
+func findAfter(n int, lookups *int) PollTarget {
+	return PollTarget{
+		Ref:       "abc",
+		StartedAt: time.Now(),
+		Find: func(ctx context.Context) (*paypack.Transaction, error) {
+			*lookups++
+			if *lookups < n {
+				return nil, paypack.ErrTransactionNotFound
+			}
+			return &paypack.Transaction{Ref: "abc", Status: "success"}, nil
+		},
+	}
+}
+
+func TestFixedAndBackoffPollersRetryUntilFound(t *testing.T) {
+	for name, poller := range map[string]Poller{
+		"fixed":   FixedIntervalPoller(time.Millisecond),
+		"backoff": BackoffPoller(time.Millisecond, 4*time.Millisecond),
+	} {
+		t.Run(name, func(t *testing.T) {
+			var lookups, waits int
+			target := findAfter(4, &lookups)
+			target.Waiting = func(context.Context) { waits++ }
+			txn, err := poller.Poll(context.Background(), target)
+			require.NoError(t, err)
+			require.Equal(t, "abc", txn.Ref)
+			require.Equal(t, 4, lookups)
+			require.Equal(t, 3, waits)
+		})
+	}
+}
+
+func TestPollerStopsAtDeadline(t *testing.T) {
+	var lookups int
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := FixedIntervalPoller(5*time.Millisecond).Poll(ctx, findAfter(1000, &lookups))
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+type fakeLister struct{ pages [][]paypack.Transaction }
+
+func (f *fakeLister) Transactions(ctx context.Context, opts paypack.ListOptions, fn func(paypack.Transaction) error) error {
+	page := f.pages[0]
+	if len(f.pages) > 1 {
+		f.pages = f.pages[1:]
+	}
+	for _, txn := range page {
+		if err := fn(txn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestEventsPollerWaitsForAFinalStatus(t *testing.T) {
+	lister := &fakeLister{pages: [][]paypack.Transaction{
+		{{Ref: "other", Status: "success"}},
+		{{Ref: "abc", Status: "pending"}},
+		{{Ref: "abc", Status: "failed"}},
+	}}
+	var waits int
+	txn, err := EventsPoller(lister, time.Millisecond).Poll(context.Background(), PollTarget{
+		Ref:       "abc",
+		StartedAt: time.Now(),
+		Waiting:   func(context.Context) { waits++ },
+	})
+	require.NoError(t, err)
+	require.Equal(t, "failed", txn.Status)
+	require.Equal(t, 2, waits)
+}
+
+func TestWebhookWaitPollerUsesCacheThenFallsBack(t *testing.T) {
+	cache := txcache.NewMemoryStore(0)
+	var lookups int
+	target := findAfter(1, &lookups)
+
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		_ = cache.Put(context.Background(), paypack.Transaction{Ref: "abc", Status: "success"}, time.Minute)
+	}()
+	txn, err := WebhookWaitPoller(cache, time.Millisecond, time.Hour).Poll(context.Background(), target)
+	require.NoError(t, err)
+	require.Equal(t, "success", txn.Status)
+	require.Zero(t, lookups)
+
+	target = findAfter(1, &lookups)
+	target.Ref = "missing"
+	_, err = WebhookWaitPoller(cache, time.Millisecond, 0).Poll(context.Background(), target)
+	require.NoError(t, err)
+	require.Equal(t, 1, lookups)
+}
+
+func TestProcessorUsesConfiguredPoller(t *testing.T) {
+	client := &fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: "abc", Status: "pending"}, nil
+		},
+	}
+	lister := &fakeLister{pages: [][]paypack.Transaction{{{Ref: "abc", Status: "success", Amount: 100}}}}
+	processor := NewProcessor(client, WithPoller(EventsPoller(lister, time.Millisecond)))
+
+	resp, err := processor.Handle(context.Background(), SubscriptionEvent{Number: "0788000001", Amount: 100})
+	require.NoError(t, err)
+	require.Equal(t, "success", resp.Status)
+	require.True(t, resp.Found)
+}
+
+func TestPollTuningStaysWithinBounds(t *testing.T) {
+	processor := NewProcessor(&fakeClient{}, WithPollBounds(PollBounds{MinInterval: 2 * time.Second, MaxInterval: 10 * time.Second, MaxAttempts: 20}))
+	interval, attempts := processor.pollTuning(SubscriptionEvent{})
+	require.Zero(t, interval)
+	require.Equal(t, 20, attempts)
+
+	interval, attempts = processor.pollTuning(SubscriptionEvent{PollIntervalSeconds: 1, MaxAttempts: 5})
+	require.Equal(t, 2*time.Second, interval)
+	require.Equal(t, 5, attempts)
+
+	interval, attempts = processor.pollTuning(SubscriptionEvent{PollIntervalSeconds: 60, MaxAttempts: 500})
+	require.Equal(t, 10*time.Second, interval)
+	require.Equal(t, 20, attempts)
+
+	_, err := processor.Handle(context.Background(), SubscriptionEvent{Number: "2507", Amount: 1000, MaxAttempts: -1})
+	require.Equal(t, CodeValidation, CodeOf(err))
+}
+
+func TestProcessorStopsAfterEventMaxAttempts(t *testing.T) {
+	var lookups int
+	client := &fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: "abc"}, nil
+		},
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			lookups++
+			return nil, paypack.ErrTransactionNotFound
+		},
+	}
+	processor := NewProcessor(client, WithTimeout(time.Hour), WithPollBounds(PollBounds{MinInterval: time.Millisecond, MaxInterval: time.Millisecond}))
+
+	resp, err := processor.Handle(context.Background(), SubscriptionEvent{Number: "2507", Amount: 1000, PollIntervalSeconds: 1, MaxAttempts: 3})
+	require.NoError(t, err)
+	require.Equal(t, "failed", resp.Status)
+	require.Equal(t, CodeConfirmationTimeout, resp.Code)
+	require.Equal(t, "transaction not confirmed after 3 attempts", resp.Message)
+	require.Equal(t, 3, lookups)
+}
+
+func TestProcessorStopsAfterMaxPollAttempts(t *testing.T) {
+	var lookups int
+	client := &fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: "abc"}, nil
+		},
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			lookups++
+			return nil, paypack.ErrTransactionNotFound
+		},
+	}
+	processor := NewProcessor(client, WithTimeout(time.Hour), WithPollInterval(time.Millisecond), WithPollBounds(PollBounds{MaxAttempts: 4}))
+
+	resp, err := processor.Handle(context.Background(), SubscriptionEvent{Number: "2507", Amount: 1000})
+	require.NoError(t, err)
+	require.Equal(t, "failed", resp.Status)
+	require.Equal(t, CodeConfirmationTimeout, resp.Code)
+	require.Equal(t, 4, resp.Attempts)
+	require.Equal(t, 4, lookups)
+
+	_, attempts := processor.pollTuning(SubscriptionEvent{MaxAttempts: 2})
+	require.Equal(t, 2, attempts, "events may ask for fewer")
+	_, attempts = processor.pollTuning(SubscriptionEvent{MaxAttempts: 10})
+	require.Equal(t, 4, attempts, "but not more")
+}
+
+func TestPollingRidesOutBriefUpstreamErrors(t *testing.T) {
+	var lookups int
+	client := &fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: "abc"}, nil
+		},
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			if lookups++; lookups <= 2 {
+				return nil, &paypack.APIError{StatusCode: 503}
+			}
+			return &paypack.Transaction{Ref: ref, Status: "success"}, nil
+		},
+	}
+	processor := NewProcessor(client, WithTimeout(time.Hour), WithPollInterval(time.Millisecond))
+
+	resp, err := processor.Handle(context.Background(), SubscriptionEvent{Number: "2507", Amount: 1000})
+	require.NoError(t, err)
+	require.Equal(t, "success", resp.Status)
+	require.Equal(t, 3, lookups)
+}
+
+func TestPollingStopsEarlyWhenPaypackStaysDown(t *testing.T) {
+	var lookups int
+	client := &fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: "abc"}, nil
+		},
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			lookups++
+			return nil, &paypack.APIError{StatusCode: 502}
+		},
+	}
+	checkpoints := checkpoint.NewMemoryStore()
+	processor := NewProcessor(client, WithTimeout(time.Hour), WithPollInterval(time.Millisecond), WithCheckpoints(checkpoints), WithUpstreamErrorLimit(4))
+
+	resp, err := processor.Handle(context.Background(), SubscriptionEvent{Number: "2507", Amount: 1000})
+	require.NoError(t, err)
+	require.Equal(t, "pending", resp.Status)
+	require.Equal(t, CodePaypackUnavailable, resp.Code)
+	require.True(t, resp.Failure.Retryable)
+	require.Equal(t, 4, lookups)
+
+	cp, err := checkpoints.Get(context.Background(), "abc")
+	require.NoError(t, err, "the reconciler picks the confirmation up")
+	require.Equal(t, "abc", cp.Ref)
+
+	lookups = 0
+	_, err = NewProcessor(client, WithTimeout(time.Hour), WithPollInterval(time.Millisecond)).Handle(context.Background(), SubscriptionEvent{Number: "2507", Amount: 1000})
+	require.Equal(t, CodePaypackUnavailable, CodeOf(err), "without checkpoints the failure is returned")
+	require.Equal(t, 3, lookups)
+}
@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	qrcode "github.com/skip2/go-qrcode"
+
+	"github.com/berniyo/paypack-lambda/internal/eventstore"
+	"github.com/berniyo/paypack-lambda/internal/objectstore"
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+// QR payload kinds.
+const (
+	QRKindLink = "link"
+	QRKindUSSD = "ussd"
+)
+
+const (
+	qrImageSize     = 256
+	qrKeyPrefix     = "qr/"
+	defaultQRURLTTL = 24 * time.Hour
+	ussdAmountSlot  = "{amount}"
+)
+
+// QRCode is a scannable payment code returned by the qr action. The PNG is inline as base64
+// unless a QR store is configured, in which case URL points at the stored image.
+type QRCode struct {
+	Kind        string `json:"kind"`
+	Content     string `json:"content"`
+	ImageBase64 string `json:"image_base64,omitempty"`
+	Key         string `json:"key,omitempty"`
+	URL         string `json:"url,omitempty"`
+}
+
+// WithQRStore stores generated QR images in store and returns a pre-signed URL valid for
+// urlTTL (default 24h) instead of inlining them.
+func WithQRStore(store objectstore.Store, urlTTL time.Duration) Option {
+	return func(p *Processor) {
+		p.qrStore = store
+		p.qrURLTTL = defaultQRURLTTL
+		if urlTTL > 0 {
+			p.qrURLTTL = urlTTL
+		}
+	}
+}
+
+// WithUSSDTemplate enables USSD QR codes, e.g. "*182*8*1*123456*{amount}#". The amount is
+// written in whole RWF.
+func WithUSSDTemplate(template string) Option {
+	return func(p *Processor) {
+		if strings.Contains(template, ussdAmountSlot) {
+			p.ussdTemplate = template
+		}
+	}
+}
+
+// handleQRCode returns a QR for event.Amount. Link codes wrap a payment link, so the payment
+// is tracked through the same webhook flow; USSD codes are used when event.QR asks for them
+// or the client cannot create links.
+func (p *Processor) handleQRCode(ctx context.Context, event SubscriptionEvent) (SubscriptionResponse, error) {
+	_, canLink := p.client.(PaymentLinkCreator)
+	kind := strings.ToLower(strings.TrimSpace(event.QR))
+	if kind == "" {
+		kind = QRKindUSSD
+		if canLink {
+			kind = QRKindLink
+		}
+	}
+
+	var (
+		resp    SubscriptionResponse
+		content string
+		err     error
+	)
+	switch kind {
+	case QRKindLink:
+		resp, err = p.handlePaymentLink(ctx, event)
+		if err != nil {
+			return SubscriptionResponse{}, err
+		}
+		content = resp.PaymentLink.URL
+	case QRKindUSSD:
+		resp, content, err = p.ussdPayment(ctx, event)
+		if err != nil {
+			return SubscriptionResponse{}, err
+		}
+	default:
+		return SubscriptionResponse{}, withCode(CodeValidation, fmt.Errorf("unsupported qr kind %q", event.QR))
+	}
+
+	code, err := p.renderQRCode(ctx, kind, content)
+	if err != nil {
+		return SubscriptionResponse{}, err
+	}
+	resp.QRCode = code
+	return resp, nil
+}
+
+func (p *Processor) ussdPayment(ctx context.Context, event SubscriptionEvent) (SubscriptionResponse, string, error) {
+	if p.ussdTemplate == "" {
+		return SubscriptionResponse{}, "", errors.New("ussd template is not configured")
+	}
+	event.Currency = normalizeCurrency(event.Currency)
+	if event.Amount <= 0 {
+		return SubscriptionResponse{}, "", withCode(CodeValidation, errors.New("amount must be positive"))
+	}
+
+	amount, currency, conversion, err := p.chargeAmount(ctx, event)
+	if err != nil {
+		return SubscriptionResponse{}, "", err
+	}
+	if currency != paypack.DefaultCurrency {
+		return SubscriptionResponse{}, "", withCode(CodeValidation, fmt.Errorf("ussd payments require %s, got %s", paypack.DefaultCurrency, currency))
+	}
+
+	whole := strconv.FormatFloat(amount, 'f', 0, 64)
+	content := strings.ReplaceAll(p.ussdTemplate, ussdAmountSlot, whole)
+	return SubscriptionResponse{
+		Status:     "pending",
+		Message:    "awaiting payment through ussd",
+		Request:    event,
+		Conversion: conversion,
+	}, content, nil
+}
+
+func (p *Processor) renderQRCode(ctx context.Context, kind, content string) (*QRCode, error) {
+	png, err := qrcode.Encode(content, qrcode.Medium, qrImageSize)
+	if err != nil {
+		return nil, fmt.Errorf("encode qr code: %w", err)
+	}
+
+	code := &QRCode{Kind: kind, Content: content}
+	if p.qrStore == nil {
+		code.ImageBase64 = base64.StdEncoding.EncodeToString(png)
+		return code, nil
+	}
+
+	key := fmt.Sprintf("%s%s/%s.png", qrKeyPrefix, time.Now().UTC().Format("2006/01/02"), eventstore.NewID())
+	if err := p.qrStore.Put(ctx, key, "image/png", png); err != nil {
+		return nil, fmt.Errorf("store qr code: %w", err)
+	}
+	url, err := p.qrStore.PresignGet(ctx, key, p.qrURLTTL)
+	if err != nil {
+		return nil, fmt.Errorf("presign qr code: %w", err)
+	}
+	code.Key = key
+	code.URL = url
+	return code, nil
+}
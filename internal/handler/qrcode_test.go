@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/berniyo/paypack-lambda/internal/objectstore"
+)
+
+var pngMagic = []byte("\x89PNG\r\n\x1a\n")
+
+func TestQRCodeWrapsPaymentLink(t *testing.T) {
+	processor := NewProcessor(&linkClient{})
+
+	resp, err := processor.Handle(context.Background(), SubscriptionEvent{Action: ActionQRCode, Amount: 1500})
+	require.NoError(t, err)
+	require.Equal(t, "pending", resp.Status)
+	require.NotNil(t, resp.QRCode)
+	require.Equal(t, QRKindLink, resp.QRCode.Kind)
+	require.Equal(t, "https://pay.example/lnk-1", resp.QRCode.Content)
+
+	png, err := base64.StdEncoding.DecodeString(resp.QRCode.ImageBase64)
+	require.NoError(t, err)
+	require.True(t, bytes.HasPrefix(png, pngMagic))
+}
+
+func TestQRCodeUSSDStoredInObjectStore(t *testing.T) {
+	objects := objectstore.NewMemoryStore()
+	processor := NewProcessor(
+		&fakeClient{},
+		WithUSSDTemplate("*182*8*1*123456*{amount}#"),
+		WithQRStore(objects, 0),
+	)
+
+	resp, err := processor.Handle(context.Background(), SubscriptionEvent{Action: ActionQRCode, Amount: 1500})
+	require.NoError(t, err)
+	require.Equal(t, QRKindUSSD, resp.QRCode.Kind)
+	require.Equal(t, "*182*8*1*123456*1500#", resp.QRCode.Content)
+	require.Empty(t, resp.QRCode.ImageBase64)
+	require.Equal(t, "memory://"+resp.QRCode.Key, resp.QRCode.URL)
+
+	obj, err := objects.Get(resp.QRCode.Key)
+	require.NoError(t, err)
+	require.Equal(t, "image/png", obj.ContentType)
+	require.True(t, bytes.HasPrefix(obj.Body, pngMagic))
+}
+
+func TestQRCodeUSSDRequiresTemplate(t *testing.T) {
+	processor := NewProcessor(&fakeClient{})
+
+	_, err := processor.Handle(context.Background(), SubscriptionEvent{Action: ActionQRCode, Amount: 1500})
+	require.EqualError(t, err, "ussd template is not configured")
+}
@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/berniyo/paypack-lambda/internal/money"
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+// QuoteClient is implemented by payment clients that preview fees before charging.
+type QuoteClient interface {
+	Quote(ctx context.Context, amount float64, provider string) (*paypack.Quote, error)
+}
+
+// handleQuote previews what a cash-in of event would charge, fees included, so checkout UIs
+// can show the total before the customer confirms. The amount is priced after currency
+// conversion and tax, as the charge would be. Paypack quotes the fee when the client can;
+// otherwise the WithFeeSchedule estimate is used. Nothing is charged.
+func (p *Processor) handleQuote(ctx context.Context, event SubscriptionEvent) (SubscriptionResponse, error) {
+	if event.Amount <= 0 {
+		return SubscriptionResponse{}, withCode(CodeValidation, errors.New("amount must be positive"))
+	}
+	event.Currency = normalizeCurrency(event.Currency)
+	if event.Currency != "" && !paypack.ValidCurrencyCode(event.Currency) {
+		return SubscriptionResponse{}, withCode(CodeValidation, fmt.Errorf("invalid currency %q", event.Currency))
+	}
+	provider := strings.ToLower(strings.TrimSpace(event.Provider))
+
+	amount, currency, conversion, err := p.chargeAmount(ctx, event)
+	if err != nil {
+		return SubscriptionResponse{}, err
+	}
+	taxLine := p.taxFor(event, amount, currency)
+	if taxLine != nil {
+		amount = taxLine.Gross
+	}
+
+	var quote *paypack.Quote
+	switch client, ok := p.client.(QuoteClient); {
+	case ok:
+		quote, err = client.Quote(ctx, amount, provider)
+		if err != nil {
+			return SubscriptionResponse{}, withCode(paypackCode(err), fmt.Errorf("quote failed: %w", err))
+		}
+	case p.fees != nil:
+		fee := p.fees.Compare(amount, 0, currency, provider).ExpectedFee
+		quote = &paypack.Quote{Amount: amount, Fee: fee, Total: money.Round(amount+fee, currency), Provider: provider}
+	default:
+		return SubscriptionResponse{}, errors.New("payment client does not quote fees and no fee schedule is configured")
+	}
+	quote.Currency = currency
+
+	return SubscriptionResponse{
+		Status:     "success",
+		Message:    fmt.Sprintf("you will be charged %.2f %s including fees", quote.Total, currency),
+		Request:    event,
+		Conversion: conversion,
+		Tax:        taxLine,
+		Quote:      quote,
+	}, nil
+}
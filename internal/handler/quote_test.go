@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/berniyo/paypack-lambda/internal/fee"
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+	"github.com/berniyo/paypack-lambda/pkg/paypack/paypacktest"
+)
+
+func TestQuoteAsksPaypackForTheFee(t *testing.T) {
+	srv := paypacktest.NewServer(paypacktest.Config{FeePercent: 2.3})
+	defer srv.Close()
+	processor := NewProcessor(srv.NewClient())
+
+	resp, err := processor.Handle(context.Background(), SubscriptionEvent{Action: ActionQuote, Amount: 10000, Provider: "mtn"})
+	require.NoError(t, err)
+	require.Equal(t, "success", resp.Status)
+	require.Equal(t, &paypack.Quote{Amount: 10000, Fee: 230, Total: 10230, Currency: "RWF", Provider: "mtn"}, resp.Quote)
+	require.Equal(t, "you will be charged 10230.00 RWF including fees", resp.Message)
+	require.Empty(t, resp.Reference, "nothing is charged")
+}
+
+func TestQuoteFallsBackToFeeSchedule(t *testing.T) {
+	schedule := &fee.Schedule{Default: []fee.Rule{{Percent: 2}}, Providers: map[string][]fee.Rule{"airtel": {{Fixed: 50}}}}
+	client := &fakeClient{}
+	processor := NewProcessor(client, WithFeeSchedule(schedule))
+
+	resp, err := processor.Handle(context.Background(), SubscriptionEvent{Action: ActionQuote, Amount: 1000, Provider: "Airtel"})
+	require.NoError(t, err)
+	require.Equal(t, 50.0, resp.Quote.Fee)
+	require.Equal(t, 1050.0, resp.Quote.Total)
+	require.Zero(t, client.lastCashIn, "nothing is charged")
+
+	_, err = NewProcessor(client).Handle(context.Background(), SubscriptionEvent{Action: ActionQuote, Amount: 1000})
+	require.Error(t, err)
+	_, err = processor.Handle(context.Background(), SubscriptionEvent{Action: ActionQuote})
+	require.Equal(t, CodeValidation, CodeOf(err))
+}
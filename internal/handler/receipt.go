@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/berniyo/paypack-lambda/internal/receipt"
+)
+
+// ReceiptIssuer renders and stores a receipt for a successful transaction.
+type ReceiptIssuer interface {
+	Issue(ctx context.Context, data receipt.Data) (*receipt.Receipt, error)
+}
+
+// ReceiptDeleter is implemented by issuers that can delete the receipts of a ref, which the
+// erase action needs when receipts are enabled.
+type ReceiptDeleter interface {
+	Delete(ctx context.Context, ref string) error
+}
+
+// WithReceipts issues a receipt for every successful transaction and includes its
+// pre-signed URL in the response and callback.
+func WithReceipts(issuer ReceiptIssuer) Option {
+	return func(p *Processor) {
+		p.receipts = issuer
+	}
+}
+
+func (p *Processor) issueReceipt(ctx context.Context, resp *SubscriptionResponse) {
+	if p.receipts == nil || !resp.Found || resp.Status != "success" || resp.Transaction == nil {
+		return
+	}
+
+	txn := resp.Transaction
+	rec, err := p.receipts.Issue(ctx, receipt.Data{
+		Ref:       resp.Reference,
+		Number:    resp.Request.Number,
+		Client:    resp.Request.Client,
+		Provider:  txn.Provider,
+		Amount:    txn.Amount,
+		Fee:       txn.Fee,
+		Currency:  txn.Currency,
+		Tax:       resp.Tax,
+		Timestamp: txn.Timestamp,
+	})
+	if err != nil {
+		p.logf(ctx, "receipt generation failed for ref=%s: %v", resp.Reference, err)
+		return
+	}
+	resp.Receipt = rec
+}
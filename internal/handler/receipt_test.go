@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/berniyo/paypack-lambda/internal/objectstore"
+	"github.com/berniyo/paypack-lambda/internal/receipt"
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+func TestProcessorIssuesReceiptOnSuccess(t *testing.T) {
+	client := &fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: "abc"}, nil
+		},
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: ref, Status: "success", Amount: 1000, Fee: 23, Provider: "mtn"}, nil
+		},
+	}
+
+	store := objectstore.NewMemoryStore()
+	issuer, err := receipt.NewIssuer(store)
+	require.NoError(t, err)
+
+	cb := &fakeCallback{}
+	processor := NewProcessor(
+		client,
+		WithPollInterval(5*time.Millisecond),
+		WithTimeout(200*time.Millisecond),
+		WithCallbackSender(cb),
+		WithReceipts(issuer),
+	)
+
+	resp, err := processor.Handle(context.Background(), SubscriptionEvent{Number: "2507", Amount: 1000})
+	require.NoError(t, err)
+	require.NotNil(t, resp.Receipt)
+	require.Equal(t, "receipts/abc.html", resp.Receipt.Key)
+	require.Equal(t, "memory://receipts/abc.html", cb.calls[0].Receipt.URL)
+
+	obj, err := store.Get("receipts/abc.html")
+	require.NoError(t, err)
+	require.True(t, strings.Contains(string(obj.Body), "23.00 RWF"))
+}
+
+func TestProcessorSkipsReceiptOnTimeout(t *testing.T) {
+	client := &fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: "abc"}, nil
+		},
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			return nil, paypack.ErrTransactionNotFound
+		},
+	}
+
+	issuer, err := receipt.NewIssuer(objectstore.NewMemoryStore(), receipt.WithFormat(receipt.FormatPDF))
+	require.NoError(t, err)
+
+	processor := NewProcessor(
+		client,
+		WithPollInterval(5*time.Millisecond),
+		WithTimeout(20*time.Millisecond),
+		WithReceipts(issuer),
+	)
+
+	resp, err := processor.Handle(context.Background(), SubscriptionEvent{Number: "2507", Amount: 1000})
+	require.NoError(t, err)
+	require.Nil(t, resp.Receipt)
+}
@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/berniyo/paypack-lambda/internal/refund"
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+// Refund describes the cash-in a refund pays back. Refunded is the total refunded on it so
+// far, this refund included, and RefundableRemaining what may still be refunded; without
+// WithRefundTracking only this refund is counted.
+type Refund struct {
+	OriginalRef         string  `json:"original_ref"`
+	Amount              float64 `json:"amount"`
+	Refunded            float64 `json:"refunded"`
+	RefundableRemaining float64 `json:"refundable_remaining"`
+}
+
+// WithRefundTracking records the cumulative refunded amount per cash-in in store, so partial
+// refunds of one charge never add up to more than it. The amount of a refund event then
+// defaults to what remains refundable rather than the full charge.
+func WithRefundTracking(store refund.Store) Option {
+	return func(p *Processor) {
+		p.refunds = store
+	}
+}
+
+// handleRefund pays a confirmed cash-in back to the number that paid it. Paypack has no
+// refund endpoint, so the refund is a cash-out; event.Amount defaults to the full charge.
+func (p *Processor) handleRefund(ctx context.Context, event SubscriptionEvent) (SubscriptionResponse, error) {
+	if event.Ref == "" {
+		return SubscriptionResponse{}, withCode(CodeValidation, errors.New("ref is required for refund"))
+	}
+	if event.Amount < 0 {
+		return SubscriptionResponse{}, withCode(CodeValidation, errors.New("refund amount must not be negative"))
+	}
+	payer, ok := p.client.(CashOutClient)
+	if !ok {
+		return SubscriptionResponse{}, errors.New("payment client does not support cash-out")
+	}
+
+	original, err := p.findTransaction(ctx, event.Ref)
+	if errors.Is(err, paypack.ErrTransactionNotFound) {
+		return SubscriptionResponse{}, withCode(CodeValidation, fmt.Errorf("transaction %s not found", event.Ref))
+	}
+	if err != nil {
+		return SubscriptionResponse{}, withCode(paypackCode(err), fmt.Errorf("refund lookup failed: %w", err))
+	}
+	if !strings.EqualFold(original.Kind, "CASHIN") || original.Status != "success" {
+		return SubscriptionResponse{}, withCode(CodeValidation, fmt.Errorf("transaction %s is not a successful cash-in", event.Ref))
+	}
+	currency := walletCurrency(original.Currency)
+	number := original.PayerNumber()
+	if number == "" {
+		return SubscriptionResponse{}, withCode(CodeValidation, fmt.Errorf("transaction %s has no payer number to refund", event.Ref))
+	}
+
+	amount := event.Amount
+	if amount == 0 {
+		amount = original.Amount
+		if p.refunds != nil {
+			balance, err := p.refunds.Get(ctx, event.Ref)
+			if err != nil {
+				return SubscriptionResponse{}, fmt.Errorf("load refunds of %s: %w", event.Ref, err)
+			}
+			balance.Currency, balance.Charged = currency, original.Amount
+			if amount = balance.Remaining(); amount == 0 {
+				return SubscriptionResponse{}, withCode(CodeValidation, fmt.Errorf("transaction %s is already fully refunded", event.Ref))
+			}
+		}
+	}
+	if amount > original.Amount {
+		return SubscriptionResponse{}, withCode(CodeValidation, fmt.Errorf("refund %.2f exceeds original charge %.2f", amount, original.Amount))
+	}
+
+	details := &Refund{OriginalRef: event.Ref, Amount: amount, Refunded: amount, RefundableRemaining: original.Amount - amount}
+	if p.refunds != nil {
+		balance, err := p.refunds.Reserve(ctx, event.Ref, currency, original.Amount, amount)
+		if errors.Is(err, refund.ErrExceedsCharge) {
+			return SubscriptionResponse{}, withCode(CodeValidation, fmt.Errorf("refund %.2f exceeds the %.2f still refundable on %s", amount, balance.Remaining(), event.Ref))
+		}
+		if err != nil {
+			return SubscriptionResponse{}, fmt.Errorf("reserve refund of %s: %w", event.Ref, err)
+		}
+		details.Refunded, details.RefundableRemaining = balance.Refunded, balance.Remaining()
+	}
+
+	p.logf(ctx, "refunding ref=%s amount=%.2f", event.Ref, amount)
+	txn, err := payer.CashOut(idempotencyContext(ctx, event), number, amount)
+	if err != nil {
+		if p.refunds != nil {
+			if rerr := p.refunds.Release(context.WithoutCancel(ctx), event.Ref, currency, amount); rerr != nil {
+				p.logf(ctx, "release refund reservation of %s: %v", event.Ref, rerr)
+			}
+		}
+		return SubscriptionResponse{}, withCode(paypackCode(err), fmt.Errorf("refund cashout failed: %w", err))
+	}
+	return SubscriptionResponse{
+		Reference:   txn.Ref,
+		Status:      txn.Status,
+		Found:       true,
+		Transaction: txn,
+		Request:     event,
+		Refund:      details,
+	}, nil
+}
@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/berniyo/paypack-lambda/internal/refund"
+	"github.com/berniyo/paypack-lambda/internal/split"
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+	"github.com/berniyo/paypack-lambda/pkg/paypack/paypacktest"
+)
+
+func TestProcessorRefundsConfirmedCashIn(t *testing.T) {
+	client := newPayoutClient()
+	client.findTransactionFn = func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+		return &paypack.Transaction{Ref: ref, Kind: "CASHIN", Status: "success", Amount: 1000, Client: "2507"}, nil
+	}
+	processor := NewProcessor(client)
+
+	resp, err := processor.Handle(context.Background(), SubscriptionEvent{Action: ActionRefund, Ref: "abc"})
+	require.NoError(t, err)
+	require.Equal(t, "out-1", resp.Reference)
+	require.Equal(t, &Refund{OriginalRef: "abc", Amount: 1000, Refunded: 1000}, resp.Refund)
+	require.Equal(t, []split.Leg{{Number: "2507", Amount: 1000}}, client.payouts)
+
+	_, err = processor.Handle(context.Background(), SubscriptionEvent{Action: ActionRefund, Ref: "abc", Amount: 1200})
+	require.Equal(t, CodeValidation, CodeOf(err))
+	require.Len(t, client.payouts, 1)
+}
+
+func TestProcessorRefusesToRefundUnconfirmedTransactions(t *testing.T) {
+	client := newPayoutClient()
+	client.findTransactionFn = func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+		return &paypack.Transaction{Ref: ref, Kind: "CASHIN", Status: "pending", Amount: 1000}, nil
+	}
+	processor := NewProcessor(client)
+
+	_, err := processor.Handle(context.Background(), SubscriptionEvent{Action: ActionRefund, Ref: "abc"})
+	require.ErrorContains(t, err, "is not a successful cash-in")
+	require.Empty(t, client.payouts)
+}
+
+func TestProcessorTracksPartialRefunds(t *testing.T) {
+	client := newPayoutClient()
+	client.findTransactionFn = func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+		return &paypack.Transaction{Ref: ref, Kind: "CASHIN", Status: "success", Amount: 1000, Client: "2507"}, nil
+	}
+	processor := NewProcessor(client, WithRefundTracking(refund.NewMemoryStore()))
+	ctx := context.Background()
+
+	resp, err := processor.Handle(ctx, SubscriptionEvent{Action: ActionRefund, Ref: "abc", Amount: 300})
+	require.NoError(t, err)
+	require.Equal(t, &Refund{OriginalRef: "abc", Amount: 300, Refunded: 300, RefundableRemaining: 700}, resp.Refund)
+
+	_, err = processor.Handle(ctx, SubscriptionEvent{Action: ActionRefund, Ref: "abc", Amount: 800})
+	require.Equal(t, CodeValidation, CodeOf(err))
+	require.ErrorContains(t, err, "700.00 still refundable")
+
+	resp, err = processor.Handle(ctx, SubscriptionEvent{Action: ActionRefund, Ref: "abc"})
+	require.NoError(t, err)
+	require.Equal(t, &Refund{OriginalRef: "abc", Amount: 700, Refunded: 1000}, resp.Refund)
+
+	_, err = processor.Handle(ctx, SubscriptionEvent{Action: ActionRefund, Ref: "abc"})
+	require.ErrorContains(t, err, "already fully refunded")
+	require.Equal(t, []split.Leg{{Number: "2507", Amount: 300}, {Number: "2507", Amount: 700}}, client.payouts)
+}
+
+func TestProcessorReleasesRefundWhenCashOutFails(t *testing.T) {
+	client := newPayoutClient()
+	client.findTransactionFn = func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+		return &paypack.Transaction{Ref: ref, Kind: "CASHIN", Status: "success", Amount: 1000, Client: "2507"}, nil
+	}
+	client.failFor = "2507"
+	store := refund.NewMemoryStore()
+	processor := NewProcessor(client, WithRefundTracking(store))
+
+	_, err := processor.Handle(context.Background(), SubscriptionEvent{Action: ActionRefund, Ref: "abc", Amount: 400})
+	require.Error(t, err)
+	balance, err := store.Get(context.Background(), "abc")
+	require.NoError(t, err)
+	require.Zero(t, balance.Refunded)
+}
+
+func TestProcessorRefundsThePayerOfATaggedCashIn(t *testing.T) {
+	srv := paypacktest.NewServer(paypacktest.Config{})
+	defer srv.Close()
+	processor := NewProcessor(srv.NewClient(), WithPollInterval(5*time.Millisecond))
+	ctx := context.Background()
+
+	paid, err := processor.Handle(ctx, SubscriptionEvent{Number: "250788123456", Amount: 1000, Client: "acme"})
+	require.NoError(t, err)
+	require.Equal(t, "success", paid.Status)
+	require.Equal(t, "acme", paid.Transaction.Client)
+
+	resp, err := processor.Handle(ctx, SubscriptionEvent{Action: ActionRefund, Ref: paid.Reference})
+	require.NoError(t, err)
+	require.Equal(t, "250788123456", resp.Transaction.Number)
+	require.Equal(t, "250788123456", resp.Transaction.Client)
+}
+
+func TestProcessorRefusesToRefundWithoutPayerNumber(t *testing.T) {
+	client := newPayoutClient()
+	client.findTransactionFn = func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+		return &paypack.Transaction{Ref: ref, Kind: "CASHIN", Status: "success", Amount: 1000, Client: "acme"}, nil
+	}
+	processor := NewProcessor(client)
+
+	_, err := processor.Handle(context.Background(), SubscriptionEvent{Action: ActionRefund, Ref: "abc"})
+	require.Equal(t, CodeValidation, CodeOf(err))
+	require.ErrorContains(t, err, "has no payer number")
+	require.Empty(t, client.payouts)
+}
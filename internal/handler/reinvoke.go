@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"time"
+
+	"github.com/berniyo/paypack-lambda/internal/checkpoint"
+)
+
+// SelfInvoker queues an event for this function; lambdainvoke.Client implements it.
+type SelfInvoker interface {
+	InvokeAsync(ctx context.Context, payload []byte) error
+}
+
+// WithSelfReinvocation continues confirmations across invocations: when an invocation nears
+// its deadline with the transaction still pending, it queues a resume event for itself with
+// the ref and the remaining confirmation budget (requires WithCheckpoints). Under
+// WithEventSignatures, pass the HMAC secret the resume event is signed with.
+func WithSelfReinvocation(invoker SelfInvoker, signingSecret string) Option {
+	return func(p *Processor) {
+		p.reinvoker = invoker
+		p.reinvokeSecret = signingSecret
+	}
+}
+
+// reinvoke queues a resume of cp for a fresh invocation, reporting whether it was queued.
+func (p *Processor) reinvoke(ctx context.Context, cp *checkpoint.Checkpoint, event SubscriptionEvent) bool {
+	budget := int(math.Ceil(time.Until(cp.Deadline).Seconds()))
+	if p.reinvoker == nil || budget <= 0 {
+		return false
+	}
+	ctx = context.WithoutCancel(ctx)
+	resume := SubscriptionEvent{
+		Action:                ActionResume,
+		Ref:                   cp.Ref,
+		CorrelationID:         event.CorrelationID,
+		ConfirmTimeoutSeconds: budget,
+	}
+	if p.reinvokeSecret != "" {
+		resume.SignedAt = time.Now().Unix()
+		signature, err := SignEvent(resume, p.reinvokeSecret)
+		if err != nil {
+			p.logf(ctx, "sign resume of ref=%s: %v", cp.Ref, err)
+			return false
+		}
+		resume.Signature = signature
+	}
+	payload, err := json.Marshal(resume)
+	if err != nil {
+		p.logf(ctx, "encode resume of ref=%s: %v", cp.Ref, err)
+		return false
+	}
+	if err := p.reinvoker.InvokeAsync(ctx, payload); err != nil {
+		p.logf(ctx, "re-invoke to resume ref=%s: %v", cp.Ref, err)
+		return false
+	}
+	p.logf(ctx, "re-invoked to resume ref=%s with %ds left", cp.Ref, budget)
+	return true
+}
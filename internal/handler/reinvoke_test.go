@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/berniyo/paypack-lambda/internal/checkpoint"
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+type fakeInvoker struct{ payloads [][]byte }
+
+func (f *fakeInvoker) InvokeAsync(ctx context.Context, payload []byte) error {
+	f.payloads = append(f.payloads, payload)
+	return nil
+}
+
+func TestSelfReinvocationResumesConfirmation(t *testing.T) {
+	var confirmed atomic.Bool
+	client := &fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: "abc"}, nil
+		},
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			if !confirmed.Load() {
+				return nil, paypack.ErrTransactionNotFound
+			}
+			return &paypack.Transaction{Ref: ref, Status: "success"}, nil
+		},
+	}
+	invoker := &fakeInvoker{}
+	cb := &fakeCallback{}
+	processor := NewProcessor(
+		client,
+		WithPollInterval(5*time.Millisecond),
+		WithTimeout(time.Minute),
+		WithCallbackSender(cb),
+		WithCheckpoints(checkpoint.NewMemoryStore()),
+		WithEventSignatures(time.Minute, HMACEventVerifier("s3cret")),
+		WithSelfReinvocation(invoker, "s3cret"),
+	)
+
+	ctx, cancel := context.WithTimeout(authenticatedContext(context.Background()), 30*time.Millisecond)
+	defer cancel()
+	resp, err := processor.Handle(ctx, SubscriptionEvent{Number: "2507", Amount: 1000, CorrelationID: "order-42"})
+	require.NoError(t, err)
+	require.Equal(t, "pending", resp.Status)
+	require.Contains(t, resp.Message, "new invocation")
+	require.Len(t, invoker.payloads, 1)
+
+	var resume SubscriptionEvent
+	require.NoError(t, json.Unmarshal(invoker.payloads[0], &resume))
+	require.Equal(t, ActionResume, resume.Action)
+	require.Equal(t, "abc", resume.Ref)
+	require.Equal(t, "order-42", resume.CorrelationID)
+	require.InDelta(t, 60, resume.ConfirmTimeoutSeconds, 1)
+
+	confirmed.Store(true)
+	resp, err = processor.Handle(context.Background(), resume)
+	require.NoError(t, err, "the resume event is signed")
+	require.Equal(t, "success", resp.Status)
+	require.Equal(t, "2507", resp.Request.Number)
+	require.Len(t, cb.calls, 1)
+}
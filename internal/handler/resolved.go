@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/berniyo/paypack-lambda/internal/eventstore"
+	"github.com/berniyo/paypack-lambda/internal/txcache"
+)
+
+// replaysResolved reports whether events of action naming a ref are answered from an
+// already resolved result: status checks, resumes, and cash-ins or payouts sent again.
+func replaysResolved(action string) bool {
+	switch action {
+	case "", ActionCashIn, ActionCashOut, ActionStatus, ActionResume:
+		return true
+	}
+	return false
+}
+
+// resolvedResult returns the final outcome already recorded for ref, without calling
+// Paypack: the last callback in the event store, or else a terminal transaction in the
+// cache. ok is false while ref is unresolved.
+func (p *Processor) resolvedResult(ctx context.Context, ref string, event SubscriptionEvent) (resp SubscriptionResponse, ok bool) {
+	if p.events != nil {
+		rec, err := p.events.Latest(ctx, ref, eventstore.KindCallback)
+		switch {
+		case err == nil:
+			if err := json.Unmarshal(rec.Payload, &resp); err != nil {
+				p.logf(ctx, "decode stored result for ref=%s: %v", ref, err)
+			} else if resp.Found && txcache.Terminal(resp.Status) {
+				resp.Replayed = true
+				return resp, true
+			}
+		case !errors.Is(err, eventstore.ErrNotFound):
+			p.logf(ctx, "stored result lookup for ref=%s: %v", ref, err)
+		}
+	}
+	if p.txCache != nil {
+		txn, err := p.txCache.Get(ctx, ref)
+		switch {
+		case err == nil:
+			resp = SubscriptionResponse{Reference: ref, Status: txn.Status, Found: true, Transaction: txn, Request: event, Replayed: true}
+			setNetAmount(&resp)
+			return resp, true
+		case !errors.Is(err, txcache.ErrNotFound):
+			p.logf(ctx, "transaction cache lookup for ref=%s: %v", ref, err)
+		}
+	}
+	return SubscriptionResponse{}, false
+}
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/berniyo/paypack-lambda/internal/eventstore"
+	"github.com/berniyo/paypack-lambda/internal/txcache"
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+func TestResolvedRefsReplayTheStoredResult(t *testing.T) {
+	var charges int
+	client := &fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			charges++
+			return &paypack.Transaction{Ref: "abc"}, nil
+		},
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: ref, Status: "success", Amount: 1000, Fee: 23}, nil
+		},
+	}
+	p := NewProcessor(client,
+		WithPollInterval(time.Millisecond),
+		WithCallbackSender(&fakeCallback{}),
+		WithEventStore(eventstore.NewMemoryStore()),
+	)
+	first, err := p.Handle(context.Background(), SubscriptionEvent{Number: "2507", Amount: 1000})
+	require.NoError(t, err)
+	require.False(t, first.Replayed)
+
+	client.findTransactionFn = func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+		return nil, errors.New("paypack should not be queried")
+	}
+	for _, event := range []SubscriptionEvent{
+		{Action: ActionStatus, Ref: "abc"},
+		{Ref: "abc", Number: "2507", Amount: 1000},
+	} {
+		resp, err := p.Handle(context.Background(), event)
+		require.NoError(t, err)
+		require.True(t, resp.Replayed)
+		require.Equal(t, "success", resp.Status)
+		require.Equal(t, first.Fee, resp.Fee, "the stored result is returned as it was")
+	}
+	require.Equal(t, 1, charges, "a repeated event is not charged again")
+}
+
+func TestResolvedRefsReplayFromTransactionCache(t *testing.T) {
+	client := &fakeClient{
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			return nil, errors.New("paypack should not be queried")
+		},
+	}
+	cache := txcache.NewMemoryStore(0)
+	require.NoError(t, cache.Put(context.Background(), paypack.Transaction{Ref: "abc", Status: "failed"}, time.Minute))
+	p := NewProcessor(client, WithTransactionCache(cache, time.Minute))
+
+	resp, err := p.Handle(context.Background(), SubscriptionEvent{Action: ActionStatus, Ref: "abc"})
+	require.NoError(t, err)
+	require.True(t, resp.Replayed)
+	require.Equal(t, "failed", resp.Status)
+}
+
+func TestUnresolvedRefsAreNotReplayed(t *testing.T) {
+	client := &fakeClient{
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			return nil, paypack.ErrTransactionNotFound
+		},
+	}
+	p := NewProcessor(client, WithEventStore(eventstore.NewMemoryStore()), WithTransactionCache(txcache.NewMemoryStore(0), time.Minute))
+
+	resp, err := p.Handle(context.Background(), SubscriptionEvent{Action: ActionStatus, Ref: "abc"})
+	require.NoError(t, err)
+	require.False(t, resp.Replayed)
+	require.Equal(t, "pending", resp.Status)
+}
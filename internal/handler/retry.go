@@ -0,0 +1,223 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/berniyo/paypack-lambda/internal/retry"
+)
+
+const (
+	defaultRetryInterval = 24 * time.Hour
+	defaultRetryBatch    = 25
+	// retryLease is how long a claimed entry stays out of other runs' Due results: Lambda's
+	// maximum run time, after which an attempt that died without rescheduling is due again.
+	retryLease = 15 * time.Minute
+)
+
+// RetryClassifier decides whether a failed outcome should be re-attempted later.
+type RetryClassifier func(resp SubscriptionResponse) bool
+
+// DefaultRetryClassifier retries transactions Paypack resolved as failed, which is how a
+// wallet without sufficient funds (or a declined push) surfaces. Confirmation timeouts are
+// not retried because the original charge may still complete.
+func DefaultRetryClassifier(resp SubscriptionResponse) bool {
+	return resp.Found && resp.Status == "failed"
+}
+
+// RetryInfo tells consumers whether another attempt is scheduled.
+type RetryInfo struct {
+	Attempt       int        `json:"attempt"`
+	MaxAttempts   int        `json:"max_attempts"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
+	Exhausted     bool       `json:"exhausted,omitempty"`
+}
+
+// RetryReport summarises one scheduled retry run.
+type RetryReport struct {
+	Due         int `json:"due"`
+	Succeeded   int `json:"succeeded"`
+	Rescheduled int `json:"rescheduled"`
+	Stopped     int `json:"stopped"`
+	Errors      int `json:"errors"`
+}
+
+// WithRetryQueue persists retryable failures and lets RunRetries re-attempt them every
+// interval (default 24h), up to maxAttempts retries.
+func WithRetryQueue(store retry.Store, maxAttempts int, interval time.Duration) Option {
+	return func(p *Processor) {
+		if store == nil || maxAttempts <= 0 {
+			return
+		}
+		p.retries = store
+		p.retryMax = maxAttempts
+		p.retryInterval = defaultRetryInterval
+		if interval > 0 {
+			p.retryInterval = interval
+		}
+	}
+}
+
+// WithRetryClassifier replaces DefaultRetryClassifier.
+func WithRetryClassifier(c RetryClassifier) Option {
+	return func(p *Processor) {
+		if c != nil {
+			p.retryClassifier = c
+		}
+	}
+}
+
+// scheduleRetry queues a first retryable failure, or advances the queue entry for an attempt
+// driven by RunRetries. The outcome is reported on resp.Retry before the callback goes out.
+func (p *Processor) scheduleRetry(ctx context.Context, resp *SubscriptionResponse) {
+	if p.retries == nil {
+		return
+	}
+
+	event := resp.Request
+	succeeded := resp.Found && resp.Status == "success"
+	retryable := !succeeded && p.retryClassifier(*resp)
+	now := time.Now()
+
+	if event.RetryID == "" {
+		if !retryable {
+			return
+		}
+		event.RetryID = resp.Reference
+		payload, err := json.Marshal(event)
+		if err != nil {
+			p.logf(ctx, "encode retry entry for ref=%s: %v", resp.Reference, err)
+			return
+		}
+		next := p.nextRetryAt(now, 0, now)
+		entry := retry.Entry{
+			ID:            event.RetryID,
+			Event:         payload,
+			MaxAttempts:   p.maxRetries(),
+			NextAttemptAt: next,
+			LastRef:       resp.Reference,
+			LastReason:    resp.Status,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		}
+		if err := p.retries.Put(ctx, entry); err != nil {
+			p.logf(ctx, "queue retry for ref=%s: %v", resp.Reference, err)
+			return
+		}
+		p.logf(ctx, "retry queued for ref=%s at %s", resp.Reference, next.Format(time.RFC3339))
+		resp.Retry = &RetryInfo{Attempt: 0, MaxAttempts: entry.MaxAttempts, NextAttemptAt: &next}
+		return
+	}
+
+	entry, err := p.retries.Get(ctx, event.RetryID)
+	if err != nil {
+		p.logf(ctx, "lookup retry entry %s: %v", event.RetryID, err)
+		return
+	}
+	entry.Attempts = event.RetryAttempt
+	entry.LastRef = resp.Reference
+	entry.LastReason = resp.Status
+	entry.UpdatedAt = now
+	info := &RetryInfo{Attempt: entry.Attempts, MaxAttempts: entry.MaxAttempts}
+	resp.Retry = info
+
+	if !retryable || entry.Attempts >= entry.MaxAttempts {
+		info.Exhausted = retryable
+		if err := p.retries.Delete(ctx, entry.ID); err != nil {
+			p.logf(ctx, "remove retry entry %s: %v", entry.ID, err)
+		}
+		return
+	}
+
+	next := p.nextRetryAt(entry.CreatedAt, entry.Attempts, now)
+	entry.NextAttemptAt = next
+	if err := p.retries.Put(ctx, *entry); err != nil {
+		p.logf(ctx, "reschedule retry entry %s: %v", entry.ID, err)
+		return
+	}
+	info.NextAttemptAt = &next
+}
+
+// RunRetries re-attempts every due queue entry. It is the entry point for a scheduled
+// (EventBridge) invocation and stops early when ctx is done. Each entry is claimed with a
+// conditional update before it is attempted, so overlapping runs never charge it twice.
+func (p *Processor) RunRetries(ctx context.Context) (report RetryReport, err error) {
+	defer p.flush(ctx)
+	ctx, inv := p.beginInvocation(ctx, "retries")
+	defer func() { inv.end(errorOutcome(err), "") }()
+	if p.retries == nil {
+		return report, fmt.Errorf("retry queue is not configured")
+	}
+
+	due, err := p.retries.Due(ctx, time.Now(), defaultRetryBatch)
+	if err != nil {
+		return report, fmt.Errorf("load due retries: %w", err)
+	}
+	report.Due = len(due)
+
+	for _, entry := range due {
+		if ctx.Err() != nil {
+			break
+		}
+
+		err := p.retries.Claim(ctx, entry, time.Now().Add(retryLease))
+		if errors.Is(err, retry.ErrClaimed) {
+			p.logf(ctx, "retry entry %s already claimed by another run", entry.ID)
+			continue
+		}
+		if err != nil {
+			p.logf(ctx, "claim retry entry %s: %v", entry.ID, err)
+			report.Errors++
+			continue
+		}
+
+		var event SubscriptionEvent
+		if err := json.Unmarshal(entry.Event, &event); err != nil {
+			p.logf(ctx, "decode retry entry %s: %v", entry.ID, err)
+			report.Errors++
+			continue
+		}
+		event.RetryID = entry.ID
+		event.RetryAttempt = entry.Attempts + 1
+
+		p.logf(ctx, "retrying %s attempt %d/%d", entry.ID, event.RetryAttempt, entry.MaxAttempts)
+		resp, err := p.runCashIn(correlationContext(ctx, event), event)
+		if err != nil {
+			p.logf(ctx, "retry %s failed: %v", entry.ID, err)
+			report.Errors++
+			p.deferRetry(ctx, entry, event.RetryAttempt, err)
+			continue
+		}
+
+		switch {
+		case resp.Found && resp.Status == "success":
+			report.Succeeded++
+		case resp.Retry != nil && resp.Retry.NextAttemptAt != nil:
+			report.Rescheduled++
+		default:
+			report.Stopped++
+		}
+	}
+
+	return report, nil
+}
+
+// deferRetry pushes an entry back after an attempt that errored before reaching Paypack's verdict.
+func (p *Processor) deferRetry(ctx context.Context, entry retry.Entry, attempt int, cause error) {
+	entry.Attempts = attempt
+	entry.LastReason = cause.Error()
+	entry.UpdatedAt = time.Now()
+	if entry.Attempts >= entry.MaxAttempts {
+		if err := p.retries.Delete(ctx, entry.ID); err != nil {
+			p.logf(ctx, "remove retry entry %s: %v", entry.ID, err)
+		}
+		return
+	}
+	entry.NextAttemptAt = p.nextRetryAt(entry.CreatedAt, entry.Attempts, entry.UpdatedAt)
+	if err := p.retries.Put(ctx, entry); err != nil {
+		p.logf(ctx, "reschedule retry entry %s: %v", entry.ID, err)
+	}
+}
@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/berniyo/paypack-lambda/internal/retry"
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+func TestProcessorRetriesFailedCashIn(t *testing.T) {
+	attempts := 0
+	outcomes := []string{"failed", "failed", "success"}
+	client := &fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			attempts++
+			return &paypack.Transaction{Ref: fmt.Sprintf("ref-%d", attempts)}, nil
+		},
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: ref, Status: outcomes[attempts-1]}, nil
+		},
+	}
+
+	store := retry.NewMemoryStore()
+	cb := &fakeCallback{}
+	processor := NewProcessor(
+		client,
+		WithPollInterval(5*time.Millisecond),
+		WithTimeout(200*time.Millisecond),
+		WithCallbackSender(cb),
+		WithRetryQueue(store, 2, time.Millisecond),
+	)
+	ctx := context.Background()
+
+	resp, err := processor.Handle(ctx, SubscriptionEvent{Number: "2507", Amount: 1000})
+	require.NoError(t, err)
+	require.Equal(t, "failed", resp.Status)
+	require.NotNil(t, resp.Retry)
+	require.NotNil(t, resp.Retry.NextAttemptAt)
+
+	time.Sleep(2 * time.Millisecond)
+	report, err := processor.RunRetries(ctx)
+	require.NoError(t, err)
+	require.Equal(t, RetryReport{Due: 1, Rescheduled: 1}, report)
+
+	entry, err := store.Get(ctx, "ref-1")
+	require.NoError(t, err)
+	require.Equal(t, 1, entry.Attempts)
+	require.Equal(t, "ref-2", entry.LastRef)
+
+	time.Sleep(2 * time.Millisecond)
+	report, err = processor.RunRetries(ctx)
+	require.NoError(t, err)
+	require.Equal(t, RetryReport{Due: 1, Succeeded: 1}, report)
+
+	_, err = store.Get(ctx, "ref-1")
+	require.ErrorIs(t, err, retry.ErrNotFound)
+	require.Len(t, cb.calls, 3)
+	require.Equal(t, 2, cb.calls[2].Request.RetryAttempt)
+}
+
+func TestOverlappingRetryRunsChargeOnce(t *testing.T) {
+	var charges atomic.Int32
+	client := &fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			charges.Add(1)
+			return &paypack.Transaction{Ref: "ref-2"}, nil
+		},
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: ref, Status: "success"}, nil
+		},
+	}
+	store := retry.NewMemoryStore()
+	require.NoError(t, store.Put(context.Background(), retry.Entry{
+		ID:            "ref-1",
+		Event:         []byte(`{"number":"2507","amount":1000}`),
+		MaxAttempts:   3,
+		NextAttemptAt: time.Now().Add(-time.Minute),
+	}))
+	processor := NewProcessor(client, WithPollInterval(time.Millisecond), WithRetryQueue(store, 3, time.Hour))
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := processor.RunRetries(context.Background())
+			require.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, int32(1), charges.Load())
+}
+
+func TestProcessorStopsRetryingAfterMaxAttempts(t *testing.T) {
+	client := &fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: "abc"}, nil
+		},
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: ref, Status: "failed"}, nil
+		},
+	}
+
+	store := retry.NewMemoryStore()
+	processor := NewProcessor(
+		client,
+		WithPollInterval(5*time.Millisecond),
+		WithTimeout(200*time.Millisecond),
+		WithRetryQueue(store, 1, time.Millisecond),
+	)
+	ctx := context.Background()
+
+	_, err := processor.Handle(ctx, SubscriptionEvent{Number: "2507", Amount: 1000})
+	require.NoError(t, err)
+
+	time.Sleep(2 * time.Millisecond)
+	report, err := processor.RunRetries(ctx)
+	require.NoError(t, err)
+	require.Equal(t, RetryReport{Due: 1, Stopped: 1}, report)
+
+	due, err := store.Due(ctx, time.Now().Add(time.Hour), 0)
+	require.NoError(t, err)
+	require.Empty(t, due)
+}
+
+func TestProcessorDoesNotRetryTimeouts(t *testing.T) {
+	client := &fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: "abc"}, nil
+		},
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			return nil, paypack.ErrTransactionNotFound
+		},
+	}
+
+	store := retry.NewMemoryStore()
+	processor := NewProcessor(
+		client,
+		WithPollInterval(5*time.Millisecond),
+		WithTimeout(20*time.Millisecond),
+		WithRetryQueue(store, 3, time.Millisecond),
+	)
+
+	resp, err := processor.Handle(context.Background(), SubscriptionEvent{Number: "2507", Amount: 1000})
+	require.NoError(t, err)
+	require.Nil(t, resp.Retry)
+}
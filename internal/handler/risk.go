@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/berniyo/paypack-lambda/internal/risk"
+)
+
+// RiskChecker assesses a payment instruction before any money moves.
+type RiskChecker interface {
+	Assess(ctx context.Context, in risk.Input) (risk.Assessment, error)
+}
+
+// WithRiskChecks runs every cash-in through checker. Flagged events proceed with the assessment
+// attached, held events go to the approval gate (or are rejected when none is configured),
+// and rejected events never reach Paypack.
+func WithRiskChecks(checker RiskChecker) Option {
+	return func(p *Processor) {
+		p.risk = checker
+	}
+}
+
+func (p *Processor) assessRisk(ctx context.Context, event SubscriptionEvent, amount float64) (*risk.Assessment, error) {
+	if p.risk == nil {
+		return nil, nil
+	}
+
+	assessment, err := p.risk.Assess(ctx, risk.Input{
+		Number: event.Number,
+		Client: event.Client,
+		Amount: amount,
+	})
+	if err != nil {
+		return nil, storeError{fmt.Errorf("risk assessment failed: %w", err)}
+	}
+	if assessment.Decision == risk.Allow {
+		return nil, nil
+	}
+
+	p.logf(ctx, "risk %s for number=%s amount=%.2f: %s", assessment.Decision, event.Number, amount, assessment.Reasons())
+	return &assessment, nil
+}
+
+func (p *Processor) applyRiskDecision(ctx context.Context, event SubscriptionEvent, amount float64, assessment *risk.Assessment) (SubscriptionResponse, error) {
+	if assessment.Decision == risk.Hold && p.approvals != nil {
+		resp, err := p.holdForApproval(ctx, event, amount)
+		if err != nil {
+			return SubscriptionResponse{}, err
+		}
+		resp.Message = "held by risk checks: " + assessment.Reasons()
+		resp.Risk = assessment
+		return resp, nil
+	}
+
+	return SubscriptionResponse{
+		Status:  "rejected",
+		Message: "rejected by risk checks: " + assessment.Reasons(),
+		Request: event,
+		Risk:    assessment,
+	}, nil
+}
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/berniyo/paypack-lambda/internal/approval"
+	"github.com/berniyo/paypack-lambda/internal/risk"
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+func TestProcessorRiskChecks(t *testing.T) {
+	cashIns := 0
+	client := &fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			cashIns++
+			return &paypack.Transaction{Ref: "abc"}, nil
+		},
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: ref, Status: "success"}, nil
+		},
+	}
+
+	engine := risk.NewEngine(nil,
+		risk.MaxAmountRule{Max: 50000, Action: risk.Hold},
+		risk.VelocityRule{MaxCount: 1, Window: time.Hour, Action: risk.Reject},
+		risk.ClientMismatchRule{Window: time.Hour, Action: risk.Flag},
+	)
+	processor := NewProcessor(
+		client,
+		WithPollInterval(5*time.Millisecond),
+		WithTimeout(200*time.Millisecond),
+		WithRiskChecks(engine),
+	)
+	ctx := context.Background()
+
+	resp, err := processor.Handle(ctx, SubscriptionEvent{Number: "2507", Amount: 1000, Client: "a"})
+	require.NoError(t, err)
+	require.Equal(t, "success", resp.Status)
+	require.Nil(t, resp.Risk)
+
+	resp, err = processor.Handle(ctx, SubscriptionEvent{Number: "2507", Amount: 1000, Client: "a"})
+	require.NoError(t, err)
+	require.Equal(t, "rejected", resp.Status)
+	require.Equal(t, risk.Reject, resp.Risk.Decision)
+
+	resp, err = processor.Handle(ctx, SubscriptionEvent{Number: "2508", Amount: 100000})
+	require.NoError(t, err)
+	require.Equal(t, "rejected", resp.Status, "hold without an approval gate rejects")
+	require.Equal(t, 1, cashIns)
+}
+
+func TestProcessorRiskHoldUsesApprovalGate(t *testing.T) {
+	client := &fakeClient{}
+	processor := NewProcessor(
+		client,
+		WithRiskChecks(risk.NewEngine(nil, risk.MaxAmountRule{Max: 500, Action: risk.Hold})),
+		WithApprovalGate(approval.NewMemoryStore(), 1000000, 0),
+	)
+
+	resp, err := processor.Handle(context.Background(), SubscriptionEvent{Number: "2507", Amount: 1000})
+	require.NoError(t, err)
+	require.Equal(t, "pending_approval", resp.Status)
+	require.NotEmpty(t, resp.ApprovalID)
+	require.Equal(t, risk.Hold, resp.Risk.Decision)
+}
@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/berniyo/paypack-lambda/internal/eventstore"
+	"github.com/berniyo/paypack-lambda/internal/retry"
+)
+
+const defaultScheduleBatch = 25
+
+// ScheduleReport summarises one run of due scheduled events.
+type ScheduleReport struct {
+	Due      int `json:"due"`
+	Executed int `json:"executed"`
+	Errors   int `json:"errors"`
+}
+
+// WithScheduledEvents holds cash-ins and payouts whose scheduled_at is still ahead in store
+// until RunScheduled executes them. Any retry.Store works, but it should not be the retry
+// queue's.
+func WithScheduledEvents(store retry.Store) Option {
+	return func(p *Processor) {
+		p.schedules = store
+	}
+}
+
+// deferEvent persists event for RunScheduled and answers "scheduled"; the outcome callback is
+// sent once it has run.
+func (p *Processor) deferEvent(ctx context.Context, event SubscriptionEvent) (SubscriptionResponse, error) {
+	if event.Action != "" && event.Action != ActionCashIn && event.Action != ActionCashOut {
+		return SubscriptionResponse{}, withCode(CodeValidation, fmt.Errorf("scheduled_at is not supported for action %q", event.Action))
+	}
+	if p.schedules == nil {
+		return SubscriptionResponse{}, withCode(CodeValidation, errors.New("scheduled_at requires a schedule store"))
+	}
+	if err := validateEvent(event); err != nil {
+		return SubscriptionResponse{}, withCode(CodeValidation, err)
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return SubscriptionResponse{}, fmt.Errorf("encode scheduled event: %w", err)
+	}
+	now := time.Now()
+	entry := retry.Entry{
+		ID:            eventstore.NewID(),
+		Event:         payload,
+		MaxAttempts:   1,
+		NextAttemptAt: event.ScheduledAt,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if err := p.schedules.Put(ctx, entry); err != nil {
+		return SubscriptionResponse{}, fmt.Errorf("persist scheduled event: %w", err)
+	}
+	p.logf(ctx, "event scheduled id=%s for %s", entry.ID, event.ScheduledAt.Format(time.RFC3339))
+	return SubscriptionResponse{
+		Status:     "scheduled",
+		Message:    "scheduled for " + event.ScheduledAt.UTC().Format(time.RFC3339),
+		Request:    event,
+		ScheduleID: entry.ID,
+	}, nil
+}
+
+// RunScheduled executes every due scheduled event. It is the entry point for a scheduled
+// (EventBridge) invocation. Entries are taken with a conditional delete before they run, so
+// neither overlapping runs nor an invocation that dies midway charge twice; the reconciler
+// finishes confirmations it left behind.
+func (p *Processor) RunScheduled(ctx context.Context) (report ScheduleReport, err error) {
+	defer p.flush(ctx)
+	ctx, inv := p.beginInvocation(ctx, "scheduled")
+	defer func() { inv.end(errorOutcome(err), "") }()
+	if p.schedules == nil {
+		return report, errors.New("schedule store is not configured")
+	}
+
+	due, err := p.schedules.Due(ctx, time.Now(), defaultScheduleBatch)
+	if err != nil {
+		return report, fmt.Errorf("load due scheduled events: %w", err)
+	}
+	report.Due = len(due)
+
+	for _, entry := range due {
+		if ctx.Err() != nil {
+			break
+		}
+		claimed, err := p.schedules.Take(ctx, entry.ID)
+		if errors.Is(err, retry.ErrNotFound) {
+			p.logf(ctx, "scheduled event %s already taken by another run", entry.ID)
+			continue
+		}
+		if err != nil {
+			p.logf(ctx, "claim scheduled event %s: %v", entry.ID, err)
+			report.Errors++
+			continue
+		}
+		entry = *claimed
+		var event SubscriptionEvent
+		if err := json.Unmarshal(entry.Event, &event); err != nil {
+			p.logf(ctx, "decode scheduled event %s: %v", entry.ID, err)
+			report.Errors++
+			continue
+		}
+
+		p.logf(ctx, "executing scheduled event %s", entry.ID)
+		if _, err := p.route(correlationContext(ctx, event), event); err != nil {
+			p.logf(ctx, "scheduled event %s failed: %v", entry.ID, err)
+			report.Errors++
+			continue
+		}
+		report.Executed++
+	}
+	return report, nil
+}
@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/berniyo/paypack-lambda/internal/retry"
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+func TestScheduledEventRunsOnceDue(t *testing.T) {
+	var charges int
+	client := &fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			charges++
+			return &paypack.Transaction{Ref: "abc"}, nil
+		},
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: ref, Status: "success"}, nil
+		},
+	}
+	store := retry.NewMemoryStore()
+	cb := &fakeCallback{}
+	processor := NewProcessor(client, WithPollInterval(time.Millisecond), WithCallbackSender(cb), WithScheduledEvents(store))
+	ctx := context.Background()
+
+	at := time.Now().Add(30 * time.Millisecond)
+	resp, err := processor.Handle(ctx, SubscriptionEvent{Number: "2507", Amount: 1000, ScheduledAt: at})
+	require.NoError(t, err)
+	require.Equal(t, "scheduled", resp.Status)
+	require.NotEmpty(t, resp.ScheduleID)
+	require.Zero(t, charges)
+	require.Empty(t, cb.calls)
+
+	report, err := processor.RunScheduled(ctx)
+	require.NoError(t, err)
+	require.Equal(t, ScheduleReport{}, report)
+
+	time.Sleep(40 * time.Millisecond)
+	report, err = processor.RunScheduled(ctx)
+	require.NoError(t, err)
+	require.Equal(t, ScheduleReport{Due: 1, Executed: 1}, report)
+	require.Equal(t, 1, charges)
+	require.Len(t, cb.calls, 1)
+	require.Equal(t, "success", cb.calls[0].Status)
+
+	report, err = processor.RunScheduled(ctx)
+	require.NoError(t, err)
+	require.Zero(t, report.Due)
+}
+
+func TestOverlappingScheduledRunsChargeOnce(t *testing.T) {
+	var charges atomic.Int32
+	client := &fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			charges.Add(1)
+			return &paypack.Transaction{Ref: "abc"}, nil
+		},
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: ref, Status: "success"}, nil
+		},
+	}
+	store := retry.NewMemoryStore()
+	require.NoError(t, store.Put(context.Background(), retry.Entry{
+		ID:            "sched-1",
+		Event:         []byte(`{"number":"2507","amount":1000}`),
+		NextAttemptAt: time.Now().Add(-time.Minute),
+	}))
+	processor := NewProcessor(client, WithPollInterval(time.Millisecond), WithScheduledEvents(store))
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := processor.RunScheduled(context.Background())
+			require.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, int32(1), charges.Load())
+}
+
+func TestScheduledAtNeedsStore(t *testing.T) {
+	processor := NewProcessor(&fakeClient{})
+	_, err := processor.Handle(context.Background(), SubscriptionEvent{Number: "2507", Amount: 1000, ScheduledAt: time.Now().Add(time.Hour)})
+	require.Equal(t, CodeValidation, CodeOf(err))
+
+	processor = NewProcessor(&fakeClient{}, WithScheduledEvents(retry.NewMemoryStore()))
+	_, err = processor.Handle(context.Background(), SubscriptionEvent{Action: ActionRefund, Ref: "abc", ScheduledAt: time.Now().Add(time.Hour)})
+	require.Equal(t, CodeValidation, CodeOf(err))
+}
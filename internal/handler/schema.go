@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/berniyo/paypack-lambda/api"
+)
+
+// Event schema versions. Events without a version are v1, which is decoded forever; a new
+// version registers its decoder in eventDecoders, which maps it onto SubscriptionEvent.
+const (
+	EventSchemaV1 = 1
+
+	// LatestEventSchema is the newest version producers may send.
+	LatestEventSchema = EventSchemaV1
+)
+
+// ErrUnsupportedEventVersion is returned when decoding an event of a version this processor
+// does not know; SupportedEventVersions lists the ones it does.
+var ErrUnsupportedEventVersion = errors.New("unsupported event version")
+
+var eventDecoders = map[int]func(data []byte, event *SubscriptionEvent) error{
+	EventSchemaV1: decodeEventV1,
+}
+
+// SupportedEventVersions returns the event schema versions the processor decodes, oldest
+// first, so producers can pick the newest they share.
+func SupportedEventVersions() []int {
+	return slices.Sorted(maps.Keys(eventDecoders))
+}
+
+// WithStrictDecoding makes DecodeEvent reject fields the event schema does not define.
+// Without it they are dropped, so a misspelled "amout" charges the wrong amount instead of
+// failing.
+func WithStrictDecoding() Option {
+	return func(p *Processor) {
+		p.strictDecoding = true
+	}
+}
+
+// DecodeEvent decodes a producer's event payload. It is first checked against the OpenAPI
+// schema, so every problem is reported at once by field path in an *api.ValidationError
+// tagged CodeValidation. WithLenientTypes coerces the variants it allows beforehand.
+func (p *Processor) DecodeEvent(data []byte) (SubscriptionEvent, error) {
+	if p.lenientTypes.any() {
+		data = p.lenientTypes.coerceEvent(data)
+	}
+	validate := api.Validate
+	if p.strictDecoding {
+		validate = api.ValidateStrict
+	}
+	var invalid *api.ValidationError
+	if err := validate("SubscriptionEvent", data); errors.As(err, &invalid) {
+		return SubscriptionEvent{}, withCode(CodeValidation, err)
+	}
+	var event SubscriptionEvent
+	if err := json.Unmarshal(data, &event); err != nil {
+		return SubscriptionEvent{}, withCode(CodeValidation, fmt.Errorf("decode event: %w", err))
+	}
+	return event, nil
+}
+
+// UnmarshalJSON decodes data with the decoder of its version. The version is kept as sent, so
+// echoed requests and signatures are unchanged.
+func (e *SubscriptionEvent) UnmarshalJSON(data []byte) error {
+	var probe struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return err
+	}
+	version := probe.Version
+	if version == 0 {
+		version = EventSchemaV1
+	}
+	decode, ok := eventDecoders[version]
+	if !ok {
+		return fmt.Errorf("%w %d; supported versions are %v", ErrUnsupportedEventVersion, probe.Version, SupportedEventVersions())
+	}
+	return decode(data, e)
+}
+
+// eventV1 has SubscriptionEvent's fields without its UnmarshalJSON.
+type eventV1 SubscriptionEvent
+
+func decodeEventV1(data []byte, event *SubscriptionEvent) error {
+	return json.Unmarshal(data, (*eventV1)(event))
+}
@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/berniyo/paypack-lambda/api"
+)
+
+func TestEventDecodesUnversionedAsV1(t *testing.T) {
+	for payload, version := range map[string]int{
+		`{"number":"2507","amount":1000,"items":[{"number":"2508","amount":5}]}`:             0,
+		`{"version":1,"number":"2507","amount":1000,"items":[{"number":"2508","amount":5}]}`: 1,
+	} {
+		var event SubscriptionEvent
+		require.NoError(t, json.Unmarshal([]byte(payload), &event))
+		require.Equal(t, "2507", event.Number)
+		require.Equal(t, 1000.0, event.Amount)
+		require.Equal(t, "2508", event.Items[0].Number)
+		// The version is kept as sent, so echoed requests round-trip.
+		require.Equal(t, version, event.Version)
+	}
+}
+
+func TestEventRejectsUnsupportedVersion(t *testing.T) {
+	var event SubscriptionEvent
+	err := json.Unmarshal([]byte(`{"version":9,"number":"2507","amount":1000}`), &event)
+	require.ErrorIs(t, err, ErrUnsupportedEventVersion)
+	require.Contains(t, err.Error(), "supported versions are [1]")
+	require.Equal(t, []int{EventSchemaV1}, SupportedEventVersions())
+}
+
+func TestDecodeEventIsStrictWhenConfigured(t *testing.T) {
+	payload := []byte(`{"number":"2507","amount":1000,"curency":"USD","retry_attempt":"2"}`)
+
+	_, err := NewProcessor(&fakeClient{}).DecodeEvent(payload)
+	require.Equal(t, CodeValidation, CodeOf(err))
+	var invalid *api.ValidationError
+	require.ErrorAs(t, err, &invalid)
+	require.Equal(t, []string{"$.retry_attempt: must be an integer"}, invalid.Problems)
+
+	_, err = NewProcessor(&fakeClient{}, WithStrictDecoding()).DecodeEvent(payload)
+	require.ErrorAs(t, err, &invalid)
+	require.Equal(t, []string{
+		"$: unknown field curency (did you mean currency?)",
+		"$.retry_attempt: must be an integer",
+	}, invalid.Problems)
+
+	event, err := NewProcessor(&fakeClient{}, WithStrictDecoding()).DecodeEvent([]byte(`{"number":"2507","amount":1000,"metadata":{"plan":"pro"}}`))
+	require.NoError(t, err)
+	require.Equal(t, "pro", event.Metadata["plan"])
+}
@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/berniyo/paypack-lambda/internal/tracing"
+)
+
+const slackPostMessageURL = "https://slack.com/api/chat.postMessage"
+
+// SlackDestination is where SlackSender posts: an incoming webhook URL, or a bot token and
+// the channel it posts to with chat.postMessage.
+type SlackDestination struct {
+	WebhookURL string
+	Token      string
+	Channel    string
+}
+
+// SlackSender posts failures and high-value confirmations to a Slack channel for operations.
+type SlackSender struct {
+	dest       SlackDestination
+	filter     OperationsFilter
+	apiURL     string
+	httpClient *http.Client
+}
+
+// NewSlackSender builds a Slack notifier.
+func NewSlackSender(dest SlackDestination, filter OperationsFilter, client *http.Client) (*SlackSender, error) {
+	dest.WebhookURL = strings.TrimSpace(dest.WebhookURL)
+	dest.Token = strings.TrimSpace(dest.Token)
+	dest.Channel = strings.TrimSpace(dest.Channel)
+	switch {
+	case dest.WebhookURL == "" && dest.Token == "":
+		return nil, errors.New("slack webhook URL or bot token is required")
+	case dest.WebhookURL != "" && dest.Token != "":
+		return nil, errors.New("slack webhook URL and bot token are mutually exclusive")
+	case dest.Token != "" && dest.Channel == "":
+		return nil, errors.New("slack channel is required with a bot token")
+	}
+	if err := filter.validate(); err != nil {
+		return nil, err
+	}
+	if client == nil {
+		client = &http.Client{Timeout: defaultCallbackTimeout}
+	}
+	return &SlackSender{dest: dest, filter: filter, apiURL: slackPostMessageURL, httpClient: client}, nil
+}
+
+// Send implements CallbackSender. Outcomes the filter does not select are skipped.
+func (s *SlackSender) Send(ctx context.Context, payload SubscriptionResponse) error {
+	if !s.filter.selects(payload) {
+		return nil
+	}
+
+	msg := slackMessage(payload)
+	url := s.dest.WebhookURL
+	if s.dest.Token != "" {
+		msg["channel"] = s.dest.Channel
+		url = s.apiURL
+	}
+	body := &bytes.Buffer{}
+	if err := json.NewEncoder(body).Encode(msg); err != nil {
+		return fmt.Errorf("encode slack message: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, body)
+	if err != nil {
+		return fmt.Errorf("build slack request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	tracing.Inject(ctx, req.Header.Set)
+	if s.dest.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.dest.Token)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("send slack message: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("slack returned %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	if s.dest.Token != "" {
+		// chat.postMessage reports failures in a 200 body.
+		var result struct {
+			OK    bool   `json:"ok"`
+			Error string `json:"error"`
+		}
+		if err := json.Unmarshal(data, &result); err != nil {
+			return fmt.Errorf("decode slack response: %w", err)
+		}
+		if !result.OK {
+			return fmt.Errorf("slack rejected message: %s", result.Error)
+		}
+	}
+	return nil
+}
+
+// slackMessage renders resp as a mrkdwn section with the details as fields, plus a plain
+// text fallback for notifications.
+func slackMessage(resp SubscriptionResponse) map[string]any {
+	amount, currency := outcomeAmount(resp)
+	headline := fmt.Sprintf(":white_check_mark: Payment of %.0f %s confirmed", amount, currency)
+	if resp.Status != "success" || !resp.Found {
+		headline = fmt.Sprintf(":x: Payment of %.0f %s %s", amount, currency, orDefault(resp.Status, "failed"))
+	}
+
+	fields := []map[string]string{}
+	field := func(name, value string) {
+		if value != "" {
+			fields = append(fields, map[string]string{"type": "mrkdwn", "text": "*" + name + "*\n" + value})
+		}
+	}
+	field("Ref", resp.Reference)
+	field("Number", resp.Request.Number)
+	field("Client", resp.Request.Client)
+	field("Subscription", resp.Request.SubscriptionID)
+	field("Code", string(resp.Code))
+	field("Reason", resp.Message)
+
+	blocks := []map[string]any{{"type": "section", "text": map[string]string{"type": "mrkdwn", "text": "*" + headline + "*"}}}
+	if len(fields) > 0 {
+		blocks = append(blocks, map[string]any{"type": "section", "fields": fields})
+	}
+	text := headline
+	if resp.Reference != "" {
+		text += " (ref " + resp.Reference + ")"
+	}
+	return map[string]any{"text": text, "blocks": blocks}
+}
+
+func orDefault(s, fallback string) string {
+	if s == "" {
+		return fallback
+	}
+	return s
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlackSenderFiltersOutcomes(t *testing.T) {
+	var posted []map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+		posted = append(posted, msg)
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	sender, err := NewSlackSender(SlackDestination{WebhookURL: srv.URL}, OperationsFilter{Failures: true, MinAmount: 50000}, srv.Client())
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, sender.Send(ctx, SubscriptionResponse{Reference: "small", Status: "success", Found: true, Request: SubscriptionEvent{Amount: 1000}}))
+	require.NoError(t, sender.Send(ctx, SubscriptionResponse{Reference: "link", Status: "pending", Request: SubscriptionEvent{Amount: 1000}}))
+	require.Empty(t, posted)
+
+	require.NoError(t, sender.Send(ctx, SubscriptionResponse{Reference: "big", Status: "success", Found: true, Request: SubscriptionEvent{Number: "2507", Amount: 75000}}))
+	require.NoError(t, sender.Send(ctx, SubscriptionResponse{
+		Reference: "bad",
+		Status:    "failed",
+		Code:      CodeInsufficientFunds,
+		Message:   "insufficient balance",
+		Request:   SubscriptionEvent{Number: "2508", Amount: 1000, Client: "acme"},
+	}))
+	require.Len(t, posted, 2)
+	require.Equal(t, ":white_check_mark: Payment of 75000 RWF confirmed (ref big)", posted[0]["text"])
+	require.Equal(t, ":x: Payment of 1000 RWF failed (ref bad)", posted[1]["text"])
+	fields := posted[1]["blocks"].([]any)[1].(map[string]any)["fields"].([]any)
+	require.Contains(t, fields, map[string]any{"type": "mrkdwn", "text": "*Code*\nINSUFFICIENT_FUNDS"})
+}
+
+func TestSlackSenderPostsWithBotToken(t *testing.T) {
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "Bearer xoxb-1", r.Header.Get("Authorization"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		if got["channel"] != "#payments" {
+			w.Write([]byte(`{"ok":false,"error":"channel_not_found"}`))
+			return
+		}
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer srv.Close()
+
+	failure := SubscriptionResponse{Reference: "bad", Status: "failed", Request: SubscriptionEvent{Amount: 1000, Client: "acme"}}
+	sender, err := NewSlackSender(SlackDestination{Token: "xoxb-1", Channel: "#payments"}, OperationsFilter{Failures: true, Clients: []string{"acme"}}, srv.Client())
+	require.NoError(t, err)
+	sender.apiURL = srv.URL
+	require.NoError(t, sender.Send(context.Background(), failure))
+	require.Equal(t, "#payments", got["channel"])
+
+	got = nil
+	failure.Request.Client = "globex"
+	require.NoError(t, sender.Send(context.Background(), failure))
+	require.Nil(t, got)
+
+	sender, err = NewSlackSender(SlackDestination{Token: "xoxb-1", Channel: "#missing"}, OperationsFilter{Failures: true}, srv.Client())
+	require.NoError(t, err)
+	sender.apiURL = srv.URL
+	require.EqualError(t, sender.Send(context.Background(), failure), "slack rejected message: channel_not_found")
+}
+
+func TestNewSlackSenderValidatesConfiguration(t *testing.T) {
+	_, err := NewSlackSender(SlackDestination{}, OperationsFilter{Failures: true}, nil)
+	require.Error(t, err)
+	_, err = NewSlackSender(SlackDestination{Token: "xoxb-1"}, OperationsFilter{Failures: true}, nil)
+	require.Error(t, err)
+	_, err = NewSlackSender(SlackDestination{WebhookURL: "https://hooks.slack.com/x"}, OperationsFilter{}, nil)
+	require.Error(t, err)
+}
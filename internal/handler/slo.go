@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/berniyo/paypack-lambda/internal/metrics"
+)
+
+const defaultSLOWindow = 5 * time.Minute
+
+// WithSLOWindow sets the span of the rolling success-rate and confirmation-latency series
+// (default 5 minutes). A non-positive span disables the rolling series; the raw outcome
+// counter and latency distribution are always recorded.
+func WithSLOWindow(span time.Duration) Option {
+	return func(p *Processor) {
+		p.sloWindow = nil
+		if span > 0 {
+			p.sloWindow = metrics.NewWindow(span)
+		}
+	}
+}
+
+// recordSLO records a concluded cash-in per provider and client. latency runs from the
+// cash-in being accepted to Paypack's verdict, across resumed invocations.
+func (p *Processor) recordSLO(resp SubscriptionResponse, latency time.Duration) {
+	provider := "unknown"
+	if resp.Transaction != nil && resp.Transaction.Provider != "" {
+		provider = resp.Transaction.Provider
+	}
+	client := resp.Request.Client
+	if client == "" {
+		client = "none"
+	}
+	tags := []metrics.Tag{metrics.T("provider", provider), metrics.T("client", client)}
+
+	ok := resp.Found && resp.Status == "success"
+	result := "failure"
+	if ok {
+		result = "success"
+		p.metrics.Observe(metrics.SLOConfirmation, latency.Seconds(), tags...)
+	}
+	p.metrics.Count(metrics.SLOOutcomes, 1, append(tags, metrics.T("result", result))...)
+
+	if p.sloWindow == nil {
+		return
+	}
+	stats := p.sloWindow.Add(provider+"\x00"+client, ok, latency)
+	p.metrics.Observe(metrics.SLOSuccessRate, stats.SuccessRate, tags...)
+	if stats.Succeeded > 0 {
+		p.metrics.Observe(metrics.SLOConfirmationP50, stats.P50.Seconds(), tags...)
+		p.metrics.Observe(metrics.SLOConfirmationP99, stats.P99.Seconds(), tags...)
+	}
+}
@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/berniyo/paypack-lambda/internal/metrics"
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+func TestProcessorRecordsRollingSLOPerProviderAndClient(t *testing.T) {
+	statuses := []string{"success", "failed", "success", "success"}
+	var calls int
+	client := &fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			calls++
+			return &paypack.Transaction{Ref: "ref"}, nil
+		},
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: ref, Status: statuses[calls-1], Provider: "mtn"}, nil
+		},
+	}
+	rec := &recordingMetrics{}
+	p := NewProcessor(client, WithPollInterval(time.Millisecond), WithMetrics(rec))
+
+	for range statuses {
+		_, err := p.Handle(context.Background(), SubscriptionEvent{Number: "2507", Amount: 100, Client: "shop"})
+		require.NoError(t, err)
+	}
+
+	require.Equal(t, 3.0, rec.counts[metrics.SLOOutcomes+" provider=mtn client=shop result=success"])
+	require.Equal(t, 1.0, rec.counts[metrics.SLOOutcomes+" provider=mtn client=shop result=failure"])
+	require.Equal(t, 0.75, rec.observed[metrics.SLOSuccessRate+" provider=mtn client=shop"])
+	require.Contains(t, rec.observed, metrics.SLOConfirmationP99+" provider=mtn client=shop")
+}
+
+func TestProcessorSLOWindowCanBeDisabled(t *testing.T) {
+	client := &fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: "ref"}, nil
+		},
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: ref, Status: "success"}, nil
+		},
+	}
+	rec := &recordingMetrics{}
+	p := NewProcessor(client, WithPollInterval(time.Millisecond), WithMetrics(rec), WithSLOWindow(0))
+
+	_, err := p.Handle(context.Background(), SubscriptionEvent{Number: "2507", Amount: 100})
+	require.NoError(t, err)
+	require.Equal(t, 1.0, rec.counts[metrics.SLOOutcomes+" provider=unknown client=none result=success"])
+	require.NotContains(t, rec.observed, metrics.SLOSuccessRate+" provider=unknown client=none")
+}
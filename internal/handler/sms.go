@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/berniyo/paypack-lambda/internal/tracing"
+)
+
+// SMSSender texts the outcome to the charged number through an HTTP SMS gateway accepting
+// {"to", "text", "sender"} JSON with bearer authentication (e.g. Pindo).
+type SMSSender struct {
+	url        string
+	token      string
+	senderID   string
+	httpClient *http.Client
+}
+
+// NewSMSSender builds an SMS gateway client.
+func NewSMSSender(url, token, senderID string, client *http.Client) (*SMSSender, error) {
+	url = strings.TrimSpace(url)
+	if url == "" {
+		return nil, errors.New("sms gateway URL is required")
+	}
+	if client == nil {
+		client = &http.Client{Timeout: defaultCallbackTimeout}
+	}
+	return &SMSSender{url: url, token: token, senderID: senderID, httpClient: client}, nil
+}
+
+// Send implements CallbackSender.
+func (s *SMSSender) Send(ctx context.Context, payload SubscriptionResponse) error {
+	to := strings.TrimSpace(payload.Request.Number)
+	if to == "" {
+		return nil
+	}
+	if !strings.HasPrefix(to, "+") {
+		to = "+" + to
+	}
+
+	body := &bytes.Buffer{}
+	if err := json.NewEncoder(body).Encode(map[string]string{
+		"to":     to,
+		"text":   notificationText(payload),
+		"sender": s.senderID,
+	}); err != nil {
+		return fmt.Errorf("encode sms payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, body)
+	if err != nil {
+		return fmt.Errorf("build sms request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	tracing.Inject(ctx, req.Header.Set)
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("send sms request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("sms gateway returned %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/berniyo/paypack-lambda/internal/wspush"
+)
+
+// Stages reported to WebSocket clients while a cash-in is confirmed.
+const (
+	StageInitiated = "initiated"
+	StagePending   = "pending"
+	StageConfirmed = "confirmed"
+	StageFailed    = "failed"
+)
+
+// ConnectionPoster posts a message to an API Gateway WebSocket connection.
+type ConnectionPoster interface {
+	PostToConnection(ctx context.Context, connectionID string, data []byte) error
+}
+
+// StatusUpdate is the message pushed to an event's connection_id.
+type StatusUpdate struct {
+	Type    string    `json:"type"`
+	Ref     string    `json:"ref"`
+	Stage   string    `json:"stage"`
+	Status  string    `json:"status,omitempty"`
+	Attempt int       `json:"attempt,omitempty"`
+	Message string    `json:"message,omitempty"`
+	At      time.Time `json:"at"`
+}
+
+// StatusListener observes every status transition of every cash-in the processor confirms,
+// including outcomes delivered by webhook. StatusChanged must not block.
+type StatusListener interface {
+	StatusChanged(update StatusUpdate)
+}
+
+type connectionKey struct{}
+
+// WithStatusUpdates pushes initiated, pending and final updates to the WebSocket connection
+// named by an event's connection_id while it is confirmed. Pushes are best effort: failures
+// are logged, and a closed connection stops further pushes for that invocation.
+func WithStatusUpdates(poster ConnectionPoster) Option {
+	return func(p *Processor) {
+		p.statusPoster = poster
+	}
+}
+
+// WithStatusListener reports every status update to l, such as the server's SSE broker.
+func WithStatusListener(l StatusListener) Option {
+	return func(p *Processor) {
+		if l != nil {
+			p.statusListeners = append(p.statusListeners, l)
+		}
+	}
+}
+
+// connectionContext carries event's connection into polling, keeping one already carried.
+func (p *Processor) connectionContext(ctx context.Context, event SubscriptionEvent) context.Context {
+	if p.statusPoster == nil || event.ConnectionID == "" || ctx.Value(connectionKey{}) != nil {
+		return ctx
+	}
+	return context.WithValue(ctx, connectionKey{}, &liveConnection{id: event.ConnectionID})
+}
+
+type liveConnection struct {
+	id   string
+	gone bool
+}
+
+// pushStatus reports update to the listeners and to the connection carried by ctx, if any.
+func (p *Processor) pushStatus(ctx context.Context, update StatusUpdate) {
+	update.Type = "payment.status"
+	update.At = time.Now().UTC()
+	for _, l := range p.statusListeners {
+		l.StatusChanged(update)
+	}
+
+	conn, _ := ctx.Value(connectionKey{}).(*liveConnection)
+	if conn == nil || conn.gone {
+		return
+	}
+	data, err := json.Marshal(update)
+	if err != nil {
+		return
+	}
+	if err := p.statusPoster.PostToConnection(ctx, conn.id, data); err != nil {
+		if errors.Is(err, wspush.ErrGone) {
+			conn.gone = true
+		}
+		p.logf(ctx, "status update %s for ref=%s to connection %s failed: %v", update.Stage, update.Ref, conn.id, err)
+	}
+}
+
+// pushOutcome reports the final stage of resp.
+func (p *Processor) pushOutcome(ctx context.Context, resp SubscriptionResponse) {
+	update := StatusUpdate{Ref: resp.Reference, Stage: StageFailed, Status: resp.Status, Message: resp.Message}
+	switch {
+	case resp.Found && resp.Status == "success":
+		update.Stage = StageConfirmed
+	case resp.Status == "pending":
+		update.Stage = StagePending
+	}
+	p.pushStatus(ctx, update)
+}
@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/berniyo/paypack-lambda/internal/wspush"
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+type recordingPoster struct {
+	connections []string
+	updates     []StatusUpdate
+	err         error
+}
+
+func (r *recordingPoster) PostToConnection(ctx context.Context, connectionID string, data []byte) error {
+	var update StatusUpdate
+	if err := json.Unmarshal(data, &update); err != nil {
+		return err
+	}
+	r.connections = append(r.connections, connectionID)
+	r.updates = append(r.updates, update)
+	return r.err
+}
+
+func stages(updates []StatusUpdate) []string {
+	out := make([]string, len(updates))
+	for i, u := range updates {
+		out[i] = u.Stage
+	}
+	return out
+}
+
+func TestProcessorPushesStatusUpdatesToConnection(t *testing.T) {
+	calls := 0
+	client := &fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: "abc"}, nil
+		},
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			if calls++; calls < 3 {
+				return nil, paypack.ErrTransactionNotFound
+			}
+			return &paypack.Transaction{Ref: ref, Status: "success"}, nil
+		},
+	}
+	poster := &recordingPoster{}
+	processor := NewProcessor(client, WithPollInterval(time.Millisecond), WithStatusUpdates(poster))
+
+	_, err := processor.Handle(context.Background(), SubscriptionEvent{Number: "2507", Amount: 1000, ConnectionID: "conn-1"})
+	require.NoError(t, err)
+	require.Equal(t, []string{StageInitiated, StagePending, StagePending, StageConfirmed}, stages(poster.updates))
+	require.Equal(t, 2, poster.updates[2].Attempt)
+	require.Equal(t, "payment.status", poster.updates[0].Type)
+	require.Equal(t, "abc", poster.updates[3].Ref)
+	for _, conn := range poster.connections {
+		require.Equal(t, "conn-1", conn)
+	}
+
+	poster.updates = nil
+	_, err = processor.Handle(context.Background(), SubscriptionEvent{Number: "2507", Amount: 1000})
+	require.NoError(t, err)
+	require.Empty(t, poster.updates, "events without a connection_id push nothing")
+}
+
+func TestProcessorStopsPushingToGoneConnections(t *testing.T) {
+	calls := 0
+	client := &fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: "abc"}, nil
+		},
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			if calls++; calls < 3 {
+				return nil, paypack.ErrTransactionNotFound
+			}
+			return &paypack.Transaction{Ref: ref, Status: "failed"}, nil
+		},
+	}
+	poster := &recordingPoster{err: wspush.ErrGone}
+	processor := NewProcessor(client, WithPollInterval(time.Millisecond), WithStatusUpdates(poster))
+
+	resp, err := processor.Handle(context.Background(), SubscriptionEvent{Number: "2507", Amount: 1000, ConnectionID: "conn-1"})
+	require.NoError(t, err)
+	require.Equal(t, "failed", resp.Status)
+	require.Equal(t, []string{StageInitiated}, stages(poster.updates))
+}
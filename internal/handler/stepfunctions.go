@@ -0,0 +1,239 @@
+package handler
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/berniyo/paypack-lambda/internal/checkpoint"
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+// StepState is the document a confirmation state machine threads through its states:
+// Initiate produces it, a Wait state sleeps for WaitSeconds, and CheckStatus advances it until
+// Done. The outcome, once known, is in Response and has already been finished (callbacks,
+// ledger, receipts) exactly as Handle would have.
+type StepState struct {
+	Ref         string                `json:"ref,omitempty"`
+	Status      string                `json:"status"`
+	Done        bool                  `json:"done"`
+	WaitSeconds int                   `json:"wait_seconds,omitempty"`
+	Attempts    int                   `json:"attempts,omitempty"`
+	StartedAt   time.Time             `json:"started_at,omitzero"`
+	Deadline    time.Time             `json:"deadline,omitzero"`
+	Pending     json.RawMessage       `json:"pending,omitempty"`
+	Response    *SubscriptionResponse `json:"response,omitempty"`
+	Signature   string                `json:"signature,omitempty"`
+}
+
+// WithStepStateSecret signs the states Initiate and CheckStatus return with an HMAC under
+// secret, and CheckStatus refuses pending states without a valid signature, so a principal
+// allowed to invoke check_status cannot fabricate the charge it settles. Under
+// WithEventSignatures, CheckStatus refuses every pending state without it.
+func WithStepStateSecret(secret string) Option {
+	return func(p *Processor) {
+		p.stepSecret = secret
+	}
+}
+
+// Initiate charges a cash-in event without waiting for confirmation, so a Step Functions Wait
+// state can hold the multi-minute wait instead of Lambda compute. Events settled by a gate
+// (risk block, approval hold) come back Done with their response.
+func (p *Processor) Initiate(ctx context.Context, event SubscriptionEvent) (state StepState, err error) {
+	defer p.flush(ctx)
+	ctx = correlationContext(ctx, event)
+	ctx, inv := p.beginInvocation(ctx, "initiate")
+	defer func() { inv.end(stepOutcome(state, err), state.Ref) }()
+	ctx = traceContext(ctx, event)
+	if err := p.verifyEvent(ctx, event); err != nil {
+		return StepState{}, err
+	}
+	if event.Action != "" && event.Action != ActionCashIn {
+		return StepState{}, withCode(CodeValidation, fmt.Errorf("initiate handles cash-in events, not %q", event.Action))
+	}
+	if err := normalizeAmounts(&event); err != nil {
+		return StepState{}, err
+	}
+	event.Metadata = withoutReserved(event.Metadata)
+	if err := p.sanitizeMetadata(&event); err != nil {
+		return StepState{}, withCode(CodeValidation, err)
+	}
+
+	event, _, held, err := p.admitCashIn(ctx, event)
+	if err != nil {
+		return StepState{}, err
+	}
+	if held != nil {
+		return p.signStepState(StepState{Ref: held.Reference, Status: held.Status, Done: true, Response: held})
+	}
+
+	cp, pending, err := p.initiateCashIn(ctx, event)
+	if err != nil {
+		return StepState{}, err
+	}
+	state, err = p.stepState(cp, pending)
+	if err != nil {
+		return StepState{}, err
+	}
+	// The state machine carries the pending state from here; a checkpoint would let the
+	// reconciler confirm the same charge behind its back.
+	p.clearCheckpoint(ctx, cp.Ref)
+	return p.signStepState(state)
+}
+
+// stepState is the pending state of a charge just initiated, due for its first check.
+func (p *Processor) stepState(cp *checkpoint.Checkpoint, pending pendingCashIn) (StepState, error) {
+	snapshot, err := json.Marshal(pending)
+	if err != nil {
+		return StepState{}, fmt.Errorf("encode pending cash-in for ref=%s: %w", cp.Ref, err)
+	}
+	return StepState{
+		Ref:         cp.Ref,
+		Status:      "pending",
+		StartedAt:   cp.StartedAt,
+		Deadline:    cp.Deadline,
+		Pending:     snapshot,
+		WaitSeconds: p.stepWait(pending.Provider, 0, cp, pending.Event),
+	}, nil
+}
+
+// CheckStatus looks the state's transaction up once. A confirmed transaction, or one still
+// unknown past the deadline, is finished and returned Done; otherwise the state comes back
+// pending with the next WaitSeconds.
+func (p *Processor) CheckStatus(ctx context.Context, state StepState) (_ StepState, err error) {
+	if state.Done {
+		return state, nil
+	}
+	defer p.flush(ctx)
+	ctx, inv := p.beginInvocation(ctx, "check_status")
+	defer func() { inv.end(stepOutcome(state, err), state.Ref) }()
+	if state.Ref == "" {
+		return state, withCode(CodeValidation, errors.New("ref is required for check_status"))
+	}
+	if err := p.verifyStepState(state); err != nil {
+		return state, err
+	}
+
+	var pending pendingCashIn
+	if len(state.Pending) > 0 {
+		if err := json.Unmarshal(state.Pending, &pending); err != nil {
+			return state, withCode(CodeValidation, fmt.Errorf("decode pending cash-in for ref=%s: %w", state.Ref, err))
+		}
+	}
+	ctx = traceContext(ctx, pending.Event)
+	ctx = correlationContext(ctx, pending.Event)
+	ctx = p.connectionContext(ctx, pending.Event)
+	cp := &checkpoint.Checkpoint{
+		Ref:       state.Ref,
+		Deadline:  state.Deadline,
+		StartedAt: state.StartedAt,
+		Attempts:  state.Attempts,
+	}
+
+	resp := pending.response(state.Ref)
+	txn, err := p.findTransaction(ctx, state.Ref)
+	switch {
+	case err == nil:
+		resp.Status = txn.Status
+		resp.Found = true
+		resp.Transaction = txn
+		p.observeConfirmation(pending.Provider, time.Since(cp.StartedAt))
+	case !errors.Is(err, paypack.ErrTransactionNotFound):
+		return state, withCode(paypackCode(err), fmt.Errorf("status lookup failed: %w", err))
+	case !cp.Deadline.IsZero() && !time.Now().Before(cp.Deadline):
+		resp.Status = "failed"
+		resp.Message = notConfirmedMessage(p.confirmTimeout(pending.Event))
+		resp.Code = CodeConfirmationTimeout
+		resp.Attempts = cp.Attempts + 1
+	case p.attemptsExhausted(pending.Event, cp.Attempts+1):
+		resp.Status = "failed"
+		resp.Message = fmt.Sprintf("transaction not confirmed after %d attempts", cp.Attempts+1)
+		resp.Code = CodeConfirmationTimeout
+		resp.Attempts = cp.Attempts + 1
+	default:
+		cp.Attempts++
+		p.pushStatus(ctx, StatusUpdate{Ref: cp.Ref, Stage: StagePending, Status: "pending", Attempt: cp.Attempts})
+		state.Attempts = cp.Attempts
+		state.WaitSeconds = p.stepWait(pending.Provider, cp.Attempts, cp, pending.Event)
+		return p.signStepState(state)
+	}
+
+	p.settle(ctx, cp, &resp)
+	state.Status = resp.Status
+	state.Done = true
+	state.WaitSeconds = 0
+	state.Response = &resp
+	return p.signStepState(state)
+}
+
+// stepStateSignature is the base64 HMAC-SHA256 under secret of state's canonical JSON
+// without its signature. The JSON is canonical because Step Functions does not keep the
+// formatting of the documents it passes between states.
+func stepStateSignature(state StepState, secret string) (string, error) {
+	state.Signature = ""
+	payload, err := canonicalJSON(state)
+	if err != nil {
+		return "", fmt.Errorf("encode step state for signing: %w", err)
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// signStepState applies WithStepStateSecret to a state about to be returned.
+func (p *Processor) signStepState(state StepState) (StepState, error) {
+	if p.stepSecret == "" {
+		return state, nil
+	}
+	signature, err := stepStateSignature(state, p.stepSecret)
+	if err != nil {
+		return StepState{}, err
+	}
+	state.Signature = signature
+	return state, nil
+}
+
+// verifyStepState checks that state was signed by signStepState, so its pending event and
+// deadline are the ones Initiate produced.
+func (p *Processor) verifyStepState(state StepState) error {
+	if p.stepSecret == "" {
+		if len(p.eventVerifiers) > 0 {
+			return withCode(CodeUnauthorized, errors.New("check_status needs a step state secret under event signatures"))
+		}
+		return nil
+	}
+	want, err := stepStateSignature(state, p.stepSecret)
+	if err != nil {
+		return withCode(CodeUnauthorized, err)
+	}
+	if state.Signature == "" || !hmac.Equal([]byte(state.Signature), []byte(want)) {
+		return withCode(CodeUnauthorized, fmt.Errorf("step state signature for ref=%s is missing or invalid", state.Ref))
+	}
+	return nil
+}
+
+// stepWait is the poll delay for attempt in whole seconds, as Wait states take, ending no
+// later than the deadline. An event's poll_interval_seconds replaces the adaptive delay.
+func (p *Processor) stepWait(provider string, attempt int, cp *checkpoint.Checkpoint, event SubscriptionEvent) int {
+	delay := p.pollDelay(provider, attempt, time.Since(cp.StartedAt))
+	if interval, _ := p.pollTuning(event); interval > 0 {
+		delay = interval
+	}
+	if left := time.Until(cp.Deadline); left < delay {
+		delay = left
+	}
+	return max(int(math.Ceil(delay.Seconds())), 1)
+}
+
+func stepOutcome(state StepState, err error) string {
+	if err != nil {
+		return errorOutcome(err)
+	}
+	return state.Status
+}
@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+// roundTrip passes state through JSON, as Step Functions does between states.
+func roundTrip(t *testing.T, state StepState) StepState {
+	t.Helper()
+	data, err := json.Marshal(state)
+	require.NoError(t, err)
+	var out StepState
+	require.NoError(t, json.Unmarshal(data, &out))
+	return out
+}
+
+func TestStepFunctionsConfirmAcrossInvocations(t *testing.T) {
+	lookups := 0
+	client := &fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: "abc"}, nil
+		},
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			lookups++
+			if lookups == 1 {
+				return nil, paypack.ErrTransactionNotFound
+			}
+			return &paypack.Transaction{Ref: ref, Status: "success", Amount: 1000}, nil
+		},
+	}
+	cb := &fakeCallback{}
+	processor := NewProcessor(client, WithPollInterval(2*time.Second), WithCallbackSender(cb))
+	ctx := context.Background()
+
+	state, err := processor.Initiate(ctx, SubscriptionEvent{Number: "2507", Amount: 1000, Client: "acme"})
+	require.NoError(t, err)
+	require.Equal(t, "abc", state.Ref)
+	require.Equal(t, "pending", state.Status)
+	require.False(t, state.Done)
+	require.Equal(t, 1, state.WaitSeconds, "the first lookup follows right away")
+	require.Zero(t, lookups, "initiate does not poll")
+
+	state, err = processor.CheckStatus(ctx, roundTrip(t, state))
+	require.NoError(t, err)
+	require.False(t, state.Done)
+	require.Equal(t, 1, state.Attempts)
+	require.Equal(t, 2, state.WaitSeconds)
+	require.Empty(t, cb.calls)
+
+	state, err = processor.CheckStatus(ctx, roundTrip(t, state))
+	require.NoError(t, err)
+	require.True(t, state.Done)
+	require.Equal(t, "success", state.Status)
+	require.Zero(t, state.WaitSeconds)
+	require.NotNil(t, state.Response)
+	require.Equal(t, "acme", state.Response.Request.Client)
+	require.Len(t, cb.calls, 1)
+	require.Equal(t, "abc", cb.calls[0].Reference)
+
+	again, err := processor.CheckStatus(ctx, state)
+	require.NoError(t, err)
+	require.Equal(t, state, again)
+	require.Equal(t, 2, lookups, "a done state is not looked up again")
+}
+
+func TestStepFunctionsTimeOutPastDeadline(t *testing.T) {
+	client := &fakeClient{
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			return nil, paypack.ErrTransactionNotFound
+		},
+	}
+	cb := &fakeCallback{}
+	processor := NewProcessor(client, WithCallbackSender(cb))
+
+	state, err := processor.CheckStatus(context.Background(), StepState{
+		Ref:       "abc",
+		Status:    "pending",
+		StartedAt: time.Now().Add(-6 * time.Minute),
+		Deadline:  time.Now().Add(-time.Minute),
+	})
+	require.NoError(t, err)
+	require.True(t, state.Done)
+	require.Equal(t, "failed", state.Status)
+	require.Equal(t, CodeConfirmationTimeout, state.Response.Code)
+	require.Len(t, cb.calls, 1)
+}
+
+func TestStepFunctionsInitiateRejectsOtherActions(t *testing.T) {
+	processor := NewProcessor(&fakeClient{})
+
+	_, err := processor.Initiate(context.Background(), SubscriptionEvent{Action: ActionRefund, Ref: "abc"})
+	require.Equal(t, CodeValidation, CodeOf(err))
+	_, err = processor.Initiate(context.Background(), SubscriptionEvent{Amount: 1000})
+	require.Equal(t, CodeValidation, CodeOf(err))
+	_, err = processor.CheckStatus(context.Background(), StepState{})
+	require.Equal(t, CodeValidation, CodeOf(err))
+}
+
+func TestStepFunctionsInitiateVerifiesEventSignatures(t *testing.T) {
+	charged := 0
+	client := &fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			charged++
+			return &paypack.Transaction{Ref: "abc"}, nil
+		},
+	}
+	processor := NewProcessor(client, WithEventSignatures(0, HMACEventVerifier("s3cret")), WithStepStateSecret("s3cret"))
+	ctx := context.Background()
+
+	_, err := processor.Initiate(ctx, SubscriptionEvent{Number: "2507", Amount: 1000})
+	require.Equal(t, CodeUnauthorized, CodeOf(err))
+	require.Zero(t, charged)
+
+	event := SubscriptionEvent{Number: "2507", Amount: 1000}
+	event.Signature, err = SignEvent(event, "s3cret")
+	require.NoError(t, err)
+	state, err := processor.Initiate(ctx, event)
+	require.NoError(t, err)
+	require.Equal(t, 1, charged)
+	require.NotEmpty(t, state.Signature)
+}
+
+func TestStepFunctionsCheckStatusRejectsForgedStates(t *testing.T) {
+	client := &fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: "abc"}, nil
+		},
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: ref, Status: "success", Amount: 1000}, nil
+		},
+	}
+	cb := &fakeCallback{}
+	processor := NewProcessor(client, WithCallbackSender(cb), WithStepStateSecret("s3cret"))
+	ctx := context.Background()
+
+	state, err := processor.Initiate(ctx, SubscriptionEvent{Number: "2507", Amount: 1000})
+	require.NoError(t, err)
+
+	forged := roundTrip(t, state)
+	forged.Pending = json.RawMessage(`{"event":{"number":"2507","amount":1000,"client":"someone-else"}}`)
+	_, err = processor.CheckStatus(ctx, forged)
+	require.Equal(t, CodeUnauthorized, CodeOf(err))
+
+	unsigned := roundTrip(t, state)
+	unsigned.Signature = ""
+	_, err = processor.CheckStatus(ctx, unsigned)
+	require.Equal(t, CodeUnauthorized, CodeOf(err))
+	require.Empty(t, cb.calls)
+
+	state, err = processor.CheckStatus(ctx, roundTrip(t, state))
+	require.NoError(t, err)
+	require.True(t, state.Done)
+	require.Len(t, cb.calls, 1)
+
+	strict := NewProcessor(client, WithEventSignatures(0, HMACEventVerifier("s3cret")))
+	_, err = strict.CheckStatus(ctx, StepState{Ref: "abc", Status: "pending"})
+	require.Equal(t, CodeUnauthorized, CodeOf(err), "event signatures without a step state secret refuse every state")
+}
@@ -0,0 +1,173 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"github.com/berniyo/paypack-lambda/internal/billing"
+	"github.com/berniyo/paypack-lambda/internal/workpool"
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+// metadataBillingRow is stamped on charges started from a billing table row.
+const metadataBillingRow = "billing_row"
+
+// BillingStreamHandler consumes the DynamoDB stream of a billing table. Every INSERT with
+// status "due" is claimed, charged and completed on the row, so teams drive billing by
+// writing rows.
+type BillingStreamHandler struct {
+	processor *Processor
+	rows      billing.Store
+}
+
+// NewBillingStreamHandler builds a stream consumer charging rows claimed from rows.
+func NewBillingStreamHandler(processor *Processor, rows billing.Store) (*BillingStreamHandler, error) {
+	if processor == nil {
+		return nil, errors.New("processor is required")
+	}
+	if rows == nil {
+		return nil, errors.New("billing store is required")
+	}
+	return &BillingStreamHandler{processor: processor, rows: rows}, nil
+}
+
+// Handle implements the DynamoDB Streams Lambda entry point. Records are charged concurrently
+// (up to the batch concurrency). Records that could not be charged for a transient reason are
+// released back to due and reported as batch item failures, so the event source mapping must
+// enable ReportBatchItemFailures.
+func (b *BillingStreamHandler) Handle(ctx context.Context, event events.DynamoDBEvent) (resp events.DynamoDBEventResponse, err error) {
+	p := b.processor
+	defer p.flush(ctx)
+	ctx, inv := p.beginInvocation(ctx, "billing_stream")
+	defer func() {
+		outcome := "ok"
+		if len(resp.BatchItemFailures) > 0 {
+			outcome = "partial"
+		}
+		inv.end(outcome, "")
+	}()
+
+	retry := workpool.Map(ctx, p.batchConcurrency, event.Records, b.process)
+	for i, failed := range retry {
+		if failed {
+			resp.BatchItemFailures = append(resp.BatchItemFailures, events.DynamoDBBatchItemFailure{
+				ItemIdentifier: event.Records[i].Change.SequenceNumber,
+			})
+		}
+	}
+	return resp, nil
+}
+
+// process charges one record and reports whether it should be redelivered. Only failures
+// known to leave the customer uncharged (the cash-in never reached Paypack, or a store error
+// before the charge) are redelivered. Paypack being unavailable once the request was sent
+// completes the row as pending, since the charge may have gone through; any other error
+// completes it as failed so a permanent one cannot block the shard.
+func (b *BillingStreamHandler) process(ctx context.Context, record events.DynamoDBEventRecord) bool {
+	p := b.processor
+	image := record.Change.NewImage
+	if record.EventName != string(events.DynamoDBOperationTypeInsert) || streamString(image, "status") != billing.StatusDue {
+		return false
+	}
+	id := streamString(record.Change.Keys, "id")
+	if id == "" {
+		p.logf(ctx, "billing stream record %s has no id key; skipped", record.EventID)
+		return false
+	}
+	if time.Until(p.pollDeadline(ctx, time.Now().Add(p.timeout))) < p.pollInterval {
+		// Leave the row due: the redelivery gets a fresh invocation.
+		return true
+	}
+
+	switch err := b.rows.Claim(ctx, id); {
+	case errors.Is(err, billing.ErrNotDue):
+		p.logf(ctx, "billing row %s already claimed; skipped", id)
+		return false
+	case err != nil:
+		p.logf(ctx, "claim billing row %s: %v", id, err)
+		return true
+	}
+
+	event, err := billingEvent(id, image)
+	var out SubscriptionResponse
+	if err == nil {
+		out, err = p.handleCashIn(ctx, event)
+	}
+	result := billing.Result{CompletedAt: time.Now().UTC()}
+	code := CodeOf(err)
+	switch {
+	case err != nil && (errors.Is(err, paypack.ErrNotSent) || isStoreError(err)):
+		p.logf(ctx, "billing row %s not charged: %v", id, err)
+		if rerr := b.rows.Release(context.WithoutCancel(ctx), id); rerr != nil {
+			p.logf(ctx, "release billing row %s: %v", id, rerr)
+		}
+		return true
+	case err != nil && (code == CodePaypackUnavailable || code == CodeUnexpectedResponse):
+		result.Status = billing.StatusPending
+		result.Code = string(code)
+		result.Message = err.Error()
+	case err != nil:
+		result.Status = billing.StatusFailed
+		result.Code = string(code)
+		result.Message = err.Error()
+	default:
+		result.Status = billingStatus(out.Status)
+		result.Ref = out.Reference
+		result.Code = string(out.Code)
+		result.Message = out.Message
+	}
+	if err := b.rows.Complete(context.WithoutCancel(ctx), id, result); err != nil {
+		p.logf(ctx, "complete billing row %s as %s: %v", id, result.Status, err)
+	}
+	return false
+}
+
+// billingEvent reads a cash-in from a row's number (S), amount (N) and optional currency,
+// client and subscription_id (S). Its correlation ID, and so its idempotency key, is derived
+// from the row ID, so every delivery of a row charges it at most once.
+func billingEvent(id string, image map[string]events.DynamoDBAttributeValue) (SubscriptionEvent, error) {
+	event := SubscriptionEvent{
+		Number:         streamString(image, "number"),
+		Currency:       streamString(image, "currency"),
+		Client:         streamString(image, "client"),
+		SubscriptionID: streamString(image, "subscription_id"),
+		CorrelationID:  "billing-" + id,
+		Metadata:       map[string]any{metadataBillingRow: id},
+	}
+	amount, ok := image["amount"]
+	if !ok || amount.DataType() != events.DataTypeNumber {
+		return event, withCode(CodeValidation, errors.New("billing row amount must be a number"))
+	}
+	value, err := strconv.ParseFloat(amount.Number(), 64)
+	if err != nil {
+		return event, withCode(CodeValidation, fmt.Errorf("invalid billing row amount %q", amount.Number()))
+	}
+	event.Amount = value
+	return event, nil
+}
+
+// billingStatus maps an outcome status onto the row statuses.
+func billingStatus(status string) string {
+	switch status {
+	case "success":
+		return billing.StatusPaid
+	case "failed":
+		return billing.StatusFailed
+	default:
+		return billing.StatusPending
+	}
+}
+
+func streamString(image map[string]events.DynamoDBAttributeValue, name string) string {
+	v, ok := image[name]
+	if !ok || v.DataType() != events.DataTypeString {
+		return ""
+	}
+	return strings.TrimSpace(v.String())
+}
@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/require"
+
+	"github.com/berniyo/paypack-lambda/internal/billing"
+	"github.com/berniyo/paypack-lambda/internal/fx"
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+func billingRecord(seq, name string, image map[string]events.DynamoDBAttributeValue) events.DynamoDBEventRecord {
+	return events.DynamoDBEventRecord{
+		EventName: name,
+		Change: events.DynamoDBStreamRecord{
+			SequenceNumber: seq,
+			Keys:           map[string]events.DynamoDBAttributeValue{"id": image["id"]},
+			NewImage:       image,
+		},
+	}
+}
+
+func dueRow(id, number, amount string) map[string]events.DynamoDBAttributeValue {
+	return map[string]events.DynamoDBAttributeValue{
+		"id":     events.NewStringAttribute(id),
+		"status": events.NewStringAttribute(billing.StatusDue),
+		"number": events.NewStringAttribute(number),
+		"amount": events.NewNumberAttribute(amount),
+		"client": events.NewStringAttribute("acme"),
+	}
+}
+
+func TestBillingStreamChargesDueRows(t *testing.T) {
+	var charged []string
+	client := &fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			charged = append(charged, number)
+			switch number {
+			case "down":
+				return nil, fmt.Errorf("dial paypack: %w", paypack.ErrNotSent)
+			case "busy":
+				return nil, &paypack.APIError{StatusCode: 503}
+			}
+			return &paypack.Transaction{Ref: "ref-" + number}, nil
+		},
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: ref, Status: "success", Amount: 1000}, nil
+		},
+	}
+	cb := &fakeCallback{}
+	rows := billing.NewMemoryStore("row-1", "row-2", "row-3", "row-4", "row-5", "row-6")
+	processor := NewProcessor(client, WithPollInterval(time.Millisecond), WithBatchConcurrency(1), WithCallbackSender(cb),
+		WithFX(fx.StaticRates{"USD/RWF": 1300}, ""))
+	stream, err := NewBillingStreamHandler(processor, rows)
+	require.NoError(t, err)
+
+	paid := dueRow("row-1", "2507", "1000")
+	settled := dueRow("row-2", "2508", "1000")
+	settled["status"] = events.NewStringAttribute(billing.StatusPaid)
+	invalid := dueRow("row-3", "2509", "1000")
+	invalid["amount"] = events.NewStringAttribute("a lot")
+	unconvertible := dueRow("row-5", "2510", "10")
+	unconvertible["currency"] = events.NewStringAttribute("EUR")
+
+	resp, err := stream.Handle(context.Background(), events.DynamoDBEvent{Records: []events.DynamoDBEventRecord{
+		billingRecord("1", "INSERT", paid),
+		billingRecord("2", "INSERT", settled),
+		billingRecord("3", "INSERT", invalid),
+		billingRecord("4", "INSERT", dueRow("row-4", "down", "500")),
+		billingRecord("5", "MODIFY", paid),
+		billingRecord("6", "INSERT", paid),
+		billingRecord("7", "INSERT", unconvertible),
+		billingRecord("8", "INSERT", dueRow("row-6", "busy", "500")),
+	}})
+	require.NoError(t, err)
+	require.Equal(t, []events.DynamoDBBatchItemFailure{{ItemIdentifier: "4"}}, resp.BatchItemFailures)
+	require.Equal(t, []string{"2507", "down", "busy"}, charged, "each due row is charged once")
+
+	status, result, err := rows.Status("row-1")
+	require.NoError(t, err)
+	require.Equal(t, billing.StatusPaid, status)
+	require.Equal(t, "ref-2507", result.Ref)
+	require.Len(t, cb.calls, 1)
+	require.Equal(t, "row-1", cb.calls[0].Request.Metadata[metadataBillingRow])
+	require.Equal(t, "acme", cb.calls[0].Request.Client)
+	require.Equal(t, "billing-row-1", cb.calls[0].CorrelationID, "redeliveries of a row share its idempotency key")
+
+	status, result, err = rows.Status("row-3")
+	require.NoError(t, err)
+	require.Equal(t, billing.StatusFailed, status)
+	require.Equal(t, string(CodeValidation), result.Code)
+
+	status, result, err = rows.Status("row-5")
+	require.NoError(t, err)
+	require.Equal(t, billing.StatusFailed, status, "untagged errors are permanent, not redelivered forever")
+	require.Contains(t, result.Message, "currency conversion failed")
+
+	status, _, err = rows.Status("row-4")
+	require.NoError(t, err)
+	require.Equal(t, billing.StatusDue, status, "cash-ins that never reached Paypack are released for redelivery")
+
+	status, result, err = rows.Status("row-6")
+	require.NoError(t, err)
+	require.Equal(t, billing.StatusPending, status, "a cash-in Paypack may have taken is not charged again")
+	require.Equal(t, string(CodePaypackUnavailable), result.Code)
+}
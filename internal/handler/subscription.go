@@ -9,21 +9,117 @@ import (
 	"strings"
 	"time"
 
-	"github.com/berniyo/paypack-lambda/internal/paypack"
+	"github.com/berniyo/paypack-lambda/internal/alert"
+	"github.com/berniyo/paypack-lambda/internal/analytics"
+	"github.com/berniyo/paypack-lambda/internal/approval"
+	"github.com/berniyo/paypack-lambda/internal/audit"
+	"github.com/berniyo/paypack-lambda/internal/checkpoint"
+	"github.com/berniyo/paypack-lambda/internal/customer"
+	"github.com/berniyo/paypack-lambda/internal/eventstore"
+	"github.com/berniyo/paypack-lambda/internal/fee"
+	"github.com/berniyo/paypack-lambda/internal/fx"
+	"github.com/berniyo/paypack-lambda/internal/ledger"
+	"github.com/berniyo/paypack-lambda/internal/metrics"
+	"github.com/berniyo/paypack-lambda/internal/objectstore"
+	"github.com/berniyo/paypack-lambda/internal/outcome"
+	"github.com/berniyo/paypack-lambda/internal/receipt"
+	"github.com/berniyo/paypack-lambda/internal/redact"
+	"github.com/berniyo/paypack-lambda/internal/refund"
+	"github.com/berniyo/paypack-lambda/internal/retry"
+	"github.com/berniyo/paypack-lambda/internal/risk"
+	"github.com/berniyo/paypack-lambda/internal/split"
+	"github.com/berniyo/paypack-lambda/internal/subscription"
+	"github.com/berniyo/paypack-lambda/internal/tax"
+	"github.com/berniyo/paypack-lambda/internal/tracing"
+	"github.com/berniyo/paypack-lambda/internal/txcache"
+	"github.com/berniyo/paypack-lambda/internal/wallet"
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
 )
 
 // PaymentClient defines the subset of the Paypack client used by the processor.
 type PaymentClient interface {
-	CashIn(ctx context.Context, number string, amount float64) (*paypack.Transaction, error)
+	CashIn(ctx context.Context, number string, amount float64, opts ...paypack.CashInOption) (*paypack.Transaction, error)
 	FindTransaction(ctx context.Context, ref string) (*paypack.Transaction, error)
 }
 
-// SubscriptionEvent represents the payload sent to the Lambda function.
+// SubscriptionLifecycle applies payment outcomes to tracked subscriptions.
+type SubscriptionLifecycle interface {
+	Activate(ctx context.Context, id, ref string) (*subscription.Subscription, error)
+	MarkPastDue(ctx context.Context, id, ref, reason string) (*subscription.Subscription, error)
+}
+
+// Actions routed by Handle. An empty action is treated as ActionCashIn.
+const (
+	ActionCashIn         = "cashin"
+	ActionCustomerGet    = "customer_get"
+	ActionCustomerPut    = "customer_put"
+	ActionCustomerDelete = "customer_delete"
+	ActionReplay         = "replay"
+	ActionApprove        = "approve"
+	ActionReject         = "reject"
+	ActionResume         = "resume"
+	ActionExport         = "export"
+	ActionPaymentLink    = "payment_link"
+	ActionQRCode         = "qr"
+	ActionBalance        = "balance"
+	ActionAuditVerify    = "audit_verify"
+	ActionBatch          = "batch"
+	ActionStatus         = "status"
+	ActionRefund         = "refund"
+	ActionCashOut        = "cashout"
+	ActionQuote          = "quote"
+	ActionBulkStatus     = "bulk_status"
+	ActionErase          = "erase"
+)
+
+// SubscriptionEvent represents the payload sent to the Lambda function. Its schema version
+// selects how it is decoded; see SupportedEventVersions.
 type SubscriptionEvent struct {
-	Number   string         `json:"number"`
-	Amount   float64        `json:"amount"`
-	Client   string         `json:"client,omitempty"`
-	Metadata map[string]any `json:"metadata,omitempty"`
+	Version           int            `json:"version,omitempty"`
+	Action            string         `json:"action,omitempty"`
+	Ref               string         `json:"ref,omitempty"`
+	Number            string         `json:"number"`
+	Amount            float64        `json:"amount"`
+	AmountMinor       int64          `json:"amount_minor,omitempty"`
+	Currency          string         `json:"currency,omitempty"`
+	Client            string         `json:"client,omitempty"`
+	SubscriptionID    string         `json:"subscription_id,omitempty"`
+	ApprovalID        string         `json:"approval_id,omitempty"`
+	Approver          string         `json:"approver,omitempty"`
+	ApproverSignature string         `json:"approver_signature,omitempty"`
+	RequestedBy       string         `json:"requested_by,omitempty"`
+	Tax               *tax.Rule      `json:"tax,omitempty"`
+	RetryID           string         `json:"retry_id,omitempty"`
+	RetryAttempt      int            `json:"retry_attempt,omitempty"`
+	Export            *ExportFilter  `json:"export,omitempty"`
+	QR                string         `json:"qr,omitempty"`
+	Split             *split.Plan    `json:"split,omitempty"`
+	Metadata          map[string]any `json:"metadata,omitempty"`
+	Debug             bool           `json:"debug,omitempty"`
+	TraceParent       string         `json:"traceparent,omitempty"`
+	TraceState        string         `json:"tracestate,omitempty"`
+	ConnectionID      string         `json:"connection_id,omitempty"`
+	CorrelationID     string         `json:"correlation_id,omitempty"`
+	SignedAt          int64          `json:"signed_at,omitempty"`
+	Signature         string         `json:"signature,omitempty"`
+
+	// ConfirmTimeoutSeconds overrides how long confirmation is awaited, up to
+	// WithMaxConfirmTimeout.
+	ConfirmTimeoutSeconds int `json:"confirm_timeout_seconds,omitempty"`
+	// PollIntervalSeconds and MaxAttempts tune confirmation polling for this event, within
+	// WithPollBounds. After MaxAttempts lookups the payment is reported unconfirmed.
+	PollIntervalSeconds int `json:"poll_interval_seconds,omitempty"`
+	MaxAttempts         int `json:"max_attempts,omitempty"`
+	// ScheduledAt defers a cash-in or payout until that time; see WithScheduledEvents.
+	ScheduledAt time.Time `json:"scheduled_at,omitzero"`
+	// Provider names the mobile money provider (mtn, airtel) a quote is priced for.
+	Provider string `json:"provider,omitempty"`
+
+	Items []SubscriptionEvent `json:"items,omitempty"`
+	// Refs are the transactions a bulk_status event asks about.
+	Refs []string `json:"refs,omitempty"`
+
+	Customer *customer.Profile `json:"customer,omitempty"`
 }
 
 // SubscriptionResponse is emitted after processing completes.
@@ -33,7 +129,52 @@ type SubscriptionResponse struct {
 	Found       bool                 `json:"found"`
 	Transaction *paypack.Transaction `json:"transaction,omitempty"`
 	Message     string               `json:"message,omitempty"`
+	Code        ErrorCode            `json:"code,omitempty"`
+	Failure     *Failure             `json:"failure,omitempty"`
 	Request     SubscriptionEvent    `json:"request"`
+	// Client is the event's client, also set on Transaction, so outcomes join back to it.
+	Client string `json:"client,omitempty"`
+	// NumberID stands in for the customer number on destinations that mask or hash it; see
+	// FieldFilter.
+	NumberID string `json:"number_id,omitempty"`
+	// ScheduleID identifies a deferred event answered "scheduled".
+	ScheduleID string `json:"schedule_id,omitempty"`
+	// CorrelationID echoes the event's correlation_id.
+	CorrelationID string `json:"correlation_id,omitempty"`
+	// ContinuationToken ties a pending callback sent when confirmation was cut short to the
+	// final outcome delivered later; see WithContinuations.
+	ContinuationToken string `json:"continuation_token,omitempty"`
+	// Replayed marks a result answered from the one already recorded for the ref, without
+	// calling Paypack.
+	Replayed bool `json:"replayed,omitempty"`
+	// Attempts is the number of status lookups made before confirmation timed out.
+	Attempts int `json:"attempts,omitempty"`
+
+	// Fee and NetAmount (amount less fee) are set once a transaction is confirmed successful,
+	// so consumers reconcile on the same figures.
+	Fee       *float64 `json:"fee,omitempty"`
+	NetAmount *float64 `json:"net_amount,omitempty"`
+
+	Subscription  *subscription.Subscription `json:"subscription,omitempty"`
+	Customer      *customer.Profile          `json:"customer,omitempty"`
+	Receipt       *receipt.Receipt           `json:"receipt,omitempty"`
+	ApprovalID    string                     `json:"approval_id,omitempty"`
+	Risk          *risk.Assessment           `json:"risk,omitempty"`
+	Conversion    *fx.Conversion             `json:"conversion,omitempty"`
+	Fees          *fee.Breakdown             `json:"fees,omitempty"`
+	Tax           *tax.Breakdown             `json:"tax,omitempty"`
+	Retry         *RetryInfo                 `json:"retry,omitempty"`
+	Export        *ExportResult              `json:"export,omitempty"`
+	PaymentLink   *paypack.PaymentLink       `json:"payment_link,omitempty"`
+	QRCode        *QRCode                    `json:"qr_code,omitempty"`
+	Disbursements []Disbursement             `json:"disbursements,omitempty"`
+	Balance       *BalanceReport             `json:"balance,omitempty"`
+	Audit         *audit.Verification        `json:"audit,omitempty"`
+	Batch         *BatchReport               `json:"batch,omitempty"`
+	Refund        *Refund                    `json:"refund,omitempty"`
+	Quote         *paypack.Quote             `json:"quote,omitempty"`
+	Statuses      []RefStatus                `json:"statuses,omitempty"`
+	Erasure       *ErasureReport             `json:"erasure,omitempty"`
 }
 
 // CallbackSender delivers subscription outcomes to downstream systems.
@@ -45,9 +186,92 @@ type CallbackSender interface {
 type Processor struct {
 	client       PaymentClient
 	pollInterval time.Duration
+	poller       Poller
 	timeout      time.Duration
-	logger       *log.Logger
-	callback     CallbackSender
+	maxTimeout   time.Duration
+	pollBounds   PollBounds
+	// upstreamErrorLimit is the number of consecutive failed lookups tolerated while polling.
+	upstreamErrorLimit int
+	metadataLimits     MetadataLimits
+	// strictDecoding rejects unknown event fields in DecodeEvent.
+	strictDecoding bool
+	// lenientTypes lists the type variants DecodeEvent coerces.
+	lenientTypes  LenientTypes
+	finishReserve time.Duration
+	logger        *log.Logger
+	debugRate     float64
+	redactor      *redact.Redactor
+
+	batchDigest      bool
+	batchConcurrency int
+	metrics          metrics.Metrics
+	sloWindow        *metrics.Window
+	confirmations    *metrics.Window
+	callback         CallbackSender
+	asyncCallbacks   *asyncCallbacks
+	lifecycle        SubscriptionLifecycle
+	customers        customer.Store
+	erasureKey       []byte
+	receipts         ReceiptIssuer
+	events           eventstore.Store
+	outcomes         outcome.Store
+
+	approvals         approval.Store
+	approvalThreshold float64
+	approvalTTL       time.Duration
+	approvers         map[string]EventVerifier
+
+	risk RiskChecker
+
+	currencies         map[string]bool
+	rates              fx.RateProvider
+	settlementCurrency string
+
+	fees   FeeCalculator
+	taxes  *tax.Table
+	ledger ledger.Store
+	splits *split.Table
+
+	wallet         wallet.Store
+	driftThreshold float64
+	alerts         alert.Sender
+
+	audit *audit.Log
+
+	retries         retry.Store
+	retryMax        int
+	retryInterval   time.Duration
+	retryClassifier RetryClassifier
+	dunning         []time.Duration
+	schedules       retry.Store
+
+	payoutMin float64
+	payoutMax float64
+	refunds   refund.Store
+
+	eventVerifiers []EventVerifier
+	eventMaxAge    time.Duration
+
+	checkpoints   checkpoint.Store
+	continuations bool
+
+	reinvoker      SelfInvoker
+	reinvokeSecret string
+	stepSecret     string
+	delayQueue     DelayQueue
+	txCache        txcache.Store
+	txCacheTTL     time.Duration
+	analytics      analytics.Sink
+
+	exports      objectstore.Store
+	exportURLTTL time.Duration
+
+	qrStore      objectstore.Store
+	qrURLTTL     time.Duration
+	ussdTemplate string
+
+	statusPoster    ConnectionPoster
+	statusListeners []StatusListener
 }
 
 // Option customizes the processor.
@@ -71,6 +295,56 @@ func WithTimeout(d time.Duration) Option {
 	}
 }
 
+// WithMaxConfirmTimeout caps the confirm_timeout_seconds an event may ask for (by default the
+// WithTimeout value, so events can only shorten it). Waits longer than the invocation are
+// carried on from the checkpoint under WithCheckpoints.
+func WithMaxConfirmTimeout(d time.Duration) Option {
+	return func(p *Processor) {
+		if d > 0 {
+			p.maxTimeout = d
+		}
+	}
+}
+
+// confirmTimeout is how long to await confirmation of event.
+func (p *Processor) confirmTimeout(event SubscriptionEvent) time.Duration {
+	if event.ConfirmTimeoutSeconds <= 0 {
+		return p.timeout
+	}
+	limit := p.maxTimeout
+	if limit <= 0 {
+		limit = p.timeout
+	}
+	return min(time.Duration(event.ConfirmTimeoutSeconds)*time.Second, limit)
+}
+
+// notConfirmedMessage is the message of a payment Paypack did not confirm within window.
+func notConfirmedMessage(window time.Duration) string {
+	text := window.String()
+	switch {
+	case window == time.Minute:
+		text = "1 minute"
+	case window > 0 && window%time.Minute == 0:
+		text = fmt.Sprintf("%d minutes", window/time.Minute)
+	case window == time.Second:
+		text = "1 second"
+	case window > 0 && window%time.Second == 0:
+		text = fmt.Sprintf("%d seconds", window/time.Second)
+	}
+	return "transaction not confirmed within " + text
+}
+
+// WithCallbackReserve sets how long before the invocation deadline polling stops (default
+// 20s), leaving time to persist the outcome and deliver its callback. Size it to the slowest
+// callback destination plus any checkpoint, ledger or audit writes.
+func WithCallbackReserve(d time.Duration) Option {
+	return func(p *Processor) {
+		if d > 0 {
+			p.finishReserve = d
+		}
+	}
+}
+
 // WithLogger lets callers supply a custom logger.
 func WithLogger(l *log.Logger) Option {
 	return func(p *Processor) {
@@ -80,6 +354,24 @@ func WithLogger(l *log.Logger) Option {
 	}
 }
 
+// WithMetrics records cash-in results, poll latency and callback outcomes to m. Backends that
+// buffer, such as OTLP, are flushed as each entry point returns.
+func WithMetrics(m metrics.Metrics) Option {
+	return func(p *Processor) {
+		if m != nil {
+			p.metrics = m
+		}
+	}
+}
+
+// WithRedaction masks phone numbers and strips r's metadata keys in everything the processor
+// logs, in metric tags and in audit records. Callbacks and stored events keep full payloads.
+func WithRedaction(r *redact.Redactor) Option {
+	return func(p *Processor) {
+		p.redactor = r
+	}
+}
+
 // WithCallbackSender wires a callback destination invoked after processing concludes.
 func WithCallbackSender(sender CallbackSender) Option {
 	return func(p *Processor) {
@@ -87,90 +379,383 @@ func WithCallbackSender(sender CallbackSender) Option {
 	}
 }
 
+// WithSubscriptions moves the subscription named in each event through its lifecycle
+// based on the payment outcome, before the callback is emitted.
+func WithSubscriptions(lifecycle SubscriptionLifecycle) Option {
+	return func(p *Processor) {
+		p.lifecycle = lifecycle
+	}
+}
+
 // NewProcessor builds a Processor with sane defaults.
 func NewProcessor(client PaymentClient, opts ...Option) *Processor {
 	p := &Processor{
-		client:       client,
-		pollInterval: 5 * time.Second,
-		timeout:      5 * time.Minute,
-		logger:       log.New(os.Stdout, "paypack-lambda ", log.LstdFlags),
+		client:        client,
+		pollInterval:  5 * time.Second,
+		timeout:       5 * time.Minute,
+		finishReserve: defaultFinishReserve,
+		logger:        log.New(os.Stdout, "paypack-lambda ", log.LstdFlags),
+		metrics:       metrics.Nop{},
+
+		currencies:         map[string]bool{paypack.DefaultCurrency: true},
+		settlementCurrency: paypack.DefaultCurrency,
+
+		retryClassifier:    DefaultRetryClassifier,
+		batchConcurrency:   defaultBatchConcurrency,
+		upstreamErrorLimit: defaultUpstreamErrorLimit,
+		sloWindow:          metrics.NewWindow(defaultSLOWindow),
 	}
 
 	for _, opt := range opts {
 		opt(p)
 	}
+	p.logger = p.redactor.Logger(p.logger)
+	p.metrics = p.redactor.Metrics(p.metrics)
 
 	return p
 }
 
-// Handle implements the AWS Lambda handler entry point.
-func (p *Processor) Handle(ctx context.Context, event SubscriptionEvent) (SubscriptionResponse, error) {
+// Handle implements the AWS Lambda handler entry point, routing the event by its action.
+func (p *Processor) Handle(ctx context.Context, event SubscriptionEvent) (resp SubscriptionResponse, err error) {
+	defer p.flush(ctx)
+	ctx = correlationContext(ctx, event)
+	ctx, inv := p.beginInvocation(ctx, entryName(event.Action))
+	defer func() {
+		outcome := resp.Status
+		if err != nil {
+			outcome = errorOutcome(err)
+		} else {
+			resp.CorrelationID = orDefault(resp.CorrelationID, event.CorrelationID)
+			if resp.Failure == nil {
+				classifyFailure(&resp)
+			}
+		}
+		inv.end(outcome, resp.Reference)
+	}()
+	ctx = traceContext(ctx, event)
+	ctx = p.debugContext(ctx, event)
+	p.debugf(ctx, "event %s", debugJSON(event))
+	defer func() {
+		p.debugf(ctx, "response %s err=%v", debugJSON(resp), err)
+	}()
+	if err := p.verifyEvent(ctx, event); err != nil {
+		return SubscriptionResponse{}, err
+	}
+	if err := normalizeAmounts(&event); err != nil {
+		return SubscriptionResponse{}, err
+	}
+	event.Metadata = withoutReserved(event.Metadata)
+	for i := range event.Items {
+		event.Items[i].Metadata = withoutReserved(event.Items[i].Metadata)
+	}
+	if err := p.sanitizeMetadata(&event); err != nil {
+		return SubscriptionResponse{}, withCode(CodeValidation, err)
+	}
+	return p.route(ctx, event)
+}
+
+func (p *Processor) route(ctx context.Context, event SubscriptionEvent) (SubscriptionResponse, error) {
+	if time.Now().Before(event.ScheduledAt) {
+		return p.deferEvent(ctx, event)
+	}
+	if ref := strings.TrimSpace(event.Ref); ref != "" && replaysResolved(event.Action) {
+		if resp, ok := p.resolvedResult(ctx, ref, event); ok {
+			p.logf(ctx, "ref=%s already resolved as %s; replaying the stored result", ref, resp.Status)
+			return resp, nil
+		}
+	}
+	switch event.Action {
+	case "", ActionCashIn:
+		return p.handleCashIn(ctx, event)
+	case ActionCustomerGet, ActionCustomerPut, ActionCustomerDelete:
+		return p.handleCustomer(ctx, event)
+	case ActionReplay:
+		return p.handleReplay(ctx, event)
+	case ActionApprove, ActionReject:
+		return p.handleApproval(ctx, event)
+	case ActionResume:
+		return p.handleResume(ctx, event)
+	case ActionExport:
+		return p.handleExport(ctx, event)
+	case ActionPaymentLink:
+		return p.handlePaymentLink(ctx, event)
+	case ActionQRCode:
+		return p.handleQRCode(ctx, event)
+	case ActionBalance:
+		return p.handleBalance(ctx, event)
+	case ActionAuditVerify:
+		return p.handleAuditVerify(ctx, event)
+	case ActionBatch:
+		return p.handleBatch(ctx, event)
+	case ActionStatus:
+		return p.handleStatus(ctx, event)
+	case ActionRefund:
+		return p.handleRefund(ctx, event)
+	case ActionCashOut:
+		return p.handlePayout(ctx, event)
+	case ActionQuote:
+		return p.handleQuote(ctx, event)
+	case ActionBulkStatus:
+		return p.handleBulkStatus(ctx, event)
+	case ActionErase:
+		return p.handleErase(ctx, event)
+	default:
+		return SubscriptionResponse{}, withCode(CodeValidation, fmt.Errorf("unsupported action %q", event.Action))
+	}
+}
+
+func (p *Processor) handleCashIn(ctx context.Context, event SubscriptionEvent) (SubscriptionResponse, error) {
+	event, assessment, held, err := p.admitCashIn(ctx, event)
+	if err != nil || held != nil {
+		return deref(held), err
+	}
+
+	resp, err := p.runCashIn(ctx, event)
+	if err != nil {
+		return SubscriptionResponse{}, err
+	}
+	resp.Risk = assessment
+	return resp, nil
+}
+
+// admitCashIn validates event and runs the risk and approval gates before a charge. A non-nil
+// held response means a gate settled the event and nothing should be charged.
+func (p *Processor) admitCashIn(ctx context.Context, event SubscriptionEvent) (SubscriptionEvent, *risk.Assessment, *SubscriptionResponse, error) {
+	event.Currency = normalizeCurrency(event.Currency)
 	if err := validateEvent(event); err != nil {
+		return event, nil, nil, withCode(CodeValidation, err)
+	}
+
+	amount, _, _, err := p.chargeAmount(ctx, event)
+	if err != nil {
+		return event, nil, nil, err
+	}
+
+	assessment, err := p.assessRisk(ctx, event, amount)
+	if err != nil {
+		return event, nil, nil, err
+	}
+	if assessment != nil && assessment.Decision >= risk.Hold {
+		resp, err := p.applyRiskDecision(ctx, event, amount, assessment)
+		return event, assessment, &resp, err
+	}
+
+	if p.requiresApproval(amount) {
+		resp, err := p.holdForApproval(ctx, event, amount)
+		resp.Risk = assessment
+		return event, assessment, &resp, err
+	}
+	return event, assessment, nil, nil
+}
+
+func deref(resp *SubscriptionResponse) SubscriptionResponse {
+	if resp == nil {
+		return SubscriptionResponse{}
+	}
+	return *resp
+}
+
+// runCashIn charges the customer and polls for confirmation. Gates run before it.
+func (p *Processor) runCashIn(ctx context.Context, event SubscriptionEvent) (SubscriptionResponse, error) {
+	ctx = p.connectionContext(ctx, event)
+	cp, pending, err := p.initiateCashIn(ctx, event)
+	if err != nil {
 		return SubscriptionResponse{}, err
 	}
+	if p.delayQueue != nil {
+		return p.enqueueCheck(ctx, cp, pending)
+	}
+	return p.confirm(ctx, cp, pending)
+}
 
-	p.logger.Printf("initiating cashin for number=%s amount=%.2f", event.Number, event.Amount)
-	cashTxn, err := p.client.CashIn(ctx, event.Number, event.Amount)
+// initiateCashIn charges the customer and checkpoints the pending confirmation.
+func (p *Processor) initiateCashIn(ctx context.Context, event SubscriptionEvent) (*checkpoint.Checkpoint, pendingCashIn, error) {
+	profile := p.enrichFromCustomer(ctx, &event)
+
+	amount, currency, conversion, err := p.chargeAmount(ctx, event)
 	if err != nil {
-		return SubscriptionResponse{}, fmt.Errorf("cashin failed: %w", err)
+		return nil, pendingCashIn{}, err
+	}
+	taxLine := p.taxFor(event, amount, currency)
+	if taxLine != nil {
+		amount = taxLine.Gross
+	}
+
+	p.logf(ctx, "initiating cashin for number=%s amount=%.2f currency=%s", event.Number, amount, currency)
+	cashTxn, err := p.client.CashIn(idempotencyContext(ctx, event), event.Number, amount, paypack.WithCurrency(currency), paypack.WithClient(event.Client))
+	if err != nil {
+		return nil, pendingCashIn{}, withCode(paypackCode(err), fmt.Errorf("cashin failed: %w", err))
 	}
 
 	ref := cashTxn.Ref
-	p.logger.Printf("cashin accepted ref=%s; starting polling", ref)
+	p.logf(ctx, "cashin accepted ref=%s; starting polling", ref)
+	p.trackInitiated(ctx, event, ref, amount, currency)
+	ctx = p.connectionContext(ctx, event)
+	p.pushStatus(ctx, StatusUpdate{Ref: ref, Stage: StageInitiated, Status: "pending"})
 
-	polledTxn, err := p.pollTransaction(ctx, ref)
-	if err != nil {
-		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
-			resp := SubscriptionResponse{
-				Reference: ref,
-				Status:    "failed",
-				Found:     false,
-				Message:   "transaction not confirmed within 5 minutes",
-				Request:   event,
+	pending := pendingCashIn{Event: event, Customer: profile, Conversion: conversion, Tax: taxLine, Provider: cashTxn.Provider}
+	cp := p.checkpointFor(ctx, ref, pending, time.Now().Add(p.confirmTimeout(event)))
+	p.saveCheckpoint(ctx, cp)
+	return cp, pending, nil
+}
+
+// confirm polls until cp.Deadline and completes the outcome. When the invocation itself is
+// cut short the checkpoint is kept and a pending response is returned for a later resume.
+func (p *Processor) confirm(ctx context.Context, cp *checkpoint.Checkpoint, pending pendingCashIn) (SubscriptionResponse, error) {
+	start := time.Now()
+	ctx = correlationContext(ctx, pending.Event)
+	ctx = p.connectionContext(ctx, pending.Event)
+	resp := pending.response(cp.Ref)
+
+	polledTxn, err := p.pollTransaction(ctx, cp, pending.Provider, pending.Event)
+	p.observePoll(start, err)
+	if errors.Is(err, errPollAttemptsExhausted) {
+		resp.Status = "failed"
+		resp.Message = fmt.Sprintf("transaction not confirmed after %d attempts", cp.Attempts)
+		resp.Code = CodeConfirmationTimeout
+		resp.Attempts = cp.Attempts
+	} else if errors.Is(err, errUpstreamUnavailable) && p.checkpoints != nil {
+		p.logf(ctx, "confirmation of ref=%s stopped: %v; checkpoint kept for the reconciler", cp.Ref, err)
+		resp.Status = "pending"
+		resp.Message = "paypack unavailable during confirmation; the reconciler resumes it from the checkpoint"
+		resp.Code = CodePaypackUnavailable
+		resp.Attempts = cp.Attempts
+		p.pushOutcome(ctx, resp)
+		return resp, nil
+	} else if err != nil {
+		if !errors.Is(err, context.DeadlineExceeded) && !errors.Is(err, context.Canceled) {
+			return SubscriptionResponse{}, withCode(paypackCode(err), err)
+		}
+		cutShort := time.Now().Before(cp.Deadline)
+		if (ctx.Err() != nil || cutShort) && p.checkpoints != nil {
+			p.logf(ctx, "confirmation of ref=%s interrupted after %d attempts; checkpoint kept", cp.Ref, cp.Attempts)
+			resp.Status = "pending"
+			resp.Message = "confirmation interrupted; it will resume from the checkpoint"
+			if p.continuations {
+				p.continueLater(ctx, cp, pending, &resp)
 			}
-			p.emitCallback(ctx, resp)
+			if p.reinvoke(ctx, cp, pending.Event) {
+				resp.Message = "confirmation interrupted; it continues in a new invocation"
+			}
+			p.pushOutcome(ctx, resp)
 			return resp, nil
 		}
-		return SubscriptionResponse{}, err
+		resp.Status = "failed"
+		resp.Message = notConfirmedMessage(p.confirmTimeout(pending.Event))
+		if cutShort {
+			resp.Message = "transaction not confirmed before the invocation deadline"
+		}
+		resp.Code = CodeConfirmationTimeout
+		resp.Attempts = cp.Attempts
+	} else {
+		resp.Status = polledTxn.Status
+		resp.Found = true
+		resp.Transaction = polledTxn
+		p.observeConfirmation(pending.Provider, time.Since(cp.StartedAt))
 	}
 
-	resp := SubscriptionResponse{
-		Reference:   ref,
-		Status:      polledTxn.Status,
-		Found:       true,
-		Transaction: polledTxn,
-		Request:     event,
-	}
-	p.emitCallback(ctx, resp)
+	p.settle(ctx, cp, &resp)
 	return resp, nil
 }
 
-func (p *Processor) pollTransaction(ctx context.Context, ref string) (*paypack.Transaction, error) {
-	ctx, cancel := context.WithTimeout(ctx, p.timeout)
-	defer cancel()
+// settle completes a confirmed or timed-out cash-in: the checkpoint is dropped, a retry
+// scheduled when due, and the outcome finished.
+func (p *Processor) settle(ctx context.Context, cp *checkpoint.Checkpoint, resp *SubscriptionResponse) {
+	p.recordSLO(*resp, time.Since(cp.StartedAt))
+	p.clearCheckpoint(ctx, cp.Ref)
+	p.scheduleRetry(ctx, resp)
+	p.finish(ctx, resp)
+}
 
-	ticker := time.NewTicker(p.pollInterval)
-	defer ticker.Stop()
+// defaultFinishReserve is the part of the invocation kept back from polling so the outcome can
+// still be recorded and the callback delivered before Lambda stops the function.
+const defaultFinishReserve = defaultCallbackTimeout + 5*time.Second
 
-	for {
-		transaction, err := p.client.FindTransaction(ctx, ref)
-		if err == nil {
-			p.logger.Printf("transaction %s confirmed", ref)
-			return transaction, nil
+// pollDeadline bounds the confirmation deadline by the invocation's own deadline, less the
+// callback reserve, so a 5 minute timeout never outlives a shorter function timeout.
+func (p *Processor) pollDeadline(ctx context.Context, deadline time.Time) time.Time {
+	if invocation, ok := ctx.Deadline(); ok {
+		if budget := invocation.Add(-p.finishReserve); budget.Before(deadline) {
+			return budget
 		}
+	}
+	return deadline
+}
 
-		if !errors.Is(err, paypack.ErrTransactionNotFound) {
-			return nil, err
-		}
+func (p *Processor) pollTransaction(ctx context.Context, cp *checkpoint.Checkpoint, provider string, event SubscriptionEvent) (*paypack.Transaction, error) {
+	ctx, cancel := context.WithDeadline(ctx, p.pollDeadline(ctx, cp.Deadline))
+	defer cancel()
 
-		p.logger.Printf("transaction %s not ready; waiting %s", ref, p.pollInterval)
+	ref := cp.Ref
+	poller := p.poller
+	if poller == nil {
+		poller = adaptivePoller{p}
+	}
+	interval, _ := p.pollTuning(event)
+	upstreamErrors := 0
+	txn, err := poller.Poll(ctx, PollTarget{
+		Ref:       ref,
+		Provider:  provider,
+		StartedAt: cp.StartedAt,
+		Interval:  interval,
+		Find: func(ctx context.Context) (*paypack.Transaction, error) {
+			if p.attemptsExhausted(event, cp.Attempts) {
+				return nil, errPollAttemptsExhausted
+			}
+			txn, err := p.findTransaction(ctx, ref)
+			if err == nil || errors.Is(err, paypack.ErrTransactionNotFound) || paypackCode(err) != CodePaypackUnavailable {
+				upstreamErrors = 0
+				return txn, err
+			}
+			if upstreamErrors++; upstreamErrors >= p.upstreamErrorLimit {
+				return nil, fmt.Errorf("%w after %d failed lookups: %w", errUpstreamUnavailable, upstreamErrors, err)
+			}
+			p.logf(ctx, "status lookup for ref=%s failed (%d in a row); retrying: %v", ref, upstreamErrors, err)
+			return nil, paypack.ErrTransactionNotFound
+		},
+		Waiting: func(ctx context.Context) {
+			cp.Attempts++
+			p.saveCheckpoint(ctx, cp)
+			p.pushStatus(ctx, StatusUpdate{Ref: ref, Stage: StagePending, Status: "pending", Attempt: cp.Attempts})
+			p.logf(ctx, "transaction %s not ready after %d attempts", ref, cp.Attempts)
+		},
+	})
+	if err == nil {
+		p.logf(ctx, "transaction %s confirmed", ref)
+	}
+	return txn, err
+}
 
-		select {
-		case <-ctx.Done():
-			return nil, ctx.Err()
-		case <-ticker.C:
-		}
+// traceContext joins the trace named by the event, else keeps the caller's (HTTP headers or
+// the Lambda X-Ray header), else starts one. Outgoing callbacks and events carry it on.
+func traceContext(ctx context.Context, event SubscriptionEvent) context.Context {
+	if tc, ok := tracing.Parse(event.TraceParent, event.TraceState); ok {
+		ctx = tracing.NewContext(ctx, tc)
+	}
+	ctx, _ = tracing.Ensure(ctx)
+	return ctx
+}
+
+// flush waits for background callbacks and pushes buffered measurements before an entry
+// point returns, so nothing is lost when Lambda freezes the execution environment.
+func (p *Processor) flush(ctx context.Context) {
+	p.waitCallbacks(ctx)
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 2*time.Second)
+	defer cancel()
+	if err := metrics.Flush(ctx, p.metrics); err != nil {
+		p.logf(ctx, "metrics flush failed: %v", err)
+	}
+}
+
+func (p *Processor) observePoll(start time.Time, err error) {
+	outcome := "confirmed"
+	switch {
+	case errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled):
+		outcome = "timeout"
+	case err != nil:
+		outcome = "error"
 	}
+	metrics.Since(p.metrics, metrics.PollDuration, start, metrics.T("outcome", outcome))
 }
 
 func validateEvent(event SubscriptionEvent) error {
@@ -180,14 +765,103 @@ func validateEvent(event SubscriptionEvent) error {
 	if event.Amount <= 0 {
 		return errors.New("amount must be positive")
 	}
+	if event.Currency != "" && !paypack.ValidCurrencyCode(event.Currency) {
+		return fmt.Errorf("invalid currency %q", event.Currency)
+	}
+	if event.ConfirmTimeoutSeconds < 0 || event.PollIntervalSeconds < 0 || event.MaxAttempts < 0 {
+		return errors.New("confirm_timeout_seconds, poll_interval_seconds and max_attempts must not be negative")
+	}
 	return nil
 }
 
-func (p *Processor) emitCallback(ctx context.Context, resp SubscriptionResponse) {
-	if p.callback == nil {
+// stampClient copies the event's client onto the response and its transaction, which Paypack
+// may report with the payer's number instead. The transaction is copied, as it can be shared
+// with the transaction cache.
+func stampClient(resp *SubscriptionResponse) {
+	client := resp.Request.Client
+	if client == "" {
+		return
+	}
+	resp.Client = client
+	if resp.Transaction != nil && resp.Transaction.Client != client {
+		txn := *resp.Transaction
+		txn.Client = client
+		resp.Transaction = &txn
+	}
+}
+
+// finish applies post-processing shared by every outcome and delivers the callback.
+func (p *Processor) finish(ctx context.Context, resp *SubscriptionResponse) {
+	p.metrics.Count(metrics.CashInResults, 1, metrics.T("status", resp.Status))
+	stampClient(resp)
+	resp.CorrelationID = orDefault(resp.CorrelationID, correlationID(ctx))
+	p.computeFees(ctx, resp)
+	if !p.claimOutcome(ctx, resp) {
 		return
 	}
-	if err := p.callback.Send(ctx, resp); err != nil {
-		p.logger.Printf("callback delivery failed: %v", err)
+	p.applyLifecycle(ctx, resp)
+	p.recordLedger(ctx, resp)
+	p.creditWallet(ctx, resp)
+	p.disburse(ctx, resp)
+	p.issueReceipt(ctx, resp)
+	classifyFailure(resp)
+	if err := p.emitCallback(ctx, *resp); err != nil && resp.Code == "" {
+		resp.Code = CodeCallbackFailed
+		classifyFailure(resp)
+	}
+	p.trackOutcome(ctx, *resp)
+	p.recordAudit(ctx, auditOutcome, resp.Reference, resp)
+	p.pushOutcome(ctx, *resp)
+}
+
+func (p *Processor) applyLifecycle(ctx context.Context, resp *SubscriptionResponse) {
+	id := resp.Request.SubscriptionID
+	if p.lifecycle == nil || id == "" {
+		return
+	}
+
+	var (
+		sub *subscription.Subscription
+		err error
+	)
+	if resp.Found && resp.Status == "success" {
+		sub, err = p.lifecycle.Activate(ctx, id, resp.Reference)
+	} else {
+		reason := resp.Message
+		if reason == "" {
+			reason = "transaction " + resp.Status
+		}
+		sub, err = p.lifecycle.MarkPastDue(ctx, id, resp.Reference, reason)
+		if err == nil && len(p.dunning) > 0 && resp.Retry != nil && resp.Retry.Exhausted {
+			sub = p.cancelAfterDunning(ctx, id, reason, sub)
+		}
+	}
+	if err != nil {
+		p.logf(ctx, "subscription %s lifecycle update failed: %v", id, err)
+		return
+	}
+	resp.Subscription = sub
+}
+
+func (p *Processor) emitCallback(ctx context.Context, resp SubscriptionResponse) error {
+	if p.callback == nil {
+		return nil
+	}
+	resp.CorrelationID = orDefault(resp.CorrelationID, correlationID(ctx))
+	if p.asyncCallbacks != nil && p.dispatchCallback(ctx, resp) {
+		return nil
+	}
+	return p.deliverCallback(ctx, resp)
+}
+
+func (p *Processor) deliverCallback(ctx context.Context, resp SubscriptionResponse) error {
+	err := p.callback.Send(ctx, resp)
+	result := "delivered"
+	if err != nil {
+		result = "failed"
+		p.logf(ctx, "callback delivery failed: %v", err)
 	}
+	p.metrics.Count(metrics.CallbackDeliveries, 1, metrics.T("result", result))
+	p.recordCallback(ctx, resp, err)
+	return err
 }
@@ -7,15 +7,19 @@ import (
 
 	"github.com/stretchr/testify/require"
 
-	"github.com/berniyo/paypack-lambda/internal/paypack"
+	"github.com/berniyo/paypack-lambda/internal/subscription"
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
 )
 
 type fakeClient struct {
 	cashInFn          func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error)
 	findTransactionFn func(ctx context.Context, ref string) (*paypack.Transaction, error)
+
+	lastCashIn paypack.CashInRequest
 }
 
-func (f *fakeClient) CashIn(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+func (f *fakeClient) CashIn(ctx context.Context, number string, amount float64, opts ...paypack.CashInOption) (*paypack.Transaction, error) {
+	f.lastCashIn = paypack.ApplyCashInOptions(opts...)
 	return f.cashInFn(ctx, number, amount)
 }
 
@@ -114,7 +118,7 @@ func TestProcessorHandleTimeout(t *testing.T) {
 	require.NoError(t, err)
 	require.False(t, resp.Found)
 	require.Equal(t, "failed", resp.Status)
-	require.Equal(t, "transaction not confirmed within 5 minutes", resp.Message)
+	require.Equal(t, "transaction not confirmed within 20ms", resp.Message)
 	require.Len(t, cb.calls, 1)
 }
 
@@ -125,3 +129,159 @@ func TestProcessorHandleValidatesInput(t *testing.T) {
 	_, err := processor.Handle(context.Background(), SubscriptionEvent{})
 	require.EqualError(t, err, "number is required")
 }
+
+func TestProcessorHandleAppliesSubscriptionLifecycle(t *testing.T) {
+	client := &fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: "abc"}, nil
+		},
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: ref, Status: "success"}, nil
+		},
+	}
+
+	subs := subscription.NewService(subscription.NewMemoryStore())
+	_, err := subs.Create(context.Background(), subscription.Subscription{ID: "sub-1", Number: "2507", Amount: 1000})
+	require.NoError(t, err)
+
+	cb := &fakeCallback{}
+	processor := NewProcessor(
+		client,
+		WithPollInterval(5*time.Millisecond),
+		WithTimeout(200*time.Millisecond),
+		WithCallbackSender(cb),
+		WithSubscriptions(subs),
+	)
+
+	resp, err := processor.Handle(context.Background(), SubscriptionEvent{Number: "2507", Amount: 1000, SubscriptionID: "sub-1"})
+	require.NoError(t, err)
+	require.NotNil(t, resp.Subscription)
+	require.Equal(t, subscription.StatusActive, resp.Subscription.Status)
+	require.Equal(t, "abc", resp.Subscription.LastRef)
+	require.Len(t, cb.calls, 1)
+	require.Equal(t, subscription.StatusActive, cb.calls[0].Subscription.Status)
+}
+
+func TestProcessorFlushesMetricsAfterEachEvent(t *testing.T) {
+	rec := &recordingMetrics{}
+	p := NewProcessor(&fakeClient{}, WithMetrics(rec))
+
+	_, err := p.Handle(context.Background(), SubscriptionEvent{})
+	require.Error(t, err)
+	require.Equal(t, 1, rec.flushes)
+}
+
+func TestProcessorBudgetsPollingByInvocationDeadline(t *testing.T) {
+	client := &fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: "abc"}, nil
+		},
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			return nil, paypack.ErrTransactionNotFound
+		},
+	}
+	cb := &fakeCallback{}
+	processor := NewProcessor(client, WithPollInterval(5*time.Millisecond), WithCallbackSender(cb))
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultFinishReserve+30*time.Millisecond)
+	defer cancel()
+	resp, err := processor.Handle(ctx, SubscriptionEvent{Number: "2507", Amount: 1000})
+	require.NoError(t, err)
+	require.NoError(t, ctx.Err(), "polling must stop while the reserve remains")
+	require.Equal(t, "failed", resp.Status)
+	require.Equal(t, "transaction not confirmed before the invocation deadline", resp.Message)
+	require.Len(t, cb.calls, 1)
+}
+
+func TestProcessorHonoursConfiguredCallbackReserve(t *testing.T) {
+	client := &fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: "abc"}, nil
+		},
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			return nil, paypack.ErrTransactionNotFound
+		},
+	}
+	var delivered time.Time
+	cb := &fakeCallback{}
+	sender := callbackFunc(func(ctx context.Context, resp SubscriptionResponse) error {
+		delivered = time.Now()
+		return cb.Send(ctx, resp)
+	})
+	processor := NewProcessor(client,
+		WithPollInterval(5*time.Millisecond),
+		WithCallbackReserve(100*time.Millisecond),
+		WithCallbackSender(sender),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
+	defer cancel()
+	deadline, _ := ctx.Deadline()
+	resp, err := processor.Handle(ctx, SubscriptionEvent{Number: "2507", Amount: 1000})
+	require.NoError(t, err)
+	require.Equal(t, "transaction not confirmed before the invocation deadline", resp.Message)
+	require.Len(t, cb.calls, 1)
+	require.Greater(t, deadline.Sub(delivered), 50*time.Millisecond, "the callback goes out inside the reserve")
+}
+
+type callbackFunc func(ctx context.Context, resp SubscriptionResponse) error
+
+func (f callbackFunc) Send(ctx context.Context, resp SubscriptionResponse) error { return f(ctx, resp) }
+
+func TestConfirmTimeoutOverrideIsCapped(t *testing.T) {
+	processor := NewProcessor(&fakeClient{}, WithTimeout(time.Minute), WithMaxConfirmTimeout(10*time.Minute))
+	require.Equal(t, time.Minute, processor.confirmTimeout(SubscriptionEvent{}))
+	require.Equal(t, 7*time.Minute, processor.confirmTimeout(SubscriptionEvent{ConfirmTimeoutSeconds: 420}))
+	require.Equal(t, 10*time.Minute, processor.confirmTimeout(SubscriptionEvent{ConfirmTimeoutSeconds: 3600}))
+
+	processor = NewProcessor(&fakeClient{}, WithTimeout(time.Minute))
+	require.Equal(t, 30*time.Second, processor.confirmTimeout(SubscriptionEvent{ConfirmTimeoutSeconds: 30}))
+	require.Equal(t, time.Minute, processor.confirmTimeout(SubscriptionEvent{ConfirmTimeoutSeconds: 300}))
+
+	_, err := processor.Handle(context.Background(), SubscriptionEvent{Number: "2507", Amount: 1000, ConfirmTimeoutSeconds: -1})
+	require.Equal(t, CodeValidation, CodeOf(err))
+}
+
+func TestProcessorHonoursEventConfirmTimeout(t *testing.T) {
+	client := &fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: "abc"}, nil
+		},
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			return nil, paypack.ErrTransactionNotFound
+		},
+	}
+	processor := NewProcessor(client, WithPollInterval(5*time.Millisecond), WithTimeout(time.Hour))
+
+	start := time.Now()
+	resp, err := processor.Handle(context.Background(), SubscriptionEvent{Number: "2507", Amount: 1000, ConfirmTimeoutSeconds: 1})
+	require.NoError(t, err)
+	require.Equal(t, CodeConfirmationTimeout, resp.Code)
+	require.Less(t, time.Since(start), 3*time.Second)
+}
+
+func TestProcessorForwardsEventClient(t *testing.T) {
+	client := &fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: "abc"}, nil
+		},
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			// Paypack reports the payer's number as the client.
+			return &paypack.Transaction{Ref: ref, Status: "success", Client: "2507"}, nil
+		},
+	}
+	processor := NewProcessor(client, WithPollInterval(time.Millisecond))
+
+	resp, err := processor.Handle(context.Background(), SubscriptionEvent{Number: "2507", Amount: 1000, Client: "acme"})
+	require.NoError(t, err)
+	require.Equal(t, "acme", client.lastCashIn.Client)
+	require.Equal(t, "acme", resp.Client)
+	require.Equal(t, "acme", resp.Transaction.Client)
+}
+
+func TestNotConfirmedMessageNamesTheWindow(t *testing.T) {
+	require.Equal(t, "transaction not confirmed within 5 minutes", notConfirmedMessage(5*time.Minute))
+	require.Equal(t, "transaction not confirmed within 1 minute", notConfirmedMessage(time.Minute))
+	require.Equal(t, "transaction not confirmed within 90 seconds", notConfirmedMessage(90*time.Second))
+	require.Equal(t, "transaction not confirmed within 1.5s", notConfirmedMessage(1500*time.Millisecond))
+}
@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"github.com/berniyo/paypack-lambda/internal/tax"
+)
+
+// WithTaxTable applies the table's rule for each event's plan (metadata "plan"). Events may
+// carry their own "tax" rule, which takes precedence. Exclusive rules raise the charged amount.
+func WithTaxTable(table *tax.Table) Option {
+	return func(p *Processor) {
+		p.taxes = table
+	}
+}
+
+func (p *Processor) taxFor(event SubscriptionEvent, amount float64, currency string) *tax.Breakdown {
+	rule, ok := p.taxRule(event)
+	if !ok {
+		return nil
+	}
+	breakdown := rule.Apply(amount, currency)
+	return &breakdown
+}
+
+func (p *Processor) taxRule(event SubscriptionEvent) (tax.Rule, bool) {
+	if event.Tax != nil {
+		return *event.Tax, true
+	}
+	if p.taxes == nil {
+		return tax.Rule{}, false
+	}
+	plan, _ := event.Metadata["plan"].(string)
+	return p.taxes.RuleFor(plan)
+}
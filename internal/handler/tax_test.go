@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/berniyo/paypack-lambda/internal/ledger"
+	"github.com/berniyo/paypack-lambda/internal/tax"
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+func TestProcessorAppliesTaxAndJournals(t *testing.T) {
+	var charged float64
+	client := &fakeClient{
+		cashInFn: func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+			charged = amount
+			return &paypack.Transaction{Ref: "abc"}, nil
+		},
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: ref, Status: "success", Amount: charged, Fee: 27, Currency: "RWF"}, nil
+		},
+	}
+
+	table, err := tax.ParseTable([]byte(`{"default":{"name":"VAT","rate":18,"inclusive":true},"plans":{"b2b":{"name":"VAT","rate":18}}}`))
+	require.NoError(t, err)
+
+	journal := ledger.NewMemoryStore()
+	processor := NewProcessor(
+		client,
+		WithPollInterval(5*time.Millisecond),
+		WithTimeout(200*time.Millisecond),
+		WithTaxTable(table),
+		WithLedger(journal),
+	)
+
+	resp, err := processor.Handle(context.Background(), SubscriptionEvent{Number: "2507", Amount: 1180})
+	require.NoError(t, err)
+	require.Equal(t, float64(1180), charged)
+	require.Equal(t, 180.0, resp.Tax.Tax)
+
+	resp, err = processor.Handle(context.Background(), SubscriptionEvent{
+		Number:   "2507",
+		Amount:   1000,
+		Metadata: map[string]any{"plan": "b2b"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, float64(1180), charged, "exclusive tax is added to the charge")
+	require.Equal(t, tax.Breakdown{Name: "VAT", Rate: 18, Net: 1000, Tax: 180, Gross: 1180}, *resp.Tax)
+
+	entries, err := journal.List(context.Background(), "abc")
+	require.NoError(t, err)
+	require.Len(t, entries, 6)
+	require.Equal(t, ledger.KindTax, entries[5].Kind)
+	require.Equal(t, 973.0, ledger.Net(entries[3:]))
+}
+
+func TestProcessorEventTaxOverride(t *testing.T) {
+	processor := NewProcessor(&fakeClient{}, WithTaxTable(tax.RwandaVAT()))
+
+	b := processor.taxFor(SubscriptionEvent{Tax: &tax.Rule{Name: "VAT", Rate: 0, Inclusive: true}}, 1000, "RWF")
+	require.Equal(t, 0.0, b.Tax)
+	require.Equal(t, 1000.0, b.Net)
+}
@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/berniyo/paypack-lambda/internal/txcache"
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+const defaultTxCacheTTL = 10 * time.Minute
+
+// WithTransactionCache keeps terminal transactions in store for ttl (default 10m). Polling,
+// the status action and webhooks populate it, and status checks are answered from it
+// without calling Paypack.
+func WithTransactionCache(store txcache.Store, ttl time.Duration) Option {
+	return func(p *Processor) {
+		p.txCache = store
+		p.txCacheTTL = defaultTxCacheTTL
+		if ttl > 0 {
+			p.txCacheTTL = ttl
+		}
+	}
+}
+
+// findTransaction looks ref up in the cache before asking Paypack, caching terminal results.
+func (p *Processor) findTransaction(ctx context.Context, ref string) (*paypack.Transaction, error) {
+	if p.txCache != nil {
+		txn, err := p.txCache.Get(ctx, ref)
+		if err == nil {
+			return txn, nil
+		}
+		if !errors.Is(err, txcache.ErrNotFound) {
+			p.logf(ctx, "transaction cache lookup for ref=%s: %v", ref, err)
+		}
+	}
+
+	txn, err := p.client.FindTransaction(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	p.cacheTransaction(ctx, *txn)
+	return txn, nil
+}
+
+// cacheTransaction stores txn when its status is final.
+func (p *Processor) cacheTransaction(ctx context.Context, txn paypack.Transaction) {
+	if p.txCache == nil || !txcache.Terminal(txn.Status) {
+		return
+	}
+	if err := p.txCache.Put(ctx, txn, p.txCacheTTL); err != nil {
+		p.logf(ctx, "cache transaction ref=%s: %v", txn.Ref, err)
+	}
+}
+
+// handleStatus reports what Paypack knows about event.Ref, without charging or callbacks.
+func (p *Processor) handleStatus(ctx context.Context, event SubscriptionEvent) (SubscriptionResponse, error) {
+	if event.Ref == "" {
+		return SubscriptionResponse{}, withCode(CodeValidation, errors.New("ref is required for status"))
+	}
+
+	resp := SubscriptionResponse{Reference: event.Ref, Request: event}
+	txn, err := p.findTransaction(ctx, event.Ref)
+	switch {
+	case errors.Is(err, paypack.ErrTransactionNotFound):
+		resp.Status = "pending"
+		resp.Message = "transaction not found yet"
+		return resp, nil
+	case err != nil:
+		return SubscriptionResponse{}, withCode(paypackCode(err), fmt.Errorf("status lookup failed: %w", err))
+	}
+	resp.Status = txn.Status
+	resp.Found = true
+	resp.Transaction = txn
+	setNetAmount(&resp)
+	return resp, nil
+}
@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/require"
+
+	"github.com/berniyo/paypack-lambda/internal/txcache"
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+func TestStatusActionAnswersTerminalResultsFromCache(t *testing.T) {
+	var finds int
+	status := "pending"
+	client := &fakeClient{
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			finds++
+			return &paypack.Transaction{Ref: ref, Status: status}, nil
+		},
+	}
+	p := NewProcessor(client, WithTransactionCache(txcache.NewMemoryStore(0), time.Minute))
+	check := func() SubscriptionResponse {
+		resp, err := p.Handle(context.Background(), SubscriptionEvent{Action: ActionStatus, Ref: "abc"})
+		require.NoError(t, err)
+		return resp
+	}
+
+	require.Equal(t, "pending", check().Status)
+	status = "success"
+	require.Equal(t, "success", check().Status)
+	require.Equal(t, "success", check().Status)
+	require.Equal(t, 2, finds, "pending results are not cached, terminal ones are")
+}
+
+func TestWebhookPopulatesTransactionCache(t *testing.T) {
+	client := &fakeClient{
+		findTransactionFn: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			return nil, errors.New("paypack should not be queried")
+		},
+	}
+	p := NewProcessor(client, WithTransactionCache(txcache.NewMemoryStore(0), time.Minute))
+	hook := NewWebhookHandler(p, "")
+
+	res, err := hook.Handle(context.Background(), events.APIGatewayV2HTTPRequest{
+		Body: `{"event_id":"e1","event_kind":"transaction:processed","data":{"ref":"abc","status":"successful","amount":1000}}`,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 200, res.StatusCode)
+
+	resp, err := p.Handle(context.Background(), SubscriptionEvent{Action: ActionStatus, Ref: "abc"})
+	require.NoError(t, err)
+	require.Equal(t, "success", resp.Status)
+	require.True(t, resp.Found)
+	require.Equal(t, 1000.0, resp.Transaction.Amount)
+}
+
+func TestStatusActionRequiresRef(t *testing.T) {
+	p := NewProcessor(&fakeClient{})
+	_, err := p.Handle(context.Background(), SubscriptionEvent{Action: ActionStatus})
+	require.Equal(t, CodeValidation, CodeOf(err))
+}
@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"github.com/berniyo/paypack-lambda/internal/tracing"
+	"github.com/berniyo/paypack-lambda/internal/webhookauth"
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+// WebhookHandler receives Paypack webhooks through API Gateway and turns them into callbacks.
+type WebhookHandler struct {
+	processor *Processor
+	secret    string
+}
+
+// NewWebhookHandler builds a webhook receiver. When secret is non-empty, requests must carry a
+// valid X-Paypack-Signature header. Further checks (shared secrets, IP allowlists) wrap Handle
+// with webhookauth.APIGateway.
+func NewWebhookHandler(processor *Processor, secret string) *WebhookHandler {
+	return &WebhookHandler{processor: processor, secret: secret}
+}
+
+// Handle implements the API Gateway HTTP API handler entry point.
+func (w *WebhookHandler) Handle(ctx context.Context, req events.APIGatewayV2HTTPRequest) (res events.APIGatewayV2HTTPResponse, _ error) {
+	defer w.processor.flush(ctx)
+	ctx, inv := w.processor.beginInvocation(ctx, "webhook")
+	var ref string
+	defer func() { inv.end(strconv.Itoa(res.StatusCode), ref) }()
+	if tc, ok := tracing.Parse(header(req.Headers, tracing.HeaderTraceParent), header(req.Headers, tracing.HeaderTraceState)); ok {
+		ctx = tracing.NewContext(ctx, tc)
+	}
+	ctx, _ = tracing.Ensure(ctx)
+	body := []byte(req.Body)
+	if req.IsBase64Encoded {
+		decoded, err := base64.StdEncoding.DecodeString(req.Body)
+		if err != nil {
+			return webhookResponse(http.StatusBadRequest, "invalid base64 body"), nil
+		}
+		body = decoded
+	}
+
+	if w.secret != "" {
+		signed := webhookauth.Request{Headers: http.Header{}, Body: body, SourceIP: req.RequestContext.HTTP.SourceIP}
+		signed.Headers.Set(paypack.WebhookSignatureHeader, header(req.Headers, paypack.WebhookSignatureHeader))
+		if rejection := webhookauth.Verify(signed, webhookauth.PaypackSignature(w.secret)); rejection != nil {
+			return events.APIGatewayV2HTTPResponse{
+				StatusCode: rejection.Status,
+				Headers:    map[string]string{"Content-Type": "application/json"},
+				Body:       rejection.Body(),
+			}, nil
+		}
+	}
+
+	var hook paypack.WebhookEvent
+	if err := json.Unmarshal(body, &hook); err != nil || hook.Data.Ref == "" {
+		return webhookResponse(http.StatusBadRequest, "invalid webhook payload"), nil
+	}
+
+	ref = hook.Data.Ref
+	p := w.processor
+	p.recordWebhook(ctx, hook.EventID, hook.Data.Ref, body)
+	p.logf(ctx, "webhook %s received for ref=%s status=%s", hook.EventKind, hook.Data.Ref, hook.Data.Status)
+
+	txn := hook.Data
+	txn.Status = normalizeStatus(txn.Status)
+	if txn.Status != "success" && txn.Status != "failed" {
+		return webhookResponse(http.StatusOK, "ok"), nil
+	}
+
+	resp := SubscriptionResponse{Reference: txn.Ref, Request: requestFromMetadata(txn)}
+	if pending, ok := p.checkpointedCashIn(ctx, txn.Ref); ok {
+		// A confirmation still in flight, or cut short: finish it with its original request.
+		resp = pending.response(txn.Ref)
+	}
+	resp.Status = txn.Status
+	resp.Found = true
+	resp.Transaction = &txn
+	p.cacheTransaction(ctx, txn)
+	p.clearCheckpoint(ctx, txn.Ref)
+	p.finish(ctx, &resp)
+
+	return webhookResponse(http.StatusOK, "ok"), nil
+}
+
+// normalizeStatus maps Paypack's webhook wording onto the statuses used in responses.
+func normalizeStatus(status string) string {
+	if status == "successful" {
+		return "success"
+	}
+	return status
+}
+
+func header(headers map[string]string, name string) string {
+	if v, ok := headers[name]; ok {
+		return v
+	}
+	for k, v := range headers {
+		if http.CanonicalHeaderKey(k) == http.CanonicalHeaderKey(name) {
+			return v
+		}
+	}
+	return ""
+}
+
+func webhookResponse(status int, message string) events.APIGatewayV2HTTPResponse {
+	body, _ := json.Marshal(map[string]string{"message": message})
+	return events.APIGatewayV2HTTPResponse{
+		StatusCode: status,
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       string(body),
+	}
+}
@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/require"
+
+	"github.com/berniyo/paypack-lambda/internal/eventstore"
+	"github.com/berniyo/paypack-lambda/internal/testdata"
+)
+
+const webhookBody = `{"event_id":"evt-1","event_kind":"transaction:processed","created_at":"2024-01-01T00:00:00Z",` +
+	`"data":{"ref":"abc","kind":"CASHIN","amount":1000,"fee":23,"client":"2507","provider":"mtn","status":"successful"}}`
+
+func sign(body, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestWebhookHandlerStoresAndForwards(t *testing.T) {
+	store := eventstore.NewMemoryStore()
+	cb := &fakeCallback{}
+	processor := NewProcessor(&fakeClient{}, WithCallbackSender(cb), WithEventStore(store))
+	wh := NewWebhookHandler(processor, "s3cret")
+
+	resp, err := wh.Handle(context.Background(), events.APIGatewayV2HTTPRequest{
+		Headers: map[string]string{"x-paypack-signature": sign(webhookBody, "s3cret")},
+		Body:    webhookBody,
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Len(t, cb.calls, 1)
+	require.Equal(t, "success", cb.calls[0].Status)
+	require.Equal(t, "2507", cb.calls[0].Request.Number)
+
+	recs, err := store.List(context.Background(), "abc")
+	require.NoError(t, err)
+	require.Len(t, recs, 2)
+	require.Equal(t, eventstore.KindWebhook, recs[0].Kind)
+	require.Equal(t, "evt-1", recs[0].ID)
+	require.Equal(t, eventstore.KindCallback, recs[1].Kind)
+}
+
+func TestWebhookCallbackGolden(t *testing.T) {
+	cb := &fakeCallback{}
+	wh := NewWebhookHandler(NewProcessor(&fakeClient{}, WithCallbackSender(cb)), "s3cret")
+
+	for _, name := range []string{testdata.WebhookSuccess, testdata.WebhookFailed} {
+		body := string(testdata.Payload(t, name))
+		resp, err := wh.Handle(context.Background(), events.APIGatewayV2HTTPRequest{
+			Headers: map[string]string{"x-paypack-signature": sign(body, "s3cret")},
+			Body:    body,
+		})
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		testdata.GoldenJSON(t, "callback_"+name, cb.calls[len(cb.calls)-1])
+	}
+}
+
+func TestWebhookHandlerRejectsBadSignature(t *testing.T) {
+	cb := &fakeCallback{}
+	wh := NewWebhookHandler(NewProcessor(&fakeClient{}, WithCallbackSender(cb)), "s3cret")
+
+	resp, err := wh.Handle(context.Background(), events.APIGatewayV2HTTPRequest{
+		Headers: map[string]string{"x-paypack-signature": sign(webhookBody, "other")},
+		Body:    webhookBody,
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	require.JSONEq(t, `{"error":"invalid signature","code":"INVALID_SIGNATURE"}`, resp.Body)
+	require.Empty(t, cb.calls)
+}
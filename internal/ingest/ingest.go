@@ -0,0 +1,315 @@
+// Package ingest reads files of billing instructions (CSV or JSON Lines) and writes the
+// per-row results file that answers them.
+package ingest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// MaxRows bounds the instructions in one file.
+const MaxRows = 10000
+
+// Format selects the encoding of an instructions file and of its results.
+type Format string
+
+const (
+	FormatCSV   Format = "csv"
+	FormatJSONL Format = "jsonl"
+)
+
+// FormatFor picks the format from key's extension: .csv, or .jsonl / .ndjson.
+func FormatFor(key string) (Format, error) {
+	switch ext := strings.ToLower(path.Ext(key)); ext {
+	case ".csv":
+		return FormatCSV, nil
+	case ".jsonl", ".ndjson":
+		return FormatJSONL, nil
+	default:
+		return "", fmt.Errorf("unsupported instructions file extension %q", ext)
+	}
+}
+
+// ContentType returns the MIME type stored with a results file.
+func (f Format) ContentType() string {
+	if f == FormatJSONL {
+		return "application/x-ndjson"
+	}
+	return "text/csv"
+}
+
+// Instruction is one row of an instructions file. Err is set when the row could not be read
+// or failed validation, so it is reported in the results alongside the others rather than
+// failing the file.
+type Instruction struct {
+	Line           int               `json:"-"`
+	Number         string            `json:"number"`
+	Amount         float64           `json:"amount"`
+	Plan           string            `json:"plan,omitempty"`
+	ExternalID     string            `json:"external_id,omitempty"`
+	Currency       string            `json:"currency,omitempty"`
+	Client         string            `json:"client,omitempty"`
+	SubscriptionID string            `json:"subscription_id,omitempty"`
+	Metadata       map[string]string `json:"metadata,omitempty"`
+	Err            error             `json:"-"`
+}
+
+// check validates a row that was read. External IDs identify a charge in the caller's
+// system, so a second row with the same one is rejected rather than billed twice.
+func (in *Instruction) check(seen map[string]int) {
+	switch {
+	case in.Err != nil:
+		return
+	case strings.TrimSpace(in.Number) == "":
+		in.Err = errors.New("number is required")
+	case in.Amount <= 0:
+		in.Err = errors.New("amount must be positive")
+	case in.ExternalID != "":
+		if first, ok := seen[in.ExternalID]; ok {
+			in.Err = fmt.Errorf("duplicate external_id %q (first on line %d)", in.ExternalID, first)
+			return
+		}
+		seen[in.ExternalID] = in.Line
+	}
+}
+
+// Errors lists the rejected rows of a parsed file, for a dry-run report.
+func Errors(rows []Instruction) []Result {
+	var out []Result
+	for _, row := range rows {
+		if row.Err != nil {
+			out = append(out, Result{Line: row.Line, Number: row.Number, Amount: row.Amount, Status: "error", Message: row.Err.Error()})
+		}
+	}
+	return out
+}
+
+// Result answers one instruction in the results file.
+type Result struct {
+	Line    int     `json:"line"`
+	Number  string  `json:"number,omitempty"`
+	Amount  float64 `json:"amount,omitempty"`
+	Ref     string  `json:"ref,omitempty"`
+	Status  string  `json:"status"`
+	Code    string  `json:"code,omitempty"`
+	Message string  `json:"message,omitempty"`
+}
+
+// Parse decodes and validates data. CSV files need a header row naming number and amount;
+// plan, external_id, currency, client and subscription_id are optional and any other column
+// lands in Metadata. Headers are matched case-insensitively with spaces read as underscores,
+// and semicolon- or tab-separated exports are accepted. JSONL files hold one instruction
+// object per line. Only an unreadable file fails the whole parse.
+func Parse(format Format, data []byte) ([]Instruction, error) {
+	var (
+		rows []Instruction
+		err  error
+	)
+	data = decodeText(data)
+	switch format {
+	case FormatCSV:
+		rows, err = parseCSV(data)
+	case FormatJSONL:
+		rows, err = parseJSONL(data)
+	default:
+		return nil, fmt.Errorf("unsupported instructions format %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("instructions file has no rows")
+	}
+	if len(rows) > MaxRows {
+		return nil, fmt.Errorf("instructions file exceeds %d rows", MaxRows)
+	}
+	seen := make(map[string]int)
+	for i := range rows {
+		rows[i].check(seen)
+	}
+	return rows, nil
+}
+
+// decodeText returns data as UTF-8 without a byte order mark. Spreadsheet exports arrive
+// with a UTF-8 or UTF-16 mark, or in Windows-1252 without one; the latter is read as Latin-1,
+// which covers the characters names and references use.
+func decodeText(data []byte) []byte {
+	switch {
+	case bytes.HasPrefix(data, []byte("\ufeff")):
+		return data[3:]
+	case bytes.HasPrefix(data, []byte{0xff, 0xfe}):
+		return decodeUTF16(data[2:], binary.LittleEndian)
+	case bytes.HasPrefix(data, []byte{0xfe, 0xff}):
+		return decodeUTF16(data[2:], binary.BigEndian)
+	case utf8.Valid(data):
+		return data
+	}
+	runes := make([]rune, len(data))
+	for i, b := range data {
+		runes[i] = rune(b)
+	}
+	return []byte(string(runes))
+}
+
+func decodeUTF16(data []byte, order binary.ByteOrder) []byte {
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = order.Uint16(data[2*i:])
+	}
+	return []byte(string(utf16.Decode(units)))
+}
+
+// csvDelimiter picks whichever of comma, semicolon and tab the header line uses most.
+func csvDelimiter(data []byte) rune {
+	header := data
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		header = data[:i]
+	}
+	delim, most := ',', bytes.Count(header, []byte(","))
+	for _, d := range []rune{';', '\t'} {
+		if n := bytes.Count(header, []byte(string(d))); n > most {
+			delim, most = d, n
+		}
+	}
+	return delim
+}
+
+func columnName(header string) string {
+	name := strings.ToLower(strings.TrimSpace(header))
+	return strings.NewReplacer(" ", "_", "-", "_").Replace(name)
+}
+
+func parseCSV(data []byte) ([]Instruction, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.Comma = csvDelimiter(data)
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read csv header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[columnName(name)] = i
+	}
+	for _, required := range []string{"number", "amount"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("csv header is missing the %s column", required)
+		}
+	}
+
+	var rows []Instruction
+	for {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		line, _ := r.FieldPos(0)
+		row := Instruction{Line: line}
+		if err != nil {
+			row.Err = err
+			rows = append(rows, row)
+			continue
+		}
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		row.Number = field("number")
+		row.Plan = field("plan")
+		row.ExternalID = field("external_id")
+		row.Currency = field("currency")
+		row.Client = field("client")
+		row.SubscriptionID = field("subscription_id")
+		if raw := field("amount"); raw != "" {
+			if row.Amount, err = strconv.ParseFloat(raw, 64); err != nil {
+				row.Err = fmt.Errorf("invalid amount %q", raw)
+			}
+		}
+		for name, i := range columns {
+			switch name {
+			case "number", "amount", "plan", "external_id", "currency", "client", "subscription_id":
+				continue
+			}
+			if i < len(record) && record[i] != "" {
+				if row.Metadata == nil {
+					row.Metadata = make(map[string]string)
+				}
+				row.Metadata[name] = record[i]
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func parseJSONL(data []byte) ([]Instruction, error) {
+	var rows []Instruction
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64<<10), 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		row := Instruction{Line: line}
+		if err := json.Unmarshal(text, &row); err != nil {
+			row = Instruction{Line: line, Err: fmt.Errorf("invalid instruction: %w", err)}
+		}
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read jsonl: %w", err)
+	}
+	return rows, nil
+}
+
+var csvResultHeader = []string{"line", "number", "amount", "ref", "status", "code", "message"}
+
+// EncodeResults renders results in format.
+func EncodeResults(format Format, results []Result) ([]byte, error) {
+	var buf bytes.Buffer
+	switch format {
+	case FormatCSV:
+		w := csv.NewWriter(&buf)
+		if err := w.Write(csvResultHeader); err != nil {
+			return nil, err
+		}
+		for _, r := range results {
+			amount := ""
+			if r.Amount != 0 {
+				amount = strconv.FormatFloat(r.Amount, 'f', -1, 64)
+			}
+			if err := w.Write([]string{strconv.Itoa(r.Line), r.Number, amount, r.Ref, r.Status, r.Code, r.Message}); err != nil {
+				return nil, err
+			}
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return nil, fmt.Errorf("encode csv results: %w", err)
+		}
+	case FormatJSONL:
+		enc := json.NewEncoder(&buf)
+		for _, r := range results {
+			if err := enc.Encode(r); err != nil {
+				return nil, fmt.Errorf("encode jsonl results: %w", err)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported instructions format %q", format)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,92 @@
+package ingest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCSV(t *testing.T) {
+	data := []byte("\ufeffnumber,amount,client,invoice\n0788000001,1000,acme,INV-1\n0788000002,lots,acme,\n")
+	rows, err := Parse(FormatCSV, data)
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	require.Equal(t, Instruction{Line: 2, Number: "0788000001", Amount: 1000, Client: "acme", Metadata: map[string]string{"invoice": "INV-1"}}, rows[0])
+	require.Equal(t, 3, rows[1].Line)
+	require.EqualError(t, rows[1].Err, `invalid amount "lots"`)
+
+	_, err = Parse(FormatCSV, []byte("phone,amount\n0788000001,1000\n"))
+	require.EqualError(t, err, "csv header is missing the number column")
+	_, err = Parse(FormatCSV, []byte("number,amount\n"))
+	require.EqualError(t, err, "instructions file has no rows")
+}
+
+func TestParseValidatesRows(t *testing.T) {
+	data := []byte("Number;Amount;Plan;External ID\n0788000001;1000;gold;A-1\n0788000002;0;gold;A-2\n0788000003;500;silver;A-1\n;500;silver;A-3\n")
+	rows, err := Parse(FormatCSV, data)
+	require.NoError(t, err)
+	require.Len(t, rows, 4)
+	require.Equal(t, Instruction{Line: 2, Number: "0788000001", Amount: 1000, Plan: "gold", ExternalID: "A-1"}, rows[0])
+	require.EqualError(t, rows[1].Err, "amount must be positive")
+	require.EqualError(t, rows[2].Err, `duplicate external_id "A-1" (first on line 2)`)
+	require.EqualError(t, rows[3].Err, "number is required")
+
+	require.Equal(t, []Result{
+		{Line: 3, Number: "0788000002", Status: "error", Message: "amount must be positive"},
+		{Line: 4, Number: "0788000003", Amount: 500, Status: "error", Message: `duplicate external_id "A-1" (first on line 2)`},
+		{Line: 5, Amount: 500, Status: "error", Message: "number is required"},
+	}, Errors(rows))
+}
+
+func TestParseDecodesSpreadsheetEncodings(t *testing.T) {
+	want := Instruction{Line: 2, Number: "0788000001", Amount: 1000, Metadata: map[string]string{"name": "Aimée"}}
+
+	utf16le := []byte{0xff, 0xfe}
+	for _, r := range "number\tamount\tname\n0788000001\t1000\tAimée\n" {
+		utf16le = append(utf16le, byte(r), byte(r>>8))
+	}
+	rows, err := Parse(FormatCSV, utf16le)
+	require.NoError(t, err)
+	require.Equal(t, []Instruction{want}, rows)
+
+	latin1 := []byte("number,amount,name\n0788000001,1000,Aim\xe9e\n")
+	rows, err = Parse(FormatCSV, latin1)
+	require.NoError(t, err)
+	require.Equal(t, []Instruction{want}, rows)
+}
+
+func TestParseJSONL(t *testing.T) {
+	data := []byte(`{"number":"0788000001","amount":1000,"metadata":{"invoice":"INV-1"}}
+
+{"number":"0788000002","amount":"1000"}
+`)
+	rows, err := Parse(FormatJSONL, data)
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	require.Equal(t, Instruction{Line: 1, Number: "0788000001", Amount: 1000, Metadata: map[string]string{"invoice": "INV-1"}}, rows[0])
+	require.Equal(t, 3, rows[1].Line)
+	require.Error(t, rows[1].Err)
+}
+
+func TestEncodeResults(t *testing.T) {
+	results := []Result{{Line: 2, Number: "0788000001", Amount: 1000, Ref: "abc", Status: "success"}, {Line: 3, Status: "error", Code: "VALIDATION_ERROR", Message: "number is required"}}
+
+	data, err := EncodeResults(FormatCSV, results)
+	require.NoError(t, err)
+	require.Equal(t, "line,number,amount,ref,status,code,message\n2,0788000001,1000,abc,success,,\n3,,,,error,VALIDATION_ERROR,number is required\n", string(data))
+
+	data, err = EncodeResults(FormatJSONL, results[:1])
+	require.NoError(t, err)
+	require.JSONEq(t, `{"line":2,"number":"0788000001","amount":1000,"ref":"abc","status":"success"}`, string(data))
+}
+
+func TestFormatFor(t *testing.T) {
+	f, err := FormatFor("incoming/2026-10.CSV")
+	require.NoError(t, err)
+	require.Equal(t, FormatCSV, f)
+	f, err = FormatFor("incoming/2026-10.ndjson")
+	require.NoError(t, err)
+	require.Equal(t, FormatJSONL, f)
+	_, err = FormatFor("incoming/2026-10.xlsx")
+	require.Error(t, err)
+}
@@ -0,0 +1,94 @@
+// Package lambdainvoke invokes Lambda functions asynchronously through the Lambda Invoke API
+// (POST /2015-03-31/functions/{name}/invocations), signing requests with SigV4.
+package lambdainvoke
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+)
+
+// Client invokes one function.
+type Client struct {
+	endpoint    string
+	function    string
+	region      string
+	credentials aws.CredentialsProvider
+	signer      *v4.Signer
+	httpClient  *http.Client
+}
+
+// New builds a client invoking function (a name or ARN) in region. An empty endpoint uses the
+// regional Lambda endpoint, https://lambda.<region>.amazonaws.com.
+func New(endpoint, region, function string, credentials aws.CredentialsProvider, httpClient *http.Client) (*Client, error) {
+	if region == "" {
+		return nil, errors.New("region is required")
+	}
+	if function == "" {
+		return nil, errors.New("function name is required")
+	}
+	if credentials == nil {
+		return nil, errors.New("credentials are required")
+	}
+	endpoint = strings.TrimRight(strings.TrimSpace(endpoint), "/")
+	if endpoint == "" {
+		endpoint = "https://lambda." + region + ".amazonaws.com"
+	}
+	if _, err := url.Parse(endpoint); err != nil {
+		return nil, fmt.Errorf("invalid lambda endpoint: %w", err)
+	}
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 5 * time.Second}
+	}
+	return &Client{
+		endpoint:    endpoint,
+		function:    function,
+		region:      region,
+		credentials: credentials,
+		signer:      v4.NewSigner(),
+		httpClient:  httpClient,
+	}, nil
+}
+
+// InvokeAsync queues payload as an event for the function and returns once Lambda accepted
+// it, without waiting for the invocation to run.
+func (c *Client) InvokeAsync(ctx context.Context, payload []byte) error {
+	u := c.endpoint + "/2015-03-31/functions/" + url.PathEscape(c.function) + "/invocations"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("build invoke request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Amz-Invocation-Type", "Event")
+
+	creds, err := c.credentials.Retrieve(ctx)
+	if err != nil {
+		return fmt.Errorf("retrieve credentials: %w", err)
+	}
+	sum := sha256.Sum256(payload)
+	if err := c.signer.SignHTTP(ctx, creds, req, hex.EncodeToString(sum[:]), "lambda", c.region, time.Now()); err != nil {
+		return fmt.Errorf("sign invoke request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("invoke %s: %w", c.function, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("invoke %s: lambda returned %d: %s", c.function, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
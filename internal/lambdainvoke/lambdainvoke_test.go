@@ -0,0 +1,51 @@
+package lambdainvoke
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInvokeAsyncSignsEventInvocations(t *testing.T) {
+	var gotAuth, gotType, gotPath, gotBody string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		gotType = r.Header.Get("X-Amz-Invocation-Type")
+		gotPath = r.URL.Path
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	creds := credentials.NewStaticCredentialsProvider("AKID", "SECRET", "")
+	client, err := New(srv.URL, "eu-west-1", "paypack", creds, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, client.InvokeAsync(context.Background(), []byte(`{"action":"resume","ref":"abc"}`)))
+	require.True(t, strings.HasPrefix(gotAuth, "AWS4-HMAC-SHA256 Credential=AKID/"), gotAuth)
+	require.Contains(t, gotAuth, "/eu-west-1/lambda/aws4_request")
+	require.Equal(t, "Event", gotType)
+	require.Equal(t, "/2015-03-31/functions/paypack/invocations", gotPath)
+	require.Equal(t, `{"action":"resume","ref":"abc"}`, gotBody)
+}
+
+func TestInvokeAsyncReportsRejections(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"Message":"not authorized"}`, http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	client, err := New(srv.URL, "eu-west-1", "paypack", credentials.NewStaticCredentialsProvider("AKID", "SECRET", ""), nil)
+	require.NoError(t, err)
+	require.ErrorContains(t, client.InvokeAsync(context.Background(), []byte(`{}`)), "lambda returned 403")
+
+	_, err = New("", "eu-west-1", "", credentials.NewStaticCredentialsProvider("AKID", "SECRET", ""), nil)
+	require.Error(t, err)
+}
@@ -0,0 +1,182 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DynamoStore is a Store backed by a DynamoDB table with partition key "ref" (S) and sort
+// key "sk" (S). Sort keys embed the write time so entries list in insertion order;
+// reservations are items under the same ref whose sort key starts with "reserved#".
+type DynamoStore struct {
+	client *dynamodb.Client
+	table  string
+}
+
+const reservationPrefix = "reserved#"
+
+type dynamoEntry struct {
+	Ref         string    `dynamodbav:"ref"`
+	SK          string    `dynamodbav:"sk"`
+	Kind        Kind      `dynamodbav:"kind"`
+	Amount      float64   `dynamodbav:"amount"`
+	Currency    string    `dynamodbav:"currency,omitempty"`
+	Description string    `dynamodbav:"description,omitempty"`
+	CreatedAt   time.Time `dynamodbav:"created_at"`
+}
+
+// NewDynamoStore builds a Store writing to table.
+func NewDynamoStore(client *dynamodb.Client, table string) (*DynamoStore, error) {
+	if client == nil {
+		return nil, errors.New("dynamodb client is required")
+	}
+	if table == "" {
+		return nil, errors.New("table is required")
+	}
+	return &DynamoStore{client: client, table: table}, nil
+}
+
+// Append writes each entry as its own item.
+func (d *DynamoStore) Append(ctx context.Context, entries ...Entry) error {
+	now := time.Now()
+	for i, e := range entries {
+		if e.Ref == "" {
+			return errors.New("ledger entry ref is required")
+		}
+		if e.CreatedAt.IsZero() {
+			e.CreatedAt = now
+		}
+
+		item, err := attributevalue.MarshalMap(dynamoEntry{
+			Ref:         e.Ref,
+			SK:          e.CreatedAt.UTC().Format(time.RFC3339Nano) + "#" + strconv.Itoa(i) + "#" + string(e.Kind),
+			Kind:        e.Kind,
+			Amount:      e.Amount,
+			Currency:    e.Currency,
+			Description: e.Description,
+			CreatedAt:   e.CreatedAt,
+		})
+		if err != nil {
+			return fmt.Errorf("encode ledger entry: %w", err)
+		}
+		if _, err := d.client.PutItem(ctx, &dynamodb.PutItemInput{
+			TableName: aws.String(d.table),
+			Item:      item,
+		}); err != nil {
+			return fmt.Errorf("put ledger entry: %w", err)
+		}
+	}
+	return nil
+}
+
+// Reserve claims kind on ref with a put conditioned on the reservation item not existing.
+func (d *DynamoStore) Reserve(ctx context.Context, ref string, kind Kind) error {
+	_, err := d.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(d.table),
+		Item: map[string]types.AttributeValue{
+			"ref":        &types.AttributeValueMemberS{Value: ref},
+			"sk":         &types.AttributeValueMemberS{Value: reservationPrefix + string(kind)},
+			"kind":       &types.AttributeValueMemberS{Value: string(kind)},
+			"created_at": &types.AttributeValueMemberS{Value: time.Now().UTC().Format(time.RFC3339Nano)},
+		},
+		ConditionExpression: aws.String("attribute_not_exists(sk)"),
+	})
+	var failed *types.ConditionalCheckFailedException
+	if errors.As(err, &failed) {
+		return ErrReserved
+	}
+	if err != nil {
+		return fmt.Errorf("reserve ledger %s: %w", kind, err)
+	}
+	return nil
+}
+
+// List returns the entries for ref in insertion order.
+func (d *DynamoStore) List(ctx context.Context, ref string) ([]Entry, error) {
+	var entries []Entry
+	err := d.query(ctx, ref, func(_ string, e Entry) error {
+		e.Seq = len(entries) + 1
+		entries = append(entries, e)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// Redescribe rewrites the descriptions fn changes, one update per entry.
+func (d *DynamoStore) Redescribe(ctx context.Context, ref string, fn func(Entry) (string, bool)) (int, error) {
+	changed, seq := 0, 0
+	err := d.query(ctx, ref, func(sk string, e Entry) error {
+		seq++
+		e.Seq = seq
+		description, ok := fn(e)
+		if !ok {
+			return nil
+		}
+		if _, err := d.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+			TableName: aws.String(d.table),
+			Key: map[string]types.AttributeValue{
+				"ref": &types.AttributeValueMemberS{Value: ref},
+				"sk":  &types.AttributeValueMemberS{Value: sk},
+			},
+			UpdateExpression: aws.String("SET description = :description"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":description": &types.AttributeValueMemberS{Value: description},
+			},
+		}); err != nil {
+			return fmt.Errorf("update ledger entry: %w", err)
+		}
+		changed++
+		return nil
+	})
+	return changed, err
+}
+
+// query calls fn with the sort key and content of every entry of ref, in insertion order.
+func (d *DynamoStore) query(ctx context.Context, ref string, fn func(sk string, e Entry) error) error {
+	paginator := dynamodb.NewQueryPaginator(d.client, &dynamodb.QueryInput{
+		TableName:                aws.String(d.table),
+		KeyConditionExpression:   aws.String("#ref = :ref"),
+		ExpressionAttributeNames: map[string]string{"#ref": "ref"},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":ref": &types.AttributeValueMemberS{Value: ref},
+		},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("query ledger entries: %w", err)
+		}
+		for _, item := range page.Items {
+			var e dynamoEntry
+			if err := attributevalue.UnmarshalMap(item, &e); err != nil {
+				return fmt.Errorf("decode ledger entry: %w", err)
+			}
+			if strings.HasPrefix(e.SK, reservationPrefix) {
+				continue
+			}
+			if err := fn(e.SK, Entry{
+				Ref:         e.Ref,
+				Kind:        e.Kind,
+				Amount:      e.Amount,
+				Currency:    e.Currency,
+				Description: e.Description,
+				CreatedAt:   e.CreatedAt,
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Kind classifies a ledger line.
+type Kind string
+
+const (
+	KindCharge Kind = "charge"
+	KindFee    Kind = "fee"
+	KindTax    Kind = "tax"
+	// KindDisbursement is a share of a payment paid out to a split recipient.
+	KindDisbursement Kind = "disbursement"
+	// KindPayout is a cash-out started by a cashout event, such as an agent commission.
+	KindPayout Kind = "payout"
+)
+
+// Entry is one signed journal line attached to a transaction reference. Money received is
+// positive; fees and amounts owed to third parties are negative.
+type Entry struct {
+	Ref         string    `json:"ref"`
+	Seq         int       `json:"seq"`
+	Kind        Kind      `json:"kind"`
+	Amount      float64   `json:"amount"`
+	Currency    string    `json:"currency,omitempty"`
+	Description string    `json:"description,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Store is an append-only journal keyed by transaction reference.
+type Store interface {
+	Append(ctx context.Context, entries ...Entry) error
+	List(ctx context.Context, ref string) ([]Entry, error)
+}
+
+// ErrReserved marks a reservation that is already held.
+var ErrReserved = errors.New("ledger action already reserved")
+
+// Reserver is implemented by stores that can claim a one-time action on a ref with a
+// conditional write, such as paying out its disbursements. Reservations are not entries and
+// never show up in List.
+type Reserver interface {
+	// Reserve claims kind on ref, returning ErrReserved when it was claimed before.
+	Reserve(ctx context.Context, ref string, kind Kind) error
+}
+
+// Redescriber is implemented by stores that can rewrite entry descriptions in place, as
+// erasing a customer's data needs. Amounts are never touched, so totals stay the same.
+type Redescriber interface {
+	// Redescribe calls fn for every entry of ref and stores the description it returns when
+	// it reports a change, returning how many entries changed.
+	Redescribe(ctx context.Context, ref string, fn func(Entry) (string, bool)) (int, error)
+}
+
+// Net sums the signed amounts of entries.
+func Net(entries []Entry) float64 {
+	var total float64
+	for _, e := range entries {
+		total += e.Amount
+	}
+	return total
+}
+
+// MemoryStore holds ledger lines and reservations in memory, for tests only; a reservation
+// in it does not stop another container disbursing the same charge.
+type MemoryStore struct {
+	mu       sync.RWMutex
+	byRef    map[string][]Entry
+	reserved map[string]bool
+}
+
+// NewMemoryStore builds an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{byRef: make(map[string][]Entry), reserved: make(map[string]bool)}
+}
+
+// Reserve claims kind on ref.
+func (m *MemoryStore) Reserve(ctx context.Context, ref string, kind Kind) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := ref + "#" + string(kind)
+	if m.reserved[key] {
+		return ErrReserved
+	}
+	m.reserved[key] = true
+	return nil
+}
+
+// Append adds entries, numbering them per reference.
+func (m *MemoryStore) Append(ctx context.Context, entries ...Entry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	for _, e := range entries {
+		if e.Ref == "" {
+			return errors.New("ledger entry ref is required")
+		}
+		if e.CreatedAt.IsZero() {
+			e.CreatedAt = now
+		}
+		e.Seq = len(m.byRef[e.Ref]) + 1
+		m.byRef[e.Ref] = append(m.byRef[e.Ref], e)
+	}
+	return nil
+}
+
+// List returns the entries for ref in insertion order.
+func (m *MemoryStore) List(ctx context.Context, ref string) ([]Entry, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return append([]Entry(nil), m.byRef[ref]...), nil
+}
+
+// Redescribe rewrites the descriptions fn changes.
+func (m *MemoryStore) Redescribe(ctx context.Context, ref string, fn func(Entry) (string, bool)) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	changed := 0
+	for i, e := range m.byRef[ref] {
+		if description, ok := fn(e); ok {
+			m.byRef[ref][i].Description = description
+			changed++
+		}
+	}
+	return changed, nil
+}
@@ -0,0 +1,81 @@
+package metrics
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// EMF writes measurements to w in the CloudWatch Embedded Metric Format. In Lambda, lines
+// written to stdout are turned into CloudWatch metrics without any API calls.
+type EMF struct {
+	namespace string
+
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewEMF builds a backend publishing under the CloudWatch namespace. A nil w writes to stdout.
+func NewEMF(namespace string, w io.Writer) *EMF {
+	if w == nil {
+		w = os.Stdout
+	}
+	return &EMF{namespace: namespace, w: w}
+}
+
+// Count emits one counter sample.
+func (e *EMF) Count(name string, delta float64, tags ...Tag) {
+	e.write(name, "Count", delta, tags)
+}
+
+// Observe emits one distribution sample; CloudWatch computes the statistics.
+func (e *EMF) Observe(name string, value float64, tags ...Tag) {
+	unit := "None"
+	if isDuration(name) {
+		unit = "Seconds"
+	}
+	e.write(name, unit, value, tags)
+}
+
+type emfMetric struct {
+	Name string `json:"Name"`
+	Unit string `json:"Unit"`
+}
+
+type emfDirective struct {
+	Namespace  string      `json:"Namespace"`
+	Dimensions [][]string  `json:"Dimensions"`
+	Metrics    []emfMetric `json:"Metrics"`
+}
+
+type emfMetadata struct {
+	Timestamp         int64          `json:"Timestamp"`
+	CloudWatchMetrics []emfDirective `json:"CloudWatchMetrics"`
+}
+
+func (e *EMF) write(name, unit string, value float64, tags []Tag) {
+	keys, values := split(tags)
+	doc := make(map[string]any, len(tags)+2)
+	for i, key := range keys {
+		doc[key] = values[i]
+	}
+	doc[name] = value
+	doc["_aws"] = emfMetadata{
+		Timestamp: time.Now().UnixMilli(),
+		CloudWatchMetrics: []emfDirective{{
+			Namespace:  e.namespace,
+			Dimensions: [][]string{keys},
+			Metrics:    []emfMetric{{Name: name, Unit: unit}},
+		}},
+	}
+
+	line, err := json.Marshal(doc)
+	if err != nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.w.Write(append(line, '\n'))
+}
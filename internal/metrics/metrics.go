@@ -0,0 +1,71 @@
+package metrics
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/berniyo/paypack-lambda/pkg/metrics"
+)
+
+// Metric names recorded by the processor and the notification senders. The Paypack client's
+// names are exported by pkg/paypack.
+const (
+	CashInResults      = "cashin_results_total"
+	PayoutResults      = "payout_results_total"
+	PollDuration       = "poll_duration_seconds"
+	CallbackDeliveries = "callback_deliveries_total"
+
+	Invocations         = "invocations_total"
+	InitDuration        = "init_duration_seconds"
+	InvocationDuration  = "invocation_duration_seconds"
+	InvocationAllocated = "invocation_allocated_bytes"
+	HeapInUse           = "heap_inuse_bytes"
+
+	NotificationDeliveries = "notification_deliveries_total"
+	NotificationLatency    = "notification_duration_seconds"
+
+	BatchItems    = "batch_items_total"
+	BatchDuration = "batch_duration_seconds"
+
+	// SLO series carry provider and client dimensions for alarming on Paypack degradation.
+	// The rolling ones are re-observed after every outcome with the window's current value.
+	SLOOutcomes        = "slo_outcomes_total"
+	SLOConfirmation    = "slo_confirmation_seconds"
+	SLOSuccessRate     = "slo_success_rate"
+	SLOConfirmationP50 = "slo_confirmation_p50_seconds"
+	SLOConfirmationP99 = "slo_confirmation_p99_seconds"
+)
+
+// The instrumentation interface lives in pkg/metrics so the public Paypack client can accept
+// these backends; it is aliased here for the Lambda's own packages.
+type (
+	// Tag is a dimension attached to a measurement.
+	Tag = metrics.Tag
+	// Metrics records counters and distributions.
+	Metrics = metrics.Metrics
+	// Flusher is implemented by backends that buffer measurements.
+	Flusher = metrics.Flusher
+	// Nop discards every measurement.
+	Nop = metrics.Nop
+)
+
+// T builds a Tag.
+func T(key, value string) Tag {
+	return metrics.T(key, value)
+}
+
+// Flush pushes buffered measurements when m is a Flusher and is a no-op otherwise.
+func Flush(ctx context.Context, m Metrics) error {
+	return metrics.Flush(ctx, m)
+}
+
+// Since observes the seconds elapsed since start.
+func Since(m Metrics, name string, start time.Time, tags ...Tag) {
+	metrics.Since(m, name, start, tags...)
+}
+
+// isDuration reports whether name follows the "_seconds" naming convention for latencies.
+func isDuration(name string) bool {
+	return strings.HasSuffix(name, "_seconds")
+}
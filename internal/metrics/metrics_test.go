@@ -0,0 +1,103 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEMFWritesEmbeddedMetricDocuments(t *testing.T) {
+	var buf bytes.Buffer
+	emf := NewEMF("PaypackLambda", &buf)
+
+	emf.Observe(PollDuration, 1.5, T("outcome", "confirmed"))
+
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &doc))
+	require.Equal(t, 1.5, doc[PollDuration])
+	require.Equal(t, "confirmed", doc["outcome"])
+	directive := doc["_aws"].(map[string]any)["CloudWatchMetrics"].([]any)[0].(map[string]any)
+	require.Equal(t, "PaypackLambda", directive["Namespace"])
+	require.Equal(t, []any{[]any{"outcome"}}, directive["Dimensions"])
+	require.Equal(t, "Seconds", directive["Metrics"].([]any)[0].(map[string]any)["Unit"])
+}
+
+func TestStatsDSendsTaggedPackets(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	statsd, err := NewStatsD(conn.LocalAddr().String(), "paypack")
+	require.NoError(t, err)
+	defer statsd.Close()
+
+	statsd.Count(CashInResults, 1, T("status", "success"))
+
+	buf := make([]byte, 512)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	n, _, err := conn.ReadFrom(buf)
+	require.NoError(t, err)
+	require.Equal(t, "paypack.cashin_results_total:1|c|#status:success", string(buf[:n]))
+}
+
+func TestOTLPFlushesAggregatedDeltas(t *testing.T) {
+	var payloads []map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/v1/metrics", r.URL.Path)
+		var payload map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		payloads = append(payloads, payload)
+	}))
+	defer srv.Close()
+
+	otlp, err := NewOTLP(srv.URL, "paypack-lambda", srv.Client())
+	require.NoError(t, err)
+
+	otlp.Count(CashInResults, 1, T("status", "success"))
+	otlp.Count(CashInResults, 2, T("status", "success"))
+	otlp.Observe(PollDuration, 0.3, T("outcome", "confirmed"))
+	require.NoError(t, otlp.Flush(context.Background()))
+	require.NoError(t, otlp.Flush(context.Background()))
+	require.Len(t, payloads, 1)
+
+	scope := payloads[0]["resourceMetrics"].([]any)[0].(map[string]any)["scopeMetrics"].([]any)[0].(map[string]any)
+	metrics := scope["metrics"].([]any)
+	require.Len(t, metrics, 2)
+
+	sum := metrics[0].(map[string]any)
+	require.Equal(t, CashInResults, sum["name"])
+	point := sum["sum"].(map[string]any)["dataPoints"].([]any)[0].(map[string]any)
+	require.Equal(t, 3.0, point["asDouble"])
+
+	hist := metrics[1].(map[string]any)
+	require.Equal(t, PollDuration, hist["name"])
+	point = hist["histogram"].(map[string]any)["dataPoints"].([]any)[0].(map[string]any)
+	require.Equal(t, "1", point["count"])
+}
+
+func TestWindowRollsOffOldOutcomes(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	w := NewWindow(time.Minute)
+	w.now = func() time.Time { return now }
+
+	w.Add("mtn", true, 2*time.Second)
+	w.Add("mtn", false, 0)
+	stats := w.Add("mtn", true, 4*time.Second)
+	require.Equal(t, 3, stats.Total)
+	require.InDelta(t, 2.0/3, stats.SuccessRate, 1e-9)
+	require.Equal(t, 2*time.Second, stats.P50)
+	require.Equal(t, 4*time.Second, stats.P99)
+
+	now = now.Add(90 * time.Second)
+	stats = w.Add("mtn", true, time.Second)
+	require.Equal(t, 1, stats.Total)
+	require.Equal(t, 1.0, stats.SuccessRate)
+	require.Equal(t, 1, w.Add("airtel", true, time.Second).Total)
+}
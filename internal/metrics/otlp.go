@@ -0,0 +1,227 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultBuckets mirrors the Prometheus client's default latency buckets, in seconds.
+var defaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// OTLP aggregates measurements in memory and pushes them as delta sums and histograms to an
+// OpenTelemetry collector over OTLP/HTTP with the JSON encoding. Nothing is sent until Flush.
+type OTLP struct {
+	endpoint   string
+	service    string
+	httpClient *http.Client
+
+	mu         sync.Mutex
+	start      time.Time
+	sums       map[string]*otlpSum
+	histograms map[string]*otlpHistogram
+}
+
+type otlpSum struct {
+	name  string
+	attrs []Tag
+	value float64
+}
+
+type otlpHistogram struct {
+	name    string
+	attrs   []Tag
+	count   uint64
+	sum     float64
+	buckets []uint64
+}
+
+// NewOTLP builds a backend exporting to the collector at endpoint, such as
+// "http://localhost:4318". The "/v1/metrics" path is appended when missing.
+func NewOTLP(endpoint, service string, httpClient *http.Client) (*OTLP, error) {
+	endpoint = strings.TrimSuffix(strings.TrimSpace(endpoint), "/")
+	if endpoint == "" {
+		return nil, errors.New("otlp endpoint is required")
+	}
+	if !strings.HasSuffix(endpoint, "/v1/metrics") {
+		endpoint += "/v1/metrics"
+	}
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 5 * time.Second}
+	}
+	return &OTLP{
+		endpoint:   endpoint,
+		service:    service,
+		httpClient: httpClient,
+		start:      time.Now(),
+		sums:       make(map[string]*otlpSum),
+		histograms: make(map[string]*otlpHistogram),
+	}, nil
+}
+
+// Count adds delta to the named sum.
+func (o *OTLP) Count(name string, delta float64, tags ...Tag) {
+	key, attrs := seriesKey(name, tags)
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	s, ok := o.sums[key]
+	if !ok {
+		s = &otlpSum{name: name, attrs: attrs}
+		o.sums[key] = s
+	}
+	s.value += delta
+}
+
+// Observe records value in the named histogram.
+func (o *OTLP) Observe(name string, value float64, tags ...Tag) {
+	key, attrs := seriesKey(name, tags)
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	h, ok := o.histograms[key]
+	if !ok {
+		h = &otlpHistogram{name: name, attrs: attrs, buckets: make([]uint64, len(defaultBuckets)+1)}
+		o.histograms[key] = h
+	}
+	h.count++
+	h.sum += value
+	h.buckets[sort.SearchFloat64s(defaultBuckets, value)]++
+}
+
+// Flush exports everything recorded since the previous flush. On failure the delta is
+// dropped rather than double counted on the next export.
+func (o *OTLP) Flush(ctx context.Context) error {
+	o.mu.Lock()
+	start, now := o.start, time.Now()
+	sums, histograms := o.sums, o.histograms
+	o.start = now
+	o.sums = make(map[string]*otlpSum)
+	o.histograms = make(map[string]*otlpHistogram)
+	o.mu.Unlock()
+
+	if len(sums) == 0 && len(histograms) == 0 {
+		return nil
+	}
+
+	startNano := strconv.FormatInt(start.UnixNano(), 10)
+	nowNano := strconv.FormatInt(now.UnixNano(), 10)
+	byName := make(map[string]map[string]any)
+	var names []string
+	metric := func(name string) map[string]any {
+		m, ok := byName[name]
+		if !ok {
+			m = map[string]any{"name": name}
+			byName[name] = m
+			names = append(names, name)
+		}
+		return m
+	}
+
+	for _, s := range sums {
+		m := metric(s.name)
+		sum, _ := m["sum"].(map[string]any)
+		if sum == nil {
+			sum = map[string]any{"aggregationTemporality": 1, "isMonotonic": true}
+			m["sum"] = sum
+		}
+		points, _ := sum["dataPoints"].([]any)
+		sum["dataPoints"] = append(points, map[string]any{
+			"attributes":        otlpAttributes(s.attrs),
+			"startTimeUnixNano": startNano,
+			"timeUnixNano":      nowNano,
+			"asDouble":          s.value,
+		})
+	}
+	for _, h := range histograms {
+		m := metric(h.name)
+		if isDuration(h.name) {
+			m["unit"] = "s"
+		}
+		hist, _ := m["histogram"].(map[string]any)
+		if hist == nil {
+			hist = map[string]any{"aggregationTemporality": 1}
+			m["histogram"] = hist
+		}
+		counts := make([]string, len(h.buckets))
+		for i, c := range h.buckets {
+			counts[i] = strconv.FormatUint(c, 10)
+		}
+		points, _ := hist["dataPoints"].([]any)
+		hist["dataPoints"] = append(points, map[string]any{
+			"attributes":        otlpAttributes(h.attrs),
+			"startTimeUnixNano": startNano,
+			"timeUnixNano":      nowNano,
+			"count":             strconv.FormatUint(h.count, 10),
+			"sum":               h.sum,
+			"bucketCounts":      counts,
+			"explicitBounds":    defaultBuckets,
+		})
+	}
+
+	sort.Strings(names)
+	metrics := make([]any, len(names))
+	for i, name := range names {
+		metrics[i] = byName[name]
+	}
+	payload := map[string]any{
+		"resourceMetrics": []any{map[string]any{
+			"resource": map[string]any{
+				"attributes": otlpAttributes([]Tag{T("service.name", o.service)}),
+			},
+			"scopeMetrics": []any{map[string]any{
+				"scope":   map[string]any{"name": "github.com/berniyo/paypack-lambda"},
+				"metrics": metrics,
+			}},
+		}},
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("encode otlp metrics: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := o.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("export otlp metrics: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("export otlp metrics: status=%d", resp.StatusCode)
+	}
+	return nil
+}
+
+// seriesKey identifies a name and tag set, independent of tag order.
+func seriesKey(name string, tags []Tag) (string, []Tag) {
+	keys, values := split(tags)
+	attrs := make([]Tag, len(keys))
+	var b strings.Builder
+	b.WriteString(name)
+	for i := range keys {
+		attrs[i] = T(keys[i], values[i])
+		b.WriteString("\x00" + keys[i] + "=" + values[i])
+	}
+	return b.String(), attrs
+}
+
+func otlpAttributes(tags []Tag) []any {
+	attrs := make([]any, len(tags))
+	for i, t := range tags {
+		attrs[i] = map[string]any{"key": t.Key, "value": map[string]any{"stringValue": t.Value}}
+	}
+	return attrs
+}
@@ -0,0 +1,91 @@
+package metrics
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Prometheus is a Metrics backend for the long-lived server mode. Collectors are registered on
+// first use, with label names taken from that first call's tags.
+type Prometheus struct {
+	namespace string
+	registry  *prometheus.Registry
+
+	mu         sync.Mutex
+	counters   map[string]*prometheus.CounterVec
+	histograms map[string]*prometheus.HistogramVec
+}
+
+// NewPrometheus builds a backend prefixing every metric with namespace.
+func NewPrometheus(namespace string) *Prometheus {
+	return &Prometheus{
+		namespace:  namespace,
+		registry:   prometheus.NewRegistry(),
+		counters:   make(map[string]*prometheus.CounterVec),
+		histograms: make(map[string]*prometheus.HistogramVec),
+	}
+}
+
+// Handler serves the registry in the Prometheus exposition format.
+func (p *Prometheus) Handler() http.Handler {
+	return promhttp.HandlerFor(p.registry, promhttp.HandlerOpts{})
+}
+
+// Count adds delta to the named counter.
+func (p *Prometheus) Count(name string, delta float64, tags ...Tag) {
+	keys, values := split(tags)
+
+	p.mu.Lock()
+	vec, ok := p.counters[name]
+	if !ok {
+		vec = prometheus.NewCounterVec(prometheus.CounterOpts{Namespace: p.namespace, Name: name, Help: name}, keys)
+		if err := p.registry.Register(vec); err != nil {
+			p.mu.Unlock()
+			return
+		}
+		p.counters[name] = vec
+	}
+	p.mu.Unlock()
+
+	if c, err := vec.GetMetricWithLabelValues(values...); err == nil {
+		c.Add(delta)
+	}
+}
+
+// Observe records value in the named histogram.
+func (p *Prometheus) Observe(name string, value float64, tags ...Tag) {
+	keys, values := split(tags)
+
+	p.mu.Lock()
+	vec, ok := p.histograms[name]
+	if !ok {
+		vec = prometheus.NewHistogramVec(prometheus.HistogramOpts{Namespace: p.namespace, Name: name, Help: name}, keys)
+		if err := p.registry.Register(vec); err != nil {
+			p.mu.Unlock()
+			return
+		}
+		p.histograms[name] = vec
+	}
+	p.mu.Unlock()
+
+	if h, err := vec.GetMetricWithLabelValues(values...); err == nil {
+		h.Observe(value)
+	}
+}
+
+// split orders tags by key so label values line up with the registered label names.
+func split(tags []Tag) ([]string, []string) {
+	sorted := append([]Tag(nil), tags...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Key < sorted[j].Key })
+	keys := make([]string, len(sorted))
+	values := make([]string, len(sorted))
+	for i, t := range sorted {
+		keys[i] = t.Key
+		values[i] = t.Value
+	}
+	return keys, values
+}
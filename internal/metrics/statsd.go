@@ -0,0 +1,67 @@
+package metrics
+
+import (
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// StatsD sends measurements over UDP in the DogStatsD dialect, with tags appended as
+// "|#key:value". Packets are fire-and-forget; a missing agent never slows the processor.
+type StatsD struct {
+	prefix string
+	conn   net.Conn
+}
+
+// NewStatsD dials the agent at addr (host:port). A non-empty prefix is joined to every
+// metric name with a dot.
+func NewStatsD(addr, prefix string) (*StatsD, error) {
+	if addr == "" {
+		return nil, errors.New("statsd address is required")
+	}
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return &StatsD{prefix: prefix, conn: conn}, nil
+}
+
+// Count sends a counter increment.
+func (s *StatsD) Count(name string, delta float64, tags ...Tag) {
+	s.send(name, delta, "c", tags)
+}
+
+// Observe sends a histogram sample.
+func (s *StatsD) Observe(name string, value float64, tags ...Tag) {
+	s.send(name, value, "h", tags)
+}
+
+// Close releases the UDP socket.
+func (s *StatsD) Close() error {
+	return s.conn.Close()
+}
+
+func (s *StatsD) send(name string, value float64, kind string, tags []Tag) {
+	var b strings.Builder
+	if s.prefix != "" {
+		b.WriteString(s.prefix)
+		b.WriteByte('.')
+	}
+	b.WriteString(name)
+	b.WriteByte(':')
+	b.WriteString(strconv.FormatFloat(value, 'f', -1, 64))
+	b.WriteByte('|')
+	b.WriteString(kind)
+	for i, t := range tags {
+		if i == 0 {
+			b.WriteString("|#")
+		} else {
+			b.WriteByte(',')
+		}
+		b.WriteString(t.Key)
+		b.WriteByte(':')
+		b.WriteString(t.Value)
+	}
+	s.conn.Write([]byte(b.String()))
+}
@@ -0,0 +1,104 @@
+package metrics
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// maxWindowSamples caps each series so a hot key cannot grow the window without bound.
+const maxWindowSamples = 10000
+
+// Window keeps the outcomes recorded in the last span per key and derives rolling success
+// rates and latency percentiles from them. It is safe for concurrent use.
+type Window struct {
+	span time.Duration
+	now  func() time.Time
+
+	mu     sync.Mutex
+	series map[string][]windowSample
+}
+
+type windowSample struct {
+	at      time.Time
+	ok      bool
+	latency time.Duration
+}
+
+// WindowStats summarises one key's outcomes within the window. Latency percentiles cover
+// successful outcomes only and are zero when there are none.
+type WindowStats struct {
+	Total       int
+	Succeeded   int
+	SuccessRate float64
+	P50         time.Duration
+	P90         time.Duration
+	P99         time.Duration
+}
+
+// NewWindow builds a Window covering the last span.
+func NewWindow(span time.Duration) *Window {
+	return &Window{span: span, now: time.Now, series: make(map[string][]windowSample)}
+}
+
+// Add records an outcome for key and returns the key's stats including it.
+func (w *Window) Add(key string, ok bool, latency time.Duration) WindowStats {
+	now := w.now()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.series[key] = append(w.series[key], windowSample{at: now, ok: ok, latency: latency})
+	return summarise(w.trim(key, now))
+}
+
+// Stats returns key's stats without recording an outcome.
+func (w *Window) Stats(key string) WindowStats {
+	now := w.now()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return summarise(w.trim(key, now))
+}
+
+// trim drops key's samples older than the span, or beyond the cap, and returns the rest.
+func (w *Window) trim(key string, now time.Time) []windowSample {
+	samples := w.series[key]
+	cutoff := now.Add(-w.span)
+	drop := 0
+	for drop < len(samples) && (samples[drop].at.Before(cutoff) || len(samples)-drop > maxWindowSamples) {
+		drop++
+	}
+	if drop > 0 {
+		samples = slices.Delete(samples, 0, drop)
+		w.series[key] = samples
+	}
+	return samples
+}
+
+func summarise(samples []windowSample) WindowStats {
+	stats := WindowStats{Total: len(samples)}
+	latencies := make([]time.Duration, 0, len(samples))
+	for _, s := range samples {
+		if s.ok {
+			stats.Succeeded++
+			latencies = append(latencies, s.latency)
+		}
+	}
+	if stats.Total > 0 {
+		stats.SuccessRate = float64(stats.Succeeded) / float64(stats.Total)
+	}
+	slices.Sort(latencies)
+	stats.P50 = percentile(latencies, 50)
+	stats.P90 = percentile(latencies, 90)
+	stats.P99 = percentile(latencies, 99)
+	return stats
+}
+
+// percentile uses the nearest-rank method on sorted values.
+func percentile(sorted []time.Duration, q int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (len(sorted)*q + 99) / 100
+	return sorted[max(rank-1, 0)]
+}
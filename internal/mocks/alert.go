@@ -0,0 +1,48 @@
+// Code generated by internal/tools/mockgen. DO NOT EDIT.
+
+package mocks
+
+import (
+	"context"
+	"sync"
+
+	"github.com/berniyo/paypack-lambda/internal/alert"
+)
+
+// AlertSenderMock is a mock of alert.Sender. Set the Func field of each method a test calls;
+// calling a method whose Func is nil panics. Calls are recorded for the Calls accessors.
+type AlertSenderMock struct {
+	// SendFunc mocks the Send method.
+	SendFunc func(ctx context.Context, a alert.Alert) error
+
+	mu    sync.Mutex
+	calls struct {
+		Send []AlertSenderMockSendCall
+	}
+}
+
+var _ alert.Sender = (*AlertSenderMock)(nil)
+
+// AlertSenderMockSendCall records one call to AlertSenderMock.Send.
+type AlertSenderMockSendCall struct {
+	Ctx context.Context
+	A   alert.Alert
+}
+
+// Send calls SendFunc.
+func (mock *AlertSenderMock) Send(ctx context.Context, a alert.Alert) error {
+	if mock.SendFunc == nil {
+		panic("AlertSenderMock.SendFunc: method is nil but Sender.Send was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.Send = append(mock.calls.Send, AlertSenderMockSendCall{Ctx: ctx, A: a})
+	mock.mu.Unlock()
+	return mock.SendFunc(ctx, a)
+}
+
+// SendCalls returns the calls made to Send so far.
+func (mock *AlertSenderMock) SendCalls() []AlertSenderMockSendCall {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]AlertSenderMockSendCall(nil), mock.calls.Send...)
+}
@@ -0,0 +1,48 @@
+// Code generated by internal/tools/mockgen. DO NOT EDIT.
+
+package mocks
+
+import (
+	"context"
+	"sync"
+
+	"github.com/berniyo/paypack-lambda/internal/analytics"
+)
+
+// AnalyticsSinkMock is a mock of analytics.Sink. Set the Func field of each method a test calls;
+// calling a method whose Func is nil panics. Calls are recorded for the Calls accessors.
+type AnalyticsSinkMock struct {
+	// EmitFunc mocks the Emit method.
+	EmitFunc func(ctx context.Context, event analytics.Event) error
+
+	mu    sync.Mutex
+	calls struct {
+		Emit []AnalyticsSinkMockEmitCall
+	}
+}
+
+var _ analytics.Sink = (*AnalyticsSinkMock)(nil)
+
+// AnalyticsSinkMockEmitCall records one call to AnalyticsSinkMock.Emit.
+type AnalyticsSinkMockEmitCall struct {
+	Ctx   context.Context
+	Event analytics.Event
+}
+
+// Emit calls EmitFunc.
+func (mock *AnalyticsSinkMock) Emit(ctx context.Context, event analytics.Event) error {
+	if mock.EmitFunc == nil {
+		panic("AnalyticsSinkMock.EmitFunc: method is nil but Sink.Emit was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.Emit = append(mock.calls.Emit, AnalyticsSinkMockEmitCall{Ctx: ctx, Event: event})
+	mock.mu.Unlock()
+	return mock.EmitFunc(ctx, event)
+}
+
+// EmitCalls returns the calls made to Emit so far.
+func (mock *AnalyticsSinkMock) EmitCalls() []AnalyticsSinkMockEmitCall {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]AnalyticsSinkMockEmitCall(nil), mock.calls.Emit...)
+}
@@ -0,0 +1,108 @@
+// Code generated by internal/tools/mockgen. DO NOT EDIT.
+
+package mocks
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/berniyo/paypack-lambda/internal/approval"
+)
+
+// ApprovalStoreMock is a mock of approval.Store. Set the Func field of each method a test calls;
+// calling a method whose Func is nil panics. Calls are recorded for the Calls accessors.
+type ApprovalStoreMock struct {
+	// CreateFunc mocks the Create method.
+	CreateFunc func(ctx context.Context, req approval.Request) error
+
+	// GetFunc mocks the Get method.
+	GetFunc func(ctx context.Context, id string) (*approval.Request, error)
+
+	// ResolveFunc mocks the Resolve method.
+	ResolveFunc func(ctx context.Context, id string, status approval.Status, approver string, at time.Time) (*approval.Request, error)
+
+	mu    sync.Mutex
+	calls struct {
+		Create  []ApprovalStoreMockCreateCall
+		Get     []ApprovalStoreMockGetCall
+		Resolve []ApprovalStoreMockResolveCall
+	}
+}
+
+var _ approval.Store = (*ApprovalStoreMock)(nil)
+
+// ApprovalStoreMockCreateCall records one call to ApprovalStoreMock.Create.
+type ApprovalStoreMockCreateCall struct {
+	Ctx context.Context
+	Req approval.Request
+}
+
+// Create calls CreateFunc.
+func (mock *ApprovalStoreMock) Create(ctx context.Context, req approval.Request) error {
+	if mock.CreateFunc == nil {
+		panic("ApprovalStoreMock.CreateFunc: method is nil but Store.Create was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.Create = append(mock.calls.Create, ApprovalStoreMockCreateCall{Ctx: ctx, Req: req})
+	mock.mu.Unlock()
+	return mock.CreateFunc(ctx, req)
+}
+
+// CreateCalls returns the calls made to Create so far.
+func (mock *ApprovalStoreMock) CreateCalls() []ApprovalStoreMockCreateCall {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]ApprovalStoreMockCreateCall(nil), mock.calls.Create...)
+}
+
+// ApprovalStoreMockGetCall records one call to ApprovalStoreMock.Get.
+type ApprovalStoreMockGetCall struct {
+	Ctx context.Context
+	Id  string
+}
+
+// Get calls GetFunc.
+func (mock *ApprovalStoreMock) Get(ctx context.Context, id string) (*approval.Request, error) {
+	if mock.GetFunc == nil {
+		panic("ApprovalStoreMock.GetFunc: method is nil but Store.Get was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.Get = append(mock.calls.Get, ApprovalStoreMockGetCall{Ctx: ctx, Id: id})
+	mock.mu.Unlock()
+	return mock.GetFunc(ctx, id)
+}
+
+// GetCalls returns the calls made to Get so far.
+func (mock *ApprovalStoreMock) GetCalls() []ApprovalStoreMockGetCall {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]ApprovalStoreMockGetCall(nil), mock.calls.Get...)
+}
+
+// ApprovalStoreMockResolveCall records one call to ApprovalStoreMock.Resolve.
+type ApprovalStoreMockResolveCall struct {
+	Ctx      context.Context
+	Id       string
+	Status   approval.Status
+	Approver string
+	At       time.Time
+}
+
+// Resolve calls ResolveFunc.
+func (mock *ApprovalStoreMock) Resolve(ctx context.Context, id string, status approval.Status, approver string, at time.Time) (*approval.Request, error) {
+	if mock.ResolveFunc == nil {
+		panic("ApprovalStoreMock.ResolveFunc: method is nil but Store.Resolve was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.Resolve = append(mock.calls.Resolve, ApprovalStoreMockResolveCall{Ctx: ctx, Id: id, Status: status, Approver: approver, At: at})
+	mock.mu.Unlock()
+	return mock.ResolveFunc(ctx, id, status, approver, at)
+}
+
+// ResolveCalls returns the calls made to Resolve so far.
+func (mock *ApprovalStoreMock) ResolveCalls() []ApprovalStoreMockResolveCall {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]ApprovalStoreMockResolveCall(nil), mock.calls.Resolve...)
+}
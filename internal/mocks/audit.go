@@ -0,0 +1,104 @@
+// Code generated by internal/tools/mockgen. DO NOT EDIT.
+
+package mocks
+
+import (
+	"context"
+	"sync"
+
+	"github.com/berniyo/paypack-lambda/internal/audit"
+)
+
+// AuditStoreMock is a mock of audit.Store. Set the Func field of each method a test calls;
+// calling a method whose Func is nil panics. Calls are recorded for the Calls accessors.
+type AuditStoreMock struct {
+	// HeadFunc mocks the Head method.
+	HeadFunc func(ctx context.Context) (*audit.Record, error)
+
+	// PutFunc mocks the Put method.
+	PutFunc func(ctx context.Context, rec audit.Record) error
+
+	// ListFunc mocks the List method.
+	ListFunc func(ctx context.Context, after int64, limit int) ([]audit.Record, error)
+
+	mu    sync.Mutex
+	calls struct {
+		Head []AuditStoreMockHeadCall
+		Put  []AuditStoreMockPutCall
+		List []AuditStoreMockListCall
+	}
+}
+
+var _ audit.Store = (*AuditStoreMock)(nil)
+
+// AuditStoreMockHeadCall records one call to AuditStoreMock.Head.
+type AuditStoreMockHeadCall struct {
+	Ctx context.Context
+}
+
+// Head calls HeadFunc.
+func (mock *AuditStoreMock) Head(ctx context.Context) (*audit.Record, error) {
+	if mock.HeadFunc == nil {
+		panic("AuditStoreMock.HeadFunc: method is nil but Store.Head was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.Head = append(mock.calls.Head, AuditStoreMockHeadCall{Ctx: ctx})
+	mock.mu.Unlock()
+	return mock.HeadFunc(ctx)
+}
+
+// HeadCalls returns the calls made to Head so far.
+func (mock *AuditStoreMock) HeadCalls() []AuditStoreMockHeadCall {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]AuditStoreMockHeadCall(nil), mock.calls.Head...)
+}
+
+// AuditStoreMockPutCall records one call to AuditStoreMock.Put.
+type AuditStoreMockPutCall struct {
+	Ctx context.Context
+	Rec audit.Record
+}
+
+// Put calls PutFunc.
+func (mock *AuditStoreMock) Put(ctx context.Context, rec audit.Record) error {
+	if mock.PutFunc == nil {
+		panic("AuditStoreMock.PutFunc: method is nil but Store.Put was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.Put = append(mock.calls.Put, AuditStoreMockPutCall{Ctx: ctx, Rec: rec})
+	mock.mu.Unlock()
+	return mock.PutFunc(ctx, rec)
+}
+
+// PutCalls returns the calls made to Put so far.
+func (mock *AuditStoreMock) PutCalls() []AuditStoreMockPutCall {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]AuditStoreMockPutCall(nil), mock.calls.Put...)
+}
+
+// AuditStoreMockListCall records one call to AuditStoreMock.List.
+type AuditStoreMockListCall struct {
+	Ctx   context.Context
+	After int64
+	Limit int
+}
+
+// List calls ListFunc.
+func (mock *AuditStoreMock) List(ctx context.Context, after int64, limit int) ([]audit.Record, error) {
+	if mock.ListFunc == nil {
+		panic("AuditStoreMock.ListFunc: method is nil but Store.List was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.List = append(mock.calls.List, AuditStoreMockListCall{Ctx: ctx, After: after, Limit: limit})
+	mock.mu.Unlock()
+	return mock.ListFunc(ctx, after, limit)
+}
+
+// ListCalls returns the calls made to List so far.
+func (mock *AuditStoreMock) ListCalls() []AuditStoreMockListCall {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]AuditStoreMockListCall(nil), mock.calls.List...)
+}
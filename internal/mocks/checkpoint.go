@@ -0,0 +1,134 @@
+// Code generated by internal/tools/mockgen. DO NOT EDIT.
+
+package mocks
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/berniyo/paypack-lambda/internal/checkpoint"
+)
+
+// CheckpointStoreMock is a mock of checkpoint.Store. Set the Func field of each method a test calls;
+// calling a method whose Func is nil panics. Calls are recorded for the Calls accessors.
+type CheckpointStoreMock struct {
+	// PutFunc mocks the Put method.
+	PutFunc func(ctx context.Context, cp checkpoint.Checkpoint) error
+
+	// GetFunc mocks the Get method.
+	GetFunc func(ctx context.Context, ref string) (*checkpoint.Checkpoint, error)
+
+	// DeleteFunc mocks the Delete method.
+	DeleteFunc func(ctx context.Context, ref string) error
+
+	// PendingFunc mocks the Pending method.
+	PendingFunc func(ctx context.Context, before time.Time, limit int) ([]checkpoint.Checkpoint, error)
+
+	mu    sync.Mutex
+	calls struct {
+		Put     []CheckpointStoreMockPutCall
+		Get     []CheckpointStoreMockGetCall
+		Delete  []CheckpointStoreMockDeleteCall
+		Pending []CheckpointStoreMockPendingCall
+	}
+}
+
+var _ checkpoint.Store = (*CheckpointStoreMock)(nil)
+
+// CheckpointStoreMockPutCall records one call to CheckpointStoreMock.Put.
+type CheckpointStoreMockPutCall struct {
+	Ctx context.Context
+	Cp  checkpoint.Checkpoint
+}
+
+// Put calls PutFunc.
+func (mock *CheckpointStoreMock) Put(ctx context.Context, cp checkpoint.Checkpoint) error {
+	if mock.PutFunc == nil {
+		panic("CheckpointStoreMock.PutFunc: method is nil but Store.Put was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.Put = append(mock.calls.Put, CheckpointStoreMockPutCall{Ctx: ctx, Cp: cp})
+	mock.mu.Unlock()
+	return mock.PutFunc(ctx, cp)
+}
+
+// PutCalls returns the calls made to Put so far.
+func (mock *CheckpointStoreMock) PutCalls() []CheckpointStoreMockPutCall {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]CheckpointStoreMockPutCall(nil), mock.calls.Put...)
+}
+
+// CheckpointStoreMockGetCall records one call to CheckpointStoreMock.Get.
+type CheckpointStoreMockGetCall struct {
+	Ctx context.Context
+	Ref string
+}
+
+// Get calls GetFunc.
+func (mock *CheckpointStoreMock) Get(ctx context.Context, ref string) (*checkpoint.Checkpoint, error) {
+	if mock.GetFunc == nil {
+		panic("CheckpointStoreMock.GetFunc: method is nil but Store.Get was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.Get = append(mock.calls.Get, CheckpointStoreMockGetCall{Ctx: ctx, Ref: ref})
+	mock.mu.Unlock()
+	return mock.GetFunc(ctx, ref)
+}
+
+// GetCalls returns the calls made to Get so far.
+func (mock *CheckpointStoreMock) GetCalls() []CheckpointStoreMockGetCall {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]CheckpointStoreMockGetCall(nil), mock.calls.Get...)
+}
+
+// CheckpointStoreMockDeleteCall records one call to CheckpointStoreMock.Delete.
+type CheckpointStoreMockDeleteCall struct {
+	Ctx context.Context
+	Ref string
+}
+
+// Delete calls DeleteFunc.
+func (mock *CheckpointStoreMock) Delete(ctx context.Context, ref string) error {
+	if mock.DeleteFunc == nil {
+		panic("CheckpointStoreMock.DeleteFunc: method is nil but Store.Delete was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.Delete = append(mock.calls.Delete, CheckpointStoreMockDeleteCall{Ctx: ctx, Ref: ref})
+	mock.mu.Unlock()
+	return mock.DeleteFunc(ctx, ref)
+}
+
+// DeleteCalls returns the calls made to Delete so far.
+func (mock *CheckpointStoreMock) DeleteCalls() []CheckpointStoreMockDeleteCall {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]CheckpointStoreMockDeleteCall(nil), mock.calls.Delete...)
+}
+
+// CheckpointStoreMockPendingCall records one call to CheckpointStoreMock.Pending.
+type CheckpointStoreMockPendingCall struct {
+	Ctx    context.Context
+	Before time.Time
+	Limit  int
+}
+
+// Pending calls PendingFunc.
+func (mock *CheckpointStoreMock) Pending(ctx context.Context, before time.Time, limit int) ([]checkpoint.Checkpoint, error) {
+	if mock.PendingFunc == nil {
+		panic("CheckpointStoreMock.PendingFunc: method is nil but Store.Pending was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.Pending = append(mock.calls.Pending, CheckpointStoreMockPendingCall{Ctx: ctx, Before: before, Limit: limit})
+	mock.mu.Unlock()
+	return mock.PendingFunc(ctx, before, limit)
+}
+
+// PendingCalls returns the calls made to Pending so far.
+func (mock *CheckpointStoreMock) PendingCalls() []CheckpointStoreMockPendingCall {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]CheckpointStoreMockPendingCall(nil), mock.calls.Pending...)
+}
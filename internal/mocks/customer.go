@@ -0,0 +1,104 @@
+// Code generated by internal/tools/mockgen. DO NOT EDIT.
+
+package mocks
+
+import (
+	"context"
+	"sync"
+
+	"github.com/berniyo/paypack-lambda/internal/customer"
+)
+
+// CustomerStoreMock is a mock of customer.Store. Set the Func field of each method a test calls;
+// calling a method whose Func is nil panics. Calls are recorded for the Calls accessors.
+type CustomerStoreMock struct {
+	// GetFunc mocks the Get method.
+	GetFunc func(ctx context.Context, number string) (*customer.Profile, error)
+
+	// PutFunc mocks the Put method.
+	PutFunc func(ctx context.Context, profile *customer.Profile) error
+
+	// DeleteFunc mocks the Delete method.
+	DeleteFunc func(ctx context.Context, number string) error
+
+	mu    sync.Mutex
+	calls struct {
+		Get    []CustomerStoreMockGetCall
+		Put    []CustomerStoreMockPutCall
+		Delete []CustomerStoreMockDeleteCall
+	}
+}
+
+var _ customer.Store = (*CustomerStoreMock)(nil)
+
+// CustomerStoreMockGetCall records one call to CustomerStoreMock.Get.
+type CustomerStoreMockGetCall struct {
+	Ctx    context.Context
+	Number string
+}
+
+// Get calls GetFunc.
+func (mock *CustomerStoreMock) Get(ctx context.Context, number string) (*customer.Profile, error) {
+	if mock.GetFunc == nil {
+		panic("CustomerStoreMock.GetFunc: method is nil but Store.Get was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.Get = append(mock.calls.Get, CustomerStoreMockGetCall{Ctx: ctx, Number: number})
+	mock.mu.Unlock()
+	return mock.GetFunc(ctx, number)
+}
+
+// GetCalls returns the calls made to Get so far.
+func (mock *CustomerStoreMock) GetCalls() []CustomerStoreMockGetCall {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]CustomerStoreMockGetCall(nil), mock.calls.Get...)
+}
+
+// CustomerStoreMockPutCall records one call to CustomerStoreMock.Put.
+type CustomerStoreMockPutCall struct {
+	Ctx     context.Context
+	Profile *customer.Profile
+}
+
+// Put calls PutFunc.
+func (mock *CustomerStoreMock) Put(ctx context.Context, profile *customer.Profile) error {
+	if mock.PutFunc == nil {
+		panic("CustomerStoreMock.PutFunc: method is nil but Store.Put was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.Put = append(mock.calls.Put, CustomerStoreMockPutCall{Ctx: ctx, Profile: profile})
+	mock.mu.Unlock()
+	return mock.PutFunc(ctx, profile)
+}
+
+// PutCalls returns the calls made to Put so far.
+func (mock *CustomerStoreMock) PutCalls() []CustomerStoreMockPutCall {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]CustomerStoreMockPutCall(nil), mock.calls.Put...)
+}
+
+// CustomerStoreMockDeleteCall records one call to CustomerStoreMock.Delete.
+type CustomerStoreMockDeleteCall struct {
+	Ctx    context.Context
+	Number string
+}
+
+// Delete calls DeleteFunc.
+func (mock *CustomerStoreMock) Delete(ctx context.Context, number string) error {
+	if mock.DeleteFunc == nil {
+		panic("CustomerStoreMock.DeleteFunc: method is nil but Store.Delete was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.Delete = append(mock.calls.Delete, CustomerStoreMockDeleteCall{Ctx: ctx, Number: number})
+	mock.mu.Unlock()
+	return mock.DeleteFunc(ctx, number)
+}
+
+// DeleteCalls returns the calls made to Delete so far.
+func (mock *CustomerStoreMock) DeleteCalls() []CustomerStoreMockDeleteCall {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]CustomerStoreMockDeleteCall(nil), mock.calls.Delete...)
+}
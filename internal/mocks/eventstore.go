@@ -0,0 +1,147 @@
+// Code generated by internal/tools/mockgen. DO NOT EDIT.
+
+package mocks
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/berniyo/paypack-lambda/internal/eventstore"
+)
+
+// EventStoreMock is a mock of eventstore.Store. Set the Func field of each method a test calls;
+// calling a method whose Func is nil panics. Calls are recorded for the Calls accessors.
+type EventStoreMock struct {
+	// AppendFunc mocks the Append method.
+	AppendFunc func(ctx context.Context, rec eventstore.Record) error
+
+	// LatestFunc mocks the Latest method.
+	LatestFunc func(ctx context.Context, ref string, kind eventstore.Kind) (*eventstore.Record, error)
+
+	// ListFunc mocks the List method.
+	ListFunc func(ctx context.Context, ref string) ([]eventstore.Record, error)
+
+	mu    sync.Mutex
+	calls struct {
+		Append []EventStoreMockAppendCall
+		Latest []EventStoreMockLatestCall
+		List   []EventStoreMockListCall
+	}
+}
+
+var _ eventstore.Store = (*EventStoreMock)(nil)
+
+// EventStoreMockAppendCall records one call to EventStoreMock.Append.
+type EventStoreMockAppendCall struct {
+	Ctx context.Context
+	Rec eventstore.Record
+}
+
+// Append calls AppendFunc.
+func (mock *EventStoreMock) Append(ctx context.Context, rec eventstore.Record) error {
+	if mock.AppendFunc == nil {
+		panic("EventStoreMock.AppendFunc: method is nil but Store.Append was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.Append = append(mock.calls.Append, EventStoreMockAppendCall{Ctx: ctx, Rec: rec})
+	mock.mu.Unlock()
+	return mock.AppendFunc(ctx, rec)
+}
+
+// AppendCalls returns the calls made to Append so far.
+func (mock *EventStoreMock) AppendCalls() []EventStoreMockAppendCall {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]EventStoreMockAppendCall(nil), mock.calls.Append...)
+}
+
+// EventStoreMockLatestCall records one call to EventStoreMock.Latest.
+type EventStoreMockLatestCall struct {
+	Ctx  context.Context
+	Ref  string
+	Kind eventstore.Kind
+}
+
+// Latest calls LatestFunc.
+func (mock *EventStoreMock) Latest(ctx context.Context, ref string, kind eventstore.Kind) (*eventstore.Record, error) {
+	if mock.LatestFunc == nil {
+		panic("EventStoreMock.LatestFunc: method is nil but Store.Latest was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.Latest = append(mock.calls.Latest, EventStoreMockLatestCall{Ctx: ctx, Ref: ref, Kind: kind})
+	mock.mu.Unlock()
+	return mock.LatestFunc(ctx, ref, kind)
+}
+
+// LatestCalls returns the calls made to Latest so far.
+func (mock *EventStoreMock) LatestCalls() []EventStoreMockLatestCall {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]EventStoreMockLatestCall(nil), mock.calls.Latest...)
+}
+
+// EventStoreMockListCall records one call to EventStoreMock.List.
+type EventStoreMockListCall struct {
+	Ctx context.Context
+	Ref string
+}
+
+// List calls ListFunc.
+func (mock *EventStoreMock) List(ctx context.Context, ref string) ([]eventstore.Record, error) {
+	if mock.ListFunc == nil {
+		panic("EventStoreMock.ListFunc: method is nil but Store.List was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.List = append(mock.calls.List, EventStoreMockListCall{Ctx: ctx, Ref: ref})
+	mock.mu.Unlock()
+	return mock.ListFunc(ctx, ref)
+}
+
+// ListCalls returns the calls made to List so far.
+func (mock *EventStoreMock) ListCalls() []EventStoreMockListCall {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]EventStoreMockListCall(nil), mock.calls.List...)
+}
+
+// EventScannerMock is a mock of eventstore.Scanner. Set the Func field of each method a test calls;
+// calling a method whose Func is nil panics. Calls are recorded for the Calls accessors.
+type EventScannerMock struct {
+	// ScanFunc mocks the Scan method.
+	ScanFunc func(ctx context.Context, kind eventstore.Kind, from time.Time, to time.Time, fn func(eventstore.Record) error) error
+
+	mu    sync.Mutex
+	calls struct {
+		Scan []EventScannerMockScanCall
+	}
+}
+
+var _ eventstore.Scanner = (*EventScannerMock)(nil)
+
+// EventScannerMockScanCall records one call to EventScannerMock.Scan.
+type EventScannerMockScanCall struct {
+	Ctx  context.Context
+	Kind eventstore.Kind
+	From time.Time
+	To   time.Time
+	Fn   func(eventstore.Record) error
+}
+
+// Scan calls ScanFunc.
+func (mock *EventScannerMock) Scan(ctx context.Context, kind eventstore.Kind, from time.Time, to time.Time, fn func(eventstore.Record) error) error {
+	if mock.ScanFunc == nil {
+		panic("EventScannerMock.ScanFunc: method is nil but Scanner.Scan was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.Scan = append(mock.calls.Scan, EventScannerMockScanCall{Ctx: ctx, Kind: kind, From: from, To: to, Fn: fn})
+	mock.mu.Unlock()
+	return mock.ScanFunc(ctx, kind, from, to, fn)
+}
+
+// ScanCalls returns the calls made to Scan so far.
+func (mock *EventScannerMock) ScanCalls() []EventScannerMockScanCall {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]EventScannerMockScanCall(nil), mock.calls.Scan...)
+}
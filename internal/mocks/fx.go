@@ -0,0 +1,49 @@
+// Code generated by internal/tools/mockgen. DO NOT EDIT.
+
+package mocks
+
+import (
+	"context"
+	"sync"
+
+	"github.com/berniyo/paypack-lambda/internal/fx"
+)
+
+// RateProviderMock is a mock of fx.RateProvider. Set the Func field of each method a test calls;
+// calling a method whose Func is nil panics. Calls are recorded for the Calls accessors.
+type RateProviderMock struct {
+	// RateFunc mocks the Rate method.
+	RateFunc func(ctx context.Context, from string, to string) (float64, error)
+
+	mu    sync.Mutex
+	calls struct {
+		Rate []RateProviderMockRateCall
+	}
+}
+
+var _ fx.RateProvider = (*RateProviderMock)(nil)
+
+// RateProviderMockRateCall records one call to RateProviderMock.Rate.
+type RateProviderMockRateCall struct {
+	Ctx  context.Context
+	From string
+	To   string
+}
+
+// Rate calls RateFunc.
+func (mock *RateProviderMock) Rate(ctx context.Context, from string, to string) (float64, error) {
+	if mock.RateFunc == nil {
+		panic("RateProviderMock.RateFunc: method is nil but RateProvider.Rate was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.Rate = append(mock.calls.Rate, RateProviderMockRateCall{Ctx: ctx, From: from, To: to})
+	mock.mu.Unlock()
+	return mock.RateFunc(ctx, from, to)
+}
+
+// RateCalls returns the calls made to Rate so far.
+func (mock *RateProviderMock) RateCalls() []RateProviderMockRateCall {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]RateProviderMockRateCall(nil), mock.calls.Rate...)
+}
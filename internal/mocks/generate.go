@@ -0,0 +1,24 @@
+// Package mocks holds generated mocks of the interfaces the handler and its stores depend on,
+// so tests outside the declaring packages can stub them without hand-writing fakes. Each
+// XxxMock has an XxxFunc field per method and records calls for the XxxCalls accessors.
+//
+// Add new interfaces to the go:generate lines below and run `go generate ./internal/mocks`.
+package mocks
+
+//go:generate go run ../tools/mockgen -src ../handler -out handler.go PaymentClient CallbackSender SubscriptionLifecycle ReceiptIssuer FeeCalculator RiskChecker BalanceClient PaymentLinkCreator PaymentLinkRecorder PreferenceStore CashOutClient
+//go:generate go run ../tools/mockgen -src ../../pkg/metrics -out metrics.go Metrics Flusher
+//go:generate go run ../tools/mockgen -src ../txcache -prefix Transaction -out txcache.go Store
+//go:generate go run ../tools/mockgen -src ../checkpoint -prefix Checkpoint -out checkpoint.go Store
+//go:generate go run ../tools/mockgen -src ../retry -prefix Retry -out retry.go Store
+//go:generate go run ../tools/mockgen -src ../customer -prefix Customer -out customer.go Store
+//go:generate go run ../tools/mockgen -src ../objectstore -prefix Object -out objectstore.go Store
+//go:generate go run ../tools/mockgen -src ../eventstore -prefix Event -out eventstore.go Store Scanner
+//go:generate go run ../tools/mockgen -src ../audit -prefix Audit -out audit.go Store
+//go:generate go run ../tools/mockgen -src ../wallet -prefix Wallet -out wallet.go Store
+//go:generate go run ../tools/mockgen -src ../ledger -prefix Ledger -out ledger.go Store
+//go:generate go run ../tools/mockgen -src ../approval -prefix Approval -out approval.go Store
+//go:generate go run ../tools/mockgen -src ../subscription -prefix Subscription -out subscription.go Store EventSink
+//go:generate go run ../tools/mockgen -src ../risk -prefix Risk -out risk.go Rule History
+//go:generate go run ../tools/mockgen -src ../alert -prefix Alert -out alert.go Sender
+//go:generate go run ../tools/mockgen -src ../fx -out fx.go RateProvider
+//go:generate go run ../tools/mockgen -src ../analytics -prefix Analytics -out analytics.go Sink
@@ -0,0 +1,497 @@
+// Code generated by internal/tools/mockgen. DO NOT EDIT.
+
+package mocks
+
+import (
+	"context"
+	"sync"
+
+	"github.com/berniyo/paypack-lambda/internal/fee"
+	"github.com/berniyo/paypack-lambda/internal/handler"
+	"github.com/berniyo/paypack-lambda/internal/receipt"
+	"github.com/berniyo/paypack-lambda/internal/risk"
+	"github.com/berniyo/paypack-lambda/internal/subscription"
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+// PaymentClientMock is a mock of handler.PaymentClient. Set the Func field of each method a test calls;
+// calling a method whose Func is nil panics. Calls are recorded for the Calls accessors.
+type PaymentClientMock struct {
+	// CashInFunc mocks the CashIn method.
+	CashInFunc func(ctx context.Context, number string, amount float64, opts ...paypack.CashInOption) (*paypack.Transaction, error)
+
+	// FindTransactionFunc mocks the FindTransaction method.
+	FindTransactionFunc func(ctx context.Context, ref string) (*paypack.Transaction, error)
+
+	mu    sync.Mutex
+	calls struct {
+		CashIn          []PaymentClientMockCashInCall
+		FindTransaction []PaymentClientMockFindTransactionCall
+	}
+}
+
+var _ handler.PaymentClient = (*PaymentClientMock)(nil)
+
+// PaymentClientMockCashInCall records one call to PaymentClientMock.CashIn.
+type PaymentClientMockCashInCall struct {
+	Ctx    context.Context
+	Number string
+	Amount float64
+	Opts   []paypack.CashInOption
+}
+
+// CashIn calls CashInFunc.
+func (mock *PaymentClientMock) CashIn(ctx context.Context, number string, amount float64, opts ...paypack.CashInOption) (*paypack.Transaction, error) {
+	if mock.CashInFunc == nil {
+		panic("PaymentClientMock.CashInFunc: method is nil but PaymentClient.CashIn was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.CashIn = append(mock.calls.CashIn, PaymentClientMockCashInCall{Ctx: ctx, Number: number, Amount: amount, Opts: opts})
+	mock.mu.Unlock()
+	return mock.CashInFunc(ctx, number, amount, opts...)
+}
+
+// CashInCalls returns the calls made to CashIn so far.
+func (mock *PaymentClientMock) CashInCalls() []PaymentClientMockCashInCall {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]PaymentClientMockCashInCall(nil), mock.calls.CashIn...)
+}
+
+// PaymentClientMockFindTransactionCall records one call to PaymentClientMock.FindTransaction.
+type PaymentClientMockFindTransactionCall struct {
+	Ctx context.Context
+	Ref string
+}
+
+// FindTransaction calls FindTransactionFunc.
+func (mock *PaymentClientMock) FindTransaction(ctx context.Context, ref string) (*paypack.Transaction, error) {
+	if mock.FindTransactionFunc == nil {
+		panic("PaymentClientMock.FindTransactionFunc: method is nil but PaymentClient.FindTransaction was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.FindTransaction = append(mock.calls.FindTransaction, PaymentClientMockFindTransactionCall{Ctx: ctx, Ref: ref})
+	mock.mu.Unlock()
+	return mock.FindTransactionFunc(ctx, ref)
+}
+
+// FindTransactionCalls returns the calls made to FindTransaction so far.
+func (mock *PaymentClientMock) FindTransactionCalls() []PaymentClientMockFindTransactionCall {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]PaymentClientMockFindTransactionCall(nil), mock.calls.FindTransaction...)
+}
+
+// CallbackSenderMock is a mock of handler.CallbackSender. Set the Func field of each method a test calls;
+// calling a method whose Func is nil panics. Calls are recorded for the Calls accessors.
+type CallbackSenderMock struct {
+	// SendFunc mocks the Send method.
+	SendFunc func(ctx context.Context, payload handler.SubscriptionResponse) error
+
+	mu    sync.Mutex
+	calls struct {
+		Send []CallbackSenderMockSendCall
+	}
+}
+
+var _ handler.CallbackSender = (*CallbackSenderMock)(nil)
+
+// CallbackSenderMockSendCall records one call to CallbackSenderMock.Send.
+type CallbackSenderMockSendCall struct {
+	Ctx     context.Context
+	Payload handler.SubscriptionResponse
+}
+
+// Send calls SendFunc.
+func (mock *CallbackSenderMock) Send(ctx context.Context, payload handler.SubscriptionResponse) error {
+	if mock.SendFunc == nil {
+		panic("CallbackSenderMock.SendFunc: method is nil but CallbackSender.Send was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.Send = append(mock.calls.Send, CallbackSenderMockSendCall{Ctx: ctx, Payload: payload})
+	mock.mu.Unlock()
+	return mock.SendFunc(ctx, payload)
+}
+
+// SendCalls returns the calls made to Send so far.
+func (mock *CallbackSenderMock) SendCalls() []CallbackSenderMockSendCall {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]CallbackSenderMockSendCall(nil), mock.calls.Send...)
+}
+
+// SubscriptionLifecycleMock is a mock of handler.SubscriptionLifecycle. Set the Func field of each method a test calls;
+// calling a method whose Func is nil panics. Calls are recorded for the Calls accessors.
+type SubscriptionLifecycleMock struct {
+	// ActivateFunc mocks the Activate method.
+	ActivateFunc func(ctx context.Context, id string, ref string) (*subscription.Subscription, error)
+
+	// MarkPastDueFunc mocks the MarkPastDue method.
+	MarkPastDueFunc func(ctx context.Context, id string, ref string, reason string) (*subscription.Subscription, error)
+
+	mu    sync.Mutex
+	calls struct {
+		Activate    []SubscriptionLifecycleMockActivateCall
+		MarkPastDue []SubscriptionLifecycleMockMarkPastDueCall
+	}
+}
+
+var _ handler.SubscriptionLifecycle = (*SubscriptionLifecycleMock)(nil)
+
+// SubscriptionLifecycleMockActivateCall records one call to SubscriptionLifecycleMock.Activate.
+type SubscriptionLifecycleMockActivateCall struct {
+	Ctx context.Context
+	Id  string
+	Ref string
+}
+
+// Activate calls ActivateFunc.
+func (mock *SubscriptionLifecycleMock) Activate(ctx context.Context, id string, ref string) (*subscription.Subscription, error) {
+	if mock.ActivateFunc == nil {
+		panic("SubscriptionLifecycleMock.ActivateFunc: method is nil but SubscriptionLifecycle.Activate was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.Activate = append(mock.calls.Activate, SubscriptionLifecycleMockActivateCall{Ctx: ctx, Id: id, Ref: ref})
+	mock.mu.Unlock()
+	return mock.ActivateFunc(ctx, id, ref)
+}
+
+// ActivateCalls returns the calls made to Activate so far.
+func (mock *SubscriptionLifecycleMock) ActivateCalls() []SubscriptionLifecycleMockActivateCall {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]SubscriptionLifecycleMockActivateCall(nil), mock.calls.Activate...)
+}
+
+// SubscriptionLifecycleMockMarkPastDueCall records one call to SubscriptionLifecycleMock.MarkPastDue.
+type SubscriptionLifecycleMockMarkPastDueCall struct {
+	Ctx    context.Context
+	Id     string
+	Ref    string
+	Reason string
+}
+
+// MarkPastDue calls MarkPastDueFunc.
+func (mock *SubscriptionLifecycleMock) MarkPastDue(ctx context.Context, id string, ref string, reason string) (*subscription.Subscription, error) {
+	if mock.MarkPastDueFunc == nil {
+		panic("SubscriptionLifecycleMock.MarkPastDueFunc: method is nil but SubscriptionLifecycle.MarkPastDue was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.MarkPastDue = append(mock.calls.MarkPastDue, SubscriptionLifecycleMockMarkPastDueCall{Ctx: ctx, Id: id, Ref: ref, Reason: reason})
+	mock.mu.Unlock()
+	return mock.MarkPastDueFunc(ctx, id, ref, reason)
+}
+
+// MarkPastDueCalls returns the calls made to MarkPastDue so far.
+func (mock *SubscriptionLifecycleMock) MarkPastDueCalls() []SubscriptionLifecycleMockMarkPastDueCall {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]SubscriptionLifecycleMockMarkPastDueCall(nil), mock.calls.MarkPastDue...)
+}
+
+// ReceiptIssuerMock is a mock of handler.ReceiptIssuer. Set the Func field of each method a test calls;
+// calling a method whose Func is nil panics. Calls are recorded for the Calls accessors.
+type ReceiptIssuerMock struct {
+	// IssueFunc mocks the Issue method.
+	IssueFunc func(ctx context.Context, data receipt.Data) (*receipt.Receipt, error)
+
+	mu    sync.Mutex
+	calls struct {
+		Issue []ReceiptIssuerMockIssueCall
+	}
+}
+
+var _ handler.ReceiptIssuer = (*ReceiptIssuerMock)(nil)
+
+// ReceiptIssuerMockIssueCall records one call to ReceiptIssuerMock.Issue.
+type ReceiptIssuerMockIssueCall struct {
+	Ctx  context.Context
+	Data receipt.Data
+}
+
+// Issue calls IssueFunc.
+func (mock *ReceiptIssuerMock) Issue(ctx context.Context, data receipt.Data) (*receipt.Receipt, error) {
+	if mock.IssueFunc == nil {
+		panic("ReceiptIssuerMock.IssueFunc: method is nil but ReceiptIssuer.Issue was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.Issue = append(mock.calls.Issue, ReceiptIssuerMockIssueCall{Ctx: ctx, Data: data})
+	mock.mu.Unlock()
+	return mock.IssueFunc(ctx, data)
+}
+
+// IssueCalls returns the calls made to Issue so far.
+func (mock *ReceiptIssuerMock) IssueCalls() []ReceiptIssuerMockIssueCall {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]ReceiptIssuerMockIssueCall(nil), mock.calls.Issue...)
+}
+
+// FeeCalculatorMock is a mock of handler.FeeCalculator. Set the Func field of each method a test calls;
+// calling a method whose Func is nil panics. Calls are recorded for the Calls accessors.
+type FeeCalculatorMock struct {
+	// CompareFunc mocks the Compare method.
+	CompareFunc func(amount float64, actualFee float64, currency string, provider string) fee.Breakdown
+
+	mu    sync.Mutex
+	calls struct {
+		Compare []FeeCalculatorMockCompareCall
+	}
+}
+
+var _ handler.FeeCalculator = (*FeeCalculatorMock)(nil)
+
+// FeeCalculatorMockCompareCall records one call to FeeCalculatorMock.Compare.
+type FeeCalculatorMockCompareCall struct {
+	Amount    float64
+	ActualFee float64
+	Currency  string
+	Provider  string
+}
+
+// Compare calls CompareFunc.
+func (mock *FeeCalculatorMock) Compare(amount float64, actualFee float64, currency string, provider string) fee.Breakdown {
+	if mock.CompareFunc == nil {
+		panic("FeeCalculatorMock.CompareFunc: method is nil but FeeCalculator.Compare was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.Compare = append(mock.calls.Compare, FeeCalculatorMockCompareCall{Amount: amount, ActualFee: actualFee, Currency: currency, Provider: provider})
+	mock.mu.Unlock()
+	return mock.CompareFunc(amount, actualFee, currency, provider)
+}
+
+// CompareCalls returns the calls made to Compare so far.
+func (mock *FeeCalculatorMock) CompareCalls() []FeeCalculatorMockCompareCall {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]FeeCalculatorMockCompareCall(nil), mock.calls.Compare...)
+}
+
+// RiskCheckerMock is a mock of handler.RiskChecker. Set the Func field of each method a test calls;
+// calling a method whose Func is nil panics. Calls are recorded for the Calls accessors.
+type RiskCheckerMock struct {
+	// AssessFunc mocks the Assess method.
+	AssessFunc func(ctx context.Context, in risk.Input) (risk.Assessment, error)
+
+	mu    sync.Mutex
+	calls struct {
+		Assess []RiskCheckerMockAssessCall
+	}
+}
+
+var _ handler.RiskChecker = (*RiskCheckerMock)(nil)
+
+// RiskCheckerMockAssessCall records one call to RiskCheckerMock.Assess.
+type RiskCheckerMockAssessCall struct {
+	Ctx context.Context
+	In  risk.Input
+}
+
+// Assess calls AssessFunc.
+func (mock *RiskCheckerMock) Assess(ctx context.Context, in risk.Input) (risk.Assessment, error) {
+	if mock.AssessFunc == nil {
+		panic("RiskCheckerMock.AssessFunc: method is nil but RiskChecker.Assess was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.Assess = append(mock.calls.Assess, RiskCheckerMockAssessCall{Ctx: ctx, In: in})
+	mock.mu.Unlock()
+	return mock.AssessFunc(ctx, in)
+}
+
+// AssessCalls returns the calls made to Assess so far.
+func (mock *RiskCheckerMock) AssessCalls() []RiskCheckerMockAssessCall {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]RiskCheckerMockAssessCall(nil), mock.calls.Assess...)
+}
+
+// BalanceClientMock is a mock of handler.BalanceClient. Set the Func field of each method a test calls;
+// calling a method whose Func is nil panics. Calls are recorded for the Calls accessors.
+type BalanceClientMock struct {
+	// BalanceFunc mocks the Balance method.
+	BalanceFunc func(ctx context.Context) (*paypack.Balance, error)
+
+	mu    sync.Mutex
+	calls struct {
+		Balance []BalanceClientMockBalanceCall
+	}
+}
+
+var _ handler.BalanceClient = (*BalanceClientMock)(nil)
+
+// BalanceClientMockBalanceCall records one call to BalanceClientMock.Balance.
+type BalanceClientMockBalanceCall struct {
+	Ctx context.Context
+}
+
+// Balance calls BalanceFunc.
+func (mock *BalanceClientMock) Balance(ctx context.Context) (*paypack.Balance, error) {
+	if mock.BalanceFunc == nil {
+		panic("BalanceClientMock.BalanceFunc: method is nil but BalanceClient.Balance was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.Balance = append(mock.calls.Balance, BalanceClientMockBalanceCall{Ctx: ctx})
+	mock.mu.Unlock()
+	return mock.BalanceFunc(ctx)
+}
+
+// BalanceCalls returns the calls made to Balance so far.
+func (mock *BalanceClientMock) BalanceCalls() []BalanceClientMockBalanceCall {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]BalanceClientMockBalanceCall(nil), mock.calls.Balance...)
+}
+
+// PaymentLinkCreatorMock is a mock of handler.PaymentLinkCreator. Set the Func field of each method a test calls;
+// calling a method whose Func is nil panics. Calls are recorded for the Calls accessors.
+type PaymentLinkCreatorMock struct {
+	// CreatePaymentLinkFunc mocks the CreatePaymentLink method.
+	CreatePaymentLinkFunc func(ctx context.Context, req paypack.PaymentLinkRequest) (*paypack.PaymentLink, error)
+
+	mu    sync.Mutex
+	calls struct {
+		CreatePaymentLink []PaymentLinkCreatorMockCreatePaymentLinkCall
+	}
+}
+
+var _ handler.PaymentLinkCreator = (*PaymentLinkCreatorMock)(nil)
+
+// PaymentLinkCreatorMockCreatePaymentLinkCall records one call to PaymentLinkCreatorMock.CreatePaymentLink.
+type PaymentLinkCreatorMockCreatePaymentLinkCall struct {
+	Ctx context.Context
+	Req paypack.PaymentLinkRequest
+}
+
+// CreatePaymentLink calls CreatePaymentLinkFunc.
+func (mock *PaymentLinkCreatorMock) CreatePaymentLink(ctx context.Context, req paypack.PaymentLinkRequest) (*paypack.PaymentLink, error) {
+	if mock.CreatePaymentLinkFunc == nil {
+		panic("PaymentLinkCreatorMock.CreatePaymentLinkFunc: method is nil but PaymentLinkCreator.CreatePaymentLink was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.CreatePaymentLink = append(mock.calls.CreatePaymentLink, PaymentLinkCreatorMockCreatePaymentLinkCall{Ctx: ctx, Req: req})
+	mock.mu.Unlock()
+	return mock.CreatePaymentLinkFunc(ctx, req)
+}
+
+// CreatePaymentLinkCalls returns the calls made to CreatePaymentLink so far.
+func (mock *PaymentLinkCreatorMock) CreatePaymentLinkCalls() []PaymentLinkCreatorMockCreatePaymentLinkCall {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]PaymentLinkCreatorMockCreatePaymentLinkCall(nil), mock.calls.CreatePaymentLink...)
+}
+
+// PaymentLinkRecorderMock is a mock of handler.PaymentLinkRecorder. Set the Func field of each method a test calls;
+// calling a method whose Func is nil panics. Calls are recorded for the Calls accessors.
+type PaymentLinkRecorderMock struct {
+	// AttachPaymentLinkFunc mocks the AttachPaymentLink method.
+	AttachPaymentLinkFunc func(ctx context.Context, id string, link subscription.PaymentLink) (*subscription.Subscription, error)
+
+	mu    sync.Mutex
+	calls struct {
+		AttachPaymentLink []PaymentLinkRecorderMockAttachPaymentLinkCall
+	}
+}
+
+var _ handler.PaymentLinkRecorder = (*PaymentLinkRecorderMock)(nil)
+
+// PaymentLinkRecorderMockAttachPaymentLinkCall records one call to PaymentLinkRecorderMock.AttachPaymentLink.
+type PaymentLinkRecorderMockAttachPaymentLinkCall struct {
+	Ctx  context.Context
+	Id   string
+	Link subscription.PaymentLink
+}
+
+// AttachPaymentLink calls AttachPaymentLinkFunc.
+func (mock *PaymentLinkRecorderMock) AttachPaymentLink(ctx context.Context, id string, link subscription.PaymentLink) (*subscription.Subscription, error) {
+	if mock.AttachPaymentLinkFunc == nil {
+		panic("PaymentLinkRecorderMock.AttachPaymentLinkFunc: method is nil but PaymentLinkRecorder.AttachPaymentLink was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.AttachPaymentLink = append(mock.calls.AttachPaymentLink, PaymentLinkRecorderMockAttachPaymentLinkCall{Ctx: ctx, Id: id, Link: link})
+	mock.mu.Unlock()
+	return mock.AttachPaymentLinkFunc(ctx, id, link)
+}
+
+// AttachPaymentLinkCalls returns the calls made to AttachPaymentLink so far.
+func (mock *PaymentLinkRecorderMock) AttachPaymentLinkCalls() []PaymentLinkRecorderMockAttachPaymentLinkCall {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]PaymentLinkRecorderMockAttachPaymentLinkCall(nil), mock.calls.AttachPaymentLink...)
+}
+
+// PreferenceStoreMock is a mock of handler.PreferenceStore. Set the Func field of each method a test calls;
+// calling a method whose Func is nil panics. Calls are recorded for the Calls accessors.
+type PreferenceStoreMock struct {
+	// ChannelsFunc mocks the Channels method.
+	ChannelsFunc func(ctx context.Context, client string) ([]handler.Channel, error)
+
+	mu    sync.Mutex
+	calls struct {
+		Channels []PreferenceStoreMockChannelsCall
+	}
+}
+
+var _ handler.PreferenceStore = (*PreferenceStoreMock)(nil)
+
+// PreferenceStoreMockChannelsCall records one call to PreferenceStoreMock.Channels.
+type PreferenceStoreMockChannelsCall struct {
+	Ctx    context.Context
+	Client string
+}
+
+// Channels calls ChannelsFunc.
+func (mock *PreferenceStoreMock) Channels(ctx context.Context, client string) ([]handler.Channel, error) {
+	if mock.ChannelsFunc == nil {
+		panic("PreferenceStoreMock.ChannelsFunc: method is nil but PreferenceStore.Channels was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.Channels = append(mock.calls.Channels, PreferenceStoreMockChannelsCall{Ctx: ctx, Client: client})
+	mock.mu.Unlock()
+	return mock.ChannelsFunc(ctx, client)
+}
+
+// ChannelsCalls returns the calls made to Channels so far.
+func (mock *PreferenceStoreMock) ChannelsCalls() []PreferenceStoreMockChannelsCall {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]PreferenceStoreMockChannelsCall(nil), mock.calls.Channels...)
+}
+
+// CashOutClientMock is a mock of handler.CashOutClient. Set the Func field of each method a test calls;
+// calling a method whose Func is nil panics. Calls are recorded for the Calls accessors.
+type CashOutClientMock struct {
+	// CashOutFunc mocks the CashOut method.
+	CashOutFunc func(ctx context.Context, number string, amount float64) (*paypack.Transaction, error)
+
+	mu    sync.Mutex
+	calls struct {
+		CashOut []CashOutClientMockCashOutCall
+	}
+}
+
+var _ handler.CashOutClient = (*CashOutClientMock)(nil)
+
+// CashOutClientMockCashOutCall records one call to CashOutClientMock.CashOut.
+type CashOutClientMockCashOutCall struct {
+	Ctx    context.Context
+	Number string
+	Amount float64
+}
+
+// CashOut calls CashOutFunc.
+func (mock *CashOutClientMock) CashOut(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+	if mock.CashOutFunc == nil {
+		panic("CashOutClientMock.CashOutFunc: method is nil but CashOutClient.CashOut was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.CashOut = append(mock.calls.CashOut, CashOutClientMockCashOutCall{Ctx: ctx, Number: number, Amount: amount})
+	mock.mu.Unlock()
+	return mock.CashOutFunc(ctx, number, amount)
+}
+
+// CashOutCalls returns the calls made to CashOut so far.
+func (mock *CashOutClientMock) CashOutCalls() []CashOutClientMockCashOutCall {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]CashOutClientMockCashOutCall(nil), mock.calls.CashOut...)
+}
@@ -0,0 +1,76 @@
+// Code generated by internal/tools/mockgen. DO NOT EDIT.
+
+package mocks
+
+import (
+	"context"
+	"sync"
+
+	"github.com/berniyo/paypack-lambda/internal/ledger"
+)
+
+// LedgerStoreMock is a mock of ledger.Store. Set the Func field of each method a test calls;
+// calling a method whose Func is nil panics. Calls are recorded for the Calls accessors.
+type LedgerStoreMock struct {
+	// AppendFunc mocks the Append method.
+	AppendFunc func(ctx context.Context, entries ...ledger.Entry) error
+
+	// ListFunc mocks the List method.
+	ListFunc func(ctx context.Context, ref string) ([]ledger.Entry, error)
+
+	mu    sync.Mutex
+	calls struct {
+		Append []LedgerStoreMockAppendCall
+		List   []LedgerStoreMockListCall
+	}
+}
+
+var _ ledger.Store = (*LedgerStoreMock)(nil)
+
+// LedgerStoreMockAppendCall records one call to LedgerStoreMock.Append.
+type LedgerStoreMockAppendCall struct {
+	Ctx     context.Context
+	Entries []ledger.Entry
+}
+
+// Append calls AppendFunc.
+func (mock *LedgerStoreMock) Append(ctx context.Context, entries ...ledger.Entry) error {
+	if mock.AppendFunc == nil {
+		panic("LedgerStoreMock.AppendFunc: method is nil but Store.Append was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.Append = append(mock.calls.Append, LedgerStoreMockAppendCall{Ctx: ctx, Entries: entries})
+	mock.mu.Unlock()
+	return mock.AppendFunc(ctx, entries...)
+}
+
+// AppendCalls returns the calls made to Append so far.
+func (mock *LedgerStoreMock) AppendCalls() []LedgerStoreMockAppendCall {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]LedgerStoreMockAppendCall(nil), mock.calls.Append...)
+}
+
+// LedgerStoreMockListCall records one call to LedgerStoreMock.List.
+type LedgerStoreMockListCall struct {
+	Ctx context.Context
+	Ref string
+}
+
+// List calls ListFunc.
+func (mock *LedgerStoreMock) List(ctx context.Context, ref string) ([]ledger.Entry, error) {
+	if mock.ListFunc == nil {
+		panic("LedgerStoreMock.ListFunc: method is nil but Store.List was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.List = append(mock.calls.List, LedgerStoreMockListCall{Ctx: ctx, Ref: ref})
+	mock.mu.Unlock()
+	return mock.ListFunc(ctx, ref)
+}
+
+// ListCalls returns the calls made to List so far.
+func (mock *LedgerStoreMock) ListCalls() []LedgerStoreMockListCall {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]LedgerStoreMockListCall(nil), mock.calls.List...)
+}
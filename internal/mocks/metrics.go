@@ -0,0 +1,115 @@
+// Code generated by internal/tools/mockgen. DO NOT EDIT.
+
+package mocks
+
+import (
+	"context"
+	"sync"
+
+	"github.com/berniyo/paypack-lambda/pkg/metrics"
+)
+
+// MetricsMock is a mock of metrics.Metrics. Set the Func field of each method a test calls;
+// calling a method whose Func is nil panics. Calls are recorded for the Calls accessors.
+type MetricsMock struct {
+	// CountFunc mocks the Count method.
+	CountFunc func(name string, delta float64, tags ...metrics.Tag)
+
+	// ObserveFunc mocks the Observe method.
+	ObserveFunc func(name string, value float64, tags ...metrics.Tag)
+
+	mu    sync.Mutex
+	calls struct {
+		Count   []MetricsMockCountCall
+		Observe []MetricsMockObserveCall
+	}
+}
+
+var _ metrics.Metrics = (*MetricsMock)(nil)
+
+// MetricsMockCountCall records one call to MetricsMock.Count.
+type MetricsMockCountCall struct {
+	Name  string
+	Delta float64
+	Tags  []metrics.Tag
+}
+
+// Count calls CountFunc.
+func (mock *MetricsMock) Count(name string, delta float64, tags ...metrics.Tag) {
+	if mock.CountFunc == nil {
+		panic("MetricsMock.CountFunc: method is nil but Metrics.Count was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.Count = append(mock.calls.Count, MetricsMockCountCall{Name: name, Delta: delta, Tags: tags})
+	mock.mu.Unlock()
+	mock.CountFunc(name, delta, tags...)
+}
+
+// CountCalls returns the calls made to Count so far.
+func (mock *MetricsMock) CountCalls() []MetricsMockCountCall {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]MetricsMockCountCall(nil), mock.calls.Count...)
+}
+
+// MetricsMockObserveCall records one call to MetricsMock.Observe.
+type MetricsMockObserveCall struct {
+	Name  string
+	Value float64
+	Tags  []metrics.Tag
+}
+
+// Observe calls ObserveFunc.
+func (mock *MetricsMock) Observe(name string, value float64, tags ...metrics.Tag) {
+	if mock.ObserveFunc == nil {
+		panic("MetricsMock.ObserveFunc: method is nil but Metrics.Observe was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.Observe = append(mock.calls.Observe, MetricsMockObserveCall{Name: name, Value: value, Tags: tags})
+	mock.mu.Unlock()
+	mock.ObserveFunc(name, value, tags...)
+}
+
+// ObserveCalls returns the calls made to Observe so far.
+func (mock *MetricsMock) ObserveCalls() []MetricsMockObserveCall {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]MetricsMockObserveCall(nil), mock.calls.Observe...)
+}
+
+// FlusherMock is a mock of metrics.Flusher. Set the Func field of each method a test calls;
+// calling a method whose Func is nil panics. Calls are recorded for the Calls accessors.
+type FlusherMock struct {
+	// FlushFunc mocks the Flush method.
+	FlushFunc func(ctx context.Context) error
+
+	mu    sync.Mutex
+	calls struct {
+		Flush []FlusherMockFlushCall
+	}
+}
+
+var _ metrics.Flusher = (*FlusherMock)(nil)
+
+// FlusherMockFlushCall records one call to FlusherMock.Flush.
+type FlusherMockFlushCall struct {
+	Ctx context.Context
+}
+
+// Flush calls FlushFunc.
+func (mock *FlusherMock) Flush(ctx context.Context) error {
+	if mock.FlushFunc == nil {
+		panic("FlusherMock.FlushFunc: method is nil but Flusher.Flush was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.Flush = append(mock.calls.Flush, FlusherMockFlushCall{Ctx: ctx})
+	mock.mu.Unlock()
+	return mock.FlushFunc(ctx)
+}
+
+// FlushCalls returns the calls made to Flush so far.
+func (mock *FlusherMock) FlushCalls() []FlusherMockFlushCall {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]FlusherMockFlushCall(nil), mock.calls.Flush...)
+}
@@ -0,0 +1,51 @@
+package mocks_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/berniyo/paypack-lambda/internal/handler"
+	"github.com/berniyo/paypack-lambda/internal/mocks"
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+func TestMocksDriveProcessor(t *testing.T) {
+	client := &mocks.PaymentClientMock{
+		CashInFunc: func(ctx context.Context, number string, amount float64, opts ...paypack.CashInOption) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: "abc", Status: "pending"}, nil
+		},
+		FindTransactionFunc: func(ctx context.Context, ref string) (*paypack.Transaction, error) {
+			return &paypack.Transaction{Ref: ref, Status: "success"}, nil
+		},
+	}
+	cb := &mocks.CallbackSenderMock{
+		SendFunc: func(ctx context.Context, payload handler.SubscriptionResponse) error { return nil },
+	}
+
+	processor := handler.NewProcessor(client,
+		handler.WithPollInterval(5*time.Millisecond),
+		handler.WithTimeout(200*time.Millisecond),
+		handler.WithCallbackSender(cb),
+	)
+	resp, err := processor.Handle(context.Background(), handler.SubscriptionEvent{Number: "2507", Amount: 1000})
+	require.NoError(t, err)
+	require.Equal(t, "success", resp.Status)
+
+	cashIns := client.CashInCalls()
+	require.Len(t, cashIns, 1)
+	require.Equal(t, "2507", cashIns[0].Number)
+	require.InDelta(t, 1000, cashIns[0].Amount, 0)
+	require.Equal(t, "abc", client.FindTransactionCalls()[0].Ref)
+	require.Len(t, cb.SendCalls(), 1)
+	require.Equal(t, resp, cb.SendCalls()[0].Payload)
+}
+
+func TestMockPanicsOnUnsetMethod(t *testing.T) {
+	store := &mocks.TransactionStoreMock{}
+	require.PanicsWithValue(t, "TransactionStoreMock.GetFunc: method is nil but Store.Get was just called", func() {
+		_, _ = store.Get(context.Background(), "abc")
+	})
+}
@@ -0,0 +1,80 @@
+// Code generated by internal/tools/mockgen. DO NOT EDIT.
+
+package mocks
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/berniyo/paypack-lambda/internal/objectstore"
+)
+
+// ObjectStoreMock is a mock of objectstore.Store. Set the Func field of each method a test calls;
+// calling a method whose Func is nil panics. Calls are recorded for the Calls accessors.
+type ObjectStoreMock struct {
+	// PutFunc mocks the Put method.
+	PutFunc func(ctx context.Context, key string, contentType string, body []byte) error
+
+	// PresignGetFunc mocks the PresignGet method.
+	PresignGetFunc func(ctx context.Context, key string, ttl time.Duration) (string, error)
+
+	mu    sync.Mutex
+	calls struct {
+		Put        []ObjectStoreMockPutCall
+		PresignGet []ObjectStoreMockPresignGetCall
+	}
+}
+
+var _ objectstore.Store = (*ObjectStoreMock)(nil)
+
+// ObjectStoreMockPutCall records one call to ObjectStoreMock.Put.
+type ObjectStoreMockPutCall struct {
+	Ctx         context.Context
+	Key         string
+	ContentType string
+	Body        []byte
+}
+
+// Put calls PutFunc.
+func (mock *ObjectStoreMock) Put(ctx context.Context, key string, contentType string, body []byte) error {
+	if mock.PutFunc == nil {
+		panic("ObjectStoreMock.PutFunc: method is nil but Store.Put was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.Put = append(mock.calls.Put, ObjectStoreMockPutCall{Ctx: ctx, Key: key, ContentType: contentType, Body: body})
+	mock.mu.Unlock()
+	return mock.PutFunc(ctx, key, contentType, body)
+}
+
+// PutCalls returns the calls made to Put so far.
+func (mock *ObjectStoreMock) PutCalls() []ObjectStoreMockPutCall {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]ObjectStoreMockPutCall(nil), mock.calls.Put...)
+}
+
+// ObjectStoreMockPresignGetCall records one call to ObjectStoreMock.PresignGet.
+type ObjectStoreMockPresignGetCall struct {
+	Ctx context.Context
+	Key string
+	Ttl time.Duration
+}
+
+// PresignGet calls PresignGetFunc.
+func (mock *ObjectStoreMock) PresignGet(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	if mock.PresignGetFunc == nil {
+		panic("ObjectStoreMock.PresignGetFunc: method is nil but Store.PresignGet was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.PresignGet = append(mock.calls.PresignGet, ObjectStoreMockPresignGetCall{Ctx: ctx, Key: key, Ttl: ttl})
+	mock.mu.Unlock()
+	return mock.PresignGetFunc(ctx, key, ttl)
+}
+
+// PresignGetCalls returns the calls made to PresignGet so far.
+func (mock *ObjectStoreMock) PresignGetCalls() []ObjectStoreMockPresignGetCall {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]ObjectStoreMockPresignGetCall(nil), mock.calls.PresignGet...)
+}
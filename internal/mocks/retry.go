@@ -0,0 +1,191 @@
+// Code generated by internal/tools/mockgen. DO NOT EDIT.
+
+package mocks
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/berniyo/paypack-lambda/internal/retry"
+)
+
+// RetryStoreMock is a mock of retry.Store. Set the Func field of each method a test calls;
+// calling a method whose Func is nil panics. Calls are recorded for the Calls accessors.
+type RetryStoreMock struct {
+	// PutFunc mocks the Put method.
+	PutFunc func(ctx context.Context, entry retry.Entry) error
+
+	// GetFunc mocks the Get method.
+	GetFunc func(ctx context.Context, id string) (*retry.Entry, error)
+
+	// DeleteFunc mocks the Delete method.
+	DeleteFunc func(ctx context.Context, id string) error
+
+	// TakeFunc mocks the Take method.
+	TakeFunc func(ctx context.Context, id string) (*retry.Entry, error)
+
+	// ClaimFunc mocks the Claim method.
+	ClaimFunc func(ctx context.Context, entry retry.Entry, until time.Time) error
+
+	// DueFunc mocks the Due method.
+	DueFunc func(ctx context.Context, now time.Time, limit int) ([]retry.Entry, error)
+
+	mu    sync.Mutex
+	calls struct {
+		Put    []RetryStoreMockPutCall
+		Get    []RetryStoreMockGetCall
+		Delete []RetryStoreMockDeleteCall
+		Take   []RetryStoreMockTakeCall
+		Claim  []RetryStoreMockClaimCall
+		Due    []RetryStoreMockDueCall
+	}
+}
+
+var _ retry.Store = (*RetryStoreMock)(nil)
+
+// RetryStoreMockPutCall records one call to RetryStoreMock.Put.
+type RetryStoreMockPutCall struct {
+	Ctx   context.Context
+	Entry retry.Entry
+}
+
+// Put calls PutFunc.
+func (mock *RetryStoreMock) Put(ctx context.Context, entry retry.Entry) error {
+	if mock.PutFunc == nil {
+		panic("RetryStoreMock.PutFunc: method is nil but Store.Put was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.Put = append(mock.calls.Put, RetryStoreMockPutCall{Ctx: ctx, Entry: entry})
+	mock.mu.Unlock()
+	return mock.PutFunc(ctx, entry)
+}
+
+// PutCalls returns the calls made to Put so far.
+func (mock *RetryStoreMock) PutCalls() []RetryStoreMockPutCall {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]RetryStoreMockPutCall(nil), mock.calls.Put...)
+}
+
+// RetryStoreMockGetCall records one call to RetryStoreMock.Get.
+type RetryStoreMockGetCall struct {
+	Ctx context.Context
+	Id  string
+}
+
+// Get calls GetFunc.
+func (mock *RetryStoreMock) Get(ctx context.Context, id string) (*retry.Entry, error) {
+	if mock.GetFunc == nil {
+		panic("RetryStoreMock.GetFunc: method is nil but Store.Get was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.Get = append(mock.calls.Get, RetryStoreMockGetCall{Ctx: ctx, Id: id})
+	mock.mu.Unlock()
+	return mock.GetFunc(ctx, id)
+}
+
+// GetCalls returns the calls made to Get so far.
+func (mock *RetryStoreMock) GetCalls() []RetryStoreMockGetCall {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]RetryStoreMockGetCall(nil), mock.calls.Get...)
+}
+
+// RetryStoreMockDeleteCall records one call to RetryStoreMock.Delete.
+type RetryStoreMockDeleteCall struct {
+	Ctx context.Context
+	Id  string
+}
+
+// Delete calls DeleteFunc.
+func (mock *RetryStoreMock) Delete(ctx context.Context, id string) error {
+	if mock.DeleteFunc == nil {
+		panic("RetryStoreMock.DeleteFunc: method is nil but Store.Delete was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.Delete = append(mock.calls.Delete, RetryStoreMockDeleteCall{Ctx: ctx, Id: id})
+	mock.mu.Unlock()
+	return mock.DeleteFunc(ctx, id)
+}
+
+// DeleteCalls returns the calls made to Delete so far.
+func (mock *RetryStoreMock) DeleteCalls() []RetryStoreMockDeleteCall {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]RetryStoreMockDeleteCall(nil), mock.calls.Delete...)
+}
+
+// RetryStoreMockTakeCall records one call to RetryStoreMock.Take.
+type RetryStoreMockTakeCall struct {
+	Ctx context.Context
+	Id  string
+}
+
+// Take calls TakeFunc.
+func (mock *RetryStoreMock) Take(ctx context.Context, id string) (*retry.Entry, error) {
+	if mock.TakeFunc == nil {
+		panic("RetryStoreMock.TakeFunc: method is nil but Store.Take was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.Take = append(mock.calls.Take, RetryStoreMockTakeCall{Ctx: ctx, Id: id})
+	mock.mu.Unlock()
+	return mock.TakeFunc(ctx, id)
+}
+
+// TakeCalls returns the calls made to Take so far.
+func (mock *RetryStoreMock) TakeCalls() []RetryStoreMockTakeCall {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]RetryStoreMockTakeCall(nil), mock.calls.Take...)
+}
+
+// RetryStoreMockClaimCall records one call to RetryStoreMock.Claim.
+type RetryStoreMockClaimCall struct {
+	Ctx   context.Context
+	Entry retry.Entry
+	Until time.Time
+}
+
+// Claim calls ClaimFunc.
+func (mock *RetryStoreMock) Claim(ctx context.Context, entry retry.Entry, until time.Time) error {
+	if mock.ClaimFunc == nil {
+		panic("RetryStoreMock.ClaimFunc: method is nil but Store.Claim was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.Claim = append(mock.calls.Claim, RetryStoreMockClaimCall{Ctx: ctx, Entry: entry, Until: until})
+	mock.mu.Unlock()
+	return mock.ClaimFunc(ctx, entry, until)
+}
+
+// ClaimCalls returns the calls made to Claim so far.
+func (mock *RetryStoreMock) ClaimCalls() []RetryStoreMockClaimCall {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]RetryStoreMockClaimCall(nil), mock.calls.Claim...)
+}
+
+// RetryStoreMockDueCall records one call to RetryStoreMock.Due.
+type RetryStoreMockDueCall struct {
+	Ctx   context.Context
+	Now   time.Time
+	Limit int
+}
+
+// Due calls DueFunc.
+func (mock *RetryStoreMock) Due(ctx context.Context, now time.Time, limit int) ([]retry.Entry, error) {
+	if mock.DueFunc == nil {
+		panic("RetryStoreMock.DueFunc: method is nil but Store.Due was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.Due = append(mock.calls.Due, RetryStoreMockDueCall{Ctx: ctx, Now: now, Limit: limit})
+	mock.mu.Unlock()
+	return mock.DueFunc(ctx, now, limit)
+}
+
+// DueCalls returns the calls made to Due so far.
+func (mock *RetryStoreMock) DueCalls() []RetryStoreMockDueCall {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]RetryStoreMockDueCall(nil), mock.calls.Due...)
+}
@@ -0,0 +1,143 @@
+// Code generated by internal/tools/mockgen. DO NOT EDIT.
+
+package mocks
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/berniyo/paypack-lambda/internal/risk"
+)
+
+// RiskRuleMock is a mock of risk.Rule. Set the Func field of each method a test calls;
+// calling a method whose Func is nil panics. Calls are recorded for the Calls accessors.
+type RiskRuleMock struct {
+	// NameFunc mocks the Name method.
+	NameFunc func() string
+
+	// EvaluateFunc mocks the Evaluate method.
+	EvaluateFunc func(ctx context.Context, in risk.Input, history risk.History) (*risk.Result, error)
+
+	mu    sync.Mutex
+	calls struct {
+		Name     []RiskRuleMockNameCall
+		Evaluate []RiskRuleMockEvaluateCall
+	}
+}
+
+var _ risk.Rule = (*RiskRuleMock)(nil)
+
+// RiskRuleMockNameCall records one call to RiskRuleMock.Name.
+type RiskRuleMockNameCall struct {
+}
+
+// Name calls NameFunc.
+func (mock *RiskRuleMock) Name() string {
+	if mock.NameFunc == nil {
+		panic("RiskRuleMock.NameFunc: method is nil but Rule.Name was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.Name = append(mock.calls.Name, RiskRuleMockNameCall{})
+	mock.mu.Unlock()
+	return mock.NameFunc()
+}
+
+// NameCalls returns the calls made to Name so far.
+func (mock *RiskRuleMock) NameCalls() []RiskRuleMockNameCall {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]RiskRuleMockNameCall(nil), mock.calls.Name...)
+}
+
+// RiskRuleMockEvaluateCall records one call to RiskRuleMock.Evaluate.
+type RiskRuleMockEvaluateCall struct {
+	Ctx     context.Context
+	In      risk.Input
+	History risk.History
+}
+
+// Evaluate calls EvaluateFunc.
+func (mock *RiskRuleMock) Evaluate(ctx context.Context, in risk.Input, history risk.History) (*risk.Result, error) {
+	if mock.EvaluateFunc == nil {
+		panic("RiskRuleMock.EvaluateFunc: method is nil but Rule.Evaluate was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.Evaluate = append(mock.calls.Evaluate, RiskRuleMockEvaluateCall{Ctx: ctx, In: in, History: history})
+	mock.mu.Unlock()
+	return mock.EvaluateFunc(ctx, in, history)
+}
+
+// EvaluateCalls returns the calls made to Evaluate so far.
+func (mock *RiskRuleMock) EvaluateCalls() []RiskRuleMockEvaluateCall {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]RiskRuleMockEvaluateCall(nil), mock.calls.Evaluate...)
+}
+
+// RiskHistoryMock is a mock of risk.History. Set the Func field of each method a test calls;
+// calling a method whose Func is nil panics. Calls are recorded for the Calls accessors.
+type RiskHistoryMock struct {
+	// RecordFunc mocks the Record method.
+	RecordFunc func(ctx context.Context, in risk.Input) error
+
+	// RecentFunc mocks the Recent method.
+	RecentFunc func(ctx context.Context, number string, since time.Time) ([]risk.Input, error)
+
+	mu    sync.Mutex
+	calls struct {
+		Record []RiskHistoryMockRecordCall
+		Recent []RiskHistoryMockRecentCall
+	}
+}
+
+var _ risk.History = (*RiskHistoryMock)(nil)
+
+// RiskHistoryMockRecordCall records one call to RiskHistoryMock.Record.
+type RiskHistoryMockRecordCall struct {
+	Ctx context.Context
+	In  risk.Input
+}
+
+// Record calls RecordFunc.
+func (mock *RiskHistoryMock) Record(ctx context.Context, in risk.Input) error {
+	if mock.RecordFunc == nil {
+		panic("RiskHistoryMock.RecordFunc: method is nil but History.Record was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.Record = append(mock.calls.Record, RiskHistoryMockRecordCall{Ctx: ctx, In: in})
+	mock.mu.Unlock()
+	return mock.RecordFunc(ctx, in)
+}
+
+// RecordCalls returns the calls made to Record so far.
+func (mock *RiskHistoryMock) RecordCalls() []RiskHistoryMockRecordCall {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]RiskHistoryMockRecordCall(nil), mock.calls.Record...)
+}
+
+// RiskHistoryMockRecentCall records one call to RiskHistoryMock.Recent.
+type RiskHistoryMockRecentCall struct {
+	Ctx    context.Context
+	Number string
+	Since  time.Time
+}
+
+// Recent calls RecentFunc.
+func (mock *RiskHistoryMock) Recent(ctx context.Context, number string, since time.Time) ([]risk.Input, error) {
+	if mock.RecentFunc == nil {
+		panic("RiskHistoryMock.RecentFunc: method is nil but History.Recent was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.Recent = append(mock.calls.Recent, RiskHistoryMockRecentCall{Ctx: ctx, Number: number, Since: since})
+	mock.mu.Unlock()
+	return mock.RecentFunc(ctx, number, since)
+}
+
+// RecentCalls returns the calls made to Recent so far.
+func (mock *RiskHistoryMock) RecentCalls() []RiskHistoryMockRecentCall {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]RiskHistoryMockRecentCall(nil), mock.calls.Recent...)
+}
@@ -0,0 +1,114 @@
+// Code generated by internal/tools/mockgen. DO NOT EDIT.
+
+package mocks
+
+import (
+	"context"
+	"sync"
+
+	"github.com/berniyo/paypack-lambda/internal/subscription"
+)
+
+// SubscriptionStoreMock is a mock of subscription.Store. Set the Func field of each method a test calls;
+// calling a method whose Func is nil panics. Calls are recorded for the Calls accessors.
+type SubscriptionStoreMock struct {
+	// GetFunc mocks the Get method.
+	GetFunc func(ctx context.Context, id string) (*subscription.Subscription, error)
+
+	// PutFunc mocks the Put method.
+	PutFunc func(ctx context.Context, sub *subscription.Subscription) error
+
+	mu    sync.Mutex
+	calls struct {
+		Get []SubscriptionStoreMockGetCall
+		Put []SubscriptionStoreMockPutCall
+	}
+}
+
+var _ subscription.Store = (*SubscriptionStoreMock)(nil)
+
+// SubscriptionStoreMockGetCall records one call to SubscriptionStoreMock.Get.
+type SubscriptionStoreMockGetCall struct {
+	Ctx context.Context
+	Id  string
+}
+
+// Get calls GetFunc.
+func (mock *SubscriptionStoreMock) Get(ctx context.Context, id string) (*subscription.Subscription, error) {
+	if mock.GetFunc == nil {
+		panic("SubscriptionStoreMock.GetFunc: method is nil but Store.Get was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.Get = append(mock.calls.Get, SubscriptionStoreMockGetCall{Ctx: ctx, Id: id})
+	mock.mu.Unlock()
+	return mock.GetFunc(ctx, id)
+}
+
+// GetCalls returns the calls made to Get so far.
+func (mock *SubscriptionStoreMock) GetCalls() []SubscriptionStoreMockGetCall {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]SubscriptionStoreMockGetCall(nil), mock.calls.Get...)
+}
+
+// SubscriptionStoreMockPutCall records one call to SubscriptionStoreMock.Put.
+type SubscriptionStoreMockPutCall struct {
+	Ctx context.Context
+	Sub *subscription.Subscription
+}
+
+// Put calls PutFunc.
+func (mock *SubscriptionStoreMock) Put(ctx context.Context, sub *subscription.Subscription) error {
+	if mock.PutFunc == nil {
+		panic("SubscriptionStoreMock.PutFunc: method is nil but Store.Put was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.Put = append(mock.calls.Put, SubscriptionStoreMockPutCall{Ctx: ctx, Sub: sub})
+	mock.mu.Unlock()
+	return mock.PutFunc(ctx, sub)
+}
+
+// PutCalls returns the calls made to Put so far.
+func (mock *SubscriptionStoreMock) PutCalls() []SubscriptionStoreMockPutCall {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]SubscriptionStoreMockPutCall(nil), mock.calls.Put...)
+}
+
+// SubscriptionEventSinkMock is a mock of subscription.EventSink. Set the Func field of each method a test calls;
+// calling a method whose Func is nil panics. Calls are recorded for the Calls accessors.
+type SubscriptionEventSinkMock struct {
+	// PublishFunc mocks the Publish method.
+	PublishFunc func(ctx context.Context, event subscription.Event) error
+
+	mu    sync.Mutex
+	calls struct {
+		Publish []SubscriptionEventSinkMockPublishCall
+	}
+}
+
+var _ subscription.EventSink = (*SubscriptionEventSinkMock)(nil)
+
+// SubscriptionEventSinkMockPublishCall records one call to SubscriptionEventSinkMock.Publish.
+type SubscriptionEventSinkMockPublishCall struct {
+	Ctx   context.Context
+	Event subscription.Event
+}
+
+// Publish calls PublishFunc.
+func (mock *SubscriptionEventSinkMock) Publish(ctx context.Context, event subscription.Event) error {
+	if mock.PublishFunc == nil {
+		panic("SubscriptionEventSinkMock.PublishFunc: method is nil but EventSink.Publish was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.Publish = append(mock.calls.Publish, SubscriptionEventSinkMockPublishCall{Ctx: ctx, Event: event})
+	mock.mu.Unlock()
+	return mock.PublishFunc(ctx, event)
+}
+
+// PublishCalls returns the calls made to Publish so far.
+func (mock *SubscriptionEventSinkMock) PublishCalls() []SubscriptionEventSinkMockPublishCall {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]SubscriptionEventSinkMockPublishCall(nil), mock.calls.Publish...)
+}
@@ -0,0 +1,79 @@
+// Code generated by internal/tools/mockgen. DO NOT EDIT.
+
+package mocks
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/berniyo/paypack-lambda/internal/txcache"
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+// TransactionStoreMock is a mock of txcache.Store. Set the Func field of each method a test calls;
+// calling a method whose Func is nil panics. Calls are recorded for the Calls accessors.
+type TransactionStoreMock struct {
+	// GetFunc mocks the Get method.
+	GetFunc func(ctx context.Context, ref string) (*paypack.Transaction, error)
+
+	// PutFunc mocks the Put method.
+	PutFunc func(ctx context.Context, txn paypack.Transaction, ttl time.Duration) error
+
+	mu    sync.Mutex
+	calls struct {
+		Get []TransactionStoreMockGetCall
+		Put []TransactionStoreMockPutCall
+	}
+}
+
+var _ txcache.Store = (*TransactionStoreMock)(nil)
+
+// TransactionStoreMockGetCall records one call to TransactionStoreMock.Get.
+type TransactionStoreMockGetCall struct {
+	Ctx context.Context
+	Ref string
+}
+
+// Get calls GetFunc.
+func (mock *TransactionStoreMock) Get(ctx context.Context, ref string) (*paypack.Transaction, error) {
+	if mock.GetFunc == nil {
+		panic("TransactionStoreMock.GetFunc: method is nil but Store.Get was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.Get = append(mock.calls.Get, TransactionStoreMockGetCall{Ctx: ctx, Ref: ref})
+	mock.mu.Unlock()
+	return mock.GetFunc(ctx, ref)
+}
+
+// GetCalls returns the calls made to Get so far.
+func (mock *TransactionStoreMock) GetCalls() []TransactionStoreMockGetCall {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]TransactionStoreMockGetCall(nil), mock.calls.Get...)
+}
+
+// TransactionStoreMockPutCall records one call to TransactionStoreMock.Put.
+type TransactionStoreMockPutCall struct {
+	Ctx context.Context
+	Txn paypack.Transaction
+	Ttl time.Duration
+}
+
+// Put calls PutFunc.
+func (mock *TransactionStoreMock) Put(ctx context.Context, txn paypack.Transaction, ttl time.Duration) error {
+	if mock.PutFunc == nil {
+		panic("TransactionStoreMock.PutFunc: method is nil but Store.Put was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.Put = append(mock.calls.Put, TransactionStoreMockPutCall{Ctx: ctx, Txn: txn, Ttl: ttl})
+	mock.mu.Unlock()
+	return mock.PutFunc(ctx, txn, ttl)
+}
+
+// PutCalls returns the calls made to Put so far.
+func (mock *TransactionStoreMock) PutCalls() []TransactionStoreMockPutCall {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]TransactionStoreMockPutCall(nil), mock.calls.Put...)
+}
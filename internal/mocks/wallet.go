@@ -0,0 +1,78 @@
+// Code generated by internal/tools/mockgen. DO NOT EDIT.
+
+package mocks
+
+import (
+	"context"
+	"sync"
+
+	"github.com/berniyo/paypack-lambda/internal/wallet"
+)
+
+// WalletStoreMock is a mock of wallet.Store. Set the Func field of each method a test calls;
+// calling a method whose Func is nil panics. Calls are recorded for the Calls accessors.
+type WalletStoreMock struct {
+	// ApplyFunc mocks the Apply method.
+	ApplyFunc func(ctx context.Context, key string, currency string, delta float64) (wallet.Balance, error)
+
+	// GetFunc mocks the Get method.
+	GetFunc func(ctx context.Context, currency string) (wallet.Balance, error)
+
+	mu    sync.Mutex
+	calls struct {
+		Apply []WalletStoreMockApplyCall
+		Get   []WalletStoreMockGetCall
+	}
+}
+
+var _ wallet.Store = (*WalletStoreMock)(nil)
+
+// WalletStoreMockApplyCall records one call to WalletStoreMock.Apply.
+type WalletStoreMockApplyCall struct {
+	Ctx      context.Context
+	Key      string
+	Currency string
+	Delta    float64
+}
+
+// Apply calls ApplyFunc.
+func (mock *WalletStoreMock) Apply(ctx context.Context, key string, currency string, delta float64) (wallet.Balance, error) {
+	if mock.ApplyFunc == nil {
+		panic("WalletStoreMock.ApplyFunc: method is nil but Store.Apply was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.Apply = append(mock.calls.Apply, WalletStoreMockApplyCall{Ctx: ctx, Key: key, Currency: currency, Delta: delta})
+	mock.mu.Unlock()
+	return mock.ApplyFunc(ctx, key, currency, delta)
+}
+
+// ApplyCalls returns the calls made to Apply so far.
+func (mock *WalletStoreMock) ApplyCalls() []WalletStoreMockApplyCall {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]WalletStoreMockApplyCall(nil), mock.calls.Apply...)
+}
+
+// WalletStoreMockGetCall records one call to WalletStoreMock.Get.
+type WalletStoreMockGetCall struct {
+	Ctx      context.Context
+	Currency string
+}
+
+// Get calls GetFunc.
+func (mock *WalletStoreMock) Get(ctx context.Context, currency string) (wallet.Balance, error) {
+	if mock.GetFunc == nil {
+		panic("WalletStoreMock.GetFunc: method is nil but Store.Get was just called")
+	}
+	mock.mu.Lock()
+	mock.calls.Get = append(mock.calls.Get, WalletStoreMockGetCall{Ctx: ctx, Currency: currency})
+	mock.mu.Unlock()
+	return mock.GetFunc(ctx, currency)
+}
+
+// GetCalls returns the calls made to Get so far.
+func (mock *WalletStoreMock) GetCalls() []WalletStoreMockGetCall {
+	mock.mu.Lock()
+	defer mock.mu.Unlock()
+	return append([]WalletStoreMockGetCall(nil), mock.calls.Get...)
+}
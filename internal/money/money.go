@@ -0,0 +1,46 @@
+// Package money converts between decimal amounts and integer minor units (cents, or whole
+// francs for currencies without a minor unit), so amounts can cross the wire exactly.
+package money
+
+import (
+	"math"
+	"strconv"
+)
+
+// exponents lists ISO 4217 currencies whose minor unit is not a hundredth.
+var exponents = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+	"PYG": 0, "RWF": 0, "UGX": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+}
+
+// Exponent returns the number of decimal places of currency's minor unit, 2 unless listed.
+func Exponent(currency string) int {
+	if exp, ok := exponents[currency]; ok {
+		return exp
+	}
+	return 2
+}
+
+// ToMinor converts amount in currency to minor units, rounding half away from zero.
+func ToMinor(amount float64, currency string) int64 {
+	return int64(math.Round(amount * math.Pow10(Exponent(currency))))
+}
+
+// FromMinor converts minor units of currency to a decimal amount.
+func FromMinor(minor int64, currency string) float64 {
+	return float64(minor) / math.Pow10(Exponent(currency))
+}
+
+// Round rounds amount to the minor unit of currency, half away from zero; fees, taxes, splits
+// and balances are kept to it.
+func Round(amount float64, currency string) float64 {
+	scale := math.Pow10(Exponent(currency))
+	return math.Round(amount*scale) / scale
+}
+
+// Format renders amount with the decimal places of currency's minor unit, as balances are
+// stored and exports written.
+func Format(amount float64, currency string) string {
+	return strconv.FormatFloat(Round(amount, currency), 'f', Exponent(currency), 64)
+}
@@ -0,0 +1,31 @@
+package money
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMinorUnitsFollowCurrencyExponent(t *testing.T) {
+	require.Equal(t, int64(1000), ToMinor(1000, "RWF"))
+	require.Equal(t, int64(1999), ToMinor(19.99, "USD"))
+	require.Equal(t, int64(30), ToMinor(0.1+0.2, "EUR"))
+	require.Equal(t, int64(1500), ToMinor(1.5, "KWD"))
+
+	require.Equal(t, 1000.0, FromMinor(1000, "RWF"))
+	require.Equal(t, 19.99, FromMinor(1999, "USD"))
+	require.Equal(t, 1.5, FromMinor(1500, "KWD"))
+}
+
+func TestRoundAndFormatFollowCurrencyExponent(t *testing.T) {
+	require.Equal(t, 0.3, Round(0.1+0.2, "USD"))
+	require.Equal(t, 11.5, Round(11.499999, "USD"))
+	require.Equal(t, -2.35, Round(-2.345, "EUR"))
+	require.Equal(t, 489.0, Round(488.5, "RWF"))
+	require.Equal(t, 1.235, Round(1.2345, "KWD"))
+
+	require.Equal(t, "488.50", Format(488.5, "USD"))
+	require.Equal(t, "1000", Format(1000, "RWF"))
+	require.Equal(t, "489", Format(488.5, "RWF"))
+	require.Equal(t, "1.500", Format(1.5, "KWD"))
+}
@@ -0,0 +1,321 @@
+package objectstore
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// ErrNotFound marks a read of a key that does not exist.
+var ErrNotFound = errors.New("object not found")
+
+// Store uploads documents and hands out time-limited download links.
+type Store interface {
+	Put(ctx context.Context, key, contentType string, body []byte) error
+	PresignGet(ctx context.Context, key string, ttl time.Duration) (string, error)
+}
+
+// Streamer uploads documents of unknown length as they are produced, without holding them in
+// memory.
+type Streamer interface {
+	PutStream(ctx context.Context, key, contentType string, body io.Reader) error
+}
+
+// Reader downloads documents.
+type Reader interface {
+	Read(ctx context.Context, key string) ([]byte, error)
+}
+
+// Deleter removes documents; deleting a key that does not exist is not an error.
+type Deleter interface {
+	Delete(ctx context.Context, key string) error
+}
+
+// Lister walks the keys under a prefix in lexical order.
+type Lister interface {
+	List(ctx context.Context, prefix string, fn func(key string) error) error
+}
+
+// S3Store is a Store backed by a single S3 bucket.
+type S3Store struct {
+	client  *s3.Client
+	presign *s3.PresignClient
+	bucket  string
+}
+
+// NewS3Store builds a Store writing to bucket.
+func NewS3Store(client *s3.Client, bucket string) (*S3Store, error) {
+	if client == nil {
+		return nil, errors.New("s3 client is required")
+	}
+	if bucket == "" {
+		return nil, errors.New("bucket is required")
+	}
+
+	return &S3Store{
+		client:  client,
+		presign: s3.NewPresignClient(client),
+		bucket:  bucket,
+	}, nil
+}
+
+// Put uploads body under key.
+func (s *S3Store) Put(ctx context.Context, key, contentType string, body []byte) error {
+	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(body),
+		ContentType: aws.String(contentType),
+	})
+	if err != nil {
+		return fmt.Errorf("put s3://%s/%s: %w", s.bucket, key, err)
+	}
+	return nil
+}
+
+// partSize is the size of multipart upload parts; S3 requires at least 5 MiB for all but the
+// last.
+const partSize = 5 << 20
+
+// PutStream uploads body under key. Bodies of up to one part are sent with PutObject; longer
+// ones as a multipart upload, buffering one part at a time. A failed upload is aborted so no
+// parts are left behind.
+func (s *S3Store) PutStream(ctx context.Context, key, contentType string, body io.Reader) error {
+	part := make([]byte, partSize)
+	n, err := io.ReadFull(body, part)
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return s.Put(ctx, key, contentType, part[:n])
+	}
+	if err != nil {
+		return fmt.Errorf("read s3://%s/%s: %w", s.bucket, key, err)
+	}
+
+	created, err := s.client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(key),
+		ContentType: aws.String(contentType),
+	})
+	if err != nil {
+		return fmt.Errorf("create upload s3://%s/%s: %w", s.bucket, key, err)
+	}
+	if err := s.uploadParts(ctx, key, created.UploadId, part, body); err != nil {
+		_, _ = s.client.AbortMultipartUpload(context.WithoutCancel(ctx), &s3.AbortMultipartUploadInput{
+			Bucket:   aws.String(s.bucket),
+			Key:      aws.String(key),
+			UploadId: created.UploadId,
+		})
+		return err
+	}
+	return nil
+}
+
+// uploadParts sends first, which is full, then the rest of body, and completes the upload.
+func (s *S3Store) uploadParts(ctx context.Context, key string, uploadID *string, first []byte, body io.Reader) error {
+	var completed []types.CompletedPart
+	chunk, last := first, false
+	for number := int32(1); ; number++ {
+		out, err := s.client.UploadPart(ctx, &s3.UploadPartInput{
+			Bucket:     aws.String(s.bucket),
+			Key:        aws.String(key),
+			UploadId:   uploadID,
+			PartNumber: aws.Int32(number),
+			Body:       bytes.NewReader(chunk),
+		})
+		if err != nil {
+			return fmt.Errorf("upload part %d of s3://%s/%s: %w", number, s.bucket, key, err)
+		}
+		completed = append(completed, types.CompletedPart{ETag: out.ETag, PartNumber: aws.Int32(number)})
+		if last {
+			break
+		}
+
+		n, err := io.ReadFull(body, first)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return fmt.Errorf("read s3://%s/%s: %w", s.bucket, key, err)
+		}
+		chunk, last = first[:n], err != nil
+	}
+
+	_, err := s.client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(s.bucket),
+		Key:             aws.String(key),
+		UploadId:        uploadID,
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: completed},
+	})
+	if err != nil {
+		return fmt.Errorf("complete upload s3://%s/%s: %w", s.bucket, key, err)
+	}
+	return nil
+}
+
+// Read downloads key, returning ErrNotFound when it does not exist.
+func (s *S3Store) Read(ctx context.Context, key string) ([]byte, error) {
+	out, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		var missing *types.NoSuchKey
+		if errors.As(err, &missing) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("get s3://%s/%s: %w", s.bucket, key, err)
+	}
+	defer out.Body.Close()
+	body, err := io.ReadAll(out.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read s3://%s/%s: %w", s.bucket, key, err)
+	}
+	return body, nil
+}
+
+// Delete removes key.
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	_, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return fmt.Errorf("delete s3://%s/%s: %w", s.bucket, key, err)
+	}
+	return nil
+}
+
+// List calls fn with every key under prefix, stopping at the first error fn returns.
+func (s *S3Store) List(ctx context.Context, prefix string, fn func(key string) error) error {
+	pages := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(prefix),
+	})
+	for pages.HasMorePages() {
+		page, err := pages.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("list s3://%s/%s: %w", s.bucket, prefix, err)
+		}
+		for _, obj := range page.Contents {
+			if err := fn(aws.ToString(obj.Key)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// PresignGet returns a GET URL for key valid for ttl.
+func (s *S3Store) PresignGet(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	req, err := s.presign.PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	}, s3.WithPresignExpires(ttl))
+	if err != nil {
+		return "", fmt.Errorf("presign s3://%s/%s: %w", s.bucket, key, err)
+	}
+	return req.URL, nil
+}
+
+// MemoryStore is an in-process Store for tests and local runs.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	objects map[string]Object
+}
+
+// Object is a document held by MemoryStore.
+type Object struct {
+	ContentType string
+	Body        []byte
+}
+
+// NewMemoryStore builds an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{objects: make(map[string]Object)}
+}
+
+// Put stores a copy of body under key.
+func (m *MemoryStore) Put(ctx context.Context, key, contentType string, body []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.objects[key] = Object{ContentType: contentType, Body: append([]byte(nil), body...)}
+	return nil
+}
+
+// PutStream stores body, read to the end, under key.
+func (m *MemoryStore) PutStream(ctx context.Context, key, contentType string, body io.Reader) error {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return fmt.Errorf("read %s: %w", key, err)
+	}
+	return m.Put(ctx, key, contentType, data)
+}
+
+// PresignGet returns a memory:// URL for key.
+func (m *MemoryStore) PresignGet(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if _, ok := m.objects[key]; !ok {
+		return "", ErrNotFound
+	}
+	return "memory://" + key, nil
+}
+
+// Read returns a copy of the stored body, or ErrNotFound.
+func (m *MemoryStore) Read(ctx context.Context, key string) ([]byte, error) {
+	obj, err := m.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(nil), obj.Body...), nil
+}
+
+// Get returns the stored object, or ErrNotFound.
+func (m *MemoryStore) Get(key string) (Object, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	obj, ok := m.objects[key]
+	if !ok {
+		return Object{}, ErrNotFound
+	}
+	return obj, nil
+}
+
+// Delete removes key.
+func (m *MemoryStore) Delete(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.objects, key)
+	return nil
+}
+
+// List calls fn with every key under prefix in lexical order.
+func (m *MemoryStore) List(ctx context.Context, prefix string, fn func(key string) error) error {
+	m.mu.RLock()
+	var keys []string
+	for key := range m.objects {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	m.mu.RUnlock()
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := fn(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
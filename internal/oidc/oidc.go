@@ -0,0 +1,193 @@
+// Package oidc fetches identity tokens from an OpenID Connect issuer with the client
+// credentials grant and caches them until shortly before they expire.
+package oidc
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// expiryBuffer is how long before its expiry a cached token is replaced.
+const expiryBuffer = time.Minute
+
+// Config names the issuer and the client credentials to exchange.
+type Config struct {
+	// Issuer is discovered through /.well-known/openid-configuration. TokenURL skips the
+	// discovery (Cognito's is https://<domain>/oauth2/token).
+	Issuer       string
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	// Audience and Scopes are sent when set; IdPs differ on which one selects the receiver.
+	Audience string
+	Scopes   []string
+}
+
+// TokenSource hands out a cached token, fetching a new one when it is about to expire.
+type TokenSource struct {
+	cfg        Config
+	httpClient *http.Client
+	now        func() time.Time
+
+	mu       sync.Mutex
+	tokenURL string
+	token    string
+	expires  time.Time
+}
+
+// New builds a TokenSource for cfg.
+func New(cfg Config, httpClient *http.Client) (*TokenSource, error) {
+	cfg.Issuer = strings.TrimSuffix(strings.TrimSpace(cfg.Issuer), "/")
+	cfg.TokenURL = strings.TrimSpace(cfg.TokenURL)
+	if cfg.Issuer == "" && cfg.TokenURL == "" {
+		return nil, errors.New("oidc issuer or token url is required")
+	}
+	if cfg.ClientID == "" {
+		return nil, errors.New("oidc client id is required")
+	}
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &TokenSource{cfg: cfg, httpClient: httpClient, now: time.Now, tokenURL: cfg.TokenURL}, nil
+}
+
+// Token returns the cached token, or fetches one. Concurrent callers share one fetch.
+func (s *TokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token != "" && s.now().Before(s.expires) {
+		return s.token, nil
+	}
+	if s.tokenURL == "" {
+		endpoint, err := s.discover(ctx)
+		if err != nil {
+			return "", err
+		}
+		s.tokenURL = endpoint
+	}
+	token, expires, err := s.fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+	s.token, s.expires = token, expires
+	return token, nil
+}
+
+// Invalidate drops the cached token, e.g. after the receiver rejected it.
+func (s *TokenSource) Invalidate() {
+	s.mu.Lock()
+	s.token = ""
+	s.mu.Unlock()
+}
+
+func (s *TokenSource) discover(ctx context.Context) (string, error) {
+	var doc struct {
+		TokenEndpoint string `json:"token_endpoint"`
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.cfg.Issuer+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return "", fmt.Errorf("build oidc discovery request: %w", err)
+	}
+	if err := s.do(req, &doc); err != nil {
+		return "", fmt.Errorf("oidc discovery: %w", err)
+	}
+	if doc.TokenEndpoint == "" {
+		return "", errors.New("oidc discovery document has no token_endpoint")
+	}
+	return doc.TokenEndpoint, nil
+}
+
+func (s *TokenSource) fetch(ctx context.Context) (string, time.Time, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if s.cfg.Audience != "" {
+		form.Set("audience", s.cfg.Audience)
+	}
+	if len(s.cfg.Scopes) > 0 {
+		form.Set("scope", strings.Join(s.cfg.Scopes, " "))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("build oidc token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(s.cfg.ClientID), url.QueryEscape(s.cfg.ClientSecret))
+
+	var out struct {
+		IDToken     string `json:"id_token"`
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := s.do(req, &out); err != nil {
+		return "", time.Time{}, fmt.Errorf("oidc token: %w", err)
+	}
+	// Client credentials grants often return only an access token, which Cognito and most
+	// IdPs issue as a JWT the receiver validates the same way.
+	token := out.IDToken
+	if token == "" {
+		token = out.AccessToken
+	}
+	if token == "" {
+		return "", time.Time{}, errors.New("oidc token response has no id_token or access_token")
+	}
+
+	now := s.now()
+	expires := now.Add(time.Duration(out.ExpiresIn) * time.Second)
+	if out.ExpiresIn <= 0 {
+		if exp, ok := jwtExpiry(token); ok {
+			expires = exp
+		} else {
+			expires = now.Add(5 * time.Minute)
+		}
+	}
+	if lifetime := expires.Sub(now); lifetime > 2*expiryBuffer {
+		expires = expires.Add(-expiryBuffer)
+	} else {
+		expires = now.Add(lifetime / 2)
+	}
+	return token, expires, nil
+}
+
+func (s *TokenSource) do(req *http.Request, v any) error {
+	req.Header.Set("Accept", "application/json")
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return json.Unmarshal(body, v)
+}
+
+// jwtExpiry reads the exp claim of an unverified JWT; the issuer is trusted by construction.
+func jwtExpiry(token string) (time.Time, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return time.Time{}, false
+	}
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+	if json.Unmarshal(payload, &claims) != nil || claims.Exp == 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(claims.Exp, 0), true
+}
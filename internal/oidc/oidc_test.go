@@ -0,0 +1,88 @@
+package oidc
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenDiscoversEndpointAndCaches(t *testing.T) {
+	var issued int
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"token_endpoint": srv.URL + "/token"})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		id, secret, _ := r.BasicAuth()
+		require.Equal(t, "client", id)
+		require.Equal(t, "s3cret", secret)
+		require.NoError(t, r.ParseForm())
+		require.Equal(t, "client_credentials", r.PostForm.Get("grant_type"))
+		require.Equal(t, "callbacks", r.PostForm.Get("audience"))
+		require.Equal(t, "a b", r.PostForm.Get("scope"))
+		issued++
+		json.NewEncoder(w).Encode(map[string]any{"id_token": fmt.Sprintf("id-%d", issued), "access_token": "access", "expires_in": 3600})
+	})
+
+	src, err := New(Config{Issuer: srv.URL + "/", ClientID: "client", ClientSecret: "s3cret", Audience: "callbacks", Scopes: []string{"a", "b"}}, srv.Client())
+	require.NoError(t, err)
+	now := time.Now()
+	src.now = func() time.Time { return now }
+
+	for range 2 {
+		token, err := src.Token(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, "id-1", token)
+	}
+
+	now = now.Add(59*time.Minute + time.Second)
+	token, err := src.Token(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "id-2", token)
+
+	src.Invalidate()
+	token, err = src.Token(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "id-3", token)
+}
+
+func TestTokenFallsBackToAccessTokenAndJWTExpiry(t *testing.T) {
+	exp := time.Now().Add(10 * time.Minute).Unix()
+	claims := base64.RawURLEncoding.EncodeToString(fmt.Appendf(nil, `{"exp":%d}`, exp))
+	jwt := "eyJhbGciOiJub25lIn0." + claims + ".sig"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"access_token": jwt})
+	}))
+	defer srv.Close()
+
+	src, err := New(Config{TokenURL: srv.URL, ClientID: "client"}, srv.Client())
+	require.NoError(t, err)
+	token, err := src.Token(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, jwt, token)
+	require.Equal(t, time.Unix(exp, 0).Add(-expiryBuffer), src.expires)
+}
+
+func TestTokenReportsIssuerErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"invalid_client"}`, http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	src, err := New(Config{TokenURL: srv.URL, ClientID: "client"}, srv.Client())
+	require.NoError(t, err)
+	_, err = src.Token(context.Background())
+	require.ErrorContains(t, err, "status 401")
+
+	_, err = New(Config{ClientID: "client"}, nil)
+	require.Error(t, err)
+}
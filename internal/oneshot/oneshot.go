@@ -0,0 +1,165 @@
+// Package oneshot schedules one-time EventBridge Scheduler invocations through the Scheduler
+// API (POST/DELETE /schedules/{name}), signing requests with SigV4. Each schedule fires once
+// at its time, delivering its body to the target as the event, and is deleted after it runs.
+package oneshot
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+)
+
+// namePrefix starts the name of every schedule created; the rest is a digest of the body, so
+// scheduling the same body twice creates one schedule.
+const namePrefix = "paypack-check-"
+
+// Scheduler creates schedules invoking one target.
+type Scheduler struct {
+	endpoint    string
+	region      string
+	target      string
+	role        string
+	credentials aws.CredentialsProvider
+	signer      *v4.Signer
+	httpClient  *http.Client
+}
+
+// New builds a scheduler invoking target (a Lambda function ARN) with role, which Scheduler
+// assumes and which needs lambda:InvokeFunction on target. An empty endpoint uses the
+// regional endpoint, https://scheduler.<region>.amazonaws.com.
+func New(endpoint, region, target, role string, credentials aws.CredentialsProvider, httpClient *http.Client) (*Scheduler, error) {
+	if region == "" {
+		return nil, errors.New("region is required")
+	}
+	if target == "" {
+		return nil, errors.New("target arn is required")
+	}
+	if role == "" {
+		return nil, errors.New("role arn is required")
+	}
+	if credentials == nil {
+		return nil, errors.New("credentials are required")
+	}
+	endpoint = strings.TrimRight(strings.TrimSpace(endpoint), "/")
+	if endpoint == "" {
+		endpoint = "https://scheduler." + region + ".amazonaws.com"
+	}
+	if _, err := url.Parse(endpoint); err != nil {
+		return nil, fmt.Errorf("invalid scheduler endpoint: %w", err)
+	}
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 5 * time.Second}
+	}
+	return &Scheduler{
+		endpoint:    endpoint,
+		region:      region,
+		target:      target,
+		role:        role,
+		credentials: credentials,
+		signer:      v4.NewSigner(),
+		httpClient:  httpClient,
+	}, nil
+}
+
+// Name is the name of the schedule delivering body.
+func Name(body []byte) string {
+	sum := sha256.Sum256(body)
+	return namePrefix + hex.EncodeToString(sum[:16])
+}
+
+type createScheduleInput struct {
+	ScheduleExpression         string             `json:"ScheduleExpression"`
+	ScheduleExpressionTimezone string             `json:"ScheduleExpressionTimezone"`
+	FlexibleTimeWindow         flexibleTimeWindow `json:"FlexibleTimeWindow"`
+	ActionAfterCompletion      string             `json:"ActionAfterCompletion"`
+	ClientToken                string             `json:"ClientToken"`
+	Target                     target             `json:"Target"`
+}
+
+type flexibleTimeWindow struct {
+	Mode string `json:"Mode"`
+}
+
+type target struct {
+	Arn     string `json:"Arn"`
+	RoleArn string `json:"RoleArn"`
+	Input   string `json:"Input"`
+}
+
+// SendDelayed schedules one invocation of the target with body, delay from now rounded up to
+// the second. A schedule for the same body that already exists is left as it is.
+func (s *Scheduler) SendDelayed(ctx context.Context, body []byte, delay time.Duration) error {
+	at := time.Now().Add(max(delay, time.Second)).UTC().Truncate(time.Second).Add(time.Second)
+	name := Name(body)
+	payload, err := json.Marshal(createScheduleInput{
+		ScheduleExpression:         "at(" + at.Format("2006-01-02T15:04:05") + ")",
+		ScheduleExpressionTimezone: "UTC",
+		FlexibleTimeWindow:         flexibleTimeWindow{Mode: "OFF"},
+		ActionAfterCompletion:      "DELETE",
+		ClientToken:                name,
+		Target:                     target{Arn: s.target, RoleArn: s.role, Input: string(body)},
+	})
+	if err != nil {
+		return fmt.Errorf("encode create schedule request: %w", err)
+	}
+	status, msg, err := s.do(ctx, http.MethodPost, name, payload)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK && status != http.StatusConflict {
+		return fmt.Errorf("create schedule %s: scheduler returned %d: %s", name, status, msg)
+	}
+	return nil
+}
+
+// Delete removes the schedule delivering body, if it still exists.
+func (s *Scheduler) Delete(ctx context.Context, body []byte) error {
+	name := Name(body)
+	status, msg, err := s.do(ctx, http.MethodDelete, name, nil)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK && status != http.StatusNotFound {
+		return fmt.Errorf("delete schedule %s: scheduler returned %d: %s", name, status, msg)
+	}
+	return nil
+}
+
+func (s *Scheduler) do(ctx context.Context, method, name string, payload []byte) (int, string, error) {
+	req, err := http.NewRequestWithContext(ctx, method, s.endpoint+"/schedules/"+url.PathEscape(name), bytes.NewReader(payload))
+	if err != nil {
+		return 0, "", fmt.Errorf("build schedule request: %w", err)
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	creds, err := s.credentials.Retrieve(ctx)
+	if err != nil {
+		return 0, "", fmt.Errorf("retrieve credentials: %w", err)
+	}
+	sum := sha256.Sum256(payload)
+	if err := s.signer.SignHTTP(ctx, creds, req, hex.EncodeToString(sum[:]), "scheduler", s.region, time.Now()); err != nil {
+		return 0, "", fmt.Errorf("sign schedule request: %w", err)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return 0, "", fmt.Errorf("schedule %s: %w", name, err)
+	}
+	defer resp.Body.Close()
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	return resp.StatusCode, strings.TrimSpace(string(msg)), nil
+}
@@ -0,0 +1,65 @@
+package oneshot
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendDelayedCreatesOneTimeSchedule(t *testing.T) {
+	var gotAuth, gotPath, gotMethod string
+	var got createScheduleInput
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		gotPath = r.URL.Path
+		gotMethod = r.Method
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		_, _ = w.Write([]byte(`{"ScheduleArn":"arn"}`))
+	}))
+	defer srv.Close()
+
+	scheduler, err := New(srv.URL, "eu-west-1", "arn:aws:lambda:eu-west-1:1:function:checks", "arn:aws:iam::1:role/scheduler", credentials.NewStaticCredentialsProvider("AKID", "SECRET", ""), srv.Client())
+	require.NoError(t, err)
+	body := []byte(`{"ref":"r-1"}`)
+	before := time.Now().UTC()
+	require.NoError(t, scheduler.SendDelayed(context.Background(), body, 15*time.Second))
+
+	require.Equal(t, http.MethodPost, gotMethod)
+	require.Equal(t, "/schedules/"+Name(body), gotPath)
+	require.True(t, strings.HasPrefix(gotAuth, "AWS4-HMAC-SHA256 Credential=AKID/"))
+	require.Contains(t, gotAuth, "/eu-west-1/scheduler/aws4_request")
+	require.Equal(t, "DELETE", got.ActionAfterCompletion)
+	require.Equal(t, "OFF", got.FlexibleTimeWindow.Mode)
+	require.Equal(t, `{"ref":"r-1"}`, got.Target.Input)
+	require.Equal(t, "arn:aws:iam::1:role/scheduler", got.Target.RoleArn)
+
+	at, err := time.Parse("at(2006-01-02T15:04:05)", got.ScheduleExpression)
+	require.NoError(t, err)
+	require.WithinRange(t, at, before.Add(15*time.Second), before.Add(17*time.Second))
+}
+
+func TestExistingAndMissingSchedulesAreNotErrors(t *testing.T) {
+	var status int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	scheduler, err := New(srv.URL, "eu-west-1", "fn", "role", credentials.NewStaticCredentialsProvider("AKID", "SECRET", ""), srv.Client())
+	require.NoError(t, err)
+
+	status = http.StatusConflict
+	require.NoError(t, scheduler.SendDelayed(context.Background(), []byte("x"), time.Second), "already scheduled")
+	status = http.StatusNotFound
+	require.NoError(t, scheduler.Delete(context.Background(), []byte("x")), "already deleted")
+	status = http.StatusForbidden
+	require.ErrorContains(t, scheduler.SendDelayed(context.Background(), []byte("x"), time.Second), "scheduler returned 403")
+	require.ErrorContains(t, scheduler.Delete(context.Background(), []byte("x")), "scheduler returned 403")
+}
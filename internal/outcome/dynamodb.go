@@ -0,0 +1,120 @@
+package outcome
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DynamoStore is a Store backed by a DynamoDB table with partition key "id" (S), one item per
+// idempotency key. Results are written with a conditional put, so the first invocation to
+// record a key wins and later ones read its result back from the failed condition.
+type DynamoStore struct {
+	client *dynamodb.Client
+	table  string
+}
+
+// NewDynamoStore builds a Store writing to table.
+func NewDynamoStore(client *dynamodb.Client, table string) (*DynamoStore, error) {
+	if client == nil {
+		return nil, errors.New("dynamodb client is required")
+	}
+	if table == "" {
+		return nil, errors.New("table is required")
+	}
+	return &DynamoStore{client: client, table: table}, nil
+}
+
+// Record stores result under key unless one is already recorded.
+func (d *DynamoStore) Record(ctx context.Context, key string, result json.RawMessage) (json.RawMessage, bool, error) {
+	if key == "" {
+		return nil, false, errors.New("idempotency key is required")
+	}
+	_, err := d.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(d.table),
+		Item: map[string]types.AttributeValue{
+			"id":          &types.AttributeValueMemberS{Value: key},
+			"result":      &types.AttributeValueMemberS{Value: string(result)},
+			"recorded_at": &types.AttributeValueMemberS{Value: time.Now().UTC().Format(time.RFC3339Nano)},
+		},
+		ConditionExpression:                 aws.String("attribute_not_exists(id)"),
+		ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+	})
+	if err == nil {
+		return result, true, nil
+	}
+	var failed *types.ConditionalCheckFailedException
+	if !errors.As(err, &failed) {
+		return nil, false, fmt.Errorf("record outcome: %w", err)
+	}
+
+	item := failed.Item
+	if item == nil {
+		out, err := d.client.GetItem(ctx, &dynamodb.GetItemInput{
+			TableName:      aws.String(d.table),
+			Key:            map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: key}},
+			ConsistentRead: aws.Bool(true),
+		})
+		if err != nil {
+			return nil, false, fmt.Errorf("get recorded outcome: %w", err)
+		}
+		item = out.Item
+	}
+	recorded, ok := item["result"].(*types.AttributeValueMemberS)
+	if !ok {
+		return nil, false, fmt.Errorf("recorded outcome for %s has no result", key)
+	}
+	return json.RawMessage(recorded.Value), false, nil
+}
+
+// Scan calls fn for every recorded result.
+func (d *DynamoStore) Scan(ctx context.Context, fn func(key string, result json.RawMessage) error) error {
+	paginator := dynamodb.NewScanPaginator(d.client, &dynamodb.ScanInput{TableName: aws.String(d.table)})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("scan outcomes: %w", err)
+		}
+		for _, item := range page.Items {
+			key, _ := item["id"].(*types.AttributeValueMemberS)
+			recorded, _ := item["result"].(*types.AttributeValueMemberS)
+			if key == nil || recorded == nil {
+				continue
+			}
+			if err := fn(key.Value, json.RawMessage(recorded.Value)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Rewrite replaces the result recorded under key with an update conditioned on it existing.
+func (d *DynamoStore) Rewrite(ctx context.Context, key string, result json.RawMessage) error {
+	_, err := d.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:           aws.String(d.table),
+		Key:                 map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: key}},
+		UpdateExpression:    aws.String("SET #result = :result"),
+		ConditionExpression: aws.String("attribute_exists(id)"),
+		ExpressionAttributeNames: map[string]string{
+			"#result": "result",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":result": &types.AttributeValueMemberS{Value: string(result)},
+		},
+	})
+	var missing *types.ConditionalCheckFailedException
+	if errors.As(err, &missing) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("rewrite outcome: %w", err)
+	}
+	return nil
+}
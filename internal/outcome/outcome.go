@@ -0,0 +1,89 @@
+// Package outcome records the final result of each idempotency key exactly once, so duplicate
+// invocations racing on the same payment converge on a single recorded result.
+package outcome
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+)
+
+// ErrNotFound is returned when rewriting a key with no recorded result.
+var ErrNotFound = errors.New("outcome not recorded")
+
+// Store records final results by idempotency key.
+type Store interface {
+	// Record stores result under key unless a result is already recorded there. It returns
+	// the result that holds for key: result itself and true for the first writer, the earlier
+	// result and false for everyone after. Concurrent writers cannot both win.
+	Record(ctx context.Context, key string, result json.RawMessage) (json.RawMessage, bool, error)
+}
+
+// Scanner is implemented by stores that can walk every recorded result, as erasing a
+// customer's data needs. Results are not returned in any particular order.
+type Scanner interface {
+	Scan(ctx context.Context, fn func(key string, result json.RawMessage) error) error
+}
+
+// Rewriter is implemented by stores that can replace a recorded result in place, returning
+// ErrNotFound when key has none. Record never overwrites, so this is the only way to edit one.
+type Rewriter interface {
+	Rewrite(ctx context.Context, key string, result json.RawMessage) error
+}
+
+// MemoryStore records results in memory, for tests only: the duplicate invocations it
+// settles usually run in different containers.
+type MemoryStore struct {
+	mu      sync.Mutex
+	results map[string]json.RawMessage
+}
+
+// NewMemoryStore builds an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{results: make(map[string]json.RawMessage)}
+}
+
+// Record stores result under key unless one is already recorded.
+func (m *MemoryStore) Record(ctx context.Context, key string, result json.RawMessage) (json.RawMessage, bool, error) {
+	if key == "" {
+		return nil, false, errors.New("idempotency key is required")
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if recorded, ok := m.results[key]; ok {
+		return recorded, false, nil
+	}
+	m.results[key] = append(json.RawMessage(nil), result...)
+	return result, true, nil
+}
+
+// Scan calls fn for every recorded result.
+func (m *MemoryStore) Scan(ctx context.Context, fn func(key string, result json.RawMessage) error) error {
+	m.mu.Lock()
+	recorded := make(map[string]json.RawMessage, len(m.results))
+	for key, result := range m.results {
+		recorded[key] = result
+	}
+	m.mu.Unlock()
+
+	for key, result := range recorded {
+		if err := fn(key, result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Rewrite replaces the result recorded under key.
+func (m *MemoryStore) Rewrite(ctx context.Context, key string, result json.RawMessage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.results[key]; !ok {
+		return ErrNotFound
+	}
+	m.results[key] = append(json.RawMessage(nil), result...)
+	return nil
+}
@@ -0,0 +1,57 @@
+package outcome
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStoreRecordsTheFirstResult(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		winners int
+		seen    = map[string]bool{}
+	)
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, won, err := store.Record(ctx, "order-42", json.RawMessage(`{"n":`+string(rune('0'+i))+`}`))
+			require.NoError(t, err)
+			mu.Lock()
+			defer mu.Unlock()
+			if won {
+				winners++
+			}
+			seen[string(result)] = true
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, 1, winners)
+	require.Len(t, seen, 1, "every writer sees the winner's result")
+
+	_, _, err := store.Record(ctx, "", json.RawMessage(`{}`))
+	require.Error(t, err)
+}
+
+func TestMemoryStoreRewritesRecordedResults(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	require.ErrorIs(t, store.Rewrite(ctx, "order-42", json.RawMessage(`{"n":2}`)), ErrNotFound)
+	_, _, err := store.Record(ctx, "order-42", json.RawMessage(`{"n":1}`))
+	require.NoError(t, err)
+	require.NoError(t, store.Rewrite(ctx, "order-42", json.RawMessage(`{"n":2}`)))
+
+	result, won, err := store.Record(ctx, "order-42", json.RawMessage(`{"n":3}`))
+	require.NoError(t, err)
+	require.False(t, won)
+	require.JSONEq(t, `{"n":2}`, string(result))
+}
@@ -0,0 +1,219 @@
+package receipt
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"time"
+
+	"github.com/berniyo/paypack-lambda/internal/objectstore"
+	"github.com/berniyo/paypack-lambda/internal/tax"
+)
+
+const defaultURLTTL = 24 * time.Hour
+
+// Format selects the rendered document type.
+type Format string
+
+const (
+	FormatHTML Format = "html"
+	FormatPDF  Format = "pdf"
+)
+
+// Data holds the transaction fields printed on a receipt.
+type Data struct {
+	Ref       string
+	Number    string
+	Client    string
+	Provider  string
+	Amount    float64
+	Fee       float64
+	Currency  string
+	Tax       *tax.Breakdown
+	Timestamp time.Time
+}
+
+// Receipt describes a stored receipt document.
+type Receipt struct {
+	Key         string    `json:"key"`
+	URL         string    `json:"url"`
+	ContentType string    `json:"content_type"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// Issuer renders receipts and stores them in an object store.
+type Issuer struct {
+	store  objectstore.Store
+	format Format
+	prefix string
+	ttl    time.Duration
+	tmpl   *template.Template
+	now    func() time.Time
+}
+
+// Option customizes the Issuer.
+type Option func(*Issuer)
+
+// WithFormat selects HTML (default) or PDF output.
+func WithFormat(f Format) Option {
+	return func(i *Issuer) {
+		if f == FormatHTML || f == FormatPDF {
+			i.format = f
+		}
+	}
+}
+
+// WithKeyPrefix sets the object key prefix (default "receipts/").
+func WithKeyPrefix(prefix string) Option {
+	return func(i *Issuer) {
+		i.prefix = prefix
+	}
+}
+
+// WithURLTTL adjusts how long pre-signed receipt URLs remain valid.
+func WithURLTTL(d time.Duration) Option {
+	return func(i *Issuer) {
+		if d > 0 {
+			i.ttl = d
+		}
+	}
+}
+
+// WithTemplate replaces the built-in HTML template. The template is executed with a Data value.
+func WithTemplate(t *template.Template) Option {
+	return func(i *Issuer) {
+		if t != nil {
+			i.tmpl = t
+		}
+	}
+}
+
+// NewIssuer builds an Issuer writing to store.
+func NewIssuer(store objectstore.Store, opts ...Option) (*Issuer, error) {
+	if store == nil {
+		return nil, errors.New("object store is required")
+	}
+
+	i := &Issuer{
+		store:  store,
+		format: FormatHTML,
+		prefix: "receipts/",
+		ttl:    defaultURLTTL,
+		tmpl:   defaultTemplate,
+		now:    time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(i)
+	}
+
+	return i, nil
+}
+
+// Issue renders and uploads the receipt for data, returning its pre-signed location.
+func (i *Issuer) Issue(ctx context.Context, data Data) (*Receipt, error) {
+	if data.Ref == "" {
+		return nil, errors.New("receipt ref is required")
+	}
+	if data.Timestamp.IsZero() {
+		data.Timestamp = i.now()
+	}
+
+	var (
+		body        []byte
+		contentType string
+		err         error
+	)
+	switch i.format {
+	case FormatPDF:
+		body, contentType = renderPDF(data), "application/pdf"
+	default:
+		body, err = i.renderHTML(data)
+		contentType = "text/html; charset=utf-8"
+	}
+	if err != nil {
+		return nil, fmt.Errorf("render receipt: %w", err)
+	}
+
+	key := fmt.Sprintf("%s%s.%s", i.prefix, data.Ref, i.format)
+	if err := i.store.Put(ctx, key, contentType, body); err != nil {
+		return nil, fmt.Errorf("store receipt: %w", err)
+	}
+
+	url, err := i.store.PresignGet(ctx, key, i.ttl)
+	if err != nil {
+		return nil, fmt.Errorf("presign receipt: %w", err)
+	}
+
+	return &Receipt{
+		Key:         key,
+		URL:         url,
+		ContentType: contentType,
+		ExpiresAt:   i.now().Add(i.ttl),
+	}, nil
+}
+
+// Delete removes the receipts stored for ref in either format. The store must implement
+// objectstore.Deleter.
+func (i *Issuer) Delete(ctx context.Context, ref string) error {
+	deleter, ok := i.store.(objectstore.Deleter)
+	if !ok {
+		return errors.New("receipt store does not support deleting")
+	}
+	for _, format := range []Format{FormatHTML, FormatPDF} {
+		key := fmt.Sprintf("%s%s.%s", i.prefix, ref, format)
+		if err := deleter.Delete(ctx, key); err != nil {
+			return fmt.Errorf("delete receipt: %w", err)
+		}
+	}
+	return nil
+}
+
+func (i *Issuer) renderHTML(data Data) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := i.tmpl.Execute(buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Lines returns the receipt body as label/value rows shared by both formats and custom templates.
+func (d Data) Lines() [][2]string {
+	currency := d.Currency
+	if currency == "" {
+		currency = "RWF"
+	}
+	rows := [][2]string{
+		{"Reference", d.Ref},
+		{"Date", d.Timestamp.UTC().Format(time.RFC3339)},
+		{"Amount", fmt.Sprintf("%.2f %s", d.Amount, currency)},
+		{"Fee", fmt.Sprintf("%.2f %s", d.Fee, currency)},
+	}
+	if d.Tax != nil {
+		rows = append(rows,
+			[2]string{"Net of tax", fmt.Sprintf("%.2f %s", d.Tax.Net, currency)},
+			[2]string{fmt.Sprintf("%s (%.2f%%)", d.Tax.Name, d.Tax.Rate), fmt.Sprintf("%.2f %s", d.Tax.Tax, currency)},
+		)
+	}
+	if d.Provider != "" {
+		rows = append(rows, [2]string{"Provider", d.Provider})
+	}
+	if d.Number != "" {
+		rows = append(rows, [2]string{"Number", d.Number})
+	}
+	return rows
+}
+
+var defaultTemplate = template.Must(template.New("receipt").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Receipt {{.Ref}}</title></head>
+<body>
+<h1>Payment receipt</h1>
+<table>
+{{range .Lines}}<tr><th>{{index . 0}}</th><td>{{index . 1}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
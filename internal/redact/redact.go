@@ -0,0 +1,211 @@
+package redact
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/berniyo/paypack-lambda/internal/metrics"
+)
+
+// Placeholder replaces the values of stripped keys.
+const Placeholder = "[redacted]"
+
+// msisdn matches phone-number-shaped digit runs, with or without a leading +.
+var msisdn = regexp.MustCompile(`\+?\b\d{9,15}\b`)
+
+// MaskNumber keeps the first four and last three digits of a phone number, e.g.
+// 250788123123 becomes 2507****123. Shorter values are masked entirely.
+func MaskNumber(number string) string {
+	digits := strings.TrimPrefix(number, "+")
+	if len(digits) < 8 {
+		return "****"
+	}
+	return digits[:4] + "****" + digits[len(digits)-3:]
+}
+
+// HashNumber replaces a phone number with a keyed hash of its digits, so "+250 788-123123"
+// and "250788123123" give the same identifier but the number cannot be recovered without key.
+func HashNumber(key []byte, number string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(digits(number)))
+	return hex.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// ReplaceNumber replaces the phone numbers in s that are number, however either is
+// formatted, with replacement, and reports whether any was found.
+func ReplaceNumber(s, number, replacement string) (string, bool) {
+	want := digits(number)
+	found := false
+	out := msisdn.ReplaceAllStringFunc(s, func(match string) string {
+		if want == "" || digits(match) != want {
+			return match
+		}
+		found = true
+		return replacement
+	})
+	return out, found
+}
+
+func digits(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// Redactor masks phone numbers and strips configured keys. A nil *Redactor leaves
+// everything untouched.
+type Redactor struct {
+	keys    map[string]bool
+	keyExpr *regexp.Regexp
+	number  func(string) string
+}
+
+// New builds a Redactor that, besides masking phone numbers, strips the values of the
+// given metadata keys (matched case-insensitively) wherever they appear.
+func New(keys ...string) *Redactor {
+	r := &Redactor{keys: make(map[string]bool), number: MaskNumber}
+	var alts []string
+	for _, k := range keys {
+		k = strings.ToLower(strings.TrimSpace(k))
+		if k == "" || r.keys[k] {
+			continue
+		}
+		r.keys[k] = true
+		alts = append(alts, regexp.QuoteMeta(k))
+	}
+	if len(alts) > 0 {
+		r.keyExpr = regexp.MustCompile(`(?i)("(?:` + strings.Join(alts, "|") + `)"\s*:\s*)("(?:[^"\\]|\\.)*"|[^,}\]\s]+)`)
+	}
+	return r
+}
+
+// Hashing is like New but replaces phone numbers with HashNumber under key instead of
+// masking them, so receivers can still tell customers apart.
+func Hashing(key []byte, keys ...string) *Redactor {
+	r := New(keys...)
+	r.number = func(number string) string { return HashNumber(key, number) }
+	return r
+}
+
+// Text masks phone numbers in free text and blanks configured keys in any JSON it embeds.
+func (r *Redactor) Text(s string) string {
+	if r == nil {
+		return s
+	}
+	if r.keyExpr != nil {
+		s = r.keyExpr.ReplaceAllString(s, `${1}"`+Placeholder+`"`)
+	}
+	return msisdn.ReplaceAllStringFunc(s, r.number)
+}
+
+// JSON returns a redacted copy of a JSON document: configured keys are removed and string
+// values are masked. Documents that fail to parse fall back to Text.
+func (r *Redactor) JSON(data []byte) []byte {
+	if r == nil || len(data) == 0 {
+		return data
+	}
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return []byte(r.Text(string(data)))
+	}
+	out, err := json.Marshal(r.value(v))
+	if err != nil {
+		return []byte(r.Text(string(data)))
+	}
+	return out
+}
+
+func (r *Redactor) value(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, inner := range v {
+			if r.keys[strings.ToLower(k)] {
+				delete(v, k)
+				continue
+			}
+			v[k] = r.value(inner)
+		}
+		return v
+	case []any:
+		for i, inner := range v {
+			v[i] = r.value(inner)
+		}
+		return v
+	case string:
+		return msisdn.ReplaceAllStringFunc(v, r.number)
+	default:
+		return v
+	}
+}
+
+// Writer wraps w so every write is passed through Text. Each log.Logger call is one write,
+// so log lines are redacted whole.
+func (r *Redactor) Writer(w io.Writer) io.Writer {
+	if r == nil {
+		return w
+	}
+	return writer{r: r, w: w}
+}
+
+type writer struct {
+	r *Redactor
+	w io.Writer
+}
+
+func (w writer) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(w.w, w.r.Text(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Logger returns a copy of l whose output is redacted.
+func (r *Redactor) Logger(l *log.Logger) *log.Logger {
+	if r == nil {
+		return l
+	}
+	return log.New(r.Writer(l.Writer()), l.Prefix(), l.Flags())
+}
+
+// Metrics wraps m so tag values are masked before they reach the backend.
+func (r *Redactor) Metrics(m metrics.Metrics) metrics.Metrics {
+	if r == nil {
+		return m
+	}
+	return redactedMetrics{r: r, m: m}
+}
+
+type redactedMetrics struct {
+	r *Redactor
+	m metrics.Metrics
+}
+
+func (rm redactedMetrics) Count(name string, delta float64, tags ...metrics.Tag) {
+	rm.m.Count(name, delta, rm.tags(tags)...)
+}
+
+func (rm redactedMetrics) Observe(name string, value float64, tags ...metrics.Tag) {
+	rm.m.Observe(name, value, rm.tags(tags)...)
+}
+
+func (rm redactedMetrics) tags(tags []metrics.Tag) []metrics.Tag {
+	out := make([]metrics.Tag, len(tags))
+	for i, t := range tags {
+		value := t.Value
+		if rm.r.keys[strings.ToLower(t.Key)] {
+			value = Placeholder
+		}
+		out[i] = metrics.T(t.Key, msisdn.ReplaceAllStringFunc(value, rm.r.number))
+	}
+	return out
+}
@@ -0,0 +1,69 @@
+package redact
+
+import (
+	"bytes"
+	"log"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/berniyo/paypack-lambda/internal/metrics"
+)
+
+func TestMaskNumber(t *testing.T) {
+	require.Equal(t, "2507****123", MaskNumber("250788123123"))
+	require.Equal(t, "0788****456", MaskNumber("0788123456"))
+	require.Equal(t, "****", MaskNumber("1234"))
+}
+
+func TestTextMasksNumbersAndKeys(t *testing.T) {
+	r := New("national_id", "Email")
+	got := r.Text(`initiating cashin for number=250788123123 body={"number":"+250788123123","email":"a@b.rw","national_id": 1199,"amount":5000}`)
+	require.Equal(t, `initiating cashin for number=2507****123 body={"number":"2507****123","email":"[redacted]","national_id": "[redacted]","amount":5000}`, got)
+}
+
+func TestJSONStripsKeys(t *testing.T) {
+	r := New("national_id")
+	out := r.JSON([]byte(`{"request":{"number":"250788123123","metadata":{"national_id":"1199","plan":"gold"}}}`))
+	require.JSONEq(t, `{"request":{"number":"2507****123","metadata":{"plan":"gold"}}}`, string(out))
+
+	var nilRedactor *Redactor
+	require.Equal(t, "250788123123", nilRedactor.Text("250788123123"))
+}
+
+func TestLoggerAndMetrics(t *testing.T) {
+	var buf bytes.Buffer
+	r := New()
+	r.Logger(log.New(&buf, "", 0)).Printf("number=%s", "250788123123")
+	require.Equal(t, "number=2507****123\n", buf.String())
+
+	var got []metrics.Tag
+	m := r.Metrics(tagRecorder(func(tags []metrics.Tag) { got = tags }))
+	m.Count("x", 1, metrics.T("client", "250788123123"))
+	require.Equal(t, "2507****123", got[0].Value)
+}
+
+type tagRecorder func([]metrics.Tag)
+
+func (f tagRecorder) Count(_ string, _ float64, tags ...metrics.Tag)   { f(tags) }
+func (f tagRecorder) Observe(_ string, _ float64, tags ...metrics.Tag) { f(tags) }
+
+func TestHashingReplacesNumbersWithStableIDs(t *testing.T) {
+	key := []byte("secret")
+	id := HashNumber(key, "250788123123")
+	require.Len(t, id, 32)
+	require.Equal(t, id, HashNumber(key, "+250 788-123123"))
+	require.NotEqual(t, id, HashNumber([]byte("other"), "250788123123"))
+
+	out := Hashing(key).JSON([]byte(`{"request":{"number":"+250788123123"},"transaction":{"client":"250788123123"}}`))
+	require.JSONEq(t, `{"request":{"number":"`+id+`"},"transaction":{"client":"`+id+`"}}`, string(out))
+}
+
+func TestReplaceNumberMatchesOnlyThatNumber(t *testing.T) {
+	out, found := ReplaceNumber("cashin from +250788123123, refund to 250788000001", "250 788 123123", "tok")
+	require.True(t, found)
+	require.Equal(t, "cashin from tok, refund to 250788000001", out)
+
+	_, found = ReplaceNumber("cashin from 250788000001", "250788123123", "tok")
+	require.False(t, found)
+}
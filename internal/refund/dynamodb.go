@@ -0,0 +1,131 @@
+package refund
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/berniyo/paypack-lambda/internal/money"
+)
+
+// DynamoStore is a Store backed by a DynamoDB table with partition key "ref" (S), one item per
+// original transaction. Reservations are a single conditional ADD, so two refunds racing for
+// the same remainder cannot both succeed.
+type DynamoStore struct {
+	client *dynamodb.Client
+	table  string
+}
+
+// NewDynamoStore builds a Store writing to table.
+func NewDynamoStore(client *dynamodb.Client, table string) (*DynamoStore, error) {
+	if client == nil {
+		return nil, errors.New("dynamodb client is required")
+	}
+	if table == "" {
+		return nil, errors.New("table is required")
+	}
+	return &DynamoStore{client: client, table: table}, nil
+}
+
+// Reserve adds amount to the refunded total unless it would exceed charged.
+func (d *DynamoStore) Reserve(ctx context.Context, originalRef, currency string, charged, amount float64) (Balance, error) {
+	if originalRef == "" {
+		return Balance{}, errors.New("original ref is required")
+	}
+	if money.Round(amount, currency) > money.Round(charged, currency) {
+		b, err := d.Get(ctx, originalRef)
+		if err != nil {
+			return Balance{}, err
+		}
+		return b, ErrExceedsCharge
+	}
+	// Condition expressions cannot add, so compare the stored total with charged - amount.
+	out, err := d.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:           aws.String(d.table),
+		Key:                 refKey(originalRef),
+		UpdateExpression:    aws.String("ADD refunded :amount SET charged = :charged, currency = :currency, updated_at = :now"),
+		ConditionExpression: aws.String("attribute_not_exists(refunded) OR refunded <= :ceiling"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":amount":   &types.AttributeValueMemberN{Value: money.Format(amount, currency)},
+			":charged":  &types.AttributeValueMemberN{Value: money.Format(charged, currency)},
+			":ceiling":  &types.AttributeValueMemberN{Value: money.Format(charged-amount, currency)},
+			":currency": &types.AttributeValueMemberS{Value: currency},
+			":now":      &types.AttributeValueMemberS{Value: time.Now().UTC().Format(time.RFC3339Nano)},
+		},
+		ReturnValues: types.ReturnValueAllNew,
+	})
+	if err != nil {
+		var failed *types.ConditionalCheckFailedException
+		if errors.As(err, &failed) {
+			b, getErr := d.Get(ctx, originalRef)
+			if getErr != nil {
+				return Balance{}, getErr
+			}
+			return b, ErrExceedsCharge
+		}
+		return Balance{}, fmt.Errorf("reserve refund: %w", err)
+	}
+	return decodeBalance(originalRef, out.Attributes)
+}
+
+// Release subtracts amount from the refunded total.
+func (d *DynamoStore) Release(ctx context.Context, originalRef, currency string, amount float64) error {
+	_, err := d.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:           aws.String(d.table),
+		Key:                 refKey(originalRef),
+		UpdateExpression:    aws.String("ADD refunded :amount SET updated_at = :now"),
+		ConditionExpression: aws.String("attribute_exists(refunded)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":amount": &types.AttributeValueMemberN{Value: money.Format(-amount, currency)},
+			":now":    &types.AttributeValueMemberS{Value: time.Now().UTC().Format(time.RFC3339Nano)},
+		},
+	})
+	var failed *types.ConditionalCheckFailedException
+	if err != nil && !errors.As(err, &failed) {
+		return fmt.Errorf("release refund: %w", err)
+	}
+	return nil
+}
+
+// Get returns the balance of originalRef.
+func (d *DynamoStore) Get(ctx context.Context, originalRef string) (Balance, error) {
+	out, err := d.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(d.table),
+		Key:            refKey(originalRef),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return Balance{}, fmt.Errorf("get refund balance: %w", err)
+	}
+	return decodeBalance(originalRef, out.Item)
+}
+
+func decodeBalance(originalRef string, item map[string]types.AttributeValue) (Balance, error) {
+	b := Balance{OriginalRef: originalRef}
+	if currency, ok := item["currency"].(*types.AttributeValueMemberS); ok {
+		b.Currency = currency.Value
+	}
+	for name, dst := range map[string]*float64{"charged": &b.Charged, "refunded": &b.Refunded} {
+		if n, ok := item[name].(*types.AttributeValueMemberN); ok {
+			v, err := strconv.ParseFloat(n.Value, 64)
+			if err != nil {
+				return Balance{}, fmt.Errorf("decode refund %s: %w", name, err)
+			}
+			*dst = money.Round(v, b.Currency)
+		}
+	}
+	if updated, ok := item["updated_at"].(*types.AttributeValueMemberS); ok {
+		b.UpdatedAt, _ = time.Parse(time.RFC3339Nano, updated.Value)
+	}
+	return b, nil
+}
+
+func refKey(ref string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{"ref": &types.AttributeValueMemberS{Value: ref}}
+}
@@ -0,0 +1,99 @@
+// Package refund tracks how much of each charge has been refunded, so partial refunds of the
+// same cash-in can never add up to more than was paid.
+package refund
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/berniyo/paypack-lambda/internal/money"
+)
+
+// ErrExceedsCharge marks a Reserve that would refund more than the charge has left.
+var ErrExceedsCharge = errors.New("refund exceeds the refundable amount")
+
+// Balance is the refund position of one original transaction.
+type Balance struct {
+	OriginalRef string    `json:"original_ref"`
+	Currency    string    `json:"currency,omitempty"`
+	Charged     float64   `json:"charged"`
+	Refunded    float64   `json:"refunded"`
+	UpdatedAt   time.Time `json:"updated_at,omitzero"`
+}
+
+// Remaining is what can still be refunded.
+func (b Balance) Remaining() float64 {
+	return max(money.Round(b.Charged-b.Refunded, b.Currency), 0)
+}
+
+// Store keeps cumulative refunded amounts per original transaction.
+type Store interface {
+	// Reserve adds amount to the refunded total of originalRef, whose charge was charged in
+	// currency, unless that would exceed the charge; then it returns ErrExceedsCharge and the
+	// current balance, unchanged. Concurrent reservations cannot both pass.
+	Reserve(ctx context.Context, originalRef, currency string, charged, amount float64) (Balance, error)
+	// Release gives back a reservation, in currency, whose cash-out failed.
+	Release(ctx context.Context, originalRef, currency string, amount float64) error
+	// Get returns the balance of originalRef; an unrefunded charge has nothing refunded.
+	Get(ctx context.Context, originalRef string) (Balance, error)
+}
+
+// MemoryStore tracks refunded amounts in memory, for tests only: concurrent containers would
+// not share the balances, so each could refund the same charge in full.
+type MemoryStore struct {
+	mu       sync.Mutex
+	balances map[string]Balance
+}
+
+// NewMemoryStore builds an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{balances: make(map[string]Balance)}
+}
+
+// Reserve adds amount to the refunded total unless it would exceed charged.
+func (m *MemoryStore) Reserve(ctx context.Context, originalRef, currency string, charged, amount float64) (Balance, error) {
+	if originalRef == "" {
+		return Balance{}, errors.New("original ref is required")
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	b := m.balances[originalRef]
+	b.OriginalRef = originalRef
+	b.Currency = currency
+	b.Charged = charged
+	if money.Round(b.Refunded+amount, currency) > money.Round(charged, currency) {
+		return b, ErrExceedsCharge
+	}
+	b.Refunded = money.Round(b.Refunded+amount, currency)
+	b.UpdatedAt = time.Now()
+	m.balances[originalRef] = b
+	return b, nil
+}
+
+// Release subtracts amount from the refunded total.
+func (m *MemoryStore) Release(ctx context.Context, originalRef, currency string, amount float64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	b, ok := m.balances[originalRef]
+	if !ok {
+		return nil
+	}
+	b.Refunded = max(money.Round(b.Refunded-amount, currency), 0)
+	b.UpdatedAt = time.Now()
+	m.balances[originalRef] = b
+	return nil
+}
+
+// Get returns the balance of originalRef.
+func (m *MemoryStore) Get(ctx context.Context, originalRef string) (Balance, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	b := m.balances[originalRef]
+	b.OriginalRef = originalRef
+	return b, nil
+}
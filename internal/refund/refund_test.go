@@ -0,0 +1,30 @@
+package refund
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStoreNeverRefundsMoreThanCharged(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	b, err := store.Reserve(ctx, "abc", "USD", 1000, 600.10)
+	require.NoError(t, err)
+	require.InDelta(t, 399.90, b.Remaining(), 0.001)
+
+	b, err = store.Reserve(ctx, "abc", "USD", 1000, 400)
+	require.ErrorIs(t, err, ErrExceedsCharge)
+	require.InDelta(t, 600.10, b.Refunded, 0.001)
+
+	b, err = store.Reserve(ctx, "abc", "USD", 1000, 399.90)
+	require.NoError(t, err)
+	require.Zero(t, b.Remaining())
+
+	require.NoError(t, store.Release(ctx, "abc", "USD", 399.90))
+	b, err = store.Get(ctx, "abc")
+	require.NoError(t, err)
+	require.InDelta(t, 600.10, b.Refunded, 0.001)
+}
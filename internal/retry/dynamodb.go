@@ -0,0 +1,219 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DynamoStore is a Store backed by a DynamoDB table with partition key "id" (S). Due entries
+// are found with a filtered scan on "next_attempt_unix", which is adequate for a queue that
+// only ever holds the currently failing payments.
+type DynamoStore struct {
+	client *dynamodb.Client
+	table  string
+}
+
+type dynamoEntry struct {
+	ID              string    `dynamodbav:"id"`
+	Event           string    `dynamodbav:"event"`
+	Attempts        int       `dynamodbav:"attempts"`
+	MaxAttempts     int       `dynamodbav:"max_attempts"`
+	NextAttemptUnix int64     `dynamodbav:"next_attempt_unix"`
+	LastRef         string    `dynamodbav:"last_ref,omitempty"`
+	LastReason      string    `dynamodbav:"last_reason,omitempty"`
+	CreatedAt       time.Time `dynamodbav:"created_at"`
+	UpdatedAt       time.Time `dynamodbav:"updated_at"`
+}
+
+// NewDynamoStore builds a Store writing to table.
+func NewDynamoStore(client *dynamodb.Client, table string) (*DynamoStore, error) {
+	if client == nil {
+		return nil, errors.New("dynamodb client is required")
+	}
+	if table == "" {
+		return nil, errors.New("table is required")
+	}
+	return &DynamoStore{client: client, table: table}, nil
+}
+
+// Put stores entry, replacing any entry with the same ID.
+func (d *DynamoStore) Put(ctx context.Context, entry Entry) error {
+	item, err := attributevalue.MarshalMap(dynamoEntry{
+		ID:              entry.ID,
+		Event:           string(entry.Event),
+		Attempts:        entry.Attempts,
+		MaxAttempts:     entry.MaxAttempts,
+		NextAttemptUnix: entry.NextAttemptAt.Unix(),
+		LastRef:         entry.LastRef,
+		LastReason:      entry.LastReason,
+		CreatedAt:       entry.CreatedAt,
+		UpdatedAt:       entry.UpdatedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("encode retry entry: %w", err)
+	}
+	if _, err := d.client.PutItem(ctx, &dynamodb.PutItemInput{TableName: aws.String(d.table), Item: item}); err != nil {
+		return fmt.Errorf("put retry entry: %w", err)
+	}
+	return nil
+}
+
+// Get returns the entry or ErrNotFound.
+func (d *DynamoStore) Get(ctx context.Context, id string) (*Entry, error) {
+	out, err := d.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(d.table),
+		Key:            map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: id}},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get retry entry: %w", err)
+	}
+	if out.Item == nil {
+		return nil, ErrNotFound
+	}
+	entry, err := decodeEntry(out.Item)
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// Delete removes the entry.
+func (d *DynamoStore) Delete(ctx context.Context, id string) error {
+	_, err := d.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(d.table),
+		Key:       map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: id}},
+	})
+	if err != nil {
+		return fmt.Errorf("delete retry entry: %w", err)
+	}
+	return nil
+}
+
+// Take removes the entry with a delete conditioned on it existing and returns what was
+// deleted, or ErrNotFound when another caller deleted it first.
+func (d *DynamoStore) Take(ctx context.Context, id string) (*Entry, error) {
+	out, err := d.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName:           aws.String(d.table),
+		Key:                 map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: id}},
+		ConditionExpression: aws.String("attribute_exists(id)"),
+		ReturnValues:        types.ReturnValueAllOld,
+	})
+	var failed *types.ConditionalCheckFailedException
+	if errors.As(err, &failed) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("take retry entry: %w", err)
+	}
+	if len(out.Attributes) == 0 {
+		return nil, ErrNotFound
+	}
+	entry, err := decodeEntry(out.Attributes)
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// Claim moves next_attempt_unix to until with an update conditioned on it still holding the
+// value entry was read with.
+func (d *DynamoStore) Claim(ctx context.Context, entry Entry, until time.Time) error {
+	_, err := d.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:           aws.String(d.table),
+		Key:                 map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: entry.ID}},
+		UpdateExpression:    aws.String("SET next_attempt_unix = :until"),
+		ConditionExpression: aws.String("next_attempt_unix = :seen"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":until": &types.AttributeValueMemberN{Value: strconv.FormatInt(until.Unix(), 10)},
+			":seen":  &types.AttributeValueMemberN{Value: strconv.FormatInt(entry.NextAttemptAt.Unix(), 10)},
+		},
+	})
+	var failed *types.ConditionalCheckFailedException
+	if errors.As(err, &failed) {
+		return ErrClaimed
+	}
+	if err != nil {
+		return fmt.Errorf("claim retry entry: %w", err)
+	}
+	return nil
+}
+
+// Due returns entries ready for another attempt.
+func (d *DynamoStore) Due(ctx context.Context, now time.Time, limit int) ([]Entry, error) {
+	var due []Entry
+	paginator := dynamodb.NewScanPaginator(d.client, &dynamodb.ScanInput{
+		TableName:        aws.String(d.table),
+		FilterExpression: aws.String("next_attempt_unix <= :now"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":now": &types.AttributeValueMemberN{Value: strconv.FormatInt(now.Unix(), 10)},
+		},
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("scan retry entries: %w", err)
+		}
+		for _, item := range page.Items {
+			entry, err := decodeEntry(item)
+			if err != nil {
+				return nil, err
+			}
+			due = append(due, entry)
+		}
+	}
+
+	sort.Slice(due, func(i, j int) bool { return due[i].NextAttemptAt.Before(due[j].NextAttemptAt) })
+	if limit > 0 && len(due) > limit {
+		due = due[:limit]
+	}
+	return due, nil
+}
+
+// Scan calls fn for every entry.
+func (d *DynamoStore) Scan(ctx context.Context, fn func(Entry) error) error {
+	paginator := dynamodb.NewScanPaginator(d.client, &dynamodb.ScanInput{TableName: aws.String(d.table)})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("scan retry entries: %w", err)
+		}
+		for _, item := range page.Items {
+			entry, err := decodeEntry(item)
+			if err != nil {
+				return err
+			}
+			if err := fn(entry); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func decodeEntry(item map[string]types.AttributeValue) (Entry, error) {
+	var rec dynamoEntry
+	if err := attributevalue.UnmarshalMap(item, &rec); err != nil {
+		return Entry{}, fmt.Errorf("decode retry entry: %w", err)
+	}
+	return Entry{
+		ID:            rec.ID,
+		Event:         []byte(rec.Event),
+		Attempts:      rec.Attempts,
+		MaxAttempts:   rec.MaxAttempts,
+		NextAttemptAt: time.Unix(rec.NextAttemptUnix, 0),
+		LastRef:       rec.LastRef,
+		LastReason:    rec.LastReason,
+		CreatedAt:     rec.CreatedAt,
+		UpdatedAt:     rec.UpdatedAt,
+	}, nil
+}
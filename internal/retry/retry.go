@@ -0,0 +1,160 @@
+package retry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrNotFound marks a lookup for an entry that does not exist.
+	ErrNotFound = errors.New("retry entry not found")
+	// ErrClaimed marks a claim on an entry another run claimed or changed first.
+	ErrClaimed = errors.New("retry entry already claimed")
+)
+
+// Entry is a failed payment instruction waiting to be re-attempted.
+type Entry struct {
+	// ID is the reference of the first failed attempt; it stays stable across retries.
+	ID            string          `json:"id"`
+	Event         json.RawMessage `json:"event"`
+	Attempts      int             `json:"attempts"`
+	MaxAttempts   int             `json:"max_attempts"`
+	NextAttemptAt time.Time       `json:"next_attempt_at"`
+	LastRef       string          `json:"last_ref,omitempty"`
+	LastReason    string          `json:"last_reason,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+}
+
+// Store persists retry entries.
+type Store interface {
+	Put(ctx context.Context, entry Entry) error
+	Get(ctx context.Context, id string) (*Entry, error)
+	Delete(ctx context.Context, id string) error
+	// Take removes the entry and returns it, or ErrNotFound when it is already gone, so only
+	// one of several overlapping runs gets it.
+	Take(ctx context.Context, id string) (*Entry, error)
+	// Claim moves entry's next attempt to until, provided it is still the one entry was read
+	// with, and returns ErrClaimed otherwise. Runs claim a due entry before attempting it.
+	Claim(ctx context.Context, entry Entry, until time.Time) error
+	// Due returns up to limit entries whose next attempt is at or before now, soonest first.
+	Due(ctx context.Context, now time.Time, limit int) ([]Entry, error)
+}
+
+// Scanner is implemented by stores that can walk every entry, due or not, as erasing a
+// customer's data needs. Entries are not returned in any particular order.
+type Scanner interface {
+	Scan(ctx context.Context, fn func(Entry) error) error
+}
+
+// MemoryStore keeps retry entries in memory, for tests. Entries have to outlive the
+// invocation that scheduled them, which takes the DynamoDB store.
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]Entry
+}
+
+// NewMemoryStore builds an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]Entry)}
+}
+
+// Put stores entry, replacing any entry with the same ID.
+func (m *MemoryStore) Put(ctx context.Context, entry Entry) error {
+	if entry.ID == "" {
+		return errors.New("retry entry id is required")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.entries[entry.ID] = entry
+	return nil
+}
+
+// Get returns the entry or ErrNotFound.
+func (m *MemoryStore) Get(ctx context.Context, id string) (*Entry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.entries[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &entry, nil
+}
+
+// Delete removes the entry. Deleting a missing entry is not an error.
+func (m *MemoryStore) Delete(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.entries, id)
+	return nil
+}
+
+// Take removes and returns the entry, or ErrNotFound.
+func (m *MemoryStore) Take(ctx context.Context, id string) (*Entry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.entries[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	delete(m.entries, id)
+	return &entry, nil
+}
+
+// Claim moves entry's next attempt to until unless it changed since entry was read.
+func (m *MemoryStore) Claim(ctx context.Context, entry Entry, until time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.entries[entry.ID]
+	if !ok || stored.NextAttemptAt.Unix() != entry.NextAttemptAt.Unix() {
+		return ErrClaimed
+	}
+	stored.NextAttemptAt = until
+	m.entries[entry.ID] = stored
+	return nil
+}
+
+// Due returns entries ready for another attempt.
+func (m *MemoryStore) Due(ctx context.Context, now time.Time, limit int) ([]Entry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var due []Entry
+	for _, e := range m.entries {
+		if !e.NextAttemptAt.After(now) {
+			due = append(due, e)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].NextAttemptAt.Before(due[j].NextAttemptAt) })
+	if limit > 0 && len(due) > limit {
+		due = due[:limit]
+	}
+	return due, nil
+}
+
+// Scan calls fn for every entry.
+func (m *MemoryStore) Scan(ctx context.Context, fn func(Entry) error) error {
+	m.mu.Lock()
+	entries := make([]Entry, 0, len(m.entries))
+	for _, e := range m.entries {
+		entries = append(entries, e)
+	}
+	m.mu.Unlock()
+
+	for _, e := range entries {
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
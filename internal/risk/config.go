@@ -0,0 +1,84 @@
+package risk
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Config is the JSON rule configuration, e.g.
+//
+//	{"rules": [
+//	  {"type": "max_amount", "action": "hold", "max": 500000},
+//	  {"type": "velocity", "action": "reject", "window": "10m", "max_count": 3},
+//	  {"type": "amount_anomaly", "action": "flag", "window": "720h", "multiple": 5, "min_history": 3},
+//	  {"type": "client_mismatch", "action": "flag", "window": "720h"}
+//	]}
+type Config struct {
+	Rules []RuleConfig `json:"rules"`
+}
+
+// RuleConfig configures one rule. Fields irrelevant to the rule type are ignored.
+type RuleConfig struct {
+	Type       string   `json:"type"`
+	Action     Decision `json:"action"`
+	Max        float64  `json:"max,omitempty"`
+	Multiple   float64  `json:"multiple,omitempty"`
+	MinHistory int      `json:"min_history,omitempty"`
+	MaxCount   int      `json:"max_count,omitempty"`
+	Window     string   `json:"window,omitempty"`
+}
+
+// ParseConfig decodes a JSON rule configuration.
+func ParseConfig(data []byte) (Config, error) {
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return Config{}, fmt.Errorf("decode risk config: %w", err)
+	}
+	return cfg, nil
+}
+
+// Build constructs the configured rules.
+func (c Config) Build() ([]Rule, error) {
+	rules := make([]Rule, 0, len(c.Rules))
+	for i, rc := range c.Rules {
+		var window time.Duration
+		if rc.Window != "" {
+			d, err := time.ParseDuration(rc.Window)
+			if err != nil {
+				return nil, fmt.Errorf("rule %d (%s): invalid window: %w", i, rc.Type, err)
+			}
+			window = d
+		}
+		if rc.Action == Allow {
+			return nil, fmt.Errorf("rule %d (%s): action is required", i, rc.Type)
+		}
+
+		switch rc.Type {
+		case "max_amount":
+			rules = append(rules, MaxAmountRule{Max: rc.Max, Action: rc.Action})
+		case "amount_anomaly":
+			rules = append(rules, AmountAnomalyRule{Multiple: rc.Multiple, MinHistory: rc.MinHistory, Window: window, Action: rc.Action})
+		case "velocity":
+			rules = append(rules, VelocityRule{MaxCount: rc.MaxCount, Window: window, Action: rc.Action})
+		case "client_mismatch":
+			rules = append(rules, ClientMismatchRule{Window: window, Action: rc.Action})
+		default:
+			return nil, fmt.Errorf("rule %d: unknown type %q", i, rc.Type)
+		}
+	}
+	return rules, nil
+}
+
+// NewEngineFromJSON builds an Engine from a JSON configuration.
+func NewEngineFromJSON(data []byte, history History) (*Engine, error) {
+	cfg, err := ParseConfig(data)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := cfg.Build()
+	if err != nil {
+		return nil, err
+	}
+	return NewEngine(history, rules...), nil
+}
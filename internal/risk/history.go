@@ -0,0 +1,54 @@
+package risk
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// History stores recent payment attempts per number.
+type History interface {
+	Record(ctx context.Context, in Input) error
+	// Recent returns attempts for number at or after since, oldest first.
+	Recent(ctx context.Context, number string, since time.Time) ([]Input, error)
+}
+
+// MemoryHistory keeps attempts in-process for a fixed retention window.
+type MemoryHistory struct {
+	mu        sync.Mutex
+	retention time.Duration
+	byNumber  map[string][]Input
+}
+
+// NewMemoryHistory builds a MemoryHistory that forgets attempts older than retention.
+func NewMemoryHistory(retention time.Duration) *MemoryHistory {
+	return &MemoryHistory{retention: retention, byNumber: make(map[string][]Input)}
+}
+
+// Record appends in and prunes entries outside the retention window.
+func (m *MemoryHistory) Record(ctx context.Context, in Input) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entries := append(m.byNumber[in.Number], in)
+	cutoff := in.At.Add(-m.retention)
+	for len(entries) > 0 && entries[0].At.Before(cutoff) {
+		entries = entries[1:]
+	}
+	m.byNumber[in.Number] = entries
+	return nil
+}
+
+// Recent returns attempts for number at or after since.
+func (m *MemoryHistory) Recent(ctx context.Context, number string, since time.Time) ([]Input, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var out []Input
+	for _, in := range m.byNumber[number] {
+		if !in.At.Before(since) {
+			out = append(out, in)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,131 @@
+package risk
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Decision is the action a rule recommends. Higher values are more severe.
+type Decision int
+
+const (
+	Allow Decision = iota
+	Flag
+	Hold
+	Reject
+)
+
+var decisionNames = map[Decision]string{
+	Allow:  "allow",
+	Flag:   "flag",
+	Hold:   "hold",
+	Reject: "reject",
+}
+
+// String returns the lowercase decision name.
+func (d Decision) String() string {
+	if name, ok := decisionNames[d]; ok {
+		return name
+	}
+	return fmt.Sprintf("decision(%d)", int(d))
+}
+
+// MarshalText encodes the decision by name.
+func (d Decision) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText parses a decision name.
+func (d *Decision) UnmarshalText(text []byte) error {
+	name := strings.ToLower(strings.TrimSpace(string(text)))
+	for dec, n := range decisionNames {
+		if n == name {
+			*d = dec
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown risk decision %q", name)
+}
+
+// Input is the subset of a payment instruction the rules inspect.
+type Input struct {
+	Number string    `json:"number"`
+	Client string    `json:"client,omitempty"`
+	Amount float64   `json:"amount"`
+	At     time.Time `json:"at"`
+}
+
+// Result is a single rule's verdict.
+type Result struct {
+	Rule     string   `json:"rule"`
+	Decision Decision `json:"decision"`
+	Reason   string   `json:"reason"`
+}
+
+// Assessment aggregates every rule that fired; Decision is the most severe among them.
+type Assessment struct {
+	Decision Decision `json:"decision"`
+	Results  []Result `json:"results,omitempty"`
+}
+
+// Reasons joins the reasons of every fired rule.
+func (a Assessment) Reasons() string {
+	reasons := make([]string, 0, len(a.Results))
+	for _, r := range a.Results {
+		reasons = append(reasons, r.Rule+": "+r.Reason)
+	}
+	return strings.Join(reasons, "; ")
+}
+
+// Rule evaluates one risk signal. Rules return a nil Result when they do not fire.
+type Rule interface {
+	Name() string
+	Evaluate(ctx context.Context, in Input, history History) (*Result, error)
+}
+
+// Engine runs a set of rules and records allowed attempts in the history.
+type Engine struct {
+	rules   []Rule
+	history History
+}
+
+// NewEngine builds an Engine. A nil history defaults to an in-memory one retained for 24 hours.
+func NewEngine(history History, rules ...Rule) *Engine {
+	if history == nil {
+		history = NewMemoryHistory(24 * time.Hour)
+	}
+	return &Engine{rules: rules, history: history}
+}
+
+// Assess evaluates every rule against in. Attempts that are allowed or only flagged are
+// recorded so velocity and pairing rules see them on the next assessment.
+func (e *Engine) Assess(ctx context.Context, in Input) (Assessment, error) {
+	if in.At.IsZero() {
+		in.At = time.Now()
+	}
+
+	var out Assessment
+	for _, rule := range e.rules {
+		res, err := rule.Evaluate(ctx, in, e.history)
+		if err != nil {
+			return Assessment{}, fmt.Errorf("risk rule %s: %w", rule.Name(), err)
+		}
+		if res == nil || res.Decision == Allow {
+			continue
+		}
+		res.Rule = rule.Name()
+		out.Results = append(out.Results, *res)
+		if res.Decision > out.Decision {
+			out.Decision = res.Decision
+		}
+	}
+
+	if out.Decision <= Flag {
+		if err := e.history.Record(ctx, in); err != nil {
+			return Assessment{}, fmt.Errorf("record risk history: %w", err)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,64 @@
+package risk
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testConfig = `{"rules": [
+	{"type": "max_amount", "action": "hold", "max": 500000},
+	{"type": "velocity", "action": "reject", "window": "10m", "max_count": 2},
+	{"type": "client_mismatch", "action": "flag", "window": "24h"}
+]}`
+
+func TestEngineFromJSON(t *testing.T) {
+	engine, err := NewEngineFromJSON([]byte(testConfig), nil)
+	require.NoError(t, err)
+	ctx := context.Background()
+	now := time.Now()
+
+	a, err := engine.Assess(ctx, Input{Number: "2507", Client: "app-a", Amount: 1000, At: now})
+	require.NoError(t, err)
+	require.Equal(t, Allow, a.Decision)
+
+	a, err = engine.Assess(ctx, Input{Number: "2507", Client: "app-b", Amount: 1000, At: now.Add(time.Minute)})
+	require.NoError(t, err)
+	require.Equal(t, Flag, a.Decision)
+	require.Equal(t, "client_mismatch", a.Results[0].Rule)
+
+	a, err = engine.Assess(ctx, Input{Number: "2507", Client: "app-a", Amount: 1000, At: now.Add(2 * time.Minute)})
+	require.NoError(t, err)
+	require.Equal(t, Reject, a.Decision)
+
+	a, err = engine.Assess(ctx, Input{Number: "2508", Amount: 600000, At: now})
+	require.NoError(t, err)
+	require.Equal(t, Hold, a.Decision)
+	require.Contains(t, a.Reasons(), "max_amount")
+}
+
+func TestAmountAnomalyRule(t *testing.T) {
+	engine := NewEngine(nil, AmountAnomalyRule{Multiple: 3, MinHistory: 2, Window: time.Hour, Action: Flag})
+	ctx := context.Background()
+	now := time.Now()
+
+	for i := 0; i < 2; i++ {
+		a, err := engine.Assess(ctx, Input{Number: "2507", Amount: 1000, At: now})
+		require.NoError(t, err)
+		require.Equal(t, Allow, a.Decision)
+	}
+
+	a, err := engine.Assess(ctx, Input{Number: "2507", Amount: 5000, At: now})
+	require.NoError(t, err)
+	require.Equal(t, Flag, a.Decision)
+}
+
+func TestParseConfigRejectsUnknownRule(t *testing.T) {
+	_, err := NewEngineFromJSON([]byte(`{"rules":[{"type":"bogus","action":"flag"}]}`), nil)
+	require.EqualError(t, err, `rule 0: unknown type "bogus"`)
+
+	_, err = NewEngineFromJSON([]byte(`{"rules":[{"type":"velocity","action":"maybe"}]}`), nil)
+	require.Error(t, err)
+}
@@ -0,0 +1,112 @@
+package risk
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// MaxAmountRule fires when a single charge exceeds Max.
+type MaxAmountRule struct {
+	Max    float64
+	Action Decision
+}
+
+// Name implements Rule.
+func (r MaxAmountRule) Name() string { return "max_amount" }
+
+// Evaluate implements Rule.
+func (r MaxAmountRule) Evaluate(ctx context.Context, in Input, history History) (*Result, error) {
+	if r.Max <= 0 || in.Amount <= r.Max {
+		return nil, nil
+	}
+	return &Result{Decision: r.Action, Reason: fmt.Sprintf("amount %.2f exceeds %.2f", in.Amount, r.Max)}, nil
+}
+
+// AmountAnomalyRule fires when a charge is more than Multiple times the number's recent
+// average, once at least MinHistory attempts are known within Window.
+type AmountAnomalyRule struct {
+	Multiple   float64
+	MinHistory int
+	Window     time.Duration
+	Action     Decision
+}
+
+// Name implements Rule.
+func (r AmountAnomalyRule) Name() string { return "amount_anomaly" }
+
+// Evaluate implements Rule.
+func (r AmountAnomalyRule) Evaluate(ctx context.Context, in Input, history History) (*Result, error) {
+	if r.Multiple <= 0 {
+		return nil, nil
+	}
+	past, err := history.Recent(ctx, in.Number, in.At.Add(-r.Window))
+	if err != nil {
+		return nil, err
+	}
+	if len(past) == 0 || len(past) < r.MinHistory {
+		return nil, nil
+	}
+
+	var total float64
+	for _, p := range past {
+		total += p.Amount
+	}
+	avg := total / float64(len(past))
+	if in.Amount <= avg*r.Multiple {
+		return nil, nil
+	}
+	return &Result{Decision: r.Action, Reason: fmt.Sprintf("amount %.2f is over %.1fx the recent average %.2f", in.Amount, r.Multiple, avg)}, nil
+}
+
+// VelocityRule fires when a number is charged more than MaxCount times within Window.
+type VelocityRule struct {
+	MaxCount int
+	Window   time.Duration
+	Action   Decision
+}
+
+// Name implements Rule.
+func (r VelocityRule) Name() string { return "velocity" }
+
+// Evaluate implements Rule.
+func (r VelocityRule) Evaluate(ctx context.Context, in Input, history History) (*Result, error) {
+	if r.MaxCount <= 0 || r.Window <= 0 {
+		return nil, nil
+	}
+	past, err := history.Recent(ctx, in.Number, in.At.Add(-r.Window))
+	if err != nil {
+		return nil, err
+	}
+	if len(past) < r.MaxCount {
+		return nil, nil
+	}
+	return &Result{Decision: r.Action, Reason: fmt.Sprintf("%d charges within %s", len(past)+1, r.Window)}, nil
+}
+
+// ClientMismatchRule fires when a number arrives with a different client than it was
+// previously seen with within Window.
+type ClientMismatchRule struct {
+	Window time.Duration
+	Action Decision
+}
+
+// Name implements Rule.
+func (r ClientMismatchRule) Name() string { return "client_mismatch" }
+
+// Evaluate implements Rule.
+func (r ClientMismatchRule) Evaluate(ctx context.Context, in Input, history History) (*Result, error) {
+	if in.Client == "" {
+		return nil, nil
+	}
+	past, err := history.Recent(ctx, in.Number, in.At.Add(-r.Window))
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range past {
+		if p.Client != "" && p.Client != in.Client {
+			return &Result{Decision: r.Action, Reason: fmt.Sprintf("number previously used by client %s", p.Client)}, nil
+		}
+	}
+	return nil, nil
+}
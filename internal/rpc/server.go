@@ -0,0 +1,134 @@
+// Package rpc serves the processor as the gRPC service paypack.v1.Payments defined in
+// proto/paypack/v1/payments.proto, for services on the mesh that neither invoke Lambda nor
+// speak the JSON HTTP API. The messages and service stubs are generated from that file; run
+// `go generate ./internal/rpc` with protoc, protoc-gen-go and protoc-gen-go-grpc installed
+// after changing it.
+package rpc
+
+//go:generate protoc -I ../../proto --go_out=../.. --go_opt=module=github.com/berniyo/paypack-lambda --go-grpc_out=../.. --go-grpc_opt=module=github.com/berniyo/paypack-lambda paypack/v1/payments.proto
+
+import (
+	"context"
+	"net"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/berniyo/paypack-lambda/internal/handler"
+	"github.com/berniyo/paypack-lambda/internal/tracing"
+)
+
+// maxMessageBytes caps request messages, matching the JSON server's body limit.
+const maxMessageBytes = 1 << 20
+
+// TrailerErrorCode carries the handler.ErrorCode of a failed call alongside grpc-status.
+const TrailerErrorCode = "paypack-error-code"
+
+// New returns a gRPC server serving the Payments service with processor.
+func New(processor *handler.Processor) *grpc.Server {
+	srv := grpc.NewServer(grpc.MaxRecvMsgSize(maxMessageBytes), grpc.UnaryInterceptor(traceContext))
+	RegisterPaymentsServer(srv, &server{processor: processor})
+	return srv
+}
+
+// ListenAndServe serves New(processor) on addr over cleartext HTTP/2, as mesh sidecars expect.
+func ListenAndServe(addr string, processor *handler.Processor) error {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return New(processor).Serve(lis)
+}
+
+type server struct {
+	UnimplementedPaymentsServer
+	processor *handler.Processor
+}
+
+func (s *server) InitiatePayment(ctx context.Context, req *InitiatePaymentRequest) (*Payment, error) {
+	event := handler.SubscriptionEvent{
+		Action:         handler.ActionCashIn,
+		Number:         req.GetNumber(),
+		Amount:         req.GetAmount(),
+		Currency:       req.GetCurrency(),
+		Client:         req.GetClient(),
+		SubscriptionID: req.GetSubscriptionId(),
+	}
+	if len(req.GetMetadata()) > 0 {
+		event.Metadata = make(map[string]any, len(req.GetMetadata()))
+		for k, v := range req.GetMetadata() {
+			event.Metadata[k] = v
+		}
+	}
+	return s.call(ctx, event)
+}
+
+func (s *server) GetStatus(ctx context.Context, req *GetStatusRequest) (*Payment, error) {
+	return s.call(ctx, handler.SubscriptionEvent{Action: handler.ActionStatus, Ref: req.GetRef()})
+}
+
+func (s *server) Refund(ctx context.Context, req *RefundRequest) (*Payment, error) {
+	return s.call(ctx, handler.SubscriptionEvent{Action: handler.ActionRefund, Ref: req.GetRef(), Amount: req.GetAmount()})
+}
+
+func (s *server) call(ctx context.Context, event handler.SubscriptionEvent) (*Payment, error) {
+	resp, err := s.processor.Handle(ctx, event)
+	if err != nil {
+		code := handler.CodeOf(err)
+		_ = grpc.SetTrailer(ctx, metadata.Pairs(TrailerErrorCode, string(code)))
+		return nil, status.Error(statusFor(code), err.Error())
+	}
+	payment := &Payment{
+		Ref:     resp.Reference,
+		Status:  resp.Status,
+		Found:   resp.Found,
+		Message: resp.Message,
+		Code:    string(resp.Code),
+	}
+	if txn := resp.Transaction; txn != nil {
+		payment.Amount = txn.Amount
+		payment.Fee = txn.Fee
+		payment.Currency = txn.Currency
+		payment.Provider = txn.Provider
+	}
+	if resp.Refund != nil {
+		payment.OriginalRef = resp.Refund.OriginalRef
+	}
+	return payment, nil
+}
+
+// traceContext continues the caller's trace from its traceparent and tracestate metadata.
+func traceContext(ctx context.Context, req any, _ *grpc.UnaryServerInfo, next grpc.UnaryHandler) (any, error) {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if tc, ok := tracing.Parse(first(md.Get(tracing.HeaderTraceParent)), first(md.Get(tracing.HeaderTraceState))); ok {
+			ctx = tracing.NewContext(ctx, tc)
+		}
+	}
+	return next(ctx, req)
+}
+
+func first(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func statusFor(code handler.ErrorCode) codes.Code {
+	switch code {
+	case handler.CodeValidation:
+		return codes.InvalidArgument
+	case handler.CodePaypackUnavailable:
+		return codes.Unavailable
+	case handler.CodeInsufficientFunds:
+		return codes.FailedPrecondition
+	case handler.CodeConfirmationTimeout:
+		return codes.DeadlineExceeded
+	case handler.CodeUnauthorized:
+		return codes.Unauthenticated
+	default:
+		return codes.Internal
+	}
+}
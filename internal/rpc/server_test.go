@@ -0,0 +1,99 @@
+package rpc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/berniyo/paypack-lambda/internal/handler"
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+type stubClient struct {
+	cashOuts []float64
+}
+
+func (*stubClient) CashIn(ctx context.Context, number string, amount float64, opts ...paypack.CashInOption) (*paypack.Transaction, error) {
+	return &paypack.Transaction{Ref: "abc"}, nil
+}
+
+func (*stubClient) FindTransaction(ctx context.Context, ref string) (*paypack.Transaction, error) {
+	if ref == "missing" {
+		return nil, paypack.ErrTransactionNotFound
+	}
+	return &paypack.Transaction{Ref: ref, Kind: "CASHIN", Status: "success", Amount: 1000, Fee: 23, Provider: "mtn", Client: "2507"}, nil
+}
+
+func (s *stubClient) CashOut(ctx context.Context, number string, amount float64) (*paypack.Transaction, error) {
+	s.cashOuts = append(s.cashOuts, amount)
+	return &paypack.Transaction{Ref: "out-1", Kind: "CASHOUT", Status: "pending", Amount: amount, Client: number}, nil
+}
+
+// dial serves a processor over client on a loopback listener and connects to it.
+func dial(t *testing.T, client *stubClient) *grpc.ClientConn {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := New(handler.NewProcessor(client, handler.WithPollInterval(5*time.Millisecond)))
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+func callContext(t *testing.T) context.Context {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	t.Cleanup(cancel)
+	return ctx
+}
+
+func TestServerInitiatesPaymentAndReportsStatus(t *testing.T) {
+	payments := NewPaymentsClient(dial(t, &stubClient{}))
+
+	payment, err := payments.InitiatePayment(callContext(t), &InitiatePaymentRequest{Number: "2507", Amount: 1000, Metadata: map[string]string{"plan": "gold"}})
+	require.NoError(t, err)
+	require.Equal(t, "abc", payment.Ref)
+	require.Equal(t, "success", payment.Status)
+	require.True(t, payment.Found)
+	require.InDelta(t, 23, payment.Fee, 0)
+	require.Equal(t, "mtn", payment.Provider)
+
+	payment, err = payments.GetStatus(callContext(t), &GetStatusRequest{Ref: "missing"})
+	require.NoError(t, err)
+	require.Equal(t, "pending", payment.Status)
+	require.False(t, payment.Found)
+}
+
+func TestServerRefundsThroughCashOut(t *testing.T) {
+	client := &stubClient{}
+	payments := NewPaymentsClient(dial(t, client))
+
+	payment, err := payments.Refund(callContext(t), &RefundRequest{Ref: "abc", Amount: 400})
+	require.NoError(t, err)
+	require.Equal(t, "out-1", payment.Ref)
+	require.Equal(t, "abc", payment.OriginalRef)
+	require.Equal(t, []float64{400}, client.cashOuts)
+
+	var trailer metadata.MD
+	_, err = payments.Refund(callContext(t), &RefundRequest{Ref: "abc", Amount: 1500}, grpc.Trailer(&trailer))
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	require.Equal(t, []string{string(handler.CodeValidation)}, trailer.Get(TrailerErrorCode))
+	require.Contains(t, status.Convert(err).Message(), "exceeds original charge")
+}
+
+func TestServerRejectsUnknownMethods(t *testing.T) {
+	err := dial(t, &stubClient{}).Invoke(callContext(t), "/"+Payments_ServiceDesc.ServiceName+"/Capture", &GetStatusRequest{Ref: "abc"}, &Payment{})
+	require.Equal(t, codes.Unimplemented, status.Code(err))
+}
@@ -0,0 +1,329 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/berniyo/paypack-lambda/internal/customer"
+	"github.com/berniyo/paypack-lambda/internal/eventstore"
+	"github.com/berniyo/paypack-lambda/internal/graphql"
+	"github.com/berniyo/paypack-lambda/internal/handler"
+	"github.com/berniyo/paypack-lambda/internal/subscription"
+)
+
+// GraphQLSchema is the SDL of the /graphql endpoint, served at /graphql/schema.
+const GraphQLSchema = `type Query {
+  "The latest outcome recorded for ref."
+  transaction(ref: String!): Transaction
+  "Latest outcomes, newest first. from (inclusive) and to (exclusive) are RFC 3339 times."
+  transactions(status: String, client: String, number: String, subscriptionId: String, from: String, to: String, limit: Int = 50): [Transaction!]!
+}
+
+type Transaction {
+  ref: String!
+  status: String!
+  found: Boolean!
+  message: String
+  code: String
+  amount: Float!
+  fee: Float
+  currency: String
+  provider: String
+  number: String
+  client: String
+  subscriptionId: String
+  createdAt: String!
+  customer: Customer
+  subscription: Subscription
+}
+
+type Customer {
+  number: String!
+  clientId: String
+  email: String
+  name: String
+  createdAt: String
+  updatedAt: String
+}
+
+type Subscription {
+  id: String!
+  number: String!
+  client: String
+  plan: String
+  amount: Float!
+  status: String!
+  lastRef: String
+  failureReason: String
+  createdAt: String
+  updatedAt: String
+}
+`
+
+// maxGraphQLLimit caps the transactions returned by one query.
+const maxGraphQLLimit = 500
+
+// TransactionLog is an event store that can also scan, as the DynamoDB and memory stores do.
+type TransactionLog interface {
+	eventstore.Store
+	eventstore.Scanner
+}
+
+// SubscriptionReader looks subscriptions up by ID, as subscription.Store and Service do.
+type SubscriptionReader interface {
+	Get(ctx context.Context, id string) (*subscription.Subscription, error)
+}
+
+// GraphQLSources are the stores /graphql reads. Transactions is required; without Customers
+// or Subscriptions the nested lookups resolve to null.
+type GraphQLSources struct {
+	Transactions  TransactionLog
+	Customers     customer.Store
+	Subscriptions SubscriptionReader
+}
+
+// WithGraphQL mounts a read-only GraphQL endpoint over the stored outcomes at /graphql
+// (POST a JSON request, or GET with a query parameter) and its SDL at /graphql/schema.
+func WithGraphQL(sources GraphQLSources) Option {
+	return func(c *config) {
+		c.graphql = &sources
+	}
+}
+
+// storedTransaction is the latest callback recorded for a ref.
+type storedTransaction struct {
+	resp      handler.SubscriptionResponse
+	createdAt time.Time
+}
+
+func newGraphQLSchema(src GraphQLSources) *graphql.Schema {
+	str := func(get func(any) string) *graphql.Field {
+		return &graphql.Field{Resolve: func(ctx context.Context, parent any, args map[string]any) (any, error) {
+			if v := get(parent); v != "" {
+				return v, nil
+			}
+			return nil, nil
+		}}
+	}
+	value := func(get func(any) any) *graphql.Field {
+		return &graphql.Field{Resolve: func(ctx context.Context, parent any, args map[string]any) (any, error) {
+			return get(parent), nil
+		}}
+	}
+	timestamp := func(get func(any) time.Time) *graphql.Field {
+		return str(func(v any) string {
+			if t := get(v); !t.IsZero() {
+				return t.UTC().Format(time.RFC3339)
+			}
+			return ""
+		})
+	}
+	txn := func(v any) handler.SubscriptionResponse { return v.(storedTransaction).resp }
+	cust := func(v any) *customer.Profile { return v.(*customer.Profile) }
+	sub := func(v any) *subscription.Subscription { return v.(*subscription.Subscription) }
+
+	customerType := &graphql.Object{Name: "Customer", Fields: map[string]*graphql.Field{
+		"number":    str(func(v any) string { return cust(v).Number }),
+		"clientId":  str(func(v any) string { return cust(v).ClientID }),
+		"email":     str(func(v any) string { return cust(v).Email }),
+		"name":      str(func(v any) string { return cust(v).Name }),
+		"createdAt": timestamp(func(v any) time.Time { return cust(v).CreatedAt }),
+		"updatedAt": timestamp(func(v any) time.Time { return cust(v).UpdatedAt }),
+	}}
+	subscriptionType := &graphql.Object{Name: "Subscription", Fields: map[string]*graphql.Field{
+		"id":            str(func(v any) string { return sub(v).ID }),
+		"number":        str(func(v any) string { return sub(v).Number }),
+		"client":        str(func(v any) string { return sub(v).Client }),
+		"plan":          str(func(v any) string { return sub(v).Plan }),
+		"amount":        value(func(v any) any { return sub(v).Amount }),
+		"status":        str(func(v any) string { return string(sub(v).Status) }),
+		"lastRef":       str(func(v any) string { return sub(v).LastRef }),
+		"failureReason": str(func(v any) string { return sub(v).FailureReason }),
+		"createdAt":     timestamp(func(v any) time.Time { return sub(v).CreatedAt }),
+		"updatedAt":     timestamp(func(v any) time.Time { return sub(v).UpdatedAt }),
+	}}
+
+	transactionType := &graphql.Object{Name: "Transaction", Fields: map[string]*graphql.Field{
+		"ref":     str(func(v any) string { return txn(v).Reference }),
+		"status":  str(func(v any) string { return txn(v).Status }),
+		"found":   value(func(v any) any { return txn(v).Found }),
+		"message": str(func(v any) string { return txn(v).Message }),
+		"code":    str(func(v any) string { return string(txn(v).Code) }),
+		"amount": value(func(v any) any {
+			if t := txn(v).Transaction; t != nil {
+				return t.Amount
+			}
+			return txn(v).Request.Amount
+		}),
+		"fee": value(func(v any) any {
+			if t := txn(v).Transaction; t != nil {
+				return t.Fee
+			}
+			return nil
+		}),
+		"currency": str(func(v any) string {
+			if t := txn(v).Transaction; t != nil && t.Currency != "" {
+				return t.Currency
+			}
+			return txn(v).Request.Currency
+		}),
+		"provider": str(func(v any) string {
+			if t := txn(v).Transaction; t != nil {
+				return t.Provider
+			}
+			return ""
+		}),
+		"number":         str(func(v any) string { return txn(v).Request.Number }),
+		"client":         str(func(v any) string { return txn(v).Request.Client }),
+		"subscriptionId": str(func(v any) string { return txn(v).Request.SubscriptionID }),
+		"createdAt":      timestamp(func(v any) time.Time { return v.(storedTransaction).createdAt }),
+		"customer": {Type: customerType, Resolve: func(ctx context.Context, parent any, args map[string]any) (any, error) {
+			number := txn(parent).Request.Number
+			if src.Customers == nil || number == "" {
+				return nil, nil
+			}
+			profile, err := src.Customers.Get(ctx, number)
+			if errors.Is(err, customer.ErrNotFound) {
+				return nil, nil
+			}
+			return profile, err
+		}},
+		"subscription": {Type: subscriptionType, Resolve: func(ctx context.Context, parent any, args map[string]any) (any, error) {
+			id := txn(parent).Request.SubscriptionID
+			if src.Subscriptions == nil || id == "" {
+				return nil, nil
+			}
+			s, err := src.Subscriptions.Get(ctx, id)
+			if errors.Is(err, subscription.ErrNotFound) {
+				return nil, nil
+			}
+			return s, err
+		}},
+	}}
+
+	return &graphql.Schema{Query: &graphql.Object{Name: "Query", Fields: map[string]*graphql.Field{
+		"transaction": {
+			Type: transactionType,
+			Args: map[string]graphql.Arg{"ref": {Required: true}},
+			Resolve: func(ctx context.Context, parent any, args map[string]any) (any, error) {
+				ref, _ := args["ref"].(string)
+				rec, err := src.Transactions.Latest(ctx, ref, eventstore.KindCallback)
+				if errors.Is(err, eventstore.ErrNotFound) {
+					return nil, nil
+				}
+				if err != nil {
+					return nil, err
+				}
+				var resp handler.SubscriptionResponse
+				if err := json.Unmarshal(rec.Payload, &resp); err != nil {
+					return nil, fmt.Errorf("decode stored outcome: %w", err)
+				}
+				return storedTransaction{resp: resp, createdAt: rec.CreatedAt}, nil
+			},
+		},
+		"transactions": {
+			Type: transactionType,
+			Args: map[string]graphql.Arg{
+				"status": {}, "client": {}, "number": {}, "subscriptionId": {}, "from": {}, "to": {},
+				"limit": {Default: 50},
+			},
+			Resolve: func(ctx context.Context, parent any, args map[string]any) (any, error) {
+				return queryTransactions(ctx, src.Transactions, args)
+			},
+		},
+	}}}
+}
+
+// queryTransactions keeps the latest callback per ref, like exports, then filters.
+func queryTransactions(ctx context.Context, log TransactionLog, args map[string]any) ([]storedTransaction, error) {
+	text := func(name string) string {
+		s, _ := args[name].(string)
+		return s
+	}
+	var from, to time.Time
+	for name, bound := range map[string]*time.Time{"from": &from, "to": &to} {
+		if s := text(name); s != "" {
+			t, err := time.Parse(time.RFC3339, s)
+			if err != nil {
+				return nil, fmt.Errorf("%s must be an RFC 3339 time", name)
+			}
+			*bound = t
+		}
+	}
+	limit := maxGraphQLLimit
+	switch n := args["limit"].(type) {
+	case int:
+		limit = n
+	case float64:
+		limit = int(n)
+	}
+	if limit <= 0 || limit > maxGraphQLLimit {
+		return nil, fmt.Errorf("limit must be between 1 and %d", maxGraphQLLimit)
+	}
+
+	latest := make(map[string]storedTransaction)
+	err := log.Scan(ctx, eventstore.KindCallback, from, to, func(rec eventstore.Record) error {
+		if prev, ok := latest[rec.Ref]; ok && !rec.CreatedAt.After(prev.createdAt) {
+			return nil
+		}
+		var resp handler.SubscriptionResponse
+		if err := json.Unmarshal(rec.Payload, &resp); err != nil {
+			return nil
+		}
+		latest[rec.Ref] = storedTransaction{resp: resp, createdAt: rec.CreatedAt}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("scan transactions: %w", err)
+	}
+
+	out := make([]storedTransaction, 0, len(latest))
+	for _, t := range latest {
+		req := t.resp.Request
+		switch {
+		case text("status") != "" && !strings.EqualFold(t.resp.Status, text("status")):
+		case text("client") != "" && req.Client != text("client"):
+		case text("number") != "" && req.Number != text("number"):
+		case text("subscriptionId") != "" && req.SubscriptionID != text("subscriptionId"):
+		default:
+			out = append(out, t)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].createdAt.After(out[j].createdAt) })
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func serveGraphQL(schema *graphql.Schema) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req graphql.Request
+		if r.Method == http.MethodGet {
+			req.Query = r.URL.Query().Get("query")
+			req.OperationName = r.URL.Query().Get("operationName")
+			if vars := r.URL.Query().Get("variables"); vars != "" {
+				if err := json.Unmarshal([]byte(vars), &req.Variables); err != nil {
+					writeJSON(w, http.StatusBadRequest, graphql.Response{Errors: []graphql.Error{{Message: "invalid variables"}}})
+					return
+				}
+			}
+		} else if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes)).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, graphql.Response{Errors: []graphql.Error{{Message: "invalid GraphQL request"}}})
+			return
+		}
+
+		resp := schema.Execute(r.Context(), req)
+		status := http.StatusOK
+		if resp.Data == nil {
+			status = http.StatusBadRequest
+		}
+		writeJSON(w, status, resp)
+	}
+}
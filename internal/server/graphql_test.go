@@ -0,0 +1,138 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/berniyo/paypack-lambda/internal/customer"
+	"github.com/berniyo/paypack-lambda/internal/eventstore"
+	"github.com/berniyo/paypack-lambda/internal/handler"
+	"github.com/berniyo/paypack-lambda/internal/subscription"
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+func appendOutcome(t *testing.T, store *eventstore.MemoryStore, resp handler.SubscriptionResponse, at time.Time) {
+	t.Helper()
+	payload, err := json.Marshal(resp)
+	require.NoError(t, err)
+	require.NoError(t, store.Append(context.Background(), eventstore.Record{
+		Kind:      eventstore.KindCallback,
+		Ref:       resp.Reference,
+		Payload:   payload,
+		CreatedAt: at,
+	}))
+}
+
+func newGraphQLServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	ctx := context.Background()
+	log := eventstore.NewMemoryStore()
+	base := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	appendOutcome(t, log, handler.SubscriptionResponse{Reference: "a", Status: "pending", Request: handler.SubscriptionEvent{Number: "2507", Amount: 1000}}, base)
+	appendOutcome(t, log, handler.SubscriptionResponse{
+		Reference:   "a",
+		Status:      "success",
+		Found:       true,
+		Request:     handler.SubscriptionEvent{Number: "2507", Amount: 1000, Client: "acme", SubscriptionID: "sub-1"},
+		Transaction: &paypack.Transaction{Ref: "a", Amount: 1000, Fee: 23, Currency: "RWF", Status: "success"},
+	}, base.Add(time.Minute))
+	appendOutcome(t, log, handler.SubscriptionResponse{Reference: "b", Status: "failed", Request: handler.SubscriptionEvent{Number: "2508", Amount: 500, Client: "acme"}}, base.Add(time.Hour))
+	appendOutcome(t, log, handler.SubscriptionResponse{Reference: "c", Status: "success", Request: handler.SubscriptionEvent{Number: "2509", Amount: 700, Client: "globex"}}, base.Add(2*time.Hour))
+
+	customers := customer.NewMemoryStore()
+	require.NoError(t, customers.Put(ctx, &customer.Profile{Number: "2507", Name: "Aline"}))
+	subs := subscription.NewMemoryStore()
+	require.NoError(t, subs.Put(ctx, &subscription.Subscription{ID: "sub-1", Number: "2507", Plan: "monthly", Amount: 1000, Status: subscription.StatusActive}))
+
+	srv := httptest.NewServer(New(handler.NewProcessor(stubClient{}), nil, nil, WithGraphQL(GraphQLSources{
+		Transactions:  log,
+		Customers:     customers,
+		Subscriptions: subs,
+	})))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func postGraphQL(t *testing.T, srv *httptest.Server, query string, variables map[string]any) (int, map[string]any) {
+	t.Helper()
+	body, err := json.Marshal(map[string]any{"query": query, "variables": variables})
+	require.NoError(t, err)
+	res, err := http.Post(srv.URL+"/graphql", "application/json", strings.NewReader(string(body)))
+	require.NoError(t, err)
+	defer res.Body.Close()
+	var out map[string]any
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&out))
+	return res.StatusCode, out
+}
+
+func TestGraphQLFiltersTransactions(t *testing.T) {
+	srv := newGraphQLServer(t)
+
+	status, out := postGraphQL(t, srv, `query ($client: String) {
+		transactions(client: $client, status: "success") { ref status amount fee client }
+	}`, map[string]any{"client": "acme"})
+	require.Equal(t, http.StatusOK, status)
+	require.Nil(t, out["errors"])
+	require.Equal(t, []any{
+		map[string]any{"ref": "a", "status": "success", "amount": 1000.0, "fee": 23.0, "client": "acme"},
+	}, out["data"].(map[string]any)["transactions"])
+
+	_, out = postGraphQL(t, srv, `{ transactions(from: "2026-03-01T09:30:00Z", limit: 1) { ref } }`, nil)
+	require.Equal(t, []any{map[string]any{"ref": "c"}}, out["data"].(map[string]any)["transactions"])
+}
+
+func TestGraphQLResolvesNestedLookups(t *testing.T) {
+	srv := newGraphQLServer(t)
+
+	_, out := postGraphQL(t, srv, `{
+		paid: transaction(ref: "a") { ref customer { name } subscription { plan status } }
+		other: transaction(ref: "b") { customer { name } subscription { id } }
+		missing: transaction(ref: "zzz") { ref }
+	}`, nil)
+	require.Nil(t, out["errors"])
+	data := out["data"].(map[string]any)
+	require.Equal(t, map[string]any{
+		"ref":          "a",
+		"customer":     map[string]any{"name": "Aline"},
+		"subscription": map[string]any{"plan": "monthly", "status": "active"},
+	}, data["paid"])
+	require.Equal(t, map[string]any{"customer": nil, "subscription": nil}, data["other"])
+	require.Nil(t, data["missing"])
+}
+
+func TestGraphQLReportsQueryErrors(t *testing.T) {
+	srv := newGraphQLServer(t)
+
+	status, out := postGraphQL(t, srv, `{ transactions { ref `, nil)
+	require.Equal(t, http.StatusBadRequest, status)
+	require.NotEmpty(t, out["errors"])
+
+	status, out = postGraphQL(t, srv, `{ transaction(ref: "a") { ref nope } }`, nil)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, map[string]any{"ref": "a", "nope": nil}, out["data"].(map[string]any)["transaction"])
+	require.NotEmpty(t, out["errors"])
+
+	_, out = postGraphQL(t, srv, `{ transactions(limit: 5000) { ref } }`, nil)
+	errs := out["errors"].([]any)
+	require.Len(t, errs, 1)
+	require.Contains(t, errs[0].(map[string]any)["message"], "limit must be between")
+}
+
+func TestGraphQLServesSchema(t *testing.T) {
+	srv := newGraphQLServer(t)
+
+	res, err := http.Get(srv.URL + "/graphql/schema")
+	require.NoError(t, err)
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	require.Contains(t, string(body), "type Transaction {")
+}
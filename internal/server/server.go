@@ -0,0 +1,213 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"expvar"
+	"io"
+	"net/http"
+	"net/http/pprof"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"github.com/berniyo/paypack-lambda/api"
+	"github.com/berniyo/paypack-lambda/internal/handler"
+	"github.com/berniyo/paypack-lambda/internal/tracing"
+	"github.com/berniyo/paypack-lambda/internal/webhookauth"
+)
+
+// maxBodyBytes caps request bodies; events and webhooks are small JSON documents.
+const maxBodyBytes = 1 << 20
+
+// Option customises the server built by New.
+type Option func(*config)
+
+type config struct {
+	diagnostics bool
+	broker      *Broker
+	graphql     *GraphQLSources
+	webhookAuth []webhookauth.Check
+}
+
+// WithDiagnostics mounts net/http/pprof under /debug/pprof/ and expvar under /debug/vars.
+// Both expose process internals, so only enable it for local runs and load tests.
+func WithDiagnostics() Option {
+	return func(c *config) {
+		c.diagnostics = true
+	}
+}
+
+// WithWebhookAuth runs checks on POST /webhook requests before they reach the webhook
+// handler. Source IPs are the connection's peer, so put allowlists behind a proxy that
+// terminates on the same network.
+func WithWebhookAuth(checks ...webhookauth.Check) Option {
+	return func(c *config) {
+		c.webhookAuth = append(c.webhookAuth, checks...)
+	}
+}
+
+// New returns the HTTP handler used when the processor runs as a long-lived server:
+//
+//	POST /events                     handles a SubscriptionEvent, same contract as the Lambda payload
+//	POST /webhook                    handles Paypack webhooks, same as the webhook mode
+//	GET  /metrics                    serves metricsHandler, when one is given
+//	GET  /transactions/{ref}/events  streams status updates, with WithStatusStream
+//	POST /graphql                    queries stored outcomes, with WithGraphQL
+//	GET  /openapi.yaml               serves the OpenAPI document (api/openapi.yaml) events are validated against
+//	GET  /healthz                    reports liveness
+//
+// The operations of api/openapi.yaml are routed by the generated api.HandlerWithOptions;
+// the rest are mounted alongside them.
+func New(processor *handler.Processor, webhook *handler.WebhookHandler, metricsHandler http.Handler, opts ...Option) http.Handler {
+	var cfg config
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	mux := http.NewServeMux()
+	ops := &operations{processor: processor}
+	if webhook != nil {
+		ops.webhook = webhookauth.HTTP(webhookHandler(webhook), maxBodyBytes, cfg.webhookAuth...)
+	}
+	api.HandlerWithOptions(ops, api.StdHTTPServerOptions{
+		BaseRouter: mux,
+		ErrorHandlerFunc: func(w http.ResponseWriter, r *http.Request, err error) {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error(), "code": handler.CodeValidation})
+		},
+	})
+	if cfg.broker != nil {
+		mux.HandleFunc("GET /transactions/{ref}/events", cfg.broker.serveEvents)
+	}
+	if cfg.graphql != nil {
+		endpoint := serveGraphQL(newGraphQLSchema(*cfg.graphql))
+		mux.HandleFunc("GET /graphql", endpoint)
+		mux.HandleFunc("POST /graphql", endpoint)
+		mux.HandleFunc("GET /graphql/schema", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			io.WriteString(w, GraphQLSchema)
+		})
+	}
+	if metricsHandler != nil {
+		mux.Handle("GET /metrics", metricsHandler)
+	}
+	if cfg.diagnostics {
+		mux.HandleFunc("/debug/pprof/", pprof.Index)
+		mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+		mux.Handle("GET /debug/vars", expvar.Handler())
+	}
+	return mux
+}
+
+// operations implements the api.ServerInterface generated from api/openapi.yaml.
+type operations struct {
+	processor *handler.Processor
+	// webhook serves POST /webhook behind the configured checks; nil without a webhook handler.
+	webhook http.Handler
+}
+
+var _ api.ServerInterface = (*operations)(nil)
+
+func (o *operations) HandleEvent(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unreadable body"})
+		return
+	}
+	event, err := o.processor.DecodeEvent(body)
+	var invalid *api.ValidationError
+	switch {
+	case errors.As(err, &invalid):
+		writeJSON(w, http.StatusBadRequest, map[string]any{
+			"error":   "invalid event payload",
+			"code":    handler.CodeValidation,
+			"details": invalid.Problems,
+		})
+		return
+	case errors.Is(err, handler.ErrUnsupportedEventVersion):
+		writeJSON(w, http.StatusBadRequest, map[string]any{
+			"error":              err.Error(),
+			"code":               handler.CodeValidation,
+			"supported_versions": handler.SupportedEventVersions(),
+		})
+		return
+	case err != nil:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid event payload"})
+		return
+	}
+	ctx := r.Context()
+	if tc, ok := tracing.Parse(r.Header.Get(tracing.HeaderTraceParent), r.Header.Get(tracing.HeaderTraceState)); ok {
+		ctx = tracing.NewContext(ctx, tc)
+	}
+	resp, err := o.processor.Handle(ctx, event)
+	if err != nil {
+		status := http.StatusUnprocessableEntity
+		code := handler.CodeOf(err)
+		switch code {
+		case handler.CodePaypackUnavailable:
+			status = http.StatusBadGateway
+		case handler.CodeUnauthorized:
+			status = http.StatusUnauthorized
+		}
+		writeJSON(w, status, map[string]any{"error": err.Error(), "code": code, "failure": handler.FailureOf(code)})
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// HandleWebhook leaves X-Paypack-Signature to the webhook handler, which verifies it against
+// the raw body.
+func (o *operations) HandleWebhook(w http.ResponseWriter, r *http.Request, _ api.HandleWebhookParams) {
+	if o.webhook == nil {
+		http.NotFound(w, r)
+		return
+	}
+	o.webhook.ServeHTTP(w, r)
+}
+
+func (o *operations) Health(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (o *operations) Spec(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/yaml")
+	w.Write(api.Document)
+}
+
+// webhookHandler adapts webhook, which takes API Gateway requests, to net/http.
+func webhookHandler(webhook *handler.WebhookHandler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unreadable body"})
+			return
+		}
+		headers := make(map[string]string, len(r.Header))
+		for name := range r.Header {
+			headers[strings.ToLower(name)] = r.Header.Get(name)
+		}
+		resp, err := webhook.Handle(r.Context(), events.APIGatewayV2HTTPRequest{
+			RawPath: r.URL.Path,
+			Headers: headers,
+			Body:    string(body),
+		})
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		for name, value := range resp.Headers {
+			w.Header().Set(name, value)
+		}
+		w.WriteHeader(resp.StatusCode)
+		io.WriteString(w, resp.Body)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
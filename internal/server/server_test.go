@@ -0,0 +1,207 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/berniyo/paypack-lambda/api"
+	"github.com/berniyo/paypack-lambda/internal/handler"
+	"github.com/berniyo/paypack-lambda/internal/metrics"
+	"github.com/berniyo/paypack-lambda/internal/webhookauth"
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+type stubClient struct{}
+
+func (stubClient) CashIn(ctx context.Context, number string, amount float64, opts ...paypack.CashInOption) (*paypack.Transaction, error) {
+	return &paypack.Transaction{Ref: "abc"}, nil
+}
+
+func (stubClient) FindTransaction(ctx context.Context, ref string) (*paypack.Transaction, error) {
+	return &paypack.Transaction{Ref: ref, Status: "success", Amount: 1000}, nil
+}
+
+func TestServerHandlesEventsAndExposesMetrics(t *testing.T) {
+	prom := metrics.NewPrometheus("paypack_lambda")
+	processor := handler.NewProcessor(stubClient{}, handler.WithPollInterval(5*time.Millisecond), handler.WithMetrics(prom))
+	srv := httptest.NewServer(New(processor, handler.NewWebhookHandler(processor, ""), prom.Handler()))
+	defer srv.Close()
+
+	res, err := http.Post(srv.URL+"/events", "application/json", strings.NewReader(`{"number":"2507","amount":1000}`))
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	var resp handler.SubscriptionResponse
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&resp))
+	require.Equal(t, "success", resp.Status)
+
+	res, err = http.Post(srv.URL+"/webhook", "application/json",
+		strings.NewReader(`{"event_id":"evt-1","data":{"ref":"xyz","amount":500,"status":"failed"}}`))
+	require.NoError(t, err)
+	res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	res, err = http.Get(srv.URL + "/metrics")
+	require.NoError(t, err)
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	require.Contains(t, string(body), `paypack_lambda_cashin_results_total{status="success"} 1`)
+	require.Contains(t, string(body), `paypack_lambda_cashin_results_total{status="failed"} 1`)
+	require.Contains(t, string(body), `paypack_lambda_poll_duration_seconds_count{outcome="confirmed"} 1`)
+}
+
+func TestServerRejectsInvalidEvents(t *testing.T) {
+	processor := handler.NewProcessor(stubClient{})
+	srv := httptest.NewServer(New(processor, nil, nil))
+	defer srv.Close()
+
+	res, err := http.Post(srv.URL+"/events", "application/json", strings.NewReader(`{`))
+	require.NoError(t, err)
+	res.Body.Close()
+	require.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+	res, err = http.Post(srv.URL+"/events", "application/json", strings.NewReader(`{"amount":1000}`))
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+	var body struct {
+		Code    string           `json:"code"`
+		Failure *handler.Failure `json:"failure"`
+	}
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&body))
+	require.Equal(t, "VALIDATION_ERROR", body.Code)
+	require.Equal(t, &handler.Failure{Code: handler.CodeValidation, Source: handler.FailureValidation}, body.Failure)
+
+	res, err = http.Get(srv.URL + "/metrics")
+	require.NoError(t, err)
+	res.Body.Close()
+	require.Equal(t, http.StatusNotFound, res.StatusCode)
+}
+
+func TestServerDiagnosticsAreOptIn(t *testing.T) {
+	processor := handler.NewProcessor(stubClient{})
+	plain := httptest.NewServer(New(processor, nil, nil))
+	defer plain.Close()
+	debug := httptest.NewServer(New(processor, nil, nil, WithDiagnostics()))
+	defer debug.Close()
+
+	for _, path := range []string{"/debug/vars", "/debug/pprof/"} {
+		res, err := http.Get(plain.URL + path)
+		require.NoError(t, err)
+		res.Body.Close()
+		require.Equal(t, http.StatusNotFound, res.StatusCode, path)
+
+		res, err = http.Get(debug.URL + path)
+		require.NoError(t, err)
+		res.Body.Close()
+		require.Equal(t, http.StatusOK, res.StatusCode, path)
+	}
+}
+
+func TestServerValidatesEventsAgainstOpenAPI(t *testing.T) {
+	processor := handler.NewProcessor(stubClient{})
+	srv := httptest.NewServer(New(processor, nil, nil))
+	defer srv.Close()
+
+	res, err := http.Post(srv.URL+"/events", "application/json", strings.NewReader(`{"number":2507,"amount":1000}`))
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusBadRequest, res.StatusCode)
+	var body struct {
+		Code    string   `json:"code"`
+		Details []string `json:"details"`
+	}
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&body))
+	require.Equal(t, "VALIDATION_ERROR", body.Code)
+	require.Equal(t, []string{"$.number: must be a string"}, body.Details)
+
+	res, err = http.Get(srv.URL + "/openapi.yaml")
+	require.NoError(t, err)
+	defer res.Body.Close()
+	spec, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	require.Equal(t, api.Document, spec)
+}
+
+func TestServerListsSupportedEventVersions(t *testing.T) {
+	processor := handler.NewProcessor(stubClient{})
+	srv := httptest.NewServer(New(processor, nil, nil))
+	defer srv.Close()
+
+	res, err := http.Post(srv.URL+"/events", "application/json", strings.NewReader(`{"version":2,"number":"2507","amount":1000}`))
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusBadRequest, res.StatusCode)
+	var body struct {
+		Code      string `json:"code"`
+		Supported []int  `json:"supported_versions"`
+	}
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&body))
+	require.Equal(t, "VALIDATION_ERROR", body.Code)
+	require.Equal(t, []int{handler.EventSchemaV1}, body.Supported)
+}
+
+// TestOpenAPIMatchesContract keeps the documented properties in step with the JSON fields of
+// the structs the server decodes and encodes.
+func TestOpenAPIMatchesContract(t *testing.T) {
+	for name, typ := range map[string]reflect.Type{
+		"SubscriptionEvent":    reflect.TypeOf(handler.SubscriptionEvent{}),
+		"SubscriptionResponse": reflect.TypeOf(handler.SubscriptionResponse{}),
+		"Transaction":          reflect.TypeOf(paypack.Transaction{}),
+		"WebhookEvent":         reflect.TypeOf(paypack.WebhookEvent{}),
+	} {
+		schema, err := api.Component(name)
+		require.NoError(t, err)
+		var documented []string
+		for prop := range schema.Properties {
+			documented = append(documented, prop)
+		}
+		var fields []string
+		for i := range typ.NumField() {
+			tag, _, _ := strings.Cut(typ.Field(i).Tag.Get("json"), ",")
+			if tag != "" && tag != "-" {
+				fields = append(fields, tag)
+			}
+		}
+		require.ElementsMatch(t, fields, documented, name)
+	}
+}
+
+func TestServerAuthenticatesWebhooks(t *testing.T) {
+	processor := handler.NewProcessor(stubClient{})
+	allowLocal, err := webhookauth.AllowIPs("127.0.0.1")
+	require.NoError(t, err)
+	srv := httptest.NewServer(New(processor, handler.NewWebhookHandler(processor, ""), nil,
+		WithWebhookAuth(allowLocal, webhookauth.SharedSecret("", "tok"))))
+	defer srv.Close()
+
+	post := func(token string) (*http.Response, string) {
+		req, err := http.NewRequest(http.MethodPost, srv.URL+"/webhook",
+			strings.NewReader(`{"event_id":"evt-1","data":{"ref":"xyz","amount":500,"status":"failed"}}`))
+		require.NoError(t, err)
+		req.Header.Set(webhookauth.DefaultSecretHeader, token)
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		return res, string(body)
+	}
+
+	res, body := post("wrong")
+	require.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	require.JSONEq(t, `{"error":"invalid shared secret","code":"INVALID_SECRET"}`, body)
+
+	res, body = post("tok")
+	require.Equal(t, http.StatusOK, res.StatusCode, body)
+}
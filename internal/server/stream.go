@@ -0,0 +1,128 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/berniyo/paypack-lambda/internal/handler"
+)
+
+// brokerCapacity bounds how many refs keep their last update for late subscribers.
+const brokerCapacity = 10000
+
+// heartbeatInterval keeps idle streams open through proxies that drop silent connections.
+const heartbeatInterval = 15 * time.Second
+
+// Broker fans status updates out to the SSE streams watching each ref and remembers the last
+// update per ref, so a stream opened mid-confirmation starts from the current stage. It
+// implements handler.StatusListener.
+type Broker struct {
+	mu    sync.Mutex
+	subs  map[string]map[chan handler.StatusUpdate]struct{}
+	last  map[string]handler.StatusUpdate
+	order []string
+}
+
+// NewBroker returns an empty broker; pass it to handler.WithStatusListener and WithStatusStream.
+func NewBroker() *Broker {
+	return &Broker{
+		subs: make(map[string]map[chan handler.StatusUpdate]struct{}),
+		last: make(map[string]handler.StatusUpdate),
+	}
+}
+
+// StatusChanged implements handler.StatusListener. Streams too slow to keep up miss updates
+// rather than stall the processor.
+func (b *Broker) StatusChanged(update handler.StatusUpdate) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, seen := b.last[update.Ref]; !seen {
+		b.order = append(b.order, update.Ref)
+		if len(b.order) > brokerCapacity {
+			delete(b.last, b.order[0])
+			b.order = b.order[1:]
+		}
+	}
+	b.last[update.Ref] = update
+	for ch := range b.subs[update.Ref] {
+		select {
+		case ch <- update:
+		default:
+		}
+	}
+}
+
+// subscribe registers a stream for ref and returns the last update seen for it, if any.
+func (b *Broker) subscribe(ref string) (<-chan handler.StatusUpdate, *handler.StatusUpdate, func()) {
+	ch := make(chan handler.StatusUpdate, 16)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.subs[ref] == nil {
+		b.subs[ref] = make(map[chan handler.StatusUpdate]struct{})
+	}
+	b.subs[ref][ch] = struct{}{}
+	var last *handler.StatusUpdate
+	if update, ok := b.last[ref]; ok {
+		last = &update
+	}
+	return ch, last, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.subs[ref], ch)
+		if len(b.subs[ref]) == 0 {
+			delete(b.subs, ref)
+		}
+	}
+}
+
+// WithStatusStream mounts GET /transactions/{ref}/events, a Server-Sent Events stream of the
+// status updates broker receives for ref. The stream ends after a confirmed or failed update.
+func WithStatusStream(broker *Broker) Option {
+	return func(c *config) {
+		c.broker = broker
+	}
+}
+
+func (b *Broker) serveEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "streaming unsupported"})
+		return
+	}
+	updates, last, cancel := b.subscribe(r.PathValue("ref"))
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	send := func(update handler.StatusUpdate) bool {
+		data, _ := json.Marshal(update)
+		fmt.Fprintf(w, "event: status\ndata: %s\n\n", data)
+		flusher.Flush()
+		return update.Stage != handler.StageConfirmed && update.Stage != handler.StageFailed
+	}
+	if last != nil && !send(*last) {
+		return
+	}
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case update := <-updates:
+			if !send(update) {
+				return
+			}
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		}
+	}
+}
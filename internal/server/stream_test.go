@@ -0,0 +1,72 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/berniyo/paypack-lambda/internal/handler"
+)
+
+// readStatuses collects the data lines of an SSE stream until the server ends it.
+func readStatuses(t *testing.T, res *http.Response) []handler.StatusUpdate {
+	t.Helper()
+	var updates []handler.StatusUpdate
+	scanner := bufio.NewScanner(res.Body)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		var update handler.StatusUpdate
+		require.NoError(t, json.Unmarshal([]byte(data), &update))
+		updates = append(updates, update)
+	}
+	return updates
+}
+
+func TestServerStreamsStatusTransitions(t *testing.T) {
+	broker := NewBroker()
+	processor := handler.NewProcessor(stubClient{}, handler.WithPollInterval(5*time.Millisecond), handler.WithStatusListener(broker))
+	srv := httptest.NewServer(New(processor, nil, nil, WithStatusStream(broker)))
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL + "/transactions/abc/events")
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+
+	post, err := http.Post(srv.URL+"/events", "application/json", strings.NewReader(`{"number":"2507","amount":1000}`))
+	require.NoError(t, err)
+	post.Body.Close()
+
+	updates := readStatuses(t, res)
+	require.Len(t, updates, 2)
+	require.Equal(t, handler.StageInitiated, updates[0].Stage)
+	require.Equal(t, handler.StageConfirmed, updates[1].Stage)
+	require.Equal(t, "success", updates[1].Status)
+}
+
+func TestServerStreamStartsFromLastUpdate(t *testing.T) {
+	broker := NewBroker()
+	srv := httptest.NewServer(New(handler.NewProcessor(stubClient{}), nil, nil, WithStatusStream(broker)))
+	defer srv.Close()
+
+	broker.StatusChanged(handler.StatusUpdate{Ref: "xyz", Stage: handler.StageFailed, Status: "failed"})
+	res, err := http.Get(srv.URL + "/transactions/xyz/events")
+	require.NoError(t, err)
+	defer res.Body.Close()
+	updates := readStatuses(t, res)
+	require.Len(t, updates, 1)
+	require.Equal(t, handler.StageFailed, updates[0].Stage)
+
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+	require.Empty(t, broker.subs, "finished streams unsubscribe")
+}
@@ -0,0 +1,122 @@
+package split
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/berniyo/paypack-lambda/internal/money"
+)
+
+// Recipient is one party in a split. Set exactly one of Percent (of the split amount, 10 for
+// 10%) or Fixed (an absolute amount in the transaction currency).
+type Recipient struct {
+	Number  string  `json:"number"`
+	Name    string  `json:"name,omitempty"`
+	Percent float64 `json:"percent,omitempty"`
+	Fixed   float64 `json:"fixed,omitempty"`
+}
+
+// Plan lists the recipients a confirmed payment is shared with. Whatever is not allocated
+// stays with the merchant.
+type Plan struct {
+	Recipients []Recipient `json:"recipients"`
+}
+
+// Leg is the amount owed to one recipient.
+type Leg struct {
+	Number string  `json:"number"`
+	Name   string  `json:"name,omitempty"`
+	Amount float64 `json:"amount"`
+}
+
+// Validate checks that every recipient has a number and exactly one positive share, and that
+// percentages do not exceed 100.
+func (p Plan) Validate() error {
+	if len(p.Recipients) == 0 {
+		return errors.New("split plan has no recipients")
+	}
+	var percent float64
+	for i, r := range p.Recipients {
+		if strings.TrimSpace(r.Number) == "" {
+			return fmt.Errorf("recipient %d: number is required", i)
+		}
+		if r.Percent < 0 || r.Fixed < 0 {
+			return fmt.Errorf("recipient %s: share must not be negative", r.Number)
+		}
+		if (r.Percent > 0) == (r.Fixed > 0) {
+			return fmt.Errorf("recipient %s: set exactly one of percent or fixed", r.Number)
+		}
+		percent += r.Percent
+	}
+	if percent > 100 {
+		return fmt.Errorf("split percentages total %.2f%%, above 100%%", percent)
+	}
+	return nil
+}
+
+// Allocate divides amount in currency across the recipients. Fixed shares are taken first;
+// percentages apply to the full amount. It fails when the shares exceed amount.
+func (p Plan) Allocate(amount float64, currency string) ([]Leg, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+
+	legs := make([]Leg, 0, len(p.Recipients))
+	var total float64
+	for _, r := range p.Recipients {
+		share := r.Fixed
+		if r.Percent > 0 {
+			share = money.Round(amount*r.Percent/100, currency)
+		}
+		total += share
+		legs = append(legs, Leg{Number: r.Number, Name: r.Name, Amount: share})
+	}
+	if money.Round(total, currency) > money.Round(amount, currency) {
+		return nil, fmt.Errorf("split shares total %.2f, above the %.2f available", total, amount)
+	}
+	return legs, nil
+}
+
+// Table selects a plan per subscription plan, falling back to Default.
+type Table struct {
+	Default *Plan           `json:"default,omitempty"`
+	Plans   map[string]Plan `json:"plans,omitempty"`
+}
+
+// ParseTable decodes a JSON split table, e.g.
+//
+//	{"default": {"recipients": [{"number": "0788000001", "percent": 10}]},
+//	 "plans": {"partner": {"recipients": [{"number": "0788000002", "fixed": 500}]}}}
+func ParseTable(data []byte) (*Table, error) {
+	var t Table
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("decode split table: %w", err)
+	}
+	if t.Default == nil && len(t.Plans) == 0 {
+		return nil, errors.New("split table has no plans")
+	}
+	if t.Default != nil {
+		if err := t.Default.Validate(); err != nil {
+			return nil, fmt.Errorf("default: %w", err)
+		}
+	}
+	for name, plan := range t.Plans {
+		if err := plan.Validate(); err != nil {
+			return nil, fmt.Errorf("plan %s: %w", name, err)
+		}
+	}
+	return &t, nil
+}
+
+// PlanFor returns the plan for name, or false when neither the plan nor a default applies.
+func (t *Table) PlanFor(name string) (Plan, bool) {
+	if p, ok := t.Plans[name]; ok {
+		return p, true
+	}
+	if t.Default != nil {
+		return *t.Default, true
+	}
+	return Plan{}, false
+}
@@ -0,0 +1,53 @@
+package split
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlanAllocate(t *testing.T) {
+	plan := Plan{Recipients: []Recipient{
+		{Number: "0788000001", Percent: 12.5},
+		{Number: "0788000002", Fixed: 300},
+	}}
+
+	legs, err := plan.Allocate(1000, "RWF")
+	require.NoError(t, err)
+	require.Equal(t, []Leg{
+		{Number: "0788000001", Amount: 125},
+		{Number: "0788000002", Amount: 300},
+	}, legs)
+
+	_, err = plan.Allocate(300, "USD")
+	require.EqualError(t, err, "split shares total 337.50, above the 300.00 available")
+
+	legs, err = Plan{Recipients: []Recipient{{Number: "0788000001", Percent: 12.5}}}.Allocate(1003, "RWF")
+	require.NoError(t, err)
+	require.Equal(t, 125.0, legs[0].Amount, "francs have no minor unit")
+}
+
+func TestPlanValidate(t *testing.T) {
+	require.EqualError(t, Plan{}.Validate(), "split plan has no recipients")
+	require.EqualError(t, Plan{Recipients: []Recipient{{Number: "1", Percent: 10, Fixed: 5}}}.Validate(),
+		"recipient 1: set exactly one of percent or fixed")
+	require.EqualError(t, Plan{Recipients: []Recipient{{Number: "1", Percent: 60}, {Number: "2", Percent: 50}}}.Validate(),
+		"split percentages total 110.00%, above 100%")
+}
+
+func TestParseTable(t *testing.T) {
+	table, err := ParseTable([]byte(`{"default":{"recipients":[{"number":"1","percent":10}]},` +
+		`"plans":{"partner":{"recipients":[{"number":"2","fixed":500}]}}}`))
+	require.NoError(t, err)
+
+	plan, ok := table.PlanFor("partner")
+	require.True(t, ok)
+	require.Equal(t, "2", plan.Recipients[0].Number)
+
+	plan, ok = table.PlanFor("basic")
+	require.True(t, ok)
+	require.Equal(t, "1", plan.Recipients[0].Number)
+
+	_, err = ParseTable([]byte(`{"plans":{"bad":{"recipients":[{"number":"1"}]}}}`))
+	require.EqualError(t, err, "plan bad: recipient 1: set exactly one of percent or fixed")
+}
@@ -0,0 +1,111 @@
+// Package sqsqueue sends delayed messages to an SQS queue through the SQS JSON protocol
+// (AmazonSQS.SendMessage), signing requests with SigV4.
+package sqsqueue
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+)
+
+// MaxDelay is the longest delay SQS accepts on a message.
+const MaxDelay = 15 * time.Minute
+
+// Queue sends to one queue.
+type Queue struct {
+	queueURL    string
+	endpoint    string
+	region      string
+	credentials aws.CredentialsProvider
+	signer      *v4.Signer
+	httpClient  *http.Client
+}
+
+// New builds a queue sending to queueURL (https://sqs.<region>.amazonaws.com/<account>/<name>)
+// in region. Requests go to the queue URL's host.
+func New(queueURL, region string, credentials aws.CredentialsProvider, httpClient *http.Client) (*Queue, error) {
+	queueURL = strings.TrimSpace(queueURL)
+	if queueURL == "" {
+		return nil, errors.New("queue url is required")
+	}
+	if region == "" {
+		return nil, errors.New("region is required")
+	}
+	if credentials == nil {
+		return nil, errors.New("credentials are required")
+	}
+	u, err := url.Parse(queueURL)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid queue url %q", queueURL)
+	}
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 5 * time.Second}
+	}
+	return &Queue{
+		queueURL:    queueURL,
+		endpoint:    u.Scheme + "://" + u.Host + "/",
+		region:      region,
+		credentials: credentials,
+		signer:      v4.NewSigner(),
+		httpClient:  httpClient,
+	}, nil
+}
+
+type sendMessageInput struct {
+	QueueURL     string `json:"QueueUrl"`
+	MessageBody  string `json:"MessageBody"`
+	DelaySeconds int    `json:"DelaySeconds,omitempty"`
+}
+
+// SendDelayed enqueues body, hidden from consumers for delay rounded up to whole seconds and
+// capped at MaxDelay.
+func (q *Queue) SendDelayed(ctx context.Context, body []byte, delay time.Duration) error {
+	delay = min(max(delay, 0), MaxDelay)
+	payload, err := json.Marshal(sendMessageInput{
+		QueueURL:     q.queueURL,
+		MessageBody:  string(body),
+		DelaySeconds: int(math.Ceil(delay.Seconds())),
+	})
+	if err != nil {
+		return fmt.Errorf("encode send message request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, q.endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("build send message request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.0")
+	req.Header.Set("X-Amz-Target", "AmazonSQS.SendMessage")
+
+	creds, err := q.credentials.Retrieve(ctx)
+	if err != nil {
+		return fmt.Errorf("retrieve credentials: %w", err)
+	}
+	sum := sha256.Sum256(payload)
+	if err := q.signer.SignHTTP(ctx, creds, req, hex.EncodeToString(sum[:]), "sqs", q.region, time.Now()); err != nil {
+		return fmt.Errorf("sign send message request: %w", err)
+	}
+
+	resp, err := q.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("send message: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("send message: sqs returned %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package sqsqueue
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendDelayedSignsSendMessage(t *testing.T) {
+	var gotAuth, gotTarget, gotPath string
+	var got sendMessageInput
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		gotTarget = r.Header.Get("X-Amz-Target")
+		gotPath = r.URL.Path
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		_, _ = w.Write([]byte(`{"MessageId":"m-1"}`))
+	}))
+	defer srv.Close()
+
+	queue, err := New(srv.URL+"/123456789012/checks", "eu-west-1", credentials.NewStaticCredentialsProvider("AKID", "SECRET", ""), srv.Client())
+	require.NoError(t, err)
+	require.NoError(t, queue.SendDelayed(context.Background(), []byte(`{"ref":"r-1"}`), 14500*time.Millisecond))
+
+	require.Equal(t, "AmazonSQS.SendMessage", gotTarget)
+	require.Equal(t, "/", gotPath)
+	require.True(t, strings.HasPrefix(gotAuth, "AWS4-HMAC-SHA256 Credential=AKID/"))
+	require.Contains(t, gotAuth, "/eu-west-1/sqs/aws4_request")
+	require.Equal(t, srv.URL+"/123456789012/checks", got.QueueURL)
+	require.Equal(t, `{"ref":"r-1"}`, got.MessageBody)
+	require.Equal(t, 15, got.DelaySeconds)
+}
+
+func TestSendDelayedCapsDelayAndReportsErrors(t *testing.T) {
+	var got sendMessageInput
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		http.Error(w, `{"__type":"AccessDenied"}`, http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	queue, err := New(srv.URL+"/1/q", "eu-west-1", credentials.NewStaticCredentialsProvider("AKID", "SECRET", ""), srv.Client())
+	require.NoError(t, err)
+	err = queue.SendDelayed(context.Background(), []byte("x"), time.Hour)
+	require.ErrorContains(t, err, "sqs returned 400")
+	require.Equal(t, 900, got.DelaySeconds)
+
+	_, err = New("", "eu-west-1", credentials.NewStaticCredentialsProvider("AKID", "SECRET", ""), nil)
+	require.Error(t, err)
+}
@@ -0,0 +1,83 @@
+// Package ssmparam reads SSM Parameter Store values through the AWS Parameters and Secrets
+// Lambda Extension, which caches them inside the execution environment so a cold start does
+// not need the SSM SDK or a round-trip per value.
+package ssmparam
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const defaultPort = "2773"
+
+// Client reads parameters from the extension's local endpoint.
+type Client struct {
+	endpoint   string
+	token      string
+	httpClient *http.Client
+}
+
+// New builds a client for the extension listening at endpoint, authenticating with the
+// function's session token.
+func New(endpoint, token string, httpClient *http.Client) (*Client, error) {
+	endpoint = strings.TrimSuffix(strings.TrimSpace(endpoint), "/")
+	if endpoint == "" {
+		return nil, errors.New("extension endpoint is required")
+	}
+	if token == "" {
+		return nil, errors.New("session token is required")
+	}
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 5 * time.Second}
+	}
+	return &Client{endpoint: endpoint, token: token, httpClient: httpClient}, nil
+}
+
+// NewFromEnv builds a client for the extension in this Lambda execution environment, on
+// PARAMETERS_SECRETS_EXTENSION_HTTP_PORT (default 2773).
+func NewFromEnv(httpClient *http.Client) (*Client, error) {
+	port := strings.TrimSpace(os.Getenv("PARAMETERS_SECRETS_EXTENSION_HTTP_PORT"))
+	if port == "" {
+		port = defaultPort
+	}
+	return New("http://localhost:"+port, os.Getenv("AWS_SESSION_TOKEN"), httpClient)
+}
+
+// Get returns the decrypted value of the parameter name.
+func (c *Client) Get(ctx context.Context, name string) (string, error) {
+	u := c.endpoint + "/systemsmanager/parameters/get?" + url.Values{"name": {name}, "withDecryption": {"true"}}.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return "", fmt.Errorf("build parameter request: %w", err)
+	}
+	req.Header.Set("X-Aws-Parameters-Secrets-Token", c.token)
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("get parameter %s: %w", name, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", fmt.Errorf("read parameter %s: %w", name, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("get parameter %s: status %d: %s", name, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	var out struct {
+		Parameter struct {
+			Value string `json:"Value"`
+		} `json:"Parameter"`
+	}
+	if err := json.Unmarshal(body, &out); err != nil {
+		return "", fmt.Errorf("decode parameter %s: %w", name, err)
+	}
+	return out.Parameter.Value, nil
+}
@@ -0,0 +1,37 @@
+package ssmparam
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetReadsThroughExtension(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/systemsmanager/parameters/get", r.URL.Path)
+		require.Equal(t, "session", r.Header.Get("X-Aws-Parameters-Secrets-Token"))
+		require.Equal(t, "true", r.URL.Query().Get("withDecryption"))
+		if r.URL.Query().Get("name") != "/callbacks/ca" {
+			http.Error(w, "parameter not found", http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, `{"Parameter":{"Name":"/callbacks/ca","Value":"-----BEGIN CERTIFICATE-----"}}`)
+	}))
+	defer srv.Close()
+	c, err := New(srv.URL, "session", nil)
+	require.NoError(t, err)
+
+	value, err := c.Get(context.Background(), "/callbacks/ca")
+	require.NoError(t, err)
+	require.Equal(t, "-----BEGIN CERTIFICATE-----", value)
+
+	_, err = c.Get(context.Background(), "/missing")
+	require.ErrorContains(t, err, "status 400")
+
+	_, err = New(srv.URL, "", nil)
+	require.Error(t, err)
+}
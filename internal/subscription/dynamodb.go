@@ -0,0 +1,65 @@
+package subscription
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DynamoStore is a Store backed by a DynamoDB table with partition key "id" (S). Items use
+// the subscription's JSON field names.
+type DynamoStore struct {
+	client *dynamodb.Client
+	table  string
+}
+
+// NewDynamoStore builds a Store writing to table.
+func NewDynamoStore(client *dynamodb.Client, table string) (*DynamoStore, error) {
+	if client == nil {
+		return nil, errors.New("dynamodb client is required")
+	}
+	if table == "" {
+		return nil, errors.New("table is required")
+	}
+	return &DynamoStore{client: client, table: table}, nil
+}
+
+// Get returns the stored subscription or ErrNotFound.
+func (d *DynamoStore) Get(ctx context.Context, id string) (*Subscription, error) {
+	out, err := d.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(d.table),
+		Key:            map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: id}},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get subscription: %w", err)
+	}
+	if out.Item == nil {
+		return nil, ErrNotFound
+	}
+	var sub Subscription
+	if err := attributevalue.UnmarshalMapWithOptions(out.Item, &sub, func(o *attributevalue.DecoderOptions) { o.TagKey = "json" }); err != nil {
+		return nil, fmt.Errorf("decode subscription: %w", err)
+	}
+	return &sub, nil
+}
+
+// Put stores the subscription, replacing any previous version.
+func (d *DynamoStore) Put(ctx context.Context, sub *Subscription) error {
+	if sub == nil || sub.ID == "" {
+		return errors.New("subscription id is required")
+	}
+	item, err := attributevalue.MarshalMapWithOptions(sub, func(o *attributevalue.EncoderOptions) { o.TagKey = "json" })
+	if err != nil {
+		return fmt.Errorf("encode subscription: %w", err)
+	}
+	if _, err := d.client.PutItem(ctx, &dynamodb.PutItemInput{TableName: aws.String(d.table), Item: item}); err != nil {
+		return fmt.Errorf("put subscription: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package subscription
+
+import (
+	"context"
+	"time"
+)
+
+// EventType names a lifecycle change.
+type EventType string
+
+const (
+	EventCreated   EventType = "subscription.created"
+	EventActivated EventType = "subscription.activated"
+	EventRenewed   EventType = "subscription.renewed"
+	EventPastDue   EventType = "subscription.past_due"
+	EventCancelled EventType = "subscription.cancelled"
+)
+
+// Event describes a single lifecycle transition.
+type Event struct {
+	Type         EventType    `json:"type"`
+	From         Status       `json:"from,omitempty"`
+	To           Status       `json:"to"`
+	Subscription Subscription `json:"subscription"`
+	Reason       string       `json:"reason,omitempty"`
+	OccurredAt   time.Time    `json:"occurred_at"`
+}
+
+// EventSink receives lifecycle events after they have been persisted.
+type EventSink interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// EventSinkFunc adapts a function to the EventSink interface.
+type EventSinkFunc func(ctx context.Context, event Event) error
+
+// Publish calls f(ctx, event).
+func (f EventSinkFunc) Publish(ctx context.Context, event Event) error {
+	return f(ctx, event)
+}
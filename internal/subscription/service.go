@@ -0,0 +1,200 @@
+package subscription
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+// Service applies lifecycle transitions to stored subscriptions and emits events for each change.
+type Service struct {
+	store  Store
+	sink   EventSink
+	now    func() time.Time
+	logger *log.Logger
+}
+
+// ServiceOption customizes the Service.
+type ServiceOption func(*Service)
+
+// WithEventSink wires a destination for lifecycle events.
+func WithEventSink(sink EventSink) ServiceOption {
+	return func(s *Service) {
+		s.sink = sink
+	}
+}
+
+// WithClock overrides the time source, mainly for tests.
+func WithClock(now func() time.Time) ServiceOption {
+	return func(s *Service) {
+		if now != nil {
+			s.now = now
+		}
+	}
+}
+
+// WithServiceLogger lets callers supply a custom logger.
+func WithServiceLogger(l *log.Logger) ServiceOption {
+	return func(s *Service) {
+		if l != nil {
+			s.logger = l
+		}
+	}
+}
+
+// NewService builds a Service around the given store.
+func NewService(store Store, opts ...ServiceOption) *Service {
+	s := &Service{
+		store:  store,
+		now:    time.Now,
+		logger: log.New(os.Stdout, "paypack-lambda ", log.LstdFlags),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Create stores a new subscription. Subscriptions without an explicit status start as trialing.
+func (s *Service) Create(ctx context.Context, sub Subscription) (*Subscription, error) {
+	if sub.ID == "" {
+		return nil, errors.New("subscription id is required")
+	}
+	if sub.Status == "" {
+		sub.Status = StatusTrialing
+	}
+	if !sub.Status.Valid() || sub.Status == StatusCancelled {
+		return nil, fmt.Errorf("cannot create subscription with status %q", sub.Status)
+	}
+
+	if _, err := s.store.Get(ctx, sub.ID); err == nil {
+		return nil, fmt.Errorf("subscription %s already exists", sub.ID)
+	} else if !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+
+	now := s.now()
+	sub.CreatedAt = now
+	sub.UpdatedAt = now
+	if sub.Status == StatusActive {
+		sub.ActivatedAt = now
+	}
+
+	if err := s.store.Put(ctx, &sub); err != nil {
+		return nil, fmt.Errorf("store subscription: %w", err)
+	}
+
+	s.publish(ctx, Event{Type: EventCreated, To: sub.Status, Subscription: sub, OccurredAt: now})
+	return &sub, nil
+}
+
+// Activate records a successful payment. Repeated activations of an active subscription count as renewals.
+func (s *Service) Activate(ctx context.Context, id, ref string) (*Subscription, error) {
+	return s.apply(ctx, id, StatusActive, "", func(sub *Subscription) {
+		sub.LastRef = ref
+		sub.PaymentLink = nil
+	})
+}
+
+// MarkPastDue records a failed payment attempt.
+func (s *Service) MarkPastDue(ctx context.Context, id, ref, reason string) (*Subscription, error) {
+	return s.apply(ctx, id, StatusPastDue, reason, func(sub *Subscription) {
+		if ref != "" {
+			sub.LastRef = ref
+		}
+		sub.FailureReason = reason
+	})
+}
+
+// Cancel ends the subscription. Cancelled subscriptions cannot transition further.
+func (s *Service) Cancel(ctx context.Context, id, reason string) (*Subscription, error) {
+	return s.apply(ctx, id, StatusCancelled, reason, nil)
+}
+
+// AttachPaymentLink records link as the subscription's outstanding payment link, replacing
+// any previous one. It does not change the status.
+func (s *Service) AttachPaymentLink(ctx context.Context, id string, link PaymentLink) (*Subscription, error) {
+	sub, err := s.store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if sub.Status == StatusCancelled {
+		return nil, fmt.Errorf("%w: cannot attach payment link to cancelled subscription", ErrInvalidTransition)
+	}
+
+	now := s.now()
+	if link.CreatedAt.IsZero() {
+		link.CreatedAt = now
+	}
+	sub.PaymentLink = &link
+	sub.UpdatedAt = now
+	if err := s.store.Put(ctx, sub); err != nil {
+		return nil, fmt.Errorf("store subscription: %w", err)
+	}
+	return sub, nil
+}
+
+// Get returns the stored subscription.
+func (s *Service) Get(ctx context.Context, id string) (*Subscription, error) {
+	return s.store.Get(ctx, id)
+}
+
+func (s *Service) apply(ctx context.Context, id string, to Status, reason string, mutate func(*Subscription)) (*Subscription, error) {
+	sub, err := s.store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	from := sub.Status
+	now := s.now()
+	if err := sub.transition(to, now); err != nil {
+		return nil, err
+	}
+	if mutate != nil {
+		mutate(sub)
+	}
+
+	if err := s.store.Put(ctx, sub); err != nil {
+		return nil, fmt.Errorf("store subscription: %w", err)
+	}
+
+	s.publish(ctx, Event{
+		Type:         eventTypeFor(from, to),
+		From:         from,
+		To:           to,
+		Subscription: *sub,
+		Reason:       reason,
+		OccurredAt:   now,
+	})
+	return sub, nil
+}
+
+func (s *Service) publish(ctx context.Context, event Event) {
+	if s.sink == nil {
+		return
+	}
+	// The stored state is the source of truth, so sink failures are logged rather than rolled back.
+	if err := s.sink.Publish(ctx, event); err != nil {
+		s.logger.Printf("subscription event %s for %s not published: %v", event.Type, event.Subscription.ID, err)
+	}
+}
+
+func eventTypeFor(from, to Status) EventType {
+	switch to {
+	case StatusActive:
+		if from == StatusActive {
+			return EventRenewed
+		}
+		return EventActivated
+	case StatusPastDue:
+		return EventPastDue
+	case StatusCancelled:
+		return EventCancelled
+	}
+	return EventType("subscription." + string(to))
+}
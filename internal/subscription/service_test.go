@@ -0,0 +1,94 @@
+package subscription
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type recordingSink struct {
+	events []Event
+}
+
+func (r *recordingSink) Publish(ctx context.Context, event Event) error {
+	r.events = append(r.events, event)
+	return nil
+}
+
+func TestServiceLifecycle(t *testing.T) {
+	ctx := context.Background()
+	sink := &recordingSink{}
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	svc := NewService(NewMemoryStore(), WithEventSink(sink), WithClock(func() time.Time { return now }))
+
+	sub, err := svc.Create(ctx, Subscription{ID: "sub-1", Number: "2507", Amount: 1000})
+	require.NoError(t, err)
+	require.Equal(t, StatusTrialing, sub.Status)
+
+	sub, err = svc.Activate(ctx, "sub-1", "ref-1")
+	require.NoError(t, err)
+	require.Equal(t, StatusActive, sub.Status)
+	require.Equal(t, "ref-1", sub.LastRef)
+
+	sub, err = svc.Activate(ctx, "sub-1", "ref-2")
+	require.NoError(t, err)
+	require.Equal(t, StatusActive, sub.Status)
+
+	sub, err = svc.MarkPastDue(ctx, "sub-1", "ref-3", "insufficient funds")
+	require.NoError(t, err)
+	require.Equal(t, StatusPastDue, sub.Status)
+	require.Equal(t, "insufficient funds", sub.FailureReason)
+
+	sub, err = svc.Cancel(ctx, "sub-1", "unpaid")
+	require.NoError(t, err)
+	require.Equal(t, StatusCancelled, sub.Status)
+	require.Equal(t, now, sub.CancelledAt)
+
+	types := make([]EventType, 0, len(sink.events))
+	for _, ev := range sink.events {
+		types = append(types, ev.Type)
+	}
+	require.Equal(t, []EventType{EventCreated, EventActivated, EventRenewed, EventPastDue, EventCancelled}, types)
+	require.Equal(t, StatusPastDue, sink.events[4].From)
+}
+
+func TestServiceRejectsTransitionFromCancelled(t *testing.T) {
+	ctx := context.Background()
+	svc := NewService(NewMemoryStore())
+
+	_, err := svc.Create(ctx, Subscription{ID: "sub-1", Status: StatusActive})
+	require.NoError(t, err)
+	_, err = svc.Cancel(ctx, "sub-1", "")
+	require.NoError(t, err)
+
+	_, err = svc.Activate(ctx, "sub-1", "ref")
+	require.ErrorIs(t, err, ErrInvalidTransition)
+
+	_, err = svc.Activate(ctx, "missing", "ref")
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestServicePaymentLinkClearedOnActivation(t *testing.T) {
+	ctx := context.Background()
+	svc := NewService(NewMemoryStore())
+
+	_, err := svc.Create(ctx, Subscription{ID: "sub-1", Number: "2507", Amount: 1000})
+	require.NoError(t, err)
+
+	sub, err := svc.AttachPaymentLink(ctx, "sub-1", PaymentLink{ID: "lnk-1", URL: "https://pay.example/lnk-1", Amount: 1000})
+	require.NoError(t, err)
+	require.Equal(t, StatusTrialing, sub.Status)
+	require.Equal(t, "lnk-1", sub.PaymentLink.ID)
+	require.False(t, sub.PaymentLink.CreatedAt.IsZero())
+
+	sub, err = svc.Activate(ctx, "sub-1", "ref-1")
+	require.NoError(t, err)
+	require.Nil(t, sub.PaymentLink)
+
+	_, err = svc.Cancel(ctx, "sub-1", "")
+	require.NoError(t, err)
+	_, err = svc.AttachPaymentLink(ctx, "sub-1", PaymentLink{ID: "lnk-2"})
+	require.ErrorIs(t, err, ErrInvalidTransition)
+}
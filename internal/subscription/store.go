@@ -0,0 +1,52 @@
+package subscription
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrNotFound marks a lookup for a subscription that does not exist.
+var ErrNotFound = errors.New("subscription not found")
+
+// Store persists subscriptions.
+type Store interface {
+	Get(ctx context.Context, id string) (*Subscription, error)
+	Put(ctx context.Context, sub *Subscription) error
+}
+
+// MemoryStore holds subscriptions in a map, for testing Service's lifecycle rules.
+type MemoryStore struct {
+	mu   sync.RWMutex
+	subs map[string]Subscription
+}
+
+// NewMemoryStore builds an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{subs: make(map[string]Subscription)}
+}
+
+// Get returns a copy of the stored subscription or ErrNotFound.
+func (m *MemoryStore) Get(ctx context.Context, id string) (*Subscription, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	sub, ok := m.subs[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &sub, nil
+}
+
+// Put stores a copy of the subscription, replacing any previous version.
+func (m *MemoryStore) Put(ctx context.Context, sub *Subscription) error {
+	if sub == nil || sub.ID == "" {
+		return errors.New("subscription id is required")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.subs[sub.ID] = *sub
+	return nil
+}
@@ -0,0 +1,89 @@
+package subscription
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Status captures where a subscription sits in its billing lifecycle.
+type Status string
+
+const (
+	StatusTrialing  Status = "trialing"
+	StatusActive    Status = "active"
+	StatusPastDue   Status = "past_due"
+	StatusCancelled Status = "cancelled"
+)
+
+// ErrInvalidTransition is returned when a lifecycle change is not allowed from the current status.
+var ErrInvalidTransition = errors.New("invalid subscription transition")
+
+// transitions lists the statuses reachable from each status.
+var transitions = map[Status][]Status{
+	StatusTrialing:  {StatusActive, StatusPastDue, StatusCancelled},
+	StatusActive:    {StatusActive, StatusPastDue, StatusCancelled},
+	StatusPastDue:   {StatusActive, StatusPastDue, StatusCancelled},
+	StatusCancelled: {},
+}
+
+// Subscription is the billing entity tracked across payment attempts.
+type Subscription struct {
+	ID            string         `json:"id"`
+	Number        string         `json:"number"`
+	Client        string         `json:"client,omitempty"`
+	Plan          string         `json:"plan,omitempty"`
+	Amount        float64        `json:"amount"`
+	Status        Status         `json:"status"`
+	LastRef       string         `json:"last_ref,omitempty"`
+	FailureReason string         `json:"failure_reason,omitempty"`
+	Metadata      map[string]any `json:"metadata,omitempty"`
+	PaymentLink   *PaymentLink   `json:"payment_link,omitempty"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	ActivatedAt   time.Time      `json:"activated_at,omitempty"`
+	CancelledAt   time.Time      `json:"cancelled_at,omitempty"`
+}
+
+// PaymentLink is an outstanding hosted checkout link the customer can pay instead of
+// approving a USSD push. It is cleared once the subscription is activated.
+type PaymentLink struct {
+	ID        string    `json:"id"`
+	URL       string    `json:"url"`
+	Amount    float64   `json:"amount"`
+	Currency  string    `json:"currency,omitempty"`
+	ExpiresAt time.Time `json:"expires_at,omitzero"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CanTransition reports whether a subscription in status from may move to status to.
+func CanTransition(from, to Status) bool {
+	for _, allowed := range transitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Valid reports whether s is a known status.
+func (s Status) Valid() bool {
+	_, ok := transitions[s]
+	return ok
+}
+
+func (s *Subscription) transition(to Status, now time.Time) error {
+	if !CanTransition(s.Status, to) {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, s.Status, to)
+	}
+	s.Status = to
+	s.UpdatedAt = now
+	switch to {
+	case StatusActive:
+		s.ActivatedAt = now
+		s.FailureReason = ""
+	case StatusCancelled:
+		s.CancelledAt = now
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package tax
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/berniyo/paypack-lambda/internal/money"
+)
+
+// Rule describes a single tax applied to a charge. Rate is a percentage (18 for 18%).
+// Inclusive rules treat the charged amount as gross; exclusive rules add the tax on top.
+type Rule struct {
+	Name      string  `json:"name"`
+	Rate      float64 `json:"rate"`
+	Inclusive bool    `json:"inclusive"`
+}
+
+// Breakdown is the tax line for one charge.
+type Breakdown struct {
+	Name      string  `json:"name"`
+	Rate      float64 `json:"rate"`
+	Inclusive bool    `json:"inclusive"`
+	Net       float64 `json:"net"`
+	Tax       float64 `json:"tax"`
+	Gross     float64 `json:"gross"`
+}
+
+// Apply computes the breakdown for amount in currency. For inclusive rules amount is the
+// gross; for exclusive rules it is the net and Gross is what must be charged.
+func (r Rule) Apply(amount float64, currency string) Breakdown {
+	b := Breakdown{Name: r.Name, Rate: r.Rate, Inclusive: r.Inclusive}
+	rate := r.Rate / 100
+	if r.Inclusive {
+		b.Gross = amount
+		b.Net = money.Round(amount/(1+rate), currency)
+		b.Tax = money.Round(b.Gross-b.Net, currency)
+	} else {
+		b.Net = amount
+		b.Tax = money.Round(amount*rate, currency)
+		b.Gross = money.Round(b.Net+b.Tax, currency)
+	}
+	return b
+}
+
+// Table selects a rule per plan, falling back to Default.
+type Table struct {
+	Default *Rule           `json:"default,omitempty"`
+	Plans   map[string]Rule `json:"plans,omitempty"`
+}
+
+// RwandaVAT is the standard 18% VAT applied inclusively, as required on RRA invoices.
+func RwandaVAT() *Table {
+	return &Table{Default: &Rule{Name: "VAT", Rate: 18, Inclusive: true}}
+}
+
+// ParseTable decodes a JSON tax table, e.g.
+//
+//	{"default": {"name": "VAT", "rate": 18, "inclusive": true},
+//	 "plans": {"export": {"name": "VAT", "rate": 0, "inclusive": true}}}
+func ParseTable(data []byte) (*Table, error) {
+	var t Table
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("decode tax table: %w", err)
+	}
+	if t.Default == nil && len(t.Plans) == 0 {
+		return nil, errors.New("tax table has no rules")
+	}
+	for name, r := range t.Plans {
+		if r.Rate < 0 {
+			return nil, fmt.Errorf("plan %s: tax rate must not be negative", name)
+		}
+	}
+	if t.Default != nil && t.Default.Rate < 0 {
+		return nil, errors.New("default tax rate must not be negative")
+	}
+	return &t, nil
+}
+
+// RuleFor returns the rule for plan, or false when neither the plan nor a default applies.
+func (t *Table) RuleFor(plan string) (Rule, bool) {
+	if r, ok := t.Plans[plan]; ok {
+		return r, true
+	}
+	if t.Default != nil {
+		return *t.Default, true
+	}
+	return Rule{}, false
+}
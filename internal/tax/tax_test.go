@@ -0,0 +1,36 @@
+package tax
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRuleApply(t *testing.T) {
+	inclusive := Rule{Name: "VAT", Rate: 18, Inclusive: true}.Apply(1180, "RWF")
+	require.Equal(t, Breakdown{Name: "VAT", Rate: 18, Inclusive: true, Net: 1000, Tax: 180, Gross: 1180}, inclusive)
+
+	exclusive := Rule{Name: "VAT", Rate: 18}.Apply(1000, "RWF")
+	require.Equal(t, Breakdown{Name: "VAT", Rate: 18, Net: 1000, Tax: 180, Gross: 1180}, exclusive)
+
+	require.Equal(t, Breakdown{Name: "VAT", Rate: 18, Inclusive: true, Net: 847, Tax: 153, Gross: 1000},
+		Rule{Name: "VAT", Rate: 18, Inclusive: true}.Apply(1000, "RWF"))
+	require.Equal(t, Breakdown{Name: "VAT", Rate: 18, Inclusive: true, Net: 847.46, Tax: 152.54, Gross: 1000},
+		Rule{Name: "VAT", Rate: 18, Inclusive: true}.Apply(1000, "USD"))
+}
+
+func TestTableRuleFor(t *testing.T) {
+	table, err := ParseTable([]byte(`{"default":{"name":"VAT","rate":18,"inclusive":true},"plans":{"export":{"name":"VAT","rate":0}}}`))
+	require.NoError(t, err)
+
+	r, ok := table.RuleFor("export")
+	require.True(t, ok)
+	require.Equal(t, 0.0, r.Rate)
+
+	r, ok = table.RuleFor("pro")
+	require.True(t, ok)
+	require.Equal(t, 18.0, r.Rate)
+
+	_, err = ParseTable([]byte(`{"plans":{"x":{"rate":-1}}}`))
+	require.Error(t, err)
+}
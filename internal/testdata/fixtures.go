@@ -0,0 +1,88 @@
+// Package testdata holds canonical Paypack payloads and golden-file helpers for tests, so new
+// features are exercised against the bodies Paypack really sends instead of minimal inline
+// JSON. Every payload describes the same cash-in (Ref, from Number) at a different stage.
+// Only _test.go files should import it.
+package testdata
+
+import (
+	"embed"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+//go:embed paypack/*.json
+var payloads embed.FS
+
+// The cash-in every payload describes.
+const (
+	Ref    = "d0c3a9f1-6e2b-4f7a-9c1d-2b8e5f4a7c60"
+	Number = "250788123123"
+	Amount = 1000.0
+	Fee    = 23.0
+)
+
+// Payload names.
+const (
+	// Auth is the authorize response.
+	Auth = "auth"
+	// CashInPending is the response to a cash-in request.
+	CashInPending = "cashin_pending"
+	// TransactionPending, TransactionSuccess and TransactionFailed are find responses.
+	TransactionPending = "transaction_pending"
+	TransactionSuccess = "transaction_success"
+	TransactionFailed  = "transaction_failed"
+	// NotFound is the find response for a ref Paypack does not know yet.
+	NotFound = "not_found"
+	// Malformed is a find response cut off mid-body.
+	Malformed = "malformed"
+	// WebhookSuccess and WebhookFailed are webhook deliveries; their status wording is
+	// Paypack's ("successful"), not the responses'.
+	WebhookSuccess = "webhook_success"
+	WebhookFailed  = "webhook_failed"
+)
+
+// Payload returns the named payload's body.
+func Payload(tb testing.TB, name string) []byte {
+	tb.Helper()
+	body, err := payloads.ReadFile("paypack/" + name + ".json")
+	if err != nil {
+		tb.Fatalf("testdata: unknown payload %q: %v", name, err)
+	}
+	return body
+}
+
+// Transaction decodes the named payload as a transaction.
+func Transaction(tb testing.TB, name string) paypack.Transaction {
+	tb.Helper()
+	var txn paypack.Transaction
+	decode(tb, name, &txn)
+	return txn
+}
+
+// Webhook decodes the named payload as a webhook delivery.
+func Webhook(tb testing.TB, name string) paypack.WebhookEvent {
+	tb.Helper()
+	var event paypack.WebhookEvent
+	decode(tb, name, &event)
+	return event
+}
+
+// Respond writes the named payload as a JSON response with status, for httptest handlers.
+func Respond(tb testing.TB, w http.ResponseWriter, status int, name string) {
+	tb.Helper()
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if _, err := w.Write(Payload(tb, name)); err != nil {
+		tb.Errorf("testdata: write %s: %v", name, err)
+	}
+}
+
+func decode(tb testing.TB, name string, v any) {
+	tb.Helper()
+	if err := json.Unmarshal(Payload(tb, name), v); err != nil {
+		tb.Fatalf("testdata: decode %s: %v", name, err)
+	}
+}
@@ -0,0 +1,48 @@
+package testdata
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// UpdateEnv, set to 1, makes Golden and GoldenJSON rewrite golden files with the values under
+// test instead of comparing against them:
+//
+//	UPDATE_GOLDEN=1 go test ./internal/handler -run Golden
+const UpdateEnv = "UPDATE_GOLDEN"
+
+// Golden compares got with testdata/<name>.golden in the calling test's package directory.
+func Golden(tb testing.TB, name string, got []byte) {
+	tb.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if os.Getenv(UpdateEnv) == "1" {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			tb.Fatalf("testdata: create golden dir: %v", err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			tb.Fatalf("testdata: write golden %s: %v", path, err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		tb.Fatalf("testdata: read golden %s (run with %s=1 to create it): %v", path, UpdateEnv, err)
+	}
+	if !bytes.Equal(got, want) {
+		tb.Errorf("testdata: %s differs (run with %s=1 to update)\n--- got\n%s\n--- want\n%s", path, UpdateEnv, got, want)
+	}
+}
+
+// GoldenJSON encodes v as indented JSON and compares it with golden name, so diffs read line
+// by line.
+func GoldenJSON(tb testing.TB, name string, v any) {
+	tb.Helper()
+	got, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		tb.Fatalf("testdata: encode %s: %v", name, err)
+	}
+	Golden(tb, name, append(got, '\n'))
+}
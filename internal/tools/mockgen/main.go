@@ -0,0 +1,370 @@
+// Command mockgen writes moq-style mocks for interfaces declared in one package: each mock has
+// a <Method>Func field per method, records its calls, and panics when an unset method is
+// called. It only needs the standard library, so `go generate ./...` works offline.
+//
+//	go run ./internal/tools/mockgen -src internal/handler -out internal/mocks/handler.go PaymentClient CallbackSender
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+func main() {
+	src := flag.String("src", "", "directory of the package declaring the interfaces")
+	out := flag.String("out", "", "file to write")
+	pkg := flag.String("pkg", "mocks", "package name of the generated file")
+	prefix := flag.String("prefix", "", "prefix for mock type names, e.g. Checkpoint for CheckpointStoreMock")
+	flag.Parse()
+	if *src == "" || *out == "" || flag.NArg() == 0 {
+		log.Fatal("usage: mockgen -src dir -out file [-pkg name] [-prefix Name] Interface...")
+	}
+
+	code, err := generate(*src, *pkg, *prefix, flag.Args())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*out, code, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// source is the parsed package the interfaces come from.
+type source struct {
+	fset       *token.FileSet
+	name       string
+	importPath string
+	types      map[string]bool
+	interfaces map[string]*ast.InterfaceType
+	imports    map[string]map[string]string // interface name -> local import name -> path
+}
+
+func load(dir string) (*source, error) {
+	importPath, err := importPathOf(dir)
+	if err != nil {
+		return nil, err
+	}
+	matches, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+
+	src := &source{
+		fset:       token.NewFileSet(),
+		importPath: importPath,
+		types:      make(map[string]bool),
+		interfaces: make(map[string]*ast.InterfaceType),
+		imports:    make(map[string]map[string]string),
+	}
+	for _, file := range matches {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(src.fset, file, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+		src.name = f.Name.Name
+
+		fileImports := make(map[string]string)
+		for _, spec := range f.Imports {
+			p, _ := strconv.Unquote(spec.Path.Value)
+			name := path.Base(p)
+			if spec.Name != nil {
+				name = spec.Name.Name
+			}
+			fileImports[name] = p
+		}
+		for _, decl := range f.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				ts := spec.(*ast.TypeSpec)
+				src.types[ts.Name.Name] = true
+				if iface, ok := ts.Type.(*ast.InterfaceType); ok {
+					src.interfaces[ts.Name.Name] = iface
+					src.imports[ts.Name.Name] = fileImports
+				}
+			}
+		}
+	}
+	if src.name == "" {
+		return nil, fmt.Errorf("no Go files in %s", dir)
+	}
+	return src, nil
+}
+
+// importPathOf derives dir's import path from the enclosing go.mod.
+func importPathOf(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for root := abs; ; root = filepath.Dir(root) {
+		data, err := os.ReadFile(filepath.Join(root, "go.mod"))
+		if err == nil {
+			for _, line := range strings.Split(string(data), "\n") {
+				if module, ok := strings.CutPrefix(strings.TrimSpace(line), "module "); ok {
+					rel, err := filepath.Rel(root, abs)
+					if err != nil {
+						return "", err
+					}
+					return path.Join(strings.TrimSpace(module), filepath.ToSlash(rel)), nil
+				}
+			}
+			return "", fmt.Errorf("%s/go.mod has no module line", root)
+		}
+		if filepath.Dir(root) == root {
+			return "", errors.New("no go.mod found above " + dir)
+		}
+	}
+}
+
+type method struct {
+	name    string
+	params  []param
+	results string
+}
+
+type param struct {
+	name     string
+	typ      string // as declared, "...T" for variadic
+	variadic bool
+}
+
+func generate(dir, pkg, prefix string, names []string) ([]byte, error) {
+	src, err := load(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	used := map[string]string{"sync": "sync", src.name: src.importPath}
+	var body bytes.Buffer
+	for _, name := range names {
+		iface, ok := src.interfaces[name]
+		if !ok {
+			return nil, fmt.Errorf("interface %s not found in %s", name, dir)
+		}
+		q := qualifier{src: src, imports: src.imports[name], used: used}
+		methods, err := q.methods(iface)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		writeMock(&body, src.name, name, prefix+name+"Mock", methods)
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by internal/tools/mockgen. DO NOT EDIT.\n\npackage %s\n\nimport (\n", pkg)
+	locals := make([]string, 0, len(used))
+	for local := range used {
+		locals = append(locals, local)
+	}
+	sort.Slice(locals, func(i, j int) bool { return used[locals[i]] < used[locals[j]] })
+	// Standard library imports first, then everything else, as goimports groups them.
+	for _, std := range []bool{true, false} {
+		if !std {
+			out.WriteString("\n")
+		}
+		for _, local := range locals {
+			p := used[local]
+			if isStd(p) != std {
+				continue
+			}
+			if path.Base(p) == local {
+				fmt.Fprintf(&out, "\t%q\n", p)
+			} else {
+				fmt.Fprintf(&out, "\t%s %q\n", local, p)
+			}
+		}
+	}
+	out.WriteString(")\n")
+	out.Write(body.Bytes())
+	return format.Source(out.Bytes())
+}
+
+// qualifier renders type expressions as seen from the mocks package.
+type qualifier struct {
+	src     *source
+	imports map[string]string
+	used    map[string]string
+}
+
+func (q qualifier) methods(iface *ast.InterfaceType) ([]method, error) {
+	var methods []method
+	for _, field := range iface.Methods.List {
+		fn, ok := field.Type.(*ast.FuncType)
+		if !ok || len(field.Names) == 0 {
+			return nil, errors.New("embedded interfaces are not supported")
+		}
+		m := method{name: field.Names[0].Name}
+		n := 0
+		for _, p := range fn.Params.List {
+			typ, variadic := p.Type, false
+			if ell, ok := typ.(*ast.Ellipsis); ok {
+				typ, variadic = ell.Elt, true
+			}
+			rendered := q.render(typ)
+			names := p.Names
+			if len(names) == 0 {
+				names = []*ast.Ident{ast.NewIdent(fmt.Sprintf("p%d", n))}
+			}
+			for _, id := range names {
+				m.params = append(m.params, param{name: id.Name, typ: rendered, variadic: variadic})
+				n++
+			}
+		}
+		if fn.Results != nil {
+			var results []string
+			for _, r := range fn.Results.List {
+				count := max(len(r.Names), 1)
+				for range count {
+					results = append(results, q.render(r.Type))
+				}
+			}
+			m.results = strings.Join(results, ", ")
+			if len(results) > 1 {
+				m.results = "(" + m.results + ")"
+			}
+		}
+		methods = append(methods, m)
+	}
+	return methods, nil
+}
+
+func (q qualifier) render(expr ast.Expr) string {
+	var b bytes.Buffer
+	printer.Fprint(&b, q.src.fset, q.qualify(expr))
+	return b.String()
+}
+
+// qualify rewrites identifiers declared in the source package as pkg.Name and notes the
+// imports the rendered types need.
+func (q qualifier) qualify(expr ast.Expr) ast.Expr {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		if q.src.types[e.Name] {
+			return &ast.SelectorExpr{X: ast.NewIdent(q.src.name), Sel: ast.NewIdent(e.Name)}
+		}
+		return e
+	case *ast.SelectorExpr:
+		if x, ok := e.X.(*ast.Ident); ok {
+			if p, ok := q.imports[x.Name]; ok {
+				q.used[x.Name] = p
+			}
+		}
+		return e
+	case *ast.StarExpr:
+		return &ast.StarExpr{X: q.qualify(e.X)}
+	case *ast.ArrayType:
+		return &ast.ArrayType{Len: e.Len, Elt: q.qualify(e.Elt)}
+	case *ast.MapType:
+		return &ast.MapType{Key: q.qualify(e.Key), Value: q.qualify(e.Value)}
+	case *ast.ChanType:
+		return &ast.ChanType{Dir: e.Dir, Value: q.qualify(e.Value)}
+	case *ast.Ellipsis:
+		return &ast.Ellipsis{Elt: q.qualify(e.Elt)}
+	case *ast.FuncType:
+		return &ast.FuncType{Params: q.fields(e.Params), Results: q.fields(e.Results)}
+	default:
+		return e
+	}
+}
+
+func (q qualifier) fields(list *ast.FieldList) *ast.FieldList {
+	if list == nil {
+		return nil
+	}
+	out := &ast.FieldList{}
+	for _, f := range list.List {
+		out.List = append(out.List, &ast.Field{Names: f.Names, Type: q.qualify(f.Type)})
+	}
+	return out
+}
+
+func writeMock(w *bytes.Buffer, pkg, iface, mock string, methods []method) {
+	fmt.Fprintf(w, "\n// %s is a mock of %s.%s. Set the Func field of each method a test calls;\n", mock, pkg, iface)
+	fmt.Fprintf(w, "// calling a method whose Func is nil panics. Calls are recorded for the Calls accessors.\n")
+	fmt.Fprintf(w, "type %s struct {\n", mock)
+	for _, m := range methods {
+		fmt.Fprintf(w, "\t// %sFunc mocks the %s method.\n\t%sFunc func(%s) %s\n\n", m.name, m.name, m.name, signature(m.params), m.results)
+	}
+	fmt.Fprintf(w, "\tmu sync.Mutex\n\tcalls struct {\n")
+	for _, m := range methods {
+		fmt.Fprintf(w, "\t\t%s []%s%sCall\n", m.name, mock, m.name)
+	}
+	fmt.Fprintf(w, "\t}\n}\n\nvar _ %s.%s = (*%s)(nil)\n", pkg, iface, mock)
+
+	for _, m := range methods {
+		call := mock + m.name + "Call"
+		fmt.Fprintf(w, "\n// %s records one call to %s.%s.\ntype %s struct {\n", call, mock, m.name, call)
+		for _, p := range m.params {
+			typ := p.typ
+			if p.variadic {
+				typ = "[]" + typ
+			}
+			fmt.Fprintf(w, "\t%s %s\n", exported(p.name), typ)
+		}
+		fmt.Fprintf(w, "}\n")
+
+		var fields, args []string
+		for _, p := range m.params {
+			fields = append(fields, exported(p.name)+": "+p.name)
+			arg := p.name
+			if p.variadic {
+				arg += "..."
+			}
+			args = append(args, arg)
+		}
+		ret := "return "
+		if m.results == "" {
+			ret = ""
+		}
+		fmt.Fprintf(w, "\n// %s calls %sFunc.\nfunc (mock *%s) %s(%s) %s {\n", m.name, m.name, mock, m.name, signature(m.params), m.results)
+		fmt.Fprintf(w, "\tif mock.%sFunc == nil {\n\t\tpanic(\"%s.%sFunc: method is nil but %s.%s was just called\")\n\t}\n", m.name, mock, m.name, iface, m.name)
+		fmt.Fprintf(w, "\tmock.mu.Lock()\n\tmock.calls.%s = append(mock.calls.%s, %s{%s})\n\tmock.mu.Unlock()\n", m.name, m.name, call, strings.Join(fields, ", "))
+		fmt.Fprintf(w, "\t%smock.%sFunc(%s)\n}\n", ret, m.name, strings.Join(args, ", "))
+
+		fmt.Fprintf(w, "\n// %sCalls returns the calls made to %s so far.\nfunc (mock *%s) %sCalls() []%s {\n", m.name, m.name, mock, m.name, call)
+		fmt.Fprintf(w, "\tmock.mu.Lock()\n\tdefer mock.mu.Unlock()\n\treturn append([]%s(nil), mock.calls.%s...)\n}\n", call, m.name)
+	}
+}
+
+func signature(params []param) string {
+	parts := make([]string, len(params))
+	for i, p := range params {
+		typ := p.typ
+		if p.variadic {
+			typ = "..." + typ
+		}
+		parts[i] = p.name + " " + typ
+	}
+	return strings.Join(parts, ", ")
+}
+
+func exported(name string) string {
+	r := []rune(name)
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
+
+func isStd(importPath string) bool {
+	first, _, _ := strings.Cut(importPath, "/")
+	return !strings.Contains(first, ".")
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestCommittedMocksAreCurrent fails when an interface changed without `go generate ./internal/mocks`.
+func TestCommittedMocksAreCurrent(t *testing.T) {
+	data, err := os.ReadFile("../../mocks/generate.go")
+	require.NoError(t, err)
+
+	found := 0
+	for _, line := range strings.Split(string(data), "\n") {
+		directive, ok := strings.CutPrefix(line, "//go:generate go run ../tools/mockgen ")
+		if !ok {
+			continue
+		}
+		found++
+		fields := strings.Fields(directive)
+		var src, out, prefix string
+		var names []string
+		for i := 0; i < len(fields); i++ {
+			switch fields[i] {
+			case "-src":
+				i++
+				src = fields[i]
+			case "-out":
+				i++
+				out = fields[i]
+			case "-prefix":
+				i++
+				prefix = fields[i]
+			default:
+				names = append(names, fields[i])
+			}
+		}
+
+		want, err := generate(filepath.Join("../../mocks", src), "mocks", prefix, names)
+		require.NoError(t, err, directive)
+		got, err := os.ReadFile(filepath.Join("../../mocks", out))
+		require.NoError(t, err)
+		require.Equal(t, string(want), string(got), "%s is stale; run go generate ./internal/mocks", out)
+	}
+	require.NotZero(t, found)
+}
+
+func TestGenerateRejectsUnknownInterface(t *testing.T) {
+	_, err := generate("../../txcache", "mocks", "", []string{"Missing"})
+	require.ErrorContains(t, err, "interface Missing not found")
+}
@@ -0,0 +1,153 @@
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+)
+
+// Header names defined by W3C Trace Context.
+const (
+	HeaderTraceParent = "traceparent"
+	HeaderTraceState  = "tracestate"
+)
+
+// lambdaTraceKey is the context key the Lambda runtime stores the X-Ray trace header under.
+const lambdaTraceKey = "x-amzn-trace-id"
+
+// Context is the W3C trace context of the current unit of work.
+type Context struct {
+	TraceID  string
+	ParentID string
+	Sampled  bool
+	State    string
+}
+
+// TraceParent renders the traceparent header value.
+func (c Context) TraceParent() string {
+	flags := "00"
+	if c.Sampled {
+		flags = "01"
+	}
+	return "00-" + c.TraceID + "-" + c.ParentID + "-" + flags
+}
+
+// Child keeps the trace and state but names a new span as the parent of outgoing calls.
+func (c Context) Child() Context {
+	c.ParentID = randomHex(8)
+	return c
+}
+
+// New starts a fresh sampled trace.
+func New() Context {
+	return Context{TraceID: randomHex(16), ParentID: randomHex(8), Sampled: true}
+}
+
+// Parse reads traceparent and tracestate header values. Only version 00 is understood.
+func Parse(traceparent, tracestate string) (Context, bool) {
+	parts := strings.Split(strings.TrimSpace(traceparent), "-")
+	if len(parts) != 4 || parts[0] != "00" || !isHex(parts[1], 32) || !isHex(parts[2], 16) || !isHex(parts[3], 2) {
+		return Context{}, false
+	}
+	if strings.Trim(parts[1], "0") == "" || strings.Trim(parts[2], "0") == "" {
+		return Context{}, false
+	}
+	flags, _ := hex.DecodeString(parts[3])
+	return Context{
+		TraceID:  parts[1],
+		ParentID: parts[2],
+		Sampled:  flags[0]&1 == 1,
+		State:    strings.TrimSpace(tracestate),
+	}, true
+}
+
+// FromXRay converts an X-Ray trace header ("Root=1-5759e988-bd86...;Parent=53995c3f42cd8ad8;
+// Sampled=1") to the W3C context carrying the same trace.
+func FromXRay(header string) (Context, bool) {
+	var c Context
+	for _, field := range strings.Split(header, ";") {
+		key, value, _ := strings.Cut(strings.TrimSpace(field), "=")
+		switch key {
+		case "Root":
+			id := strings.ReplaceAll(strings.TrimPrefix(value, "1-"), "-", "")
+			if !isHex(id, 32) {
+				return Context{}, false
+			}
+			c.TraceID = id
+		case "Parent":
+			if isHex(value, 16) {
+				c.ParentID = value
+			}
+		case "Sampled":
+			c.Sampled = value == "1"
+		}
+	}
+	if c.TraceID == "" {
+		return Context{}, false
+	}
+	if c.ParentID == "" {
+		c.ParentID = randomHex(8)
+	}
+	return c, true
+}
+
+type contextKey struct{}
+
+// NewContext attaches c to ctx.
+func NewContext(ctx context.Context, c Context) context.Context {
+	return context.WithValue(ctx, contextKey{}, c)
+}
+
+// FromContext returns the trace context attached to ctx, falling back to the X-Ray header the
+// Lambda runtime attaches to each invocation.
+func FromContext(ctx context.Context) (Context, bool) {
+	if c, ok := ctx.Value(contextKey{}).(Context); ok {
+		return c, true
+	}
+	if header, ok := ctx.Value(lambdaTraceKey).(string); ok && header != "" {
+		return FromXRay(header)
+	}
+	return Context{}, false
+}
+
+// Ensure returns ctx carrying a trace context, starting a new trace when none is present.
+func Ensure(ctx context.Context) (context.Context, Context) {
+	if c, ok := ctx.Value(contextKey{}).(Context); ok {
+		return ctx, c
+	}
+	c, ok := FromContext(ctx)
+	if !ok {
+		c = New()
+	}
+	return NewContext(ctx, c), c
+}
+
+// Inject calls set with the headers for a call made on behalf of ctx: a child traceparent
+// and, when present, the tracestate.
+func Inject(ctx context.Context, set func(key, value string)) {
+	_, c := Ensure(ctx)
+	child := c.Child()
+	set(HeaderTraceParent, child.TraceParent())
+	if child.State != "" {
+		set(HeaderTraceState, child.State)
+	}
+}
+
+func isHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for _, r := range s {
+		if !('0' <= r && r <= '9' || 'a' <= r && r <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,47 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRoundTrip(t *testing.T) {
+	c, ok := Parse("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "vendor=x")
+	require.True(t, ok)
+	require.True(t, c.Sampled)
+	require.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", c.TraceParent())
+
+	for _, bad := range []string{"", "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "00-00000000000000000000000000000000-00f067aa0ba902b7-01", "00-xyz-00f067aa0ba902b7-01"} {
+		_, ok := Parse(bad, "")
+		require.False(t, ok, bad)
+	}
+}
+
+func TestFromXRay(t *testing.T) {
+	c, ok := FromXRay("Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=1")
+	require.True(t, ok)
+	require.Equal(t, "00-5759e988bd862e3fe1be46a994272793-53995c3f42cd8ad8-01", c.TraceParent())
+
+	ctx := context.WithValue(context.Background(), lambdaTraceKey, "Root=1-5759e988-bd862e3fe1be46a994272793")
+	got, ok := FromContext(ctx)
+	require.True(t, ok)
+	require.Equal(t, "5759e988bd862e3fe1be46a994272793", got.TraceID)
+}
+
+func TestInjectKeepsTraceWithNewSpan(t *testing.T) {
+	parent, _ := Parse("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "vendor=x")
+	headers := map[string]string{}
+	Inject(NewContext(context.Background(), parent), func(k, v string) { headers[k] = v })
+
+	child, ok := Parse(headers[HeaderTraceParent], headers[HeaderTraceState])
+	require.True(t, ok)
+	require.Equal(t, parent.TraceID, child.TraceID)
+	require.NotEqual(t, parent.ParentID, child.ParentID)
+	require.Equal(t, "vendor=x", child.State)
+
+	ctx, started := Ensure(context.Background())
+	again, _ := FromContext(ctx)
+	require.Equal(t, started, again)
+}
@@ -0,0 +1,186 @@
+package txcache
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+const redisDialTimeout = 2 * time.Second
+
+// RedisStore shares cached transactions across execution environments through Redis (or
+// ElastiCache without in-transit encryption). It speaks just enough RESP for GET, SET EX and
+// DEL over a single connection, redialled after any error.
+type RedisStore struct {
+	addr     string
+	password string
+	prefix   string
+
+	mu   sync.Mutex
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// NewRedisStore builds a store for the Redis server at addr (host:port). Keys are prefixed
+// with prefix; password, when set, is sent with AUTH on connect.
+func NewRedisStore(addr, password, prefix string) *RedisStore {
+	return &RedisStore{addr: addr, password: password, prefix: prefix}
+}
+
+// Get returns the cached transaction or ErrNotFound.
+func (s *RedisStore) Get(ctx context.Context, ref string) (*paypack.Transaction, error) {
+	reply, err := s.do(ctx, "GET", s.prefix+ref)
+	if err != nil {
+		return nil, fmt.Errorf("redis get %s: %w", ref, err)
+	}
+	if reply == nil {
+		return nil, ErrNotFound
+	}
+	var txn paypack.Transaction
+	if err := json.Unmarshal(reply, &txn); err != nil {
+		return nil, fmt.Errorf("decode cached %s: %w", ref, err)
+	}
+	return &txn, nil
+}
+
+// Put caches txn with an expiry of ttl, rounded up to whole seconds.
+func (s *RedisStore) Put(ctx context.Context, txn paypack.Transaction, ttl time.Duration) error {
+	if txn.Ref == "" {
+		return errors.New("transaction ref is required")
+	}
+	payload, err := json.Marshal(txn)
+	if err != nil {
+		return fmt.Errorf("encode %s: %w", txn.Ref, err)
+	}
+	seconds := max(int64((ttl+time.Second-1)/time.Second), 1)
+	if _, err := s.do(ctx, "SET", s.prefix+txn.Ref, string(payload), "EX", strconv.FormatInt(seconds, 10)); err != nil {
+		return fmt.Errorf("redis set %s: %w", txn.Ref, err)
+	}
+	return nil
+}
+
+// Delete drops the entry for ref.
+func (s *RedisStore) Delete(ctx context.Context, ref string) error {
+	if _, err := s.do(ctx, "DEL", s.prefix+ref); err != nil {
+		return fmt.Errorf("redis del %s: %w", ref, err)
+	}
+	return nil
+}
+
+// Close drops the connection.
+func (s *RedisStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.reset()
+}
+
+// do sends one command and returns a bulk reply's bytes; nil means a nil reply.
+func (s *RedisStore) do(ctx context.Context, args ...string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		if err := s.dial(ctx); err != nil {
+			return nil, err
+		}
+	}
+	reply, err := s.roundTrip(ctx, args)
+	if err != nil {
+		var serverErr redisError
+		if !errors.As(err, &serverErr) {
+			s.reset()
+		}
+		return nil, err
+	}
+	return reply, nil
+}
+
+func (s *RedisStore) dial(ctx context.Context) error {
+	d := net.Dialer{Timeout: redisDialTimeout}
+	conn, err := d.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	s.conn, s.r = conn, bufio.NewReader(conn)
+	if s.password != "" {
+		if _, err := s.roundTrip(ctx, []string{"AUTH", s.password}); err != nil {
+			s.reset()
+			return fmt.Errorf("auth: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *RedisStore) reset() error {
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn, s.r = nil, nil
+	return err
+}
+
+func (s *RedisStore) roundTrip(ctx context.Context, args []string) ([]byte, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(redisDialTimeout)
+	}
+	s.conn.SetDeadline(deadline)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, a := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(a), a)
+	}
+	if _, err := io.WriteString(s.conn, b.String()); err != nil {
+		return nil, err
+	}
+	return readReply(s.r)
+}
+
+type redisError string
+
+func (e redisError) Error() string { return string(e) }
+
+// readReply parses one simple, error, integer or bulk string reply.
+func readReply(r *bufio.Reader) ([]byte, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("empty redis reply")
+	}
+	switch line[0] {
+	case '+', ':':
+		return []byte(line[1:]), nil
+	case '-':
+		return nil, redisError(line[1:])
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid bulk length %q", line)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	default:
+		return nil, fmt.Errorf("unsupported redis reply %q", line)
+	}
+}
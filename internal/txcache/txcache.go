@@ -0,0 +1,120 @@
+// Package txcache caches terminal Paypack transactions by ref so status checks and webhook
+// follow-ups do not re-query Paypack for outcomes that can no longer change.
+package txcache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+// ErrNotFound is returned by Get on a miss or an expired entry.
+var ErrNotFound = errors.New("transaction not cached")
+
+// Store caches transactions keyed by ref.
+type Store interface {
+	Get(ctx context.Context, ref string) (*paypack.Transaction, error)
+	// Put caches txn for ttl, replacing any entry for the same ref.
+	Put(ctx context.Context, txn paypack.Transaction, ttl time.Duration) error
+}
+
+// Deleter is implemented by stores that can drop an entry before it expires, as erasing a
+// customer's data needs. Deleting a missing entry is not an error.
+type Deleter interface {
+	Delete(ctx context.Context, ref string) error
+}
+
+// Terminal reports whether status is final, and therefore safe to cache.
+func Terminal(status string) bool {
+	return status == "success" || status == "failed"
+}
+
+// defaultCapacity bounds a MemoryStore built with a non-positive capacity.
+const defaultCapacity = 10000
+
+// MemoryStore is an in-process Store for a warm Lambda container or server mode. Once full,
+// the entry closest to expiry is evicted to make room.
+type MemoryStore struct {
+	capacity int
+	now      func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+}
+
+type memoryEntry struct {
+	txn     paypack.Transaction
+	expires time.Time
+}
+
+// NewMemoryStore builds a MemoryStore holding up to capacity transactions (default 10000).
+func NewMemoryStore(capacity int) *MemoryStore {
+	if capacity <= 0 {
+		capacity = defaultCapacity
+	}
+	return &MemoryStore{capacity: capacity, now: time.Now, entries: make(map[string]memoryEntry)}
+}
+
+// Get returns the cached transaction or ErrNotFound.
+func (m *MemoryStore) Get(ctx context.Context, ref string) (*paypack.Transaction, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.entries[ref]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if !m.now().Before(e.expires) {
+		delete(m.entries, ref)
+		return nil, ErrNotFound
+	}
+	txn := e.txn
+	return &txn, nil
+}
+
+// Put caches txn until ttl elapses.
+func (m *MemoryStore) Put(ctx context.Context, txn paypack.Transaction, ttl time.Duration) error {
+	if txn.Ref == "" {
+		return errors.New("transaction ref is required")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	if _, ok := m.entries[txn.Ref]; !ok && len(m.entries) >= m.capacity {
+		m.evict(now)
+	}
+	m.entries[txn.Ref] = memoryEntry{txn: txn, expires: now.Add(ttl)}
+	return nil
+}
+
+// Delete drops the entry for ref.
+func (m *MemoryStore) Delete(ctx context.Context, ref string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.entries, ref)
+	return nil
+}
+
+// evict drops expired entries, or the one expiring soonest when none has.
+func (m *MemoryStore) evict(now time.Time) {
+	var soonest string
+	var soonestAt time.Time
+	for ref, e := range m.entries {
+		if !now.Before(e.expires) {
+			delete(m.entries, ref)
+			continue
+		}
+		if soonest == "" || e.expires.Before(soonestAt) {
+			soonest, soonestAt = ref, e.expires
+		}
+	}
+	if len(m.entries) >= m.capacity {
+		delete(m.entries, soonest)
+	}
+}
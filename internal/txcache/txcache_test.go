@@ -0,0 +1,149 @@
+package txcache
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+func TestMemoryStoreExpiresAndEvicts(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	m := NewMemoryStore(2)
+	m.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	require.NoError(t, m.Put(ctx, paypack.Transaction{Ref: "a", Status: "success"}, time.Minute))
+	require.NoError(t, m.Put(ctx, paypack.Transaction{Ref: "b", Status: "failed"}, 2*time.Minute))
+	require.NoError(t, m.Put(ctx, paypack.Transaction{Ref: "c", Status: "success"}, 3*time.Minute))
+
+	_, err := m.Get(ctx, "a")
+	require.ErrorIs(t, err, ErrNotFound, "soonest-expiring entry is evicted when full")
+	txn, err := m.Get(ctx, "b")
+	require.NoError(t, err)
+	require.Equal(t, "failed", txn.Status)
+
+	now = now.Add(150 * time.Second)
+	_, err = m.Get(ctx, "b")
+	require.ErrorIs(t, err, ErrNotFound)
+	_, err = m.Get(ctx, "c")
+	require.NoError(t, err)
+
+	require.NoError(t, m.Delete(ctx, "c"))
+	_, err = m.Get(ctx, "c")
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+// fakeRedis answers AUTH, GET, SET ... EX and DEL from a map.
+func fakeRedis(t *testing.T) (addr string, expiry func(key string) string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	var mu sync.Mutex
+	data := map[string]string{}
+	expiries := map[string]string{}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				for {
+					args, err := readCommand(r)
+					if err != nil {
+						return
+					}
+					mu.Lock()
+					switch strings.ToUpper(args[0]) {
+					case "AUTH":
+						fmt.Fprint(conn, "+OK\r\n")
+					case "SET":
+						data[args[1]] = args[2]
+						expiries[args[1]] = args[4]
+						fmt.Fprint(conn, "+OK\r\n")
+					case "GET":
+						if v, ok := data[args[1]]; ok {
+							fmt.Fprintf(conn, "$%d\r\n%s\r\n", len(v), v)
+						} else {
+							fmt.Fprint(conn, "$-1\r\n")
+						}
+					case "DEL":
+						_, ok := data[args[1]]
+						delete(data, args[1])
+						if ok {
+							fmt.Fprint(conn, ":1\r\n")
+						} else {
+							fmt.Fprint(conn, ":0\r\n")
+						}
+					default:
+						fmt.Fprint(conn, "-ERR unknown command\r\n")
+					}
+					mu.Unlock()
+				}
+			}()
+		}
+	}()
+	return ln.Addr().String(), func(key string) string {
+		mu.Lock()
+		defer mu.Unlock()
+		return expiries[key]
+	}
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+	args := make([]string, n)
+	for i := range args {
+		if _, err := r.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		arg, err := r.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		args[i] = strings.TrimSuffix(arg, "\r\n")
+	}
+	return args, nil
+}
+
+func TestRedisStoreRoundTrips(t *testing.T) {
+	addr, expiry := fakeRedis(t)
+	s := NewRedisStore(addr, "secret", "txn:")
+	defer s.Close()
+	ctx := context.Background()
+
+	_, err := s.Get(ctx, "abc")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	require.NoError(t, s.Put(ctx, paypack.Transaction{Ref: "abc", Status: "success", Amount: 1000}, 1500*time.Millisecond))
+	require.Equal(t, "2", expiry("txn:abc"))
+
+	txn, err := s.Get(ctx, "abc")
+	require.NoError(t, err)
+	require.Equal(t, "success", txn.Status)
+	require.Equal(t, 1000.0, txn.Amount)
+
+	require.NoError(t, s.Delete(ctx, "abc"))
+	_, err = s.Get(ctx, "abc")
+	require.ErrorIs(t, err, ErrNotFound)
+	require.NoError(t, s.Delete(ctx, "abc"), "deleting a missing entry is not an error")
+}
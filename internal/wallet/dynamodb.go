@@ -0,0 +1,109 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/berniyo/paypack-lambda/internal/money"
+)
+
+// DynamoStore is a Store backed by a DynamoDB table with partition key "id" (S). Balances
+// live under "balance#<currency>"; each applied movement leaves a "movement#<key>" marker
+// written in the same transaction, so a repeated key fails the transaction's condition.
+type DynamoStore struct {
+	client *dynamodb.Client
+	table  string
+}
+
+// NewDynamoStore builds a Store writing to table.
+func NewDynamoStore(client *dynamodb.Client, table string) (*DynamoStore, error) {
+	if client == nil {
+		return nil, errors.New("dynamodb client is required")
+	}
+	if table == "" {
+		return nil, errors.New("table is required")
+	}
+	return &DynamoStore{client: client, table: table}, nil
+}
+
+// Apply adds delta to the currency balance once per key.
+func (d *DynamoStore) Apply(ctx context.Context, key, currency string, delta float64) (Balance, error) {
+	if key == "" {
+		return Balance{}, errors.New("movement key is required")
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	_, err := d.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			{Put: &types.Put{
+				TableName: aws.String(d.table),
+				Item: map[string]types.AttributeValue{
+					"id":         &types.AttributeValueMemberS{Value: "movement#" + key},
+					"currency":   &types.AttributeValueMemberS{Value: currency},
+					"delta":      &types.AttributeValueMemberN{Value: money.Format(delta, currency)},
+					"created_at": &types.AttributeValueMemberS{Value: now},
+				},
+				ConditionExpression: aws.String("attribute_not_exists(id)"),
+			}},
+			{Update: &types.Update{
+				TableName:        aws.String(d.table),
+				Key:              balanceKey(currency),
+				UpdateExpression: aws.String("ADD amount :delta SET currency = :currency, updated_at = :now"),
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":delta":    &types.AttributeValueMemberN{Value: money.Format(delta, currency)},
+					":currency": &types.AttributeValueMemberS{Value: currency},
+					":now":      &types.AttributeValueMemberS{Value: now},
+				},
+			}},
+		},
+	})
+	if err != nil {
+		var cancelled *types.TransactionCanceledException
+		if errors.As(err, &cancelled) && len(cancelled.CancellationReasons) > 0 &&
+			aws.ToString(cancelled.CancellationReasons[0].Code) == "ConditionalCheckFailed" {
+			b, getErr := d.Get(ctx, currency)
+			if getErr != nil {
+				return Balance{}, getErr
+			}
+			return b, ErrDuplicate
+		}
+		return Balance{}, fmt.Errorf("apply balance movement: %w", err)
+	}
+	return d.Get(ctx, currency)
+}
+
+// Get returns the balance for currency.
+func (d *DynamoStore) Get(ctx context.Context, currency string) (Balance, error) {
+	out, err := d.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(d.table),
+		Key:            balanceKey(currency),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return Balance{}, fmt.Errorf("get balance: %w", err)
+	}
+
+	b := Balance{Currency: currency}
+	if amount, ok := out.Item["amount"].(*types.AttributeValueMemberN); ok {
+		v, err := strconv.ParseFloat(amount.Value, 64)
+		if err != nil {
+			return Balance{}, fmt.Errorf("decode balance: %w", err)
+		}
+		b.Amount = money.Round(v, currency)
+	}
+	if updated, ok := out.Item["updated_at"].(*types.AttributeValueMemberS); ok {
+		b.UpdatedAt, _ = time.Parse(time.RFC3339Nano, updated.Value)
+	}
+	return b, nil
+}
+
+func balanceKey(currency string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: "balance#" + currency}}
+}
@@ -0,0 +1,74 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/berniyo/paypack-lambda/internal/money"
+)
+
+// ErrDuplicate marks an Apply whose key was already applied.
+var ErrDuplicate = errors.New("balance movement already applied")
+
+// Balance is the running merchant balance in one currency.
+type Balance struct {
+	Currency  string    `json:"currency"`
+	Amount    float64   `json:"amount"`
+	UpdatedAt time.Time `json:"updated_at,omitzero"`
+}
+
+// Store keeps running balances. Movements are keyed so that a confirmation seen twice (for
+// example by polling and by webhook) only moves the balance once.
+type Store interface {
+	// Apply adds delta to the currency balance unless key was applied before, in which case
+	// it returns ErrDuplicate and leaves the balance untouched.
+	Apply(ctx context.Context, key, currency string, delta float64) (Balance, error)
+	// Get returns the balance for currency; an untouched currency has a zero balance.
+	Get(ctx context.Context, currency string) (Balance, error)
+}
+
+// MemoryStore keeps balances and applied keys in memory, for tests. Other containers do not
+// see what is applied to it, so it cannot back a real wallet.
+type MemoryStore struct {
+	mu       sync.Mutex
+	balances map[string]Balance
+	applied  map[string]bool
+}
+
+// NewMemoryStore builds an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{balances: make(map[string]Balance), applied: make(map[string]bool)}
+}
+
+// Apply adds delta to the currency balance once per key.
+func (m *MemoryStore) Apply(ctx context.Context, key, currency string, delta float64) (Balance, error) {
+	if key == "" {
+		return Balance{}, errors.New("movement key is required")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.applied[key] {
+		return m.balances[currency], ErrDuplicate
+	}
+	m.applied[key] = true
+	b := m.balances[currency]
+	b.Currency = currency
+	b.Amount = money.Round(b.Amount+delta, currency)
+	b.UpdatedAt = time.Now()
+	m.balances[currency] = b
+	return b, nil
+}
+
+// Get returns the balance for currency.
+func (m *MemoryStore) Get(ctx context.Context, currency string) (Balance, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	b := m.balances[currency]
+	b.Currency = currency
+	return b, nil
+}
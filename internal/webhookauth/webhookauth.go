@@ -0,0 +1,228 @@
+// Package webhookauth authenticates inbound webhook requests before their payload is decoded.
+// Checks are plain functions so they compose, and the same checks wrap API Gateway handlers
+// and net/http handlers alike; rejected requests get a JSON {"error", "code"} body.
+package webhookauth
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+// Rejection codes reported in the response body.
+const (
+	CodeMissingSignature = "MISSING_SIGNATURE"
+	CodeInvalidSignature = "INVALID_SIGNATURE"
+	CodeInvalidSecret    = "INVALID_SECRET"
+	CodeIPNotAllowed     = "IP_NOT_ALLOWED"
+	// CodeUnauthenticated covers checks that fail with a plain error.
+	CodeUnauthenticated = "UNAUTHENTICATED"
+)
+
+// DefaultSecretHeader carries the shared secret checked by SharedSecret.
+const DefaultSecretHeader = "X-Webhook-Token"
+
+// Request is what checks see of an inbound request. Body is already base64-decoded.
+type Request struct {
+	Headers  http.Header
+	Body     []byte
+	SourceIP string
+}
+
+// Check accepts req by returning nil, or rejects it with a *Rejection.
+type Check func(req Request) error
+
+// Rejection is a refused request: Status is 401 for bad credentials and 403 for callers
+// that are not allowed at all.
+type Rejection struct {
+	Status  int
+	Code    string
+	Message string
+}
+
+func (r *Rejection) Error() string { return r.Message }
+
+func unauthorized(code, message string) *Rejection {
+	return &Rejection{Status: http.StatusUnauthorized, Code: code, Message: message}
+}
+
+// PaypackSignature requires the X-Paypack-Signature Paypack computes over the body.
+func PaypackSignature(secret string) Check {
+	return func(req Request) error {
+		signature := req.Headers.Get(paypack.WebhookSignatureHeader)
+		if signature == "" {
+			return unauthorized(CodeMissingSignature, "missing signature")
+		}
+		if !paypack.VerifyWebhookSignature(req.Body, signature, secret) {
+			return unauthorized(CodeInvalidSignature, "invalid signature")
+		}
+		return nil
+	}
+}
+
+// SharedSecret requires header (DefaultSecretHeader when empty) to equal secret, for senders
+// that cannot sign bodies.
+func SharedSecret(header, secret string) Check {
+	if header == "" {
+		header = DefaultSecretHeader
+	}
+	return func(req Request) error {
+		if subtle.ConstantTimeCompare([]byte(req.Headers.Get(header)), []byte(secret)) != 1 {
+			return unauthorized(CodeInvalidSecret, "invalid shared secret")
+		}
+		return nil
+	}
+}
+
+// AllowIPs only admits requests whose source address is within one of the given IPs or
+// CIDR prefixes.
+func AllowIPs(allowed ...string) (Check, error) {
+	prefixes := make([]netip.Prefix, 0, len(allowed))
+	for _, raw := range allowed {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		if !strings.Contains(raw, "/") {
+			addr, err := netip.ParseAddr(raw)
+			if err != nil {
+				return nil, fmt.Errorf("invalid allowed ip %q: %w", raw, err)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allowed prefix %q: %w", raw, err)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	if len(prefixes) == 0 {
+		return nil, errors.New("at least one allowed ip is required")
+	}
+
+	return func(req Request) error {
+		addr, err := netip.ParseAddr(req.SourceIP)
+		if err == nil {
+			addr = addr.Unmap()
+			for _, p := range prefixes {
+				if p.Contains(addr) {
+					return nil
+				}
+			}
+		}
+		return &Rejection{Status: http.StatusForbidden, Code: CodeIPNotAllowed, Message: "source ip not allowed"}
+	}, nil
+}
+
+// Verify runs checks in order and returns the first rejection, or nil when all pass.
+func Verify(req Request, checks ...Check) *Rejection {
+	for _, check := range checks {
+		err := check(req)
+		if err == nil {
+			continue
+		}
+		var rejection *Rejection
+		if errors.As(err, &rejection) {
+			return rejection
+		}
+		return unauthorized(CodeUnauthenticated, err.Error())
+	}
+	return nil
+}
+
+// Body is the JSON response body for r.
+func (r *Rejection) Body() string {
+	body, _ := json.Marshal(map[string]string{"error": r.Message, "code": r.Code})
+	return string(body)
+}
+
+// APIGatewayHandler is the API Gateway HTTP API handler signature, as WebhookHandler.Handle.
+type APIGatewayHandler func(ctx context.Context, req events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error)
+
+// APIGateway wraps next so requests reach it only after passing every check. The source IP
+// is the one API Gateway observed.
+func APIGateway(next APIGatewayHandler, checks ...Check) APIGatewayHandler {
+	if len(checks) == 0 {
+		return next
+	}
+	return func(ctx context.Context, req events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+		body := []byte(req.Body)
+		if req.IsBase64Encoded {
+			decoded, err := base64.StdEncoding.DecodeString(req.Body)
+			if err != nil {
+				return events.APIGatewayV2HTTPResponse{
+					StatusCode: http.StatusBadRequest,
+					Headers:    map[string]string{"Content-Type": "application/json"},
+					Body:       `{"error":"invalid base64 body"}`,
+				}, nil
+			}
+			body = decoded
+		}
+		headers := make(http.Header, len(req.Headers))
+		for name, value := range req.Headers {
+			headers.Set(name, value)
+		}
+
+		if r := Verify(Request{Headers: headers, Body: body, SourceIP: req.RequestContext.HTTP.SourceIP}, checks...); r != nil {
+			return events.APIGatewayV2HTTPResponse{
+				StatusCode: r.Status,
+				Headers:    map[string]string{"Content-Type": "application/json"},
+				Body:       r.Body(),
+			}, nil
+		}
+		return next(ctx, req)
+	}
+}
+
+// HTTP wraps next so requests reach it only after passing every check. The source IP is the
+// connection's peer; forwarding headers are not trusted. Bodies are limited to maxBody bytes.
+func HTTP(next http.Handler, maxBody int64, checks ...Check) http.Handler {
+	if len(checks) == 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := readBody(w, r, maxBody)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error":"unreadable body"}`)
+			return
+		}
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			host = r.RemoteAddr
+		}
+
+		if rejection := Verify(Request{Headers: r.Header, Body: body, SourceIP: host}, checks...); rejection != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(rejection.Status)
+			fmt.Fprint(w, rejection.Body())
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// readBody reads r's body and replaces it so next can read it again.
+func readBody(w http.ResponseWriter, r *http.Request, limit int64) ([]byte, error) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, limit))
+	if err != nil {
+		return nil, err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
@@ -0,0 +1,111 @@
+package webhookauth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/require"
+)
+
+func sign(body, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestPaypackSignature(t *testing.T) {
+	check := PaypackSignature("s3cret")
+	req := Request{Headers: http.Header{}, Body: []byte(`{"ref":"abc"}`)}
+
+	require.Equal(t, &Rejection{Status: http.StatusUnauthorized, Code: CodeMissingSignature, Message: "missing signature"}, Verify(req, check))
+
+	req.Headers.Set("X-Paypack-Signature", sign(`{"ref":"abc"}`, "other"))
+	require.Equal(t, CodeInvalidSignature, Verify(req, check).Code)
+
+	req.Headers.Set("X-Paypack-Signature", sign(`{"ref":"abc"}`, "s3cret"))
+	require.Nil(t, Verify(req, check))
+}
+
+func TestAllowIPs(t *testing.T) {
+	check, err := AllowIPs("203.0.113.7", " 198.51.100.0/24", "2001:db8::/32")
+	require.NoError(t, err)
+
+	for ip, allowed := range map[string]bool{
+		"203.0.113.7":         true,
+		"203.0.113.8":         false,
+		"198.51.100.200":      true,
+		"::ffff:198.51.100.1": true,
+		"2001:db8::1":         true,
+		"":                    false,
+		"not-an-ip":           false,
+	} {
+		rejection := Verify(Request{SourceIP: ip}, check)
+		if allowed {
+			require.Nil(t, rejection, ip)
+			continue
+		}
+		require.NotNil(t, rejection, ip)
+		require.Equal(t, http.StatusForbidden, rejection.Status)
+		require.Equal(t, CodeIPNotAllowed, rejection.Code)
+	}
+
+	_, err = AllowIPs("300.1.1.1")
+	require.Error(t, err)
+	_, err = AllowIPs(" ", "")
+	require.Error(t, err)
+}
+
+func TestAPIGatewayRejectsBeforeHandler(t *testing.T) {
+	called := false
+	next := func(ctx context.Context, req events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+		called = true
+		return events.APIGatewayV2HTTPResponse{StatusCode: http.StatusOK}, nil
+	}
+	allow, err := AllowIPs("203.0.113.0/24")
+	require.NoError(t, err)
+	handle := APIGateway(next, allow, SharedSecret("", "tok"))
+
+	req := events.APIGatewayV2HTTPRequest{Headers: map[string]string{"x-webhook-token": "tok"}, Body: "{}"}
+	req.RequestContext.HTTP.SourceIP = "192.0.2.1"
+	res, err := handle(context.Background(), req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusForbidden, res.StatusCode)
+	require.JSONEq(t, `{"error":"source ip not allowed","code":"IP_NOT_ALLOWED"}`, res.Body)
+	require.False(t, called)
+
+	req.RequestContext.HTTP.SourceIP = "203.0.113.9"
+	res, err = handle(context.Background(), req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	require.True(t, called)
+}
+
+func TestHTTPPassesBodyThrough(t *testing.T) {
+	signed := `{"ref":"abc"}`
+	handler := HTTP(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		w.Write(body)
+	}), 1<<10, PaypackSignature("s3cret"))
+
+	req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(signed))
+	req.Header.Set("X-Paypack-Signature", sign(signed, "s3cret"))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, signed, rec.Body.String())
+
+	req = httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(signed))
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+}
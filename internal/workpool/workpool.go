@@ -0,0 +1,42 @@
+package workpool
+
+import (
+	"context"
+	"sync"
+)
+
+// Map calls fn for every item with at most concurrency calls in flight and returns the results
+// in item order. Each call gets its own context derived from ctx, cancelled when the call
+// returns, so per-item resources never outlive their item.
+func Map[T, R any](ctx context.Context, concurrency int, items []T, fn func(ctx context.Context, item T) R) []R {
+	results := make([]R, len(items))
+	if len(items) == 0 {
+		return results
+	}
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	if concurrency > len(items) {
+		concurrency = len(items)
+	}
+
+	next := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(concurrency)
+	for range concurrency {
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				itemCtx, cancel := context.WithCancel(ctx)
+				results[i] = fn(itemCtx, items[i])
+				cancel()
+			}
+		}()
+	}
+	for i := range items {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	return results
+}
@@ -0,0 +1,48 @@
+package workpool
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMapBoundsConcurrencyAndKeepsOrder(t *testing.T) {
+	items := make([]int, 50)
+	for i := range items {
+		items[i] = i
+	}
+
+	var inFlight, peak atomic.Int32
+	results := Map(context.Background(), 4, items, func(ctx context.Context, n int) int {
+		cur := inFlight.Add(1)
+		for {
+			old := peak.Load()
+			if cur <= old || peak.CompareAndSwap(old, cur) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		inFlight.Add(-1)
+		return n * 2
+	})
+
+	require.LessOrEqual(t, peak.Load(), int32(4))
+	for i, r := range results {
+		require.Equal(t, i*2, r)
+	}
+}
+
+func TestMapCancelsItemContexts(t *testing.T) {
+	var ctxs []context.Context
+	Map(context.Background(), 1, []int{1, 2}, func(ctx context.Context, n int) int {
+		ctxs = append(ctxs, ctx)
+		return n
+	})
+	for _, ctx := range ctxs {
+		require.Error(t, ctx.Err())
+	}
+	require.Empty(t, Map(context.Background(), 3, []int(nil), func(context.Context, int) int { return 0 }))
+}
@@ -0,0 +1,94 @@
+// Package wspush posts messages to API Gateway WebSocket connections through the API Gateway
+// Management API (POST /@connections/{id}), signing requests with SigV4.
+package wspush
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+)
+
+// ErrGone reports a connection the client has already closed.
+var ErrGone = errors.New("websocket connection is gone")
+
+// Client posts to the connections of one WebSocket API stage.
+type Client struct {
+	endpoint    string
+	region      string
+	credentials aws.CredentialsProvider
+	signer      *v4.Signer
+	httpClient  *http.Client
+}
+
+// New builds a client for endpoint, the stage's connection URL, e.g.
+// https://abc123.execute-api.eu-west-1.amazonaws.com/prod.
+func New(endpoint, region string, credentials aws.CredentialsProvider, httpClient *http.Client) (*Client, error) {
+	endpoint = strings.TrimRight(strings.TrimSpace(endpoint), "/")
+	if endpoint == "" {
+		return nil, errors.New("websocket endpoint is required")
+	}
+	if _, err := url.Parse(endpoint); err != nil {
+		return nil, fmt.Errorf("invalid websocket endpoint: %w", err)
+	}
+	if region == "" {
+		return nil, errors.New("region is required")
+	}
+	if credentials == nil {
+		return nil, errors.New("credentials are required")
+	}
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 5 * time.Second}
+	}
+	return &Client{
+		endpoint:    endpoint,
+		region:      region,
+		credentials: credentials,
+		signer:      v4.NewSigner(),
+		httpClient:  httpClient,
+	}, nil
+}
+
+// PostToConnection sends data as one message to connectionID. It returns ErrGone when the
+// connection no longer exists.
+func (c *Client) PostToConnection(ctx context.Context, connectionID string, data []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint+"/@connections/"+url.PathEscape(connectionID), bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("build websocket request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	creds, err := c.credentials.Retrieve(ctx)
+	if err != nil {
+		return fmt.Errorf("retrieve credentials: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	if err := c.signer.SignHTTP(ctx, creds, req, hex.EncodeToString(sum[:]), "execute-api", c.region, time.Now()); err != nil {
+		return fmt.Errorf("sign websocket request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("post to connection: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusGone:
+		return ErrGone
+	case resp.StatusCode >= 300:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("management api returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
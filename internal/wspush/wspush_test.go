@@ -0,0 +1,50 @@
+package wspush
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostToConnectionSignsRequests(t *testing.T) {
+	var gotPath, gotAuth, gotBody string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.EscapedPath()
+		gotAuth = r.Header.Get("Authorization")
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+		if strings.Contains(gotPath, "closed") {
+			w.WriteHeader(http.StatusGone)
+		}
+	}))
+	defer srv.Close()
+
+	creds := credentials.NewStaticCredentialsProvider("AKID", "SECRET", "")
+	client, err := New(srv.URL+"/prod/", "eu-west-1", creds, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, client.PostToConnection(context.Background(), "abc=", []byte(`{"stage":"pending"}`)))
+	require.Equal(t, "/prod/@connections/abc=", gotPath)
+	require.Equal(t, `{"stage":"pending"}`, gotBody)
+	require.True(t, strings.HasPrefix(gotAuth, "AWS4-HMAC-SHA256 Credential=AKID/"), gotAuth)
+	require.Contains(t, gotAuth, "/eu-west-1/execute-api/aws4_request")
+
+	require.ErrorIs(t, client.PostToConnection(context.Background(), "closed", nil), ErrGone)
+}
+
+func TestNewValidatesConfiguration(t *testing.T) {
+	creds := aws.AnonymousCredentials{}
+	_, err := New("", "eu-west-1", creds, nil)
+	require.Error(t, err)
+	_, err = New("https://example.com/prod", "", creds, nil)
+	require.Error(t, err)
+	_, err = New("https://example.com/prod", "eu-west-1", nil, nil)
+	require.Error(t, err)
+}
@@ -0,0 +1,59 @@
+package envelope
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// CloudEvents 1.0 constants for the structured and binary content modes.
+const (
+	CloudEventsSpecVersion = "1.0"
+	// CloudEventsContentType is the Content-Type of structured-mode messages.
+	CloudEventsContentType = "application/cloudevents+json"
+)
+
+// CloudEvent is an Event in CloudEvents 1.0 structured JSON form. The api_version of the
+// envelope travels as the apiversion extension attribute.
+type CloudEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            time.Time       `json:"time"`
+	DataContentType string          `json:"datacontenttype"`
+	APIVersion      string          `json:"apiversion"`
+	Data            json.RawMessage `json:"data"`
+}
+
+// CloudEvent converts e, keeping its ID and Type. subject is usually the transaction ref.
+func (e Event) CloudEvent(source, subject string) CloudEvent {
+	return CloudEvent{
+		SpecVersion:     CloudEventsSpecVersion,
+		ID:              e.ID,
+		Source:          source,
+		Type:            e.Type,
+		Subject:         subject,
+		Time:            e.CreatedAt,
+		DataContentType: "application/json",
+		APIVersion:      e.APIVersion,
+		Data:            e.Data,
+	}
+}
+
+// Attributes returns the context attributes carried as headers in binary mode (with a ce-
+// prefix over HTTP, ce_ over Kafka); the body is then Data alone.
+func (c CloudEvent) Attributes() map[string]string {
+	attrs := map[string]string{
+		"specversion": c.SpecVersion,
+		"id":          c.ID,
+		"source":      c.Source,
+		"type":        c.Type,
+		"time":        c.Time.Format(time.RFC3339Nano),
+		"apiversion":  c.APIVersion,
+	}
+	if c.Subject != "" {
+		attrs["subject"] = c.Subject
+	}
+	return attrs
+}
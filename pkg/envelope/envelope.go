@@ -0,0 +1,114 @@
+// Package envelope defines the versioned envelope callbacks are delivered in, for receivers
+// written in Go. Every callback body is an Event whose Data depends on Type; payment, refund
+// and payout events carry a Payment.
+//
+//	var event envelope.Event
+//	if err := json.Unmarshal(body, &event); err != nil {
+//		return err
+//	}
+//	if seen(event.ID) {
+//		return nil // redelivery of an event already handled
+//	}
+//	switch event.Type {
+//	case envelope.TypePaymentSucceeded, envelope.TypePaymentFailed:
+//		var payment envelope.Payment
+//		if err := event.DecodeData(&payment); err != nil {
+//			return err
+//		}
+//	}
+//
+// The ID is derived from the data, so retries and replays of the same outcome share it.
+// APIVersion names the shape of Data; new fields may appear within a version, and removals
+// or changes of meaning only ever come with a new version, negotiated per endpoint.
+package envelope
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+// API versions, oldest first. Latest is used unless an endpoint pins an older one.
+const (
+	Version20261014 = "2026-10-14"
+
+	Latest = Version20261014
+)
+
+// Versions lists every supported APIVersion.
+var Versions = []string{Version20261014}
+
+// Event types.
+const (
+	TypePaymentSucceeded = "payment.succeeded"
+	TypePaymentFailed    = "payment.failed"
+	TypePaymentPending   = "payment.pending"
+	TypeRefundSucceeded  = "refund.succeeded"
+	TypeRefundFailed     = "refund.failed"
+	TypeRefundPending    = "refund.pending"
+	TypePayoutSucceeded  = "payout.succeeded"
+	TypePayoutFailed     = "payout.failed"
+	TypePayoutPending    = "payout.pending"
+	// TypeBatchCompleted is a batch digest; its data carries the batch report.
+	TypeBatchCompleted = "batch.completed"
+)
+
+// Event is the envelope around every callback payload.
+type Event struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	CreatedAt  time.Time       `json:"created_at"`
+	APIVersion string          `json:"api_version"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// DecodeData unmarshals the event's data into v, typically a *Payment.
+func (e Event) DecodeData(v any) error {
+	if len(e.Data) == 0 {
+		return errors.New("event has no data")
+	}
+	if err := json.Unmarshal(e.Data, v); err != nil {
+		return fmt.Errorf("decode %s data: %w", e.Type, err)
+	}
+	return nil
+}
+
+// Payment is the data of payment.*, refund.* and payout.* events. It covers the fields
+// receivers commonly need; the full payload carries more (receipts, fees, subscriptions, ...),
+// which receivers may decode into their own types.
+type Payment struct {
+	Ref         string               `json:"ref"`
+	Status      string               `json:"status"`
+	Found       bool                 `json:"found"`
+	Transaction *paypack.Transaction `json:"transaction,omitempty"`
+	Message     string               `json:"message,omitempty"`
+	Code        string               `json:"code,omitempty"`
+	Request     PaymentRequest       `json:"request"`
+	Refund      *Refund              `json:"refund,omitempty"`
+	// Fee and NetAmount (amount less fee) are set once the transaction succeeded.
+	Fee       *float64 `json:"fee,omitempty"`
+	NetAmount *float64 `json:"net_amount,omitempty"`
+}
+
+// PaymentRequest is the event that started the payment.
+type PaymentRequest struct {
+	Number         string         `json:"number"`
+	Amount         float64        `json:"amount"`
+	AmountMinor    int64          `json:"amount_minor,omitempty"`
+	Currency       string         `json:"currency,omitempty"`
+	Client         string         `json:"client,omitempty"`
+	SubscriptionID string         `json:"subscription_id,omitempty"`
+	Metadata       map[string]any `json:"metadata,omitempty"`
+}
+
+// Refund identifies the charge a refund event pays back, with its cumulative refunded total
+// and what remains refundable.
+type Refund struct {
+	OriginalRef         string  `json:"original_ref"`
+	Amount              float64 `json:"amount"`
+	Refunded            float64 `json:"refunded"`
+	RefundableRemaining float64 `json:"refundable_remaining"`
+}
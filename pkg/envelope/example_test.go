@@ -0,0 +1,31 @@
+package envelope_test
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/berniyo/paypack-lambda/pkg/envelope"
+)
+
+func ExampleEvent_DecodeData() {
+	body := []byte(`{
+		"id": "evt_5b9c0c3e2f4d4a1e8f5a6b7c",
+		"type": "payment.succeeded",
+		"created_at": "2026-10-14T09:00:00Z",
+		"api_version": "2026-10-14",
+		"data": {"ref": "abc", "status": "success", "found": true, "request": {"number": "0788000000", "amount": 1000}}
+	}`)
+
+	var event envelope.Event
+	if err := json.Unmarshal(body, &event); err != nil {
+		fmt.Println(err)
+		return
+	}
+	var payment envelope.Payment
+	if err := event.DecodeData(&payment); err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(event.Type, payment.Ref, payment.Request.Amount)
+	// Output: payment.succeeded abc 1000
+}
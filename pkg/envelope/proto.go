@@ -0,0 +1,362 @@
+package envelope
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+// ProtobufContentType is the Content-Type of protobuf-encoded events.
+const ProtobufContentType = "application/x-protobuf"
+
+// The methods below implement proto/paypack/v1/events.proto by hand with protowire. Keep
+// field numbers in sync with the .proto file.
+
+var errMalformed = errors.New("malformed protobuf message")
+
+// MarshalProto encodes e as a paypack.v1.Event. Data must hold a Payment.
+func (e Event) MarshalProto() ([]byte, error) {
+	var payment Payment
+	if err := e.DecodeData(&payment); err != nil {
+		return nil, err
+	}
+	var b []byte
+	b = appendString(b, 1, e.ID)
+	b = appendString(b, 2, e.Type)
+	b = appendTimestamp(b, 3, e.CreatedAt)
+	b = appendString(b, 4, e.APIVersion)
+	b = appendMessage(b, 5, payment.MarshalProto())
+	return b, nil
+}
+
+// UnmarshalProto decodes a paypack.v1.Event into e. Data is set to the payment's JSON, so
+// DecodeData works as for JSON events.
+func (e *Event) UnmarshalProto(b []byte) error {
+	var payment Payment
+	err := decode(b, func(num protowire.Number, v []byte, x uint64) error {
+		switch num {
+		case 1:
+			e.ID = string(v)
+		case 2:
+			e.Type = string(v)
+		case 3:
+			return decodeTimestamp(v, &e.CreatedAt)
+		case 4:
+			e.APIVersion = string(v)
+		case 5:
+			return payment.UnmarshalProto(v)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if e.Data, err = json.Marshal(payment); err != nil {
+		return fmt.Errorf("encode payment: %w", err)
+	}
+	return nil
+}
+
+// MarshalProto encodes p as a paypack.v1.PaymentOutcome.
+func (p Payment) MarshalProto() []byte {
+	var b []byte
+	b = appendString(b, 1, p.Ref)
+	b = appendString(b, 2, p.Status)
+	b = appendBool(b, 3, p.Found)
+	b = appendString(b, 4, p.Message)
+	b = appendString(b, 5, p.Code)
+	if t := p.Transaction; t != nil {
+		var tb []byte
+		tb = appendString(tb, 1, t.Ref)
+		tb = appendString(tb, 2, t.Status)
+		tb = appendDouble(tb, 3, t.Amount)
+		tb = appendDouble(tb, 4, t.Fee)
+		tb = appendString(tb, 5, t.Currency)
+		tb = appendString(tb, 6, t.Kind)
+		tb = appendString(tb, 7, t.Provider)
+		tb = appendString(tb, 8, t.Client)
+		tb = appendMetadata(tb, 9, t.Metadata)
+		tb = appendTimestamp(tb, 10, t.CreatedAt)
+		b = appendMessage(b, 6, tb)
+	}
+	var rb []byte
+	rb = appendString(rb, 1, p.Request.Number)
+	rb = appendDouble(rb, 2, p.Request.Amount)
+	rb = appendString(rb, 3, p.Request.Currency)
+	rb = appendString(rb, 4, p.Request.Client)
+	rb = appendString(rb, 5, p.Request.SubscriptionID)
+	rb = appendMetadata(rb, 6, p.Request.Metadata)
+	rb = appendInt64(rb, 7, p.Request.AmountMinor)
+	b = appendMessage(b, 7, rb)
+	if r := p.Refund; r != nil {
+		var fb []byte
+		fb = appendString(fb, 1, r.OriginalRef)
+		fb = appendDouble(fb, 2, r.Amount)
+		fb = appendDouble(fb, 3, r.Refunded)
+		fb = appendDouble(fb, 4, r.RefundableRemaining)
+		b = appendMessage(b, 8, fb)
+	}
+	b = appendOptionalDouble(b, 9, p.Fee)
+	b = appendOptionalDouble(b, 10, p.NetAmount)
+	return b
+}
+
+// UnmarshalProto decodes a paypack.v1.PaymentOutcome into p.
+func (p *Payment) UnmarshalProto(b []byte) error {
+	return decode(b, func(num protowire.Number, v []byte, x uint64) error {
+		switch num {
+		case 1:
+			p.Ref = string(v)
+		case 2:
+			p.Status = string(v)
+		case 3:
+			p.Found = x != 0
+		case 4:
+			p.Message = string(v)
+		case 5:
+			p.Code = string(v)
+		case 6:
+			p.Transaction = &paypack.Transaction{}
+			return unmarshalTransaction(v, p.Transaction)
+		case 7:
+			return unmarshalRequest(v, &p.Request)
+		case 8:
+			p.Refund = &Refund{}
+			return decode(v, func(num protowire.Number, v []byte, x uint64) error {
+				switch num {
+				case 1:
+					p.Refund.OriginalRef = string(v)
+				case 2:
+					p.Refund.Amount = math.Float64frombits(x)
+				case 3:
+					p.Refund.Refunded = math.Float64frombits(x)
+				case 4:
+					p.Refund.RefundableRemaining = math.Float64frombits(x)
+				}
+				return nil
+			})
+		case 9:
+			fee := math.Float64frombits(x)
+			p.Fee = &fee
+		case 10:
+			net := math.Float64frombits(x)
+			p.NetAmount = &net
+		}
+		return nil
+	})
+}
+
+func unmarshalTransaction(b []byte, t *paypack.Transaction) error {
+	return decode(b, func(num protowire.Number, v []byte, x uint64) error {
+		switch num {
+		case 1:
+			t.Ref = string(v)
+		case 2:
+			t.Status = string(v)
+		case 3:
+			t.Amount = math.Float64frombits(x)
+		case 4:
+			t.Fee = math.Float64frombits(x)
+		case 5:
+			t.Currency = string(v)
+		case 6:
+			t.Kind = string(v)
+		case 7:
+			t.Provider = string(v)
+		case 8:
+			t.Client = string(v)
+		case 9:
+			return decodeMetadataEntry(v, &t.Metadata)
+		case 10:
+			return decodeTimestamp(v, &t.CreatedAt)
+		}
+		return nil
+	})
+}
+
+func unmarshalRequest(b []byte, r *PaymentRequest) error {
+	return decode(b, func(num protowire.Number, v []byte, x uint64) error {
+		switch num {
+		case 1:
+			r.Number = string(v)
+		case 2:
+			r.Amount = math.Float64frombits(x)
+		case 3:
+			r.Currency = string(v)
+		case 4:
+			r.Client = string(v)
+		case 5:
+			r.SubscriptionID = string(v)
+		case 6:
+			return decodeMetadataEntry(v, &r.Metadata)
+		case 7:
+			r.AmountMinor = int64(x)
+		}
+		return nil
+	})
+}
+
+// appendString, appendDouble, appendInt64 and appendBool skip zero values, as proto3 does for implicit
+// presence.
+func appendString(b []byte, num protowire.Number, s string) []byte {
+	if s == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, s)
+}
+
+func appendDouble(b []byte, num protowire.Number, f float64) []byte {
+	if f == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.Fixed64Type)
+	return protowire.AppendFixed64(b, math.Float64bits(f))
+}
+
+// appendOptionalDouble encodes an optional double, keeping an explicit zero.
+func appendOptionalDouble(b []byte, num protowire.Number, f *float64) []byte {
+	if f == nil {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.Fixed64Type)
+	return protowire.AppendFixed64(b, math.Float64bits(*f))
+}
+
+func appendInt64(b []byte, num protowire.Number, i int64) []byte {
+	if i == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, uint64(i))
+}
+
+func appendBool(b []byte, num protowire.Number, v bool) []byte {
+	if !v {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, 1)
+}
+
+func appendMessage(b []byte, num protowire.Number, msg []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, msg)
+}
+
+// appendTimestamp encodes t as a google.protobuf.Timestamp, omitting the zero time.
+func appendTimestamp(b []byte, num protowire.Number, t time.Time) []byte {
+	if t.IsZero() {
+		return b
+	}
+	var ts []byte
+	if secs := t.Unix(); secs != 0 {
+		ts = protowire.AppendTag(ts, 1, protowire.VarintType)
+		ts = protowire.AppendVarint(ts, uint64(secs))
+	}
+	if nanos := t.Nanosecond(); nanos != 0 {
+		ts = protowire.AppendTag(ts, 2, protowire.VarintType)
+		ts = protowire.AppendVarint(ts, uint64(nanos))
+	}
+	return appendMessage(b, num, ts)
+}
+
+func decodeTimestamp(b []byte, t *time.Time) error {
+	var secs, nanos int64
+	err := decode(b, func(num protowire.Number, v []byte, x uint64) error {
+		switch num {
+		case 1:
+			secs = int64(x)
+		case 2:
+			nanos = int64(int32(x))
+		}
+		return nil
+	})
+	*t = time.Unix(secs, nanos).UTC()
+	return err
+}
+
+// appendMetadata encodes m as map<string, string>, JSON-encoding values that are not strings.
+func appendMetadata(b []byte, num protowire.Number, m map[string]any) []byte {
+	for k, v := range m {
+		s, ok := v.(string)
+		if !ok {
+			data, err := json.Marshal(v)
+			if err != nil {
+				continue
+			}
+			s = string(data)
+		}
+		var entry []byte
+		entry = protowire.AppendTag(entry, 1, protowire.BytesType)
+		entry = protowire.AppendString(entry, k)
+		entry = protowire.AppendTag(entry, 2, protowire.BytesType)
+		entry = protowire.AppendString(entry, s)
+		b = appendMessage(b, num, entry)
+	}
+	return b
+}
+
+func decodeMetadataEntry(b []byte, m *map[string]any) error {
+	var key, value string
+	err := decode(b, func(num protowire.Number, v []byte, x uint64) error {
+		switch num {
+		case 1:
+			key = string(v)
+		case 2:
+			value = string(v)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if *m == nil {
+		*m = make(map[string]any)
+	}
+	(*m)[key] = value
+	return nil
+}
+
+// decode walks the fields of b. Length-delimited values arrive in v and varint and fixed
+// values in x; unknown fields are skipped so older receivers accept newer events.
+func decode(b []byte, field func(num protowire.Number, v []byte, x uint64) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return errMalformed
+		}
+		b = b[n:]
+
+		var v []byte
+		var x uint64
+		switch typ {
+		case protowire.BytesType:
+			v, n = protowire.ConsumeBytes(b)
+		case protowire.VarintType:
+			x, n = protowire.ConsumeVarint(b)
+		case protowire.Fixed64Type:
+			x, n = protowire.ConsumeFixed64(b)
+		case protowire.Fixed32Type:
+			var x32 uint32
+			x32, n = protowire.ConsumeFixed32(b)
+			x = uint64(x32)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return errMalformed
+		}
+		b = b[n:]
+		if err := field(num, v, x); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package envelope
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+func TestEventProtoRoundTrip(t *testing.T) {
+	created := time.Date(2026, 10, 14, 9, 0, 0, 500, time.UTC)
+	net := 1000.0
+	payment := Payment{
+		Ref:    "abc",
+		Status: "success",
+		Found:  true,
+		Code:   "",
+		Transaction: &paypack.Transaction{
+			Ref:       "abc",
+			Status:    "success",
+			Amount:    1000,
+			Fee:       23.5,
+			Currency:  "RWF",
+			Kind:      "CASHIN",
+			Provider:  "mtn",
+			Metadata:  map[string]any{"plan": "monthly"},
+			CreatedAt: created,
+		},
+		Request:   PaymentRequest{Number: "2507", Amount: 1000, AmountMinor: 1000, Client: "acme", Metadata: map[string]any{"seats": 3.0, "plan": "monthly"}},
+		Refund:    &Refund{OriginalRef: "orig", Amount: 500},
+		Fee:       new(float64),
+		NetAmount: &net,
+	}
+	data, err := json.Marshal(payment)
+	require.NoError(t, err)
+	event := Event{ID: "evt_1", Type: TypeRefundSucceeded, CreatedAt: created, APIVersion: Latest, Data: data}
+
+	b, err := event.MarshalProto()
+	require.NoError(t, err)
+	var decoded Event
+	require.NoError(t, decoded.UnmarshalProto(b))
+	require.Equal(t, event.ID, decoded.ID)
+	require.Equal(t, event.Type, decoded.Type)
+	require.Equal(t, created, decoded.CreatedAt)
+	require.Equal(t, Latest, decoded.APIVersion)
+
+	var got Payment
+	require.NoError(t, decoded.DecodeData(&got))
+	payment.Request.Metadata["seats"] = "3"
+	require.Equal(t, payment, got)
+	require.Less(t, len(b), len(data), "protobuf is smaller than the JSON payload")
+}
+
+func TestTimestampMatchesWellKnownType(t *testing.T) {
+	at := time.Date(2026, 10, 14, 9, 0, 0, 123456789, time.UTC)
+	want, err := proto.Marshal(timestamppb.New(at))
+	require.NoError(t, err)
+
+	b := appendTimestamp(nil, 3, at)
+	_, _, n := protowire.ConsumeTag(b)
+	got, _ := protowire.ConsumeBytes(b[n:])
+	require.Equal(t, want, got)
+}
+
+func TestUnmarshalProtoRejectsGarbage(t *testing.T) {
+	var event Event
+	require.ErrorIs(t, event.UnmarshalProto([]byte{0x0a, 0x05, 'a'}), errMalformed)
+}
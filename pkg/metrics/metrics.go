@@ -0,0 +1,57 @@
+// Package metrics is the instrumentation interface shared by the public packages of this
+// module. Services importing pkg/paypack plug their own backend in by implementing Metrics;
+// the Lambda's Prometheus, EMF, StatsD and OTLP backends implement it too.
+package metrics
+
+import (
+	"context"
+	"time"
+)
+
+// Tag is a dimension attached to a measurement.
+type Tag struct {
+	Key   string
+	Value string
+}
+
+// T builds a Tag.
+func T(key, value string) Tag {
+	return Tag{Key: key, Value: value}
+}
+
+// Metrics records counters and distributions. Backends must be safe for concurrent use and
+// expect a metric name to always be used with the same tag keys.
+type Metrics interface {
+	// Count adds delta to a counter.
+	Count(name string, delta float64, tags ...Tag)
+	// Observe records one sample of a distribution, such as a latency in seconds.
+	Observe(name string, value float64, tags ...Tag)
+}
+
+// Flusher is implemented by backends that buffer measurements and must push them before a
+// Lambda invocation returns and the execution environment is frozen.
+type Flusher interface {
+	Flush(ctx context.Context) error
+}
+
+// Flush pushes buffered measurements when m is a Flusher and is a no-op otherwise.
+func Flush(ctx context.Context, m Metrics) error {
+	if f, ok := m.(Flusher); ok {
+		return f.Flush(ctx)
+	}
+	return nil
+}
+
+// Nop discards every measurement.
+type Nop struct{}
+
+// Count does nothing.
+func (Nop) Count(string, float64, ...Tag) {}
+
+// Observe does nothing.
+func (Nop) Observe(string, float64, ...Tag) {}
+
+// Since observes the seconds elapsed since start.
+func Since(m Metrics, name string, start time.Time, tags ...Tag) {
+	m.Observe(name, time.Since(start).Seconds(), tags...)
+}
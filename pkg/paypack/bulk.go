@@ -0,0 +1,85 @@
+package paypack
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+const defaultBulkConcurrency = 8
+
+// BulkFindOptions tunes FindTransactions.
+type BulkFindOptions struct {
+	// Since, when set, lists the transactions created since then in one pass first; only the
+	// refs the listing did not settle are looked up one by one.
+	Since time.Time
+	// Concurrency bounds the per-ref lookups (default 8).
+	Concurrency int
+}
+
+// StatusResult is the outcome of one ref in FindTransactions. Err matches
+// ErrTransactionNotFound for refs Paypack does not know yet.
+type StatusResult struct {
+	Ref         string
+	Transaction *Transaction
+	Err         error
+}
+
+var errAllListed = errors.New("every ref listed")
+
+// FindTransactions resolves the status of many refs in one pass, returning a result per ref
+// in order. With opts.Since the transaction list settles every ref it shows with a final
+// status; the rest, or all of them without Since, are looked up with FindTransaction, up to
+// opts.Concurrency at a time.
+func (c *Client) FindTransactions(ctx context.Context, refs []string, opts BulkFindOptions) []StatusResult {
+	results := make([]StatusResult, len(refs))
+	index := make(map[string][]int, len(refs))
+	for i, ref := range refs {
+		results[i].Ref = ref
+		index[ref] = append(index[ref], i)
+	}
+
+	if !opts.Since.IsZero() && len(index) > 0 {
+		settled := 0
+		// A failed listing only means more refs are looked up one by one below.
+		_ = c.Transactions(ctx, ListOptions{From: opts.Since}, func(txn Transaction) error {
+			slots, ok := index[txn.Ref]
+			if !ok || results[slots[0]].Transaction != nil || (txn.Status != "success" && txn.Status != "failed") {
+				return nil
+			}
+			for _, i := range slots {
+				t := txn
+				results[i].Transaction = &t
+			}
+			if settled++; settled == len(index) {
+				return errAllListed
+			}
+			return nil
+		})
+	}
+
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultBulkConcurrency
+	}
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for ref, slots := range index {
+		if results[slots[0]].Transaction != nil {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			txn, err := c.FindTransaction(ctx, ref)
+			for _, i := range slots {
+				results[i].Transaction, results[i].Err = txn, err
+			}
+		}()
+	}
+	wg.Wait()
+	return results
+}
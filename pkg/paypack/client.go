@@ -0,0 +1,578 @@
+package paypack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/berniyo/paypack-lambda/pkg/metrics"
+)
+
+const (
+	defaultBaseURL = "https://payments.paypack.rw"
+	authorizePath  = "/api/auth/agents/authorize"
+)
+
+// DefaultCurrency is the currency Paypack settles in when none is requested.
+const DefaultCurrency = "RWF"
+
+// APIError surfaces non-successful HTTP responses from Paypack.
+type APIError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("paypack api error: status=%d body=%s", e.StatusCode, e.Body)
+}
+
+// ErrTransactionNotFound marks a FindTransaction miss.
+var ErrTransactionNotFound = errors.New("transaction not found")
+
+// ErrNotSent marks requests that never reached Paypack: authorization failed, or no base URL
+// could be resolved or connected to. A cash-in failing with it charged nobody.
+var ErrNotSent = errors.New("request not sent to paypack")
+
+// ErrUnexpectedResponse marks cash-in and cash-out responses that could not be read, such as
+// an undecodable body or one without a reference. The request reached Paypack, so money may
+// have moved.
+var ErrUnexpectedResponse = errors.New("unexpected paypack response")
+
+// taggedError makes err match tag under errors.Is without changing its message.
+type taggedError struct{ err, tag error }
+
+func (e taggedError) Error() string   { return e.err.Error() }
+func (e taggedError) Unwrap() []error { return []error{e.err, e.tag} }
+
+// Client is a lightweight Paypack API client tailored for Lambda usage.
+type Client struct {
+	httpClient *http.Client
+	endpoints  *endpoints
+	appID      string
+	appSecret  string
+	metrics    metrics.Metrics
+	transport  TransportConfig
+
+	authMu      sync.Mutex
+	cachedToken string
+	tokenExpiry time.Time
+
+	// refreshMu serialises authorization so concurrent callers finding the token expired
+	// share one refresh instead of each authorizing.
+	refreshMu sync.Mutex
+}
+
+// ClientOption customises a Client built by NewClientFromEnv.
+type ClientOption func(*Client)
+
+// WithMetrics records the count and latency of Paypack API calls, tagged by endpoint and
+// HTTP status.
+func WithMetrics(m metrics.Metrics) ClientOption {
+	return func(c *Client) {
+		if m != nil {
+			c.metrics = m
+		}
+	}
+}
+
+// NewClientFromEnv constructs a client using PAYPACK_* environment variables. Transport options
+// only apply when httpClient is nil and the client builds its own.
+func NewClientFromEnv(httpClient *http.Client, opts ...ClientOption) (*Client, error) {
+	appID := strings.TrimSpace(os.Getenv("PAYPACK_APP_ID"))
+	appSecret := strings.TrimSpace(os.Getenv("PAYPACK_APP_SECRET"))
+	if appID == "" || appSecret == "" {
+		return nil, errors.New("PAYPACK_APP_ID and PAYPACK_APP_SECRET must be set")
+	}
+	return NewClient(os.Getenv("PAYPACK_BASE_URL"), appID, appSecret, httpClient, opts...)
+}
+
+// NewClient constructs a client for the Paypack API at baseURL (the production API when
+// empty), authenticating with appID and appSecret. WithFallbackBaseURLs adds base URLs to
+// fail over to.
+func NewClient(baseURL, appID, appSecret string, httpClient *http.Client, opts ...ClientOption) (*Client, error) {
+	if appID == "" || appSecret == "" {
+		return nil, errors.New("app id and app secret are required")
+	}
+	baseURL = strings.TrimSpace(baseURL)
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+	baseURL = strings.TrimSuffix(baseURL, "/")
+
+	c := &Client{
+		httpClient: httpClient,
+		endpoints:  newEndpoints(baseURL),
+		appID:      appID,
+		appSecret:  appSecret,
+		metrics:    metrics.Nop{},
+		transport:  DefaultTransportConfig(),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.httpClient == nil {
+		c.httpClient = &http.Client{Timeout: 30 * time.Second, Transport: newTransport(c.transport)}
+	}
+	return c, nil
+}
+
+// CashInOption adds optional fields to a cash-in request.
+type CashInOption func(*CashInRequest)
+
+// CashInRequest holds the optional cash-in fields set through CashInOptions.
+type CashInRequest struct {
+	Currency string
+	Client   string
+}
+
+// ApplyCashInOptions resolves opts over the defaults. Test doubles use it to inspect requests.
+func ApplyCashInOptions(opts ...CashInOption) CashInRequest {
+	req := CashInRequest{Currency: DefaultCurrency}
+	for _, opt := range opts {
+		opt(&req)
+	}
+	return req
+}
+
+// WithCurrency sets the ISO 4217 currency of the cash-in amount (default RWF).
+func WithCurrency(code string) CashInOption {
+	return func(r *CashInRequest) {
+		if code != "" {
+			r.Currency = strings.ToUpper(code)
+		}
+	}
+}
+
+// WithClient tags the cash-in with the caller's client identifier, which Paypack stores on
+// the transaction.
+func WithClient(client string) CashInOption {
+	return func(r *CashInRequest) {
+		r.Client = strings.TrimSpace(client)
+	}
+}
+
+// CashIn triggers a mobile-money cash-in transaction for the given number and amount.
+func (c *Client) CashIn(ctx context.Context, number string, amount float64, opts ...CashInOption) (*Transaction, error) {
+	if number == "" {
+		return nil, errors.New("number is required")
+	}
+	if amount <= 0 {
+		return nil, errors.New("amount must be positive")
+	}
+
+	req := ApplyCashInOptions(opts...)
+	if !ValidCurrencyCode(req.Currency) {
+		return nil, fmt.Errorf("invalid currency %q", req.Currency)
+	}
+
+	token, err := c.ensureAccessToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	payload := map[string]any{
+		"amount": amount,
+		"number": number,
+	}
+	if req.Currency != DefaultCurrency {
+		payload["currency"] = req.Currency
+	}
+	if req.Client != "" {
+		payload["client"] = req.Client
+	}
+
+	_, body, err := c.doRequest(ctx, http.MethodPost, "/api/transactions/cashin", token, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var txn Transaction
+	if err := json.Unmarshal(body, &txn); err != nil {
+		return nil, taggedError{fmt.Errorf("decode cashin response: %w", err), ErrUnexpectedResponse}
+	}
+	if txn.Ref == "" {
+		return nil, taggedError{errors.New("cashin response missing reference"), ErrUnexpectedResponse}
+	}
+	if txn.Currency == "" {
+		txn.Currency = req.Currency
+	}
+	if txn.Client == "" {
+		txn.Client = req.Client
+	}
+	if txn.Number == "" {
+		txn.Number = number
+	}
+
+	return &txn, nil
+}
+
+// CashOut sends amount from the merchant wallet to the given mobile-money number.
+func (c *Client) CashOut(ctx context.Context, number string, amount float64) (*Transaction, error) {
+	if number == "" {
+		return nil, errors.New("number is required")
+	}
+	if amount <= 0 {
+		return nil, errors.New("amount must be positive")
+	}
+
+	token, err := c.ensureAccessToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	payload := map[string]any{
+		"amount": amount,
+		"number": number,
+	}
+
+	_, body, err := c.doRequest(ctx, http.MethodPost, "/api/transactions/cashout", token, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var txn Transaction
+	if err := json.Unmarshal(body, &txn); err != nil {
+		return nil, taggedError{fmt.Errorf("decode cashout response: %w", err), ErrUnexpectedResponse}
+	}
+	if txn.Ref == "" {
+		return nil, taggedError{errors.New("cashout response missing reference"), ErrUnexpectedResponse}
+	}
+	if txn.Currency == "" {
+		txn.Currency = DefaultCurrency
+	}
+	if txn.Number == "" {
+		txn.Number = number
+	}
+
+	return &txn, nil
+}
+
+// Balance fetches the merchant wallet balance held by Paypack.
+func (c *Client) Balance(ctx context.Context) (*Balance, error) {
+	token, err := c.ensureAccessToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	_, body, err := c.doRequest(ctx, http.MethodGet, "/api/merchants/balance", token, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var bal Balance
+	if err := json.Unmarshal(body, &bal); err != nil {
+		return nil, fmt.Errorf("decode balance response: %w", err)
+	}
+	if bal.Currency == "" {
+		bal.Currency = DefaultCurrency
+	}
+	return &bal, nil
+}
+
+// PaymentLinkRequest describes a hosted checkout link. Metadata is echoed back on the
+// transaction the link produces, including in its webhook.
+type PaymentLinkRequest struct {
+	Amount      float64
+	Currency    string
+	Description string
+	Metadata    map[string]any
+}
+
+// CreatePaymentLink creates a hosted checkout page the customer opens to pay, as an
+// alternative to approving a USSD push.
+func (c *Client) CreatePaymentLink(ctx context.Context, req PaymentLinkRequest) (*PaymentLink, error) {
+	if req.Amount <= 0 {
+		return nil, errors.New("amount must be positive")
+	}
+	if req.Currency == "" {
+		req.Currency = DefaultCurrency
+	}
+	req.Currency = strings.ToUpper(req.Currency)
+	if !ValidCurrencyCode(req.Currency) {
+		return nil, fmt.Errorf("invalid currency %q", req.Currency)
+	}
+
+	token, err := c.ensureAccessToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	payload := map[string]any{
+		"amount":   req.Amount,
+		"currency": req.Currency,
+	}
+	if req.Description != "" {
+		payload["description"] = req.Description
+	}
+	if len(req.Metadata) > 0 {
+		payload["metadata"] = req.Metadata
+	}
+
+	_, body, err := c.doRequest(ctx, http.MethodPost, "/api/checkouts/links", token, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var link PaymentLink
+	if err := json.Unmarshal(body, &link); err != nil {
+		return nil, fmt.Errorf("decode payment link response: %w", err)
+	}
+	if link.ID == "" || link.URL == "" {
+		return nil, errors.New("payment link response missing id or url")
+	}
+	if link.Amount == 0 {
+		link.Amount = req.Amount
+	}
+	if link.Currency == "" {
+		link.Currency = req.Currency
+	}
+
+	return &link, nil
+}
+
+// ValidCurrencyCode reports whether code looks like an ISO 4217 alphabetic code.
+func ValidCurrencyCode(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// FindTransaction fetches the transaction payload, returning ErrTransactionNotFound on misses.
+func (c *Client) FindTransaction(ctx context.Context, ref string) (*Transaction, error) {
+	if ref == "" {
+		return nil, errors.New("ref is required")
+	}
+
+	token, err := c.ensureAccessToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	status, body, err := c.doRequest(ctx, http.MethodGet, fmt.Sprintf("/api/transactions/find/%s", ref), token, nil)
+	if err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			return nil, ErrTransactionNotFound
+		}
+		return nil, err
+	}
+
+	var txn Transaction
+	if err := json.Unmarshal(body, &txn); err == nil && txn.Ref != "" {
+		return &txn, nil
+	}
+
+	var miss TransactionNotFound
+	if err := json.Unmarshal(body, &miss); err == nil && miss.Message != "" {
+		return nil, ErrTransactionNotFound
+	}
+
+	if status == http.StatusNotFound {
+		return nil, ErrTransactionNotFound
+	}
+
+	return nil, fmt.Errorf("unexpected transaction payload: %s", string(body))
+}
+
+// WarmToken fetches and caches an access token ahead of the first API call, so a cold start
+// can pay for the authorize round-trip during init instead of inside a payment.
+func (c *Client) WarmToken(ctx context.Context) error {
+	_, err := c.ensureAccessToken(ctx)
+	return err
+}
+
+func (c *Client) authorize(ctx context.Context) (*AuthResponse, error) {
+	payload := map[string]string{
+		"client_id":     c.appID,
+		"client_secret": c.appSecret,
+	}
+
+	_, body, err := c.doRequest(ctx, http.MethodPost, authorizePath, "", payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var auth AuthResponse
+	if err := json.Unmarshal(body, &auth); err != nil {
+		return nil, fmt.Errorf("decode authorize response: %w", err)
+	}
+
+	if auth.Access == "" {
+		return nil, errors.New("authorize response missing access token")
+	}
+
+	return &auth, nil
+}
+
+func (c *Client) ensureAccessToken(ctx context.Context) (string, error) {
+	if token, ok := c.currentToken(); ok {
+		return token, nil
+	}
+
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+	if token, ok := c.currentToken(); ok {
+		return token, nil
+	}
+
+	auth, err := c.authorize(ctx)
+	if err != nil {
+		return "", taggedError{err, ErrNotSent}
+	}
+
+	lifetime := time.Duration(auth.Expires) * time.Second
+	if lifetime <= 0 {
+		lifetime = 5 * time.Minute
+	}
+
+	buffer := time.Minute
+	if lifetime <= buffer {
+		buffer = lifetime / 2
+	}
+	expiresAt := time.Now().Add(lifetime - buffer)
+
+	c.authMu.Lock()
+	c.cachedToken = auth.Access
+	c.tokenExpiry = expiresAt
+	c.authMu.Unlock()
+
+	return auth.Access, nil
+}
+
+func (c *Client) currentToken() (string, bool) {
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+	return c.cachedToken, c.cachedToken != "" && time.Now().Before(c.tokenExpiry)
+}
+
+func (c *Client) doRequest(ctx context.Context, method, path, token string, payload any) (int, []byte, error) {
+	resp, logf, err := c.send(ctx, method, path, token, payload)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, nil, err
+	}
+	if logf != nil {
+		logf("paypack response %s %s status=%d body=%s", method, path, resp.StatusCode, data)
+	}
+
+	if resp.StatusCode >= 400 {
+		return resp.StatusCode, data, &APIError{StatusCode: resp.StatusCode, Body: string(data)}
+	}
+
+	return resp.StatusCode, data, nil
+}
+
+// send issues the request and returns the open response, with the debug logger to use for
+// it (nil when debug logging is off). The caller closes the body.
+func (c *Client) send(ctx context.Context, method, path, token string, payload any) (*http.Response, func(string, ...any), error) {
+	logf := debugLog(ctx)
+	if path == authorizePath {
+		logf = nil
+	}
+
+	var body []byte
+	if payload != nil {
+		buf := &bytes.Buffer{}
+		if err := json.NewEncoder(buf).Encode(payload); err != nil {
+			return nil, nil, taggedError{err, ErrNotSent}
+		}
+		if logf != nil {
+			logf("paypack request %s %s body=%s", method, path, bytes.TrimSpace(buf.Bytes()))
+		}
+		body = buf.Bytes()
+	} else if logf != nil {
+		logf("paypack request %s %s", method, path)
+	}
+
+	urls := c.endpoints.order(time.Now())
+	for i, baseURL := range urls {
+		resp, err := c.sendTo(ctx, baseURL, method, path, token, body, logf)
+		if err == nil {
+			c.endpoints.record(baseURL, time.Now(), true)
+			return resp, logf, nil
+		}
+		if !unreachable(ctx, method, err) {
+			return nil, nil, notSent(err)
+		}
+		c.endpoints.record(baseURL, time.Now(), false)
+		if i == len(urls)-1 {
+			return nil, nil, notSent(err)
+		}
+		c.metrics.Count(MetricFailovers, 1, metrics.T("endpoint", endpointName(path)))
+		if logf != nil {
+			logf("paypack %s unreachable; failing over to %s: %v", baseURL, urls[i+1], err)
+		}
+	}
+	return nil, nil, taggedError{errors.New("no paypack base URL configured"), ErrNotSent}
+}
+
+// sendTo issues one attempt of the request against baseURL.
+func (c *Client) sendTo(ctx context.Context, baseURL, method, path, token string, payload []byte, logf func(string, ...any)) (*http.Response, error) {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, baseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	contextHeaders(ctx, method, token, req.Header.Set)
+
+	trace := &connTrace{}
+	req = req.WithContext(httptrace.WithClientTrace(ctx, trace.clientTrace()))
+
+	start := time.Now()
+	resp, err := c.httpClient.Do(req)
+	endpoint := endpointName(path)
+	metrics.Since(c.metrics, MetricLatency, start, metrics.T("endpoint", endpoint))
+	timings, reused := trace.record(ctx, c.metrics, endpoint)
+	if logf != nil {
+		logf("paypack connection %s %s reused=%t dns=%s connect=%s tls=%s wait=%s", method, path,
+			reused, timings.DNS, timings.Connect, timings.TLS, timings.Wait)
+	}
+	if err != nil {
+		c.metrics.Count(MetricRequests, 1, metrics.T("endpoint", endpoint), metrics.T("status", "error"))
+		return nil, err
+	}
+	c.metrics.Count(MetricRequests, 1, metrics.T("endpoint", endpoint), metrics.T("status", strconv.Itoa(resp.StatusCode)))
+	return resp, nil
+}
+
+// endpointName drops query strings and the transaction reference from lookup paths so metric
+// series stay bounded.
+func endpointName(path string) string {
+	path, _, _ = strings.Cut(path, "?")
+	if before, _, ok := strings.Cut(path, "/find/"); ok {
+		return before + "/find"
+	}
+	return path
+}
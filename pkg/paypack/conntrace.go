@@ -0,0 +1,119 @@
+package paypack
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"github.com/berniyo/paypack-lambda/pkg/metrics"
+)
+
+// ConnTimings splits where Paypack request time went: connection setup for new connections
+// (DNS, TCP connect, TLS handshake) versus waiting on Paypack after the request was written.
+type ConnTimings struct {
+	Requests int
+	Reused   int
+	DNS      time.Duration
+	Connect  time.Duration
+	TLS      time.Duration
+	Wait     time.Duration
+}
+
+// ConnStats accumulates ConnTimings over every request made under a context from
+// WithConnStats. It is safe for concurrent use.
+type ConnStats struct {
+	mu      sync.Mutex
+	timings ConnTimings
+}
+
+// Timings returns the totals so far.
+func (s *ConnStats) Timings() ConnTimings {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.timings
+}
+
+func (s *ConnStats) add(t ConnTimings) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.timings.Requests += t.Requests
+	s.timings.Reused += t.Reused
+	s.timings.DNS += t.DNS
+	s.timings.Connect += t.Connect
+	s.timings.TLS += t.TLS
+	s.timings.Wait += t.Wait
+}
+
+type connStatsKey struct{}
+
+// WithConnStats returns a context whose Paypack requests are tallied into the returned stats.
+func WithConnStats(ctx context.Context) (context.Context, *ConnStats) {
+	stats := &ConnStats{}
+	return context.WithValue(ctx, connStatsKey{}, stats), stats
+}
+
+// connTrace records one request's connection phases through httptrace. Dials can finish on
+// transport goroutines after the request has moved on, hence the lock.
+type connTrace struct {
+	mu                               sync.Mutex
+	reused                           bool
+	dnsStart, connectStart, tlsStart time.Time
+	wrote                            time.Time
+	timings                          ConnTimings
+}
+
+func (t *connTrace) clientTrace() *httptrace.ClientTrace {
+	at := func(fn func()) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		fn()
+	}
+	return &httptrace.ClientTrace{
+		GotConn:              func(info httptrace.GotConnInfo) { at(func() { t.reused = info.Reused }) },
+		DNSStart:             func(httptrace.DNSStartInfo) { at(func() { t.dnsStart = time.Now() }) },
+		DNSDone:              func(httptrace.DNSDoneInfo) { at(func() { t.timings.DNS = since(t.dnsStart) }) },
+		ConnectStart:         func(string, string) { at(func() { t.connectStart = time.Now() }) },
+		ConnectDone:          func(string, string, error) { at(func() { t.timings.Connect = since(t.connectStart) }) },
+		TLSHandshakeStart:    func() { at(func() { t.tlsStart = time.Now() }) },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { at(func() { t.timings.TLS = since(t.tlsStart) }) },
+		WroteRequest:         func(httptrace.WroteRequestInfo) { at(func() { t.wrote = time.Now() }) },
+		GotFirstResponseByte: func() { at(func() { t.timings.Wait = since(t.wrote) }) },
+	}
+}
+
+func since(start time.Time) time.Duration {
+	if start.IsZero() {
+		return 0
+	}
+	return time.Since(start)
+}
+
+// record publishes the trace as metrics and into the context's ConnStats, if any, and
+// returns what was recorded.
+func (t *connTrace) record(ctx context.Context, m metrics.Metrics, endpoint string) (ConnTimings, bool) {
+	t.mu.Lock()
+	timings, reused, wrote := t.timings, t.reused, !t.wrote.IsZero()
+	t.mu.Unlock()
+
+	timings.Requests = 1
+	tag := metrics.T("endpoint", endpoint)
+	if reused {
+		timings.Reused = 1
+		m.Count(MetricConnections, 1, tag, metrics.T("reused", "true"))
+	} else {
+		m.Count(MetricConnections, 1, tag, metrics.T("reused", "false"))
+		m.Observe(MetricConnect, (timings.DNS + timings.Connect).Seconds(), tag)
+		if timings.TLS > 0 {
+			m.Observe(MetricTLSHandshake, timings.TLS.Seconds(), tag)
+		}
+	}
+	if wrote {
+		m.Observe(MetricWait, timings.Wait.Seconds(), tag)
+	}
+	if stats, ok := ctx.Value(connStatsKey{}).(*ConnStats); ok {
+		stats.add(timings)
+	}
+	return timings, reused
+}
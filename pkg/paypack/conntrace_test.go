@@ -0,0 +1,36 @@
+package paypack
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnStatsSeparateHandshakeFromReuse(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == authorizePath {
+			fmt.Fprint(w, `{"access":"tok","expires":3600}`)
+			return
+		}
+		fmt.Fprint(w, `{"balance":1000}`)
+	}))
+	defer srv.Close()
+	c, err := NewClient(srv.URL, "id", "secret", srv.Client())
+	require.NoError(t, err)
+
+	ctx, stats := WithConnStats(context.Background())
+	_, err = c.Balance(ctx)
+	require.NoError(t, err)
+	_, err = c.Balance(ctx)
+	require.NoError(t, err)
+
+	timings := stats.Timings()
+	require.Equal(t, 3, timings.Requests, "authorize plus two balance calls")
+	require.Equal(t, 2, timings.Reused)
+	require.Positive(t, timings.TLS)
+	require.Positive(t, timings.Wait)
+}
@@ -0,0 +1,104 @@
+package paypack_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+// The contract suite runs against the real Paypack sandbox, so upstream API changes show up
+// here before they reach production. It only runs with PAYPACK_CONTRACT_TESTS=1 and sandbox
+// credentials in PAYPACK_APP_ID and PAYPACK_APP_SECRET (and PAYPACK_BASE_URL when the
+// sandbox is not the default host):
+//
+//	PAYPACK_CONTRACT_TESTS=1 PAYPACK_CONTRACT_NUMBER=0788000000 go test ./pkg/paypack -run Contract -v
+//
+// PAYPACK_CONTRACT_NUMBER is the sandbox payer charged by the cash-in test, which is skipped
+// without it; PAYPACK_CONTRACT_AMOUNT sets the amount (default 100).
+const contractEnv = "PAYPACK_CONTRACT_TESTS"
+
+// contractWait bounds how long a sandbox cash-in may take to become visible.
+const contractWait = 2 * time.Minute
+
+func contractClient(t *testing.T) *paypack.Client {
+	t.Helper()
+	if os.Getenv(contractEnv) != "1" {
+		t.Skipf("set %s=1 to run the Paypack sandbox contract tests", contractEnv)
+	}
+	client, err := paypack.NewClientFromEnv(nil)
+	require.NoError(t, err)
+	return client
+}
+
+func TestContractAuthorize(t *testing.T) {
+	client := contractClient(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	require.NoError(t, client.WarmToken(ctx), "authorize must accept the sandbox credentials and return an access token")
+}
+
+func TestContractFindUnknownRef(t *testing.T) {
+	client := contractClient(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	_, err := client.FindTransaction(ctx, "contract-missing-"+strconv.FormatInt(time.Now().UnixNano(), 36))
+	require.ErrorIs(t, err, paypack.ErrTransactionNotFound, "a miss must still decode as not found")
+}
+
+func TestContractCashInFindList(t *testing.T) {
+	client := contractClient(t)
+	number := strings.TrimSpace(os.Getenv("PAYPACK_CONTRACT_NUMBER"))
+	if number == "" {
+		t.Skip("set PAYPACK_CONTRACT_NUMBER to the sandbox payer to run the cash-in contract test")
+	}
+	amount := 100.0
+	if raw := strings.TrimSpace(os.Getenv("PAYPACK_CONTRACT_AMOUNT")); raw != "" {
+		parsed, err := strconv.ParseFloat(raw, 64)
+		require.NoError(t, err, "PAYPACK_CONTRACT_AMOUNT")
+		amount = parsed
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), contractWait+time.Minute)
+	defer cancel()
+	started := time.Now()
+
+	txn, err := client.CashIn(ctx, number, amount)
+	require.NoError(t, err)
+	require.NotEmpty(t, txn.Ref, "cash-in response must carry ref")
+	require.Equal(t, amount, txn.Amount)
+	require.Equal(t, "CASHIN", strings.ToUpper(txn.Kind))
+	t.Logf("sandbox cash-in ref=%s status=%s", txn.Ref, txn.Status)
+
+	var found *paypack.Transaction
+	require.Eventually(t, func() bool {
+		found, err = client.FindTransaction(ctx, txn.Ref)
+		return err == nil
+	}, contractWait, 5*time.Second, "find must return the cash-in once Paypack records it")
+	require.Equal(t, txn.Ref, found.Ref)
+	require.Equal(t, amount, found.Amount)
+	require.Equal(t, "CASHIN", strings.ToUpper(found.Kind))
+	require.NotEmpty(t, found.Status)
+	require.NotEmpty(t, found.Provider)
+	require.False(t, found.CreatedAt.IsZero() && found.Timestamp.IsZero(), "find must carry a timestamp")
+
+	errListed := errors.New("listed")
+	opts := paypack.ListOptions{From: started.Add(-time.Minute), Kind: "CASHIN", PageSize: 20}
+	require.Eventually(t, func() bool {
+		err := client.Transactions(ctx, opts, func(listed paypack.Transaction) error {
+			if listed.Ref == txn.Ref {
+				return errListed
+			}
+			return nil
+		})
+		return errors.Is(err, errListed)
+	}, contractWait, 5*time.Second, "list must include the cash-in")
+}
@@ -0,0 +1,41 @@
+package paypack
+
+import (
+	"context"
+	"net/http"
+)
+
+// Request headers set from the context.
+const (
+	// HeaderCorrelationID carries the caller's correlation ID on every API request.
+	HeaderCorrelationID = "X-Correlation-ID"
+	// HeaderIdempotencyKey lets Paypack recognise a cash-in or cash-out sent twice.
+	HeaderIdempotencyKey = "Idempotency-Key"
+)
+
+type (
+	correlationKey struct{}
+	idempotencyKey struct{}
+)
+
+// WithCorrelationID returns a context under which every request carries id in
+// X-Correlation-ID, so Paypack's records join to the caller's.
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationKey{}, id)
+}
+
+// WithIdempotencyKey returns a context under which authorized POST requests carry key in
+// Idempotency-Key. Use one key per intended charge: a request repeated with the same key is
+// not charged again.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKey{}, key)
+}
+
+func contextHeaders(ctx context.Context, method, token string, set func(key, value string)) {
+	if id, _ := ctx.Value(correlationKey{}).(string); id != "" {
+		set(HeaderCorrelationID, id)
+	}
+	if key, _ := ctx.Value(idempotencyKey{}).(string); key != "" && method == http.MethodPost && token != "" {
+		set(HeaderIdempotencyKey, key)
+	}
+}
@@ -0,0 +1,38 @@
+package paypack
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientSendsCorrelationHeaders(t *testing.T) {
+	headers := map[string]http.Header{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers[r.URL.Path] = r.Header.Clone()
+		switch r.URL.Path {
+		case authorizePath:
+			fmt.Fprint(w, `{"access":"tok","expires":3600}`)
+		default:
+			fmt.Fprint(w, `{"ref":"abc","status":"pending","amount":1000}`)
+		}
+	}))
+	defer srv.Close()
+	c, err := NewClient(srv.URL, "id", "secret", nil)
+	require.NoError(t, err)
+
+	ctx := WithIdempotencyKey(WithCorrelationID(context.Background(), "order-42"), "order-42")
+	_, err = c.CashIn(ctx, "0788000001", 1000)
+	require.NoError(t, err)
+
+	cashIn := headers["/api/transactions/cashin"]
+	require.Equal(t, "order-42", cashIn.Get(HeaderCorrelationID))
+	require.Equal(t, "order-42", cashIn.Get(HeaderIdempotencyKey))
+	auth := headers[authorizePath]
+	require.Equal(t, "order-42", auth.Get(HeaderCorrelationID))
+	require.Empty(t, auth.Get(HeaderIdempotencyKey), "the token request is not a charge")
+}
@@ -0,0 +1,16 @@
+package paypack
+
+import "context"
+
+type debugKey struct{}
+
+// WithDebugLog returns a context under which the client logs each API request and response
+// body through logf. Authorization calls are never logged, so credentials stay out of logs.
+func WithDebugLog(ctx context.Context, logf func(format string, args ...any)) context.Context {
+	return context.WithValue(ctx, debugKey{}, logf)
+}
+
+func debugLog(ctx context.Context) func(format string, args ...any) {
+	logf, _ := ctx.Value(debugKey{}).(func(format string, args ...any))
+	return logf
+}
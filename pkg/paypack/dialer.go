@@ -0,0 +1,158 @@
+package paypack
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WithDNSServers resolves hosts through the given DNS servers ("10.0.0.2" or "10.0.0.2:53")
+// instead of the system resolver.
+func WithDNSServers(servers ...string) ClientOption {
+	return func(c *Client) {
+		var addrs []string
+		for _, s := range servers {
+			if s = strings.TrimSpace(s); s == "" {
+				continue
+			}
+			if _, _, err := net.SplitHostPort(s); err != nil {
+				s = net.JoinHostPort(s, "53")
+			}
+			addrs = append(addrs, s)
+		}
+		if len(addrs) == 0 {
+			return
+		}
+		c.transport.Resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				var d net.Dialer
+				var err error
+				for _, addr := range addrs {
+					var conn net.Conn
+					if conn, err = d.DialContext(ctx, network, addr); err == nil {
+						return conn, nil
+					}
+				}
+				return nil, err
+			},
+		}
+	}
+}
+
+// WithDNSCacheTTL caches resolved addresses for ttl. When a refresh fails the last answer
+// keeps being used, so a resolver hiccup mid-poll does not surface as "no such host".
+func WithDNSCacheTTL(ttl time.Duration) ClientOption {
+	return func(c *Client) {
+		if ttl > 0 {
+			c.transport.DNSCacheTTL = ttl
+		}
+	}
+}
+
+// WithPinnedIPs connects to the primary base URL's host at ips without resolving it. TLS is
+// still verified against the host name.
+func WithPinnedIPs(ips ...string) ClientOption {
+	return func(c *Client) {
+		u, err := url.Parse(c.endpoints.list[0].URL)
+		if err != nil || u.Hostname() == "" {
+			return
+		}
+		var pinned []string
+		for _, ip := range ips {
+			if ip = strings.TrimSpace(ip); net.ParseIP(ip) != nil {
+				pinned = append(pinned, ip)
+			}
+		}
+		if len(pinned) == 0 {
+			return
+		}
+		if c.transport.PinnedHosts == nil {
+			c.transport.PinnedHosts = make(map[string][]string)
+		}
+		c.transport.PinnedHosts[u.Hostname()] = pinned
+	}
+}
+
+// hostResolver looks hosts up for the transport's dialer: pinned hosts first, then the
+// cache, then lookup.
+type hostResolver struct {
+	lookup func(ctx context.Context, host string) ([]string, error)
+	ttl    time.Duration
+	pinned map[string][]string
+	now    func() time.Time
+
+	mu    sync.Mutex
+	cache map[string]resolved
+}
+
+type resolved struct {
+	addrs   []string
+	expires time.Time
+}
+
+func newHostResolver(cfg TransportConfig) *hostResolver {
+	resolver := cfg.Resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	return &hostResolver{
+		lookup: resolver.LookupHost,
+		ttl:    cfg.DNSCacheTTL,
+		pinned: cfg.PinnedHosts,
+		now:    time.Now,
+		cache:  make(map[string]resolved),
+	}
+}
+
+func (r *hostResolver) resolve(ctx context.Context, host string) ([]string, error) {
+	if addrs, ok := r.pinned[host]; ok {
+		return addrs, nil
+	}
+	if r.ttl <= 0 {
+		return r.lookup(ctx, host)
+	}
+
+	r.mu.Lock()
+	cached, ok := r.cache[host]
+	r.mu.Unlock()
+	if ok && r.now().Before(cached.expires) {
+		return cached.addrs, nil
+	}
+	addrs, err := r.lookup(ctx, host)
+	if err != nil {
+		if ok && ctx.Err() == nil {
+			return cached.addrs, nil
+		}
+		return nil, err
+	}
+	r.mu.Lock()
+	r.cache[host] = resolved{addrs: addrs, expires: r.now().Add(r.ttl)}
+	r.mu.Unlock()
+	return addrs, nil
+}
+
+// dialContext dials addr through the resolver, trying each address in turn.
+func (r *hostResolver) dialContext(dialer *net.Dialer) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil || net.ParseIP(host) != nil {
+			return dialer.DialContext(ctx, network, addr)
+		}
+		ips, err := r.resolve(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		err = &net.DNSError{Err: "no addresses", Name: host, IsNotFound: true}
+		for _, ip := range ips {
+			var conn net.Conn
+			if conn, err = dialer.DialContext(ctx, network, net.JoinHostPort(ip, port)); err == nil {
+				return conn, nil
+			}
+		}
+		return nil, err
+	}
+}
@@ -0,0 +1,67 @@
+package paypack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientDialsPinnedIPs(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == authorizePath {
+			fmt.Fprint(w, `{"access":"tok","expires":3600}`)
+			return
+		}
+		fmt.Fprint(w, `{"balance":1000}`)
+	}))
+	defer srv.Close()
+	_, port, err := net.SplitHostPort(srv.Listener.Addr().String())
+	require.NoError(t, err)
+
+	c, err := NewClient("http://paypack.invalid:"+port, "id", "secret", nil, WithPinnedIPs("127.0.0.1"))
+	require.NoError(t, err)
+	_, err = c.Balance(context.Background())
+	require.NoError(t, err)
+}
+
+func TestHostResolverServesStaleAnswersWhenLookupFails(t *testing.T) {
+	now := time.Now()
+	lookups := 0
+	r := &hostResolver{
+		lookup: func(ctx context.Context, host string) ([]string, error) {
+			lookups++
+			if lookups > 1 {
+				return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+			}
+			return []string{"10.0.0.1"}, nil
+		},
+		ttl:   time.Minute,
+		now:   func() time.Time { return now },
+		cache: make(map[string]resolved),
+	}
+	ctx := context.Background()
+
+	addrs, err := r.resolve(ctx, "payments.paypack.rw")
+	require.NoError(t, err)
+	require.Equal(t, []string{"10.0.0.1"}, addrs)
+	_, err = r.resolve(ctx, "payments.paypack.rw")
+	require.NoError(t, err)
+	require.Equal(t, 1, lookups, "answered from the cache")
+
+	now = now.Add(2 * time.Minute)
+	addrs, err = r.resolve(ctx, "payments.paypack.rw")
+	require.NoError(t, err)
+	require.Equal(t, []string{"10.0.0.1"}, addrs, "a failed refresh keeps the last answer")
+	require.Equal(t, 2, lookups)
+
+	_, err = r.resolve(ctx, "other.paypack.rw")
+	var dnsErr *net.DNSError
+	require.True(t, errors.As(err, &dnsErr))
+}
@@ -0,0 +1,29 @@
+// Package paypack is a client for the Paypack mobile-money API (https://paypack.rw): cash-in
+// and cash-out, transaction lookup and listing, merchant balance, payment links and webhook
+// signature checks. It manages bearer tokens itself, refreshing them single-flight across
+// goroutines, and tunes its HTTP transport for short-lived functions.
+//
+//	client, err := paypack.NewClient("", appID, appSecret, nil, paypack.WithMetrics(m))
+//	if err != nil {
+//		return err
+//	}
+//	txn, err := client.CashIn(ctx, "0788000000", 1000)
+//	if err != nil {
+//		return err
+//	}
+//	found, err := client.FindTransaction(ctx, txn.Ref)
+//	if errors.Is(err, paypack.ErrTransactionNotFound) {
+//		// Not settled yet: poll again later.
+//	}
+//
+// Errors returned for non-2xx answers are *APIError, carrying the status code and body.
+// Measurements go to any metrics.Metrics from pkg/metrics under the Metric* names.
+//
+// # Stability
+//
+// The exported API of this package, pkg/paypack/paypacktest, pkg/metrics and pkg/envelope
+// follows the module's semantic version: within a major version, exported identifiers are
+// neither removed nor changed incompatibly, and new fields, options and methods are added in
+// minor releases.
+// Everything under internal/ is free to change.
+package paypack
@@ -0,0 +1,30 @@
+package paypack_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+	"github.com/berniyo/paypack-lambda/pkg/paypack/paypacktest"
+)
+
+func ExampleClient_FindTransaction() {
+	srv := paypacktest.NewServer(paypacktest.Config{})
+	defer srv.Close()
+	client := srv.NewClient()
+	ctx := context.Background()
+
+	txn, err := client.CashIn(ctx, "0788000000", 1000)
+	if err != nil {
+		fmt.Println("cash-in failed:", err)
+		return
+	}
+	found, err := client.FindTransaction(ctx, txn.Ref)
+	if errors.Is(err, paypack.ErrTransactionNotFound) {
+		fmt.Println("not settled yet")
+		return
+	}
+	fmt.Println(found.Status, found.Amount)
+	// Output: success 1000
+}
@@ -0,0 +1,138 @@
+package paypack
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultEndpointCooldown = 30 * time.Second
+
+// MetricFailovers counts requests re-sent to another base URL, tagged with the endpoint that
+// could not be reached.
+const MetricFailovers = "paypack_failovers_total"
+
+// EndpointHealth is a base URL's recent reachability, as reported by Client.Endpoints.
+type EndpointHealth struct {
+	URL       string    `json:"url"`
+	Healthy   bool      `json:"healthy"`
+	Failures  int       `json:"failures,omitempty"`
+	DownUntil time.Time `json:"down_until,omitzero"`
+}
+
+// endpoints tracks the configured base URLs, primary first. A base URL that could not be
+// reached is skipped for the cooldown; once it passes, the next request tries it again.
+type endpoints struct {
+	cooldown time.Duration
+
+	mu   sync.Mutex
+	list []EndpointHealth
+}
+
+func newEndpoints(primary string) *endpoints {
+	return &endpoints{cooldown: defaultEndpointCooldown, list: []EndpointHealth{{URL: primary, Healthy: true}}}
+}
+
+// WithFallbackBaseURLs adds base URLs to fail over to, in order, when the ones before them
+// are unreachable (DNS failures, refused or timed-out connections).
+func WithFallbackBaseURLs(urls ...string) ClientOption {
+	return func(c *Client) {
+		for _, u := range urls {
+			if u = strings.TrimSuffix(strings.TrimSpace(u), "/"); u != "" {
+				c.endpoints.list = append(c.endpoints.list, EndpointHealth{URL: u, Healthy: true})
+			}
+		}
+	}
+}
+
+// WithEndpointCooldown sets how long an unreachable base URL is skipped (default 30s).
+func WithEndpointCooldown(d time.Duration) ClientOption {
+	return func(c *Client) {
+		if d > 0 {
+			c.endpoints.cooldown = d
+		}
+	}
+}
+
+// Endpoints reports the health of each configured base URL, primary first.
+func (c *Client) Endpoints() []EndpointHealth {
+	now := time.Now()
+	c.endpoints.mu.Lock()
+	defer c.endpoints.mu.Unlock()
+	out := slices.Clone(c.endpoints.list)
+	for i := range out {
+		out[i].Healthy = !now.Before(out[i].DownUntil)
+	}
+	return out
+}
+
+// order lists the base URLs to try: reachable ones as configured, then those cooling down,
+// soonest back first, so a request is still attempted when every endpoint is down.
+func (e *endpoints) order(now time.Time) []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var up, down []EndpointHealth
+	for _, ep := range e.list {
+		if now.Before(ep.DownUntil) {
+			down = append(down, ep)
+		} else {
+			up = append(up, ep)
+		}
+	}
+	slices.SortStableFunc(down, func(a, b EndpointHealth) int { return a.DownUntil.Compare(b.DownUntil) })
+	urls := make([]string, 0, len(e.list))
+	for _, ep := range append(up, down...) {
+		urls = append(urls, ep.URL)
+	}
+	return urls
+}
+
+func (e *endpoints) record(url string, now time.Time, reachable bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for i := range e.list {
+		if e.list[i].URL != url {
+			continue
+		}
+		if reachable {
+			e.list[i].Failures = 0
+			e.list[i].DownUntil = time.Time{}
+		} else {
+			e.list[i].Failures++
+			e.list[i].DownUntil = now.Add(e.cooldown)
+		}
+	}
+}
+
+// unreachable reports whether err means the base URL could not be used and the request may
+// be re-sent elsewhere. Requests that never left (DNS and dial failures) always qualify;
+// other transport errors only for reads, since a cash-in may already have reached Paypack.
+func unreachable(ctx context.Context, method string, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	return neverLeft(err) || method == http.MethodGet
+}
+
+// neverLeft reports whether err is a DNS or dial failure, so the request was never sent.
+func neverLeft(err error) bool {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// notSent tags err with ErrNotSent when the request never left.
+func notSent(err error) error {
+	if neverLeft(err) {
+		return taggedError{err, ErrNotSent}
+	}
+	return err
+}
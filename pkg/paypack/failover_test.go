@@ -0,0 +1,109 @@
+package paypack
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func fakePaypack(t *testing.T, hits *atomic.Int32) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		switch r.URL.Path {
+		case authorizePath:
+			fmt.Fprint(w, `{"access":"tok","expires":3600}`)
+		case "/api/transactions/cashin":
+			fmt.Fprint(w, `{"ref":"abc","status":"pending","amount":1000}`)
+		default:
+			fmt.Fprint(w, `{"balance":1000}`)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// deadURL is a base URL nothing listens on, so connections are refused.
+func deadURL(t *testing.T) string {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+	return srv.URL
+}
+
+func TestClientFailsOverToReachableBaseURL(t *testing.T) {
+	var hits atomic.Int32
+	fallback := fakePaypack(t, &hits)
+	primary := deadURL(t)
+	c, err := NewClient(primary, "id", "secret", nil, WithFallbackBaseURLs(fallback.URL+"/"), WithEndpointCooldown(50*time.Millisecond))
+	require.NoError(t, err)
+
+	txn, err := c.CashIn(context.Background(), "0788000001", 1000)
+	require.NoError(t, err)
+	require.Equal(t, "abc", txn.Ref)
+	require.Equal(t, int32(2), hits.Load(), "authorize and cash-in both reached the fallback")
+
+	health := c.Endpoints()
+	require.Len(t, health, 2)
+	require.Equal(t, primary, health[0].URL)
+	require.False(t, health[0].Healthy)
+	require.Equal(t, 1, health[0].Failures)
+	require.True(t, health[1].Healthy)
+	require.Equal(t, []string{fallback.URL, primary}, c.endpoints.order(time.Now()), "the primary is skipped while cooling down")
+
+	time.Sleep(60 * time.Millisecond)
+	require.Equal(t, []string{primary, fallback.URL}, c.endpoints.order(time.Now()), "and tried first again afterwards")
+}
+
+func TestClientDoesNotResendCashInAfterAmbiguousFailure(t *testing.T) {
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == authorizePath {
+			fmt.Fprint(w, `{"access":"tok","expires":3600}`)
+			return
+		}
+		// The request arrived, but the connection drops before an answer.
+		conn, _, err := w.(http.Hijacker).Hijack()
+		require.NoError(t, err)
+		conn.Close()
+	}))
+	defer primary.Close()
+	var hits atomic.Int32
+	fallback := fakePaypack(t, &hits)
+	c, err := NewClient(primary.URL, "id", "secret", nil, WithFallbackBaseURLs(fallback.URL))
+	require.NoError(t, err)
+
+	_, err = c.CashIn(context.Background(), "0788000001", 1000)
+	require.Error(t, err)
+	require.NotErrorIs(t, err, ErrNotSent)
+	require.Zero(t, hits.Load(), "the cash-in may have been accepted, so it is not re-sent")
+
+	_, err = c.Balance(context.Background())
+	require.NoError(t, err, "reads fail over on any transport error")
+	require.Equal(t, int32(1), hits.Load())
+}
+
+func TestClientTellsUnsentCashInsFromUnreadableOnes(t *testing.T) {
+	c, err := NewClient(deadURL(t), "id", "secret", nil)
+	require.NoError(t, err)
+	_, err = c.CashIn(context.Background(), "0788000001", 1000)
+	require.ErrorIs(t, err, ErrNotSent, "a refused connection never reached Paypack")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == authorizePath {
+			fmt.Fprint(w, `{"access":"tok","expires":3600}`)
+			return
+		}
+		fmt.Fprint(w, `{"status":"pending"}`)
+	}))
+	defer srv.Close()
+	c, err = NewClient(srv.URL, "id", "secret", nil)
+	require.NoError(t, err)
+	_, err = c.CashIn(context.Background(), "0788000001", 1000)
+	require.ErrorIs(t, err, ErrUnexpectedResponse)
+	require.NotErrorIs(t, err, ErrNotSent)
+	require.EqualError(t, err, "cashin response missing reference")
+}
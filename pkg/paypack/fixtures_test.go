@@ -0,0 +1,69 @@
+package paypack_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/berniyo/paypack-lambda/internal/testdata"
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+// fixtureClient is a client whose find endpoint answers with the named payload and status.
+func fixtureClient(t *testing.T, status int, payload string) *paypack.Client {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/auth/agents/authorize", func(w http.ResponseWriter, r *http.Request) {
+		testdata.Respond(t, w, http.StatusOK, testdata.Auth)
+	})
+	mux.HandleFunc("POST /api/transactions/cashin", func(w http.ResponseWriter, r *http.Request) {
+		testdata.Respond(t, w, http.StatusOK, testdata.CashInPending)
+	})
+	mux.HandleFunc("GET /api/transactions/find/{ref}", func(w http.ResponseWriter, r *http.Request) {
+		testdata.Respond(t, w, status, payload)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	client, err := paypack.NewClient(srv.URL, "app-id", "app-secret", srv.Client())
+	require.NoError(t, err)
+	return client
+}
+
+func TestCashInDecodesPendingPayload(t *testing.T) {
+	txn, err := fixtureClient(t, http.StatusOK, testdata.TransactionPending).CashIn(context.Background(), testdata.Number, testdata.Amount)
+	require.NoError(t, err)
+	require.Equal(t, testdata.Ref, txn.Ref)
+	require.Equal(t, "pending", txn.Status)
+	require.Equal(t, "CASHIN", txn.Kind)
+}
+
+func TestFindTransactionDecodesFixtures(t *testing.T) {
+	ctx := context.Background()
+
+	for _, name := range []string{testdata.TransactionPending, testdata.TransactionSuccess, testdata.TransactionFailed} {
+		txn, err := fixtureClient(t, http.StatusOK, name).FindTransaction(ctx, testdata.Ref)
+		require.NoError(t, err, name)
+		require.Equal(t, testdata.Transaction(t, name), *txn, name)
+	}
+
+	txn, err := fixtureClient(t, http.StatusOK, testdata.TransactionSuccess).FindTransaction(ctx, testdata.Ref)
+	require.NoError(t, err)
+	require.Equal(t, testdata.Fee, txn.Fee)
+	require.Equal(t, "gold", txn.Metadata["plan"])
+}
+
+func TestFindTransactionReportsMissesAndMalformedBodies(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := fixtureClient(t, http.StatusNotFound, testdata.NotFound).FindTransaction(ctx, testdata.Ref)
+	require.ErrorIs(t, err, paypack.ErrTransactionNotFound)
+
+	_, err = fixtureClient(t, http.StatusOK, testdata.NotFound).FindTransaction(ctx, testdata.Ref)
+	require.ErrorIs(t, err, paypack.ErrTransactionNotFound, "a 200 with the miss body is still a miss")
+
+	_, err = fixtureClient(t, http.StatusOK, testdata.Malformed).FindTransaction(ctx, testdata.Ref)
+	require.ErrorContains(t, err, "unexpected transaction payload")
+}
@@ -0,0 +1,126 @@
+package paypack
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const defaultListPageSize = 100
+
+// ListOptions filters Transactions. Zero values leave a filter unset.
+type ListOptions struct {
+	From     time.Time
+	To       time.Time
+	Kind     string
+	PageSize int
+}
+
+// Transactions streams every transaction matching opts to fn, oldest page first. Pages are
+// fetched one at a time and decoded incrementally, so memory is bounded by a single
+// transaction rather than by the size of the history. An error from fn stops the listing and
+// is returned as is.
+func (c *Client) Transactions(ctx context.Context, opts ListOptions, fn func(Transaction) error) error {
+	limit := opts.PageSize
+	if limit <= 0 {
+		limit = defaultListPageSize
+	}
+
+	for offset := 0; ; {
+		token, err := c.ensureAccessToken(ctx)
+		if err != nil {
+			return err
+		}
+
+		query := url.Values{"offset": {strconv.Itoa(offset)}, "limit": {strconv.Itoa(limit)}}
+		if !opts.From.IsZero() {
+			query.Set("from", opts.From.UTC().Format(time.RFC3339))
+		}
+		if !opts.To.IsZero() {
+			query.Set("to", opts.To.UTC().Format(time.RFC3339))
+		}
+		if opts.Kind != "" {
+			query.Set("kind", opts.Kind)
+		}
+
+		count, total, err := c.listPage(ctx, "/api/transactions/list?"+query.Encode(), token, fn)
+		if err != nil {
+			return err
+		}
+		offset += count
+		if count < limit || (total > 0 && offset >= total) {
+			return nil
+		}
+	}
+}
+
+// listPage decodes one {"total": n, "transactions": [...]} page straight off the wire.
+func (c *Client) listPage(ctx context.Context, path, token string, fn func(Transaction) error) (int, int, error) {
+	resp, _, err := c.send(ctx, http.MethodGet, path, token, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		return 0, 0, &APIError{StatusCode: resp.StatusCode, Body: string(data)}
+	}
+
+	dec := json.NewDecoder(resp.Body)
+	if err := expectDelim(dec, '{'); err != nil {
+		return 0, 0, err
+	}
+	var count, total int
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return count, total, fmt.Errorf("decode transaction list: %w", err)
+		}
+		switch tok {
+		case "total":
+			if err := dec.Decode(&total); err != nil {
+				return count, total, fmt.Errorf("decode transaction list total: %w", err)
+			}
+		case "transactions":
+			if err := expectDelim(dec, '['); err != nil {
+				return count, total, err
+			}
+			for dec.More() {
+				var txn Transaction
+				if err := dec.Decode(&txn); err != nil {
+					return count, total, fmt.Errorf("decode listed transaction: %w", err)
+				}
+				count++
+				if err := fn(txn); err != nil {
+					return count, total, err
+				}
+			}
+			if err := expectDelim(dec, ']'); err != nil {
+				return count, total, err
+			}
+		default:
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return count, total, fmt.Errorf("decode transaction list: %w", err)
+			}
+		}
+	}
+	return count, total, nil
+}
+
+func expectDelim(dec *json.Decoder, want json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return fmt.Errorf("decode transaction list: %w", err)
+	}
+	if got, ok := tok.(json.Delim); !ok || got != want {
+		return errors.New("decode transaction list: unexpected " + fmt.Sprint(tok))
+	}
+	return nil
+}
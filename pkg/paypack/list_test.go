@@ -0,0 +1,73 @@
+package paypack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestClient(t *testing.T, h http.Handler) *Client {
+	t.Helper()
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	t.Setenv("PAYPACK_APP_ID", "id")
+	t.Setenv("PAYPACK_APP_SECRET", "secret")
+	t.Setenv("PAYPACK_BASE_URL", srv.URL)
+	c, err := NewClientFromEnv(srv.Client())
+	require.NoError(t, err)
+	return c
+}
+
+func listServer(total int) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/auth/agents/authorize", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"access":"tok","expires":3600}`)
+	})
+	mux.HandleFunc("GET /api/transactions/list", func(w http.ResponseWriter, r *http.Request) {
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		fmt.Fprintf(w, `{"offset":%d,"meta":{"x":[1,2]},"total":%d,"transactions":[`, offset, total)
+		for i := offset; i < min(offset+limit, total); i++ {
+			if i > offset {
+				fmt.Fprint(w, ",")
+			}
+			fmt.Fprintf(w, `{"ref":"t%d","amount":%d,"kind":"CASHIN"}`, i, 100+i)
+		}
+		fmt.Fprint(w, `]}`)
+	})
+	return mux
+}
+
+func TestTransactionsStreamsEveryPage(t *testing.T) {
+	c := newTestClient(t, listServer(7))
+
+	var refs []string
+	err := c.Transactions(context.Background(), ListOptions{PageSize: 3}, func(txn Transaction) error {
+		refs = append(refs, txn.Ref)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"t0", "t1", "t2", "t3", "t4", "t5", "t6"}, refs)
+}
+
+func TestTransactionsStopsOnCallbackError(t *testing.T) {
+	c := newTestClient(t, listServer(10))
+	stop := errors.New("stop")
+
+	seen := 0
+	err := c.Transactions(context.Background(), ListOptions{PageSize: 4}, func(Transaction) error {
+		seen++
+		if seen == 5 {
+			return stop
+		}
+		return nil
+	})
+	require.ErrorIs(t, err, stop)
+	require.Equal(t, 5, seen)
+}
@@ -0,0 +1,14 @@
+package paypack
+
+// Metric names recorded through WithMetrics. Every series is tagged with the API endpoint.
+const (
+	MetricRequests = "paypack_requests_total"
+	MetricLatency  = "paypack_request_duration_seconds"
+
+	// Connection phases split request latency into setup (new connections only) and the wait
+	// for Paypack's first response byte.
+	MetricConnections  = "paypack_connections_total"
+	MetricConnect      = "paypack_connect_seconds"
+	MetricTLSHandshake = "paypack_tls_handshake_seconds"
+	MetricWait         = "paypack_wait_seconds"
+)
@@ -0,0 +1,87 @@
+package paypack
+
+import (
+	"strings"
+	"time"
+)
+
+// AuthResponse captures the payload returned by the Paypack authorization endpoint.
+type AuthResponse struct {
+	Access  string `json:"access"`
+	Refresh string `json:"refresh"`
+	Expires int    `json:"expires"`
+}
+
+// Transaction represents a payment transaction returned by Paypack.
+type Transaction struct {
+	Ref       string         `json:"ref"`
+	Status    string         `json:"status,omitempty"`
+	Amount    float64        `json:"amount"`
+	Fee       float64        `json:"fee,omitempty"`
+	Currency  string         `json:"currency,omitempty"`
+	Kind      string         `json:"kind"`
+	Provider  string         `json:"provider"`
+	Client    string         `json:"client,omitempty"`
+	Number    string         `json:"number,omitempty"`
+	Metadata  map[string]any `json:"metadata,omitempty"`
+	Merchant  string         `json:"merchant,omitempty"`
+	Timestamp time.Time      `json:"timestamp"`
+	CreatedAt time.Time      `json:"created_at,omitempty"`
+}
+
+// PayerNumber is the mobile-money number the transaction moved money to or from. Paypack
+// reports it as Client unless the cash-in was tagged with WithClient, so Client is only used
+// when Number is unset and it is number-shaped; otherwise PayerNumber is empty.
+func (t Transaction) PayerNumber() string {
+	if t.Number != "" {
+		return t.Number
+	}
+	if numberShaped(t.Client) {
+		return t.Client
+	}
+	return ""
+}
+
+// numberShaped reports whether s is a phone number: digits with an optional leading +.
+func numberShaped(s string) bool {
+	s = strings.TrimPrefix(s, "+")
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Balance is the merchant wallet balance reported by Paypack.
+type Balance struct {
+	Amount   float64 `json:"balance"`
+	Currency string  `json:"currency,omitempty"`
+}
+
+// PaymentLink is a hosted checkout page created by CreatePaymentLink.
+type PaymentLink struct {
+	ID        string    `json:"id"`
+	URL       string    `json:"url"`
+	Amount    float64   `json:"amount"`
+	Currency  string    `json:"currency,omitempty"`
+	Status    string    `json:"status,omitempty"`
+	ExpiresAt time.Time `json:"expires_at,omitzero"`
+	CreatedAt time.Time `json:"created_at,omitzero"`
+}
+
+// TransactionNotFound models the error payload delivered when a transaction cannot be located.
+type TransactionNotFound struct {
+	Message string `json:"message"`
+}
+
+// WebhookEvent is the envelope Paypack posts to registered webhook endpoints.
+type WebhookEvent struct {
+	EventID   string      `json:"event_id"`
+	EventKind string      `json:"event_kind"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      Transaction `json:"data"`
+}
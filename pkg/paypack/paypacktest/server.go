@@ -0,0 +1,285 @@
+// Package paypacktest provides an in-memory fake of the Paypack API for tests, benchmarks and
+// load tests, with configurable latency, error injection and confirmation delay.
+package paypacktest
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+// Credentials accepted by the fake's authorize endpoint.
+const (
+	AppID     = "paypacktest-app"
+	AppSecret = "paypacktest-secret"
+)
+
+// Config shapes the fake's behaviour. The zero value answers instantly, never errors and
+// confirms every cash-in as successful on the first lookup.
+type Config struct {
+	// Latency is added to every response, plus a uniformly random extra of up to Jitter.
+	Latency time.Duration
+	Jitter  time.Duration
+	// ErrorRate is the fraction of API calls (authorization excluded) answered with a 503.
+	ErrorRate float64
+	// ConfirmAfter is how long a cash-in stays unknown to FindTransaction.
+	ConfirmAfter time.Duration
+	// FailRate is the fraction of confirmed cash-ins whose final status is failed.
+	FailRate float64
+	// FeePercent is the fee the quote endpoint prices amounts at.
+	FeePercent float64
+	// Seed makes the random draws reproducible; zero picks a random seed.
+	Seed uint64
+}
+
+// Server is a running fake. Embedding httptest.Server gives it URL and Close.
+type Server struct {
+	*httptest.Server
+	cfg Config
+
+	randMu sync.Mutex
+	rand   *rand.Rand
+
+	mu    sync.Mutex
+	seq   int
+	txns  map[string]*entry
+	order []string
+
+	requests atomic.Int64
+	injected atomic.Int64
+}
+
+type entry struct {
+	txn       paypack.Transaction
+	visibleAt time.Time
+	final     string
+}
+
+// NewServer starts a fake configured by cfg. Callers must Close it.
+func NewServer(cfg Config) *Server {
+	seed := cfg.Seed
+	if seed == 0 {
+		seed = rand.Uint64()
+	}
+	s := &Server{cfg: cfg, rand: rand.New(rand.NewPCG(seed, seed)), txns: make(map[string]*entry)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/auth/agents/authorize", s.authorize)
+	mux.HandleFunc("POST /api/transactions/cashin", s.api(s.cashIn))
+	mux.HandleFunc("POST /api/transactions/cashout", s.api(s.cashOut))
+	mux.HandleFunc("GET /api/transactions/find/{ref}", s.api(s.find))
+	mux.HandleFunc("GET /api/transactions/list", s.api(s.list))
+	mux.HandleFunc("GET /api/merchants/balance", s.api(s.balance))
+	mux.HandleFunc("GET /api/transactions/fee", s.api(s.fee))
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// NewClient returns a Paypack client pointed at the fake.
+func (s *Server) NewClient(opts ...paypack.ClientOption) *paypack.Client {
+	c, err := paypack.NewClient(s.URL, AppID, AppSecret, nil, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// Requests reports how many API calls were served, authorization included.
+func (s *Server) Requests() int64 { return s.requests.Load() }
+
+// InjectedErrors reports how many calls were answered with an injected 503.
+func (s *Server) InjectedErrors() int64 { return s.injected.Load() }
+
+func (s *Server) float() float64 {
+	s.randMu.Lock()
+	defer s.randMu.Unlock()
+	return s.rand.Float64()
+}
+
+func (s *Server) delay() {
+	d := s.cfg.Latency
+	if s.cfg.Jitter > 0 {
+		d += time.Duration(s.float() * float64(s.cfg.Jitter))
+	}
+	if d > 0 {
+		time.Sleep(d)
+	}
+}
+
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request) {
+	s.requests.Add(1)
+	s.delay()
+	var body struct {
+		ClientID     string `json:"client_id"`
+		ClientSecret string `json:"client_secret"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.ClientID != AppID || body.ClientSecret != AppSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"message": "invalid credentials"})
+		return
+	}
+	writeJSON(w, http.StatusOK, paypack.AuthResponse{Access: "access-token", Refresh: "refresh-token", Expires: 3600})
+}
+
+// api wraps authenticated endpoints with latency, error injection and a bearer check.
+func (s *Server) api(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.requests.Add(1)
+		s.delay()
+		if r.Header.Get("Authorization") != "Bearer access-token" {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"message": "unauthorized"})
+			return
+		}
+		if s.cfg.ErrorRate > 0 && s.float() < s.cfg.ErrorRate {
+			s.injected.Add(1)
+			writeJSON(w, http.StatusServiceUnavailable, map[string]string{"message": "service unavailable"})
+			return
+		}
+		h(w, r)
+	}
+}
+
+type moneyRequest struct {
+	Amount   float64 `json:"amount"`
+	Number   string  `json:"number"`
+	Currency string  `json:"currency"`
+	Client   string  `json:"client"`
+}
+
+func (s *Server) record(kind string, req moneyRequest, confirmAfter time.Duration, final string) paypack.Transaction {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seq++
+	now := time.Now().UTC()
+	currency := req.Currency
+	if currency == "" {
+		currency = paypack.DefaultCurrency
+	}
+	client := req.Client
+	if client == "" {
+		client = req.Number
+	}
+	txn := paypack.Transaction{
+		Ref:       fmt.Sprintf("ptt-%08d", s.seq),
+		Status:    "pending",
+		Amount:    req.Amount,
+		Currency:  currency,
+		Kind:      kind,
+		Provider:  "mtn",
+		Client:    client,
+		Number:    req.Number,
+		Timestamp: now,
+		CreatedAt: now,
+	}
+	s.txns[txn.Ref] = &entry{txn: txn, visibleAt: now.Add(confirmAfter), final: final}
+	s.order = append(s.order, txn.Ref)
+	return txn
+}
+
+func (s *Server) cashIn(w http.ResponseWriter, r *http.Request) {
+	var req moneyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Amount <= 0 || req.Number == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"message": "invalid cashin request"})
+		return
+	}
+	final := "success"
+	if s.cfg.FailRate > 0 && s.float() < s.cfg.FailRate {
+		final = "failed"
+	}
+	writeJSON(w, http.StatusOK, s.record("CASHIN", req, s.cfg.ConfirmAfter, final))
+}
+
+func (s *Server) cashOut(w http.ResponseWriter, r *http.Request) {
+	var req moneyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Amount <= 0 || req.Number == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"message": "invalid cashout request"})
+		return
+	}
+	txn := s.record("CASHOUT", req, 0, "success")
+	txn.Status = "success"
+	writeJSON(w, http.StatusOK, txn)
+}
+
+func (s *Server) find(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	e, ok := s.txns[r.PathValue("ref")]
+	var txn paypack.Transaction
+	if ok && !time.Now().Before(e.visibleAt) {
+		e.txn.Status = e.final
+		txn = e.txn
+	}
+	s.mu.Unlock()
+
+	if txn.Ref == "" {
+		writeJSON(w, http.StatusNotFound, paypack.TransactionNotFound{Message: "transaction not found"})
+		return
+	}
+	writeJSON(w, http.StatusOK, txn)
+}
+
+func (s *Server) list(w http.ResponseWriter, r *http.Request) {
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit <= 0 {
+		limit = 100
+	}
+
+	s.mu.Lock()
+	total := len(s.order)
+	page := []paypack.Transaction{}
+	for i := offset; i < total && i < offset+limit; i++ {
+		e := s.txns[s.order[i]]
+		if !time.Now().Before(e.visibleAt) {
+			e.txn.Status = e.final
+		}
+		page = append(page, e.txn)
+	}
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]any{"offset": offset, "limit": limit, "total": total, "transactions": page})
+}
+
+func (s *Server) balance(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	var bal float64
+	for _, e := range s.txns {
+		switch {
+		case e.txn.Kind == "CASHIN" && e.final == "success" && !time.Now().Before(e.visibleAt):
+			bal += e.txn.Amount
+		case e.txn.Kind == "CASHOUT":
+			bal -= e.txn.Amount
+		}
+	}
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, paypack.Balance{Amount: bal, Currency: paypack.DefaultCurrency})
+}
+
+func (s *Server) fee(w http.ResponseWriter, r *http.Request) {
+	amount, err := strconv.ParseFloat(r.URL.Query().Get("amount"), 64)
+	if err != nil || amount <= 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"message": "invalid amount"})
+		return
+	}
+	fee := math.Round(amount*s.cfg.FeePercent) / 100
+	writeJSON(w, http.StatusOK, paypack.Quote{
+		Amount:   amount,
+		Fee:      fee,
+		Total:    amount + fee,
+		Currency: paypack.DefaultCurrency,
+		Provider: r.URL.Query().Get("provider"),
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,117 @@
+package paypacktest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/berniyo/paypack-lambda/pkg/paypack"
+)
+
+func TestServerConfirmsCashInAfterDelay(t *testing.T) {
+	srv := NewServer(Config{ConfirmAfter: 50 * time.Millisecond})
+	defer srv.Close()
+	client := srv.NewClient()
+	ctx := context.Background()
+
+	txn, err := client.CashIn(ctx, "0788000000", 1000)
+	require.NoError(t, err)
+	require.Equal(t, "pending", txn.Status)
+
+	_, err = client.FindTransaction(ctx, txn.Ref)
+	require.ErrorIs(t, err, paypack.ErrTransactionNotFound)
+
+	time.Sleep(60 * time.Millisecond)
+	found, err := client.FindTransaction(ctx, txn.Ref)
+	require.NoError(t, err)
+	require.Equal(t, "success", found.Status)
+
+	bal, err := client.Balance(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1000.0, bal.Amount)
+}
+
+func TestServerInjectsFailuresAndErrors(t *testing.T) {
+	srv := NewServer(Config{FailRate: 1, Seed: 1})
+	defer srv.Close()
+	client := srv.NewClient()
+	ctx := context.Background()
+
+	txn, err := client.CashIn(ctx, "0788000000", 500)
+	require.NoError(t, err)
+	found, err := client.FindTransaction(ctx, txn.Ref)
+	require.NoError(t, err)
+	require.Equal(t, "failed", found.Status)
+
+	down := NewServer(Config{ErrorRate: 1, Seed: 1})
+	defer down.Close()
+	_, err = down.NewClient().CashIn(ctx, "0788000000", 500)
+	var apiErr *paypack.APIError
+	require.True(t, errors.As(err, &apiErr))
+	require.Equal(t, 503, apiErr.StatusCode)
+	require.EqualValues(t, 1, down.InjectedErrors())
+}
+
+func TestServerKeepsCashInClient(t *testing.T) {
+	srv := NewServer(Config{})
+	defer srv.Close()
+	client := srv.NewClient()
+	ctx := context.Background()
+
+	txn, err := client.CashIn(ctx, "0788000000", 1000, paypack.WithClient("acme"))
+	require.NoError(t, err)
+	require.Equal(t, "acme", txn.Client)
+	found, err := client.FindTransaction(ctx, txn.Ref)
+	require.NoError(t, err)
+	require.Equal(t, "acme", found.Client)
+}
+
+func TestServerQuotesFees(t *testing.T) {
+	srv := NewServer(Config{FeePercent: 2.3})
+	defer srv.Close()
+
+	quote, err := srv.NewClient().Quote(context.Background(), 10000, "MTN")
+	require.NoError(t, err)
+	require.Equal(t, 230.0, quote.Fee)
+	require.Equal(t, 10230.0, quote.Total)
+	require.Equal(t, "mtn", quote.Provider)
+	require.Equal(t, paypack.DefaultCurrency, quote.Currency)
+
+	_, err = srv.NewClient().Quote(context.Background(), 0, "")
+	require.Error(t, err)
+}
+
+func TestFindTransactionsListsBeforeLookingUp(t *testing.T) {
+	srv := NewServer(Config{})
+	defer srv.Close()
+	client := srv.NewClient()
+	ctx := context.Background()
+
+	var refs []string
+	for range 3 {
+		txn, err := client.CashIn(ctx, "0788000000", 100)
+		require.NoError(t, err)
+		refs = append(refs, txn.Ref)
+	}
+	refs = append(refs, "unknown", refs[0])
+
+	before := srv.Requests()
+	results := client.FindTransactions(ctx, refs, paypack.BulkFindOptions{Since: time.Now().Add(-time.Minute)})
+	require.Len(t, results, 5)
+	for i, res := range results[:3] {
+		require.NoError(t, res.Err)
+		require.Equal(t, refs[i], res.Transaction.Ref)
+		require.Equal(t, "success", res.Transaction.Status)
+	}
+	require.ErrorIs(t, results[3].Err, paypack.ErrTransactionNotFound)
+	require.Equal(t, refs[0], results[4].Transaction.Ref)
+	require.Equal(t, int64(2), srv.Requests()-before, "one list page and one lookup for the unlisted ref")
+
+	before = srv.Requests()
+	results = client.FindTransactions(ctx, refs, paypack.BulkFindOptions{Concurrency: 2})
+	require.Equal(t, "success", results[1].Transaction.Status)
+	require.Equal(t, int64(4), srv.Requests()-before, "one lookup per distinct ref")
+}
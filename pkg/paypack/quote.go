@@ -0,0 +1,64 @@
+package paypack
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Quote is the fee Paypack expects to charge for an amount through a provider, before
+// anything is charged. Total is the amount including the fee.
+type Quote struct {
+	Amount   float64 `json:"amount"`
+	Fee      float64 `json:"fee"`
+	Total    float64 `json:"total"`
+	Currency string  `json:"currency,omitempty"`
+	Provider string  `json:"provider,omitempty"`
+}
+
+// Quote previews the fee and total of charging amount through provider (mtn, airtel); an
+// empty provider quotes Paypack's default rate.
+func (c *Client) Quote(ctx context.Context, amount float64, provider string) (*Quote, error) {
+	if amount <= 0 {
+		return nil, errors.New("amount must be positive")
+	}
+	provider = strings.ToLower(strings.TrimSpace(provider))
+
+	token, err := c.ensureAccessToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := url.Values{"amount": {strconv.FormatFloat(amount, 'f', -1, 64)}}
+	if provider != "" {
+		query.Set("provider", provider)
+	}
+	_, body, err := c.doRequest(ctx, http.MethodGet, "/api/transactions/fee?"+query.Encode(), token, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var quote Quote
+	if err := json.Unmarshal(body, &quote); err != nil {
+		return nil, fmt.Errorf("decode quote response: %w", err)
+	}
+	if quote.Amount == 0 {
+		quote.Amount = amount
+	}
+	if quote.Total == 0 {
+		quote.Total = math.Round((quote.Amount+quote.Fee)*100) / 100
+	}
+	if quote.Currency == "" {
+		quote.Currency = DefaultCurrency
+	}
+	if quote.Provider == "" {
+		quote.Provider = provider
+	}
+	return &quote, nil
+}
@@ -0,0 +1,114 @@
+package paypack
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// TransportConfig tunes the HTTP transport built when NewClientFromEnv is not handed its own
+// http.Client. Every connection goes to a single Paypack host, so the idle pool is per host.
+type TransportConfig struct {
+	MaxIdleConns        int
+	IdleConnTimeout     time.Duration
+	TLSHandshakeTimeout time.Duration
+	ForceHTTP2          bool
+
+	// TLS, when set, replaces Go's client TLS defaults (e.g. to trust a proxy's CA or raise
+	// the minimum version). Proxy picks the proxy per request; the default reads HTTP_PROXY,
+	// HTTPS_PROXY and NO_PROXY.
+	TLS   *tls.Config
+	Proxy func(*http.Request) (*url.URL, error)
+
+	// Resolver replaces the system resolver, DNSCacheTTL caches its answers, and PinnedHosts
+	// maps host names to the IPs dialled for them without any lookup.
+	Resolver    *net.Resolver
+	DNSCacheTTL time.Duration
+	PinnedHosts map[string][]string
+}
+
+// DefaultTransportConfig keeps enough warm connections for batch concurrency and outlives the
+// gap between polls, so a polling loop reuses one TLS session instead of reconnecting.
+func DefaultTransportConfig() TransportConfig {
+	return TransportConfig{
+		MaxIdleConns:        32,
+		IdleConnTimeout:     90 * time.Second,
+		TLSHandshakeTimeout: 5 * time.Second,
+		ForceHTTP2:          true,
+		Proxy:               http.ProxyFromEnvironment,
+	}
+}
+
+// WithMaxIdleConns caps the idle keep-alive connections kept to Paypack.
+func WithMaxIdleConns(n int) ClientOption {
+	return func(c *Client) {
+		if n > 0 {
+			c.transport.MaxIdleConns = n
+		}
+	}
+}
+
+// WithIdleConnTimeout sets how long an idle connection is kept before it is closed.
+func WithIdleConnTimeout(d time.Duration) ClientOption {
+	return func(c *Client) {
+		if d > 0 {
+			c.transport.IdleConnTimeout = d
+		}
+	}
+}
+
+// WithTLSHandshakeTimeout bounds the TLS handshake of new connections.
+func WithTLSHandshakeTimeout(d time.Duration) ClientOption {
+	return func(c *Client) {
+		if d > 0 {
+			c.transport.TLSHandshakeTimeout = d
+		}
+	}
+}
+
+// WithForceHTTP2 controls whether HTTP/2 is attempted, multiplexing concurrent requests over
+// one connection.
+func WithForceHTTP2(on bool) ClientOption {
+	return func(c *Client) {
+		c.transport.ForceHTTP2 = on
+	}
+}
+
+// WithTLSConfig dials Paypack with cfg, e.g. to add the CA of an inspection proxy or
+// require TLS 1.3.
+func WithTLSConfig(cfg *tls.Config) ClientOption {
+	return func(c *Client) {
+		c.transport.TLS = cfg
+	}
+}
+
+// WithProxy routes requests through the proxy proxy returns (nil for a direct connection),
+// instead of the proxy named by the environment.
+func WithProxy(proxy func(*http.Request) (*url.URL, error)) ClientOption {
+	return func(c *Client) {
+		if proxy != nil {
+			c.transport.Proxy = proxy
+		}
+	}
+}
+
+func newTransport(cfg TransportConfig) *http.Transport {
+	dialer := &net.Dialer{Timeout: 10 * time.Second, KeepAlive: 30 * time.Second}
+	dial := dialer.DialContext
+	if cfg.Resolver != nil || cfg.DNSCacheTTL > 0 || len(cfg.PinnedHosts) > 0 {
+		dial = newHostResolver(cfg).dialContext(dialer)
+	}
+	return &http.Transport{
+		Proxy:                 cfg.Proxy,
+		TLSClientConfig:       cfg.TLS.Clone(),
+		DialContext:           dial,
+		MaxIdleConns:          cfg.MaxIdleConns,
+		MaxIdleConnsPerHost:   cfg.MaxIdleConns,
+		IdleConnTimeout:       cfg.IdleConnTimeout,
+		TLSHandshakeTimeout:   cfg.TLSHandshakeTimeout,
+		ExpectContinueTimeout: time.Second,
+		ForceAttemptHTTP2:     cfg.ForceHTTP2,
+	}
+}
@@ -0,0 +1,58 @@
+package paypack
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientTrustsConfiguredRoots(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == authorizePath {
+			fmt.Fprint(w, `{"access":"tok","expires":3600}`)
+			return
+		}
+		fmt.Fprint(w, `{"balance":1000}`)
+	}))
+	defer srv.Close()
+
+	untrusted, err := NewClient(srv.URL, "id", "secret", nil)
+	require.NoError(t, err)
+	_, err = untrusted.Balance(context.Background())
+	require.Error(t, err)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(srv.Certificate())
+	c, err := NewClient(srv.URL, "id", "secret", nil, WithTLSConfig(&tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12}))
+	require.NoError(t, err)
+	_, err = c.Balance(context.Background())
+	require.NoError(t, err)
+}
+
+func TestClientRoutesThroughProxy(t *testing.T) {
+	var hosts []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hosts = append(hosts, r.URL.Host)
+		if r.URL.Path == authorizePath {
+			fmt.Fprint(w, `{"access":"tok","expires":3600}`)
+			return
+		}
+		fmt.Fprint(w, `{"balance":1000}`)
+	}))
+	defer proxy.Close()
+	proxyURL, err := url.Parse(proxy.URL)
+	require.NoError(t, err)
+
+	c, err := NewClient("http://paypack.internal", "id", "secret", nil, WithProxy(http.ProxyURL(proxyURL)))
+	require.NoError(t, err)
+	_, err = c.Balance(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []string{"paypack.internal", "paypack.internal"}, hosts)
+}
@@ -0,0 +1,18 @@
+package paypack
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+)
+
+// WebhookSignatureHeader carries the HMAC Paypack computes over each webhook body.
+const WebhookSignatureHeader = "X-Paypack-Signature"
+
+// VerifyWebhookSignature reports whether signature is the base64 HMAC-SHA256 of body under secret.
+func VerifyWebhookSignature(body []byte, signature, secret string) bool {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	expected := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(expected), []byte(signature))
+}